@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// builtinAliases are the short names every installation has. Aliases in
+// axle_config.json with the same name replace them.
+var builtinAliases = map[string]string{
+	"s":  "start",
+	"t":  "team",
+	"c":  "chat",
+	"st": "status",
+	"sn": "sync-now",
+	"h":  "history",
+}
+
+// aliasAnnotation marks the subcommands that expand aliases.
+const aliasAnnotation = "axle.alias"
+
+// commandAlias is an alias and whether it could be registered.
+type commandAlias struct {
+	Name      string
+	Expansion string
+	Builtin   bool
+	Problem   string // Why it isn't registered, "" when it is
+}
+
+// aliasesCmd lists the built-in and configured aliases
+var aliasesCmd = &cobra.Command{
+	Use:   "aliases",
+	Short: "List command aliases",
+	Long: utils.RenderTitle("⌨️  Aliases") + `
+
+Aliases are short names for commands, optionally with arguments. A few are
+built in (s for start, t for team, c for chat, ...); define your own in
+axle_config.json:
+
+  "aliases": {"s": "start", "p": "chat -p", "sync": "sync-now"}
+
+Arguments after an alias are added to its expansion, so 'axle p hi' runs
+'axle chat -p hi'. An alias can't replace a command or point at another
+alias.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		aliases := resolveAliases()
+		if len(aliases) == 0 {
+			fmt.Println(utils.RenderInfo("No aliases defined"))
+			return
+		}
+
+		fmt.Println(utils.RenderTitle("⌨️  Aliases"))
+		for _, alias := range aliases {
+			source := "config"
+			if alias.Builtin {
+				source = "built-in"
+			}
+			status := ""
+			if alias.Problem != "" {
+				status = " " + utils.RenderWarning(alias.Problem)
+			}
+			fmt.Printf("  %-10s %-24s %-9s%s\n", alias.Name, alias.Expansion, source, status)
+		}
+	},
+}
+
+// registerAliases adds a subcommand for every usable alias. It runs after the
+// built-in commands and plugins are registered, so those always win.
+func registerAliases() {
+	for _, alias := range resolveAliases() {
+		if alias.Problem == "" {
+			rootCmd.AddCommand(newAliasCommand(alias))
+		}
+	}
+}
+
+// resolveAliases merges the configured aliases over the built-in ones and
+// checks each against the registered commands, sorted by name.
+func resolveAliases() []commandAlias {
+	merged := make(map[string]commandAlias)
+	for name, expansion := range builtinAliases {
+		merged[name] = commandAlias{Name: name, Expansion: expansion, Builtin: true}
+	}
+	for name, expansion := range configuredAliases() {
+		merged[name] = commandAlias{Name: name, Expansion: strings.TrimSpace(expansion)}
+	}
+
+	aliases := make([]commandAlias, 0, len(merged))
+	for _, alias := range merged {
+		fields := strings.Fields(alias.Expansion)
+		switch {
+		case alias.Name == "" || strings.ContainsAny(alias.Name, " \t") || strings.HasPrefix(alias.Name, "-"):
+			alias.Problem = "invalid name"
+		case len(fields) == 0:
+			alias.Problem = "empty expansion"
+		case commandTaken(alias.Name):
+			alias.Problem = "shadowed by a command"
+		case merged[fields[0]].Name != "" && !commandTaken(fields[0]):
+			alias.Problem = "points at another alias"
+		case !commandTaken(fields[0]):
+			alias.Problem = fmt.Sprintf("unknown command %q", fields[0])
+		}
+		aliases = append(aliases, alias)
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].Name < aliases[j].Name })
+	return aliases
+}
+
+// configuredAliases reads the aliases from axle_config.json, if there is
+// one. It reads the file directly since this runs for every invocation,
+// before flags are parsed and without taking the config lock.
+func configuredAliases() map[string]string {
+	path, err := configFilePath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var localCfg LocalAppConfig
+	if json.Unmarshal(data, &localCfg) != nil {
+		return nil
+	}
+	return localCfg.Aliases
+}
+
+// commandTaken reports whether name is a command or plugin (not an alias).
+func commandTaken(name string) bool {
+	for _, c := range rootCmd.Commands() {
+		if _, isAlias := c.Annotations[aliasAnnotation]; isAlias {
+			continue
+		}
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return name == "help" || name == "completion"
+}
+
+// newAliasCommand wraps alias as a subcommand that runs its expansion with
+// any further arguments appended.
+func newAliasCommand(alias commandAlias) *cobra.Command {
+	return &cobra.Command{
+		Use:                alias.Name,
+		Short:              fmt.Sprintf("Alias for 'axle %s'", alias.Expansion),
+		Annotations:        map[string]string{aliasAnnotation: alias.Expansion},
+		DisableFlagParsing: true,
+		// The expanded command reports its own errors
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rootCmd.SetArgs(append(strings.Fields(alias.Expansion), args...))
+			return rootCmd.Execute()
+		},
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(aliasesCmd)
+}
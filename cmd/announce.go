@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var announceRequireAck bool
+
+// announceCmd sends an announcement to the team
+var announceCmd = &cobra.Command{
+	Use:   "announce <message>",
+	Short: "Announce something to the team, optionally requiring acknowledgment",
+	Long: utils.RenderTitle("📣 Team Announcements") + `
+
+Announcements are for things everyone must notice, like a changed port or a
+frozen branch. They are shown prominently with a desktop notification, and
+members who were offline see them the next time they run 'axle start'.
+
+With --require-ack, every other member must run 'axle ack <id>'. Check who
+has with 'axle announce status <id>'.
+
+Examples:
+  axle announce "Switching API port to 8081" --require-ack
+  axle announce status 3
+  axle announce list`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		text := strings.TrimSpace(strings.Join(args, " "))
+		if text == "" {
+			return fmt.Errorf("nothing to announce")
+		}
+
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		announcement, err := utils.PostAnnouncement(context.Background(), config, text, announceRequireAck)
+		if err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Sent announcement #%d", announcement.ID)))
+		if announcement.RequireAck {
+			fmt.Println(utils.RenderInfo(fmt.Sprintf("Waiting for %d member(s) to acknowledge; check with 'axle announce status %d'",
+				len(announcement.Recipients), announcement.ID)))
+		}
+		return nil
+	},
+}
+
+var announceStatusCmd = &cobra.Command{
+	Use:   "status <id>",
+	Short: "Show who has and hasn't acknowledged an announcement",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseAnnouncementID(args[0])
+		if err != nil {
+			return err
+		}
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		announcement, err := utils.GetAnnouncement(ctx, config, id)
+		if err != nil {
+			return err
+		}
+		acks, err := utils.AnnouncementAcks(ctx, config, id)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(utils.RenderTitle(fmt.Sprintf("📣 Announcement #%d", announcement.ID)))
+		fmt.Printf("  %s\n", announcement.Text)
+		fmt.Printf("  from %s, %s\n\n", announcement.Author, formatTime(time.Unix(announcement.Timestamp, 0)))
+		if !announcement.RequireAck {
+			fmt.Println(utils.RenderInfo("This announcement doesn't require acknowledgment"))
+			return nil
+		}
+
+		var waiting int
+		for _, member := range announcement.Recipients {
+			if at, ok := acks[member]; ok {
+				fmt.Printf("  ✅ %-20s acknowledged %s\n", member, formatTime(at))
+			} else {
+				fmt.Printf("  ⏳ %-20s waiting\n", member)
+				waiting++
+			}
+		}
+		fmt.Println()
+		if waiting == 0 {
+			fmt.Println(utils.RenderSuccess("Everyone has acknowledged"))
+		} else {
+			fmt.Println(utils.RenderWarning(fmt.Sprintf("%d of %d still to acknowledge", waiting, len(announcement.Recipients))))
+		}
+		return nil
+	},
+}
+
+var announceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the team's announcements",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		announcements, err := utils.ListAnnouncements(context.Background(), config)
+		if err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderInfo("📣 Announcements"))
+		if len(announcements) == 0 {
+			fmt.Println("  No announcements")
+			return nil
+		}
+		for _, announcement := range announcements {
+			ack := ""
+			if announcement.RequireAck {
+				ack = ", ack required"
+			}
+			fmt.Printf("  #%-4d %s  (%s, %s%s)\n", announcement.ID, announcement.Text, announcement.Author,
+				formatTime(time.Unix(announcement.Timestamp, 0)), ack)
+		}
+		return nil
+	},
+}
+
+// ackCmd acknowledges an announcement
+var ackCmd = &cobra.Command{
+	Use:   "ack [id]",
+	Short: "Acknowledge an announcement, or list the ones waiting for you",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var id int64
+		if len(args) == 1 {
+			var err error
+			if id, err = parseAnnouncementID(args[0]); err != nil {
+				return err
+			}
+		}
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		if id == 0 {
+			pending, err := utils.PendingAnnouncements(ctx, config)
+			if err != nil {
+				return err
+			}
+			if len(pending) == 0 {
+				fmt.Println(utils.RenderSuccess("No announcements waiting for your acknowledgment"))
+				return nil
+			}
+			printPendingAnnouncements(pending)
+			return nil
+		}
+
+		announcement, err := utils.AckAnnouncement(ctx, config, id)
+		if err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Acknowledged #%d from %s", announcement.ID, announcement.Author)))
+		return nil
+	},
+}
+
+// parseAnnouncementID parses an announcement ID, with or without a leading '#'
+func parseAnnouncementID(arg string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid announcement ID %q", arg)
+	}
+	return id, nil
+}
+
+// printPendingAnnouncements lists announcements waiting for this member's acknowledgment
+func printPendingAnnouncements(pending []utils.Announcement) {
+	fmt.Println(utils.RenderWarning(utils.T("announce.pending")))
+	for _, announcement := range pending {
+		fmt.Printf("  #%-4d %s  (%s, %s)\n", announcement.ID, announcement.Text, announcement.Author,
+			formatTime(time.Unix(announcement.Timestamp, 0)))
+	}
+	fmt.Println(utils.T("announce.ack_hint"))
+}
+
+func init() {
+	rootCmd.AddCommand(announceCmd)
+	announceCmd.AddCommand(announceStatusCmd, announceListCmd)
+	announceCmd.Flags().BoolVar(&announceRequireAck, "require-ack", false, "Ask every other member to acknowledge with 'axle ack <id>'")
+
+	rootCmd.AddCommand(ackCmd)
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var artifactsCleanResolved bool
+
+// artifactsCmd lists files conflict strategies left behind
+var artifactsCmd = &cobra.Command{
+	Use:   "artifacts",
+	Short: "List .rej files and backups left by conflicts",
+	Long: utils.RenderTitle("🧹 Conflict Artifacts") + `
+
+When a patch can't be applied cleanly, the merge strategy saves the hunks it
+couldn't apply as <file>.rej next to the file, and the backup strategy keeps
+the previous version of every file it touches in .axle/backups. Axle keeps
+track of these artifacts, never syncs them, and while 'axle start' is
+running removes each one once its conflict is resolved:
+
+  • a .rej file once its file has been saved without conflict markers
+  • a backup a day after its file was last free of conflicts
+
+Examples:
+  axle artifacts                     # List conflict artifacts
+  axle artifacts clean               # Remove all of them
+  axle artifacts clean --resolved    # Remove only resolved ones`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		artifacts, err := utils.ListConflictArtifacts(config.RootDir)
+		if err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderTitle("🧹 Conflict Artifacts"))
+		if len(artifacts) == 0 {
+			fmt.Println(utils.RenderSuccess("No conflict artifacts"))
+			return nil
+		}
+		unmerged := utils.UnmergedFiles(config.RootDir)
+		for _, artifact := range artifacts {
+			state := "unresolved"
+			if utils.ArtifactResolved(config.RootDir, artifact, unmerged) {
+				state = "resolved"
+			}
+			fmt.Printf("  %-50s %-7s %-11s %s\n", artifact.Path, artifact.Kind, state, formatTime(time.Unix(artifact.CreatedAt, 0)))
+		}
+		fmt.Println(utils.RenderInfo("Remove them with 'axle artifacts clean'"))
+		return nil
+	},
+}
+
+var artifactsCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove conflict artifacts",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		removed, err := utils.CleanConflictArtifacts(config.RootDir, artifactsCleanResolved)
+		if err != nil {
+			return err
+		}
+		if len(removed) == 0 {
+			fmt.Println(utils.RenderInfo("Nothing to clean"))
+			return nil
+		}
+		for _, artifact := range removed {
+			fmt.Printf("  %s\n", artifact.Path)
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Removed %d conflict artifact(s)", len(removed))))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(artifactsCmd)
+	artifactsCmd.AddCommand(artifactsCleanCmd)
+	artifactsCleanCmd.Flags().BoolVar(&artifactsCleanResolved, "resolved", false, "Only remove artifacts whose conflict is resolved")
+}
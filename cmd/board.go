@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	unpinID     int64
+	todoListAll bool
+)
+
+// pinCmd pins a message to the team's noticeboard
+var pinCmd = &cobra.Command{
+	Use:   "pin [message]",
+	Short: "Pin a message to the team's noticeboard",
+	Long: utils.RenderTitle("📌 Pinned Messages") + `
+
+Pins a message for the whole team, such as a deadline or the demo Wi-Fi
+password. Pinned messages are shown when 'axle start' launches, in
+'axle stats', and with 'axle team --pins'. Without a message, lists the
+current pins.
+
+Examples:
+  axle pin "Demo at 5pm in room B"
+  axle pin                 # List pinned messages
+  axle pin --remove 3      # Unpin message #3`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		switch {
+		case cmd.Flags().Changed("remove"):
+			if err := utils.RemovePin(ctx, config, unpinID); err != nil {
+				return err
+			}
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("Unpinned #%d", unpinID)))
+		case len(args) > 0:
+			pin, err := utils.AddPin(ctx, config, strings.Join(args, " "))
+			if err != nil {
+				return err
+			}
+			announceChatAction(ctx, config, "pinned: "+pin.Text)
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("Pinned #%d", pin.ID)))
+		default:
+			pins, err := utils.ListPins(ctx, config)
+			if err != nil {
+				return err
+			}
+			printPins(pins)
+		}
+		return nil
+	},
+}
+
+// todoCmd groups the team TODO board commands
+var todoCmd = &cobra.Command{
+	Use:   "todo",
+	Short: "Manage the team's shared TODO list",
+	Long: utils.RenderTitle("✅ Team TODO Board") + `
+
+A lightweight TODO list shared by the whole team. Open items are shown when
+'axle start' launches, in 'axle stats', and with 'axle team --pins'.
+
+Examples:
+  axle todo add "Write the README"
+  axle todo list
+  axle todo done 4`,
+}
+
+var todoAddCmd = &cobra.Command{
+	Use:   "add <text>",
+	Short: "Add an item to the team's TODO list",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		item, err := utils.AddTodo(context.Background(), config, strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Added TODO #%d", item.ID)))
+		return nil
+	},
+}
+
+var todoListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the team's open TODO items",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		items, err := utils.ListTodos(context.Background(), config, todoListAll)
+		if err != nil {
+			return err
+		}
+		printTodos(items)
+		return nil
+	},
+}
+
+var todoDoneCmd = &cobra.Command{
+	Use:   "done <id>",
+	Short: "Mark a TODO item as done",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid TODO ID %q", args[0])
+		}
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		item, err := utils.CompleteTodo(ctx, config, id)
+		if err != nil {
+			return err
+		}
+		announceChatAction(ctx, config, "finished: "+item.Text)
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Marked #%d as done", item.ID)))
+		return nil
+	},
+}
+
+// printBoard shows the pinned messages and open TODO items, if there are any
+func printBoard(ctx context.Context, cfg utils.AppConfig) {
+	pins, err := utils.ListPins(ctx, cfg)
+	if err != nil {
+		return
+	}
+	todos, err := utils.ListTodos(ctx, cfg, false)
+	if err != nil {
+		return
+	}
+	if len(pins) > 0 {
+		printPins(pins)
+	}
+	if len(todos) > 0 {
+		printTodos(todos)
+	}
+}
+
+// printPins lists pinned messages
+func printPins(pins []utils.Pin) {
+	fmt.Println(utils.RenderInfo(utils.T("board.pinned")))
+	if len(pins) == 0 {
+		fmt.Println(utils.T("board.nothing_pinned"))
+		return
+	}
+	for _, pin := range pins {
+		fmt.Printf("  #%-4d %s  (%s, %s)\n", pin.ID, pin.Text, pin.Author, formatTime(time.Unix(pin.Timestamp, 0)))
+	}
+}
+
+// printTodos lists TODO items
+func printTodos(items []utils.TodoItem) {
+	fmt.Println(utils.RenderInfo(utils.T("board.todo")))
+	if len(items) == 0 {
+		fmt.Println(utils.T("board.nothing_todo"))
+		return
+	}
+	for _, item := range items {
+		if item.Done {
+			fmt.Println(utils.T("board.todo_done", item.ID, item.Text, item.DoneBy))
+		} else {
+			fmt.Printf("  #%-4d [ ] %s  (%s)\n", item.ID, item.Text, item.Author)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(pinCmd)
+	pinCmd.Flags().Int64Var(&unpinID, "remove", 0, "Unpin the message with this ID")
+
+	rootCmd.AddCommand(todoCmd)
+	todoCmd.AddCommand(todoAddCmd, todoListCmd, todoDoneCmd)
+	todoListCmd.Flags().BoolVar(&todoListAll, "all", false, "Include completed items")
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var catchupConflict string
+
+// catchupCmd replays batches the team published while this node was offline
+var catchupCmd = &cobra.Command{
+	Use:   "catchup",
+	Short: "Apply the changes teammates made while you were offline",
+	Long: utils.RenderTitle("⏩ Catch Up") + `
+
+For teams working across time zones. When batch persistence is enabled
+('axle team persistence on'), every published batch is stored in a Redis
+Stream. 'axle catchup' replays everything after the last batch this node
+handled, oldest first, so you pick up work done while you were away.
+
+'axle start' catches up by itself before publishing local changes; run
+this to catch up without starting the daemon.
+
+Examples:
+  axle catchup
+  axle catchup --conflict theirs`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' or 'axle join' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		if !config.PersistBatches {
+			return fmt.Errorf("batch persistence is not enabled for team %s; the admin can enable it with 'axle team persistence on'", config.TeamID)
+		}
+		if state, err := utils.ReadDaemonState(config.RootDir); err == nil && state.IsRunning() {
+			return fmt.Errorf("the sync daemon is running (PID %d); stop it before catching up", state.PID)
+		}
+
+		config.ConflictStrategy = utils.ConflictStrategy(catchupConflict)
+
+		fmt.Println(utils.RenderTitle("⏩ Catching Up"))
+		ctx := context.Background()
+		missed, lastID, err := utils.ReadMissedBatches(ctx, config)
+		if err != nil {
+			return err
+		}
+		if len(missed) == 0 {
+			if lastID != "" {
+				utils.CompleteCatchup(config.RootDir, lastID)
+			}
+			fmt.Println(utils.RenderSuccess("Already up to date"))
+			return nil
+		}
+
+		for i, batch := range missed {
+			fmt.Printf("[%d/%d] %s from %s (%d changes)... ", i+1, len(missed),
+				time.Unix(batch.Timestamp, 0).Format("Jan 2 15:04"), batch.PeerID, len(batch.Changes))
+			receiveSyncBatch(config, batch)
+			fmt.Println(utils.RenderSuccess("done"))
+		}
+
+		if err := utils.CompleteCatchup(config.RootDir, lastID); err != nil {
+			return fmt.Errorf("failed to save catch-up position: %w", err)
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Replayed %d batches", len(missed))))
+		fmt.Println(utils.RenderInfo("Run 'axle start' to resume live syncing"))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(catchupCmd)
+	catchupCmd.Flags().StringVar(&catchupConflict, "conflict", string(utils.ConflictStrategyMerge), "Conflict resolution strategy for replayed changes")
+}
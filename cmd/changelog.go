@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	changelogSince     string
+	changelogSummarize bool
+	changelogOutput    string
+)
+
+// changelogCmd writes a Markdown changelog from the sync history
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Generate a Markdown changelog from the sync history",
+	Long: utils.RenderTitle("📝 Changelog") + `
+
+Writes a Markdown changelog of the repository's sync history, grouped by day
+and member, ready to paste into a submission or release notes. Commit
+messages people wrote are kept; Axle's own sync commits contribute the files
+they changed. Conflicts from the sync ledger are counted per member.
+
+With --summarize, directories with several changed files are folded into
+one line and lockfile churn (package-lock.json, go.sum, ...) becomes a
+single note. It needs no connection to Redis.
+
+Examples:
+  axle changelog
+  axle changelog --since 48h --summarize
+  axle changelog --since 2024-05-01 -o CHANGELOG.md`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		opts := utils.ChangelogOptions{Summarize: changelogSummarize}
+		if changelogSince != "" {
+			since, err := parseSince(changelogSince)
+			if err != nil {
+				return err
+			}
+			opts.Since = since
+		}
+
+		changelog, err := utils.GenerateChangelog(config.RootDir, opts)
+		if err != nil {
+			return err
+		}
+
+		if changelogOutput == "" {
+			fmt.Print(changelog)
+			return nil
+		}
+		if err := os.WriteFile(changelogOutput, []byte(changelog), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", changelogOutput, err)
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Changelog written to %s", changelogOutput)))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(changelogCmd)
+	changelogCmd.Flags().StringVar(&changelogSince, "since", "", "Only include changes after this: a duration (48h) or a date (2006-01-02)")
+	changelogCmd.Flags().BoolVarP(&changelogSummarize, "summarize", "s", false, "Group files by directory and fold lockfile changes")
+	changelogCmd.Flags().StringVarP(&changelogOutput, "output", "o", "", "Write the changelog to a file instead of stdout")
+}
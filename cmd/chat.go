@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,58 +24,269 @@ Messages are delivered in real-time through Redis pub/sub.
 
 Use -p flag to send priority messages that trigger desktop notifications.
 
+Messages starting with / are commands:
+  /me <action>     Describe what you're doing ("* alice is deploying")
+  /status [text]   Set (or clear) your status in 'axle team'
+  /lock [file]     Claim a file so teammates leave it alone (no file: list locks)
+  /unlock <file>   Release your claim on a file
+  /who             Show who is online
+  /history [n]     Show the last n messages (20 by default) in the order they were sent
+  /help            List chat commands
+Start a message with // to send it literally.
+
 Examples:
   axle chat "Hello team!"
   axle chat "Ready to review the PR"
   axle chat -p "URGENT: Production issue needs immediate attention!"
-  axle chat --priority "Please review this ASAP"`,
+  axle chat --priority "Please review this ASAP"
+  axle chat /me is fixing the login bug
+  axle chat /lock src/api/routes.go`,
 	
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Load configuration
-		if err := loadConfig(); err != nil {
+		// Load configuration; Redis is only needed if no daemon is running
+		if err := loadLocalConfig(); err != nil {
 			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
 		}
-		
-		defer config.RedisClient.Close()
 
 		// Join all arguments to form the message
 		messageContent := strings.Join(args, " ")
+		ctx := context.Background()
+
+		if strings.HasPrefix(messageContent, "//") {
+			messageContent = messageContent[1:]
+		} else if strings.HasPrefix(messageContent, "/") {
+			return runChatCommand(ctx, messageContent)
+		}
 
-		fmt.Println(utils.RenderTitle("💬 Sending Message"))
-		fmt.Printf("To team: %s\n", config.TeamID)
-		fmt.Printf("Message: \"%s\"\n", messageContent)
+		fmt.Println(utils.RenderTitle(utils.T("chat.title")))
+		fmt.Println(utils.T("chat.to_team", config.TeamID))
+		fmt.Println(utils.T("chat.message", messageContent))
 		if priorityFlag {
-			fmt.Printf("Priority: 🔔 HIGH (will trigger notifications)\n")
+			fmt.Println(utils.T("chat.priority"))
 		}
 
 		// Send the chat message
-		ctx := context.Background()
-		if err := publishChatMessage(ctx, config, messageContent); err != nil {
+		if err := deliverChat(ctx, utils.ChatMessage{Message: messageContent, Priority: priorityFlag}); err != nil {
 			return fmt.Errorf("failed to send message: %w", err)
 		}
 
 		if priorityFlag {
-			fmt.Println(utils.RenderSuccess("Priority message sent with notifications!"))
+			fmt.Println(utils.RenderSuccess(utils.T("chat.sent_priority")))
 		} else {
-			fmt.Println(utils.RenderSuccess("Message sent successfully!"))
+			fmt.Println(utils.RenderSuccess(utils.T("chat.sent")))
 		}
 		
 		return nil
 	},
 }
 
-// publishChatMessage publishes a chat message to the Redis channel
-func publishChatMessage(ctx context.Context, cfg utils.AppConfig, messageContent string) error {
-	msg := utils.ChatMessage{
-		Sender:    cfg.Username,
-		Message:   messageContent,
-		Timestamp: time.Now().Unix(),
-		Priority:  priorityFlag,
+// deliverChat sends a chat message through the running daemon, or over a Redis
+// connection of its own when no daemon is running. loadLocalConfig must have
+// been called.
+func deliverChat(ctx context.Context, msg utils.ChatMessage) error {
+	_, ok, err := askDaemon("chat", map[string]string{
+		"message":  msg.Message,
+		"priority": strconv.FormatBool(msg.Priority),
+		"action":   strconv.FormatBool(msg.Action),
+	})
+	if ok {
+		return err
+	}
+
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+	}
+	defer config.RedisClient.Close()
+	return sendChat(ctx, config, msg)
+}
+
+// publishChatAction publishes a /me-style action message to the Redis channel
+func publishChatAction(ctx context.Context, cfg utils.AppConfig, action string) error {
+	return sendChat(ctx, cfg, utils.ChatMessage{Message: action, Action: true})
+}
+
+// sendChat stamps a chat message with the sender, time and team sequence
+// number and publishes it
+func sendChat(ctx context.Context, cfg utils.AppConfig, msg utils.ChatMessage) error {
+	if !cfg.FeatureEnabled(utils.FeatureChatEnabled) {
+		return fmt.Errorf("chat is %w", utils.ErrFeatureDisabled)
+	}
+	msg.Sender = cfg.Username
+	msg.Timestamp = time.Now().Unix()
+	if err := utils.CheckOutgoingChat(msg); err != nil {
+		return err
+	}
+	msg.Seq = utils.NextSequence(ctx, cfg)
+
+	chatChannel := utils.ChatChannel(cfg.TeamID)
+	return utils.PublishMessage(ctx, cfg.Transport, chatChannel, msg)
+}
+
+// runChatCommand routes a chat slash-command to the subsystem that handles it.
+// loadLocalConfig must have been called.
+func runChatCommand(ctx context.Context, line string) error {
+	name, arg, _ := strings.Cut(strings.TrimPrefix(line, "/"), " ")
+	name = strings.ToLower(name)
+	arg = strings.TrimSpace(arg)
+
+	// Commands that can go through the running daemon
+	switch name {
+	case "me":
+		if arg == "" {
+			return fmt.Errorf("usage: /me <action>")
+		}
+		if err := deliverChat(ctx, utils.ChatMessage{Message: arg, Action: true}); err != nil {
+			return fmt.Errorf("failed to send message: %w", err)
+		}
+		fmt.Println(formatChatLine(utils.ChatMessage{Sender: config.Username, Message: arg, Action: true, Timestamp: time.Now().Unix()}))
+		return nil
+
+	case "help":
+		fmt.Println(utils.RenderTitle(utils.T("chat.help_title")))
+		fmt.Println(utils.T("chat.help"))
+		return nil
+
+	case "history":
+		limit := 20
+		if arg != "" {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 {
+				return fmt.Errorf("usage: /history [count]")
+			}
+			limit = n
+		}
+		return printChatHistory(limit)
+	}
+
+	// The rest talk to Redis directly
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+	}
+	defer config.RedisClient.Close()
+	cfg := config
+
+	switch name {
+	case "status":
+		if err := utils.SetStatusNote(ctx, cfg, arg); err != nil {
+			return err
+		}
+		if arg == "" {
+			fmt.Println(utils.RenderSuccess(utils.T("chat.status_cleared")))
+			return nil
+		}
+		announceChatAction(ctx, cfg, utils.T("chat.status_action", arg))
+		fmt.Println(utils.RenderSuccess(utils.T("chat.status_set", arg)))
+
+	case "lock":
+		if arg == "" {
+			return printFileLocks(ctx, cfg)
+		}
+		relPath, err := rootRelPath(arg)
+		if err != nil {
+			return err
+		}
+		lock, err := utils.LockFile(ctx, cfg, relPath)
+		if err != nil {
+			return err
+		}
+		announceChatAction(ctx, cfg, utils.T("chat.lock_action", lock.File))
+		fmt.Println(utils.RenderSuccess(utils.T("chat.locked", lock.File)))
+
+	case "unlock":
+		if arg == "" {
+			return fmt.Errorf("usage: /unlock <file>")
+		}
+		relPath, err := rootRelPath(arg)
+		if err != nil {
+			return err
+		}
+		if err := utils.UnlockFile(ctx, cfg, relPath); err != nil {
+			return err
+		}
+		announceChatAction(ctx, cfg, utils.T("chat.unlock_action", relPath))
+		fmt.Println(utils.RenderSuccess(utils.T("chat.unlocked", relPath)))
+
+	case "who":
+		presenceList, err := utils.GetTeamPresence(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderTitle("👥 Team: " + cfg.TeamID))
+		fmt.Println(utils.RenderPresenceTable(presenceList))
+
+	default:
+		return fmt.Errorf("unknown chat command /%s; see /help", name)
+	}
+	return nil
+}
+
+// announceChatAction tells the team about a command's effect. The command has
+// already succeeded, so a failed announcement is only a warning.
+func announceChatAction(ctx context.Context, cfg utils.AppConfig, action string) {
+	if !cfg.FeatureEnabled(utils.FeatureChatEnabled) {
+		return
+	}
+	if err := publishChatAction(ctx, cfg, action); err != nil {
+		fmt.Println(utils.RenderWarning(fmt.Sprintf("Could not announce to the team: %v", err)))
+	}
+}
+
+// printFileLocks lists the team's file locks
+func printFileLocks(ctx context.Context, cfg utils.AppConfig) error {
+	locks, err := utils.GetFileLocks(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if len(locks) == 0 {
+		fmt.Println(utils.RenderInfo(utils.T("chat.no_locks")))
+		return nil
+	}
+	fmt.Println(utils.RenderTitle("🔒 Locked Files"))
+	for _, lock := range locks {
+		fmt.Printf("  %-40s %-16s since %s\n", lock.File, lock.Owner, time.Unix(lock.Timestamp, 0).Format("Jan 02 15:04"))
+	}
+	return nil
+}
+
+// printChatHistory prints the last messages the daemon received, in the
+// order the team sent them
+func printChatHistory(limit int) error {
+	messages, err := utils.ChatHistory(config.RootDir, limit)
+	if err != nil {
+		return err
+	}
+	fmt.Println(utils.RenderTitle(utils.T("chat.history_title")))
+	if len(messages) == 0 {
+		fmt.Println(utils.RenderInfo(utils.T("chat.no_history")))
+		return nil
+	}
+	for _, msg := range messages {
+		fmt.Println(formatChatLine(msg))
 	}
+	return nil
+}
 
-	chatChannel := fmt.Sprintf("axle:chat:%s", cfg.TeamID)
-	return utils.PublishMessage(ctx, cfg.RedisClient, chatChannel, msg)
+// formatChatLine renders a chat message for the terminal. It shows when the
+// message arrived by the local clock, and the sender's time only when their
+// clock is noticeably off.
+func formatChatLine(msg utils.ChatMessage) string {
+	arrived := msg.ReceivedAt
+	if arrived == 0 {
+		arrived = msg.Timestamp
+	}
+	timestamp := time.Unix(arrived, 0).Local().Format("15:04:05")
+	if skew := utils.ClockSkew(msg.Timestamp, msg.ReceivedAt); skew != 0 {
+		timestamp += fmt.Sprintf(", sent %s", time.Unix(msg.Timestamp, 0).Local().Format("15:04:05"))
+	}
+	switch {
+	case msg.Action:
+		return fmt.Sprintf("[CHAT %s] * %s %s", timestamp, msg.Sender, msg.Message)
+	case msg.Priority:
+		return fmt.Sprintf("[CHAT %s] 🔔 <%s> %s", timestamp, msg.Sender, msg.Message)
+	default:
+		return fmt.Sprintf("[CHAT %s] <%s> %s", timestamp, msg.Sender, msg.Message)
+	}
 }
 
 func init() {
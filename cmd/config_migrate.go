@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+)
+
+// CurrentConfigSchema is the schema version of axle_config.json written by this binary.
+const CurrentConfigSchema = 2
+
+// configMigration upgrades a raw config document from one schema version to the next.
+type configMigration struct {
+	from        int
+	description string
+	migrate     func(raw map[string]interface{})
+}
+
+// configMigrations must stay ordered by version; each step upgrades from 'from' to 'from+1'.
+var configMigrations = []configMigration{
+	{
+		from:        0,
+		description: "assign a persistent node ID",
+		migrate: func(raw map[string]interface{}) {
+			if nodeID, _ := raw["nodeID"].(string); nodeID == "" {
+				raw["nodeID"] = utils.GenerateNodeID()
+			}
+		},
+	},
+	{
+		from:        1,
+		description: "always ignore .git and the local config file",
+		migrate: func(raw map[string]interface{}) {
+			patterns, _ := raw["ignorePatterns"].([]interface{})
+			for _, required := range []string{".git", ConfigFileName} {
+				found := false
+				for _, pattern := range patterns {
+					if pattern == required {
+						found = true
+						break
+					}
+				}
+				if !found {
+					patterns = append(patterns, required)
+				}
+			}
+			raw["ignorePatterns"] = patterns
+		},
+	},
+}
+
+// migrateConfigData upgrades raw config JSON to the current schema. It returns
+// the (possibly unchanged) JSON, the schema version it started from, and
+// whether any migration ran.
+func migrateConfigData(data []byte) ([]byte, int, bool, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, false, err
+	}
+
+	version := 0
+	if v, ok := raw["schemaVersion"].(float64); ok {
+		version = int(v)
+	}
+
+	if version > CurrentConfigSchema {
+		return nil, version, false, fmt.Errorf("config schema version %d is newer than this version of Axle supports (%d); please upgrade Axle",
+			version, CurrentConfigSchema)
+	}
+	if version == CurrentConfigSchema {
+		return data, version, false, nil
+	}
+
+	startVersion := version
+	for _, migration := range configMigrations {
+		if migration.from != version {
+			continue
+		}
+		migration.migrate(raw)
+		version++
+		log.Printf("[CONFIG] Migrated config to schema v%d: %s", version, migration.description)
+	}
+	raw["schemaVersion"] = version
+
+	migrated, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return nil, startVersion, false, fmt.Errorf("failed to marshal migrated config: %w", err)
+	}
+	return migrated, startVersion, true, nil
+}
+
+// backupConfigFile saves a copy of the pre-migration config under .axle/config-backups.
+func backupConfigFile(rootDir string, data []byte, version int) (string, error) {
+	backupDir := utils.AxlePath(rootDir, "config-backups")
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create config backup directory: %w", err)
+	}
+
+	backupPath := filepath.Join(backupDir, fmt.Sprintf("axle_config.v%d.%d.json", version, time.Now().Unix()))
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to back up config: %w", err)
+	}
+	return backupPath, nil
+}
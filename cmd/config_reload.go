@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+)
+
+// conflictFlagSet records that 'axle start' got --conflict, which keeps
+// winning over the conflict strategy in axle_config.json after a reload
+var conflictFlagSet bool
+
+// liveConflictStrategy is the conflict strategy reloaded from
+// axle_config.json while the daemon runs, "" until the first reload
+var liveConflictStrategy = struct {
+	sync.RWMutex
+	strategy utils.ConflictStrategy
+}{}
+
+// parseConflictStrategy checks a conflict strategy name.
+func parseConflictStrategy(name string) (utils.ConflictStrategy, error) {
+	strategy := utils.ConflictStrategy(name)
+	switch strategy {
+	case utils.ConflictStrategyTheirs, utils.ConflictStrategyMine,
+		utils.ConflictStrategyMerge, utils.ConflictStrategyBackup,
+		utils.ConflictStrategyInteractive, utils.ConflictStrategyAuto:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("invalid conflict mode: %s (use: theirs, mine, merge, backup, interactive, or auto)", name)
+}
+
+// withLiveSettings returns the daemon's config with the settings reloaded
+// from axle_config.json since it started.
+func withLiveSettings(cfg utils.AppConfig) utils.AppConfig {
+	liveConflictStrategy.RLock()
+	defer liveConflictStrategy.RUnlock()
+	if liveConflictStrategy.strategy != "" {
+		cfg.ConflictStrategy = liveConflictStrategy.strategy
+	}
+	return cfg
+}
+
+// reloadLocalSettings rereads axle_config.json and applies what can change
+// while the daemon runs: the ignore patterns, the batch window and the
+// conflict strategy. cfg is the daemon's config as it started; removing a
+// setting from the file returns to that.
+func reloadLocalSettings(cfg utils.AppConfig) error {
+	localCfg, err := loadConfigFromFile()
+	if err != nil {
+		return err
+	}
+
+	strategy := cfg.ConflictStrategy
+	if localCfg.ConflictStrategy != "" && !conflictFlagSet {
+		if strategy, err = parseConflictStrategy(localCfg.ConflictStrategy); err != nil {
+			return err
+		}
+	}
+	window := time.Duration(localCfg.BatchWindowMs) * time.Millisecond
+	if window < 0 {
+		return fmt.Errorf("invalid batchWindowMs %d", localCfg.BatchWindowMs)
+	}
+
+	utils.SetIgnorePatterns(localCfg.IgnorePatterns)
+	utils.SetBatchWindow(window)
+	liveConflictStrategy.Lock()
+	liveConflictStrategy.strategy = strategy
+	liveConflictStrategy.Unlock()
+
+	windowDesc := "adaptive"
+	if window > 0 {
+		windowDesc = window.String()
+	}
+	log.Printf("[CONFIG] Reloaded %s: ignore patterns %v, batch window %s, conflict strategy %s",
+		ConfigFileName, localCfg.IgnorePatterns, windowDesc, strategy)
+	return nil
+}
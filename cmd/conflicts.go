@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// conflictsCmd groups commands for files left with conflict markers
+var conflictsCmd = &cobra.Command{
+	Use:   "conflicts",
+	Short: "Track files with unresolved conflict markers",
+	Long: utils.RenderTitle("⚔️  Unresolved Conflicts") + `
+
+When the merge strategy can't merge an incoming change, it writes git
+conflict markers (<<<<<<<, =======, >>>>>>>) into the file. Axle remembers
+every such file until the markers are gone. Meanwhile, your changes to it
+are held back instead of spreading the markers to the team, and go out
+once it is resolved (team setting conflicts.block).
+
+Examples:
+  axle conflicts list    # Files with conflict markers, their age and peer`,
+}
+
+var conflictsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List files with unresolved conflict markers",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		conflicted, err := utils.ListConflictedFiles(config.RootDir)
+		if err != nil {
+			return err
+		}
+		held, err := utils.ListHeldBatches(config.RootDir, utils.HeldConflicted, nil)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(utils.RenderTitle("⚔️  Unresolved Conflicts"))
+		if len(conflicted) == 0 {
+			fmt.Println(utils.RenderSuccess("No files with conflict markers"))
+		}
+		for _, entry := range conflicted {
+			peer := entry.Peer
+			if peer == "" {
+				peer = "unknown"
+			}
+			fmt.Printf("  %-50s %-16s %s\n", entry.File, peer, formatTime(time.Unix(entry.Since, 0)))
+		}
+		if len(held) > 0 && len(conflicted) > 0 {
+			fmt.Println(utils.RenderWarning(fmt.Sprintf("%d local batch(es) held until these conflicts are resolved", len(held))))
+		} else if len(held) > 0 {
+			fmt.Println(utils.RenderInfo(fmt.Sprintf("%d held local batch(es) go out within a minute while 'axle start' runs", len(held))))
+		}
+		if len(conflicted) > 0 {
+			fmt.Println(utils.RenderInfo("Remove the markers and save each file to resolve it"))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(conflictsCmd)
+	conflictsCmd.AddCommand(conflictsListCmd)
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	convergeWait  time.Duration
+	convergePaths bool
+)
+
+// convergeCheckCmd checks whether every online node holds the same files
+var convergeCheckCmd = &cobra.Command{
+	Use:   "converge-check",
+	Short: "Check whether every online teammate has identical files",
+	Long: utils.RenderTitle("🎯 Convergence Check") + `
+
+Asks every online node to hash the files it syncs, exactly as they are on
+disk (uncommitted edits count, ignored files don't), and compares the
+digests. A quick "are we all identical?" before a demo.
+
+Nodes are grouped by digest; the largest group is taken as the reference
+and everyone else is reported as diverged. With --paths every node also
+sends its file list, and the files that differ are listed.
+
+Examples:
+  axle converge-check                # Who matches, who diverges
+  axle converge-check --paths        # Also list the differing files
+  axle converge-check --wait 10s     # Give slow machines longer to hash`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+		ctx := context.Background()
+
+		fmt.Println(utils.RenderTitle("🎯 Convergence Check"))
+		fmt.Printf("Asking online teammates for their tree digest (waiting %v)...\n", convergeWait)
+		report, err := utils.RunConvergeCheck(ctx, config, convergeWait, convergePaths)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println()
+		for i, group := range report.Groups {
+			label := fmt.Sprintf("Digest %s", shortHash(group.Digest))
+			if i == 0 && !report.Converged() {
+				label += " - reference"
+			}
+			fmt.Println(utils.RenderInfo(label))
+			for _, node := range group.Nodes {
+				fmt.Printf("  %-20s %d files\n", node.Username, node.Files)
+			}
+			if i > 0 && convergePaths {
+				printDigestDifferences(report.Groups[0], group)
+			}
+		}
+		fmt.Println()
+
+		if len(report.Silent) > 0 {
+			fmt.Println(utils.RenderWarning(fmt.Sprintf("No answer from %s; they may be busy or running an older release",
+				strings.Join(report.Silent, ", "))))
+		}
+
+		if report.Converged() {
+			nodes := 0
+			if len(report.Groups) > 0 {
+				nodes = len(report.Groups[0].Nodes)
+			}
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("All %d node(s) have identical files", nodes)))
+			return nil
+		}
+
+		var diverged []string
+		for _, group := range report.Groups[1:] {
+			for _, node := range group.Nodes {
+				diverged = append(diverged, node.Username)
+			}
+		}
+		fmt.Println(utils.RenderWarning(fmt.Sprintf("%d node(s) diverge from the reference: %s",
+			len(diverged), strings.Join(diverged, ", "))))
+		if !convergePaths {
+			fmt.Println(utils.RenderInfo("Run 'axle converge-check --paths' to see which files differ, or 'axle elect' to pick a state to repair from"))
+		}
+		return nil
+	},
+}
+
+// printDigestDifferences lists the files a diverged group has different
+// from the reference group
+func printDigestDifferences(reference, group utils.DigestGroup) {
+	diffs := utils.DigestDifferences(reference, group.Nodes[0])
+	if diffs == nil {
+		fmt.Println("    (file list unavailable)")
+		return
+	}
+	for _, diff := range diffs {
+		switch diff.Status {
+		case "added":
+			fmt.Printf("    + %s (only here)\n", diff.Path)
+		case "removed":
+			fmt.Printf("    - %s (missing here)\n", diff.Path)
+		default:
+			fmt.Printf("    ~ %s\n", diff.Path)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(convergeCheckCmd)
+	convergeCheckCmd.Flags().DurationVar(&convergeWait, "wait", 3*time.Second, "How long to wait for teammates to report")
+	convergeCheckCmd.Flags().BoolVar(&convergePaths, "paths", false, "List the files that differ from the reference")
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+)
+
+// daemonRequestTimeout bounds the requests CLI commands route through the daemon.
+const daemonRequestTimeout = 10 * time.Second
+
+// teamStatus is the team's presence and noticeboard, as shown by 'axle team'
+// and 'axle stats'.
+type teamStatus struct {
+	Presence          []utils.PresenceInfo `json:"presence"`
+	AuthoritativeNode string               `json:"authoritativeNode,omitempty"`
+	Pins              []utils.Pin          `json:"pins"`
+	Todos             []utils.TodoItem     `json:"todos"`
+	Health            []utils.HealthReport `json:"health,omitempty"`
+	Builds            []utils.BuildResult  `json:"builds,omitempty"`
+}
+
+// askDaemon sends a request to the daemon running for this repository, so the
+// command reuses its Redis connection instead of opening another. ok is false
+// when no daemon (or one too old to handle the command) is running; the
+// caller then does the work itself. loadLocalConfig must have been called.
+func askDaemon(command string, args map[string]string) (resp utils.ControlResponse, ok bool, err error) {
+	if state, err := utils.ReadDaemonState(config.RootDir); err != nil || !state.IsRunning() {
+		return utils.ControlResponse{}, false, nil
+	}
+
+	resp, err = utils.SendControlRequest(config.RootDir, utils.ControlRequest{Command: command, Args: args}, daemonRequestTimeout)
+	if errors.Is(err, utils.ErrDaemonNotRunning) || errors.Is(err, utils.ErrUnknownControlCommand) {
+		return utils.ControlResponse{}, false, nil
+	}
+	return resp, true, err
+}
+
+// getTeamStatus fetches the team status through the running daemon, or from
+// Redis directly when no daemon is running. loadLocalConfig must have been called.
+func getTeamStatus(ctx context.Context) (teamStatus, error) {
+	resp, ok, err := askDaemon("team-status", nil)
+	if ok {
+		if err != nil {
+			return teamStatus{}, err
+		}
+		var status teamStatus
+		if err := json.Unmarshal([]byte(resp.Message), &status); err != nil {
+			return teamStatus{}, fmt.Errorf("failed to parse team status from daemon: %w", err)
+		}
+		return status, nil
+	}
+
+	if err := loadConfig(); err != nil {
+		return teamStatus{}, fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+	}
+	defer config.RedisClient.Close()
+	return gatherTeamStatus(ctx, config)
+}
+
+// gatherTeamStatus reads the team status from Redis.
+func gatherTeamStatus(ctx context.Context, cfg utils.AppConfig) (teamStatus, error) {
+	var status teamStatus
+	var err error
+	if status.Presence, err = utils.GetTeamPresence(ctx, cfg); err != nil {
+		return teamStatus{}, fmt.Errorf("failed to retrieve team presence: %w", err)
+	}
+	if status.Pins, err = utils.ListPins(ctx, cfg); err != nil {
+		return teamStatus{}, err
+	}
+	if status.Todos, err = utils.ListTodos(ctx, cfg, false); err != nil {
+		return teamStatus{}, err
+	}
+	if status.Health, err = utils.TeamHealth(ctx, cfg); err != nil {
+		return teamStatus{}, err
+	}
+	if status.Builds, err = utils.TeamBuilds(ctx, cfg); err != nil {
+		return teamStatus{}, err
+	}
+	// Read the designation fresh; a running daemon only loads it at startup
+	if teamConfig, err := utils.GetTeamConfig(ctx, cfg.RedisClient, cfg.TeamID); err == nil {
+		status.AuthoritativeNode = teamConfig.AuthoritativeNode
+	}
+	return status, nil
+}
+
+// registerDaemonHandlers lets CLI commands run through the daemon's Redis
+// connection while 'axle start' is running.
+func registerDaemonHandlers(ctx context.Context, cfg utils.AppConfig) {
+	utils.RegisterControlHandler("chat", func(req utils.ControlRequest) (string, error) {
+		priority, _ := strconv.ParseBool(req.Args["priority"])
+		action, _ := strconv.ParseBool(req.Args["action"])
+		msg := utils.ChatMessage{Message: req.Args["message"], Priority: priority, Action: action}
+		if err := sendChat(ctx, cfg, msg); err != nil {
+			return "", err
+		}
+		return "sent", nil
+	})
+
+	utils.RegisterControlHandler("force-sync", func(req utils.ControlRequest) (string, error) {
+		change, err := utils.QueueForcedFile(ctx, cfg, req.Args["path"])
+		if err != nil {
+			return "", err
+		}
+		return change.TraceID, nil
+	})
+
+	utils.RegisterControlHandler("resync", func(req utils.ControlRequest) (string, error) {
+		prune, _ := strconv.ParseBool(req.Args["prune"])
+		dryRun, _ := strconv.ParseBool(req.Args["dry-run"])
+		timeout, err := time.ParseDuration(req.Args["timeout"])
+		if err != nil {
+			return "", fmt.Errorf("invalid timeout: %w", err)
+		}
+		report, err := utils.RunResync(ctx, cfg, req.Args["from"], prune, dryRun, timeout)
+		if err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(report)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	})
+
+	utils.RegisterControlHandler("rollback", func(req utils.ControlRequest) (string, error) {
+		report, err := utils.RunRollback(cfg, req.Args["target"], req.Args["head"])
+		if err != nil {
+			return "", err
+		}
+		// Tell the team why their files just changed
+		notice := utils.ChatMessage{
+			Message: fmt.Sprintf("rolled back %d commits (%s)", len(report.Reverted), shortCommit(report.Commit)),
+			Action:  true,
+		}
+		if err := sendChat(ctx, cfg, notice); err != nil {
+			log.Printf("[ROLLBACK] Failed to announce the rollback: %v", err)
+		}
+		data, err := json.Marshal(report)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	})
+
+	utils.RegisterControlHandler("reload-config", func(req utils.ControlRequest) (string, error) {
+		if err := reloadLocalSettings(cfg); err != nil {
+			return "", err
+		}
+		return "reloaded", nil
+	})
+
+	utils.RegisterControlHandler("team-status", func(req utils.ControlRequest) (string, error) {
+		status, err := gatherTeamStatus(ctx, cfg)
+		if err != nil {
+			return "", err
+		}
+		data, err := json.Marshal(status)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	})
+}
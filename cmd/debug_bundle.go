@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// redacted replaces secrets in the debug bundle
+const redacted = "[redacted]"
+
+var (
+	bundleOutput   string
+	bundleLedger   int
+	bundleLogLines int
+)
+
+// debugBundleCmd collects diagnostics for a bug report into one zip file
+var debugBundleCmd = &cobra.Command{
+	Use:   "debug-bundle",
+	Short: "Collect diagnostics for a bug report into a zip file",
+	Long: utils.RenderTitle("🧰 Debug Bundle") + `
+
+Collects what a bug report needs into a single zip file to attach to an
+issue: the local config and team settings with passwords, tokens and
+credentials redacted, the end of the daemon log and the conflict log, the
+daemon's last reported state, the newest entries of the team's batch
+stream (file names only, never contents), git status and recent commits,
+and the versions of Axle, Go, git and the OS.
+
+Chat history and the signing key are never included. Look through the
+bundle before sharing it; file names and usernames are in it.
+
+Examples:
+  axle debug-bundle                        # Writes axle-debug-<time>.zip here
+  axle debug-bundle -o /tmp/report.zip
+  axle debug-bundle --ledger 200 --log-lines 5000`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		localCfg, err := loadConfigFromFile()
+		if err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		output := bundleOutput
+		if output == "" {
+			output = fmt.Sprintf("axle-debug-%s.zip", time.Now().Format("20060102-150405"))
+		}
+		file, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", output, err)
+		}
+		defer file.Close()
+
+		fmt.Println(utils.RenderTitle("🧰 Debug Bundle"))
+		b := &debugBundle{zip: zip.NewWriter(file)}
+		b.addJSON("config.json", redactLocalConfig(localCfg))
+		b.add("environment.txt", []byte(environmentReport()))
+
+		// Redis may be what's broken; collect what's local regardless
+		online := true
+		if err := loadConfig(); err != nil {
+			b.problem("connect", err)
+			online = false
+			if err := loadLocalConfig(); err != nil {
+				b.problem("load config", err)
+			}
+		} else {
+			defer config.RedisClient.Close()
+		}
+
+		ctx := context.Background()
+		var teamConfig utils.AxleConfig
+		if online {
+			teamConfig, err = utils.GetTeamConfig(ctx, config.RedisClient, config.TeamID)
+		} else {
+			teamConfig, err = utils.LoadCachedTeamConfig(localCfg.RootDir)
+		}
+		if err != nil {
+			b.problem("team settings", err)
+		} else {
+			b.addJSON("team.json", redactTeamConfig(teamConfig))
+		}
+
+		if state, err := utils.ReadDaemonState(localCfg.RootDir); os.IsNotExist(err) {
+			b.problem("daemon state", fmt.Errorf("the daemon is not running"))
+		} else if err != nil {
+			b.problem("daemon state", err)
+		} else {
+			b.addJSON("daemon_state.json", state)
+		}
+		// Local bookkeeping that explains most "why didn't this sync" reports
+		for _, name := range []string{"skipped.json", "catchup.json", "placeholders.json", "conflicted.json", "line_endings.json"} {
+			if data, err := os.ReadFile(utils.AxlePath(localCfg.RootDir, name)); err == nil {
+				b.add("axle/"+name, data)
+			}
+		}
+
+		if data, err := utils.TailDaemonLog(localCfg.RootDir, bundleLogLines); os.IsNotExist(err) {
+			b.problem("daemon log", fmt.Errorf("none yet; 'axle start' keeps one in %s", utils.AxlePath(localCfg.RootDir, "daemon.log")))
+		} else if err != nil {
+			b.problem("daemon log", err)
+		} else {
+			b.add("daemon.log", data)
+		}
+		if data, err := os.ReadFile(utils.AxlePath(localCfg.RootDir, "conflicts.log")); err == nil {
+			b.add("conflicts.log", utils.TailLines(data, bundleLogLines))
+		}
+
+		if online && bundleLedger > 0 {
+			if entries, err := utils.RecentStreamEntries(ctx, config, bundleLedger); err != nil {
+				b.problem("batch stream", err)
+			} else {
+				b.addJSON("ledger.json", entries)
+			}
+		}
+
+		b.add("git.txt", []byte(gitReport(localCfg.RootDir)))
+
+		if len(b.problems) > 0 {
+			b.add("problems.txt", []byte(strings.Join(b.problems, "\n")+"\n"))
+		}
+		if err := b.zip.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", output, err)
+		}
+
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Wrote %s (%d files)", output, b.files)))
+		for _, problem := range b.problems {
+			fmt.Println(utils.RenderWarning("Not included: " + problem))
+		}
+		fmt.Println(utils.RenderInfo("Secrets are redacted, but look through the bundle before attaching it to an issue"))
+		return nil
+	},
+}
+
+// debugBundle writes the bundle's files, noting what couldn't be collected.
+type debugBundle struct {
+	zip      *zip.Writer
+	files    int
+	problems []string
+}
+
+func (b *debugBundle) add(name string, data []byte) {
+	w, err := b.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err == nil {
+		_, err = w.Write(data)
+	}
+	if err != nil {
+		b.problem(name, err)
+		return
+	}
+	b.files++
+}
+
+func (b *debugBundle) addJSON(name string, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		b.problem(name, err)
+		return
+	}
+	b.add(name, data)
+}
+
+func (b *debugBundle) problem(what string, err error) {
+	b.problems = append(b.problems, fmt.Sprintf("%s: %v", what, err))
+}
+
+// redactLocalConfig blanks the secrets in the local config.
+func redactLocalConfig(c LocalAppConfig) LocalAppConfig {
+	if c.RedisPassword != "" {
+		c.RedisPassword = redacted
+	}
+	if c.GuestToken != "" {
+		c.GuestToken = redacted
+	}
+	c.NATSURL = redactURLs(c.NATSURL)
+	c.Proxy = redactURLs(c.Proxy)
+	// Git environment values can hold keys and tokens; the names are enough
+	if len(c.GitEnv) > 0 {
+		env := make(map[string]string, len(c.GitEnv))
+		for name := range c.GitEnv {
+			env[name] = redacted
+		}
+		c.GitEnv = env
+	}
+	return c
+}
+
+// redactTeamConfig blanks the password hash and guest tokens in the team
+// settings.
+func redactTeamConfig(c utils.AxleConfig) utils.AxleConfig {
+	c.PasswordHash = redacted
+	c.Guests = append([]utils.GuestPass(nil), c.Guests...)
+	for i := range c.Guests {
+		if c.Guests[i].TokenHash != "" {
+			c.Guests[i].TokenHash = redacted
+		}
+	}
+	return c
+}
+
+// redactURLs drops the credentials from a comma-separated list of URLs.
+func redactURLs(raw string) string {
+	if !strings.Contains(raw, "@") {
+		return raw
+	}
+	urls := strings.Split(raw, ",")
+	for i, rawURL := range urls {
+		if u, err := url.Parse(strings.TrimSpace(rawURL)); err == nil && u.User != nil {
+			urls[i] = u.Redacted()
+		} else if err != nil {
+			urls[i] = redacted
+		}
+	}
+	return strings.Join(urls, ",")
+}
+
+// environmentReport describes the machine and the Axle build.
+func environmentReport() string {
+	var report strings.Builder
+	fmt.Fprintf(&report, "axle:     %s (%s)\n", Version, BuildDate)
+	fmt.Fprintf(&report, "protocol: %d\n", utils.ProtocolVersion)
+	fmt.Fprintf(&report, "go:       %s\n", runtime.Version())
+	fmt.Fprintf(&report, "os:       %s/%s, %d CPUs\n", runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+	if version, err := utils.GitVersion(); err == nil {
+		fmt.Fprintf(&report, "git:      %s (%s)\n", version, utils.GitPath())
+	} else {
+		fmt.Fprintf(&report, "git:      unavailable (%v); embedded backend\n", err)
+	}
+	if path, err := configFilePath(); err == nil {
+		fmt.Fprintf(&report, "config:   %s\n", path)
+	}
+
+	// Which AXLE_ variables are set matters; their values may be secrets
+	var names []string
+	for _, entry := range os.Environ() {
+		if name, _, _ := strings.Cut(entry, "="); strings.HasPrefix(name, "AXLE_") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		fmt.Fprintf(&report, "env:      %s\n", strings.Join(names, ", "))
+	}
+	return report.String()
+}
+
+// gitReport runs the git commands that show the repository's state.
+func gitReport(rootDir string) string {
+	if utils.UsingEmbeddedBackend() {
+		return "Git is unavailable; Axle is using its embedded backend\n"
+	}
+	var report strings.Builder
+	for _, args := range [][]string{
+		{"status", "--branch", "--porcelain=v1"},
+		{"log", "-20", "--format=%h %ci %an %s"},
+		{"stash", "list"},
+	} {
+		fmt.Fprintf(&report, "$ git %s\n", strings.Join(args, " "))
+		output, err := utils.GitCommand(append([]string{"-C", filepath.Clean(rootDir)}, args...)...).CombinedOutput()
+		report.Write(output)
+		if err != nil {
+			fmt.Fprintf(&report, "(%v)\n", err)
+		}
+		report.WriteString("\n")
+	}
+	return report.String()
+}
+
+func init() {
+	rootCmd.AddCommand(debugBundleCmd)
+	debugBundleCmd.Flags().StringVarP(&bundleOutput, "output", "o", "", "Where to write the zip file (default axle-debug-<time>.zip)")
+	debugBundleCmd.Flags().IntVar(&bundleLedger, "ledger", 50, "How many of the newest batch stream entries to include, 0 for none")
+	debugBundleCmd.Flags().IntVar(&bundleLogLines, "log-lines", 2000, "How many lines of the daemon and conflict logs to include")
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// doctorCmd checks the local environment Axle depends on
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the local setup for problems",
+	Long: utils.RenderTitle("🩺 Axle Doctor") + `
+
+Checks the things Axle needs from this machine and reports what is wrong:
+the local config file, the git executable Axle runs (and any other gits on
+PATH that might be picked up instead), the extra environment passed to git,
+the repository itself, and the proxy Redis connections go through.
+
+Without git Axle still runs, on an embedded backend: changed files are sent
+whole instead of as patches, incoming patches are applied only when they
+match exactly, and there is no local history, checkpoints or merging.
+
+Git can be configured in axle_config.json for machines with several gits
+or that need SSH or proxy settings:
+
+  "gitPath": "/opt/git/bin/git",
+  "gitEnv": {
+    "GIT_SSH_COMMAND": "ssh -i ~/.ssh/work_key",
+    "HTTPS_PROXY": "http://proxy.corp:8080"
+  }
+
+Redis connections use the proxy in "proxy" (http, https, socks5 or
+socks5h URLs; "direct" to ignore the environment), or else ALL_PROXY,
+HTTPS_PROXY or HTTP_PROXY. "noProxy" or NO_PROXY lists hosts to reach
+directly.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Println(utils.RenderTitle("🩺 Axle Doctor"))
+		var problems int
+		fail := func(message string) {
+			fmt.Println(utils.RenderError(message))
+			problems++
+		}
+
+		// Read the file directly so a bad setting is reported, not fatal
+		localCfg, err := loadConfigFromFile()
+		if err != nil {
+			fail(fmt.Sprintf("Config: %v", err))
+			return fmt.Errorf("doctor found %d problem(s)", problems)
+		}
+		configPath, _ := configFilePath()
+		fmt.Println(utils.RenderSuccess("Config: " + configPath))
+
+		// Git environment
+		if err := utils.ValidateGitEnv(localCfg.GitEnv); err != nil {
+			fail(fmt.Sprintf("Git environment: %v", err))
+		} else {
+			utils.SetGitConfig(localCfg.GitPath, localCfg.GitEnv)
+			if len(localCfg.GitEnv) > 0 {
+				names := make([]string, 0, len(localCfg.GitEnv))
+				for name := range localCfg.GitEnv {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				fmt.Println(utils.RenderSuccess("Git environment: " + strings.Join(names, ", ")))
+			}
+		}
+		for _, name := range inheritedGitVars() {
+			fmt.Println(utils.RenderWarning(fmt.Sprintf("%s is set in your shell; Axle ignores it for its own git commands", name)))
+		}
+
+		// Git executable
+		var gitOK bool
+		resolved, err := exec.LookPath(utils.GitPath())
+		if err != nil {
+			if localCfg.GitPath != "" {
+				fail(fmt.Sprintf("Git: gitPath %s is not an executable: %v", localCfg.GitPath, err))
+			} else {
+				// Not fatal: Axle falls back to its embedded backend
+				fmt.Println(utils.RenderWarning("Git: git CLI not found, using embedded backend"))
+				fmt.Println("   Files sync whole, with no local history, checkpoints or conflict merging;")
+				fmt.Println("   install git or set gitPath in " + ConfigFileName + " for the full feature set")
+			}
+		} else if version, err := utils.GitVersion(); err != nil {
+			fail(fmt.Sprintf("Git: %v", err))
+		} else {
+			source := "from PATH"
+			if localCfg.GitPath != "" {
+				source = "from gitPath"
+			}
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("Git: %s %s (%s)", resolved, version, source)))
+			gitOK = true
+		}
+		if err := utils.SetGitBackend(localCfg.GitBackend); err != nil {
+			fail(fmt.Sprintf("Git backend: %v", err))
+		} else if utils.GitBackend() == utils.GitBackendExec {
+			fmt.Println(utils.RenderInfo("Git backend: exec (git for every operation)"))
+		}
+		if gits := gitsOnPath(); len(gits) > 1 {
+			fmt.Println(utils.RenderInfo(fmt.Sprintf("%d gits on PATH: %s", len(gits), strings.Join(gits, ", "))))
+			if localCfg.GitPath == "" {
+				fmt.Println("   Axle uses the first; set gitPath in " + ConfigFileName + " to pick another")
+			}
+		}
+
+		// Repository, which needs a working git to check
+		if gitOK {
+			if output, err := utils.GitCommand("-C", localCfg.RootDir, "rev-parse", "--show-toplevel").Output(); err != nil {
+				fail(fmt.Sprintf("Repository: %s is not a git repository", localCfg.RootDir))
+			} else {
+				fmt.Println(utils.RenderSuccess("Repository: " + strings.TrimSpace(string(output))))
+			}
+			for _, key := range []string{"user.name", "user.email"} {
+				if output, err := utils.GitCommand("-C", localCfg.RootDir, "config", key).Output(); err != nil || strings.TrimSpace(string(output)) == "" {
+					fmt.Println(utils.RenderWarning(fmt.Sprintf("git %s is not set; commits may fail", key)))
+				}
+			}
+		}
+
+		// Proxy for Redis
+		if err := utils.SetProxy(localCfg.Proxy, localCfg.NoProxy); err != nil {
+			fail(fmt.Sprintf("Proxy: %v", err))
+		} else if proxyURL, source, err := utils.ProxyFor(localCfg.redisEndpoints()[0].Addr); err != nil {
+			fail(fmt.Sprintf("Proxy: %v", err))
+		} else if proxyURL != nil {
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("Proxy: Redis is reached through %s (%s)", proxyURL.Redacted(), source)))
+		}
+
+		fmt.Println()
+		if problems > 0 {
+			return fmt.Errorf("doctor found %d problem(s)", problems)
+		}
+		fmt.Println(utils.RenderSuccess("No problems found"))
+		return nil
+	},
+}
+
+// inheritedGitVars returns the repository-redirecting git variables set in
+// this process's environment.
+func inheritedGitVars() []string {
+	var names []string
+	for _, name := range []string{"GIT_DIR", "GIT_WORK_TREE", "GIT_INDEX_FILE"} {
+		if _, ok := os.LookupEnv(name); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// gitsOnPath lists every git executable on PATH, in lookup order.
+func gitsOnPath() []string {
+	name := "git"
+	if runtime.GOOS == "windows" {
+		name = "git.exe"
+	}
+	var found []string
+	seen := make(map[string]bool)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			path = resolved
+		}
+		if !seen[path] {
+			seen[path] = true
+			found = append(found, path)
+		}
+	}
+	return found
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
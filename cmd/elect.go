@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	electRule  string
+	electWait  time.Duration
+	electApply bool
+	electPlan  bool
+)
+
+// electCmd audits the team for divergence and elects a reference state
+var electCmd = &cobra.Command{
+	Use:   "elect",
+	Short: "Check whether teammates' trees agree and elect a reference state",
+	Long: utils.RenderTitle("🗳️  Reference State Election") + `
+
+Asks every online node for its state (HEAD, its tree and how much of the
+team's batch stream it has handled) and groups nodes with identical trees.
+When they disagree, one state is elected as the team's reference:
+
+  ledger    The state that has handled the team's batch stream the furthest
+            wins, then the most recent commit (default)
+  priority  The authoritative node's state wins, then the team's peer
+            priority order (set with 'axle init --peer-priority')
+
+Every other node gets a repair plan. By default this is a dry run that only
+prints the report. With --apply the reference is recorded and diverged
+nodes are notified; each of them runs 'axle elect --plan' to see its plan,
+which comes down to 'axle reset --from <reference>'.
+
+Examples:
+  axle elect                     # Dry run: report and plans
+  axle elect --rule priority     # Let the admin's state win
+  axle elect --apply             # Record the reference and notify the team
+  axle elect --plan              # Show this node's plan from the last election`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := utils.ValidateElectionRule(electRule); err != nil {
+			return err
+		}
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+		ctx := context.Background()
+
+		if electPlan {
+			return printElectionPlan(ctx)
+		}
+
+		fmt.Println(utils.RenderTitle("🗳️  Reference State Election"))
+		fmt.Printf("Asking online teammates for their state (waiting %v)...\n", electWait)
+		versions, err := utils.RunDivergenceAudit(ctx, config, electWait)
+		if err != nil {
+			return err
+		}
+
+		priority := utils.AxleConfig{PeerPriority: config.PeerPriority, AuthoritativeNode: config.AuthoritativeNode}.EffectivePriority()
+		election, err := utils.ElectReference(versions, electRule, priority)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println()
+		for i, group := range election.Groups {
+			label := fmt.Sprintf("State %d (tree %s)", i+1, shortHash(group.Tree))
+			if i == 0 && election.Diverged() {
+				label += " - reference"
+			}
+			fmt.Println(utils.RenderInfo(label))
+			for _, node := range group.Nodes {
+				fmt.Printf("  %-20s HEAD %s  stream %-16s %s\n", node.Username, shortHash(node.Head), orNone(node.LedgerID), dirtyNote(node))
+			}
+		}
+		fmt.Println()
+
+		if !election.Diverged() {
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("All %d node(s) agree; nothing to repair", len(versions))))
+			return nil
+		}
+
+		fmt.Println(utils.RenderWarning(fmt.Sprintf("The team has diverged into %d states", len(election.Groups))))
+		fmt.Printf("Reference: %s's state (%s rule), because %s\n\n", election.Reference.Username, election.Rule, election.Reason)
+		for _, plan := range election.Repairs {
+			printRepairPlan(plan)
+		}
+
+		if !electApply {
+			fmt.Println(utils.RenderInfo("Dry run: nothing was changed. Run 'axle elect --apply' to record this reference and notify the team"))
+			return nil
+		}
+		if err := utils.PublishElection(ctx, config, election); err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Recorded %s's state as the reference and notified the team", election.Reference.Username)))
+		return nil
+	},
+}
+
+// printElectionPlan shows this node's part of the last recorded election
+func printElectionPlan(ctx context.Context) error {
+	election, err := utils.LoadElection(ctx, config)
+	if err != nil {
+		return err
+	}
+	if election == nil {
+		fmt.Println(utils.RenderInfo("No election is recorded; run 'axle elect' to check the team"))
+		return nil
+	}
+
+	fmt.Println(utils.RenderTitle("🗳️  Repair Plan"))
+	fmt.Printf("%s elected %s's state (tree %s) %s\n\n", election.ElectedBy, election.Reference.Username,
+		shortHash(election.Reference.Tree), formatTime(time.Unix(election.ElectedAt, 0)))
+
+	local, err := utils.LocalNodeVersion(ctx, config)
+	if err != nil {
+		return err
+	}
+	if local.Tree == election.Reference.Tree {
+		fmt.Println(utils.RenderSuccess("Your tree matches the reference; nothing to do"))
+		return nil
+	}
+	for _, plan := range election.Repairs {
+		if plan.NodeID == config.NodeID {
+			printRepairPlan(plan)
+			return nil
+		}
+	}
+	// Not audited then, or changed since: the reset is the same either way
+	printRepairPlan(utils.RepairPlanFor(local, election.Reference))
+	return nil
+}
+
+func printRepairPlan(plan utils.RepairPlan) {
+	fmt.Printf("Plan for %s:\n", plan.Username)
+	for i, step := range plan.Steps {
+		fmt.Printf("  %d. %s\n", i+1, step)
+	}
+	fmt.Println()
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+func dirtyNote(node utils.NodeVersion) string {
+	if node.Dirty {
+		return "(uncommitted changes)"
+	}
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(electCmd)
+	electCmd.Flags().StringVar(&electRule, "rule", utils.ElectByLedger, "How to pick the reference: ledger or priority")
+	electCmd.Flags().DurationVar(&electWait, "wait", 3*time.Second, "How long to wait for teammates to report")
+	electCmd.Flags().BoolVar(&electApply, "apply", false, "Record the reference and notify diverged nodes")
+	electCmd.Flags().BoolVar(&electPlan, "plan", false, "Show this node's repair plan from the last election")
+}
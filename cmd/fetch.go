@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fetchList    bool
+	fetchTimeout time.Duration
+)
+
+// fetchCmd represents the fetch command
+var fetchCmd = &cobra.Command{
+	Use:   "fetch [path]...",
+	Short: "Download large files shared by teammates as placeholders",
+	Long: utils.RenderTitle("📥 Fetch Large Files") + `
+
+Files over the sync size limit are not pushed automatically. Instead their
+owner shares a placeholder (name, size, hash). Use this command to see
+which placeholders you know about and to download a file on demand from
+its owner, who must be running 'axle start'.
+
+Examples:
+  axle fetch --list
+  axle fetch assets/demo-video.mp4`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		if fetchList || len(args) == 0 {
+			placeholders, err := utils.ListPlaceholders(config.RootDir)
+			if err != nil {
+				return err
+			}
+			if len(placeholders) == 0 {
+				fmt.Println(utils.RenderInfo("No large-file placeholders received"))
+				return nil
+			}
+
+			fmt.Println(utils.RenderTitle("📥 Available Large Files"))
+			for _, placeholder := range placeholders {
+				fmt.Printf("  %-50s %10s  from %s\n", placeholder.Path, formatFileSize(placeholder.Size), placeholder.Owner)
+			}
+			return nil
+		}
+
+		placeholders, err := utils.LoadPlaceholders(config.RootDir)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		for _, path := range args {
+			relPath, err := rootRelPath(path)
+			if err != nil {
+				return err
+			}
+			placeholder, ok := placeholders[relPath]
+			if !ok {
+				return fmt.Errorf("no placeholder known for %s (see 'axle fetch --list')", path)
+			}
+
+			fmt.Printf("Fetching %s (%s) from %s... ", placeholder.Path, formatFileSize(placeholder.Size), placeholder.Owner)
+			if err := utils.FetchFile(ctx, config, placeholder, fetchTimeout); err != nil {
+				fmt.Println(utils.RenderError(utils.T("common.failed")))
+				return err
+			}
+			fmt.Println(utils.RenderSuccess(utils.T("common.done")))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fetchCmd)
+	fetchCmd.Flags().BoolVarP(&fetchList, "list", "l", false, "List available large-file placeholders")
+	fetchCmd.Flags().DurationVar(&fetchTimeout, "timeout", 2*time.Minute, "How long to wait for the owner to upload the file")
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// forceSyncCmd pushes a file the watcher skipped
+var forceSyncCmd = &cobra.Command{
+	Use:   "force-sync <path>",
+	Short: "Push a file in full, even if it is over the size limit or binary",
+	Long: utils.RenderTitle("📦 Force Sync") + `
+
+Files over the size limit are only shared as placeholders, and binary files
+are skipped. This command sends one such file in full through the chunk
+store, so teammates receive the real content with their next batch.
+
+The path is relative to the current directory. 'axle start' must be running.
+See 'axle status --skipped' for the files that were skipped.
+
+Examples:
+  axle force-sync assets/logo.png
+  axle force-sync data/dataset.csv`,
+
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		relPath, err := rootRelPath(args[0])
+		if err != nil {
+			return err
+		}
+		resp, ok, err := askDaemon("force-sync", map[string]string{"path": relPath})
+		if !ok {
+			return fmt.Errorf("the sync daemon is not running; start it with 'axle start' and try again")
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Queued %s for syncing (trace %s)", relPath, resp.Message)))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(forceSyncCmd)
+}
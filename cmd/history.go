@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyAcks   bool
+	historyTraces bool
+	historyLimit  int
+
+	historyTimeline bool
+	historySince    string
+	historyAuthor   string
+	historyFile     string
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show published batches, or the sync timeline of this repository",
+	Long: utils.RenderTitle("📜 Sync History") + `
+
+Lists the batches this member published recently. With --acks, shows the
+delivery state of each batch: which peers applied it, which failed (with
+the error), which are holding it for confirmation, and who hasn't seen it
+yet. With --traces, lists the correlation IDs of the batch's changes for
+'axle trace'.
+
+With --timeline, walks the repository's commits instead and shows which
+peer synced which files and when, with the size of each batch and the
+conflicts this node hit. It needs no connection to Redis. --since, --author
+and --file narrow the timeline and imply it.
+
+Examples:
+  axle history
+  axle history --acks -n 5
+  axle history --traces
+  axle history --timeline
+  axle history --since 2h --author alice
+  axle history --since 2024-05-01 --file src/`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if historyTimeline || historySince != "" || historyAuthor != "" || historyFile != "" {
+			return showSyncTimeline()
+		}
+
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		records, err := utils.ListSentBatches(ctx, config, config.Username, historyLimit)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(utils.RenderTitle("📜 Published Batches"))
+		if len(records) == 0 {
+			fmt.Println(utils.RenderInfo("No published batches recorded yet"))
+			return nil
+		}
+
+		var peers []string
+		online := make(map[string]bool)
+		if historyAcks {
+			members, _ := utils.GetMembers(ctx, config.RedisClient, config.TeamID)
+			for _, member := range members {
+				if member != config.Username {
+					peers = append(peers, member)
+				}
+			}
+			if presenceList, err := utils.GetTeamPresence(ctx, config); err == nil {
+				for _, presence := range presenceList {
+					if presence.Status == "online" {
+						online[presence.Username] = true
+					}
+				}
+			}
+		}
+
+		for _, record := range records {
+			fmt.Printf("%s  %s  %d files: %s\n",
+				time.Unix(record.Timestamp, 0).Format("Jan 02 15:04:05"), record.BatchID,
+				len(record.Files), truncateString(strings.Join(record.Files, ", "), 60))
+
+			if historyTraces && len(record.TraceIDs) > 0 {
+				fmt.Printf("    traces: %s\n", strings.Join(record.TraceIDs, " "))
+			}
+
+			if historyAcks {
+				printBatchAcks(ctx, record, peers, online)
+			}
+		}
+		return nil
+	},
+}
+
+// showSyncTimeline prints the commits and conflicts on the local timeline
+func showSyncTimeline() error {
+	if err := loadLocalConfig(); err != nil {
+		return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+	}
+
+	filter := utils.TimelineFilter{Author: historyAuthor, Limit: historyLimit}
+	if historySince != "" {
+		since, err := parseSince(historySince)
+		if err != nil {
+			return err
+		}
+		filter.Since = since
+	}
+	if historyFile != "" {
+		filter.File = rootRelativePath(historyFile)
+	}
+
+	entries, err := utils.SyncTimeline(config.RootDir, filter)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(utils.RenderTitle("📜 Sync Timeline"))
+	if len(entries) == 0 {
+		fmt.Println(utils.RenderInfo("No sync activity matches"))
+		return nil
+	}
+	for _, entry := range entries {
+		when := entry.Time.Local().Format("Jan 02 15:04:05")
+		files := truncateString(strings.Join(entry.Files, ", "), 60)
+		switch entry.Kind {
+		case utils.TimelineConflict:
+			peer := entry.Peer
+			if peer == "" {
+				peer = "-"
+			}
+			fmt.Printf("%s  ⚔️  %-16s %s: %s\n", when, peer, utils.RenderWarning(entry.Subject), files)
+		case utils.TimelineReceived:
+			fmt.Printf("%s  ⬇️  %-16s %s  %s\n", when, entry.Peer, pluralFiles(len(entry.Files)), files)
+			// The peer's clock disagrees with ours; say when they made it by theirs
+			if skew := utils.ClockSkew(entry.SentAt.Unix(), entry.Time.Unix()); skew != 0 {
+				fmt.Printf("    made %s by %s's clock\n", entry.SentAt.Local().Format("Jan 02 15:04:05"), entry.Peer)
+			}
+		default:
+			fmt.Printf("%s  ⬆️  %-16s %s  %s\n", when, entry.Peer, pluralFiles(len(entry.Files)), files)
+		}
+	}
+	return nil
+}
+
+// parseSince accepts a duration back from now ("90m", "2h") or a date
+// ("2006-01-02", RFC 3339).
+func parseSince(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration like 2h or a date like 2006-01-02", value)
+}
+
+// rootRelativePath resolves a path given from inside the sync root to one
+// relative to it. Anything else is taken as relative to the root already.
+func rootRelativePath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	cwd, _ := os.Getwd()
+	rel, err := filepath.Rel(config.RootDir, abs)
+	if err != nil || strings.HasPrefix(rel, "..") || !strings.HasPrefix(cwd, config.RootDir) {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func pluralFiles(n int) string {
+	if n == 1 {
+		return "1 file "
+	}
+	return fmt.Sprintf("%d files", n)
+}
+
+// printBatchAcks prints the delivery state of a batch for every known peer
+func printBatchAcks(ctx context.Context, record utils.BatchRecord, peers []string, online map[string]bool) {
+	acks, err := utils.GetAcks(ctx, config, record.BatchID)
+	if err != nil {
+		fmt.Printf("    %s\n", utils.RenderError(err.Error()))
+		return
+	}
+
+	// Include peers that acknowledged but are no longer registered
+	seen := make(map[string]bool)
+	for _, peer := range peers {
+		seen[peer] = true
+	}
+	for peer := range acks {
+		if !seen[peer] && peer != config.Username {
+			peers = append(peers, peer)
+		}
+	}
+	sort.Strings(peers)
+
+	for _, peer := range peers {
+		ack, ok := acks[peer]
+		switch {
+		case ok && ack.Status == utils.AckApplied:
+			fmt.Printf("    ✅ %-16s applied %s\n", peer, formatTime(time.Unix(ack.Timestamp, 0)))
+		case ok && ack.Status == utils.AckFailed:
+			fmt.Printf("    ❌ %-16s failed: %s\n", peer, truncateString(ack.Error, 80))
+		case ok && ack.Status == utils.AckHeld:
+			fmt.Printf("    🛡️  %-16s holding for confirmation\n", peer)
+		case ok && ack.Status == utils.AckQuarantined:
+			fmt.Printf("    ☣️  %-16s quarantined: %s\n", peer, truncateString(ack.Error, 80))
+		case online[peer]:
+			fmt.Printf("    ⏳ %-16s not seen yet\n", peer)
+		default:
+			fmt.Printf("    💤 %-16s offline\n", peer)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().BoolVar(&historyAcks, "acks", false, "Show per-peer delivery state for each batch")
+	historyCmd.Flags().BoolVar(&historyTraces, "traces", false, "Show the correlation IDs of each batch's changes")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of batches or timeline entries to show")
+	historyCmd.Flags().BoolVar(&historyTimeline, "timeline", false, "Show the sync timeline from the local git history")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only show the timeline since a duration ago (2h) or a date (2006-01-02)")
+	historyCmd.Flags().StringVar(&historyAuthor, "author", "", "Only show the timeline entries of peers whose name contains this")
+	historyCmd.Flags().StringVar(&historyFile, "file", "", "Only show the timeline entries touching this file or directory")
+}
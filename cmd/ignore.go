@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// ignoreCmd manages the ignorePatterns in axle_config.json
+var ignoreCmd = &cobra.Command{
+	Use:   "ignore",
+	Short: "Manage the patterns of files Axle doesn't sync",
+	Long: utils.RenderTitle("🙈 Ignore Patterns") + `
+
+Adds, removes and lists the ignorePatterns in axle_config.json. A running
+daemon picks up the new patterns right away; there's no need to restart it.
+
+Patterns are globs matched against file and directory names ("*.log",
+"node_modules"), not paths. For path rules, use a .gitignore file, which
+Axle also honors.
+
+Examples:
+  axle ignore add "*.log" dist
+  axle ignore remove dist
+  axle ignore list`,
+}
+
+var ignoreAddCmd = &cobra.Command{
+	Use:   "add <pattern>...",
+	Short: "Add ignore patterns",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		for _, pattern := range args {
+			if err := validateIgnorePattern(pattern); err != nil {
+				return err
+			}
+		}
+
+		var added []string
+		if err := updateConfigFile(func(localCfg *LocalAppConfig) {
+			for _, pattern := range args {
+				if !containsString(localCfg.IgnorePatterns, pattern) {
+					localCfg.IgnorePatterns = append(localCfg.IgnorePatterns, pattern)
+					added = append(added, pattern)
+				}
+			}
+		}); err != nil {
+			return fmt.Errorf("failed to save ignore patterns: %w", err)
+		}
+
+		if len(added) == 0 {
+			fmt.Println(utils.RenderInfo("Already ignored; nothing changed"))
+			return nil
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Ignoring %s", strings.Join(added, ", "))))
+		return reloadIgnorePatterns()
+	},
+}
+
+var ignoreRemoveCmd = &cobra.Command{
+	Use:   "remove <pattern>...",
+	Short: "Remove ignore patterns",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		for _, pattern := range args {
+			if pattern == ".git" || pattern == ConfigFileName {
+				return fmt.Errorf("%s is always ignored", pattern)
+			}
+		}
+
+		var removed []string
+		if err := updateConfigFile(func(localCfg *LocalAppConfig) {
+			kept := localCfg.IgnorePatterns[:0]
+			for _, pattern := range localCfg.IgnorePatterns {
+				if containsString(args, pattern) {
+					removed = append(removed, pattern)
+					continue
+				}
+				kept = append(kept, pattern)
+			}
+			localCfg.IgnorePatterns = kept
+		}); err != nil {
+			return fmt.Errorf("failed to save ignore patterns: %w", err)
+		}
+
+		for _, pattern := range args {
+			if !containsString(removed, pattern) {
+				fmt.Println(utils.RenderWarning(fmt.Sprintf("%s is not an ignore pattern", pattern)))
+			}
+		}
+		if len(removed) == 0 {
+			return nil
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("No longer ignoring %s", strings.Join(removed, ", "))))
+		return reloadIgnorePatterns()
+	},
+}
+
+var ignoreListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List ignore patterns",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		if len(config.IgnorePatterns) == 0 {
+			fmt.Println(utils.RenderInfo("No ignore patterns"))
+			return nil
+		}
+		fmt.Println(utils.RenderTitle("🙈 Ignore Patterns"))
+		for _, pattern := range config.IgnorePatterns {
+			fmt.Printf("  %s\n", pattern)
+		}
+		return nil
+	},
+}
+
+// validateIgnorePattern rejects patterns the watcher could never match.
+func validateIgnorePattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("ignore patterns can't be empty")
+	}
+	if strings.Contains(pattern, "/") {
+		return fmt.Errorf("%q contains a path separator; ignore patterns match names only, use .gitignore for paths", pattern)
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+// reloadIgnorePatterns tells a running daemon to reread the ignore patterns.
+func reloadIgnorePatterns() error {
+	_, ok, err := askDaemon("reload-config", nil)
+	if !ok {
+		fmt.Println(utils.RenderInfo("The daemon isn't running; the patterns apply from the next 'axle start'"))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reload the daemon's ignore patterns: %w", err)
+	}
+	fmt.Println(utils.RenderInfo("The running daemon reloaded its ignore patterns"))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(ignoreCmd)
+	ignoreCmd.AddCommand(ignoreAddCmd)
+	ignoreCmd.AddCommand(ignoreRemoveCmd)
+	ignoreCmd.AddCommand(ignoreListCmd)
+}
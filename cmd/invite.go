@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+)
+
+var (
+	inviteGuest bool
+	inviteTTL   time.Duration
+	inviteName  string
+	invitePaths []string
+	inviteShare bool
+)
+
+// inviteCmd prints how someone joins the team, or issues a guest pass
+var inviteCmd = &cobra.Command{
+	Use:   "invite",
+	Short: "Invite someone to the team, or give a guest temporary access",
+	Long: utils.RenderTitle("✉️  Invite") + `
+
+Creates a single-use invite token for a new member and prints the command
+they join with. The invite carries the team password, sealed so that only
+the token opens it, so the password never has to go through chat; it is
+used up by the first join and expires after --ttl. With --name only that
+username can use it. Creating one asks for the team password (or reads
+AXLE_PASSWORD). With --share-password the command is printed for sharing
+the password yourself instead.
+
+With --guest, the team admin issues a guest pass instead: a token that lets
+someone such as a mentor join without the password until it expires. Guests
+receive the team's changes but their own changes don't sync, except to the
+paths given with --paths. They're marked as guests in 'axle team', and when
+the pass expires their daemon stops, their token is revoked and they leave
+the member list.
+
+Examples:
+  axle invite                                  # Single-use member invite, valid 3 hours
+  axle invite --name dana --ttl 24h            # Only dana can use it, for a day
+  axle invite --guest --ttl 3h                 # Read-only guest for 3 hours
+  axle invite --guest --name mentor --paths "docs/**" --ttl 90m`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+		defer config.Transport.Close()
+
+		if inviteTTL <= 0 {
+			return fmt.Errorf("--ttl must be positive")
+		}
+		if !inviteGuest {
+			fmt.Println(utils.RenderTitle("✉️  Invite"))
+			// Invites live in Redis; teams on NATS have none
+			if inviteShare || teamTransport.name == utils.TransportNATS {
+				fmt.Println("Share the team password, then have them run:")
+				fmt.Printf("  axle join --team %s --username <name> %s\n", config.TeamID, joinConnectionFlags())
+				return nil
+			}
+			return createMemberInvite()
+		}
+
+		ctx := context.Background()
+		teamConfig, err := utils.GetVerifiedTeamConfig(ctx, config.RedisClient, config.TeamID, config.TeamAdminKey)
+		if err != nil {
+			return err
+		}
+		pass, token, err := utils.NewGuestPass(inviteName, invitePaths, inviteTTL, config.Username)
+		if err != nil {
+			return err
+		}
+		members, err := utils.GetMembers(ctx, config.RedisClient, config.TeamID)
+		if err == nil && containsString(members, pass.Name) {
+			if _, ok := teamConfig.Guest(pass.Name); !ok {
+				return fmt.Errorf("%s is already a team member; choose another name for the guest", pass.Name)
+			}
+		}
+		guests := []utils.GuestPass{pass}
+		for _, guest := range teamConfig.Guests {
+			if guest.Name != pass.Name {
+				guests = append(guests, guest)
+			}
+		}
+		teamConfig.Guests = guests
+		if err := utils.SaveTeamConfig(ctx, config.RedisClient, teamConfig); err != nil {
+			if errors.Is(err, utils.ErrNotTeamAdmin) {
+				return fmt.Errorf("only the team admin can invite guests: %w", err)
+			}
+			return err
+		}
+
+		access := "read-only"
+		if len(invitePaths) > 0 {
+			access = "can change " + strings.Join(invitePaths, ", ")
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Guest pass for %s (%s), expires %s",
+			pass.Name, access, time.Unix(pass.ExpiresAt, 0).Format("Jan 2 15:04"))))
+		fmt.Println("Send the guest this command; the token is shown only once:")
+		fmt.Printf("  axle join --team %s --username %s --guest-token %s %s\n", config.TeamID, pass.Name, token, joinConnectionFlags())
+		fmt.Println(utils.RenderInfo("Running daemons learn about the guest within a minute"))
+		return nil
+	},
+}
+
+// createMemberInvite stores a single-use member invite and prints how to use it
+func createMemberInvite() error {
+	ctx := context.Background()
+	teamConfig, err := utils.GetVerifiedTeamConfig(ctx, config.RedisClient, config.TeamID, config.TeamAdminKey)
+	if err != nil {
+		return err
+	}
+
+	// The invite carries the password, so it has to be the right one
+	password := os.Getenv("AXLE_PASSWORD")
+	if password == "" {
+		fmt.Print(utils.T("prompt.team_password"))
+		bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+		if err != nil {
+			return fmt.Errorf("failed to read password: %w", err)
+		}
+		password = string(bytePassword)
+		fmt.Println()
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(teamConfig.PasswordHash), []byte(password)); err != nil {
+		return errors.New(utils.T("error.invalid_password"))
+	}
+
+	token, expiresAt, err := utils.CreateInvite(ctx, config.RedisClient, config.TeamID, password, config.Username, inviteName, inviteTTL)
+	if err != nil {
+		return err
+	}
+
+	name := "<name>"
+	if inviteName != "" {
+		name = inviteName
+	}
+	fmt.Println(utils.RenderSuccess(fmt.Sprintf("Single-use invite, expires %s", expiresAt.Format("Jan 2 15:04"))))
+	fmt.Println("Send them this command; the token is shown only once and works for one join:")
+	fmt.Printf("  axle join --team %s --username %s --token %s %s\n", config.TeamID, name, token, joinConnectionFlags())
+	return nil
+}
+
+// joinConnectionFlags returns the 'axle join' flags that reach the team's
+// server, leaving out any login.
+func joinConnectionFlags() string {
+	if teamTransport.name == utils.TransportNATS {
+		return "--nats-url <NATS server URL>"
+	}
+	if utils.CurrentRedisHA().Enabled() {
+		return "<your Redis Sentinel or Cluster flags>"
+	}
+	host, port, err := net.SplitHostPort(config.RedisAddr)
+	if err != nil {
+		return "--host <Redis host> --port <Redis port>"
+	}
+	return fmt.Sprintf("--host %s --port %s", host, port)
+}
+
+func init() {
+	rootCmd.AddCommand(inviteCmd)
+	inviteCmd.Flags().BoolVar(&inviteGuest, "guest", false, "Issue a temporary guest pass instead")
+	inviteCmd.Flags().DurationVar(&inviteTTL, "ttl", 3*time.Hour, "How long the invite or guest pass lasts")
+	inviteCmd.Flags().StringVar(&inviteName, "name", "", "Username the invite is for; for guests the default is a generated one")
+	inviteCmd.Flags().BoolVar(&inviteShare, "share-password", false, "Print the join command for sharing the password yourself instead")
+	inviteCmd.Flags().StringSliceVar(&invitePaths, "paths", nil, "Globs the guest may change; read-only without")
+}
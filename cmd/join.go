@@ -3,17 +3,29 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"syscall"
+	"time"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/parzi-val/axle-file-sync/utils"
 	"github.com/spf13/cobra"
 	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/term"
 )
 
+var (
+	joinNoBootstrap      bool
+	joinBootstrapTimeout time.Duration
+	joinGuestToken       string
+	joinInviteToken      string
+	// Who sent the invite a member joined with
+	joinInvitedBy string
+)
+
 // joinCmd represents the join command
 var joinCmd = &cobra.Command{
 	Use:   "join",
@@ -21,17 +33,42 @@ var joinCmd = &cobra.Command{
 	Long: utils.RenderTitle("🤝 Join Axle Team") + `
 
 Joins an existing Axle team by creating a local configuration file.
-You will be prompted for the team password.`,
+You will be prompted for the team password. With --bare-hub the node
+joins as the team's headless, always-on hub (see 'axle init --help').
+
+A new member starts from the team's current files: an online teammate
+serves a snapshot of the repository (the authoritative node first), or,
+when nobody answers, the snapshot daemons keep in Redis is used. Batches
+published since that snapshot are replayed by catch-up on 'axle start'.
+This only happens when the directory holds no work of its own; otherwise
+run 'axle reset' to take the team's state.
+
+New members can join with the token from 'axle invite' instead of typing
+the team password: the invite carries the password, sealed so only the
+token opens it, and works once. Guests join with the token from
+'axle invite --guest' instead, under the username the invite names.`,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Validate required flags
 		if teamID == "" || username == "" {
-			return fmt.Errorf("both --team and --username flags are required")
+			return errors.New(utils.T("error.flags_required"))
+		}
+		if err := checkNotInsideAxleRoot(); err != nil {
+			return err
+		}
+		if joinGuestToken != "" && bareHub {
+			return fmt.Errorf("a guest can't be the team hub")
+		}
+		if joinInviteToken != "" && (joinGuestToken != "" || password != "") {
+			return fmt.Errorf("--token can't be combined with --guest-token or --password")
+		}
+		if joinInviteToken != "" && natsURL != "" {
+			return fmt.Errorf("invites are kept in Redis; teams on NATS join with the password")
 		}
 
-		// Prompt for password if not provided as flag
-		if password == "" {
-			fmt.Print("Enter the team password: ")
+		// Prompt for password if not provided as flag; invitees and guests have a token instead
+		if password == "" && joinGuestToken == "" && joinInviteToken == "" {
+			fmt.Print(utils.T("prompt.team_password"))
 			bytePassword, err := term.ReadPassword(int(syscall.Stdin))
 			if err != nil {
 				return fmt.Errorf("failed to read password: %w", err)
@@ -40,7 +77,12 @@ You will be prompted for the team password.`,
 			fmt.Println()
 		}
 
-		fmt.Println(utils.RenderTitle("🤝 Joining Axle Team"))
+		redisAuth, redisHA, err := resolveRedisFlags(cmd)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(utils.RenderTitle(utils.T("join.title")))
 
 		// Get current working directory
 		rootDir, err := os.Getwd()
@@ -48,96 +90,180 @@ You will be prompted for the team password.`,
 			return fmt.Errorf("failed to get current working directory: %w", err)
 		}
 
+		// Set once this member is registered; a redeemed invite is put back otherwise
+		joined := false
+
 		// Connect to Redis
-		fmt.Print("Connecting to Redis... ")
+		fmt.Print(utils.T("join.step.redis"))
 		redisAddr := fmt.Sprintf("%s:%d", redisHost, redisPort)
-		redisClient, err := utils.NewRedisClient(redisAddr)
+		redisClient, transport, err := connectTeam(redisAddr)
 		if err != nil {
-			fmt.Println(utils.RenderError("failed"))
-			return fmt.Errorf("failed to connect to Redis: %w", err)
+			fmt.Println(utils.RenderError(utils.T("common.failed")))
+			return err
 		}
 		defer redisClient.Close()
-		fmt.Println(utils.RenderSuccess("done"))
+		defer transport.Close()
+		fmt.Println(utils.RenderSuccess(utils.T("common.done")))
 
 		// Fetch team config from Redis
-		fmt.Print("Fetching team configuration... ")
-		teamConfigKey := fmt.Sprintf("axle:config:%s", teamID)
-		teamConfigData, err := redisClient.Get(context.Background(), teamConfigKey).Bytes()
+		fmt.Print(utils.T("join.step.team_config"))
+		teamConfig, err := utils.GetVerifiedTeamConfig(context.Background(), redisClient, teamID, "")
 		if err != nil {
-			fmt.Println(utils.RenderError("failed"))
-			return fmt.Errorf("failed to fetch team config from Redis: %w. Make sure the team exists and the team ID is correct.", err)
-		}
-
-		var teamConfig utils.AxleConfig
-		if err := json.Unmarshal(teamConfigData, &teamConfig); err != nil {
-			fmt.Println(utils.RenderError("failed"))
-			return fmt.Errorf("failed to unmarshal team config: %w", err)
+			fmt.Println(utils.RenderError(utils.T("common.failed")))
+			return fmt.Errorf("%w. Make sure the team exists and the team ID is correct.", err)
 		}
-		fmt.Println(utils.RenderSuccess("done"))
+		fmt.Println(utils.RenderSuccess(utils.T("common.done")))
 
 		// Verify password
-		fmt.Print("Verifying password... ")
-		if err := bcrypt.CompareHashAndPassword([]byte(teamConfig.PasswordHash), []byte(password)); err != nil {
-			fmt.Println(utils.RenderError("failed"))
-			return fmt.Errorf("invalid password")
+		fmt.Print(utils.T("join.step.password"))
+		if joinGuestToken != "" {
+			if err := teamConfig.VerifyGuestToken(username, joinGuestToken); err != nil {
+				fmt.Println(utils.RenderError(utils.T("common.failed")))
+				return err
+			}
+		} else {
+			// Checked before an invite is used up
+			if teamConfig.WasKicked(username) {
+				fmt.Println(utils.RenderError(utils.T("common.failed")))
+				return fmt.Errorf("%w; ask the team admin to run 'axle team kick --undo %s'", utils.ErrKicked, username)
+			}
+			// A guest's changes are filtered by name, so members can't take one
+			if _, ok := teamConfig.Guest(username); ok {
+				fmt.Println(utils.RenderError(utils.T("common.failed")))
+				return fmt.Errorf("username %s belongs to a guest; choose another", username)
+			}
+			if joinInviteToken != "" {
+				redeemed, err := utils.RedeemInvite(context.Background(), redisClient, teamID, joinInviteToken, username)
+				if err != nil {
+					fmt.Println(utils.RenderError(utils.T("common.failed")))
+					return fmt.Errorf("%w; ask a teammate for a new one with 'axle invite'", err)
+				}
+				password, joinInvitedBy = redeemed.Password, redeemed.InvitedBy
+				// Until the join is through, a failure leaves the invite usable for another try
+				defer func() {
+					if joined {
+						return
+					}
+					if err := redeemed.Restore(context.Background(), redisClient); err != nil {
+						fmt.Println(utils.RenderWarning(fmt.Sprintf("%v; ask a teammate for a new invite", err)))
+					}
+				}()
+			}
+			if err := bcrypt.CompareHashAndPassword([]byte(teamConfig.PasswordHash), []byte(password)); err != nil {
+				fmt.Println(utils.RenderError(utils.T("common.failed")))
+				if joinInviteToken != "" {
+					return fmt.Errorf("the team password changed after the invite was made; ask for a new one")
+				}
+				return fmt.Errorf("invalid password")
+			}
 		}
-		fmt.Println(utils.RenderSuccess("done"))
+		fmt.Println(utils.RenderSuccess(utils.T("common.done")))
 
 		// Initialize Git repository
-		fmt.Print("Setting up Git repository... ")
+		fmt.Print(utils.T("init.step.git"))
 		if err := utils.InitGitRepo(rootDir); err != nil {
-			fmt.Println(utils.RenderError("failed"))
+			fmt.Println(utils.RenderError(utils.T("common.failed")))
 			return fmt.Errorf("failed to initialize Git repository: %w", err)
 		}
-		fmt.Println(utils.RenderSuccess("done"))
+		fmt.Println(utils.RenderSuccess(utils.T("common.done")))
 
 		// Add config to local git exclude file
-		fmt.Print("Configuring Git exclusions... ")
-		excludePath := filepath.Join(rootDir, ".git", "info", "exclude")
-		f, err := os.OpenFile(excludePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			fmt.Println(utils.RenderError("failed"))
-			return fmt.Errorf("failed to open .git/info/exclude: %w", err)
+		fmt.Print(utils.T("init.step.exclude"))
+		if err := utils.EnsureGitExclude(rootDir, ConfigFileName); err != nil {
+			fmt.Println(utils.RenderError(utils.T("common.failed")))
+			return err
 		}
-		defer f.Close()
+		fmt.Println(utils.RenderSuccess(utils.T("common.done")))
 
-		if _, err := f.WriteString("\n" + ConfigFileName + "\n"); err != nil {
-			fmt.Println(utils.RenderError("failed"))
-			return fmt.Errorf("failed to write to .git/info/exclude: %w", err)
-		}
-		fmt.Println(utils.RenderSuccess("done"))
+		installCommitHook(rootDir)
 
 		// Create local config
 		localCfg := LocalAppConfig{
+			SchemaVersion:  CurrentConfigSchema,
 			TeamID:         teamID,
 			Username:       username,
 			RootDir:        rootDir,
 			RedisHost:      redisHost,
 			RedisPort:      redisPort,
+			RedisUsername:  redisAuth.Username,
+			RedisPassword:  redisAuth.Password,
+			RedisDB:        redisAuth.DB,
+			RedisTLS:       redisAuth.TLS,
+			RedisSentinels: redisHA.SentinelAddrs,
+			RedisCluster:   redisHA.ClusterAddrs,
+			Transport:      flagTransport(),
+			NATSURL:        natsURL,
 			IgnorePatterns: []string{".git", ConfigFileName},
+			TeamAdminKey:   teamConfig.AdminPublicKey,
+			Hub:            bareHub,
+
+			RedisSentinelMaster: redisHA.SentinelMaster,
+			GuestToken:          joinGuestToken,
 		}
 
 		// Create local configuration file
-		fmt.Print("Creating local configuration file... ")
+		fmt.Print(utils.T("init.step.config"))
 		filePath := filepath.Join(localCfg.RootDir, ConfigFileName)
 		jsonData, err := json.MarshalIndent(localCfg, "", "  ")
 		if err != nil {
-			fmt.Println(utils.RenderError("failed"))
+			fmt.Println(utils.RenderError(utils.T("common.failed")))
 			return fmt.Errorf("failed to marshal local config to JSON: %w", err)
 		}
 
-		if err := os.WriteFile(filePath, jsonData, 0644); err != nil {
-			fmt.Println(utils.RenderError("failed"))
+		if err := os.WriteFile(filePath, jsonData, localCfg.fileMode()); err != nil {
+			fmt.Println(utils.RenderError(utils.T("common.failed")))
 			return fmt.Errorf("failed to write local config to file %s: %w", filePath, err)
 		}
-		fmt.Println(utils.RenderSuccess("done"))
+		// Guests have no password to sign with
+		if joinGuestToken == "" {
+			if err := utils.SaveSigningKey(rootDir, teamID, password); err != nil {
+				fmt.Println(utils.RenderError(utils.T("common.failed")))
+				return err
+			}
+		}
+		fmt.Println(utils.RenderSuccess(utils.T("common.done")))
+
+		// Register in the team's membership registry
+		fmt.Print(utils.T("join.step.membership"))
+		if err := utils.RegisterMember(context.Background(), redisClient, teamID, username); err != nil {
+			fmt.Println(utils.RenderError(utils.T("common.failed")))
+			return err
+		}
+		fmt.Println(utils.RenderSuccess(utils.T("common.done")))
+		joined = true
+
+		if !joinNoBootstrap {
+			bootstrapJoiner(redisClient, transport, rootDir)
+		}
 
-		fmt.Println(utils.RenderSuccess("Successfully joined the team!"))
+		fmt.Println(utils.RenderSuccess(utils.T("join.success")))
 		fmt.Println("")
-		fmt.Println(utils.RenderInfo("Next steps:"))
-		fmt.Println("  axle start    - Start file synchronization")
-		fmt.Println("  axle team     - Check team member status")
-		fmt.Println("  axle chat \"Hi team!\" - Send a message to your team")
+		if joinInviteToken != "" {
+			// 'axle start' asks for the password; it never went through chat
+			fmt.Println(utils.RenderInfo(fmt.Sprintf("Invited by %s. The team password, which 'axle start' asks for, is:", joinInvitedBy)))
+			fmt.Printf("  %s\n", password)
+			fmt.Println("Keep it in your password manager; it is shown only this once.")
+			fmt.Println("")
+		}
+		fmt.Println(utils.RenderInfo(utils.T("common.next_steps")))
+		if bareHub {
+			// Only the team admin can sign these settings
+			admin := teamConfig.Admin
+			if admin == "" {
+				admin = "the team admin"
+			}
+			if teamConfig.AuthoritativeNode != username {
+				fmt.Printf("  axle team authority %s - Ask %s to make the hub authoritative\n", username, admin)
+			}
+			if !teamConfig.PersistBatches {
+				fmt.Printf("  axle team persistence on - Ask %s to keep history for members who were away\n", admin)
+			}
+			printHubHint()
+			return nil
+		}
+		fmt.Println(utils.T("hint.start"))
+		fmt.Println(utils.T("hint.team"))
+		fmt.Println(utils.T("hint.chat"))
 
 		return nil
 	},
@@ -152,8 +278,57 @@ func init() {
 	joinCmd.Flags().StringVar(&password, "password", "", "Team password")
 	joinCmd.Flags().StringVar(&redisHost, "host", "localhost", "Redis server host")
 	joinCmd.Flags().IntVar(&redisPort, "port", 6379, "Redis server port")
+	addRedisFlags(joinCmd)
+	joinCmd.Flags().BoolVar(&bareHub, "bare-hub", false, "Join as the team's headless, always-on hub")
+	joinCmd.Flags().BoolVar(&joinNoBootstrap, "no-bootstrap", false, "Start from an empty tree instead of the team's current files")
+	joinCmd.Flags().DurationVar(&joinBootstrapTimeout, "bootstrap-timeout", 15*time.Second, "How long to wait for a teammate to serve the current files")
+	joinCmd.Flags().StringVar(&joinGuestToken, "guest-token", "", "Join as a guest with the token from 'axle invite --guest'")
+	joinCmd.Flags().StringVar(&joinInviteToken, "token", "", "Join with the single-use invite token from 'axle invite' instead of the password")
 
 	// Mark required flags
 	joinCmd.MarkFlagRequired("team")
 	joinCmd.MarkFlagRequired("username")
 }
+
+// bootstrapJoiner brings a new member's tree to the team's current state.
+// Failing to is not fatal: the member is already in the team and can run
+// 'axle reset' later.
+func bootstrapJoiner(redisClient redis.UniversalClient, transport utils.Transport, rootDir string) {
+	ok, err := utils.CanBootstrap(rootDir)
+	if err != nil {
+		fmt.Println(utils.RenderWarning(fmt.Sprintf("Skipping the team's current files: %v", err)))
+		return
+	}
+	if !ok {
+		fmt.Println(utils.RenderWarning("This directory already has files, so it was left as is; run 'axle reset' to replace it with the team's state"))
+		return
+	}
+
+	cfg := utils.AppConfig{
+		RedisClient: redisClient,
+		Transport:   transport,
+		TeamID:      teamID,
+		Username:    username,
+		NodeID:      utils.GenerateNodeID(),
+		RootDir:     rootDir,
+	}
+	fmt.Print("Fetching the team's current files... ")
+	snapshot, err := utils.FetchBootstrapSnapshot(context.Background(), cfg, joinBootstrapTimeout)
+	if err != nil {
+		fmt.Println(utils.RenderWarning(utils.T("common.warning")))
+		fmt.Printf("  %v\n", err)
+		fmt.Println("  You start with an empty tree; run 'axle reset' once a teammate is online")
+		return
+	}
+	if err := utils.ApplyBootstrapSnapshot(rootDir, snapshot); err != nil {
+		fmt.Println(utils.RenderError(utils.T("common.failed")))
+		fmt.Printf("  %v\n", err)
+		return
+	}
+	if snapshot.Stored {
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("done (stored by %s %s, %s)", snapshot.Source,
+			formatTime(snapshot.StoredAt), formatFileSize(int64(len(snapshot.Bundle))))))
+	} else {
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("done (from %s, %s)", snapshot.Source, formatFileSize(int64(len(snapshot.Bundle))))))
+	}
+}
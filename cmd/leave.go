@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var purgeLocal bool
+
+// daemonStopTimeout bounds waiting for the daemon to flush and shut down
+const daemonStopTimeout = 20 * time.Second
+
+// leaveCmd represents the leave command
+var leaveCmd = &cobra.Command{
+	Use:   "leave",
+	Short: "Leave the Axle team",
+	Long: utils.RenderTitle("👋 Leave Axle Team") + `
+
+Cleanly leaves the team: stops the sync daemon running for this repository,
+sends a goodbye to online members, removes this node's presence entry, and
+unregisters your username from the team.
+
+Use --purge to also delete local Axle metadata (axle_config.json and the
+.axle directory). The Git repository and your files are always left intact.
+
+Examples:
+  axle leave
+  axle leave --purge`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Load configuration
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Nothing to leave", err)
+		}
+		defer config.RedisClient.Close()
+
+		fmt.Println(utils.RenderTitle("👋 Leaving Axle Team"))
+
+		// A running daemon would announce this node again with its next heartbeat
+		if err := stopRunningDaemon(); err != nil {
+			return err
+		}
+
+		fmt.Print("Notifying team and removing membership... ")
+		if err := utils.LeaveTeam(context.Background(), config); err != nil {
+			fmt.Println(utils.RenderError(utils.T("common.failed")))
+			return fmt.Errorf("failed to leave team: %w", err)
+		}
+		fmt.Println(utils.RenderSuccess(utils.T("common.done")))
+
+		if purgeLocal {
+			fmt.Print("Removing local Axle metadata... ")
+			if err := os.RemoveAll(filepath.Join(config.RootDir, ".axle")); err != nil {
+				fmt.Println(utils.RenderError(utils.T("common.failed")))
+				return fmt.Errorf("failed to remove .axle directory: %w", err)
+			}
+			if err := os.Remove(filepath.Join(config.RootDir, ConfigFileName)); err != nil && !os.IsNotExist(err) {
+				fmt.Println(utils.RenderError(utils.T("common.failed")))
+				return fmt.Errorf("failed to remove %s: %w", ConfigFileName, err)
+			}
+			fmt.Println(utils.RenderSuccess(utils.T("common.done")))
+		}
+
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Left team %s", config.TeamID)))
+		fmt.Println(utils.RenderInfo("Your Git repository and files were left intact"))
+		return nil
+	},
+}
+
+// stopRunningDaemon asks the daemon running for this repository to shut down
+// and waits for it to finish.
+func stopRunningDaemon() error {
+	state, err := utils.ReadDaemonState(config.RootDir)
+	if err != nil || !state.IsRunning() {
+		return nil
+	}
+
+	fmt.Printf("Stopping the sync daemon (PID %d)... ", state.PID)
+	if _, ok, err := askDaemon("stop", nil); !ok || err != nil {
+		fmt.Println(utils.RenderError(utils.T("common.failed")))
+		return fmt.Errorf("could not stop the sync daemon (PID %d); stop it with Ctrl+C and run 'axle leave' again", state.PID)
+	}
+
+	deadline := time.Now().Add(daemonStopTimeout)
+	for time.Now().Before(deadline) {
+		// It leaves the team's presence on its way out; wait until it exits
+		if !utils.ProcessRunning(state.PID) {
+			fmt.Println(utils.RenderSuccess(utils.T("common.done")))
+			return nil
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	fmt.Println(utils.RenderError(utils.T("common.failed")))
+	return fmt.Errorf("the sync daemon (PID %d) did not stop within %v; stop it with Ctrl+C and run 'axle leave' again", state.PID, daemonStopTimeout)
+}
+
+func init() {
+	rootCmd.AddCommand(leaveCmd)
+	leaveCmd.Flags().BoolVar(&purgeLocal, "purge", false, "Also delete axle_config.json and the .axle directory")
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// notesCmd shows the team scratchpad
+var notesCmd = &cobra.Command{
+	Use:   "notes",
+	Short: "Show the team scratchpad (TEAM_NOTES.md)",
+	Long: utils.RenderTitle("📝 Team Scratchpad") + `
+
+TEAM_NOTES.md is a shared scratchpad for notes, links and TODOs. It isn't
+synced through git: every line is stored in Redis and edits are merged line
+by line, so everyone can write to it at once without ever getting a
+conflict. Lines added concurrently in the same place both survive.
+
+While 'axle start' is running you can also edit TEAM_NOTES.md directly;
+saved changes are shared a moment later. Don't put credentials in it.
+
+Examples:
+  axle notes                          # Print the scratchpad
+  axle notes add "Staging: https://staging.example.com"
+  axle notes edit                     # Open it in $EDITOR`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		entries, err := utils.LoadScratchpad(context.Background(), config)
+		if err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderTitle("📝 " + utils.ScratchpadFileName))
+		if len(entries) == 0 {
+			fmt.Println(utils.RenderInfo("The scratchpad is empty; add to it with 'axle notes add' or 'axle notes edit'"))
+			return nil
+		}
+		fmt.Print(utils.RenderScratchpad(entries))
+		return nil
+	},
+}
+
+var notesAddCmd = &cobra.Command{
+	Use:   "add <text>",
+	Short: "Add a line to the end of the team scratchpad",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		text := strings.TrimSpace(strings.Join(args, " "))
+		if text == "" {
+			return fmt.Errorf("nothing to add")
+		}
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		if err := utils.AppendScratchpad(ctx, config, text+"\n"); err != nil {
+			return err
+		}
+		refreshLocalScratchpad(ctx)
+		fmt.Println(utils.RenderSuccess("Added to " + utils.ScratchpadFileName))
+		return nil
+	},
+}
+
+var notesEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the team scratchpad in your editor",
+	Long: `Opens the team scratchpad in $VISUAL or $EDITOR. When you save and close
+the editor, your changes are merged with whatever teammates changed in the
+meantime.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		base, err := utils.LoadScratchpad(ctx, config)
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(utils.AxlePath(config.RootDir, "tmp"), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", utils.AxlePath(config.RootDir, "tmp"), err)
+		}
+		tmp, err := os.CreateTemp(utils.AxlePath(config.RootDir, "tmp"), "TEAM_NOTES-*.md")
+		if err != nil {
+			return fmt.Errorf("failed to create temporary file: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		_, err = tmp.WriteString(utils.RenderScratchpad(base))
+		tmp.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write temporary file: %w", err)
+		}
+
+		if err := openEditor(tmp.Name()); err != nil {
+			return err
+		}
+		edited, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return fmt.Errorf("failed to read your edits: %w", err)
+		}
+
+		inserted, deleted, err := utils.EditScratchpad(ctx, config, base, string(edited))
+		if err != nil {
+			return err
+		}
+		if inserted == 0 && deleted == 0 {
+			fmt.Println(utils.RenderInfo("No changes"))
+			return nil
+		}
+		refreshLocalScratchpad(ctx)
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Saved %s: %d line(s) added, %d removed", utils.ScratchpadFileName, inserted, deleted)))
+		return nil
+	},
+}
+
+// refreshLocalScratchpad updates TEAM_NOTES.md when no daemon is running to do it
+func refreshLocalScratchpad(ctx context.Context) {
+	if state, err := utils.ReadDaemonState(config.RootDir); err == nil && state.IsRunning() {
+		return
+	}
+	if err := utils.EnsureGitExclude(config.RootDir, "/"+utils.ScratchpadFileName); err != nil {
+		fmt.Println(utils.RenderWarning(err.Error()))
+	}
+	utils.RefreshScratchpadFile(ctx, config)
+}
+
+// openEditor opens a file in the user's editor and waits for it to close
+func openEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// Editors like "code --wait" come with their own arguments
+	fields := strings.Fields(editor)
+	editorCmd := exec.Command(fields[0], append(fields[1:], path)...)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", fields[0], err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(notesCmd)
+	notesCmd.AddCommand(notesAddCmd, notesEditCmd)
+}
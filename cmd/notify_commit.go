@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// notifyCommitCmd is invoked by the post-commit hook that axle init/join installs
+var notifyCommitCmd = &cobra.Command{
+	Use:    "notify-commit [commit]",
+	Short:  "Ask the running daemon to publish a manual commit",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rootDir, err := findConfigDir()
+		if err != nil {
+			return err
+		}
+
+		resp, err := utils.SendControlRequest(rootDir, utils.ControlRequest{
+			Command: "publish-commit",
+			Args:    map[string]string{"commit": args[0]},
+		}, 5*time.Second)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(resp.Message)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(notifyCommitCmd)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// pauseCmd stops the running daemon from publishing and applying changes
+var pauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Stop publishing and applying changes without stopping the daemon",
+	Long: utils.RenderTitle("⏸️  Pause Sync") + `
+
+Use this during risky local work, like a large refactor or a tricky
+merge. The daemon keeps running and stays connected, but:
+• Your changes are still committed locally and wait in the outbox
+• Teammates' changes are held instead of applied to your files
+
+'axle resume' applies the held changes in the order they arrived and
+publishes your backlog. The pause survives a restart of 'axle start'.
+
+Examples:
+  axle pause
+  axle resume`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sendPauseRequest("pause")
+	},
+}
+
+// resumeCmd ends a pause
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Apply held changes and publish your backlog after 'axle pause'",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return sendPauseRequest("resume")
+	},
+}
+
+// sendPauseRequest asks the daemon to pause or resume
+func sendPauseRequest(command string) error {
+	if err := loadLocalConfig(); err != nil {
+		return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+	}
+
+	resp, ok, err := askDaemon(command, nil)
+	if !ok {
+		return fmt.Errorf("the sync daemon is not running; start it with 'axle start' and try again")
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(utils.RenderSuccess("Daemon: " + resp.Message))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(pauseCmd)
+	rootCmd.AddCommand(resumeCmd)
+}
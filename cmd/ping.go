@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pingCount         int
+	pingPreferFastest bool
+)
+
+// pingCmd measures the round-trip time to the configured Redis endpoints
+var pingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Measure latency to the team's Redis endpoints",
+	Long: utils.RenderTitle("📶 Redis Latency") + `
+
+Measures the round-trip time to every configured Redis endpoint, so teams on
+cloud Redis can see which region is closest. Connection setup is not counted.
+
+Axle connects to the endpoints in the order they are listed under
+"redisEndpoints" in axle_config.json and fails over to the next one when an
+endpoint is unreachable. Use --prefer-fastest to reorder the list by the
+measured latency. Teammates' latency is shown in 'axle team'.
+
+Examples:
+  axle ping
+  axle ping --count 10
+  axle ping --prefer-fastest`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		if pingCount < 1 {
+			return fmt.Errorf("--count must be at least 1")
+		}
+		if ha := utils.CurrentRedisHA(); ha.Enabled() {
+			return fmt.Errorf("Redis is reached through the %s, which picks its own servers; 'axle status' shows the round trip", ha.Label())
+		}
+
+		ctx := context.Background()
+		endpoints := config.RedisEndpoints
+
+		fmt.Println(utils.RenderTitle("📶 Redis Latency"))
+		results := make([]utils.EndpointLatency, 0, len(endpoints))
+		for i, endpoint := range endpoints {
+			result := utils.PingEndpoint(ctx, endpoint, pingCount)
+			results = append(results, result)
+
+			role := "fallback"
+			if i == 0 {
+				role = "primary"
+			}
+			if result.Err != nil {
+				fmt.Printf("%d. %-40s %-8s unreachable: %v\n", i+1, endpoint.Label(), role, result.Err)
+				continue
+			}
+			fmt.Printf("%d. %-40s %-8s min %s  avg %s\n", i+1, endpoint.Label(), role, formatRTT(result.Min), formatRTT(result.Avg))
+		}
+
+		ranked := utils.RankEndpoints(results)
+		fastest := ranked[0]
+		if fastest.Err != nil {
+			return fmt.Errorf("no Redis endpoint is reachable")
+		}
+		if fastest.Endpoint == endpoints[0] {
+			fmt.Println(utils.RenderSuccess("The primary endpoint is the fastest"))
+			return nil
+		}
+
+		if !pingPreferFastest {
+			reason := "is faster than the primary"
+			if results[0].Err != nil {
+				reason = "is reachable but the primary is not"
+			}
+			fmt.Println(utils.RenderInfo(fmt.Sprintf("%s %s; run 'axle ping --prefer-fastest' to use it first", fastest.Endpoint.Label(), reason)))
+			return nil
+		}
+
+		ordered := make([]utils.RedisEndpoint, len(ranked))
+		for i, result := range ranked {
+			ordered[i] = result.Endpoint
+		}
+		if err := updateConfigFile(func(localCfg *LocalAppConfig) {
+			localCfg.RedisEndpoints = ordered
+		}); err != nil {
+			return fmt.Errorf("failed to save endpoint order: %w", err)
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("%s is now the primary endpoint; restart 'axle start' to switch", fastest.Endpoint.Label())))
+		return nil
+	},
+}
+
+// formatRTT formats a round-trip time in milliseconds
+func formatRTT(rtt time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(rtt.Microseconds())/1000)
+}
+
+func init() {
+	rootCmd.AddCommand(pingCmd)
+	pingCmd.Flags().IntVarP(&pingCount, "count", "c", 5, "Number of pings per endpoint")
+	pingCmd.Flags().BoolVar(&pingPreferFastest, "prefer-fastest", false, "Reorder the endpoints in axle_config.json from fastest to slowest")
+}
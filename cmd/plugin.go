@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// pluginsCmd lists the external plugins found on PATH
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "List installed plugins",
+	Long: utils.RenderTitle("🧩 Plugins") + `
+
+Any executable named axle-<name> on your PATH becomes the 'axle <name>'
+subcommand. Plugins receive the repository root, the daemon's control
+socket, the team ID, and the username in AXLE_ROOT, AXLE_CONTROL_SOCKET,
+AXLE_TEAM_ID, and AXLE_USERNAME.
+
+To follow daemon events, a plugin connects to the control socket and sends
+{"command":"subscribe","args":{"topics":"batch.applied,chat.received"}}
+(omit topics for all of them). The daemon replies with one status line,
+then streams one JSON event per line until the plugin disconnects.`,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		plugins := utils.DiscoverPlugins()
+		if len(plugins) == 0 {
+			fmt.Println(utils.RenderInfo("No plugins found. Install an executable named axle-<name> on your PATH."))
+			return
+		}
+
+		fmt.Println(utils.RenderTitle("🧩 Installed Plugins"))
+		for _, plugin := range plugins {
+			status := ""
+			if isBuiltinCommand(plugin.Name) {
+				status = " " + utils.RenderWarning("shadowed by a built-in command")
+			}
+			fmt.Printf("  %-16s %s%s\n", plugin.Name, plugin.Path, status)
+		}
+	},
+}
+
+// pluginAnnotation marks the subcommands that wrap plugins.
+const pluginAnnotation = "axle.plugin"
+
+// registerPlugins adds a subcommand for every plugin on PATH. Built-in
+// commands always win over plugins of the same name.
+func registerPlugins() {
+	for _, plugin := range utils.DiscoverPlugins() {
+		if isBuiltinCommand(plugin.Name) {
+			continue
+		}
+		rootCmd.AddCommand(newPluginCommand(plugin))
+	}
+}
+
+// isBuiltinCommand reports whether name is already taken by a root subcommand.
+func isBuiltinCommand(name string) bool {
+	for _, c := range rootCmd.Commands() {
+		if _, isPlugin := c.Annotations[pluginAnnotation]; isPlugin {
+			continue
+		}
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return name == "help" || name == "completion"
+}
+
+// newPluginCommand wraps plugin as a subcommand that passes all arguments through.
+func newPluginCommand(plugin utils.Plugin) *cobra.Command {
+	return &cobra.Command{
+		Use:                plugin.Name,
+		Short:              fmt.Sprintf("Plugin (%s)", plugin.Path),
+		Annotations:        map[string]string{pluginAnnotation: plugin.Path},
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Plugins also work outside an Axle repository, just without its context
+			var rootDir, teamID, username string
+			if localCfg, err := loadConfigFromFile(); err == nil {
+				rootDir, teamID, username = localCfg.RootDir, localCfg.TeamID, localCfg.Username
+			}
+
+			c := exec.Command(plugin.Path, args...)
+			c.Env = utils.PluginEnv(rootDir, teamID, username)
+			c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+			if err := c.Run(); err != nil {
+				// Pass the plugin's exit code through without an extra error message
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					os.Exit(exitErr.ExitCode())
+				}
+				return fmt.Errorf("failed to run plugin %s: %w", plugin.Name, err)
+			}
+			return nil
+		},
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(pluginsCmd)
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var confirmProtected bool
+
+// protectCmd represents the protect command
+var protectCmd = &cobra.Command{
+	Use:   "protect",
+	Short: "Manage write-protected paths for the team",
+	Long: utils.RenderTitle("🛡️  Protected Paths") + `
+
+Protected paths (e.g. infra/prod/**) are never synced silently:
+• Incoming changes touching them wait for 'axle accept-protected --confirm'
+• Local changes touching them wait for 'axle push-protected --confirm'
+
+Patterns are team-wide and support *, ?, and ** (any depth).
+
+Examples:
+  axle protect add "infra/prod/**"
+  axle protect remove "infra/prod/**"
+  axle protect list`,
+}
+
+var protectAddCmd = &cobra.Command{
+	Use:   "add <glob>...",
+	Short: "Protect paths matching the given globs",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateProtectedPaths(func(paths []string) []string {
+			for _, pattern := range args {
+				if !containsString(paths, pattern) {
+					paths = append(paths, pattern)
+				}
+			}
+			return paths
+		})
+	},
+}
+
+var protectRemoveCmd = &cobra.Command{
+	Use:   "remove <glob>...",
+	Short: "Stop protecting the given globs",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateProtectedPaths(func(paths []string) []string {
+			kept := []string{}
+			for _, pattern := range paths {
+				if !containsString(args, pattern) {
+					kept = append(kept, pattern)
+				}
+			}
+			return kept
+		})
+	},
+}
+
+var protectListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List protected path globs",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		if len(config.ProtectedPaths) == 0 {
+			fmt.Println(utils.RenderInfo("No protected paths configured"))
+			return nil
+		}
+
+		fmt.Println(utils.RenderTitle("🛡️  Protected Paths"))
+		for _, pattern := range config.ProtectedPaths {
+			fmt.Printf("  • %s\n", pattern)
+		}
+		return nil
+	},
+}
+
+// updateProtectedPaths applies an edit to the team's protected path list
+func updateProtectedPaths(edit func([]string) []string) error {
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+	}
+	defer config.RedisClient.Close()
+
+	ctx := context.Background()
+	teamConfig, err := utils.GetTeamConfigForUpdate(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	teamConfig.ProtectedPaths = edit(teamConfig.ProtectedPaths)
+	if err := utils.SaveTeamConfig(ctx, config.RedisClient, teamConfig); err != nil {
+		return err
+	}
+
+	fmt.Println(utils.RenderSuccess("Protected paths updated"))
+	if len(teamConfig.ProtectedPaths) > 0 {
+		fmt.Printf("  %s\n", strings.Join(teamConfig.ProtectedPaths, ", "))
+	}
+	fmt.Println(utils.RenderInfo("Running daemons pick up the change on their next restart"))
+	return nil
+}
+
+// pushProtectedCmd publishes local batches held because they touch protected paths
+var pushProtectedCmd = &cobra.Command{
+	Use:   "push-protected",
+	Short: "Review and publish held local changes to protected paths",
+	Long: utils.RenderTitle("🛡️  Push Protected Changes") + `
+
+Lists local batches that were held because they touch protected paths, or
+because they were over the team's sync message limit ('axle team limits').
+Run with --confirm to publish them to the team.`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		batches, err := utils.ListHeldBatches(config.RootDir, utils.HeldOutgoing, config.ProtectedPaths)
+		if err != nil {
+			return err
+		}
+		oversized, err := utils.ListHeldBatches(config.RootDir, utils.HeldOversized, nil)
+		if err != nil {
+			return err
+		}
+		batches = append(batches, oversized...)
+		if len(batches) == 0 {
+			fmt.Println(utils.RenderInfo("No held local changes"))
+			return nil
+		}
+
+		printHeldBatches(batches)
+		if !confirmProtected {
+			fmt.Println(utils.RenderWarning("Re-run with --confirm to publish these changes to the team"))
+			return nil
+		}
+
+		ctx := context.Background()
+		channel := utils.TeamChannel(config.TeamID)
+		for _, batch := range batches {
+			batch.Metadata.Timestamp = time.Now().Unix()
+			if err := utils.CheckOutgoingBatch(batch.Metadata); err != nil {
+				return fmt.Errorf("held batch %s can't be published yet: %w", batch.ID, err)
+			}
+			if err := utils.PublishMessage(ctx, config.Transport, channel, batch.Metadata); err != nil {
+				return fmt.Errorf("failed to publish held batch %s: %w", batch.ID, err)
+			}
+			if err := utils.ReleaseHeldBatch(batch); err != nil {
+				return err
+			}
+		}
+
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Published %d held batches", len(batches))))
+		return nil
+	},
+}
+
+// acceptProtectedCmd applies incoming batches held because they touch protected paths
+var acceptProtectedCmd = &cobra.Command{
+	Use:   "accept-protected",
+	Short: "Review and apply held team changes to protected paths",
+	Long: utils.RenderTitle("🛡️  Accept Protected Changes") + `
+
+Lists incoming batches that were held because they touch protected paths.
+Run with --confirm to apply them to your working tree.`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		batches, err := utils.ListHeldBatches(config.RootDir, utils.HeldIncoming, config.ProtectedPaths)
+		if err != nil {
+			return err
+		}
+		if len(batches) == 0 {
+			fmt.Println(utils.RenderInfo("No held incoming changes"))
+			return nil
+		}
+
+		printHeldBatches(batches)
+		if !confirmProtected {
+			fmt.Println(utils.RenderWarning("Re-run with --confirm to apply these changes"))
+			return nil
+		}
+
+		config.ConflictStrategy = utils.ConflictStrategyMerge
+		for _, batch := range batches {
+			applySyncBatch(config, batch.Metadata)
+			if err := utils.ReleaseHeldBatch(batch); err != nil {
+				return err
+			}
+		}
+
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Applied %d held batches", len(batches))))
+		return nil
+	},
+}
+
+// printHeldBatches lists held batches and the protected files they touch
+func printHeldBatches(batches []utils.HeldBatch) {
+	for _, batch := range batches {
+		fmt.Printf("  %s  from %s, %d changes, %s\n", batch.ID, batch.Metadata.PeerID,
+			len(batch.Metadata.Changes), formatTime(time.Unix(batch.Metadata.Timestamp, 0)))
+		for _, file := range batch.ProtectedFiles {
+			fmt.Printf("    🛡️  %s\n", file)
+		}
+	}
+}
+
+// containsString checks if a slice contains a string
+func containsString(slice []string, item string) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(protectCmd)
+	protectCmd.AddCommand(protectAddCmd, protectRemoveCmd, protectListCmd)
+
+	rootCmd.AddCommand(pushProtectedCmd)
+	pushProtectedCmd.Flags().BoolVar(&confirmProtected, "confirm", false, "Publish the held changes")
+
+	rootCmd.AddCommand(acceptProtectedCmd)
+	acceptProtectedCmd.Flags().BoolVar(&confirmProtected, "confirm", false, "Apply the held changes")
+}
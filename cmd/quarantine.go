@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// quarantineCmd lists incoming batches the scanner flagged
+var quarantineCmd = &cobra.Command{
+	Use:   "quarantine",
+	Short: "List incoming changes held back by the content scanner",
+	Long: utils.RenderTitle("☣️  Quarantine") + `
+
+When "scanCommand" is set in axle_config.json, every incoming batch is
+scanned before it is applied: the content it would write is put in a
+scratch directory and the command is run on it (the directory replaces {}
+in the command, or is added at the end). Exit status 0 means clean. Any
+other result quarantines the batch: it never touches the working tree,
+the team is alerted, and it waits here for you to decide.
+
+  "scanCommand": "clamdscan --no-summary --fdpass"
+
+Examples:
+  axle quarantine                 # List quarantined batches
+  axle quarantine show <id>       # Show the scanner output and the files
+  axle quarantine release <id>    # Apply it anyway
+  axle quarantine delete <id>     # Throw it away`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		batches, err := utils.ListQuarantinedBatches(config.RootDir)
+		if err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderTitle("☣️  Quarantine"))
+		if config.ScanCommand == "" {
+			fmt.Println(utils.RenderWarning("No scanCommand is set; incoming changes aren't scanned"))
+		}
+		if len(batches) == 0 {
+			fmt.Println(utils.RenderSuccess("Nothing is quarantined"))
+			return nil
+		}
+		for _, batch := range batches {
+			fmt.Printf("  %s  from %s, %d changes, %s\n", batch.ID, batch.Metadata.PeerID,
+				len(batch.Metadata.Changes), formatTime(time.Unix(batch.QuarantinedAt, 0)))
+			fmt.Printf("    %s\n", batch.Reason)
+		}
+		return nil
+	},
+}
+
+var quarantineShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show why a batch was quarantined",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		batch, err := utils.LoadQuarantinedBatch(config.RootDir, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderTitle("☣️  " + batch.ID))
+		fmt.Printf("From:        %s\n", batch.Metadata.PeerID)
+		fmt.Printf("Quarantined: %s\n", formatTime(time.Unix(batch.QuarantinedAt, 0)))
+		fmt.Printf("Reason:      %s\n", batch.Reason)
+		fmt.Println("Changes:")
+		for _, change := range batch.Metadata.Changes {
+			fmt.Printf("  %-9s %s\n", change.Event, change.File)
+		}
+		if batch.Output != "" {
+			fmt.Println("Scanner output:")
+			fmt.Println(batch.Output)
+		}
+		return nil
+	},
+}
+
+var quarantineReleaseCmd = &cobra.Command{
+	Use:   "release <id>",
+	Short: "Apply a quarantined batch anyway",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		batch, err := utils.LoadQuarantinedBatch(config.RootDir, args[0])
+		if err != nil {
+			return err
+		}
+		config.ConflictStrategy = utils.ConflictStrategyMerge
+		applySyncBatch(config, batch.Metadata)
+		if err := utils.RemoveQuarantinedBatch(config.RootDir, batch.ID); err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Applied %d changes from %s", len(batch.Metadata.Changes), batch.Metadata.PeerID)))
+		return nil
+	},
+}
+
+var quarantineDeleteCmd = &cobra.Command{
+	Use:   "delete <id>",
+	Short: "Discard a quarantined batch",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		if err := utils.RemoveQuarantinedBatch(config.RootDir, args[0]); err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderSuccess("Deleted quarantined batch " + args[0]))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(quarantineCmd)
+	quarantineCmd.AddCommand(quarantineShowCmd, quarantineReleaseCmd, quarantineDeleteCmd)
+}
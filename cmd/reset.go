@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resetForce   bool
+	resetTimeout time.Duration
+	resetFrom    string
+)
+
+// resetCmd represents the reset command
+var resetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Rebuild local state from the team's canonical history",
+	Long: utils.RenderTitle("♻️  Reset Local State") + `
+
+Use this when your node has hopelessly diverged from the team. Axle will:
+• Save an automatic checkpoint of your working tree ('last-auto')
+• Back up your local commits to an 'axle-backup-<timestamp>' branch
+• Stash any uncommitted changes (including untracked files)
+• Fetch a snapshot of the canonical history from an online peer
+  (the authoritative node is preferred when one is designated, and
+  --from picks a specific teammate, e.g. the one 'axle elect' chose)
+• Replace your working tree with that snapshot
+
+Stop 'axle start' before running this, then start it again afterwards
+to resume syncing from the clean baseline.
+
+Examples:
+  axle reset
+  axle reset --force --timeout 1m
+  axle reset --from alice`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Load configuration
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' or 'axle join' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		if !resetForce {
+			fmt.Println(utils.RenderWarning("This will replace your working tree with the team's canonical state."))
+			fmt.Print("Type 'reset' to continue: ")
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if strings.TrimSpace(answer) != "reset" {
+				fmt.Println(utils.RenderInfo("Reset cancelled"))
+				return nil
+			}
+		}
+
+		fmt.Println(utils.RenderTitle("♻️  Resetting Local State"))
+		ctx := context.Background()
+
+		// Back up local history and changes
+		if _, err := utils.CreateAutoCheckpoint(config.RootDir, "reset"); err != nil {
+			return err
+		}
+		backupBranch := fmt.Sprintf("axle-backup-%d", time.Now().Unix())
+		fmt.Print("Backing up local changes... ")
+		if output, err := utils.GitCommand("-C", config.RootDir, "branch", backupBranch).CombinedOutput(); err != nil {
+			fmt.Println(utils.RenderError(utils.T("common.failed")))
+			return fmt.Errorf("failed to create backup branch: %s", string(output))
+		}
+		utils.GitCommand("-C", config.RootDir, "stash", "push", "-u", "-m", "Axle: backup before reset").Run()
+		fmt.Println(utils.RenderSuccess(utils.T("common.done")))
+
+		// Fetch the canonical snapshot from a peer
+		if resetFrom != "" {
+			fmt.Printf("Requesting snapshot from %s... ", resetFrom)
+		} else {
+			fmt.Print("Requesting snapshot from team... ")
+		}
+		bundle, servedBy, err := utils.RequestSnapshot(ctx, config, resetFrom, resetTimeout)
+		if err != nil {
+			fmt.Println(utils.RenderError(utils.T("common.failed")))
+			return err
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("done (from %s, %s)", servedBy, formatFileSize(int64(len(bundle))))))
+
+		// Replace the working tree
+		fmt.Print("Rebuilding working tree... ")
+		if err := utils.ApplyBundle(config.RootDir, bundle); err != nil {
+			fmt.Println(utils.RenderError(utils.T("common.failed")))
+			return fmt.Errorf("failed to apply snapshot: %w", err)
+		}
+		fmt.Println(utils.RenderSuccess(utils.T("common.done")))
+
+		fmt.Println(utils.RenderSuccess("Local state rebuilt from the team's canonical history"))
+		fmt.Println("")
+		fmt.Println(utils.RenderInfo("Your previous work is preserved:"))
+		fmt.Printf("  git log %s     - Previous commits\n", backupBranch)
+		fmt.Println("  git stash list        - Uncommitted changes")
+		fmt.Printf("  axle checkpoint restore %s - The tree as it was\n", utils.LastAutoCheckpoint)
+		fmt.Println("  axle start            - Resume syncing")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(resetCmd)
+	resetCmd.Flags().BoolVarP(&resetForce, "force", "f", false, "Skip the confirmation prompt")
+	resetCmd.Flags().DurationVar(&resetTimeout, "timeout", 30*time.Second, "How long to wait for a peer to serve a snapshot")
+	resetCmd.Flags().StringVar(&resetFrom, "from", "", "Take the snapshot from this teammate")
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resyncFrom    string
+	resyncPrune   bool
+	resyncDryRun  bool
+	resyncTimeout time.Duration
+)
+
+// resyncCmd reconciles the local tree with a teammate's, file by file
+var resyncCmd = &cobra.Command{
+	Use:   "resync",
+	Short: "Reconcile your tree with the team's after missed or failed changes",
+	Long: utils.RenderTitle("🔁 Resync") + `
+
+When your node has drifted (missed messages, patches that failed to apply),
+this compares your files with a teammate's and fixes only the differences.
+A peer sends a manifest of its tree (path, size and SHA-256 of every synced
+file) over Redis. Files that are missing here or differ are downloaded
+through the blob store, replacing your version; an automatic checkpoint
+('last-auto') keeps the old tree. Files only you have are published to the
+team, or deleted with --prune.
+
+The peer is --from, else the reference of the last 'axle elect --apply',
+else whoever answers first (the authoritative node or a hub is preferred).
+'axle start' must be running. Unlike 'axle reset', your history and
+uncommitted work elsewhere in the tree are kept.
+
+Examples:
+  axle resync --dry-run       # Show what would change
+  axle resync                 # Take the team's versions, publish your extras
+  axle resync --from alice --prune`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		if state, err := utils.ReadDaemonState(config.RootDir); err != nil || !state.IsRunning() {
+			return fmt.Errorf("the sync daemon is not running; start it with 'axle start' and try again")
+		}
+
+		fmt.Println(utils.RenderTitle("🔁 Resync"))
+		fmt.Println("Comparing your tree with the team's...")
+		// The daemon waits for the manifest and then for the files
+		resp, err := utils.SendControlRequest(config.RootDir, utils.ControlRequest{
+			Command: "resync",
+			Args: map[string]string{
+				"from":    resyncFrom,
+				"prune":   strconv.FormatBool(resyncPrune),
+				"dry-run": strconv.FormatBool(resyncDryRun),
+				"timeout": resyncTimeout.String(),
+			},
+		}, 2*resyncTimeout+time.Minute)
+		if err != nil {
+			return err
+		}
+		var report utils.ResyncReport
+		if err := json.Unmarshal([]byte(resp.Message), &report); err != nil {
+			return fmt.Errorf("failed to parse resync report from daemon: %w", err)
+		}
+
+		fmt.Printf("Compared %d files with %s\n", report.Compared, report.From)
+		if len(report.Downloaded)+len(report.Uploaded)+len(report.Deleted)+len(report.Failed) == 0 {
+			fmt.Println(utils.RenderSuccess("Your tree already matches"))
+			return nil
+		}
+		verb := map[bool][3]string{
+			true:  {"Would download", "Would publish", "Would delete"},
+			false: {"Downloaded", "Published", "Deleted"},
+		}[report.DryRun]
+		printResyncFiles(verb[0], report.Downloaded)
+		printResyncFiles(verb[1], report.Uploaded)
+		printResyncFiles(verb[2], report.Deleted)
+		if len(report.Failed) > 0 {
+			fmt.Println(utils.RenderWarning(fmt.Sprintf("%d files failed:", len(report.Failed))))
+			for _, failure := range report.Failed {
+				fmt.Printf("  %s\n", failure)
+			}
+		}
+
+		if report.DryRun {
+			fmt.Println(utils.RenderInfo("Dry run: nothing was changed"))
+		} else if len(report.Downloaded) > 0 || len(report.Deleted) > 0 {
+			fmt.Printf("The previous tree is saved; 'axle checkpoint restore %s' brings it back\n", utils.LastAutoCheckpoint)
+		}
+		return nil
+	},
+}
+
+func printResyncFiles(verb string, files []string) {
+	if len(files) == 0 {
+		return
+	}
+	fmt.Println(utils.RenderInfo(fmt.Sprintf("%s %d files:", verb, len(files))))
+	for _, file := range files {
+		fmt.Printf("  %s\n", file)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(resyncCmd)
+	resyncCmd.Flags().StringVar(&resyncFrom, "from", "", "Take the state of this teammate")
+	resyncCmd.Flags().BoolVar(&resyncPrune, "prune", false, "Delete files only you have instead of publishing them")
+	resyncCmd.Flags().BoolVar(&resyncDryRun, "dry-run", false, "Only show what would change")
+	resyncCmd.Flags().DurationVar(&resyncTimeout, "timeout", 30*time.Second, "How long to wait for a teammate to answer")
+}
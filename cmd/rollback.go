@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rollbackForce  bool
+	rollbackDryRun bool
+)
+
+// rollbackCmd reverts recent sync commits for the whole team
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [commit|n]",
+	Short: "Revert the last synced commits for the whole team",
+	Long: utils.RenderTitle("⏪ Rollback") + `
+
+Undoes recent changes safely: the last n commits (1 by default), or every
+commit from the newest back to and including the given one, are reverted
+with 'git revert' in a single new commit. That commit is published like any
+other change, so every teammate rolls back the same way instead of
+diverging, and history keeps what was undone. An automatic checkpoint
+('last-auto') saves the tree as it was.
+
+'axle start' must be running, and everything you changed must be synced.
+'axle history --timeline' shows the commits to pick from.
+
+Examples:
+  axle rollback               # Revert the last commit
+  axle rollback 3 --dry-run   # Show the last 3 commits it would revert
+  axle rollback 4f2c9e1`,
+
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		target := "1"
+		if len(args) == 1 {
+			target = args[0]
+		}
+
+		commits, err := utils.RollbackTargets(config.RootDir, target)
+		if err != nil {
+			return err
+		}
+		head, err := utils.RevParse(config.RootDir, "HEAD")
+		if err != nil {
+			return fmt.Errorf("failed to read HEAD: %w", err)
+		}
+
+		fmt.Println(utils.RenderTitle("⏪ Rollback"))
+		fmt.Printf("Reverting %d commits:\n", len(commits))
+		printRollbackCommits(commits)
+		if rollbackDryRun {
+			fmt.Println(utils.RenderInfo("Dry run: nothing was changed"))
+			return nil
+		}
+		if state, err := utils.ReadDaemonState(config.RootDir); err != nil || !state.IsRunning() {
+			return fmt.Errorf("the sync daemon is not running; start it with 'axle start' and try again")
+		}
+
+		if !rollbackForce {
+			fmt.Println(utils.RenderWarning("The revert is published to the whole team."))
+			fmt.Print("Type 'rollback' to continue: ")
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if strings.TrimSpace(answer) != "rollback" {
+				fmt.Println(utils.RenderInfo("Rollback cancelled"))
+				return nil
+			}
+		}
+
+		fmt.Print("Reverting and publishing... ")
+		resp, err := utils.SendControlRequest(config.RootDir, utils.ControlRequest{
+			Command: "rollback",
+			Args:    map[string]string{"target": target, "head": head},
+		}, time.Minute)
+		if err != nil {
+			fmt.Println(utils.RenderError(utils.T("common.failed")))
+			return err
+		}
+		var report utils.RollbackReport
+		if err := json.Unmarshal([]byte(resp.Message), &report); err != nil {
+			fmt.Println(utils.RenderError(utils.T("common.failed")))
+			return fmt.Errorf("failed to parse rollback report from daemon: %w", err)
+		}
+		fmt.Println(utils.RenderSuccess(utils.T("common.done")))
+
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Rolled back %d commits in %s; %d files sent to the team",
+			len(report.Reverted), shortCommit(report.Commit), report.Files)))
+		fmt.Printf("The previous tree is saved; 'axle checkpoint restore %s' brings it back\n", utils.LastAutoCheckpoint)
+		return nil
+	},
+}
+
+func printRollbackCommits(commits []utils.RollbackCommit) {
+	for _, commit := range commits {
+		fmt.Printf("  %s  %-16s %s\n", shortCommit(commit.Hash), commit.Author, truncateString(commit.Subject, 60))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+	rollbackCmd.Flags().BoolVarP(&rollbackForce, "force", "f", false, "Skip the confirmation prompt")
+	rollbackCmd.Flags().BoolVar(&rollbackDryRun, "dry-run", false, "Only show the commits that would be reverted")
+}
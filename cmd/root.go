@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/parzi-val/axle-file-sync/utils"
@@ -13,6 +18,12 @@ import (
 var (
 	// Global config that will be populated from local config file
 	config utils.AppConfig
+
+	// langFlag overrides the UI language from the config file and environment
+	langFlag string
+
+	// profileFlag reports where the command's time went when it finishes
+	profileFlag bool
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -34,99 +45,484 @@ Features:
 
 Use "axle [command] --help" for more information about a command.`,
 	
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		utils.SetLocale(langFlag)
+		if profileFlag {
+			utils.EnableProfiling()
+		}
+	},
+
 	// This runs when no subcommands are called
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println(utils.RenderTitle("🔄 Axle"))
-		fmt.Println("Welcome to Axle! Use --help to see available commands.")
+		fmt.Println(utils.RenderTitle(utils.T("root.title")))
+		fmt.Println(utils.T("root.welcome"))
 		fmt.Println("")
-		fmt.Println(utils.RenderInfo("Get started:"))
-		fmt.Println("  axle init     - Initialize a new team repository")
-		fmt.Println("  axle start    - Start file synchronization")
-		fmt.Println("  axle team     - View team member status")
-		fmt.Println("  axle chat     - Send a message to your team")
+		fmt.Println(utils.RenderInfo(utils.T("common.get_started")))
+		fmt.Println(utils.T("hint.init"))
+		fmt.Println(utils.T("hint.start"))
+		fmt.Println(utils.T("hint.team"))
+		fmt.Println(utils.T("hint.chat_short"))
 	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	rootCmd.PersistentFlags().StringVar(&langFlag, "lang", "", "UI language (en, es); defaults to the config file or $LANG")
+	rootCmd.PersistentFlags().BoolVar(&profileFlag, "profile", false, "Report where the command's time went (config, Redis, git, rendering) on stderr")
+	registerPlugins()
+	registerAliases()
+
+	start := time.Now()
+	err := rootCmd.Execute()
+	if profileFlag {
+		fmt.Fprint(os.Stderr, utils.ProfileReport(time.Since(start)))
+	}
+	if err != nil {
 		fmt.Println(utils.RenderError(err.Error()))
 		os.Exit(1)
 	}
 }
 
-// loadConfig loads the configuration from the local JSON file and ensures a persistent NodeID.
+// loadConfig loads the configuration from the local JSON file (migrating it to
+// the current schema, which also ensures a persistent NodeID).
 func loadConfig() error {
-	localCfg, err := loadConfigFromFile()
+	if err := loadLocalConfig(); err != nil {
+		return err
+	}
+
+	// Initialize Redis client, failing over between endpoints if several are
+	// configured. Teams on NATS keep no state in Redis, so there's none to reach
+	if teamTransport.name == utils.TransportNATS {
+		config.RedisClient = utils.NewNoRedisClient()
+	} else {
+		rdb, err := utils.NewFailoverRedisClient(config.RedisEndpoints)
+		if err != nil {
+			return fmt.Errorf("failed to connect to Redis at %s: %w", config.RedisAddr, err)
+		}
+		config.RedisClient = rdb
+	}
+	transport, err := utils.NewTransport(teamTransport.name, teamTransport.natsURL, config.RedisClient)
 	if err != nil {
 		return err
 	}
+	config.Transport = transport
+	utils.SetTransport(transport)
 
-	// Generate and save unique node ID if not present
-	if localCfg.NodeID == "" {
-		localCfg.NodeID = utils.GenerateNodeID()
-		if err := saveConfigToFile(localCfg); err != nil {
-			return fmt.Errorf("failed to save updated config with new NodeID: %w", err)
+	// Apply team-wide settings; commands still work if the team config is unavailable
+	teamConfig, err := utils.GetVerifiedTeamConfig(context.Background(), config.RedisClient, config.TeamID, config.TeamAdminKey)
+	switch {
+	case errors.Is(err, utils.ErrTeamConfigTampered):
+		fmt.Println(utils.RenderWarning(fmt.Sprintf("Ignoring team settings: %v", err)))
+	case err == nil:
+		applyTeamSettings(teamConfig)
+		// Members who joined before the config was signed pin the key on first sight
+		if config.TeamAdminKey == "" && teamConfig.AdminPublicKey != "" {
+			config.TeamAdminKey = teamConfig.AdminPublicKey
+			err := updateConfigFile(func(localCfg *LocalAppConfig) {
+				if localCfg.TeamAdminKey == "" {
+					localCfg.TeamAdminKey = teamConfig.AdminPublicKey
+				}
+			})
+			if err != nil {
+				log.Printf("[CONFIG] Failed to pin team admin key: %v", err)
+			}
 		}
 	}
 
+	return nil
+}
+
+// loadLocalConfig populates the global config from the local JSON file without
+// connecting to Redis, for commands the running daemon can serve.
+func loadLocalConfig() error {
+	localCfg, err := loadConfigFromFile()
+	if err != nil {
+		return err
+	}
+
 	// Populate global runtime config from loaded local config
 	config.NodeID = localCfg.NodeID
 	config.TeamID = localCfg.TeamID
 	config.Username = localCfg.Username
 	config.RootDir = localCfg.RootDir
-	config.RedisAddr = fmt.Sprintf("%s:%d", localCfg.RedisHost, localCfg.RedisPort)
+	config.RedisEndpoints = localCfg.redisEndpoints()
+	switch localCfg.Transport {
+	case "", utils.TransportRedis, utils.TransportNATS:
+	default:
+		return fmt.Errorf("invalid transport %q in %s (use: %s or %s)", localCfg.Transport, ConfigFileName, utils.TransportRedis, utils.TransportNATS)
+	}
+	teamTransport.name, teamTransport.natsURL = localCfg.Transport, localCfg.NATSURL
+	guestToken = localCfg.GuestToken
+	redisHA := localCfg.redisHA()
+	if err := redisHA.Validate(localCfg.RedisDB); err != nil {
+		return fmt.Errorf("invalid Redis settings in %s: %w", ConfigFileName, err)
+	}
+	// A running 'axle tunnel' carries Redis over SSH, to a single server
+	if endpoint, ok := tunnelEndpoint(localCfg.RootDir); ok {
+		config.RedisEndpoints = []utils.RedisEndpoint{endpoint}
+		redisHA = utils.RedisHA{}
+	}
+	config.RedisAddr = config.RedisEndpoints[0].Addr
+	if redisHA.Enabled() {
+		config.RedisAddr = redisHA.Label()
+	}
 	config.IgnorePatterns = localCfg.IgnorePatterns
-
-	// Initialize Redis client
-	rdb, err := utils.NewRedisClient(config.RedisAddr)
-	if err != nil {
-		return fmt.Errorf("failed to connect to Redis at %s: %w", config.RedisAddr, err)
+	config.LowPower = localCfg.LowPower
+	config.LowBandwidth = localCfg.LowBandwidth
+	config.MaxProcs = localCfg.MaxProcs
+	config.MemoryLimitMB = localCfg.MemoryLimitMB
+	config.CacheQuotaMB = localCfg.CacheQuotaMB
+	config.MinFreeDiskMB = localCfg.MinFreeDiskMB
+	config.MaxFileSizeMB = localCfg.MaxFileSizeMB
+	config.TeamAdminKey = localCfg.TeamAdminKey
+	config.ScanCommand = strings.TrimSpace(localCfg.ScanCommand)
+	config.BuildCommand = strings.TrimSpace(localCfg.BuildCommand)
+	config.Hub = localCfg.Hub
+	config.SyncInclude = localCfg.SyncInclude
+	config.SyncExclude = localCfg.SyncExclude
+	config.BatchWindow = time.Duration(localCfg.BatchWindowMs) * time.Millisecond
+	config.ConflictStrategy = utils.ConflictStrategy(localCfg.ConflictStrategy)
+	if err := utils.ValidateGitEnv(localCfg.GitEnv); err != nil {
+		return fmt.Errorf("invalid gitEnv in %s: %w", ConfigFileName, err)
+	}
+	utils.SetGitConfig(localCfg.GitPath, localCfg.GitEnv)
+	if err := utils.SetGitBackend(localCfg.GitBackend); err != nil {
+		return fmt.Errorf("invalid gitBackend in %s: %w", ConfigFileName, err)
+	}
+	utils.SetRedisAuth(localCfg.redisAuth())
+	utils.SetRedisHA(redisHA)
+	utils.SetBlobDir(localCfg.BlobDir)
+	utils.SetDirectTransfer(localCfg.DirectTransferMB, localCfg.DirectTransferPort)
+	// A blob directory inside the tree is generated output too
+	outputDirs := localCfg.OutputDirs
+	if localCfg.BlobDir != "" {
+		if blobDir, err := filepath.Abs(localCfg.BlobDir); err == nil {
+			outputDirs = append(append([]string(nil), outputDirs...), blobDir)
+		}
+	}
+	if err := utils.SetOutputDirs(localCfg.RootDir, outputDirs); err != nil {
+		return fmt.Errorf("invalid outputDirs in %s: %w", ConfigFileName, err)
+	}
+	if err := utils.SetProxy(localCfg.Proxy, localCfg.NoProxy); err != nil {
+		return fmt.Errorf("invalid proxy in %s: %w", ConfigFileName, err)
+	}
+	if err := utils.LoadSigningKey(localCfg.RootDir); err != nil {
+		return err
+	}
+	// Guests have no password; they sign with their token
+	if guestToken != "" {
+		utils.SetGuestSigningKey(localCfg.Username, guestToken)
 	}
-	config.RedisClient = rdb
-
 	return nil
 }
 
+// applyTeamSettings copies team-wide settings from the Redis team config into the runtime config.
+func applyTeamSettings(teamConfig utils.AxleConfig) {
+	config.PeerPriority = teamConfig.EffectivePriority()
+	config.AuthoritativeNode = teamConfig.AuthoritativeNode
+	config.HeartbeatInterval = time.Duration(teamConfig.HeartbeatIntervalSeconds) * time.Second
+	config.ProtectedPaths = teamConfig.ProtectedPaths
+	config.PersistBatches = teamConfig.PersistBatches
+	config.RetentionDays = teamConfig.RetentionDays
+	config.HealthInterval = time.Duration(teamConfig.StatsIntervalMinutes) * time.Minute
+	utils.SetFeatures(teamConfig.Features, teamConfig.FeatureRollout)
+	utils.SetGuests(teamConfig.Guests)
+	utils.SetMessageLimits(teamConfig.Limits)
+	utils.SetRoles(teamConfig.Roles)
+	utils.SetKicked(teamConfig.Kicked)
+}
+
 // LocalAppConfig represents the configuration stored in a local JSON file.
 type LocalAppConfig struct {
-	TeamID         string   `json:"teamID"`
-	Username       string   `json:"username"`
-	NodeID         string   `json:"nodeID"`
-	RootDir        string   `json:"rootDir"`
-	RedisHost      string   `json:"redisHost"`
-	RedisPort      int      `json:"redisPort"`
-	IgnorePatterns []string `json:"ignorePatterns"`
+	SchemaVersion  int                   `json:"schemaVersion"`
+	TeamID         string                `json:"teamID"`
+	Username       string                `json:"username"`
+	NodeID         string                `json:"nodeID"`
+	RootDir        string                `json:"rootDir"`
+	RedisHost      string                `json:"redisHost"`
+	RedisPort      int                   `json:"redisPort"`
+	RedisEndpoints []utils.RedisEndpoint `json:"redisEndpoints,omitempty"` // Redis servers in failover order; overrides redisHost/redisPort
+	RedisUsername  string                `json:"redisUsername,omitempty"`  // Redis ACL user, "" for the default user
+	RedisPassword  string                `json:"redisPassword,omitempty"`  // Redis password; AXLE_REDIS_PASSWORD overrides it
+	RedisDB        int                   `json:"redisDB,omitempty"`        // Redis database index
+	RedisTLS       bool                  `json:"redisTLS,omitempty"`       // Connect to Redis over TLS (rediss://)
+	RedisSentinels []string              `json:"redisSentinels,omitempty"` // Redis Sentinels (host:port) that find the master; overrides the endpoints
+	RedisCluster   []string              `json:"redisCluster,omitempty"`   // Redis Cluster nodes (host:port); overrides the endpoints
+	Transport      string                `json:"transport,omitempty"`      // Message transport: "redis" (default) or "nats"
+	NATSURL        string                `json:"natsURL,omitempty"`        // NATS server for the "nats" transport, e.g. "nats://host:4222"
+	IgnorePatterns []string              `json:"ignorePatterns"`
+	LowPower       string                `json:"lowPower,omitempty"`      // "off", "on", or "auto" (battery-aware)
+	LowBandwidth   string                `json:"lowBandwidth,omitempty"`  // "off", "on", or "auto" (latency-aware)
+	MaxProcs       int                   `json:"maxProcs,omitempty"`      // CPU parallelism cap, 0 for no limit
+	MemoryLimitMB  int                   `json:"memoryLimitMB,omitempty"` // Soft memory limit, 0 for no limit
+	Language       string                `json:"language,omitempty"`      // UI language, e.g. "en" or "es"
+	TeamAdminKey   string                `json:"teamAdminKey,omitempty"`  // Team admin's public key, pinned when joining
+	CacheQuotaMB   int                   `json:"cacheQuotaMB,omitempty"`  // Cap for evictable .axle caches, 0 for the default
+	MinFreeDiskMB  int                   `json:"minFreeDiskMB,omitempty"` // Low disk space warning threshold, 0 for the default
+	MaxFileSizeMB  int                   `json:"maxFileSizeMB,omitempty"` // Files above this aren't synced in full, 0 for the default
+	GitPath        string                `json:"gitPath,omitempty"`       // Git executable, "" for git from PATH
+	GitEnv         map[string]string     `json:"gitEnv,omitempty"`        // Extra environment for git, e.g. GIT_SSH_COMMAND
+	GitBackend     string                `json:"gitBackend,omitempty"`    // "library" (default) for commits and patches through go-git, or "exec"
+	ScanCommand    string                `json:"scanCommand,omitempty"`   // Scanner for incoming content, e.g. "clamdscan --no-summary"
+	Proxy          string                `json:"proxy,omitempty"`         // Proxy for Redis, e.g. "socks5://proxy:1080"; "direct" ignores the environment
+	NoProxy        string                `json:"noProxy,omitempty"`       // Hosts to reach directly, as in NO_PROXY
+	BlobDir        string                `json:"blobDir,omitempty"`       // Shared directory for binary file content instead of Redis
+	Hub            bool                  `json:"hub,omitempty"`           // Headless always-on node (init/join --bare-hub)
+	Aliases        map[string]string     `json:"aliases,omitempty"`       // Command aliases, e.g. {"p": "chat -p"}
+	SyncInclude    []string              `json:"syncInclude,omitempty"`   // Only sync paths matching these globs, e.g. ["frontend/**"]
+	SyncExclude    []string              `json:"syncExclude,omitempty"`   // Never sync paths matching these globs
+	BatchWindowMs  int                   `json:"batchWindowMs,omitempty"` // Fixed batch window, 0 to adapt it to activity
+	OutputDirs     []string              `json:"outputDirs,omitempty"`    // Directories generated output goes to; never synced
+	// Conflict strategy for 'axle start' without --conflict
+	ConflictStrategy string `json:"conflictStrategy,omitempty"`
+	// Name of the master the Redis Sentinels monitor
+	RedisSentinelMaster string `json:"redisSentinelMaster,omitempty"`
+	// Token of a guest pass, for members who joined with 'axle join --guest-token'
+	GuestToken string `json:"guestToken,omitempty"`
+	// Patches and binary files above this size go straight to teammates
+	// instead of through Redis or NATS, 0 to never send them directly
+	DirectTransferMB int `json:"directTransferMB,omitempty"`
+	// Port 'axle start' serves direct transfers on, 0 for any free port
+	DirectTransferPort int `json:"directTransferPort,omitempty"`
+	// Build or test command 'axle start' runs after incoming changes settle, e.g. "go test ./..."
+	BuildCommand string `json:"buildCommand,omitempty"`
+}
+
+// redisEndpoints returns the Redis servers to connect to, in priority order.
+func (c LocalAppConfig) redisEndpoints() []utils.RedisEndpoint {
+	if len(c.RedisEndpoints) > 0 {
+		return c.RedisEndpoints
+	}
+	return []utils.RedisEndpoint{{Addr: fmt.Sprintf("%s:%d", c.RedisHost, c.RedisPort)}}
+}
+
+// redisAuth returns the Redis login. A password in AXLE_REDIS_PASSWORD wins,
+// so it can stay out of the config file.
+func (c LocalAppConfig) redisAuth() utils.RedisAuth {
+	auth := utils.RedisAuth{Username: c.RedisUsername, Password: c.RedisPassword, DB: c.RedisDB, TLS: c.RedisTLS}
+	if password := os.Getenv("AXLE_REDIS_PASSWORD"); password != "" {
+		auth.Password = password
+	}
+	return auth
+}
+
+// redisHA returns the Sentinel or Cluster deployment to connect through, the
+// zero value for plain endpoints.
+func (c LocalAppConfig) redisHA() utils.RedisHA {
+	return utils.RedisHA{SentinelMaster: c.RedisSentinelMaster, SentinelAddrs: c.RedisSentinels, ClusterAddrs: c.RedisCluster}
+}
+
+// fileMode returns the config file's permissions: private once it holds a
+// Redis password, a NATS URL with credentials or a guest token.
+func (c LocalAppConfig) fileMode() os.FileMode {
+	if c.RedisPassword != "" || strings.Contains(c.NATSURL, "@") || c.GuestToken != "" {
+		return 0600
+	}
+	return 0644
 }
 
 // ConfigFilePath defines the standard location for the local Axle configuration file.
 const ConfigFileName = "axle_config.json"
 
+// teamTransport is the message transport named in the local config file,
+// connected by loadConfig
+var teamTransport struct {
+	name    string
+	natsURL string
+}
+
+// guestToken is the guest pass token from the local config file, "" for
+// members who joined with the team password
+var guestToken string
+
+// configDir caches the Axle root found by findConfigDir
+var configDir string
+
+// findConfigDir returns the Axle root: the nearest directory, starting from
+// the working directory and walking up, that holds the local config file.
+// Like git, this lets axle run from anywhere inside the synced tree.
+func findConfigDir() (string, error) {
+	if configDir != "" {
+		return configDir, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	dir, ok := findAxleRoot(cwd)
+	if !ok {
+		return "", fmt.Errorf("no %s found in %s or any parent directory", ConfigFileName, cwd)
+	}
+	configDir = dir
+	return dir, nil
+}
+
+// findAxleRoot walks up from dir looking for the local config file.
+func findAxleRoot(dir string) (string, bool) {
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ConfigFileName)); err == nil && !info.IsDir() {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// configFilePath returns the path of the local config file.
+func configFilePath() (string, error) {
+	dir, err := findConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, ConfigFileName), nil
+}
+
+// configLockPath is the lock that serializes access to the local config file
+// across concurrent axle invocations.
+func configLockPath() (string, error) {
+	dir, err := findConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return utils.AxlePath(dir, "config.lock"), nil
+}
+
+// checkNotInsideAxleRoot refuses to set up Axle in a folder that a parent
+// folder's Axle setup already syncs, which would sync its files twice.
+func checkNotInsideAxleRoot() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current working directory: %w", err)
+	}
+	if parent := filepath.Dir(cwd); parent != cwd {
+		if root, ok := findAxleRoot(parent); ok {
+			return fmt.Errorf("%s is inside the Axle folder %s, which already syncs it. Run axle commands from anywhere in that folder, or run 'axle leave' there first", cwd, root)
+		}
+	}
+	return nil
+}
+
+// rootRelPath turns a path given on the command line, relative to the working
+// directory, into the slash-separated path relative to the Axle root that
+// Axle uses to name files. loadLocalConfig must have been called.
+func rootRelPath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %s: %w", path, err)
+	}
+	relPath, err := filepath.Rel(config.RootDir, absPath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the synced folder %s", path, config.RootDir)
+	}
+	return filepath.ToSlash(relPath), nil
+}
+
 // loadConfigFromFile reads the LocalAppConfig from the local JSON file.
 func loadConfigFromFile() (LocalAppConfig, error) {
-	filePath := filepath.Join(".", ConfigFileName)
+	defer utils.ProfileSpan(utils.ProfileConfig, "load "+ConfigFileName)()
+	if _, err := configFilePath(); err != nil {
+		return LocalAppConfig{}, err
+	}
+
+	// Migration rewrites the file (and may assign the node ID), so two
+	// invocations starting at once must not both do it
+	lockPath, err := configLockPath()
+	if err != nil {
+		return LocalAppConfig{}, err
+	}
+	unlock, err := utils.AcquireFileLock(lockPath)
+	if err != nil {
+		return LocalAppConfig{}, err
+	}
+	defer unlock()
+
+	localCfg, err := readConfigFile()
+	if err != nil {
+		return LocalAppConfig{}, err
+	}
+
+	// The --lang flag wins over the configured language
+	utils.SetLocale(langFlag, localCfg.Language)
+	return localCfg, nil
+}
+
+// readConfigFile reads and, if needed, migrates the local config file. The
+// caller must hold the config lock.
+func readConfigFile() (LocalAppConfig, error) {
+	filePath, err := configFilePath()
+	if err != nil {
+		return LocalAppConfig{}, err
+	}
 	jsonData, err := os.ReadFile(filePath)
 	if err != nil {
 		return LocalAppConfig{}, fmt.Errorf("failed to read config file %s: %w", filePath, err)
 	}
 
+	// Upgrade older config files, keeping a backup of the original
+	migratedData, fromVersion, migrated, err := migrateConfigData(jsonData)
+	if err != nil {
+		return LocalAppConfig{}, fmt.Errorf("failed to load config %s: %w", filePath, err)
+	}
+
 	var localCfg LocalAppConfig
-	if err := json.Unmarshal(jsonData, &localCfg); err != nil {
+	if err := json.Unmarshal(migratedData, &localCfg); err != nil {
 		return LocalAppConfig{}, fmt.Errorf("failed to unmarshal config JSON from %s: %w", filePath, err)
 	}
+	// The config file marks the root, even if the folder was moved since init
+	localCfg.RootDir = filepath.Dir(filePath)
+
+	if migrated {
+		backupPath, err := backupConfigFile(localCfg.RootDir, jsonData, fromVersion)
+		if err != nil {
+			return LocalAppConfig{}, err
+		}
+		if err := writeConfigFile(localCfg); err != nil {
+			return LocalAppConfig{}, fmt.Errorf("failed to save migrated config: %w", err)
+		}
+		log.Printf("[CONFIG] Upgraded %s from schema v%d to v%d (backup: %s)", ConfigFileName, fromVersion, CurrentConfigSchema, backupPath)
+	}
 	return localCfg, nil
 }
 
-// saveConfigToFile saves the LocalAppConfig to the local JSON file.
-func saveConfigToFile(localCfg LocalAppConfig) error {
-	filePath := filepath.Join(".", ConfigFileName)
+// updateConfigFile applies update to the current contents of the local config
+// file and saves it, holding the config lock so concurrent invocations don't
+// overwrite each other's changes.
+func updateConfigFile(update func(*LocalAppConfig)) error {
+	lockPath, err := configLockPath()
+	if err != nil {
+		return err
+	}
+	unlock, err := utils.AcquireFileLock(lockPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	localCfg, err := readConfigFile()
+	if err != nil {
+		return err
+	}
+	update(&localCfg)
+	return writeConfigFile(localCfg)
+}
+
+// writeConfigFile atomically saves the LocalAppConfig to the local JSON file.
+// The caller must hold the config lock.
+func writeConfigFile(localCfg LocalAppConfig) error {
+	filePath, err := configFilePath()
+	if err != nil {
+		return err
+	}
 	jsonData, err := json.MarshalIndent(localCfg, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal local config to JSON: %w", err)
 	}
 
-	if err := os.WriteFile(filePath, jsonData, 0644); err != nil {
+	if err := utils.WriteFileAtomic(filePath, jsonData, localCfg.fileMode()); err != nil {
 		return fmt.Errorf("failed to write local config to file %s: %w", filePath, err)
 	}
 	return nil
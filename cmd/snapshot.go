@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// snapshotCmd represents the snapshot command
+var snapshotCmd = &cobra.Command{
+	Use:     "snapshot",
+	Aliases: []string{"checkpoint"},
+	Short:   "Create, list, compare, and restore named snapshots of the repository",
+	Long: utils.RenderTitle("📸 Snapshots") + `
+
+Snapshots are named checkpoints of the synced tree (stored as git tags
+under axle/snapshot/). Use them to mark milestones like "before-demo"
+and later see exactly what changed since.
+
+Axle also checkpoints the working tree, including uncommitted files, before
+risky operations: applying large incoming batches, resolving conflicts in
+favour of a teammate, and resets. The newest one is always 'last-auto'.
+
+Examples:
+  axle snapshot create before-demo
+  axle snapshot list
+  axle snapshot diff before-demo current
+  axle snapshot diff before-demo after-demo
+  axle checkpoint restore last-auto`,
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a snapshot of the current HEAD",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		localCfg, err := loadConfigFromFile()
+		if err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		if err := utils.CreateCheckpoint(localCfg.RootDir, args[0]); err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Created snapshot %s", args[0])))
+		return nil
+	},
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List snapshots",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		localCfg, err := loadConfigFromFile()
+		if err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		checkpoints, err := utils.ListCheckpoints(localCfg.RootDir)
+		if err != nil {
+			return err
+		}
+		if len(checkpoints) == 0 {
+			fmt.Println(utils.RenderInfo("No snapshots yet. Create one with 'axle snapshot create <name>'"))
+			return nil
+		}
+
+		fmt.Println(utils.RenderTitle("📸 Snapshots"))
+		for _, checkpoint := range checkpoints {
+			fmt.Printf("  %-24s %s  %s\n", checkpoint.Name, shortHash(checkpoint.Commit), formatTime(checkpoint.Created))
+		}
+		return nil
+	},
+}
+
+var snapshotDiffCmd = &cobra.Command{
+	Use:   "diff <snapshotA> [snapshotB]",
+	Short: "Show files added, removed, or changed between two snapshots",
+	Long: utils.RenderTitle("📸 Snapshot Diff") + `
+
+Compares two snapshots and prints added, removed, and changed files with
+size deltas. If the second snapshot is omitted (or is 'current'), the
+first snapshot is compared against the current working tree. Commits and
+branch names are accepted too.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		localCfg, err := loadConfigFromFile()
+		if err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		toName := utils.CurrentTreeRef
+		if len(args) == 2 {
+			toName = args[1]
+		}
+
+		fromRef, err := utils.ResolveCheckpointRef(localCfg.RootDir, args[0])
+		if err != nil {
+			return err
+		}
+		toRef, err := utils.ResolveCheckpointRef(localCfg.RootDir, toName)
+		if err != nil {
+			return err
+		}
+
+		fromManifest, err := utils.TreeManifest(localCfg.RootDir, fromRef)
+		if err != nil {
+			return err
+		}
+		toManifest, err := utils.TreeManifest(localCfg.RootDir, toRef)
+		if err != nil {
+			return err
+		}
+
+		diffs := utils.DiffManifests(fromManifest, toManifest)
+		fmt.Println(utils.RenderTitle(fmt.Sprintf("📸 %s → %s", args[0], toName)))
+		if len(diffs) == 0 {
+			fmt.Println(utils.RenderSuccess("No differences"))
+			return nil
+		}
+
+		var added, removed, changed int
+		var totalDelta int64
+		for _, diff := range diffs {
+			delta := diff.NewSize - diff.OldSize
+			totalDelta += delta
+			switch diff.Status {
+			case "added":
+				added++
+				fmt.Printf("  + %-50s %s\n", diff.Path, formatSizeDelta(delta))
+			case "removed":
+				removed++
+				fmt.Printf("  - %-50s %s\n", diff.Path, formatSizeDelta(delta))
+			case "changed":
+				changed++
+				fmt.Printf("  ~ %-50s %s\n", diff.Path, formatSizeDelta(delta))
+			}
+		}
+
+		fmt.Println()
+		fmt.Println(utils.RenderInfo(fmt.Sprintf("%d added, %d removed, %d changed (%s total)",
+			added, removed, changed, formatSizeDelta(totalDelta))))
+		return nil
+	},
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Put the working tree back the way it was at a snapshot",
+	Long: utils.RenderTitle("📸 Restore Snapshot") + `
+
+Rewrites your files to match a snapshot, leaving HEAD where it is so the
+difference shows up as ordinary local changes. Untracked files that aren't
+in the snapshot are left alone.
+
+The current state is saved as a new automatic checkpoint first, so a
+restore can be undone too. If 'axle start' is running, the restored files
+sync to your team like any other edit.
+
+Examples:
+  axle checkpoint restore last-auto
+  axle checkpoint restore before-demo`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		localCfg, err := loadConfigFromFile()
+		if err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		if err := utils.RestoreCheckpoint(localCfg.RootDir, args[0]); err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Restored snapshot %s", args[0])))
+		fmt.Println(utils.RenderInfo("The previous state was saved first; see 'axle snapshot list'"))
+		return nil
+	},
+}
+
+// formatSizeDelta formats a signed size difference
+func formatSizeDelta(delta int64) string {
+	if delta < 0 {
+		return "-" + formatFileSize(-delta)
+	}
+	return "+" + formatFileSize(delta)
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotCreateCmd, snapshotListCmd, snapshotDiffCmd, snapshotRestoreCmd)
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	snipName    string
+	snipTTL     time.Duration
+	snipOutFile string
+)
+
+// snipCmd groups the shared clipboard commands
+var snipCmd = &cobra.Command{
+	Use:   "snip",
+	Short: "Share snippets with your team through a shared clipboard",
+	Long: utils.RenderTitle("📋 Shared Clipboard") + `
+
+Share a code snippet, stack trace, or command with the team without
+committing it or pasting a wall of text into chat. Snippets live in Redis and
+expire on their own (after a day by default).
+
+Examples:
+  axle snip push < main.go
+  go test ./... 2>&1 | axle snip push --name "test failure"
+  axle snip push notes.txt --ttl 2h
+  axle snip list
+  axle snip pull            # Print the newest snippet
+  axle snip pull 7 -o fix.go`,
+}
+
+var snipPushCmd = &cobra.Command{
+	Use:   "push [file]",
+	Short: "Share a file, or standard input, as a snippet",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var content []byte
+		var err error
+		name := snipName
+		if len(args) == 1 {
+			content, err = os.ReadFile(args[0])
+			if name == "" {
+				name = filepath.Base(args[0])
+			}
+		} else {
+			content, err = io.ReadAll(io.LimitReader(os.Stdin, utils.MaxSnippetSize+1))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read snippet: %w", err)
+		}
+
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		snippet, err := utils.PushSnippet(ctx, config, name, content, snipTTL)
+		if err != nil {
+			return err
+		}
+
+		announceChatAction(ctx, config, fmt.Sprintf("shared %s; get it with 'axle snip pull %d'", describeSnippet(snippet), snippet.ID))
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Shared snippet #%d (expires in %v)", snippet.ID, snipTTL)))
+		return nil
+	},
+}
+
+var snipPullCmd = &cobra.Command{
+	Use:   "pull [id]",
+	Short: "Print a snippet, the newest one by default",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var id int64
+		if len(args) == 1 {
+			var err error
+			if id, err = strconv.ParseInt(strings.TrimPrefix(args[0], "#"), 10, 64); err != nil || id < 1 {
+				return fmt.Errorf("invalid snippet ID %q", args[0])
+			}
+		}
+
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		snippet, err := utils.GetSnippet(context.Background(), config, id)
+		if err != nil {
+			return err
+		}
+
+		if snipOutFile != "" {
+			if err := os.WriteFile(snipOutFile, snippet.Content, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", snipOutFile, err)
+			}
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("Saved snippet #%d to %s", snippet.ID, snipOutFile)))
+			return nil
+		}
+
+		// Only the content goes to stdout so it can be piped or redirected
+		fmt.Fprintf(os.Stderr, "# Snippet #%d: %s\n", snippet.ID, describeSnippet(snippet))
+		os.Stdout.Write(snippet.Content)
+		return nil
+	},
+}
+
+var snipListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the team's snippets that haven't expired",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		snippets, err := utils.ListSnippets(context.Background(), config)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(utils.RenderInfo("📋 Snippets"))
+		if len(snippets) == 0 {
+			fmt.Println("  No snippets")
+			return nil
+		}
+		for _, snippet := range snippets {
+			expires := time.Until(time.Unix(snippet.ExpiresAt, 0)).Round(time.Minute)
+			fmt.Printf("  #%-4d %s  (%s, %s, expires in %v)\n", snippet.ID, describeSnippet(snippet), snippet.Author,
+				formatTime(time.Unix(snippet.Timestamp, 0)), expires)
+		}
+		return nil
+	},
+}
+
+// describeSnippet names a snippet by its name, or its first line, and size
+func describeSnippet(snippet utils.Snippet) string {
+	name := snippet.Name
+	if name == "" {
+		firstLine, _, _ := strings.Cut(strings.TrimSpace(string(snippet.Content)), "\n")
+		name = strconv.Quote(truncateString(firstLine, 40))
+	}
+	return fmt.Sprintf("%s (%s)", name, formatFileSize(int64(len(snippet.Content))))
+}
+
+func init() {
+	rootCmd.AddCommand(snipCmd)
+	snipCmd.AddCommand(snipPushCmd, snipPullCmd, snipListCmd)
+	snipPushCmd.Flags().StringVar(&snipName, "name", "", "Name to show for the snippet (default: the file name)")
+	snipPushCmd.Flags().DurationVar(&snipTTL, "ttl", utils.DefaultSnippetTTL, "How long the snippet is kept")
+	snipPullCmd.Flags().StringVarP(&snipOutFile, "output", "o", "", "Write the snippet to a file instead of standard output")
+}
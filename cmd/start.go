@@ -3,11 +3,12 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
@@ -18,7 +19,15 @@ import (
 )
 
 var (
-	conflictMode string // Flag for conflict resolution strategy
+	conflictMode     string // Flag for conflict resolution strategy
+	lowPowerFlag     string // Flag for low-power mode: off, on, or auto
+	lowBandwidthFlag string // Flag for low-bandwidth mode: off, on, or auto
+	maxProcsFlag     int    // Flag for CPU parallelism cap
+	memoryLimitMB    int    // Flag for soft memory limit in MB
+	traceFlag        bool   // Flag for trace mode
+	offlineFlag      bool   // Flag for starting without Redis
+	noReconnectFlag  bool   // Flag for staying offline until 'axle sync-now'
+	pprofAddr        string // Flag for serving Go pprof profiles
 )
 
 // startCmd represents the start command
@@ -37,93 +46,332 @@ The process runs continuously until you press Ctrl+C for graceful shutdown.
 All team members running 'axle start' will be synchronized in real-time.`,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Load configuration
-		if err := loadConfig(); err != nil {
+		// Load configuration (offline, without waiting for Redis)
+		if offlineFlag {
+			if err := loadLocalConfig(); err != nil {
+				return fmt.Errorf("configuration error: %w. Please run 'axle init' or 'axle join' first", err)
+			}
+			if teamTransport.name == utils.TransportNATS {
+				return fmt.Errorf("--offline needs Redis; over NATS, Axle reconnects by itself when the server comes back")
+			}
+			rdb, err := utils.NewLazyRedisClient(config.RedisEndpoints)
+			if err != nil {
+				return err
+			}
+			config.RedisClient = rdb
+			config.Transport = utils.NewRedisTransport(rdb)
+		} else if err := loadConfig(); err != nil {
 			return fmt.Errorf("configuration error: %w. Please run 'axle init' or 'axle join' first", err)
 		}
 		defer config.RedisClient.Close()
+		defer config.Transport.Close()
 
-		// Fetch team config from Redis
-		teamConfigKey := fmt.Sprintf("axle:config:%s", config.TeamID)
-		teamConfigData, err := config.RedisClient.Get(context.Background(), teamConfigKey).Bytes()
+		// Only one daemon per directory, or every change is committed twice
+		releaseLock, err := utils.AcquireSessionLock(config.RootDir)
 		if err != nil {
-			return fmt.Errorf("failed to fetch team config from Redis: %w. Make sure the team exists and the team ID is correct", err)
+			return err
 		}
+		defer releaseLock()
 
+		// Fetch team config from Redis, or use the copy saved by the last online start
 		var teamConfig utils.AxleConfig
-		if err := json.Unmarshal(teamConfigData, &teamConfig); err != nil {
-			return fmt.Errorf("failed to unmarshal team config: %w", err)
+		if offlineFlag {
+			teamConfig, err = utils.LoadCachedTeamConfig(config.RootDir)
+			if err != nil {
+				return err
+			}
+		} else {
+			teamConfig, err = utils.GetVerifiedTeamConfig(context.Background(), config.RedisClient, config.TeamID, config.TeamAdminKey)
+			if errors.Is(err, utils.ErrTeamConfigTampered) {
+				return fmt.Errorf("%w. Someone with Redis access changed it; refusing to start", err)
+			} else if err != nil {
+				return fmt.Errorf("%w. Make sure the team exists and the team ID is correct", err)
+			}
 		}
 
-		// Prompt for password
-		fmt.Print("Enter the team password: ")
-		bytePassword, err := term.ReadPassword(int(syscall.Stdin))
-		if err != nil {
-			return fmt.Errorf("failed to read password: %w", err)
+		// Prompt for password, unless a headless start passes it in the
+		// environment or this is a guest, who has a token instead
+		password := os.Getenv("AXLE_PASSWORD")
+		if password == "" && guestToken == "" {
+			fmt.Print(utils.T("prompt.team_password"))
+			bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+			if err != nil {
+				if config.Hub {
+					return fmt.Errorf("failed to read password: %w. Set AXLE_PASSWORD to start the hub without a terminal", err)
+				}
+				return fmt.Errorf("failed to read password: %w", err)
+			}
+			password = string(bytePassword)
+			fmt.Println()
 		}
-		password := string(bytePassword)
-		fmt.Println()
 
 		// Verify password
-		if err := bcrypt.CompareHashAndPassword([]byte(teamConfig.PasswordHash), []byte(password)); err != nil {
-			return fmt.Errorf("invalid password")
+		if err := checkTeamCredential(teamConfig, password); err != nil {
+			return err
+		}
+		// Members sign what they publish with a key derived from the password
+		if guestToken == "" {
+			if err := utils.SaveSigningKey(config.RootDir, config.TeamID, password); err != nil {
+				return err
+			}
+			utils.SetPasswordHash(teamConfig.PasswordHash)
+		}
+		if !offlineFlag {
+			if err := utils.CacheTeamConfig(config.RootDir, teamConfig); err != nil {
+				log.Printf("[OFFLINE] Could not save team settings for offline use: %v", err)
+			}
 		}
 
 		ctx := context.Background()
 
-		fmt.Println(utils.RenderTitle("🔄 Starting Axle"))
-		fmt.Printf("Team: %s | User: %s | Directory: %s\n",
-			config.TeamID, config.Username, config.RootDir)
-		fmt.Println(utils.RenderInfo("Press Ctrl+C to stop"))
+		fmt.Println(utils.RenderTitle(utils.T("start.title")))
+		fmt.Println(utils.T("start.summary", config.TeamID, config.Username, config.RootDir))
+		fmt.Println(utils.RenderInfo(utils.T("start.stop_hint")))
 		fmt.Println("")
+		if offlineFlag {
+			fmt.Println(utils.RenderWarning(utils.T("start.offline")))
+		} else if !config.Hub {
+			printBoard(ctx, config)
+			// Announcements sent while we were offline still need acknowledging
+			if pending, err := utils.PendingAnnouncements(ctx, config); err == nil && len(pending) > 0 {
+				printPendingAnnouncements(pending)
+			}
+		}
+
+		// The flag overrides the local config file. Nobody edits on a hub,
+		// so teammates' changes win there unless configured otherwise
+		conflictFlagSet = cmd.Flags().Changed("conflict")
+		if !conflictFlagSet {
+			if config.ConflictStrategy != "" {
+				conflictMode = string(config.ConflictStrategy)
+			} else if config.Hub {
+				conflictMode = string(utils.ConflictStrategyTheirs)
+			}
+		}
 
 		// Validate conflict mode
-		strategy := utils.ConflictStrategy(conflictMode)
-		switch strategy {
-		case utils.ConflictStrategyTheirs, utils.ConflictStrategyMine,
-			utils.ConflictStrategyMerge, utils.ConflictStrategyBackup,
-			utils.ConflictStrategyInteractive:
-			// Valid strategy
-			fmt.Printf("Conflict resolution mode: %s\n", conflictMode)
-		default:
-			return fmt.Errorf("invalid conflict mode: %s (use: theirs, mine, merge, backup, or interactive)", conflictMode)
+		strategy, err := parseConflictStrategy(conflictMode)
+		if err != nil {
+			return err
 		}
+		fmt.Println(utils.T("start.conflict_mode", conflictMode))
 
 		// Store conflict strategy in config for use in handleSyncMessage
 		config.ConflictStrategy = strategy
+		applyTeamSettings(teamConfig)
+		if teamConfig.AuthoritativeNode != "" {
+			fmt.Println(utils.T("start.authority", teamConfig.AuthoritativeNode))
+		}
+		if config.Hub {
+			fmt.Println(utils.RenderInfo(utils.T("start.hub")))
+			if teamConfig.AuthoritativeNode != config.Username {
+				fmt.Println(utils.RenderWarning(utils.T("start.hub_authority", config.Username)))
+			}
+			if !teamConfig.PersistBatches {
+				fmt.Println(utils.RenderWarning(utils.T("start.hub_no_history")))
+			}
+		}
+
+		// Flags override the local config file
+		if cmd.Flags().Changed("low-power") || config.LowPower == "" {
+			config.LowPower = lowPowerFlag
+		}
+		if err := utils.ValidateLowPowerSetting(config.LowPower); err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("low-bandwidth") || config.LowBandwidth == "" {
+			config.LowBandwidth = lowBandwidthFlag
+		}
+		if err := utils.ValidateLowBandwidthSetting(config.LowBandwidth); err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("max-procs") {
+			config.MaxProcs = maxProcsFlag
+		}
+		if cmd.Flags().Changed("memory-limit") {
+			config.MemoryLimitMB = memoryLimitMB
+		}
+		utils.ApplyResourceLimits(config.MaxProcs, config.MemoryLimitMB)
+		utils.SetMaxFileSize(int64(config.MaxFileSizeMB) << 20)
+		utils.SetBatchWindow(config.BatchWindow)
+		config.Trace = traceFlag
 
 		// Start Axle with presence tracking
-		startAxleWithPresence(ctx, config)
+		startAxleWithPresence(ctx, config, password)
 
 		return nil
 	},
 }
 
-// startAxleWithPresence starts Axle with integrated presence tracking
-func startAxleWithPresence(ctx context.Context, cfg utils.AppConfig) {
+// checkTeamCredential verifies the team password, or the guest token of a
+// member who joined as a guest.
+func checkTeamCredential(teamConfig utils.AxleConfig, password string) error {
+	if teamConfig.WasKicked(config.Username) {
+		return fmt.Errorf("%w; ask the team admin to run 'axle team kick --undo %s'", utils.ErrKicked, config.Username)
+	}
+	if guestToken != "" {
+		return teamConfig.VerifyGuestToken(config.Username, guestToken)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(teamConfig.PasswordHash), []byte(password)); err != nil {
+		return errors.New(utils.T("error.invalid_password"))
+	}
+	return nil
+}
+
+// startAxleWithPresence starts Axle with integrated presence tracking. With
+// --offline only the local services start; the rest follow once Redis is
+// reachable and the password still matches the team's.
+func startAxleWithPresence(ctx context.Context, cfg utils.AppConfig, password string) {
 	// Create a cancellable context for coordinated shutdown
 	appCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	// 1. Start presence heartbeat system
-	go utils.StartPresenceHeartbeat(appCtx, cfg)
-	log.Printf("[PRESENCE] Started heartbeat system (Node ID: %s)", cfg.NodeID)
+	// Keep Axle's own files in .axle and out of git before anything writes there
+	if err := utils.PrepareAxleDir(cfg.RootDir); err != nil {
+		log.Printf("[AXLE] %v", err)
+	}
+	// Keep a copy of the log for 'axle debug-bundle'
+	if stopLog, err := utils.StartDaemonLog(cfg.RootDir); err != nil {
+		log.Printf("[AXLE] %v", err)
+	} else {
+		defer stopLog()
+	}
+
+	// Send nothing until we're back online; changes wait in the outbox
+	if offlineFlag {
+		utils.SetOfflineMode(true)
+	}
+	// A node behind the team's batch stream would publish changes that
+	// conflict with everything it missed, so it holds them until caught up
+	behind := !offlineFlag && behindTeamLedger(appCtx, cfg)
+	if behind {
+		utils.SetCatchingUp(true)
+	}
+	// Stay paused if 'axle pause' was in effect when the daemon last stopped
+	utils.LoadPauseState(cfg.RootDir)
+	if utils.IsSyncPaused() {
+		log.Println("[PAUSE] Sync is paused; run 'axle resume' to publish and apply changes again")
+	}
+
+	// 0. Apply low-power mode (and follow the power source in auto mode)
+	go utils.StartPowerMonitor(appCtx, cfg.LowPower)
+
+	// Ask teammates for lean payloads on a slow link (auto mode follows heartbeat latency)
+	utils.SetLowBandwidthSetting(cfg.LowBandwidth)
+
+	// Report daemon state for 'axle status'
+	go utils.StartStateReporter(appCtx, cfg)
 
-	// 2. Start the file system watcher
+	// Serve Go profiles for performance bug reports
+	if pprofAddr != "" {
+		go utils.StartPprofServer(appCtx, pprofAddr)
+	}
+
+	// Keep .axle caches within quota and warn before the disk fills
+	go utils.StartDiskMonitor(appCtx, cfg)
+
+	// Remove .rej files and backups once their conflict is resolved
+	go utils.StartArtifactCleanup(appCtx, cfg)
+
+	// Publish changes held for conflict markers once their files are resolved
+	go utils.StartConflictedRelease(appCtx, cfg)
+
+	// Check that teammates' changes still build, and tell the team
+	go utils.StartBuildRunner(appCtx, cfg)
+
+	// 1. Start the file system watcher
 	go utils.WatchDirectory(appCtx, cfg)
 	log.Println("[WATCHER] Started file system watcher")
 
-	// 3. Start the Redis subscriber (with presence handling)
-	go startRedisSubscriberWithPresence(appCtx, cfg)
-	log.Println("[SUBSCRIBER] Started Redis subscriber")
+	// Accept local control requests (e.g. manual commits from the post-commit hook)
+	if _, err := utils.InstallPostCommitHook(cfg.RootDir); err != nil {
+		log.Printf("[HOOKS] Could not install post-commit hook: %v", err)
+	}
+	utils.RegisterControlHandler("publish-commit", func(req utils.ControlRequest) (string, error) {
+		count, err := utils.QueueManualCommit(cfg, req.Args["commit"])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("queued %d files for publishing", count), nil
+	})
+	utils.RegisterControlHandler("sync-now", func(req utils.ControlRequest) (string, error) {
+		if !utils.RequestSyncNow() {
+			return "already online; changes are published as they happen", nil
+		}
+		return "reconnecting to Redis; the daemon log shows the result", nil
+	})
+	utils.RegisterControlHandler("pause", func(req utils.ControlRequest) (string, error) {
+		if utils.IsSyncPaused() {
+			return "already paused since " + formatTime(utils.PausedSince()), nil
+		}
+		if err := utils.PauseSync(cfg.RootDir); err != nil {
+			return "", err
+		}
+		log.Println("[PAUSE] Sync paused; local changes wait in the outbox and team changes are held")
+		return "paused", nil
+	})
+	utils.RegisterControlHandler("resume", func(req utils.ControlRequest) (string, error) {
+		if !utils.IsSyncPaused() {
+			return "not paused", nil
+		}
+		held, err := utils.ResumeSync(cfg.RootDir, func(syncMeta utils.SyncMetadata) {
+			admitSyncBatch(withLiveSettings(cfg), syncMeta)
+		})
+		if err != nil {
+			return "", err
+		}
+		log.Printf("[PAUSE] Sync resumed; applied %d held batches", held)
+		return fmt.Sprintf("resumed; applied %d held batches", held), nil
+	})
+	registerDaemonHandlers(appCtx, cfg)
 
-	// 4. Handle OS signals for graceful shutdown
+	// Signals and 'axle leave' both shut the daemon down gracefully
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	utils.RegisterControlHandler("stop", func(req utils.ControlRequest) (string, error) {
+		select {
+		case sigCh <- syscall.SIGTERM:
+		default:
+		}
+		return "stopping", nil
+	})
+	go utils.StartControlServer(appCtx, cfg)
+
+	// Apply edits to axle_config.json without a restart
+	if path, err := configFilePath(); err == nil {
+		go utils.WatchConfigFile(appCtx, path, func() {
+			if err := reloadLocalSettings(cfg); err != nil {
+				log.Printf("[CONFIG] Keeping the current settings: %v", err)
+			}
+		})
+	}
+
+	// 2. Start the services that need Redis
+	if offlineFlag {
+		log.Println("[OFFLINE] Working offline; changes are kept in the outbox until Redis is reachable")
+		go goOnlineWhenReachable(appCtx, cfg, password)
+	} else if behind {
+		go func() {
+			replayMissedBatches(appCtx, cfg, "[CATCHUP]", "catching up on start")
+			utils.SetCatchingUp(false)
+			log.Println("[CATCHUP] Caught up with the team; publishing local changes")
+			startOnlineServices(appCtx, cfg)
+		}()
+	} else {
+		startOnlineServices(appCtx, cfg)
+	}
+
+	// Keep the team settings current; stops this daemon once it may no longer run
+	go utils.StartTeamWatcher(appCtx, cfg, func() {
+		select {
+		case sigCh <- syscall.SIGTERM:
+		default:
+		}
+	})
 
 	log.Println("[AXLE] All systems started. Watching for changes and team activity...")
 
-	// 5. Main event loop: wait for a shutdown signal
+	// 6. Main event loop: wait for a shutdown signal
 	<-sigCh
 	log.Println("[AXLE] Shutdown signal received. Gracefully shutting down...")
 
@@ -137,8 +385,19 @@ func startAxleWithPresence(ctx context.Context, cfg utils.AppConfig) {
 	// Clean up any remaining batch processing
 	utils.ForceProcessPendingBatch(cfg)
 
-	// Clean up presence information
-	utils.CleanupPresence(ctx, cfg)
+	if utils.IsOfflineMode() || utils.IsCatchingUp() {
+		// Keep unpublished work for the next start
+		utils.FlushOfflineChanges(cfg.RootDir)
+	}
+	if !utils.IsOfflineMode() {
+		// Remember how far through the team's batch stream we got, for 'axle catchup'
+		if cfg.PersistBatches {
+			utils.AdvanceStreamPosition(ctx, cfg)
+		}
+
+		// Clean up presence information
+		utils.CleanupPresence(ctx, cfg)
+	}
 
 	// Close Redis connection
 	if cfg.RedisClient != nil {
@@ -151,35 +410,231 @@ func startAxleWithPresence(ctx context.Context, cfg utils.AppConfig) {
 	log.Println("[AXLE] Shutdown complete")
 }
 
+// startOnlineServices starts everything that talks to the team through Redis
+func startOnlineServices(ctx context.Context, cfg utils.AppConfig) {
+	// Alert if the team config changes without the admin's signature
+	go utils.StartTeamConfigGuard(ctx, cfg)
+
+	// Services that keep the team's state in Redis; teams on NATS go without
+	if cfg.HasRedis() {
+		// Store published batches for teammates who are offline
+		go utils.StartBatchPersister(ctx, cfg)
+
+		// Keep a snapshot in Redis for members who join while nobody is online
+		go utils.StartBootstrapRefresher(ctx, cfg)
+
+		// Share the team scratchpad (TEAM_NOTES.md) outside git
+		go utils.StartScratchpad(ctx, cfg)
+
+		// Record delivery, health and activity for 'axle status' and 'axle stats'
+		go utils.StartSentBatchRecorder(ctx, cfg)
+		go utils.StartHealthBroadcaster(ctx, cfg)
+		go utils.StartFileStatsRecorder(ctx, cfg)
+		if cfg.Trace {
+			go utils.StartTraceRecorder(ctx, cfg)
+			log.Println("[TRACE] Trace mode on; run 'axle trace <id>' to follow a change")
+		}
+	} else {
+		log.Printf("[%s] Batch history, the scratchpad, delivery tracking and stats need Redis and are off", strings.ToUpper(cfg.Transport.Name()))
+	}
+
+	// Serve large changes straight to teammates; heartbeats advertise the port
+	if err := utils.ServeDirectTransfers(ctx, cfg); err != nil {
+		log.Printf("[DIRECT] %v; large changes go through the team's server instead", err)
+	}
+
+	// Start presence heartbeat system
+	go utils.StartPresenceHeartbeat(ctx, cfg)
+	// Reconnect and re-announce when a laptop moves to another network
+	go utils.StartNetworkMonitor(ctx, cfg)
+	log.Printf("[PRESENCE] Started heartbeat system (Node ID: %s)", cfg.NodeID)
+
+	// Start event bus subscribers
+	go startChatNotifier(ctx, cfg)
+	go utils.StartErrorReporter(ctx, cfg)
+
+	// Start the Redis subscriber (with presence handling)
+	go startRedisSubscriberWithPresence(ctx, cfg)
+	log.Println("[SUBSCRIBER] Started Redis subscriber")
+
+	// Replay what was published while Redis failed over
+	go replayAfterRedisFailover(ctx, cfg)
+}
+
+// replayAfterRedisFailover replays the team's batches each time Redis fails
+// over to another server. The subscription reconnects and resubscribes on
+// its own, but whatever was published in between never reached it.
+func replayAfterRedisFailover(ctx context.Context, cfg utils.AppConfig) {
+	events, unsubscribe := utils.Events.Subscribe(utils.TopicRedisFailover)
+	defer unsubscribe()
+
+	for {
+		select {
+		case event := <-events:
+			failover, ok := event.Payload.(utils.RedisFailoverEvent)
+			if !ok {
+				continue
+			}
+			log.Printf("[REDIS] Failed over from %s to %s", failover.From.Label(), failover.To.Label())
+			if cfg.PersistBatches {
+				replayMissedBatches(ctx, cfg, "[REDIS]", "Redis failover")
+			} else {
+				log.Println("[REDIS] Batch persistence is off, so changes published during the failover can't be replayed; run 'axle elect' to check for divergence")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// goOnlineWhenReachable brings a daemon started with --offline online once
+// Redis answers: it checks the password against the team's current config,
+// replays what the team published in the meantime, and only then lets the
+// outbox publish the offline backlog.
+func goOnlineWhenReachable(ctx context.Context, cfg utils.AppConfig, password string) {
+	if err := utils.WaitForConnectivity(ctx, cfg.RedisClient, !noReconnectFlag); err != nil {
+		return
+	}
+	log.Println("[OFFLINE] Redis is reachable again; reconnecting")
+
+	teamConfig, err := utils.GetVerifiedTeamConfig(ctx, cfg.RedisClient, cfg.TeamID, cfg.TeamAdminKey)
+	if err != nil {
+		log.Printf("[OFFLINE] Staying offline: %v", err)
+		return
+	}
+	if err := checkTeamCredential(teamConfig, password); err != nil {
+		if guestToken != "" {
+			log.Printf("[OFFLINE] Staying offline: %v", err)
+		} else {
+			log.Println("[OFFLINE] Staying offline: the team password changed; restart 'axle start' with the new one")
+		}
+		return
+	}
+	if err := utils.CacheTeamConfig(cfg.RootDir, teamConfig); err != nil {
+		log.Printf("[OFFLINE] Could not save team settings for offline use: %v", err)
+	}
+	// Pick up settings the admin changed while we were away
+	applyTeamSettings(teamConfig)
+	cfg = config
+
+	if cfg.PersistBatches {
+		replayMissedBatches(ctx, cfg, "[OFFLINE]", "reconnecting after working offline")
+	} else {
+		log.Println("[OFFLINE] Batch persistence is off, so the team's changes from while you were offline can't be replayed; run 'axle elect' to check for divergence")
+	}
+
+	utils.SetOfflineMode(false)
+	startOnlineServices(ctx, cfg)
+	log.Println("[OFFLINE] Back online; publishing the changes made offline")
+}
+
+// behindTeamLedger reports whether the team's batch stream holds batches
+// this node hasn't handled yet.
+func behindTeamLedger(ctx context.Context, cfg utils.AppConfig) bool {
+	if !cfg.PersistBatches {
+		return false
+	}
+	position, err := utils.StreamPosition(ctx, cfg)
+	if err == nil {
+		var head string
+		if head, err = utils.StreamHead(ctx, cfg); err == nil {
+			if position == head {
+				return false
+			}
+			log.Printf("[CATCHUP] Behind the team's batch stream (handled up to %s, team at %s); catching up before publishing local changes", orNone(position), head)
+			return true
+		}
+	}
+	log.Printf("[CATCHUP] Could not compare with the team's batch stream: %v", err)
+	return false
+}
+
+// replayMissedBatches applies the batches the team published that this node
+// missed, oldest first, and moves the catch-up position past them.
+func replayMissedBatches(ctx context.Context, cfg utils.AppConfig, tag, reason string) {
+	if _, err := utils.CreateAutoCheckpoint(cfg.RootDir, reason); err != nil {
+		log.Printf("%s Could not create a checkpoint before replaying: %v", tag, err)
+	}
+	// Never let the team's work silently overwrite local work
+	replayCfg := withLiveSettings(cfg)
+	if replayCfg.ConflictStrategy == utils.ConflictStrategyTheirs {
+		replayCfg.ConflictStrategy = utils.ConflictStrategyMerge
+	}
+	missed, lastID, err := utils.ReadMissedBatches(ctx, replayCfg)
+	if err != nil {
+		log.Printf("%s Could not read the team's missed batches: %v; run 'axle elect' to check for divergence", tag, err)
+	}
+	for _, batch := range missed {
+		receiveSyncBatch(replayCfg, batch)
+	}
+	if lastID != "" {
+		if err := utils.CompleteCatchup(cfg.RootDir, lastID); err != nil {
+			log.Printf("%s Failed to save catch-up position: %v", tag, err)
+		}
+	}
+	log.Printf("%s Replayed %d batches the team published while you were away", tag, len(missed))
+}
+
 // startRedisSubscriberWithPresence subscribes to Redis channels including presence
 func startRedisSubscriberWithPresence(ctx context.Context, cfg utils.AppConfig) {
 	defer log.Println("[SUBSCRIBER] Redis subscriber stopped")
 
-	channels := []string{
-		fmt.Sprintf("axle:team:%s", cfg.TeamID),		// Sync messages
-		fmt.Sprintf("axle:chat:%s", cfg.TeamID),		// Chat messages
-		fmt.Sprintf("axle:presence:%s", cfg.TeamID),	// Presence messages
-	}
+	channels := utils.DaemonChannels(cfg.TeamID)
 
-	pubsub, err := utils.SubscribeToChannels(ctx, cfg.RedisClient, channels...)
+	pubsub, err := utils.SubscribeToChannels(ctx, cfg.Transport, channels...)
 	if err != nil {
 		log.Printf("[SUBSCRIBER] Failed to subscribe to Redis channels: %v", err)
 		return
 	}
 	defer pubsub.Close()
 
+	// Redis reports presence keys expiring, so offline members show up at once
+	if patterns, ok := pubsub.(utils.PatternSubscriber); ok && cfg.HasRedis() && cfg.FeatureEnabled(utils.FeaturePresenceEnabled) {
+		utils.EnablePresenceExpiryEvents(ctx, cfg)
+		if err := patterns.PSubscribe(ctx, utils.PresenceExpiryPattern(cfg)); err != nil {
+			log.Printf("[SUBSCRIBER] Failed to subscribe to presence expiry: %v", err)
+		}
+	}
+
 	ch := pubsub.Channel()
 
 	for {
 		select {
 		case msg := <-ch:
-			switch msg.Channel {
-			case fmt.Sprintf("axle:team:%s", cfg.TeamID):
+			if msg.Pattern == utils.PresenceExpiryPattern(cfg) {
+				utils.ProcessPresenceExpiry(cfg, msg.Channel, msg.Payload)
+				continue
+			}
+			channel := msg.Channel
+			// Older releases sent chat and presence on the sync channel
+			if canonical, ok := utils.RouteLegacyMessage(cfg.TeamID, channel, msg.Payload); ok {
+				channel = canonical
+			}
+			switch channel {
+			case utils.TeamChannel(cfg.TeamID):
 				handleSyncMessage(cfg, msg.Payload)
-			case fmt.Sprintf("axle:chat:%s", cfg.TeamID):
+			case utils.ChatChannel(cfg.TeamID):
 				handleChatMessage(cfg, msg.Payload)
-			case fmt.Sprintf("axle:presence:%s", cfg.TeamID):
+			case utils.PresenceChannel(cfg.TeamID):
 				utils.ProcessPresenceMessage(ctx, cfg, msg.Payload)
+			case utils.SnapshotChannel(cfg.TeamID):
+				utils.ProcessSnapshotRequest(ctx, cfg, msg.Payload)
+			case utils.FetchChannel(cfg.TeamID):
+				utils.ProcessFetchRequest(ctx, cfg, msg.Payload)
+			case utils.ErrorsChannel(cfg.TeamID):
+				utils.ProcessErrorReport(cfg, msg.Payload)
+			case utils.AnnounceChannel(cfg.TeamID):
+				utils.ProcessAnnouncementMessage(cfg, msg.Payload)
+			case utils.ScratchpadChannel(cfg.TeamID):
+				utils.ProcessScratchpadMessage(ctx, cfg, msg.Payload)
+			case utils.AuditChannel(cfg.TeamID):
+				go utils.ProcessAuditMessage(ctx, cfg, msg.Payload)
+			case utils.ResyncChannel(cfg.TeamID):
+				utils.ProcessResyncRequest(ctx, cfg, msg.Payload)
+			case utils.BuildChannel(cfg.TeamID):
+				utils.ProcessBuildResult(cfg, msg.Payload)
+			case utils.PasswordChannel(cfg.TeamID):
+				utils.ProcessPasswordChange(cfg, msg.Payload)
 			}
 		case <-ctx.Done():
 			return
@@ -194,34 +649,250 @@ func handleSyncMessage(cfg utils.AppConfig, payload string) {
 		log.Printf("[SYNC] Error unmarshaling sync metadata: %v", err)
 		return
 	}
+	// Refuse forged or replayed batches, and ones over the team's limits;
+	// the ack tells the sender why
+	if syncMeta.PeerID != cfg.Username {
+		if utils.AdmitSigned(cfg, utils.TeamChannel(cfg.TeamID), syncMeta.PeerID, payload) != nil {
+			return
+		}
+		if utils.AdmitFresh(utils.TeamChannel(cfg.TeamID), syncMeta.PeerID, payload, syncMeta.Timestamp) != nil {
+			return
+		}
+		if err := utils.AdmitIncoming(utils.LimitSync, syncMeta.PeerID, len(payload)); err != nil {
+			utils.ReportDroppedMessage(utils.LimitSync, syncMeta.PeerID, err)
+			utils.SendAck(context.Background(), cfg, syncMeta.BatchID, utils.AckFailed, err.Error(), nil)
+			return
+		}
+	}
+
+	receiveSyncBatch(withLiveSettings(cfg), syncMeta)
+}
+
+// receiveSyncBatch handles a teammate's batch, whether it arrived live or through 'axle catchup'
+func receiveSyncBatch(cfg utils.AppConfig, syncMeta utils.SyncMetadata) {
+	// Skip our own messages, and removed members'
+	if syncMeta.PeerID == cfg.Username || utils.IsKicked(syncMeta.PeerID) {
+		return
+	}
+
+	// Remember live batches so catch-up doesn't replay them
+	if cfg.PersistBatches {
+		utils.RecordSeenBatch(cfg.RootDir, syncMeta.BatchID)
+	}
 
-	// Skip our own messages
-	if syncMeta.PeerID == cfg.Username {
+	// Refuse batches in a newer schema that use what this release can't apply
+	if err := utils.CheckBatchProtocol(syncMeta); err != nil {
+		log.Printf("[PROTOCOL] Rejected batch from %s: %v", syncMeta.PeerID, err)
+		utils.SendAck(context.Background(), cfg, syncMeta.BatchID, utils.AckFailed, err.Error(), nil)
 		return
 	}
+	// Releases before wire compression don't flag their batches
+	utils.NoteBatchCompression(syncMeta)
 
-	// Track changed files for committing
+	// Download patches the sender serves directly while they're offered
+	syncMeta = utils.FetchDirectPatches(context.Background(), cfg, syncMeta)
+
+	// Keep the team's changes out of the working tree until 'axle resume'
+	if held, err := utils.HoldIfPaused(cfg.RootDir, syncMeta); held {
+		if err != nil {
+			log.Printf("[PAUSE] Failed to hold batch from %s while paused: %v", syncMeta.PeerID, err)
+		} else {
+			log.Printf("[PAUSE] Held batch from %s (%d changes) until 'axle resume'", syncMeta.PeerID, len(syncMeta.Changes))
+		}
+		return
+	}
+
+	admitSyncBatch(cfg, syncMeta)
+}
+
+// admitSyncBatch filters and scans a teammate's batch, then applies it or
+// holds it for confirmation
+func admitSyncBatch(cfg utils.AppConfig, syncMeta utils.SyncMetadata) {
+	// Drop what the team settings don't sync, even if the sender still does,
+	// and what is outside this member's sync scope
+	syncMeta.Changes = utils.FilterSyncScope(cfg, utils.FilterDisabledChanges(cfg, syncMeta.Changes))
+	// and what a guest's pass doesn't allow them to change, or a spectator sent
+	syncMeta.Changes = utils.FilterGuestChanges(syncMeta.PeerID, syncMeta.Changes)
+	syncMeta.Changes = utils.FilterSpectatorChanges(syncMeta.PeerID, syncMeta.Changes)
+
+	// Scan incoming content before anything touches the working tree
+	if verdict := utils.ScanBatch(context.Background(), cfg, syncMeta); !verdict.Clean {
+		quarantineSyncBatch(cfg, syncMeta, verdict)
+		return
+	}
+
+	// Hold batches touching protected paths until 'axle accept-protected --confirm'
+	if protected := utils.ProtectedFiles(syncMeta.Changes, cfg.ProtectedPaths); len(protected) > 0 {
+		id, err := utils.HoldBatch(cfg.RootDir, utils.HeldIncoming, syncMeta)
+		if err != nil {
+			log.Printf("[PROTECT] Failed to hold incoming batch from %s: %v", syncMeta.PeerID, err)
+			return
+		}
+		log.Printf("[PROTECT] Held batch %s from %s touching protected paths %v; run 'axle accept-protected --confirm' to apply", id, syncMeta.PeerID, protected)
+		utils.SendAck(context.Background(), cfg, syncMeta.BatchID, utils.AckHeld, "", nil)
+		utils.SendNotification("Axle - Protected change", fmt.Sprintf("%s changed protected files; confirm with 'axle accept-protected'", syncMeta.PeerID))
+		return
+	}
+
+	applySyncBatch(cfg, syncMeta)
+}
+
+// quarantineSyncBatch keeps a batch the scanner flagged out of the working
+// tree and alerts the team
+func quarantineSyncBatch(cfg utils.AppConfig, syncMeta utils.SyncMetadata, verdict utils.ScanVerdict) {
+	id, err := utils.QuarantineBatch(cfg.RootDir, syncMeta, verdict)
+	if err != nil {
+		log.Printf("[SCAN] Failed to quarantine batch from %s, dropping it: %v", syncMeta.PeerID, err)
+	} else {
+		log.Printf("[SCAN] Quarantined batch %s from %s: %s; see 'axle quarantine show %s'", id, syncMeta.PeerID, verdict.Reason, id)
+	}
+	ctx := context.Background()
+	utils.SendAck(ctx, cfg, syncMeta.BatchID, utils.AckQuarantined, verdict.Reason, nil)
+	utils.SendNotification("Axle - Incoming change quarantined", fmt.Sprintf("A batch from %s was quarantined: %s", syncMeta.PeerID, verdict.Reason))
+	if cfg.FeatureEnabled(utils.FeatureChatEnabled) {
+		alert := utils.ChatMessage{
+			Message:  fmt.Sprintf("☣️ quarantined a batch from %s (%d changes): %s", syncMeta.PeerID, len(syncMeta.Changes), verdict.Reason),
+			Priority: true,
+		}
+		if err := sendChat(ctx, cfg, alert); err != nil {
+			log.Printf("[SCAN] Failed to alert the team: %v", err)
+		}
+	}
+}
+
+// applySyncBatch applies the changes of an incoming batch and commits them
+func applySyncBatch(cfg utils.AppConfig, syncMeta utils.SyncMetadata) {
+	// Track changed files for committing, and failures for the sender's ACK
 	var changedFiles []string
+	var applyErrors, failedTraces []string
+	// Everything applied, including appends that commit themselves, for the event bus
+	var appliedFiles, appliedTraces []string
+
+	// Large batches can clobber a lot of local work; keep a way back
+	if utils.IsLargeBatch(syncMeta) {
+		reason := fmt.Sprintf("applying %d changes from %s", len(syncMeta.Changes), syncMeta.PeerID)
+		if _, err := utils.CreateAutoCheckpoint(cfg.RootDir, reason); err != nil {
+			log.Printf("[CHECKPOINT] %v", err)
+		}
+	}
 	utils.SetIsApplyingPatch(true)
 
+	// Commits whose patch is in the batch; it already covers their renames and mode changes
+	patchedCommits := make(map[string]bool)
+	for _, change := range syncMeta.Changes {
+		if change.Patch != "" && change.CommitHash != "" {
+			patchedCommits[change.CommitHash] = true
+		}
+	}
+
 	var autoCommittedAny bool
 	for _, change := range syncMeta.Changes {
+		// Expand patches compressed for low-bandwidth peers, and refuse
+		// paths outside the sync root before any branch touches them
+		if err := utils.ValidateIncomingChange(&change); err != nil {
+			log.Printf("[SYNC] Refused change to %s (trace %s): %v", change.File, change.TraceID, err)
+			applyErrors = append(applyErrors, fmt.Sprintf("%s: %v", change.File, err))
+			failedTraces = append(failedTraces, change.TraceID)
+			publishApplyFailed(syncMeta, change, err)
+			continue
+		}
+
+		// On a slow link, force-synced files wait for 'axle fetch' like placeholders
+		if change.Event == "chunked" && utils.IsLowBandwidthMode() && change.OwnerNode != "" {
+			change.Event = "placeholder"
+		}
+
+		// Record large-file placeholders; the content is fetched on demand
+		if change.Event == "placeholder" {
+			if err := utils.RecordPlaceholder(cfg.RootDir, change); err != nil {
+				log.Printf("[PLACEHOLDER] Error recording placeholder for %s: %v", change.File, err)
+				applyErrors = append(applyErrors, fmt.Sprintf("%s: %v", change.File, err))
+				failedTraces = append(failedTraces, change.TraceID)
+				publishApplyFailed(syncMeta, change, err)
+			} else {
+				log.Printf("[PLACEHOLDER] %s has %s (%d bytes); run 'axle fetch %s' to download it", change.Owner, change.File, change.Size, change.File)
+			}
+			continue
+		}
+
+		// Write files a teammate force-synced through the chunk store
+		if change.Event == "chunked" {
+			if err := utils.ApplyChunkedChange(context.Background(), cfg, change); err != nil {
+				log.Printf("[SYNC] Error applying force-synced file (trace %s): %v", change.TraceID, err)
+				applyErrors = append(applyErrors, fmt.Sprintf("%s: %v", change.File, err))
+				failedTraces = append(failedTraces, change.TraceID)
+				publishApplyFailed(syncMeta, change, err)
+			} else {
+				log.Printf("[SYNC] Received %s (%d bytes) from %s", change.File, change.Size, syncMeta.PeerID)
+				changedFiles = append(changedFiles, change.File)
+				appliedFiles = append(appliedFiles, change.File)
+				appliedTraces = append(appliedTraces, change.TraceID)
+			}
+			continue
+		}
+
+		// Apply append-only changes by writing just the new tail
+		if change.Event == "appended" {
+			if err := utils.ApplyAppend(cfg.RootDir, change, syncMeta.Author()); err != nil {
+				log.Printf("[APPEND] Error applying append (trace %s): %v", change.TraceID, err)
+				applyErrors = append(applyErrors, fmt.Sprintf("%s: %v", change.File, err))
+				failedTraces = append(failedTraces, change.TraceID)
+				publishApplyFailed(syncMeta, change, err)
+			} else {
+				log.Printf("[APPEND] Appended %s from %s", change.File, syncMeta.PeerID)
+				appliedFiles = append(appliedFiles, change.File)
+				appliedTraces = append(appliedTraces, change.TraceID)
+			}
+			continue
+		}
+
+		// Apply renames, mode changes, touches and new directories directly
+		if utils.IsMetadataEvent(change.Event) && change.Patch == "" {
+			if patchedCommits[change.CommitHash] {
+				continue
+			}
+			if err := utils.ApplyMetadataChange(cfg.RootDir, change); err != nil {
+				log.Printf("[SYNC] Error applying %s of %s (trace %s): %v", change.Event, change.File, change.TraceID, err)
+				applyErrors = append(applyErrors, fmt.Sprintf("%s: %v", change.File, err))
+				failedTraces = append(failedTraces, change.TraceID)
+				publishApplyFailed(syncMeta, change, err)
+			} else {
+				// Touches and empty directories leave nothing for git to commit
+				if change.Event == utils.EventRenamed || change.Event == utils.EventChmod {
+					changedFiles = append(changedFiles, change.File)
+				}
+				appliedFiles = append(appliedFiles, change.File)
+				appliedTraces = append(appliedTraces, change.TraceID)
+			}
+			continue
+		}
+
 		// Handle Patches (Create/Modify)
 		if change.Patch != "" {
 			var autoCommitted bool
 			var err error
 
 			// Use conflict strategy if available
-			if cfg.ConflictStrategy != "" {
-				autoCommitted, err = utils.ApplyPatchWithStrategy(cfg.RootDir, change.Patch, cfg.ConflictStrategy)
-			} else {
-				autoCommitted, err = utils.ApplyPatch(cfg.RootDir, change.Patch)
+			apply := func(patch string) (bool, error) {
+				if cfg.ConflictStrategy == utils.ConflictStrategyAuto {
+					return utils.ApplyPatchWithTieBreak(cfg.RootDir, patch, cfg.Username, syncMeta.PeerID, cfg.PeerPriority)
+				} else if cfg.ConflictStrategy != "" {
+					return utils.ApplyPatchWithStrategy(cfg.RootDir, patch, syncMeta.PeerID, cfg.ConflictStrategy)
+				}
+				return utils.ApplyPatch(cfg.RootDir, patch)
 			}
+			// Patches from a checkout with other line endings are converted
+			autoCommitted, err = utils.ApplyPatchWithLineEndings(cfg.RootDir, syncMeta.PeerID, change.Patch, apply)
 
 			if err != nil {
-				log.Printf("[SYNC] Error applying patch: %v", err)
+				log.Printf("[SYNC] Error applying patch (trace %s): %v", change.TraceID, err)
+				applyErrors = append(applyErrors, fmt.Sprintf("%s: %v", change.File, err))
+				failedTraces = append(failedTraces, change.TraceID)
+				publishApplyFailed(syncMeta, change, err)
 			} else {
 				changedFiles = append(changedFiles, change.File)
+				appliedFiles = append(appliedFiles, change.File)
+				appliedTraces = append(appliedTraces, change.TraceID)
 				if autoCommitted {
 					autoCommittedAny = true
 				}
@@ -231,12 +902,15 @@ func handleSyncMessage(cfg utils.AppConfig, payload string) {
 
 		// Handle Deletion
 		if change.Event == "deleted" {
-			localPathToDelete := filepath.Join(cfg.RootDir, change.File)
-			err := os.RemoveAll(localPathToDelete)
-			if err != nil && !os.IsNotExist(err) {
-				log.Printf("[SYNC] Error deleting file/directory %s: %v", localPathToDelete, err)
+			if err := utils.ApplyDeletion(cfg.RootDir, change); err != nil {
+				log.Printf("[SYNC] Error deleting %s (trace %s): %v", change.File, change.TraceID, err)
+				applyErrors = append(applyErrors, fmt.Sprintf("%s: %v", change.File, err))
+				failedTraces = append(failedTraces, change.TraceID)
+				publishApplyFailed(syncMeta, change, err)
 			} else {
 				changedFiles = append(changedFiles, change.File)
+				appliedFiles = append(appliedFiles, change.File)
+				appliedTraces = append(appliedTraces, change.TraceID)
 			}
 		}
 	}
@@ -246,7 +920,7 @@ func handleSyncMessage(cfg utils.AppConfig, payload string) {
 		commitMessage := fmt.Sprintf("[SYNC] Received %d changes from %s", len(changedFiles), syncMeta.PeerID)
 		log.Printf("[SYNC] Attempting to commit %d changed files: %v", len(changedFiles), changedFiles)
 
-		if _, err := utils.CommitChanges(cfg.RootDir, commitMessage); err != nil {
+		if _, err := utils.CommitChangesAs(cfg.RootDir, commitMessage, syncMeta.Author()); err != nil {
 			log.Printf("[SYNC] Error committing synced changes in directory '%s': %v", cfg.RootDir, err)
 			log.Printf("[SYNC] Failed files were: %v", changedFiles)
 		} else {
@@ -256,29 +930,92 @@ func handleSyncMessage(cfg utils.AppConfig, payload string) {
 		log.Printf("[SYNC] Applied and committed %d changes from %s (auto-committed by git am)", len(changedFiles), syncMeta.PeerID)
 	}
 
+	// Acknowledge the batch so the sender can track delivery
+	if len(applyErrors) > 0 {
+		utils.SendAck(context.Background(), cfg, syncMeta.BatchID, utils.AckFailed, strings.Join(applyErrors, "; "), failedTraces)
+	} else {
+		utils.SendAck(context.Background(), cfg, syncMeta.BatchID, utils.AckApplied, "", nil)
+	}
+
+	if len(appliedFiles) > 0 {
+		utils.Events.Publish(utils.TopicBatchApplied, utils.BatchAppliedEvent{PeerID: syncMeta.PeerID, BatchID: syncMeta.BatchID, Files: appliedFiles, TraceIDs: appliedTraces})
+	}
+
 	time.Sleep(100 * time.Millisecond)	// Brief pause for FS events
 	utils.SetIsApplyingPatch(false)
 }
 
+// publishApplyFailed reports a change from an incoming batch that could not be applied
+func publishApplyFailed(syncMeta utils.SyncMetadata, change utils.FileChange, err error) {
+	utils.Events.Publish(utils.TopicApplyFailed, utils.ApplyFailedEvent{
+		PeerID:  syncMeta.PeerID,
+		BatchID: syncMeta.BatchID,
+		File:    change.File,
+		TraceID: change.TraceID,
+		Err:     err,
+	})
+}
+
 // handleChatMessage processes chat messages
 func handleChatMessage(cfg utils.AppConfig, payload string) {
+	if !cfg.FeatureEnabled(utils.FeatureChatEnabled) {
+		return
+	}
+
 	var chatMsg utils.ChatMessage
 	if err := json.Unmarshal([]byte(payload), &chatMsg); err != nil {
 		log.Printf("[CHAT] Error unmarshaling chat message: %v", err)
 		return
 	}
+	// Verify every message, our own echo included, so no one can put
+	// words in this user's mouth; only then is the sender trusted
+	if utils.AdmitSigned(cfg, utils.ChatChannel(cfg.TeamID), chatMsg.Sender, payload) != nil {
+		return
+	}
+	if utils.AdmitFresh(utils.ChatChannel(cfg.TeamID), chatMsg.Sender, payload, chatMsg.Timestamp) != nil {
+		return
+	}
+	if chatMsg.Sender != cfg.Username {
+		if utils.IsKicked(chatMsg.Sender) {
+			return
+		}
+		if err := utils.AdmitIncoming(utils.LimitChat, chatMsg.Sender, len(payload)); err != nil {
+			utils.ReportDroppedMessage(utils.LimitChat, chatMsg.Sender, err)
+			return
+		}
+	}
+
+	// Clients without slash-command support send /me as plain text
+	if action, ok := strings.CutPrefix(chatMsg.Message, "/me "); ok && !chatMsg.Action {
+		chatMsg.Message = strings.TrimSpace(action)
+		chatMsg.Action = true
+	}
+
+	// Keep the message, stamped with its local arrival time, for /history
+	chatMsg.ReceivedAt = time.Now().Unix()
+	utils.RecordChat(cfg.RootDir, chatMsg)
+
+	// Display the message with priority or action formatting if applicable
+	fmt.Println(formatChatLine(chatMsg))
 
-	timestamp := time.Unix(chatMsg.Timestamp, 0).Format("15:04:05")
+	utils.Events.Publish(utils.TopicChatReceived, utils.ChatReceivedEvent{Message: chatMsg})
+}
+
+// startChatNotifier sends desktop notifications for priority chat messages from teammates
+func startChatNotifier(ctx context.Context, cfg utils.AppConfig) {
+	events, unsubscribe := utils.Events.Subscribe(utils.TopicChatReceived)
+	defer unsubscribe()
 
-	// Display the message with priority indicator if applicable
-	if chatMsg.Priority {
-		fmt.Printf("[CHAT %s] 🔔 <%s> %s\n", timestamp, chatMsg.Sender, chatMsg.Message)
-		// Send desktop notification for priority messages (but not for our own messages)
-		if chatMsg.Sender != cfg.Username {
-			utils.SendChatNotification(chatMsg.Sender, chatMsg.Message)
+	for {
+		select {
+		case event := <-events:
+			chatMsg := event.Payload.(utils.ChatReceivedEvent).Message
+			if chatMsg.Priority && chatMsg.Sender != cfg.Username {
+				utils.SendChatNotification(chatMsg.Sender, chatMsg.Message)
+			}
+		case <-ctx.Done():
+			return
 		}
-	} else {
-		fmt.Printf("[CHAT %s] <%s> %s\n", timestamp, chatMsg.Sender, chatMsg.Message)
 	}
 }
 
@@ -287,5 +1024,15 @@ func init() {
 
 	// Add conflict resolution flag
 	startCmd.Flags().StringVar(&conflictMode, "conflict", "merge",
-		"Conflict resolution strategy: theirs, mine, merge, backup, or interactive")
+		"Conflict resolution strategy: theirs, mine, merge, backup, interactive, or auto")
+	startCmd.Flags().StringVar(&lowPowerFlag, "low-power", utils.LowPowerOff,
+		"Low-power mode: off, on, or auto (enable while on battery)")
+	startCmd.Flags().StringVar(&lowBandwidthFlag, "low-bandwidth", utils.LowBandwidthOff,
+		"Low-bandwidth mode: off, on, or auto (enable while Redis is slow to reach)")
+	startCmd.Flags().IntVar(&maxProcsFlag, "max-procs", 0, "Limit the number of CPUs Axle may use (0 = no limit)")
+	startCmd.Flags().IntVar(&memoryLimitMB, "memory-limit", 0, "Soft memory limit in MB (0 = no limit)")
+	startCmd.Flags().BoolVar(&traceFlag, "trace", false, "Record each change's journey (capture, commit, publish, apply) for 'axle trace'")
+	startCmd.Flags().BoolVar(&offlineFlag, "offline", false, "Work without Redis: commit locally and publish the backlog once reconnected")
+	startCmd.Flags().StringVar(&pprofAddr, "pprof", "", "Serve Go pprof profiles on this address, e.g. localhost:6060")
+	startCmd.Flags().BoolVar(&noReconnectFlag, "no-reconnect", false, "With --offline, stay offline until 'axle sync-now' instead of reconnecting automatically")
 }
\ No newline at end of file
@@ -2,11 +2,14 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -37,8 +40,24 @@ type SyncStats struct {
 	ChangesInLastHour int
 	MostActiveFile    string
 	MostActiveCount   int
+
+	// Team noticeboard
+	Pins      []utils.Pin
+	OpenTodos []utils.TodoItem
+
+	// Latest stats summary broadcast by each member
+	Health []utils.HealthReport
+
+	// Latest result of each member's build command
+	Builds []utils.BuildResult
 }
 
+var (
+	statsWatch bool
+	statsFiles bool
+	statsTop   int
+)
+
 // statsCmd represents the stats command
 var statsCmd = &cobra.Command{
 	Use:   "stats",
@@ -53,14 +72,27 @@ Shows detailed statistics about your Axle synchronization session including:
 • Current pending changes
 
 Use this command to get a quick overview of your team's sync status
-and identify any issues or bottlenecks.`,
+and identify any issues or bottlenecks.
+
+When the team broadcasts stats ('axle team stats 5m'), a Team Health section
+shows each member's last summary. Use --watch to follow it live. Members
+with a "buildCommand" in axle_config.json show their last build result.
+
+Use --files for the team's most-synced and most-conflicted files, to find
+hot files worth splitting or ignoring.`,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Load configuration
-		if err := loadConfig(); err != nil {
+		if statsWatch {
+			return watchTeamHealth()
+		}
+		if statsFiles {
+			return showFileStats(statsTop)
+		}
+
+		// Load configuration; team data comes through the daemon if it is running
+		if err := loadLocalConfig(); err != nil {
 			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
 		}
-		defer config.RedisClient.Close()
 
 		ctx := context.Background()
 
@@ -90,19 +122,22 @@ func gatherStats(ctx context.Context, cfg utils.AppConfig) (*SyncStats, error) {
 		return nil, fmt.Errorf("failed to get file stats: %w", err)
 	}
 
-	// Get team presence
-	presenceList, err := utils.GetTeamPresence(ctx, cfg)
-	if err == nil {
-		stats.TeamMembers = len(presenceList)
-		for _, presence := range presenceList {
+	// Get team presence and the team noticeboard
+	if status, err := getTeamStatus(ctx); err == nil {
+		stats.TeamMembers = len(status.Presence)
+		for _, presence := range status.Presence {
 			if presence.Status == "online" {
 				stats.OnlineMembers++
 			}
 		}
+		stats.Pins = status.Pins
+		stats.OpenTodos = status.Todos
+		stats.Health = status.Health
+		stats.Builds = status.Builds
 	}
 
 	// Get pending changes (git status)
-	pendingCmd := exec.Command("git", "-C", cfg.RootDir, "status", "--porcelain")
+	pendingCmd := utils.GitCommand("-C", cfg.RootDir, "status", "--porcelain")
 	if output, err := pendingCmd.Output(); err == nil {
 		lines := strings.Split(string(output), "\n")
 		for _, line := range lines {
@@ -117,13 +152,13 @@ func gatherStats(ctx context.Context, cfg utils.AppConfig) (*SyncStats, error) {
 
 func getGitStats(rootDir string, stats *SyncStats) error {
 	// Get total commit count
-	countCmd := exec.Command("git", "-C", rootDir, "rev-list", "--count", "HEAD")
+	countCmd := utils.GitCommand("-C", rootDir, "rev-list", "--count", "HEAD")
 	if output, err := countCmd.Output(); err == nil {
 		fmt.Sscanf(strings.TrimSpace(string(output)), "%d", &stats.TotalCommits)
 	}
 
 	// Get last commit info
-	lastCommitCmd := exec.Command("git", "-C", rootDir, "log", "-1", "--format=%ct|%s")
+	lastCommitCmd := utils.GitCommand("-C", rootDir, "log", "-1", "--format=%ct|%s")
 	if output, err := lastCommitCmd.Output(); err == nil {
 		parts := strings.SplitN(strings.TrimSpace(string(output)), "|", 2)
 		if len(parts) == 2 {
@@ -136,13 +171,13 @@ func getGitStats(rootDir string, stats *SyncStats) error {
 
 	// Get commits in last hour
 	hourAgo := time.Now().Add(-time.Hour).Unix()
-	recentCmd := exec.Command("git", "-C", rootDir, "rev-list", "--count", "--since", fmt.Sprintf("%d", hourAgo), "HEAD")
+	recentCmd := utils.GitCommand("-C", rootDir, "rev-list", "--count", "--since", fmt.Sprintf("%d", hourAgo), "HEAD")
 	if output, err := recentCmd.Output(); err == nil {
 		fmt.Sscanf(strings.TrimSpace(string(output)), "%d", &stats.ChangesInLastHour)
 	}
 
 	// Get most frequently changed file
-	freqCmd := exec.Command("git", "-C", rootDir, "log", "--pretty=format:", "--name-only")
+	freqCmd := utils.GitCommand("-C", rootDir, "log", "--pretty=format:", "--name-only")
 	if output, err := freqCmd.Output(); err == nil {
 		fileCount := make(map[string]int)
 		lines := strings.Split(string(output), "\n")
@@ -252,6 +287,25 @@ func displayStats(stats *SyncStats, cfg utils.AppConfig) {
 	fmt.Printf("  Currently Offline:  %d\n", stats.TeamMembers-stats.OnlineMembers)
 	fmt.Println()
 
+	if len(stats.Health) > 0 {
+		printTeamHealth(stats.Health)
+		fmt.Println()
+	}
+	if len(stats.Builds) > 0 {
+		printTeamBuilds(stats.Builds)
+		fmt.Println()
+	}
+
+	// Noticeboard
+	if len(stats.Pins) > 0 {
+		printPins(stats.Pins)
+		fmt.Println()
+	}
+	if len(stats.OpenTodos) > 0 {
+		printTodos(stats.OpenTodos)
+		fmt.Println()
+	}
+
 	// Activity Stats
 	fmt.Println(utils.RenderInfo("📈 Activity"))
 	fmt.Printf("  Changes (Last Hour): %d\n", stats.ChangesInLastHour)
@@ -276,6 +330,155 @@ func displayStats(stats *SyncStats, cfg utils.AppConfig) {
 	}
 }
 
+// printTeamHealth shows each member's last stats summary and flags the ones
+// whose syncs are failing or who stopped reporting.
+func printTeamHealth(reports []utils.HealthReport) {
+	fmt.Println(utils.RenderInfo("🩺 Team Health"))
+	for _, r := range reports {
+		fmt.Printf("  %-14s ↑%-4d ↓%-4d failed %-3d conflicts %-3d %5dms  %s\n",
+			r.Username, r.FilesSent, r.FilesReceived, r.FailedChanges, r.Conflicts, r.LatencyMs,
+			formatTime(time.Unix(r.Timestamp, 0)))
+	}
+	for _, r := range reports {
+		window := time.Duration(r.IntervalSeconds) * time.Second
+		switch {
+		case r.Stale():
+			fmt.Println(utils.RenderWarning(fmt.Sprintf("%s hasn't reported since %s", r.Username, formatTime(time.Unix(r.Timestamp, 0)))))
+		case r.FailedChanges > 0:
+			fmt.Println(utils.RenderWarning(fmt.Sprintf("%s: %d incoming changes failed to apply in the last %v", r.Username, r.FailedChanges, window)))
+		case r.PublisherState == utils.PublisherBackoff:
+			fmt.Println(utils.RenderWarning(fmt.Sprintf("%s can't publish (%d changes queued)", r.Username, r.QueuedChanges)))
+		}
+	}
+}
+
+// printTeamBuilds shows the last result of each member's build command and
+// whose changes broke the failing ones.
+func printTeamBuilds(results []utils.BuildResult) {
+	fmt.Println(utils.RenderInfo("🔨 Builds"))
+	for _, r := range results {
+		status := "✅ passed"
+		if !r.Passed {
+			status = "❌ failed"
+		}
+		fmt.Printf("  %-14s %-10s %-24s %s\n", r.Username, status, r.Command, formatTime(time.Unix(r.Timestamp, 0)))
+	}
+	for _, r := range results {
+		if r.Passed {
+			continue
+		}
+		msg := fmt.Sprintf("%s's build fails (%s)", r.Username, r.Reason)
+		if len(r.Peers) > 0 {
+			msg += " since changes from " + strings.Join(r.Peers, ", ")
+		}
+		fmt.Println(utils.RenderWarning(msg))
+	}
+}
+
+// watchTeamHealth reprints the team health view every time a member
+// broadcasts a stats summary, until interrupted.
+func watchTeamHealth() error {
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+	}
+	defer config.RedisClient.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	reports, err := utils.TeamHealth(ctx, config)
+	if err != nil {
+		return err
+	}
+	latest := make(map[string]utils.HealthReport)
+	for _, r := range reports {
+		latest[r.Username] = r
+	}
+
+	pubsub, err := utils.SubscribeToChannels(ctx, config.Transport, utils.HealthChannel(config.TeamID))
+	if err != nil {
+		return err
+	}
+	defer pubsub.Close()
+
+	show := func() {
+		reports := make([]utils.HealthReport, 0, len(latest))
+		for _, r := range latest {
+			reports = append(reports, r)
+		}
+		sort.Slice(reports, func(i, j int) bool { return reports[i].Username < reports[j].Username })
+		fmt.Println()
+		fmt.Println(utils.RenderTitle(fmt.Sprintf("Team %s at %s", config.TeamID, time.Now().Format("15:04:05"))))
+		if len(reports) == 0 {
+			fmt.Println(utils.RenderInfo("No stats yet; turn broadcasts on with 'axle team stats 5m'"))
+			return
+		}
+		printTeamHealth(reports)
+	}
+	show()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case msg := <-ch:
+			var r utils.HealthReport
+			if err := json.Unmarshal([]byte(msg.Payload), &r); err != nil || r.Username == "" {
+				continue
+			}
+			latest[r.Username] = r
+			show()
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// showFileStats prints the top most-synced and most-conflicted files.
+func showFileStats(top int) error {
+	if top < 1 {
+		return fmt.Errorf("--top must be at least 1")
+	}
+	if err := loadConfig(); err != nil {
+		return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+	}
+	defer config.RedisClient.Close()
+
+	ctx := context.Background()
+	synced, err := utils.TopSyncedFiles(ctx, config, top)
+	if err != nil {
+		return err
+	}
+	conflicted, err := utils.TopConflictedFiles(ctx, config, top)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(utils.RenderTitle("📊 File Statistics"))
+	if len(synced) == 0 && len(conflicted) == 0 {
+		fmt.Println(utils.RenderInfo("No file stats yet; they are recorded while 'axle start' runs"))
+		return nil
+	}
+
+	fmt.Println(utils.RenderInfo("🔥 Most synced"))
+	for _, stat := range synced {
+		fmt.Printf("  %6d syncs  %10s  %4d conflicts  %s\n", stat.Syncs, formatFileSize(stat.Bytes), stat.Conflicts, stat.File)
+	}
+	fmt.Println()
+
+	fmt.Println(utils.RenderInfo("⚔️  Most conflicted"))
+	if len(conflicted) == 0 {
+		fmt.Println("  No conflicts recorded")
+	}
+	for _, stat := range conflicted {
+		fmt.Printf("  %6d conflicts  %6d syncs  %s\n", stat.Conflicts, stat.Syncs, stat.File)
+	}
+	if len(conflicted) > 0 {
+		fmt.Println()
+		fmt.Println(utils.RenderInfo("Files that keep conflicting are worth splitting up, or ignoring if they are generated"))
+	}
+	return nil
+}
+
 func formatTime(t time.Time) string {
 	duration := time.Since(t)
 	if duration < time.Minute {
@@ -316,4 +519,7 @@ func truncateString(s string, maxLen int) string {
 
 func init() {
 	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().BoolVar(&statsFiles, "files", false, "Show the most-synced and most-conflicted files")
+	statsCmd.Flags().IntVar(&statsTop, "top", 10, "How many files --files lists")
+	statsCmd.Flags().BoolVar(&statsWatch, "watch", false, "Follow the team health view as members broadcast stats")
 }
\ No newline at end of file
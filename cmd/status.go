@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusJSON    bool
+	statusSkipped bool
+)
+
+// statusRedisTimeout bounds the connectivity check so status stays quick
+const statusRedisTimeout = 3 * time.Second
+
+// redisStatus is whether this machine can reach the team's Redis, or its
+// NATS server for teams on NATS.
+type redisStatus struct {
+	Transport string `json:"transport,omitempty"`
+	Endpoint  string `json:"endpoint"`
+	Reachable bool   `json:"reachable"`
+	LatencyMs int64  `json:"latencyMs,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// conflictStatus is the repository's unresolved conflicts.
+type conflictStatus struct {
+	Unmerged  []string `json:"unmerged"`  // Files with conflict markers git still tracks
+	Artifacts []string `json:"artifacts"` // Unresolved .rej and backup files
+}
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the state of the sync daemon for this repository",
+	Long: utils.RenderTitle("📡 Daemon Status") + `
+
+Shows whether 'axle start' is running for this repository and how its
+publisher is coping with Redis: when Redis is slow or failing, pending
+batches are coalesced into fewer, larger publishes and retried with
+backoff instead of being dropped. Also checks that Redis is reachable from
+here and lists unresolved conflicts, whether or not the daemon runs.
+
+Use --skipped to list files the watcher did not sync in full because they
+are over the size limit or binary.`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		if statusSkipped {
+			return printSkippedFiles(config.RootDir)
+		}
+
+		state, err := utils.ReadDaemonState(config.RootDir)
+		running := err == nil && state.IsRunning()
+		redis := checkRedis()
+		conflicts := currentConflicts(config.RootDir)
+
+		if statusJSON {
+			data, err := json.MarshalIndent(struct {
+				Running   bool              `json:"running"`
+				State     utils.DaemonState `json:"state"`
+				Redis     redisStatus       `json:"redis"`
+				Conflicts conflictStatus    `json:"conflicts"`
+			}{running, state, redis, conflicts}, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal status: %w", err)
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		fmt.Println(utils.RenderTitle("📡 Axle Status"))
+		server := "Redis"
+		if redis.Transport == utils.TransportNATS {
+			server = "NATS"
+		}
+		if redis.Reachable {
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("%s at %s is reachable (%dms)", server, redis.Endpoint, redis.LatencyMs)))
+		} else {
+			fmt.Println(utils.RenderError(fmt.Sprintf("%s at %s is unreachable: %s", server, redis.Endpoint, redis.Error)))
+		}
+		if tunnel, err := utils.ReadTunnelState(config.RootDir); err == nil && tunnel.IsRunning() {
+			link := "up"
+			if !tunnel.Connected {
+				link = "reconnecting"
+			}
+			fmt.Println(utils.RenderInfo(fmt.Sprintf("Redis goes through an SSH tunnel via %s (%s, %d restarts)", tunnel.Target, link, tunnel.Restarts)))
+		}
+		if !running {
+			fmt.Println(utils.RenderWarning("Sync daemon is not running. Start it with 'axle start'"))
+			printConflictStatus(conflicts)
+			return nil
+		}
+
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Sync daemon running (PID %d, up %s)",
+			state.PID, time.Since(time.Unix(state.StartedAt, 0)).Round(time.Second))))
+		fmt.Println()
+
+		publisher := state.Publisher
+		fmt.Println(utils.RenderInfo("📤 Publisher"))
+		fmt.Printf("  State:              %s\n", publisher.State)
+		fmt.Printf("  Queued Changes:     %d\n", publisher.QueuedChanges)
+		fmt.Printf("  Last Latency:       %dms\n", publisher.LastLatencyMs)
+		if publisher.LastPublish > 0 {
+			fmt.Printf("  Last Publish:       %s\n", formatTime(time.Unix(publisher.LastPublish, 0)))
+		}
+		if state.LastApply > 0 {
+			fmt.Printf("  Last Apply:         %s\n", formatTime(time.Unix(state.LastApply, 0)))
+		}
+		if state.Paused > 0 {
+			fmt.Println(utils.RenderWarning(fmt.Sprintf("Sync paused since %s; %d changes wait in the outbox. Run 'axle resume' to continue",
+				formatTime(time.Unix(state.Paused, 0)), publisher.QueuedChanges)))
+		}
+
+		switch publisher.State {
+		case utils.PublisherCoalescing:
+			fmt.Println(utils.RenderWarning("Redis is slow; changes are merged and published every few seconds"))
+		case utils.PublisherBackoff:
+			fmt.Printf("  Failed Attempts:    %d\n", publisher.ConsecutiveFailures)
+			fmt.Printf("  Next Attempt:       %s\n", time.Unix(publisher.NextAttempt, 0).Format("15:04:05"))
+			fmt.Println(utils.RenderWarning("Publishing is failing: " + publisher.LastError))
+		case utils.PublisherOffline:
+			fmt.Println(utils.RenderWarning(fmt.Sprintf("Working offline; %d changes wait in the outbox. Run 'axle sync-now' to reconnect", publisher.QueuedChanges)))
+		case utils.PublisherCatchingUp:
+			fmt.Println(utils.RenderWarning(fmt.Sprintf("Catching up on the team's changes; %d local changes wait in the outbox until then", publisher.QueuedChanges)))
+		}
+
+		batching := state.Batching
+		fmt.Println()
+		fmt.Println(utils.RenderInfo("⏱️  Batching"))
+		window := (time.Duration(batching.WindowMs) * time.Millisecond).String()
+		if batching.LowPower {
+			window += " (low-power mode)"
+		}
+		fmt.Printf("  Batch Window:       %s\n", window)
+		fmt.Printf("  Event Rate:         %.1f/s\n", batching.EventRate)
+		fmt.Printf("  Pending Files:      %d\n", batching.Pending)
+
+		if disk := state.Disk; disk.CheckedAt > 0 {
+			fmt.Println()
+			fmt.Println(utils.RenderInfo("💾 Disk"))
+			fmt.Printf("  Repository:         %s\n", formatFileSize(disk.RepoBytes))
+			fmt.Printf("  .axle:              %s (caches %s of %s)\n",
+				formatFileSize(disk.AxleBytes), formatFileSize(disk.CacheBytes), formatFileSize(disk.CacheQuotaBytes))
+			fmt.Printf("  Free Space:         %s of %s\n", formatFileSize(int64(disk.FreeBytes)), formatFileSize(int64(disk.TotalBytes)))
+			if disk.LowSpace {
+				fmt.Println(utils.RenderWarning("Disk space is running low"))
+			}
+		}
+		printConflictStatus(conflicts)
+		return nil
+	},
+}
+
+// checkRedis pings the first configured Redis endpoint that answers.
+func checkRedis() redisStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), statusRedisTimeout)
+	defer cancel()
+
+	if teamTransport.name == utils.TransportNATS {
+		endpoint, rtt, err := utils.PingNATS(teamTransport.natsURL)
+		status := redisStatus{Transport: utils.TransportNATS, Endpoint: endpoint}
+		if err != nil {
+			status.Error = err.Error()
+			return status
+		}
+		status.Reachable = true
+		status.LatencyMs = rtt.Milliseconds()
+		return status
+	}
+	if ha := utils.CurrentRedisHA(); ha.Enabled() {
+		return checkRedisHA(ctx, ha)
+	}
+
+	var status redisStatus
+	for _, endpoint := range config.RedisEndpoints {
+		result := utils.PingEndpoint(ctx, endpoint, 1)
+		status = redisStatus{Endpoint: endpoint.Label()}
+		if result.Err != nil {
+			status.Error = result.Err.Error()
+			continue
+		}
+		status.Reachable = true
+		status.LatencyMs = result.Avg.Milliseconds()
+		break
+	}
+	return status
+}
+
+// checkRedisHA pings a Sentinel or Cluster deployment through a client of
+// its own, reporting the master it reached.
+func checkRedisHA(ctx context.Context, ha utils.RedisHA) redisStatus {
+	status := redisStatus{Endpoint: ha.Label()}
+	rdb, err := utils.NewLazyRedisClient(nil)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	defer rdb.Close()
+
+	// Finding the master or the cluster's nodes isn't part of the round trip
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	rtt, err := utils.MeasureLatency(ctx, rdb)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	if active := utils.ActiveRedisEndpoint(); active.Addr != "" {
+		status.Endpoint = active.Label()
+	}
+	status.Reachable = true
+	status.LatencyMs = rtt.Milliseconds()
+	return status
+}
+
+// currentConflicts lists the conflicts waiting to be resolved.
+func currentConflicts(rootDir string) conflictStatus {
+	status := conflictStatus{Unmerged: utils.UnmergedFiles(rootDir), Artifacts: []string{}}
+	if artifacts, err := utils.ListConflictArtifacts(rootDir); err == nil {
+		for _, artifact := range artifacts {
+			if !utils.ArtifactResolved(rootDir, artifact, status.Unmerged) {
+				status.Artifacts = append(status.Artifacts, artifact.Path)
+			}
+		}
+	}
+	return status
+}
+
+// printConflictStatus shows the unresolved conflicts, if any.
+func printConflictStatus(conflicts conflictStatus) {
+	fmt.Println()
+	if len(conflicts.Unmerged) == 0 && len(conflicts.Artifacts) == 0 {
+		fmt.Println(utils.RenderSuccess("No unresolved conflicts"))
+		return
+	}
+	fmt.Println(utils.RenderInfo("⚔️  Conflicts"))
+	for _, file := range conflicts.Unmerged {
+		fmt.Printf("  %-50s conflict markers\n", file)
+	}
+	for _, file := range conflicts.Artifacts {
+		fmt.Printf("  %-50s unresolved\n", file)
+	}
+	fmt.Println(utils.RenderWarning("Resolve the files above; then 'axle artifacts clean --resolved' removes the leftovers"))
+}
+
+// printSkippedFiles lists the files the watcher skipped
+func printSkippedFiles(rootDir string) error {
+	skipped, err := utils.ListSkippedFiles(rootDir)
+	if err != nil {
+		return err
+	}
+
+	if statusJSON {
+		data, err := json.MarshalIndent(skipped, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal skipped files: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Println(utils.RenderTitle("⏭️ Skipped Files"))
+	if len(skipped) == 0 {
+		fmt.Println(utils.RenderSuccess("No files were skipped"))
+		return nil
+	}
+	for _, file := range skipped {
+		fmt.Printf("  %-50s %10s  %s\n", file.Path, formatFileSize(file.Size), file.Reason)
+		if file.ForcedAt > 0 {
+			fmt.Printf("  %-50s %10s  force-synced %s\n", "", "", formatTime(time.Unix(file.ForcedAt, 0)))
+		}
+	}
+	fmt.Println(utils.RenderInfo("Push one anyway with 'axle force-sync <path>'; files that shrink or fit a raised limit sync again on their own"))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Output status as JSON")
+	statusCmd.Flags().BoolVar(&statusSkipped, "skipped", false, "List files that were skipped because of their size or type")
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// syncNowCmd asks a daemon started with --offline to reconnect
+var syncNowCmd = &cobra.Command{
+	Use:   "sync-now",
+	Short: "Reconnect an offline daemon and sync the work done offline",
+	Long: utils.RenderTitle("🔌 Sync Now") + `
+
+A daemon started with 'axle start --offline' keeps committing locally and
+holds every change in the outbox. It tries to reconnect every 30 seconds
+(unless started with --no-reconnect); this command makes it try right away.
+
+Once Redis answers, the daemon checks the team password again, takes a
+checkpoint, replays the batches the team published meanwhile (when batch
+persistence is on) and then publishes the offline backlog.
+
+Examples:
+  axle sync-now`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadLocalConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+
+		resp, ok, err := askDaemon("sync-now", nil)
+		if !ok {
+			return fmt.Errorf("the sync daemon is not running; start it with 'axle start' and try again")
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(utils.RenderSuccess("Daemon: " + resp.Message))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncNowCmd)
+}
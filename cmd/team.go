@@ -3,9 +3,14 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/parzi-val/axle-file-sync/utils"
+	"golang.org/x/term"
 )
 
 // teamCmd represents the team command
@@ -19,23 +24,22 @@ last seen time, IP addresses, and node IDs. This helps you see who's
 currently active on your team and available for collaboration.
 
 The status is updated in real-time based on heartbeat messages sent
-every 30 seconds by each team member's Axle instance.`,
+by each team member's Axle instance (every 30 seconds by default, see
+'axle team heartbeat'). Use --pins to also show the team's pinned messages
+and open TODO items.`,
 	
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Load configuration
-		if err := loadConfig(); err != nil {
+		if err := loadLocalConfig(); err != nil {
 			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
 		}
-		
-		defer config.RedisClient.Close()
 
-		ctx := context.Background()
-		
-		// Get team presence information
-		presenceList, err := utils.GetTeamPresence(ctx, config)
+		// Get team presence information, through the daemon if it is running
+		status, err := getTeamStatus(context.Background())
 		if err != nil {
-			return fmt.Errorf("failed to retrieve team presence: %w", err)
+			return err
 		}
+		presenceList := status.Presence
 
 		// Display the team status table
 		fmt.Println(utils.RenderTitle("👥 Team: " + config.TeamID))
@@ -54,10 +58,726 @@ every 30 seconds by each team member's Axle instance.`,
 			totalCount, onlineCount, totalCount-onlineCount)
 		fmt.Println(utils.RenderInfo(summaryMsg))
 
+		// Show the authoritative node, if one is designated
+		if status.AuthoritativeNode != "" {
+			authorityStatus := "offline"
+			for _, presence := range presenceList {
+				if presence.Username == status.AuthoritativeNode && presence.Status == "online" {
+					authorityStatus = "online"
+					break
+				}
+			}
+			fmt.Println(utils.RenderInfo(fmt.Sprintf("Authoritative node: %s (%s)", status.AuthoritativeNode, authorityStatus)))
+		}
+
+		if teamShowPins {
+			fmt.Println()
+			printPins(status.Pins)
+			printTodos(status.Todos)
+		}
+
+		return nil
+	},
+}
+
+var (
+	clearAuthority bool
+	teamShowPins   bool
+)
+
+// teamAuthorityCmd designates the team's authoritative node
+var teamAuthorityCmd = &cobra.Command{
+	Use:   "authority [username]",
+	Short: "Show or set the team's authoritative node",
+	Long: utils.RenderTitle("👑 Authoritative Node") + `
+
+The authoritative node's version always wins automatic conflict resolution
+(--conflict auto) and it serves as the source for late-joiner snapshots
+and repairs. Typically this is the team lead's machine.
+
+Examples:
+  axle team authority          # Show the current authoritative node
+  axle team authority alice    # Make alice's node authoritative
+  axle team authority --clear  # Remove the designation`,
+
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		teamConfig, err := utils.GetTeamConfigForUpdate(ctx, config)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 && !clearAuthority {
+			if teamConfig.AuthoritativeNode == "" {
+				fmt.Println(utils.RenderInfo("No authoritative node is designated"))
+			} else {
+				fmt.Println(utils.RenderInfo("Authoritative node: " + teamConfig.AuthoritativeNode))
+			}
+			return nil
+		}
+
+		if clearAuthority {
+			teamConfig.AuthoritativeNode = ""
+		} else {
+			teamConfig.AuthoritativeNode = args[0]
+		}
+
+		if err := utils.SaveTeamConfig(ctx, config.RedisClient, teamConfig); err != nil {
+			return err
+		}
+
+		if clearAuthority {
+			fmt.Println(utils.RenderSuccess("Authoritative node cleared"))
+		} else {
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("%s is now the authoritative node", teamConfig.AuthoritativeNode)))
+		}
+		fmt.Println(utils.RenderInfo("Running daemons pick up the change on their next restart"))
+		return nil
+	},
+}
+
+// teamHeartbeatCmd configures the team-wide heartbeat interval
+var teamHeartbeatCmd = &cobra.Command{
+	Use:   "heartbeat [interval]",
+	Short: "Show or set the team's heartbeat interval",
+	Long: utils.RenderTitle("💓 Heartbeat Interval") + `
+
+Controls how often every member's daemon announces its presence. Members are
+shown as offline after missing heartbeats for twice this interval. Large teams
+on a constrained Redis may want slower heartbeats; demos may want faster ones.
+
+Examples:
+  axle team heartbeat        # Show the current interval
+  axle team heartbeat 15s    # Heartbeat every 15 seconds
+  axle team heartbeat 2m     # Heartbeat every 2 minutes`,
+
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		teamConfig, err := utils.GetTeamConfigForUpdate(ctx, config)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			interval := utils.EffectiveHeartbeatInterval(config)
+			fmt.Println(utils.RenderInfo(fmt.Sprintf("Heartbeat interval: %v (members go offline after %v)",
+				interval, utils.EffectivePresenceTimeout(config))))
+			return nil
+		}
+
+		interval, err := time.ParseDuration(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid interval %q: %w", args[0], err)
+		}
+		if interval < utils.MinHeartbeatInterval {
+			return fmt.Errorf("heartbeat interval must be at least %v", utils.MinHeartbeatInterval)
+		}
+
+		teamConfig.HeartbeatIntervalSeconds = int(interval.Seconds())
+		if err := utils.SaveTeamConfig(ctx, config.RedisClient, teamConfig); err != nil {
+			return err
+		}
+
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Heartbeat interval set to %v (presence timeout %v)", interval, 2*interval)))
+		fmt.Println(utils.RenderInfo("Running daemons pick up the change on their next restart"))
+		return nil
+	},
+}
+
+var persistenceRetentionDays int
+
+// teamPersistenceCmd turns durable batch storage on or off
+var teamPersistenceCmd = &cobra.Command{
+	Use:   "persistence [on|off]",
+	Short: "Show or set whether batches are stored for offline members",
+	Long: utils.RenderTitle("🗄️  Batch Persistence") + `
+
+When enabled, every published batch is also appended to a Redis Stream and
+kept for the retention period, so members who were offline can replay what
+they missed with 'axle catchup'. Useful for teams across time zones.
+
+Examples:
+  axle team persistence                        # Show the current setting
+  axle team persistence on --retention-days 30
+  axle team persistence off`,
+
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"on", "off"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		teamConfig, err := utils.GetTeamConfigForUpdate(ctx, config)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			if !teamConfig.PersistBatches {
+				fmt.Println(utils.RenderInfo("Batch persistence: off"))
+				return nil
+			}
+			length, _ := config.RedisClient.XLen(ctx, utils.BatchStreamKey(config.TeamID)).Result()
+			fmt.Println(utils.RenderInfo(fmt.Sprintf("Batch persistence: on (%d days retention, %d batches stored)",
+				retentionOrDefault(teamConfig.RetentionDays), length)))
+			return nil
+		}
+
+		switch args[0] {
+		case "on":
+			teamConfig.PersistBatches = true
+			if cmd.Flags().Changed("retention-days") {
+				if persistenceRetentionDays < 1 {
+					return fmt.Errorf("retention must be at least 1 day")
+				}
+				teamConfig.RetentionDays = persistenceRetentionDays
+			}
+		case "off":
+			teamConfig.PersistBatches = false
+		default:
+			return fmt.Errorf("invalid setting %q (use: on or off)", args[0])
+		}
+
+		if err := utils.SaveTeamConfig(ctx, config.RedisClient, teamConfig); err != nil {
+			return err
+		}
+
+		if teamConfig.PersistBatches {
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("Batch persistence enabled (%d days retention)", retentionOrDefault(teamConfig.RetentionDays))))
+		} else {
+			fmt.Println(utils.RenderSuccess("Batch persistence disabled"))
+		}
+		fmt.Println(utils.RenderInfo("Running daemons pick up the change on their next restart"))
+		return nil
+	},
+}
+
+// settingsMembers are the members a setting is rolled out to
+var settingsMembers []string
+
+// teamSettingsCmd shows or changes the team's feature toggles
+var teamSettingsCmd = &cobra.Command{
+	Use:   "settings [setting] [on|off]",
+	Short: "Show or change which kinds of events the team syncs",
+	Long: utils.RenderTitle("🎛️  Team Settings") + `
+
+Turns parts of Axle on or off for the whole team. Every member's daemon
+enforces a setting both when sending and when receiving, so a member whose
+daemon hasn't picked up a change yet can't push a disabled kind of event
+onto the others. Running daemons apply changes within 30 seconds; the
+settings marked (restart) need 'axle start' again.
+
+With --members a setting is rolled out gradually: it is on only for the
+members named, and off for the rest, until it is set on or off for
+everyone. New protocol features start off, so they can be tried by a few
+members, then turned on once the whole team has upgraded. Members on older
+releases ignore settings they don't know.
+
+Settings:
+  sync.deletes       Propagate file deletions to teammates
+  chat.enabled       Team chat through 'axle chat'
+  presence.enabled   Heartbeats and online status in 'axle team'
+
+Examples:
+  axle team settings                     # Show all settings
+  axle team settings sync.deletes false  # Keep deleted files on teammates' machines
+  axle team settings chat.enabled on
+  axle team settings sync.compress on --members alice,bob  # Only for alice and bob`,
+
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			if err := utils.ValidateFeature(args[0]); err != nil {
+				return err
+			}
+		}
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		teamConfig, err := utils.GetTeamConfigForUpdate(ctx, config)
+		if err != nil {
+			return err
+		}
+
+		if len(args) < 2 {
+			names := utils.FeatureNames()
+			if len(args) == 1 {
+				names = args
+			}
+			fmt.Println(utils.RenderInfo("🎛️  Team settings"))
+			for _, name := range names {
+				feature := utils.TeamFeatures[name]
+				state := onOff(teamConfig.FeatureEnabled(name))
+				if members, ok := teamConfig.FeatureRollout[name]; ok {
+					state = "on for " + strings.Join(members, ", ")
+				}
+				description := feature.Description
+				if feature.Restart {
+					description += " (restart)"
+				}
+				fmt.Printf("  %-18s %-5s %s\n", name, state, description)
+			}
+			if unknown := utils.UnknownFeatures(teamConfig.Features); len(unknown) > 0 && len(args) == 0 {
+				fmt.Println(utils.RenderWarning("Set by a newer Axle release: " + strings.Join(unknown, ", ")))
+			}
+			return nil
+		}
+
+		var enabled bool
+		switch strings.ToLower(args[1]) {
+		case "on", "true", "yes":
+			enabled = true
+		case "off", "false", "no":
+			enabled = false
+		default:
+			return fmt.Errorf("invalid value %q (use: on or off)", args[1])
+		}
+
+		if len(settingsMembers) > 0 && !enabled {
+			return fmt.Errorf("--members rolls a setting out; turning it off applies to everyone")
+		}
+
+		// The maps are shared with the fetched config; edit copies
+		features := make(map[string]bool, len(teamConfig.Features))
+		for name, on := range teamConfig.Features {
+			features[name] = on
+		}
+		rollout := make(map[string][]string, len(teamConfig.FeatureRollout))
+		for name, members := range teamConfig.FeatureRollout {
+			rollout[name] = members
+		}
+		delete(rollout, args[0])
+		if len(settingsMembers) > 0 {
+			rollout[args[0]] = settingsMembers
+		}
+		// Only settings that differ from their default are stored
+		if enabled == utils.TeamFeatures[args[0]].Default {
+			delete(features, args[0])
+		} else {
+			features[args[0]] = enabled
+		}
+		teamConfig.Features, teamConfig.FeatureRollout = features, rollout
+		if len(features) == 0 {
+			teamConfig.Features = nil
+		}
+		if len(rollout) == 0 {
+			teamConfig.FeatureRollout = nil
+		}
+		if err := utils.SaveTeamConfig(ctx, config.RedisClient, teamConfig); err != nil {
+			return err
+		}
+
+		if len(settingsMembers) > 0 {
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("%s is now on for %s", args[0], strings.Join(settingsMembers, ", "))))
+		} else {
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("%s is now %s", args[0], onOff(enabled))))
+		}
+		if utils.TeamFeatures[args[0]].Restart {
+			fmt.Println(utils.RenderInfo("Running daemons pick up the change on their next restart"))
+		} else {
+			fmt.Println(utils.RenderInfo("Running daemons pick up the change within 30 seconds"))
+		}
 		return nil
 	},
 }
 
+// teamStatsCmd turns the periodic stats broadcast on or off
+var teamStatsCmd = &cobra.Command{
+	Use:   "stats [interval|off]",
+	Short: "Show or set how often members broadcast a stats summary",
+	Long: utils.RenderTitle("🩺 Stats Broadcast") + `
+
+When enabled, every member's daemon publishes a compact summary of its syncs
+(files sent and received, failed changes, conflicts, publish latency) at the
+given interval. 'axle stats' gathers them into a team health view so a lead
+can spot a member whose syncs keep failing.
+
+Examples:
+  axle team stats        # Show the current setting
+  axle team stats 5m     # Broadcast every 5 minutes
+  axle team stats off`,
+
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		teamConfig, err := utils.GetTeamConfigForUpdate(ctx, config)
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			if teamConfig.StatsIntervalMinutes == 0 {
+				fmt.Println(utils.RenderInfo("Stats broadcast: off"))
+			} else {
+				fmt.Println(utils.RenderInfo(fmt.Sprintf("Stats broadcast: every %v", config.HealthInterval)))
+			}
+			return nil
+		}
+
+		if args[0] == "off" {
+			teamConfig.StatsIntervalMinutes = 0
+		} else {
+			interval, err := time.ParseDuration(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid interval %q: %w", args[0], err)
+			}
+			if interval < utils.MinHealthInterval {
+				return fmt.Errorf("stats interval must be at least %v", utils.MinHealthInterval)
+			}
+			teamConfig.StatsIntervalMinutes = int(interval.Round(time.Minute).Minutes())
+		}
+		if err := utils.SaveTeamConfig(ctx, config.RedisClient, teamConfig); err != nil {
+			return err
+		}
+
+		if teamConfig.StatsIntervalMinutes == 0 {
+			fmt.Println(utils.RenderSuccess("Stats broadcast disabled"))
+		} else {
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("Members broadcast their stats every %v",
+				time.Duration(teamConfig.StatsIntervalMinutes)*time.Minute)))
+		}
+		fmt.Println(utils.RenderInfo("Running daemons pick up the change on their next restart"))
+		return nil
+	},
+}
+
+// teamRoleCmd shows or sets members' roles
+var teamRoleCmd = &cobra.Command{
+	Use:   "role [username] [member|spectator]",
+	Short: "Show or set a member's role",
+	Long: utils.RenderTitle("🎭 Member Roles") + `
+
+Spectators, such as mentors or reviewers watching a hackathon team, receive
+the team's changes but never publish any: their daemon keeps their edits
+local, and everyone else's daemon drops changes that come from them anyway.
+They can still chat. Everyone else is a full member.
+
+Examples:
+  axle team role                    # List the spectators
+  axle team role alice              # Show alice's role
+  axle team role alice spectator    # alice only watches
+  axle team role alice member       # alice syncs again`,
+
+	Args: cobra.MaximumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		teamConfig, err := utils.GetTeamConfigForUpdate(ctx, config)
+		if err != nil {
+			return err
+		}
+
+		switch len(args) {
+		case 0:
+			spectators := teamConfig.Spectators()
+			if len(spectators) == 0 {
+				fmt.Println(utils.RenderInfo("No spectators; every member syncs"))
+				return nil
+			}
+			fmt.Println(utils.RenderInfo("Spectators: " + strings.Join(spectators, ", ")))
+			return nil
+		case 1:
+			fmt.Println(utils.RenderInfo(fmt.Sprintf("%s is a %s", args[0], teamConfig.Role(args[0]))))
+			return nil
+		}
+
+		username, role := args[0], args[1]
+		if err := utils.ValidateRole(role); err != nil {
+			return err
+		}
+		if role == utils.RoleSpectator && teamConfig.AuthoritativeNode == username {
+			return fmt.Errorf("%s is the authoritative node; clear that first with 'axle team authority --clear'", username)
+		}
+		roles := make(map[string]string, len(teamConfig.Roles)+1)
+		for name, r := range teamConfig.Roles {
+			roles[name] = r
+		}
+		if role == utils.RoleMember {
+			delete(roles, username)
+		} else {
+			roles[username] = role
+		}
+		teamConfig.Roles = roles
+		if err := utils.SaveTeamConfig(ctx, config.RedisClient, teamConfig); err != nil {
+			return err
+		}
+
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("%s is now a %s", username, role)))
+		fmt.Println(utils.RenderInfo("Running daemons pick up the change within a minute"))
+		return nil
+	},
+}
+
+var undoKick bool
+
+// teamKickCmd removes a member from the team
+var teamKickCmd = &cobra.Command{
+	Use:   "kick [username]",
+	Short: "Remove a member from the team",
+	Long: utils.RenderTitle("🚪 Remove Member") + `
+
+Removes a member from the team. Their username is recorded in the team
+settings, so every daemon ignores their changes, chat and presence; their
+membership and presence entries are deleted, and their own daemon stops
+within a minute. They can't join or start under that username again. Only
+the team admin can kick, and the team owner can't be kicked.
+
+Kicked members still know the team password, so they could join again
+under another username; change it with 'axle team passwd'.
+
+Examples:
+  axle team kick              # List kicked members
+  axle team kick mallory      # Remove mallory
+  axle team kick --undo mallory`,
+
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		if len(args) == 0 {
+			teamConfig, err := utils.GetTeamConfigForUpdate(ctx, config)
+			if err != nil {
+				return err
+			}
+			if len(teamConfig.Kicked) == 0 {
+				fmt.Println(utils.RenderInfo("No one has been kicked"))
+				return nil
+			}
+			fmt.Println(utils.RenderInfo("Kicked: " + strings.Join(teamConfig.Kicked, ", ")))
+			return nil
+		}
+
+		username := args[0]
+		if undoKick {
+			if err := utils.UnkickMember(ctx, config, username); err != nil {
+				return err
+			}
+			fmt.Println(utils.RenderSuccess(fmt.Sprintf("%s may join the team again", username)))
+			return nil
+		}
+
+		online, err := utils.KickMember(ctx, config, username)
+		if err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderSuccess(fmt.Sprintf("Removed %s from team %s", username, config.TeamID)))
+		if online > 0 {
+			fmt.Println(utils.RenderInfo(fmt.Sprintf("%s had %d node(s) online; their daemons stop within a minute", username, online)))
+		}
+		return nil
+	},
+}
+
+// teamPasswdCmd changes the team password
+var teamPasswdCmd = &cobra.Command{
+	Use:   "passwd",
+	Short: "Change the team password",
+	Long: utils.RenderTitle("🔑 Team Password") + `
+
+Changes the team password. Only the team admin can, as the team settings
+holding the password hash are signed with their key. Asks for the current
+password, or reads AXLE_PASSWORD, then for the new one twice.
+
+Messages are signed with a key derived from the password, so this machine
+switches to the new key and every member's daemon is told: their keys no
+longer match, so their daemons stop, with a notification, until they run
+'axle start' with the new password, which you tell them. A daemon that
+misses the announcement stops within 30 seconds of coming back. Guests keep
+their passes; invites from 'axle invite' carry the old password and stop
+working.
+
+Examples:
+  axle team passwd`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		current := os.Getenv("AXLE_PASSWORD")
+		if current == "" {
+			fmt.Print(utils.T("prompt.team_password"))
+			bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+			if err != nil {
+				return fmt.Errorf("failed to read password: %w", err)
+			}
+			current = string(bytePassword)
+			fmt.Println()
+		}
+		var passwords [2]string
+		for i, prompt := range []string{"New team password: ", "Repeat the new password: "} {
+			fmt.Print(prompt)
+			bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+			if err != nil {
+				return fmt.Errorf("failed to read password: %w", err)
+			}
+			passwords[i] = string(bytePassword)
+			fmt.Println()
+		}
+		if passwords[0] == "" {
+			return fmt.Errorf("the new password can't be empty")
+		}
+		if passwords[0] != passwords[1] {
+			return fmt.Errorf("the new passwords don't match")
+		}
+
+		if err := utils.ChangeTeamPassword(context.Background(), config, current, passwords[0]); err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderSuccess("Team password changed"))
+		fmt.Println(utils.RenderInfo("Members' daemons stop until restarted with the new password; tell them what it is"))
+		return nil
+	},
+}
+
+var teamLimits utils.MessageLimits
+
+// teamLimitsCmd shows or sets the size limits for chat and sync messages
+var teamLimitsCmd = &cobra.Command{
+	Use:   "limits",
+	Short: "Show or set the size limits for chat and sync messages",
+	Long: utils.RenderTitle("📏 Message Limits") + `
+
+Caps how large a single chat or sync message may be, and how much of each
+every member may send per minute, so one member can't swamp the team's
+shared server. Senders refuse messages over the limits with an error;
+receivers drop ones that get through anyway. A sync batch over the
+per-message limit is held until 'axle push-protected --confirm'.
+
+Sizes are in KB; 0 removes a limit.
+
+Examples:
+  axle team limits                                     # Show the current limits
+  axle team limits --chat-message 8 --chat-per-minute 64
+  axle team limits --sync-message 4096 --sync-per-minute 32768
+  axle team limits --sync-per-minute 0                 # No per-minute sync limit`,
+
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		teamConfig, err := utils.GetTeamConfigForUpdate(ctx, config)
+		if err != nil {
+			return err
+		}
+
+		limits := &teamConfig.Limits
+		settings := []struct {
+			flag  string
+			value *int
+			set   int
+		}{
+			{"chat-message", &limits.ChatMessageKB, teamLimits.ChatMessageKB},
+			{"chat-per-minute", &limits.ChatKBPerMinute, teamLimits.ChatKBPerMinute},
+			{"sync-message", &limits.SyncMessageKB, teamLimits.SyncMessageKB},
+			{"sync-per-minute", &limits.SyncKBPerMinute, teamLimits.SyncKBPerMinute},
+		}
+		changed := false
+		for _, setting := range settings {
+			if !cmd.Flags().Changed(setting.flag) {
+				continue
+			}
+			if setting.set < 0 {
+				return fmt.Errorf("--%s must be 0 or more", setting.flag)
+			}
+			*setting.value = setting.set
+			changed = true
+		}
+
+		if changed {
+			if err := utils.SaveTeamConfig(ctx, config.RedisClient, teamConfig); err != nil {
+				return err
+			}
+			fmt.Println(utils.RenderSuccess("Message limits updated"))
+		}
+		fmt.Printf("  Chat: %s per message, %s per minute\n", formatLimitKB(limits.ChatMessageKB), formatLimitKB(limits.ChatKBPerMinute))
+		fmt.Printf("  Sync: %s per message, %s per minute\n", formatLimitKB(limits.SyncMessageKB), formatLimitKB(limits.SyncKBPerMinute))
+		if changed {
+			fmt.Println(utils.RenderInfo("Running daemons pick up the change on their next restart"))
+		}
+		return nil
+	},
+}
+
+// formatLimitKB renders a message limit
+func formatLimitKB(kb int) string {
+	if kb == 0 {
+		return "no limit"
+	}
+	return fmt.Sprintf("%d KB", kb)
+}
+
+// onOff renders a toggle's state
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// retentionOrDefault returns the configured retention, falling back to the default
+func retentionOrDefault(days int) int {
+	if days > 0 {
+		return days
+	}
+	return utils.DefaultRetentionDays
+}
+
 func init() {
 	rootCmd.AddCommand(teamCmd)
+	teamCmd.Flags().BoolVar(&teamShowPins, "pins", false, "Also show pinned messages and open TODO items")
+	teamCmd.AddCommand(teamPersistenceCmd)
+	teamPersistenceCmd.Flags().IntVar(&persistenceRetentionDays, "retention-days", utils.DefaultRetentionDays, "How many days stored batches are kept")
+	teamCmd.AddCommand(teamHeartbeatCmd)
+	teamCmd.AddCommand(teamSettingsCmd)
+	teamSettingsCmd.Flags().StringSliceVar(&settingsMembers, "members", nil, "Turn the setting on only for these members, to roll it out gradually")
+	teamCmd.AddCommand(teamStatsCmd)
+	teamCmd.AddCommand(teamLimitsCmd)
+	teamCmd.AddCommand(teamRoleCmd)
+	teamCmd.AddCommand(teamKickCmd)
+	teamCmd.AddCommand(teamPasswdCmd)
+	teamKickCmd.Flags().BoolVar(&undoKick, "undo", false, "Let a kicked member join again")
+	teamLimitsCmd.Flags().IntVar(&teamLimits.ChatMessageKB, "chat-message", 0, "Largest chat message in KB, 0 for no limit")
+	teamLimitsCmd.Flags().IntVar(&teamLimits.ChatKBPerMinute, "chat-per-minute", 0, "KB of chat each member may send per minute, 0 for no limit")
+	teamLimitsCmd.Flags().IntVar(&teamLimits.SyncMessageKB, "sync-message", 0, "Largest sync batch in KB, 0 for no limit")
+	teamLimitsCmd.Flags().IntVar(&teamLimits.SyncKBPerMinute, "sync-per-minute", 0, "KB of sync batches each member may send per minute, 0 for no limit")
+	teamCmd.AddCommand(teamAuthorityCmd)
+	teamAuthorityCmd.Flags().BoolVar(&clearAuthority, "clear", false, "Remove the authoritative node designation")
 }
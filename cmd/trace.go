@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+// traceCmd reconstructs the journey of a single change across the team
+var traceCmd = &cobra.Command{
+	Use:   "trace <id>",
+	Short: "Follow a change from capture to every teammate's apply",
+	Long: utils.RenderTitle("🔎 Trace a Change") + `
+
+Every change gets a correlation ID (tr_...) when the watcher captures it.
+The ID travels with the change through the commit, the published batch,
+and each teammate's apply and ACK, and appears in the daemon logs.
+
+'axle trace' reconstructs the change's journey. Members running
+'axle start --trace' record every stage with a timestamp; otherwise the
+journey is rebuilt from the published batch and its ACKs. Find IDs with
+'axle history --traces' or in the [TRACE] log lines.
+
+Examples:
+  axle trace tr_3f9a1c2b7d4e`,
+	Args: cobra.ExactArgs(1),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := loadConfig(); err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' first", err)
+		}
+		defer config.RedisClient.Close()
+
+		ctx := context.Background()
+		traceID := args[0]
+
+		steps, err := utils.GetTraceSteps(ctx, config, traceID)
+		if err != nil {
+			return err
+		}
+		record, found, err := utils.FindTracedBatch(ctx, config, traceID)
+		if err != nil {
+			return err
+		}
+		if len(steps) == 0 && !found {
+			return fmt.Errorf("no trace found for %s; traces are kept for 24 hours", traceID)
+		}
+
+		fmt.Println(utils.RenderTitle("🔎 Trace " + traceID))
+		if len(steps) > 0 {
+			printTraceSteps(steps)
+		}
+		if found {
+			fmt.Println("")
+			printTracedBatch(ctx, record, traceID)
+		}
+		return nil
+	},
+}
+
+// printTraceSteps prints the recorded timeline with the time elapsed since capture
+func printTraceSteps(steps []utils.TraceStep) {
+	start := steps[0].TimestampMs
+	for _, step := range steps {
+		var details []string
+		if step.File != "" {
+			details = append(details, step.File)
+		}
+		if step.CommitHash != "" {
+			details = append(details, "commit "+shortCommit(step.CommitHash))
+		}
+		if step.BatchID != "" {
+			details = append(details, step.BatchID)
+		}
+		if step.Detail != "" {
+			details = append(details, step.Detail)
+		}
+		elapsed := time.Duration(step.TimestampMs-start) * time.Millisecond
+		fmt.Printf("  %s  +%-8s %-10s %-16s %s\n",
+			time.UnixMilli(step.TimestampMs).Format("15:04:05.000"), elapsed.Round(time.Millisecond),
+			step.Stage, step.Peer, truncateString(strings.Join(details, "  "), 70))
+	}
+}
+
+// printTracedBatch prints the batch that carried the change and each peer's ACK for it
+func printTracedBatch(ctx context.Context, record utils.BatchRecord, traceID string) {
+	fmt.Printf("Published by %s at %s in %s (%d files)\n",
+		record.PeerID, time.Unix(record.Timestamp, 0).Format("Jan 02 15:04:05"), record.BatchID, len(record.Files))
+
+	acks, err := utils.GetAcks(ctx, config, record.BatchID)
+	if err != nil {
+		fmt.Printf("    %s\n", utils.RenderError(err.Error()))
+		return
+	}
+	if len(acks) == 0 {
+		fmt.Println(utils.RenderInfo("No teammate has acknowledged this batch yet"))
+		return
+	}
+
+	peers := make([]string, 0, len(acks))
+	for peer := range acks {
+		peers = append(peers, peer)
+	}
+	sort.Strings(peers)
+
+	for _, peer := range peers {
+		ack := acks[peer]
+		switch {
+		case ack.Status == utils.AckHeld:
+			fmt.Printf("    🛡️  %-16s holding for confirmation\n", peer)
+		case ack.Status == utils.AckQuarantined:
+			fmt.Printf("    ☣️  %-16s quarantined: %s\n", peer, truncateString(ack.Error, 80))
+		case ack.Status == utils.AckFailed && containsString(ack.FailedTraces, traceID):
+			fmt.Printf("    ❌ %-16s failed: %s\n", peer, truncateString(ack.Error, 80))
+		default:
+			// A failed batch ACK still means this change applied unless it is listed
+			fmt.Printf("    ✅ %-16s applied %s\n", peer, formatTime(time.Unix(ack.Timestamp, 0)))
+		}
+	}
+}
+
+// shortCommit abbreviates a commit hash for display
+func shortCommit(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
+
+func init() {
+	rootCmd.AddCommand(traceCmd)
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tunnelRedis     string
+	tunnelLocalPort int
+	tunnelSSHPort   int
+	tunnelIdentity  string
+)
+
+// tunnelCmd carries Redis over SSH for teams whose Redis isn't exposed
+var tunnelCmd = &cobra.Command{
+	Use:   "tunnel <user@host>",
+	Short: "Reach the team's Redis through an SSH tunnel",
+	Long: utils.RenderTitle("🚇 SSH Tunnel") + `
+
+For teams that run Redis on a machine only reachable over SSH. Keeps an
+SSH port forward to it open, reconnecting whenever the link drops, and
+while it runs every axle command in this directory (including 'axle
+start') connects to Redis through it. The address in axle_config.json is
+left alone.
+
+Run it in its own terminal and leave it running; ssh asks for a password
+or passphrase there if it needs one. By default it forwards to Redis on
+the SSH host itself, at the port in your config.
+
+Examples:
+  axle tunnel alice@build-box
+  axle tunnel alice@bastion --redis 10.0.0.5:6379
+  axle tunnel alice@build-box -i ~/.ssh/team_key --ssh-port 2222`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		localCfg, err := loadConfigFromFile()
+		if err != nil {
+			return fmt.Errorf("configuration error: %w. Please run 'axle init' or 'axle join' first", err)
+		}
+
+		remote := tunnelRedis
+		if remote == "" {
+			_, port, err := net.SplitHostPort(localCfg.redisEndpoints()[0].Addr)
+			if err != nil {
+				port = "6379"
+			}
+			remote = net.JoinHostPort("localhost", port)
+		}
+		if _, _, err := net.SplitHostPort(remote); err != nil {
+			return fmt.Errorf("invalid --redis address %q: use host:port", remote)
+		}
+		if strings.HasPrefix(args[0], "-") {
+			return fmt.Errorf("invalid SSH target %q", args[0])
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		fmt.Println(utils.RenderTitle("🚇 SSH Tunnel"))
+		fmt.Println(utils.RenderInfo(fmt.Sprintf("Forwarding Redis at %s through %s. Press Ctrl+C to close the tunnel", remote, args[0])))
+		if err := utils.RunTunnel(ctx, localCfg.RootDir, utils.TunnelOptions{
+			Target:     args[0],
+			RemoteAddr: remote,
+			LocalPort:  tunnelLocalPort,
+			SSHPort:    tunnelSSHPort,
+			Identity:   tunnelIdentity,
+		}); err != nil {
+			return err
+		}
+		fmt.Println(utils.RenderSuccess("Tunnel closed; axle commands use the address in " + ConfigFileName + " again"))
+		return nil
+	},
+}
+
+// tunnelEndpoint returns the local end of a running 'axle tunnel' for rootDir.
+func tunnelEndpoint(rootDir string) (utils.RedisEndpoint, bool) {
+	tunnel, err := utils.ReadTunnelState(rootDir)
+	if err != nil || !tunnel.IsRunning() {
+		return utils.RedisEndpoint{}, false
+	}
+	return utils.RedisEndpoint{Addr: tunnel.LocalAddr, Region: "ssh tunnel via " + tunnel.Target}, true
+}
+
+func init() {
+	rootCmd.AddCommand(tunnelCmd)
+	tunnelCmd.Flags().StringVar(&tunnelRedis, "redis", "", "Redis address as seen from the SSH host (default localhost and the port in your config)")
+	tunnelCmd.Flags().IntVar(&tunnelLocalPort, "local-port", 0, "Local port for the forward (0 = any free port)")
+	tunnelCmd.Flags().IntVar(&tunnelSSHPort, "ssh-port", 0, "SSH port on the host (0 = ssh's default)")
+	tunnelCmd.Flags().StringVarP(&tunnelIdentity, "identity", "i", "", "Private key file for SSH")
+}
@@ -0,0 +1,311 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/parzi-val/axle-file-sync/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tutorialKeep bool
+	tutorialAuto bool
+)
+
+// tutorialCmd walks a new member through syncing and resolving a conflict in a sandbox
+var tutorialCmd = &cobra.Command{
+	Use:   "tutorial",
+	Short: "Learn how Axle syncs and resolves conflicts in a safe sandbox",
+	Long: utils.RenderTitle("🎓 Axle Tutorial") + `
+
+Walks you through a scripted scenario using two throwaway repositories in a
+temporary directory: "you" and a simulated teammate. Nothing touches your
+real project, Redis, or your team.
+
+You will:
+1. Make a change and see the patch Axle sends to your team
+2. Receive a change from your teammate
+3. Edit the same line at the same time and hit a conflict
+4. Resolve the conflict the way you would in a real session
+
+Examples:
+  axle tutorial
+  axle tutorial --keep   # Keep the sandbox afterwards to poke around`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The sandbox uses the same git helpers as the daemon; keep their logs out of the lesson
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(os.Stderr)
+
+		sandbox, err := os.MkdirTemp("", "axle-tutorial-")
+		if err != nil {
+			return fmt.Errorf("failed to create sandbox: %w", err)
+		}
+		if tutorialKeep {
+			defer fmt.Println(utils.RenderInfo("Sandbox kept at " + sandbox))
+		} else {
+			defer os.RemoveAll(sandbox)
+		}
+
+		t := &tutorial{
+			reader: bufio.NewReader(os.Stdin),
+			you:    filepath.Join(sandbox, "you"),
+			mate:   filepath.Join(sandbox, "teammate"),
+		}
+
+		fmt.Println(utils.RenderTitle("🎓 Axle Tutorial"))
+		fmt.Println("This sandbox has two copies of a tiny project: yours and your teammate Sam's.")
+		fmt.Println("In a real session each copy runs 'axle start' on its own machine and Redis")
+		fmt.Println("carries the patches between them. Here we pass them along ourselves.")
+		fmt.Println()
+
+		steps := []func() error{t.setup, t.sendChange, t.receiveChange, t.conflict}
+		for _, step := range steps {
+			if err := step(); err != nil {
+				return err
+			}
+		}
+
+		fmt.Println()
+		fmt.Println(utils.RenderSuccess("Tutorial complete! You're ready to sync with your team."))
+		fmt.Println(utils.RenderInfo("Next steps:"))
+		fmt.Println("  axle join     - Join your team's repository")
+		fmt.Println("  axle start    - Start syncing (try --conflict merge)")
+		return nil
+	},
+}
+
+// tutorial holds the sandbox repositories and input for one run
+type tutorial struct {
+	reader *bufio.Reader
+	you    string
+	mate   string
+}
+
+// pause waits for the user to press Enter
+func (t *tutorial) pause() {
+	if tutorialAuto {
+		fmt.Println()
+		return
+	}
+	fmt.Print(utils.RenderInfo("Press Enter to continue..."))
+	t.reader.ReadString('\n')
+	fmt.Println()
+}
+
+// git runs a git command in dir with the sandbox identity
+func (t *tutorial) git(dir string, args ...string) (string, error) {
+	full := append([]string{"-C", dir, "-c", "user.name=Axle Tutorial", "-c", "user.email=tutorial@axle.local"}, args...)
+	output, err := utils.GitCommand(full...).CombinedOutput()
+	return string(output), err
+}
+
+// configureIdentity sets a local identity so commits work without global git config
+func (t *tutorial) configureIdentity(dir, name string) error {
+	if _, err := t.git(dir, "config", "user.name", name); err != nil {
+		return err
+	}
+	_, err := t.git(dir, "config", "user.email", strings.ToLower(name)+"@axle.local")
+	return err
+}
+
+// sync commits the changes in from and applies the resulting patch to to, like the daemon does
+func (t *tutorial) sync(from, to string, message string) (string, error) {
+	commitHash, err := utils.CommitChanges(from, message)
+	if err != nil {
+		return "", err
+	}
+	patch, err := utils.GetPatch(from, commitHash)
+	if err != nil {
+		return "", err
+	}
+	if _, err := utils.ApplyPatch(to, patch); err != nil {
+		return "", fmt.Errorf("failed to apply patch: %w", err)
+	}
+	return patch, nil
+}
+
+// setup creates both repositories from a shared first commit
+func (t *tutorial) setup() error {
+	fmt.Print("Creating sandbox repositories... ")
+	if err := os.MkdirAll(t.you, 0755); err != nil {
+		fmt.Println(utils.RenderError("failed"))
+		return err
+	}
+	if out, err := t.git(t.you, "init", "-q"); err != nil {
+		fmt.Println(utils.RenderError("failed"))
+		return fmt.Errorf("git init failed: %s", out)
+	}
+	if err := t.configureIdentity(t.you, "You"); err != nil {
+		fmt.Println(utils.RenderError("failed"))
+		return err
+	}
+	greeting := "Welcome to our hackathon project!\nStatus: planning\n"
+	if err := os.WriteFile(filepath.Join(t.you, "README.md"), []byte(greeting), 0644); err != nil {
+		fmt.Println(utils.RenderError("failed"))
+		return err
+	}
+	if _, err := utils.CommitChanges(t.you, "Initial commit"); err != nil {
+		fmt.Println(utils.RenderError("failed"))
+		return err
+	}
+	if out, err := utils.GitCommand("clone", "-q", t.you, t.mate).CombinedOutput(); err != nil {
+		fmt.Println(utils.RenderError("failed"))
+		return fmt.Errorf("git clone failed: %s", out)
+	}
+	if err := t.configureIdentity(t.mate, "Sam"); err != nil {
+		fmt.Println(utils.RenderError("failed"))
+		return err
+	}
+	fmt.Println(utils.RenderSuccess("done"))
+	fmt.Printf("  You:  %s\n  Sam:  %s\n", t.you, t.mate)
+	t.pause()
+	return nil
+}
+
+// sendChange shows the user's edit travelling to the teammate
+func (t *tutorial) sendChange() error {
+	fmt.Println(utils.RenderTitle("Step 1: Making a change"))
+	fmt.Println("You add a file. The watcher notices it, waits a moment to batch related")
+	fmt.Println("edits, commits them, and publishes the commit as a patch:")
+	fmt.Println()
+
+	if err := os.WriteFile(filepath.Join(t.you, "app.py"), []byte("print(\"hello\")\n"), 0644); err != nil {
+		return err
+	}
+	patch, err := t.sync(t.you, t.mate, "Create app.py")
+	if err != nil {
+		return err
+	}
+	fmt.Println(summarizePatch(patch))
+	fmt.Println()
+	fmt.Println(utils.RenderSuccess("Sam's copy now has app.py too."))
+	t.pause()
+	return nil
+}
+
+// receiveChange shows a teammate's edit arriving
+func (t *tutorial) receiveChange() error {
+	fmt.Println(utils.RenderTitle("Step 2: Receiving a change"))
+	fmt.Println("Sam improves app.py. Their daemon publishes the patch and yours applies it:")
+	fmt.Println()
+
+	if err := os.WriteFile(filepath.Join(t.mate, "app.py"), []byte("print(\"hello, team\")\n"), 0644); err != nil {
+		return err
+	}
+	if _, err := t.sync(t.mate, t.you, "Modify app.py"); err != nil {
+		return err
+	}
+	content, _ := os.ReadFile(filepath.Join(t.you, "app.py"))
+	fmt.Printf("  Your app.py now reads: %s\n", strings.TrimSpace(string(content)))
+	fmt.Println()
+	fmt.Println(utils.RenderSuccess("No action needed on your side: non-overlapping changes just merge."))
+	t.pause()
+	return nil
+}
+
+// conflict has both sides edit the same line and lets the user resolve it
+func (t *tutorial) conflict() error {
+	fmt.Println(utils.RenderTitle("Step 3: Hitting a conflict"))
+	fmt.Println("Now you and Sam both edit the Status line of README.md at the same time.")
+	fmt.Println()
+
+	readmeYou := filepath.Join(t.you, "README.md")
+	if err := os.WriteFile(readmeYou, []byte("Welcome to our hackathon project!\nStatus: building the API\n"), 0644); err != nil {
+		return err
+	}
+	if _, err := utils.CommitChanges(t.you, "Modify README.md"); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(t.mate, "README.md"), []byte("Welcome to our hackathon project!\nStatus: designing the UI\n"), 0644); err != nil {
+		return err
+	}
+	mateHash, err := utils.CommitChanges(t.mate, "Modify README.md")
+	if err != nil {
+		return err
+	}
+	patch, err := utils.GetPatch(t.mate, mateHash)
+	if err != nil {
+		return err
+	}
+
+	// This is what 'axle start --conflict merge' does with an overlapping patch
+	cmd := utils.GitCommand("-C", t.you, "am", "--3way")
+	cmd.Stdin = strings.NewReader(patch)
+	if err := cmd.Run(); err == nil {
+		return fmt.Errorf("expected a conflict, but Sam's patch applied cleanly")
+	}
+
+	content, _ := os.ReadFile(readmeYou)
+	fmt.Println("Sam's patch can't apply cleanly, so Axle (with --conflict merge) leaves")
+	fmt.Println("Git conflict markers in the file for you to resolve:")
+	fmt.Println()
+	for _, line := range strings.Split(strings.TrimRight(string(content), "\n"), "\n") {
+		fmt.Println("  │ " + line)
+	}
+	fmt.Println()
+	fmt.Println("Between <<<<<<< and ======= is your version; between ======= and >>>>>>> is Sam's.")
+	fmt.Println("Keep what you want and delete the marker lines.")
+	fmt.Println()
+
+	if tutorialAuto {
+		os.WriteFile(readmeYou, []byte("Welcome to our hackathon project!\nStatus: building the API and designing the UI\n"), 0644)
+	} else {
+		fmt.Printf("Open %s in your editor, resolve it, and save.\n", readmeYou)
+		for {
+			t.pause()
+			content, _ := os.ReadFile(readmeYou)
+			if !strings.Contains(string(content), "<<<<<<<") && !strings.Contains(string(content), ">>>>>>>") {
+				break
+			}
+			fmt.Println(utils.RenderWarning("The file still has conflict markers. Remove every <<<<<<<, =======, and >>>>>>> line."))
+		}
+	}
+
+	if out, err := t.git(t.you, "add", "README.md"); err != nil {
+		return fmt.Errorf("git add failed: %s", out)
+	}
+	if out, err := t.git(t.you, "am", "--continue"); err != nil {
+		return fmt.Errorf("failed to finish the merge: %s", out)
+	}
+
+	content, _ = os.ReadFile(readmeYou)
+	fmt.Println(utils.RenderSuccess("Conflict resolved! Your README.md:"))
+	for _, line := range strings.Split(strings.TrimRight(string(content), "\n"), "\n") {
+		fmt.Println("  │ " + line)
+	}
+	fmt.Println()
+	fmt.Println("In a real session, saving the resolved file is enough: Axle syncs the")
+	fmt.Println("resolution to everyone. Other strategies avoid markers entirely:")
+	fmt.Println("  --conflict theirs   Always take incoming changes")
+	fmt.Println("  --conflict auto     Let a deterministic rule pick the same winner on every machine")
+	return nil
+}
+
+// summarizePatch shows the diff part of a patch, indented
+func summarizePatch(patch string) string {
+	if idx := strings.Index(patch, "diff --git"); idx >= 0 {
+		patch = patch[idx:]
+	}
+	if idx := strings.Index(patch, "\n-- \n"); idx >= 0 {
+		patch = patch[:idx]
+	}
+	lines := strings.Split(strings.TrimRight(patch, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = "  │ " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func init() {
+	rootCmd.AddCommand(tutorialCmd)
+	tutorialCmd.Flags().BoolVar(&tutorialKeep, "keep", false, "Keep the sandbox directory afterwards")
+	tutorialCmd.Flags().BoolVar(&tutorialAuto, "yes", false, "Run without pausing and resolve the conflict automatically")
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ACK statuses reported by receivers
+const (
+	AckApplied = "applied"
+	AckFailed  = "failed"
+	AckHeld    = "held" // Waiting for local confirmation (protected paths)
+	// Flagged by the receiver's scanner and not applied
+	AckQuarantined = "quarantined"
+)
+
+const (
+	// ackRetention bounds how long batch records and their ACKs are kept
+	ackRetention = 24 * time.Hour
+	// maxSentBatches is how many published batches are remembered per member
+	maxSentBatches = 100
+)
+
+// BatchRecord summarizes a published batch for delivery tracking.
+type BatchRecord struct {
+	BatchID   string   `json:"batchID"`
+	PeerID    string   `json:"peerID"`
+	Timestamp int64    `json:"timestamp"`
+	Seq       int64    `json:"seq,omitempty"` // Team-wide sequence number of the batch
+	Files     []string `json:"files"`
+	TraceIDs  []string `json:"traceIDs,omitempty"`
+}
+
+// BatchAck is a receiver's report on what happened to a batch.
+type BatchAck struct {
+	Peer      string `json:"peer"`
+	NodeID    string `json:"nodeID"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+	// Correlation IDs of the changes that failed, for 'axle trace'
+	FailedTraces []string `json:"failedTraces,omitempty"`
+}
+
+// GenerateBatchID creates a unique identifier for a published batch.
+func GenerateBatchID() string {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return fmt.Sprintf("batch_%d", time.Now().UnixNano())
+	}
+	return "batch_" + hex.EncodeToString(bytes)
+}
+
+func sentBatchesKey(teamID, username string) string {
+	return fmt.Sprintf("axle:batches:%s:%s", teamID, username)
+}
+
+func acksKey(teamID, batchID string) string {
+	return fmt.Sprintf("axle:acks:%s:%s", teamID, batchID)
+}
+
+// StartSentBatchRecorder records every batch this node publishes so its
+// delivery can be tracked with 'axle history --acks'.
+func StartSentBatchRecorder(ctx context.Context, cfg AppConfig) {
+	events, unsubscribe := Events.Subscribe(TopicBatchPublished)
+	defer unsubscribe()
+
+	for {
+		select {
+		case event := <-events:
+			metadata := event.Payload.(BatchPublishedEvent).Metadata
+			if metadata.BatchID == "" {
+				continue
+			}
+
+			record := BatchRecord{BatchID: metadata.BatchID, PeerID: metadata.PeerID, Timestamp: metadata.Timestamp, Seq: metadata.Seq}
+			for _, change := range metadata.Changes {
+				if !contains(record.Files, change.File) {
+					record.Files = append(record.Files, change.File)
+				}
+				if change.TraceID != "" {
+					record.TraceIDs = append(record.TraceIDs, change.TraceID)
+				}
+			}
+
+			data, err := json.Marshal(record)
+			if err != nil {
+				continue
+			}
+			key := sentBatchesKey(cfg.TeamID, cfg.Username)
+			pipe := cfg.RedisClient.TxPipeline()
+			pipe.LPush(ctx, key, data)
+			pipe.LTrim(ctx, key, 0, maxSentBatches-1)
+			pipe.Expire(ctx, key, ackRetention)
+			if _, err := pipe.Exec(ctx); err != nil {
+				log.Printf("[ACK] Failed to record published batch %s: %v", metadata.BatchID, err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// SendAck reports this node's outcome for a received batch, including the
+// correlation IDs of any changes that failed.
+func SendAck(ctx context.Context, cfg AppConfig, batchID, status, errText string, failedTraces []string) {
+	if batchID == "" {
+		return // Batch from an older client without delivery tracking
+	}
+
+	ack := BatchAck{
+		Peer:         cfg.Username,
+		NodeID:       cfg.NodeID,
+		Status:       status,
+		Error:        errText,
+		Timestamp:    time.Now().Unix(),
+		FailedTraces: failedTraces,
+	}
+	data, err := json.Marshal(ack)
+	if err != nil {
+		return
+	}
+
+	key := acksKey(cfg.TeamID, batchID)
+	pipe := cfg.RedisClient.TxPipeline()
+	pipe.HSet(ctx, key, cfg.Username, data)
+	pipe.Expire(ctx, key, ackRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[ACK] Failed to acknowledge batch %s: %v", batchID, err)
+	}
+}
+
+// ListSentBatches returns the most recent batches published by a member, newest first.
+func ListSentBatches(ctx context.Context, cfg AppConfig, username string, limit int) ([]BatchRecord, error) {
+	entries, err := cfg.RedisClient.LRange(ctx, sentBatchesKey(cfg.TeamID, username), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list published batches: %w", err)
+	}
+
+	records := make([]BatchRecord, 0, len(entries))
+	for _, entry := range entries {
+		var record BatchRecord
+		if err := json.Unmarshal([]byte(entry), &record); err == nil {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// GetAcks returns the ACKs received for a batch, keyed by username.
+func GetAcks(ctx context.Context, cfg AppConfig, batchID string) (map[string]BatchAck, error) {
+	entries, err := cfg.RedisClient.HGetAll(ctx, acksKey(cfg.TeamID, batchID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get acknowledgments for %s: %w", batchID, err)
+	}
+
+	acks := make(map[string]BatchAck, len(entries))
+	for peer, entry := range entries {
+		var ack BatchAck
+		if err := json.Unmarshal([]byte(entry), &ack); err == nil {
+			acks[peer] = ack
+		}
+	}
+	return acks, nil
+}
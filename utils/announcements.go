@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Announcement is a message for the whole team that, unlike chat, is kept
+// and can require each member to acknowledge it.
+type Announcement struct {
+	ID         int64    `json:"id"`
+	Text       string   `json:"text"`
+	Author     string   `json:"author"`
+	Timestamp  int64    `json:"timestamp"`
+	RequireAck bool     `json:"requireAck,omitempty"`
+	Recipients []string `json:"recipients,omitempty"` // Members expected to acknowledge, fixed when sent
+}
+
+// AnnouncementMessage is published when an announcement is made or acknowledged.
+type AnnouncementMessage struct {
+	Type         string       `json:"type"` // "announce" or "ack"
+	Announcement Announcement `json:"announcement"`
+	Username     string       `json:"username,omitempty"` // Who acknowledged, for "ack"
+	Timestamp    int64        `json:"timestamp"`
+}
+
+func announcementsKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:announcements", teamID)
+}
+
+// announcementAcksKey maps usernames to when they acknowledged an announcement.
+func announcementAcksKey(teamID string, id int64) string {
+	return fmt.Sprintf("axle:team:%s:announcement:%d:acks", teamID, id)
+}
+
+func announcementSeqKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:announcement_seq", teamID)
+}
+
+// PostAnnouncement stores an announcement and broadcasts it to the team.
+func PostAnnouncement(ctx context.Context, cfg AppConfig, text string, requireAck bool) (Announcement, error) {
+	id, err := cfg.RedisClient.Incr(ctx, announcementSeqKey(cfg.TeamID)).Result()
+	if err != nil {
+		return Announcement{}, fmt.Errorf("failed to allocate announcement ID: %w", err)
+	}
+
+	announcement := Announcement{
+		ID:         id,
+		Text:       text,
+		Author:     cfg.Username,
+		Timestamp:  time.Now().Unix(),
+		RequireAck: requireAck,
+	}
+	if requireAck {
+		members, err := GetMembers(ctx, cfg.RedisClient, cfg.TeamID)
+		if err != nil {
+			return Announcement{}, err
+		}
+		for _, member := range members {
+			if member != cfg.Username {
+				announcement.Recipients = append(announcement.Recipients, member)
+			}
+		}
+	}
+
+	data, err := json.Marshal(announcement)
+	if err != nil {
+		return Announcement{}, err
+	}
+	if err := cfg.RedisClient.HSet(ctx, announcementsKey(cfg.TeamID), strconv.FormatInt(id, 10), data).Err(); err != nil {
+		return Announcement{}, fmt.Errorf("failed to save announcement: %w", err)
+	}
+
+	msg := AnnouncementMessage{Type: "announce", Announcement: announcement, Timestamp: announcement.Timestamp}
+	if err := PublishMessage(ctx, cfg.Transport, AnnounceChannel(cfg.TeamID), msg); err != nil {
+		return Announcement{}, fmt.Errorf("failed to broadcast announcement: %w", err)
+	}
+	return announcement, nil
+}
+
+// GetAnnouncement returns an announcement by ID.
+func GetAnnouncement(ctx context.Context, cfg AppConfig, id int64) (Announcement, error) {
+	data, err := cfg.RedisClient.HGet(ctx, announcementsKey(cfg.TeamID), strconv.FormatInt(id, 10)).Bytes()
+	if err == redis.Nil {
+		return Announcement{}, fmt.Errorf("no announcement #%d", id)
+	} else if err != nil {
+		return Announcement{}, fmt.Errorf("failed to read announcement #%d: %w", id, err)
+	}
+
+	var announcement Announcement
+	if err := json.Unmarshal(data, &announcement); err != nil {
+		return Announcement{}, fmt.Errorf("corrupt announcement #%d: %w", id, err)
+	}
+	return announcement, nil
+}
+
+// ListAnnouncements returns all announcements, oldest first.
+func ListAnnouncements(ctx context.Context, cfg AppConfig) ([]Announcement, error) {
+	entries, err := cfg.RedisClient.HGetAll(ctx, announcementsKey(cfg.TeamID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list announcements: %w", err)
+	}
+
+	announcements := make([]Announcement, 0, len(entries))
+	for _, data := range entries {
+		var announcement Announcement
+		if err := json.Unmarshal([]byte(data), &announcement); err != nil {
+			continue
+		}
+		announcements = append(announcements, announcement)
+	}
+	sort.Slice(announcements, func(i, j int) bool { return announcements[i].ID < announcements[j].ID })
+	return announcements, nil
+}
+
+// AckAnnouncement records that this member has read an announcement and
+// tells the team.
+func AckAnnouncement(ctx context.Context, cfg AppConfig, id int64) (Announcement, error) {
+	announcement, err := GetAnnouncement(ctx, cfg, id)
+	if err != nil {
+		return Announcement{}, err
+	}
+
+	now := time.Now().Unix()
+	if err := cfg.RedisClient.HSet(ctx, announcementAcksKey(cfg.TeamID, id), cfg.Username, now).Err(); err != nil {
+		return Announcement{}, fmt.Errorf("failed to acknowledge #%d: %w", id, err)
+	}
+
+	msg := AnnouncementMessage{Type: "ack", Announcement: announcement, Username: cfg.Username, Timestamp: now}
+	if err := PublishMessage(ctx, cfg.Transport, AnnounceChannel(cfg.TeamID), msg); err != nil {
+		log.Printf("[ANNOUNCE] Failed to broadcast acknowledgment of #%d: %v", id, err)
+	}
+	return announcement, nil
+}
+
+// AnnouncementAcks returns when each member acknowledged an announcement.
+func AnnouncementAcks(ctx context.Context, cfg AppConfig, id int64) (map[string]time.Time, error) {
+	entries, err := cfg.RedisClient.HGetAll(ctx, announcementAcksKey(cfg.TeamID, id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read acknowledgments for #%d: %w", id, err)
+	}
+
+	acks := make(map[string]time.Time, len(entries))
+	for username, value := range entries {
+		timestamp, _ := strconv.ParseInt(value, 10, 64)
+		acks[username] = time.Unix(timestamp, 0)
+	}
+	return acks, nil
+}
+
+// PendingAnnouncements returns the announcements this member still has to acknowledge.
+func PendingAnnouncements(ctx context.Context, cfg AppConfig) ([]Announcement, error) {
+	announcements, err := ListAnnouncements(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Announcement
+	for _, announcement := range announcements {
+		if !announcement.RequireAck || !contains(announcement.Recipients, cfg.Username) {
+			continue
+		}
+		acked, err := cfg.RedisClient.HExists(ctx, announcementAcksKey(cfg.TeamID, announcement.ID), cfg.Username).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read acknowledgments for #%d: %w", announcement.ID, err)
+		}
+		if !acked {
+			pending = append(pending, announcement)
+		}
+	}
+	return pending, nil
+}
+
+// ProcessAnnouncementMessage shows teammates' announcements prominently and
+// tells authors when their announcements are acknowledged.
+func ProcessAnnouncementMessage(cfg AppConfig, payload string) {
+	var msg AnnouncementMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		log.Printf("[ANNOUNCE] Error unmarshaling announcement: %v", err)
+		return
+	}
+	announcement := msg.Announcement
+	sender := announcement.Author
+	if msg.Type == "ack" {
+		sender = msg.Username
+	}
+	if AdmitSigned(cfg, AnnounceChannel(cfg.TeamID), sender, payload) != nil {
+		return
+	}
+
+	switch msg.Type {
+	case "announce":
+		if announcement.Author == cfg.Username {
+			return
+		}
+		fmt.Println()
+		fmt.Println(RenderWarning(fmt.Sprintf("📣 Announcement #%d from %s", announcement.ID, announcement.Author)))
+		fmt.Println("   " + announcement.Text)
+		if announcement.RequireAck && contains(announcement.Recipients, cfg.Username) {
+			fmt.Println("   " + RenderInfo(fmt.Sprintf("Acknowledge with 'axle ack %d'", announcement.ID)))
+		}
+		fmt.Println()
+		SendNotification(fmt.Sprintf("Axle - Announcement from %s", announcement.Author), announcement.Text)
+	case "ack":
+		if announcement.Author != cfg.Username || msg.Username == cfg.Username {
+			return
+		}
+		log.Printf("[ANNOUNCE] %s acknowledged announcement #%d", msg.Username, announcement.ID)
+	}
+}
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// appendSyncThreshold is the minimum size for a file to be considered for
+// append-only (tail) sync. Smaller files go through the regular patch path.
+const appendSyncThreshold int64 = 1024 * 1024
+
+// detectAppend checks whether a file only grew since its last committed
+// version. It returns the offset where new data starts and the appended bytes.
+func detectAppend(directory, relPath string) (int64, []byte, bool) {
+	gitPath := filepath.ToSlash(relPath)
+
+	sizeOut, err := GitCommand("-C", directory, "cat-file", "-s", "HEAD:"+gitPath).Output()
+	if err != nil {
+		return 0, nil, false // Not committed yet
+	}
+	oldSize, err := strconv.ParseInt(strings.TrimSpace(string(sizeOut)), 10, 64)
+	if err != nil || oldSize < appendSyncThreshold {
+		return 0, nil, false
+	}
+
+	current, err := os.ReadFile(filepath.Join(directory, relPath))
+	if err != nil || int64(len(current)) <= oldSize {
+		return 0, nil, false
+	}
+
+	committed, err := GitCommand("-C", directory, "show", "HEAD:"+gitPath).Output()
+	if err != nil || int64(len(committed)) != oldSize || !bytes.Equal(current[:oldSize], committed) {
+		return 0, nil, false
+	}
+
+	return oldSize, current[oldSize:], true
+}
+
+// queueAppendChange syncs an append-only modification of a large file by
+// sending just the appended bytes. It returns false if the modification is
+// not a pure append, in which case the regular batch path should be used.
+func queueAppendChange(cfg AppConfig, absPath, relPath string) bool {
+	offset, appended, ok := detectAppend(cfg.RootDir, relPath)
+	if !ok || int64(len(appended)) > maxFileSize {
+		return false
+	}
+
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return false
+	}
+
+	// Commit locally so the next append is measured from here
+	commitHash, err := CommitFiles(cfg.RootDir, fmt.Sprintf("Append to %s", relPath), relPath)
+	if err != nil {
+		log.Printf("[APPEND] Failed to commit append to %s, falling back to patch sync: %v", relPath, err)
+		return false
+	}
+
+	mu.Lock()
+	queueChanges(cfg.RootDir, FileChange{
+		File:       relPath,
+		Event:      "appended",
+		CommitHash: commitHash,
+		Offset:     offset,
+		Data:       base64.StdEncoding.EncodeToString(appended),
+		Hash:       HashContent(content),
+		TraceID:    GenerateTraceID(),
+	})
+	mu.Unlock()
+
+	log.Printf("[APPEND] %s grew by %d bytes; syncing the tail only", relPath, len(appended))
+	return true
+}
+
+// ApplyAppend applies an append-only change by writing the new bytes at the
+// recorded offset, then commits the file as author. The local file must
+// match the sender's size before the append, and the result must match its
+// hash.
+func ApplyAppend(directory string, change FileChange, author CommitAuthor) error {
+	if err := validatePatchPath(change.File); err != nil {
+		return err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(change.Data)
+	if err != nil {
+		return fmt.Errorf("invalid appended data for %s: %w", change.File, err)
+	}
+
+	fullPath := filepath.Join(directory, change.File)
+	current, err := os.ReadFile(fullPath)
+	if err != nil {
+		return fmt.Errorf("cannot append to %s: %w", change.File, err)
+	}
+	if int64(len(current)) != change.Offset {
+		return fmt.Errorf("cannot append to %s: local size %d does not match sender's %d", change.File, len(current), change.Offset)
+	}
+
+	updated := append(current, data...)
+	if change.Hash != "" && HashContent(updated) != change.Hash {
+		return fmt.Errorf("cannot append to %s: content differs from sender's", change.File)
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(fullPath, updated, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", change.File, err)
+	}
+
+	if _, err := CommitFilesAs(directory, fmt.Sprintf("Append to %s", change.File), author, change.File); err != nil {
+		return fmt.Errorf("failed to commit append to %s: %w", change.File, err)
+	}
+	return nil
+}
+
+// ValidateIncomingChange decodes a teammate's change and checks the paths it
+// names and its patch, before anything of it is applied. 'axle fuzz' runs
+// the same checks.
+func ValidateIncomingChange(change *FileChange) error {
+	if err := DecodeChange(change); err != nil {
+		return err
+	}
+	if err := validatePatchPath(change.File); err != nil {
+		return err
+	}
+	if change.From != "" {
+		if err := validatePatchPath(change.From); err != nil {
+			return err
+		}
+	}
+	if change.Patch != "" {
+		if err := validatePatch(change.Patch); err != nil {
+			return fmt.Errorf("patch validation failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// validatePatchPath rejects paths that escape the sync root, and the root
+// itself.
+func validatePatchPath(relPath string) error {
+	clean := filepath.ToSlash(filepath.Clean(relPath))
+	if filepath.IsAbs(relPath) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("path %s is outside the sync root", relPath)
+	}
+	if clean == "." {
+		return fmt.Errorf("path %q is the sync root itself", relPath)
+	}
+	if isAxlePath(clean) {
+		return fmt.Errorf("path %s is inside Axle's reserved %s directory", relPath, AxleDirName)
+	}
+	if dir := outputDirOf(clean); dir != "" {
+		return fmt.Errorf("path %s is inside the generated-output directory %s", relPath, dir)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AxleDirName is the directory holding Axle's local state inside the sync
+// root. It is reserved: the watcher never watches it, git never tracks it,
+// and incoming changes may not touch it.
+const AxleDirName = ".axle"
+
+// AxlePath returns a path inside the sync root's Axle state directory.
+func AxlePath(rootDir string, elem ...string) string {
+	return filepath.Join(append([]string{rootDir, AxleDirName}, elem...)...)
+}
+
+// isAxlePath reports whether any component of a path is the Axle state
+// directory. Nested ones count too, so a subdirectory that is itself an
+// Axle root is never synced either.
+func isAxlePath(path string) bool {
+	for _, part := range strings.FieldsFunc(filepath.ToSlash(path), func(r rune) bool { return r == '/' }) {
+		// Case-insensitive file systems treat .AXLE as the same directory
+		if strings.EqualFold(part, AxleDirName) {
+			return true
+		}
+	}
+	return false
+}
+
+// patchHeaderTouchesAxleDir reports whether a diff header line names a path
+// inside an Axle state directory.
+func patchHeaderTouchesAxleDir(line string) bool {
+	for _, field := range strings.Fields(line) {
+		field = strings.Trim(field, `"`)
+		if strings.HasPrefix(field, "a/") || strings.HasPrefix(field, "b/") {
+			field = field[2:]
+		}
+		if isAxlePath(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// axleTempFile creates an empty temporary file under .axle/tmp, so scratch
+// files stay on the repository's file system and out of the synced tree.
+func axleTempFile(rootDir, pattern string) (string, error) {
+	dir := AxlePath(rootDir, "tmp")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmp.Close()
+	return tmp.Name(), nil
+}
+
+// EnsureGitExclude adds Axle's local files to the repository's
+// .git/info/exclude so git never tracks them, whatever .gitignore says.
+func EnsureGitExclude(rootDir string, extra ...string) error {
+	if UsingEmbeddedBackend() {
+		return nil
+	}
+	output, err := GitCommand("-C", rootDir, "rev-parse", "--git-path", "info/exclude").Output()
+	if err != nil {
+		return fmt.Errorf("failed to locate .git/info/exclude: %w", err)
+	}
+	excludePath := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(excludePath) {
+		excludePath = filepath.Join(rootDir, excludePath)
+	}
+
+	existing, err := os.ReadFile(excludePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read .git/info/exclude: %w", err)
+	}
+	present := make(map[string]bool)
+	for _, line := range strings.Split(string(existing), "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+
+	var missing []string
+	for _, pattern := range append([]string{"/" + AxleDirName + "/"}, extra...) {
+		if !present[pattern] {
+			missing = append(missing, pattern)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(excludePath), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(excludePath), err)
+	}
+	f, err := os.OpenFile(excludePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open .git/info/exclude: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString("\n" + strings.Join(missing, "\n") + "\n"); err != nil {
+		return fmt.Errorf("failed to write to .git/info/exclude: %w", err)
+	}
+	return nil
+}
+
+// PrepareAxleDir makes sure the Axle state directory exists and is excluded
+// from git along with conflict artifacts, and moves Axle files left elsewhere by older versions into it.
+func PrepareAxleDir(rootDir string) error {
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", AxleDirName, err)
+	}
+	// Rejected hunks are conflict artifacts and must not be committed
+	if err := EnsureGitExclude(rootDir, "*.rej"); err != nil {
+		return err
+	}
+	if err := excludeOutputDirs(rootDir); err != nil {
+		return err
+	}
+
+	// Repositories set up before the exclude may have committed Axle state
+	if output, err := GitCommand("-C", rootDir, "ls-files", "--", AxleDirName).Output(); err == nil && len(output) > 0 {
+		if err := GitCommand("-C", rootDir, "rm", "-r", "--cached", "--quiet", "--", AxleDirName).Run(); err != nil {
+			log.Printf("[AXLE] Failed to stop tracking %s: %v", AxleDirName, err)
+		} else {
+			log.Printf("[AXLE] Stopped tracking %s in git; the removal is committed with your next change", AxleDirName)
+		}
+	}
+
+	migrateConflictBackups(rootDir)
+	return nil
+}
+
+// migrateConflictBackups moves the "<file>.backup" copies the backup conflict
+// strategy used to leave next to the originals into .axle/backups. Only
+// copies in the conflict artifact registry are moved; a .backup file Axle
+// has no record of may be the user's own and stays where it is.
+func migrateConflictBackups(rootDir string) {
+	artifactsMu.Lock()
+	defer artifactsMu.Unlock()
+	artifacts, err := loadConflictArtifacts(rootDir)
+	if err != nil {
+		return
+	}
+
+	moved := make(map[string]ConflictArtifact)
+	for path, artifact := range artifacts {
+		if artifact.Kind != ArtifactBackup || isAxlePath(path) {
+			continue
+		}
+		dest := conflictBackupPath(rootDir, artifact.Original)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			continue
+		}
+		if err := os.Rename(filepath.Join(rootDir, filepath.FromSlash(path)), dest); err != nil {
+			continue
+		}
+		moved[path] = artifact
+	}
+	if len(moved) == 0 {
+		return
+	}
+
+	err = updateConflictArtifacts(rootDir, func(artifacts map[string]ConflictArtifact) {
+		for path, artifact := range moved {
+			delete(artifacts, path)
+			artifact.Path = filepath.ToSlash(filepath.Join(AxleDirName, "backups", artifact.Original+".backup"))
+			artifacts[artifact.Path] = artifact
+		}
+	})
+	if err != nil {
+		log.Printf("[AXLE] Failed to record moved conflict backups: %v", err)
+	}
+	log.Printf("[AXLE] Moved %d conflict backup(s) into %s", len(moved), AxlePath(rootDir, "backups"))
+}
+
+// conflictBackupPath is where the backup conflict strategy saves a file's
+// previous version.
+func conflictBackupPath(rootDir, relPath string) string {
+	return AxlePath(rootDir, "backups", relPath+".backup")
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gitattributesBaseline normalizes line endings so members on different
+// operating systems don't produce whole-file diffs for the same content.
+var gitattributesBaseline = []string{
+	"# Normalize line endings for all text files",
+	"* text=auto eol=lf",
+	"",
+	"# Windows scripts need CRLF",
+	"*.bat text eol=crlf",
+	"*.cmd text eol=crlf",
+	"*.ps1 text eol=crlf",
+	"",
+	"# Binary files",
+	"*.png binary",
+	"*.jpg binary",
+	"*.jpeg binary",
+	"*.gif binary",
+	"*.ico binary",
+	"*.pdf binary",
+	"*.zip binary",
+	"*.woff binary",
+	"*.woff2 binary",
+}
+
+// linguistHints marks generated or vendored content per stack so it doesn't
+// skew repository language statistics.
+var linguistHints = map[StackType][]string{
+	StackNode:   {"package-lock.json linguist-generated", "yarn.lock linguist-generated", "dist/** linguist-generated"},
+	StackGo:     {"go.sum linguist-generated", "vendor/** linguist-vendored"},
+	StackPython: {"*.ipynb linguist-documentation"},
+	StackRust:   {"Cargo.lock linguist-generated"},
+	StackPHP:    {"composer.lock linguist-generated", "vendor/** linguist-vendored"},
+}
+
+const editorconfigBaseline = `# Axle auto-generated editor settings
+root = true
+
+[*]
+end_of_line = lf
+insert_final_newline = true
+charset = utf-8
+trim_trailing_whitespace = true
+
+[*.{bat,cmd,ps1}]
+end_of_line = crlf
+
+[*.md]
+trim_trailing_whitespace = false
+
+[{Makefile,*.go}]
+indent_style = tab
+`
+
+// WriteGitattributes writes a baseline .gitattributes with EOL normalization
+// and linguist hints for the detected stacks. An existing file is kept and
+// only missing lines are appended. It returns whether the file changed.
+func WriteGitattributes(rootDir string, stacks []StackType) (bool, error) {
+	lines := append([]string{"# Axle auto-generated attributes"}, gitattributesBaseline...)
+	for _, stack := range stacks {
+		if hints, ok := linguistHints[stack]; ok {
+			lines = append(lines, "", fmt.Sprintf("# %s generated files", stack))
+			lines = append(lines, hints...)
+		}
+	}
+
+	return mergeLinesIntoFile(filepath.Join(rootDir, ".gitattributes"), lines)
+}
+
+// WriteEditorconfig writes a baseline .editorconfig unless one already exists.
+// It returns whether the file was created.
+func WriteEditorconfig(rootDir string) (bool, error) {
+	path := filepath.Join(rootDir, ".editorconfig")
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	}
+	if err := os.WriteFile(path, []byte(editorconfigBaseline), 0644); err != nil {
+		return false, fmt.Errorf("failed to write .editorconfig: %w", err)
+	}
+	return true, nil
+}
+
+// mergeLinesIntoFile appends rule lines missing from an existing file, or
+// writes all lines when the file doesn't exist yet.
+func mergeLinesIntoFile(path string, lines []string) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if len(existing) == 0 {
+		content := strings.Join(lines, "\n") + "\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return false, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		return true, nil
+	}
+
+	present := make(map[string]bool)
+	for _, line := range strings.Split(string(existing), "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+
+	var missing []string
+	for _, line := range lines {
+		if line != "" && !strings.HasPrefix(line, "#") && !present[line] {
+			missing = append(missing, line)
+		}
+	}
+	if len(missing) == 0 {
+		return false, nil
+	}
+
+	content := string(existing)
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += "\n# Added by Axle\n" + strings.Join(missing, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// CommitAuthor is who a commit is attributed to.
+type CommitAuthor struct {
+	Name  string
+	Email string
+}
+
+// String formats the author the way 'git commit --author' takes it.
+func (a CommitAuthor) String() string {
+	return fmt.Sprintf("%s <%s>", a.Name, a.Email)
+}
+
+// LocalCommitAuthor returns the user git commits as in directory, so
+// teammates can attribute the changes we publish to us. Name falls back to
+// fallback when git has no user.name.
+func LocalCommitAuthor(directory, fallback string) CommitAuthor {
+	author := CommitAuthor{Name: fallback}
+	if out, err := GitCommand("-C", directory, "config", "user.name").Output(); err == nil {
+		if name := strings.TrimSpace(string(out)); name != "" {
+			author.Name = name
+		}
+	}
+	if out, err := GitCommand("-C", directory, "config", "user.email").Output(); err == nil {
+		author.Email = strings.TrimSpace(string(out))
+	}
+	return author
+}
+
+// commitCommand builds 'git commit' with args, attributed to author. The
+// committer stays the local user; a zero author commits as the local user.
+func commitCommand(directory string, author CommitAuthor, args ...string) *exec.Cmd {
+	if author.Name == "" {
+		return GitCommand(append([]string{"-C", directory, "commit"}, args...)...)
+	}
+	cmd := GitCommand(append([]string{"-C", directory, "commit", "--author", author.String()}, args...)...)
+	cmd.Env = append(cmd.Env, "GIT_AUTHOR_NAME="+author.Name, "GIT_AUTHOR_EMAIL="+author.Email)
+	return cmd
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// LastAutoCheckpoint always names the newest automatic checkpoint
+	LastAutoCheckpoint = "last-auto"
+	// autoCheckpointPrefix starts the name of every automatic checkpoint
+	autoCheckpointPrefix = "auto-"
+	// maxAutoCheckpoints is how many automatic checkpoints are kept
+	maxAutoCheckpoints = 20
+
+	// Incoming batches at or above either limit are checkpointed before applying
+	largeBatchChanges    = 20
+	largeBatchPatchBytes = 64 * 1024
+)
+
+// autoCheckpointMu keeps concurrent risky operations from racing on the
+// temporary index and the last-auto tag.
+var autoCheckpointMu sync.Mutex
+
+// IsLargeBatch reports whether an incoming batch is big enough to checkpoint
+// the working tree before applying it.
+func IsLargeBatch(syncMeta SyncMetadata) bool {
+	if len(syncMeta.Changes) >= largeBatchChanges {
+		return true
+	}
+	var patchBytes int
+	for _, change := range syncMeta.Changes {
+		patchBytes += len(change.Patch) + len(change.Data)
+	}
+	return patchBytes >= largeBatchPatchBytes
+}
+
+// CreateAutoCheckpoint records the working tree, including uncommitted and
+// untracked files, as a checkpoint before a risky operation. HEAD, the index,
+// and the files on disk are left alone. Nothing is created if the tree is the
+// same as the last automatic checkpoint. Returns the checkpoint name.
+func CreateAutoCheckpoint(directory, reason string) (string, error) {
+	if UsingEmbeddedBackend() {
+		return "", nil // Checkpoints are git objects
+	}
+
+	autoCheckpointMu.Lock()
+	defer autoCheckpointMu.Unlock()
+
+	tree, err := workingTreeObject(directory)
+	if err != nil {
+		return "", err
+	}
+
+	lastRef := "refs/tags/" + CheckpointTagPrefix + LastAutoCheckpoint
+	if output, err := GitCommand("-C", directory, "rev-parse", "--verify", "--quiet", lastRef+"^{tree}").Output(); err == nil &&
+		strings.TrimSpace(string(output)) == tree {
+		return LastAutoCheckpoint, nil
+	}
+
+	message := fmt.Sprintf("Axle auto-checkpoint: %s", reason)
+	args := []string{"-C", directory, "commit-tree", tree, "-m", message}
+	if GitCommand("-C", directory, "rev-parse", "--verify", "--quiet", "HEAD").Run() == nil {
+		args = append(args, "-p", "HEAD")
+	}
+	output, err := GitCommand(args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to record auto-checkpoint: %s", string(output))
+	}
+	commit := strings.TrimSpace(string(output))
+
+	name := autoCheckpointPrefix + time.Now().Format("20060102-150405")
+	for _, tag := range []string{name, LastAutoCheckpoint} {
+		cmd := GitCommand("-C", directory, "tag", "-f", "-a", "-m", message, CheckpointTagPrefix+tag, commit)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("failed to tag auto-checkpoint: %s", string(output))
+		}
+	}
+
+	pruneAutoCheckpoints(directory)
+	log.Printf("[CHECKPOINT] Created %s before %s; undo with 'axle checkpoint restore %s'", name, reason, LastAutoCheckpoint)
+	return name, nil
+}
+
+// workingTreeObject writes the working tree, tracked and untracked files
+// alike, as a git tree using a throwaway copy of the index.
+func workingTreeObject(directory string) (string, error) {
+	tmpIndex, err := axleTempFile(directory, "checkpoint-index-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	defer os.Remove(tmpIndex)
+
+	// Starting from the real index lets git reuse its cached file stats
+	if output, err := GitCommand("-C", directory, "rev-parse", "--git-path", "index").Output(); err == nil {
+		indexPath := strings.TrimSpace(string(output))
+		if !filepath.IsAbs(indexPath) {
+			indexPath = filepath.Join(directory, indexPath)
+		}
+		if err := copyFile(indexPath, tmpIndex); err != nil {
+			os.Remove(tmpIndex)
+		}
+	}
+
+	addCmd := GitCommand("-C", directory, "add", "-A")
+	addCmd.Env = append(addCmd.Env, "GIT_INDEX_FILE="+tmpIndex)
+	if output, err := addCmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to stage working tree for checkpoint: %s", string(output))
+	}
+
+	treeCmd := GitCommand("-C", directory, "write-tree")
+	treeCmd.Env = append(treeCmd.Env, "GIT_INDEX_FILE="+tmpIndex)
+	output, err := treeCmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to write checkpoint tree: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// pruneAutoCheckpoints deletes all but the newest automatic checkpoints.
+func pruneAutoCheckpoints(directory string) {
+	checkpoints, err := ListCheckpoints(directory)
+	if err != nil {
+		return
+	}
+	var auto []Checkpoint
+	for _, checkpoint := range checkpoints {
+		if strings.HasPrefix(checkpoint.Name, autoCheckpointPrefix) {
+			auto = append(auto, checkpoint)
+		}
+	}
+	for i := 0; i < len(auto)-maxAutoCheckpoints; i++ {
+		GitCommand("-C", directory, "tag", "-d", CheckpointTagPrefix+auto[i].Name).Run()
+	}
+}
+
+// RestoreCheckpoint makes the working tree match a checkpoint without moving
+// HEAD, so the difference shows up as ordinary local changes. The current
+// state is checkpointed first so the restore can itself be undone. Untracked
+// files that aren't in the checkpoint are left in place.
+func RestoreCheckpoint(directory, name string) error {
+	if UsingEmbeddedBackend() {
+		return ErrGitMissing
+	}
+	ref, err := ResolveCheckpointRef(directory, name)
+	if err != nil {
+		return err
+	}
+	if ref == CurrentTreeRef {
+		return fmt.Errorf("cannot restore the current working tree onto itself")
+	}
+	// Resolve before the safety checkpoint below moves last-auto
+	output, err := GitCommand("-C", directory, "rev-parse", "--verify", ref+"^{tree}").Output()
+	if err != nil {
+		return fmt.Errorf("failed to resolve checkpoint %s: %w", name, err)
+	}
+	tree := strings.TrimSpace(string(output))
+
+	if _, err := CreateAutoCheckpoint(directory, "restoring "+name); err != nil {
+		return fmt.Errorf("refusing to restore without saving the current state: %w", err)
+	}
+
+	cleanupGitState(directory)
+	if output, err := GitCommand("-C", directory, "read-tree", "-u", "--reset", tree).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to restore checkpoint %s: %s", name, string(output))
+	}
+	// Keep the index on HEAD so the restored files show up as unstaged changes
+	GitCommand("-C", directory, "reset", "-q").Run()
+	return nil
+}
@@ -0,0 +1,295 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Low-bandwidth mode settings
+const (
+	LowBandwidthOff  = "off"  // Always take full payloads
+	LowBandwidthOn   = "on"   // Always ask for lean payloads
+	LowBandwidthAuto = "auto" // Ask for lean payloads while Redis is slow to reach
+
+	// lowBandwidthLatency is the Redis round-trip time above which auto mode
+	// treats the connection as slow (a congested hotspot, not a distant region)
+	lowBandwidthLatency = 300 * time.Millisecond
+
+	// Patches smaller than this aren't worth compressing
+	minCompressSize = 1024
+	// maxDecompressedPatch bounds what a compressed patch may expand to
+	maxDecompressedPatch = 64 * 1024 * 1024
+
+	// EncodingGzip marks a FileChange whose Patch is base64-encoded gzip
+	EncodingGzip = "gzip"
+	// CompressionNone flags a batch whose sender doesn't compress patches
+	CompressionNone = "none"
+)
+
+var (
+	lowBandwidthMu      sync.RWMutex
+	lowBandwidthSetting = LowBandwidthOff
+	lowBandwidthEnabled bool
+	// lowBandwidthPeers maps node IDs that advertised low bandwidth to the
+	// time of their last heartbeat
+	lowBandwidthPeers = make(map[string]int64)
+)
+
+// ValidateLowBandwidthSetting checks a low-bandwidth setting value.
+func ValidateLowBandwidthSetting(setting string) error {
+	switch setting {
+	case LowBandwidthOff, LowBandwidthOn, LowBandwidthAuto:
+		return nil
+	default:
+		return fmt.Errorf("invalid low-bandwidth setting: %s (use: off, on, or auto)", setting)
+	}
+}
+
+// SetLowBandwidthSetting applies the low-bandwidth setting for the running
+// daemon. In auto mode the mode follows the latency measured with each
+// heartbeat.
+func SetLowBandwidthSetting(setting string) {
+	lowBandwidthMu.Lock()
+	lowBandwidthSetting = setting
+	lowBandwidthMu.Unlock()
+	setLowBandwidthMode(setting == LowBandwidthOn)
+}
+
+func setLowBandwidthMode(enabled bool) {
+	lowBandwidthMu.Lock()
+	defer lowBandwidthMu.Unlock()
+	if lowBandwidthEnabled != enabled {
+		if enabled {
+			log.Println("[BANDWIDTH] Low-bandwidth mode enabled: teammates will send compressed patches and large files on demand")
+		} else {
+			log.Println("[BANDWIDTH] Low-bandwidth mode disabled")
+		}
+	}
+	lowBandwidthEnabled = enabled
+}
+
+// IsLowBandwidthMode reports whether this node asks teammates for lean payloads.
+func IsLowBandwidthMode() bool {
+	lowBandwidthMu.RLock()
+	defer lowBandwidthMu.RUnlock()
+	return lowBandwidthEnabled
+}
+
+// observeLatency updates the mode from a Redis round-trip time in auto mode.
+func observeLatency(rtt time.Duration) {
+	lowBandwidthMu.RLock()
+	auto := lowBandwidthSetting == LowBandwidthAuto
+	lowBandwidthMu.RUnlock()
+	if auto {
+		setLowBandwidthMode(rtt > lowBandwidthLatency)
+	}
+}
+
+// notePeerBandwidth remembers which peers advertise low bandwidth, from their
+// presence messages.
+func notePeerBandwidth(msg PresenceMessage) {
+	lowBandwidthMu.Lock()
+	defer lowBandwidthMu.Unlock()
+	if msg.LowBandwidth && msg.Type != "goodbye" {
+		lowBandwidthPeers[msg.NodeID] = msg.Timestamp
+	} else {
+		delete(lowBandwidthPeers, msg.NodeID)
+	}
+}
+
+// LowBandwidthPeersOnline returns the node IDs of online peers that asked for
+// lean payloads.
+func LowBandwidthPeersOnline(cfg AppConfig) []string {
+	lowBandwidthMu.Lock()
+	defer lowBandwidthMu.Unlock()
+
+	cutoff := time.Now().Add(-EffectivePresenceTimeout(cfg)).Unix()
+	var peers []string
+	for nodeID, lastSeen := range lowBandwidthPeers {
+		if lastSeen < cutoff {
+			delete(lowBandwidthPeers, nodeID)
+			continue
+		}
+		peers = append(peers, nodeID)
+	}
+	sort.Strings(peers)
+	return peers
+}
+
+// AdaptForLowBandwidth rewrites an outgoing batch for slow peers. Batch
+// commits are split into one patch per file, so the whole commit isn't
+// repeated for every file in it, and patches are compressed. Force-synced
+// files already name their owner, so slow peers fetch them on demand.
+func AdaptForLowBandwidth(cfg AppConfig, metadata SyncMetadata) SyncMetadata {
+	adapted := metadata
+	adapted.Changes = make([]FileChange, len(metadata.Changes))
+	copy(adapted.Changes, metadata.Changes)
+
+	var before, after int
+	splittable := make(map[string]bool)
+	for i := range adapted.Changes {
+		change := &adapted.Changes[i]
+		before += len(change.Patch)
+		if change.Encoding != "" {
+			after += len(change.Patch)
+			continue
+		}
+
+		if change.CommitHash != "" {
+			split, checked := splittable[change.CommitHash]
+			if !checked {
+				split = commitCoveredByBatch(cfg.RootDir, change.CommitHash, metadata.Changes)
+				splittable[change.CommitHash] = split
+			}
+			if split {
+				if patch, err := filePatch(cfg.RootDir, change.CommitHash, change.File); err == nil && patch != "" {
+					change.Patch = patch
+				}
+			}
+		}
+
+		compressChange(change)
+		after += len(change.Patch)
+	}
+
+	if after < before {
+		log.Printf("[BANDWIDTH] Trimmed batch for low-bandwidth peers: %d -> %d bytes of patches", before, after)
+	}
+	return adapted
+}
+
+// CompressPatches compresses the patches of an outgoing batch and flags the
+// batch with how, unless the team turned compression off.
+func CompressPatches(cfg AppConfig, metadata SyncMetadata) SyncMetadata {
+	if !cfg.FeatureEnabled(FeatureSyncCompress) || !teamSupports(cfg, CapGzipPatches) {
+		metadata.Compression = CompressionNone
+		return metadata
+	}
+
+	compressed := metadata
+	compressed.Changes = make([]FileChange, len(metadata.Changes))
+	copy(compressed.Changes, metadata.Changes)
+	for i := range compressed.Changes {
+		compressChange(&compressed.Changes[i])
+	}
+	compressed.Compression = EncodingGzip
+	return compressed
+}
+
+// compressChange compresses a change's patch in place, if that's worth it.
+func compressChange(change *FileChange) {
+	if change.Encoding != "" || len(change.Patch) < minCompressSize {
+		return
+	}
+	if compressed, err := compressPatch(change.Patch); err == nil && len(compressed) < len(change.Patch) {
+		change.Patch = compressed
+		change.Encoding = EncodingGzip
+	}
+}
+
+var (
+	uncompressedWarnedMu sync.Mutex
+	uncompressedWarned   = make(map[string]bool)
+)
+
+// NoteBatchCompression warns once per peer whose batches carry no
+// compression flag: they run a release from before patches were compressed
+// for everyone.
+func NoteBatchCompression(metadata SyncMetadata) {
+	if metadata.Compression != "" {
+		return
+	}
+	uncompressedWarnedMu.Lock()
+	defer uncompressedWarnedMu.Unlock()
+	if uncompressedWarned[metadata.PeerID] {
+		return
+	}
+	uncompressedWarned[metadata.PeerID] = true
+	log.Printf("[COMPAT] ⚠️  %s runs an older Axle that doesn't compress patches; they should upgrade", metadata.PeerID)
+}
+
+// commitCoveredByBatch reports whether every file a commit touches has its
+// own change in the batch, so per-file patches lose nothing.
+func commitCoveredByBatch(directory, commitHash string, changes []FileChange) bool {
+	output, err := GitCommand("-C", directory, "diff-tree", "--no-commit-id", "--name-only", "-r", "--root", commitHash).Output()
+	if err != nil {
+		return false
+	}
+	inBatch := make(map[string]bool)
+	for _, change := range changes {
+		if change.CommitHash == commitHash {
+			inBatch[change.File] = true
+		}
+	}
+	for _, path := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if path != "" && !inBatch[path] {
+			return false
+		}
+	}
+	return true
+}
+
+// filePatch returns the plain diff a commit made to one file.
+func filePatch(directory, commitHash, file string) (string, error) {
+	output, err := GitCommand("-C", directory, "diff-tree", "-p", "--no-commit-id", "--root", commitHash, "--", file).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate patch for %s: %w", file, err)
+	}
+	return string(output), nil
+}
+
+func compressPatch(patch string) (string, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(patch)); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeChange expands a compressed patch in place so the change can be
+// applied like any other.
+func DecodeChange(change *FileChange) error {
+	if change.IsDirectPatch() {
+		return fmt.Errorf("the patch was not received from %s", change.Owner)
+	}
+	switch change.Encoding {
+	case "":
+		return nil
+	case EncodingGzip:
+	default:
+		return fmt.Errorf("unsupported patch encoding %q", change.Encoding)
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(change.Patch)
+	if err != nil {
+		return fmt.Errorf("corrupt compressed patch: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return fmt.Errorf("corrupt compressed patch: %w", err)
+	}
+	defer zr.Close()
+
+	patch, err := io.ReadAll(io.LimitReader(zr, maxDecompressedPatch+1))
+	if err != nil {
+		return fmt.Errorf("corrupt compressed patch: %w", err)
+	}
+	if len(patch) > maxDecompressedPatch {
+		return fmt.Errorf("compressed patch expands beyond %d bytes", maxDecompressedPatch)
+	}
+	change.Patch = string(patch)
+	change.Encoding = ""
+	return nil
+}
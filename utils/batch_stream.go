@@ -0,0 +1,321 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// DefaultRetentionDays is how long persisted batches are kept when the team doesn't say
+	DefaultRetentionDays = 14
+	// maxSeenBatches bounds the local record of batches already received live
+	maxSeenBatches  = 500
+	catchupPageSize = 100
+)
+
+// BatchStreamKey returns the Redis Stream holding the team's persisted batches.
+func BatchStreamKey(teamID string) string {
+	return fmt.Sprintf("axle:stream:%s", teamID)
+}
+
+// CatchupState is this node's position in the team's batch stream, kept in .axle/catchup.json.
+type CatchupState struct {
+	LastStreamID string   `json:"lastStreamID,omitempty"` // Last stream entry known to be handled
+	SeenBatches  []string `json:"seenBatches,omitempty"`  // Recent batch IDs received live, newest last
+}
+
+var catchupMu sync.Mutex
+
+func catchupStateFile(rootDir string) string {
+	return AxlePath(rootDir, "catchup.json")
+}
+
+// LoadCatchupState reads the local catch-up position; a missing file is an empty state.
+func LoadCatchupState(rootDir string) (CatchupState, error) {
+	var state CatchupState
+	data, err := os.ReadFile(catchupStateFile(rootDir))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to parse catch-up state: %w", err)
+	}
+	return state, nil
+}
+
+// SaveCatchupState writes the local catch-up position.
+func SaveCatchupState(rootDir string, state CatchupState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(catchupStateFile(rootDir), data, 0644)
+}
+
+// HasSeenBatch reports whether a batch was already received.
+func (s CatchupState) HasSeenBatch(batchID string) bool {
+	return contains(s.SeenBatches, batchID)
+}
+
+// RecordSeenBatch remembers that a batch arrived live so catch-up won't apply it again.
+func RecordSeenBatch(rootDir, batchID string) {
+	if batchID == "" {
+		return
+	}
+	catchupMu.Lock()
+	defer catchupMu.Unlock()
+
+	state, err := LoadCatchupState(rootDir)
+	if err != nil {
+		log.Printf("[CATCHUP] %v", err)
+	}
+	state.SeenBatches = append(state.SeenBatches, batchID)
+	if len(state.SeenBatches) > maxSeenBatches {
+		state.SeenBatches = state.SeenBatches[len(state.SeenBatches)-maxSeenBatches:]
+	}
+	if err := SaveCatchupState(rootDir, state); err != nil {
+		log.Printf("[CATCHUP] Failed to save catch-up state: %v", err)
+	}
+}
+
+// AdvanceStreamPosition moves the catch-up cursor past the stream entries
+// this node already handled (its own or received live), stopping at the
+// first one it missed. The daemon calls this on shutdown.
+func AdvanceStreamPosition(ctx context.Context, cfg AppConfig) {
+	catchupMu.Lock()
+	defer catchupMu.Unlock()
+
+	state, err := LoadCatchupState(cfg.RootDir)
+	if err != nil {
+		log.Printf("[CATCHUP] %v", err)
+		return
+	}
+
+	advanced, err := handledStreamPosition(ctx, cfg, state)
+	if err != nil {
+		log.Printf("[CATCHUP] %v", err)
+	}
+	if advanced == state.LastStreamID {
+		return
+	}
+	state.LastStreamID = advanced
+	if err := SaveCatchupState(cfg.RootDir, state); err != nil {
+		log.Printf("[CATCHUP] Failed to save catch-up state: %v", err)
+	}
+}
+
+// StreamPosition returns the ID of the last team stream entry this node has
+// handled, without moving the saved cursor. Empty means none.
+func StreamPosition(ctx context.Context, cfg AppConfig) (string, error) {
+	catchupMu.Lock()
+	defer catchupMu.Unlock()
+
+	state, err := LoadCatchupState(cfg.RootDir)
+	if err != nil {
+		return "", err
+	}
+	return handledStreamPosition(ctx, cfg, state)
+}
+
+// handledStreamPosition scans the stream from the saved cursor and returns
+// the last entry before the first one this node missed. On a read error it
+// returns how far it got.
+func handledStreamPosition(ctx context.Context, cfg AppConfig, state CatchupState) (string, error) {
+	start := "-"
+	if state.LastStreamID != "" {
+		start = "(" + state.LastStreamID
+	}
+	advanced := state.LastStreamID
+
+	for {
+		entries, err := cfg.RedisClient.XRangeN(ctx, BatchStreamKey(cfg.TeamID), start, "+", catchupPageSize).Result()
+		if err != nil {
+			return advanced, fmt.Errorf("failed to read batch stream: %w", err)
+		}
+		for _, entry := range entries {
+			raw, _ := entry.Values["batch"].(string)
+			var metadata SyncMetadata
+			if json.Unmarshal([]byte(raw), &metadata) == nil &&
+				metadata.PeerID != cfg.Username && !state.HasSeenBatch(metadata.BatchID) {
+				return advanced, nil
+			}
+			advanced = entry.ID
+		}
+		if len(entries) < catchupPageSize {
+			return advanced, nil
+		}
+		start = "(" + advanced
+	}
+}
+
+// StreamHead returns the ID of the newest entry in the team's batch stream,
+// "" when it is empty.
+func StreamHead(ctx context.Context, cfg AppConfig) (string, error) {
+	entries, err := cfg.RedisClient.XRevRangeN(ctx, BatchStreamKey(cfg.TeamID), "+", "-", 1).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to read batch stream: %w", err)
+	}
+	if len(entries) == 0 {
+		return "", nil
+	}
+	return entries[0].ID, nil
+}
+
+// StreamEntry summarizes an entry of the team's batch stream, without the
+// content of its changes.
+type StreamEntry struct {
+	ID        string   `json:"id"`
+	BatchID   string   `json:"batchID,omitempty"`
+	PeerID    string   `json:"peerID,omitempty"`
+	Seq       int64    `json:"seq,omitempty"`
+	Timestamp int64    `json:"timestamp,omitempty"`
+	Files     []string `json:"files,omitempty"`
+	Events    []string `json:"events,omitempty"` // Event of each file, in order
+	Signed    bool     `json:"signed"`
+	Error     string   `json:"error,omitempty"` // Why the entry couldn't be read
+}
+
+// RecentStreamEntries summarizes the newest n entries of the team's batch
+// stream, newest first.
+func RecentStreamEntries(ctx context.Context, cfg AppConfig, n int) ([]StreamEntry, error) {
+	entries, err := cfg.RedisClient.XRevRangeN(ctx, BatchStreamKey(cfg.TeamID), "+", "-", int64(n)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch stream: %w", err)
+	}
+
+	summaries := make([]StreamEntry, 0, len(entries))
+	for _, entry := range entries {
+		summary := StreamEntry{ID: entry.ID}
+		raw, _ := entry.Values["batch"].(string)
+		_, signature := splitSignature(raw)
+		summary.Signed = signature != ""
+		var metadata SyncMetadata
+		if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+			summary.Error = err.Error()
+		} else {
+			summary.BatchID, summary.PeerID, summary.Seq, summary.Timestamp = metadata.BatchID, metadata.PeerID, metadata.Seq, metadata.Timestamp
+			for _, change := range metadata.Changes {
+				summary.Files = append(summary.Files, change.File)
+				summary.Events = append(summary.Events, change.Event)
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// effectiveRetentionDays returns the team's batch retention, falling back to the default.
+func effectiveRetentionDays(cfg AppConfig) int {
+	if cfg.RetentionDays > 0 {
+		return cfg.RetentionDays
+	}
+	return DefaultRetentionDays
+}
+
+// StartBatchPersister appends every batch this node publishes to the team's
+// stream while persistence is enabled, trimming entries past the retention.
+func StartBatchPersister(ctx context.Context, cfg AppConfig) {
+	if !cfg.PersistBatches {
+		return
+	}
+	log.Printf("[CATCHUP] Persisting published batches for %d days", effectiveRetentionDays(cfg))
+
+	events, unsubscribe := Events.Subscribe(TopicBatchPublished)
+	defer unsubscribe()
+
+	for {
+		select {
+		case event := <-events:
+			metadata := event.Payload.(BatchPublishedEvent).Metadata
+			data, err := json.Marshal(metadata)
+			if err != nil {
+				continue
+			}
+			data = SignMessage(BatchStreamKey(cfg.TeamID), data)
+
+			cutoff := time.Now().AddDate(0, 0, -effectiveRetentionDays(cfg))
+			if err := cfg.RedisClient.XAdd(ctx, &redis.XAddArgs{
+				Stream: BatchStreamKey(cfg.TeamID),
+				MinID:  fmt.Sprintf("%d-0", cutoff.UnixMilli()),
+				Approx: true,
+				Values: map[string]interface{}{"batch": string(data)},
+			}).Err(); err != nil {
+				log.Printf("[CATCHUP] Failed to persist batch %s: %v", metadata.BatchID, err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ReadMissedBatches returns persisted batches after the node's last handled
+// position that it hasn't seen live, oldest first, with the newest stream ID read.
+func ReadMissedBatches(ctx context.Context, cfg AppConfig) ([]SyncMetadata, string, error) {
+	state, err := LoadCatchupState(cfg.RootDir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	start := "-"
+	if state.LastStreamID != "" {
+		start = "(" + state.LastStreamID
+	}
+
+	var missed []SyncMetadata
+	lastID := state.LastStreamID
+	for {
+		entries, err := cfg.RedisClient.XRangeN(ctx, BatchStreamKey(cfg.TeamID), start, "+", catchupPageSize).Result()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read batch stream: %w", err)
+		}
+
+		for _, entry := range entries {
+			lastID = entry.ID
+			raw, _ := entry.Values["batch"].(string)
+			var metadata SyncMetadata
+			if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+				log.Printf("[CATCHUP] Skipping unreadable stream entry %s: %v", entry.ID, err)
+				continue
+			}
+			if metadata.PeerID == cfg.Username || state.HasSeenBatch(metadata.BatchID) {
+				continue
+			}
+			if AdmitSigned(cfg, BatchStreamKey(cfg.TeamID), metadata.PeerID, raw) != nil {
+				continue
+			}
+			missed = append(missed, metadata)
+		}
+
+		if len(entries) < catchupPageSize {
+			break
+		}
+		start = "(" + lastID
+	}
+	return missed, lastID, nil
+}
+
+// CompleteCatchup records lastID as handled after replaying missed batches.
+func CompleteCatchup(rootDir, lastID string) error {
+	catchupMu.Lock()
+	defer catchupMu.Unlock()
+
+	state, err := LoadCatchupState(rootDir)
+	if err != nil {
+		return err
+	}
+	state.LastStreamID = lastID
+	return SaveCatchupState(rootDir, state)
+}
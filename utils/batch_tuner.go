@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// batchRateTimeConstant is how quickly the event rate estimate follows
+// changes in activity: an event's weight falls to about a third after this.
+const batchRateTimeConstant = 10 * time.Second
+
+// batchLevel is one batch window and the event rates (per second) at which
+// the tuner moves to the next level up or back down from it. The gap
+// between the two is the hysteresis that keeps the window from flapping
+// when activity hovers around a boundary.
+type batchLevel struct {
+	window time.Duration
+	up     float64 // Move to the next level above this rate
+	down   float64 // Move to the previous level below this rate
+}
+
+// batchLevels go from quick response for occasional edits to long windows
+// that gather bursts (a build, a bulk rename) into few commits.
+var batchLevels = []batchLevel{
+	{window: 1 * time.Second, up: 1.2},
+	{window: 2 * time.Second, up: 6, down: 0.8},
+	{window: 5 * time.Second, down: 4},
+}
+
+// BatchStatus describes the current batch window for 'axle status'.
+type BatchStatus struct {
+	WindowMs  int64   `json:"windowMs"`
+	EventRate float64 `json:"eventRate"` // Events per second, averaged
+	LowPower  bool    `json:"lowPower,omitempty"`
+	Pending   int     `json:"pending"` // Changed files waiting for the window to close
+}
+
+// batchTuner picks the batch window from an exponential moving average of
+// the file event rate.
+type batchTuner struct {
+	rate  float64   // Average events per second as of last
+	last  time.Time // When rate was last updated
+	level int       // Index into batchLevels
+}
+
+// decayedRate returns the average rate as of now, without recording an event.
+func (t *batchTuner) decayedRate(now time.Time) float64 {
+	if t.last.IsZero() {
+		return 0
+	}
+	elapsed := now.Sub(t.last)
+	if elapsed <= 0 {
+		return t.rate
+	}
+	return t.rate * math.Exp(-elapsed.Seconds()/batchRateTimeConstant.Seconds())
+}
+
+// observe records one event at now and returns the batch window to use.
+// Each event adds 1/τ to an estimate that decays with time constant τ, so
+// a steady rate of r events per second converges on r and simultaneous
+// events in a burst don't blow the estimate up.
+func (t *batchTuner) observe(now time.Time) time.Duration {
+	t.rate = t.decayedRate(now) + 1/batchRateTimeConstant.Seconds()
+	t.last = now
+
+	for t.level+1 < len(batchLevels) && t.rate > batchLevels[t.level].up {
+		t.level++
+	}
+	for t.level > 0 && t.rate < batchLevels[t.level].down {
+		t.level--
+	}
+	return batchLevels[t.level].window
+}
+
+var (
+	batchTunerMu sync.Mutex
+	tuner        batchTuner
+	// fixedBatchWindow replaces the adaptive window when batchWindowMs is set
+	fixedBatchWindow time.Duration
+)
+
+// SetBatchWindow fixes the batch window, or lets it adapt to activity again
+// for 0.
+func SetBatchWindow(window time.Duration) {
+	batchTunerMu.Lock()
+	defer batchTunerMu.Unlock()
+	fixedBatchWindow = window
+}
+
+// getDynamicBatchDuration records a file event and returns the batch window
+// for the current activity level.
+func getDynamicBatchDuration() time.Duration {
+	batchTunerMu.Lock()
+	defer batchTunerMu.Unlock()
+
+	window := tuner.observe(time.Now())
+	// Low-power mode trades latency for fewer commits
+	if IsLowPowerMode() {
+		return lowPowerBatchDuration
+	}
+	if fixedBatchWindow > 0 {
+		return fixedBatchWindow
+	}
+	return window
+}
+
+// GetBatchStatus returns the batch window and the event rate behind it.
+func GetBatchStatus() BatchStatus {
+	batchTunerMu.Lock()
+	defer batchTunerMu.Unlock()
+
+	status := BatchStatus{
+		WindowMs:  batchLevels[tuner.level].window.Milliseconds(),
+		EventRate: tuner.decayedRate(time.Now()),
+	}
+	if fixedBatchWindow > 0 {
+		status.WindowMs = fixedBatchWindow.Milliseconds()
+	}
+	if IsLowPowerMode() {
+		status.WindowMs = lowPowerBatchDuration.Milliseconds()
+		status.LowPower = true
+	}
+	status.Pending = pendingBatchSize()
+	return status
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// observeChecked records an event and checks that the level only moved
+// because the rate crossed the threshold of the level it left.
+func observeChecked(t *testing.T, tuner *batchTuner, now time.Time) time.Duration {
+	t.Helper()
+	before := tuner.level
+	window := tuner.observe(now)
+	after := tuner.level
+
+	switch {
+	case after > before:
+		for level := before; level < after; level++ {
+			if tuner.rate <= batchLevels[level].up {
+				t.Fatalf("moved up from level %d at rate %.3f, not above %.3f", level, tuner.rate, batchLevels[level].up)
+			}
+		}
+	case after < before:
+		for level := before; level > after; level-- {
+			if tuner.rate >= batchLevels[level].down {
+				t.Fatalf("moved down from level %d at rate %.3f, not below %.3f", level, tuner.rate, batchLevels[level].down)
+			}
+		}
+	default:
+		if after+1 < len(batchLevels) && tuner.rate > batchLevels[after].up {
+			t.Fatalf("stayed at level %d at rate %.3f, above %.3f", after, tuner.rate, batchLevels[after].up)
+		}
+		if after > 0 && tuner.rate < batchLevels[after].down {
+			t.Fatalf("stayed at level %d at rate %.3f, below %.3f", after, tuner.rate, batchLevels[after].down)
+		}
+	}
+	if window != batchLevels[after].window {
+		t.Fatalf("window %s doesn't match level %d", window, after)
+	}
+	return window
+}
+
+// oscillate sends events whose spacing alternates between short and long,
+// averaging one a second, so the rate hovers around 1 event per second:
+// between the first level's up threshold and the second's down threshold.
+// It returns the windows chosen.
+func oscillate(t *testing.T, tuner *batchTuner, now time.Time, events int) (time.Time, map[time.Duration]int) {
+	t.Helper()
+	windows := make(map[time.Duration]int)
+	for i := 0; i < events; i++ {
+		if i%2 == 0 {
+			now = now.Add(750 * time.Millisecond)
+		} else {
+			now = now.Add(1250 * time.Millisecond)
+		}
+		windows[observeChecked(t, tuner, now)]++
+	}
+	return now, windows
+}
+
+func TestBatchTunerBurst(t *testing.T) {
+	var tuner batchTuner
+	now := time.Unix(1700000000, 0)
+
+	if window := observeChecked(t, &tuner, now); window != time.Second {
+		t.Fatalf("first event: window %s, want 1s", window)
+	}
+	// A build touching 100 files at once
+	var window time.Duration
+	for i := 0; i < 100; i++ {
+		window = observeChecked(t, &tuner, now)
+	}
+	if window != 5*time.Second {
+		t.Errorf("after a burst: window %s, want 5s", window)
+	}
+	if tuner.rate > 11 {
+		t.Errorf("simultaneous events blew the rate up to %.2f", tuner.rate)
+	}
+}
+
+func TestBatchTunerSustainedIdle(t *testing.T) {
+	var tuner batchTuner
+	now := time.Unix(1700000000, 0)
+	for i := 0; i < 100; i++ {
+		observeChecked(t, &tuner, now)
+	}
+
+	// The window only changes on the next event, whatever happened since
+	now = now.Add(time.Minute)
+	if rate := tuner.decayedRate(now); rate > 0.05 {
+		t.Errorf("rate after a minute idle: %.3f, want it decayed", rate)
+	}
+	if window := observeChecked(t, &tuner, now); window != time.Second {
+		t.Errorf("first event after a minute idle: window %s, want 1s", window)
+	}
+
+	// Occasional edits keep it short
+	for i := 0; i < 20; i++ {
+		now = now.Add(5 * time.Second)
+		if window := observeChecked(t, &tuner, now); window != time.Second {
+			t.Fatalf("edit every 5s: window %s, want 1s", window)
+		}
+	}
+}
+
+func TestBatchTunerHysteresis(t *testing.T) {
+	var tuner batchTuner
+	now := time.Unix(1700000000, 0)
+
+	// From the bottom, traffic hovering around 1/s never crosses 1.2/s
+	now, windows := oscillate(t, &tuner, now, 200)
+	if len(windows) != 1 || windows[time.Second] == 0 {
+		t.Fatalf("oscillating from the bottom: windows %v, want only 1s", windows)
+	}
+
+	// Two events a second climb past 1.2/s to the second level
+	for i := 0; i < 40; i++ {
+		now = now.Add(500 * time.Millisecond)
+		observeChecked(t, &tuner, now)
+	}
+	if tuner.level != 1 {
+		t.Fatalf("two events a second: level %d, want 1", tuner.level)
+	}
+
+	// The same hovering traffic stays above 0.8/s, so the window holds
+	now, windows = oscillate(t, &tuner, now, 200)
+	if len(windows) != 1 || windows[2*time.Second] == 0 {
+		t.Fatalf("oscillating from the second level: windows %v, want only 2s", windows)
+	}
+
+	// Slowing to one event every 2s drops below 0.8/s and back down
+	for i := 0; i < 30; i++ {
+		now = now.Add(2 * time.Second)
+		observeChecked(t, &tuner, now)
+	}
+	if tuner.level != 0 {
+		t.Errorf("one event every 2s: level %d, want 0", tuner.level)
+	}
+}
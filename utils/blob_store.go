@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// BlobStoreShared marks a change whose content is in the shared blob
+// directory instead of Redis.
+const BlobStoreShared = "shared"
+
+var (
+	blobDirMu sync.RWMutex
+	blobDir   string
+)
+
+// SetBlobDir sets a directory every teammate can reach, such as a network
+// drive, to hold the content of synced binary files instead of Redis. Unlike
+// Redis chunks, blobs there don't expire. An empty dir uses Redis.
+func SetBlobDir(dir string) {
+	blobDirMu.Lock()
+	defer blobDirMu.Unlock()
+	blobDir = dir
+}
+
+func currentBlobDir() string {
+	blobDirMu.RLock()
+	defer blobDirMu.RUnlock()
+	return blobDir
+}
+
+func sharedBlobPath(dir, teamID, hash string) string {
+	return filepath.Join(dir, teamID, hash[:2], hash)
+}
+
+// StoreBlob stores content for teammates and returns its hash and the store
+// that holds it ("" for Redis). Without a shared blob directory, content
+// above the direct transfer threshold is served by this node instead.
+func StoreBlob(ctx context.Context, cfg AppConfig, data []byte) (string, string, error) {
+	dir := currentBlobDir()
+	if dir == "" {
+		if sendsDirect(cfg, len(data)) {
+			hash, err := offerDirect(cfg.RootDir, data)
+			return hash, BlobStoreDirect, err
+		}
+		hash, err := StoreChunks(ctx, cfg.RedisClient, cfg.TeamID, data)
+		return hash, "", err
+	}
+
+	hash := HashContent(data)
+	path := sharedBlobPath(dir, cfg.TeamID, hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, BlobStoreShared, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	// Write under a temporary name so readers never see a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(path), hash+".*.tmp")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to store blob: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", "", fmt.Errorf("failed to store blob: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", "", fmt.Errorf("failed to store blob: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", "", fmt.Errorf("failed to store blob: %w", err)
+	}
+	return hash, BlobStoreShared, nil
+}
+
+// LoadBlob loads the content a chunked change refers to and verifies its
+// hash.
+func LoadBlob(ctx context.Context, cfg AppConfig, change FileChange) ([]byte, error) {
+	if change.Store == BlobStoreDirect {
+		return loadDirectContent(ctx, cfg, change)
+	}
+	if change.Store != BlobStoreShared {
+		return LoadChunks(ctx, cfg.RedisClient, cfg.TeamID, change.Hash)
+	}
+
+	dir := currentBlobDir()
+	if dir == "" {
+		return nil, fmt.Errorf("%s is in the team's shared blob directory; set blobDir in axle_config.json to receive it", change.File)
+	}
+	if _, err := hex.DecodeString(change.Hash); err != nil || len(change.Hash) != 64 {
+		return nil, fmt.Errorf("invalid blob hash %q", change.Hash)
+	}
+	data, err := os.ReadFile(sharedBlobPath(dir, cfg.TeamID, change.Hash))
+	if err != nil {
+		return nil, fmt.Errorf("blob %s is not available: %w", change.Hash, err)
+	}
+	if HashContent(data) != change.Hash {
+		return nil, fmt.Errorf("blob %s failed integrity check", change.Hash)
+	}
+	return data, nil
+}
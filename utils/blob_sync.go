@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// queueBinaryChange syncs a binary file within the size limit through the
+// blob store: it commits the file locally and queues a change that refers
+// to its content by hash. It returns false if the file isn't binary or the
+// team doesn't sync binary files.
+func queueBinaryChange(cfg AppConfig, absPath, relPath, event string) bool {
+	if !cfg.FeatureEnabled(FeatureSyncBinary) || !isBinaryFile(absPath) {
+		return false
+	}
+	info, err := os.Stat(absPath)
+	if err != nil || info.IsDir() || info.Size() > maxFileSize {
+		return false
+	}
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		log.Printf("[BLOB] Cannot read %s: %v", relPath, err)
+		return true
+	}
+
+	// Commit locally like any other change; nothing to commit means the
+	// content is what we already have, such as a file a teammate just sent.
+	// The embedded backend keeps no history, so there it is sent as is.
+	var commitHash string
+	if !UsingEmbeddedBackend() {
+		commitHash, err = CommitFiles(cfg.RootDir, fmt.Sprintf("%s %s", strings.Title(event), relPath), relPath)
+		if err != nil {
+			log.Printf("[BLOB] Failed to commit %s: %v", relPath, err)
+			return true
+		}
+		if commitHash == "" {
+			return true
+		}
+	}
+
+	hash, store, err := StoreBlob(context.Background(), cfg, data)
+	if err != nil {
+		log.Printf("[BLOB] Failed to upload %s: %v", relPath, err)
+		recordSkippedFile(cfg.RootDir, relPath, "binary upload failed; run 'axle force-sync' to retry")
+		return true
+	}
+
+	change := FileChange{
+		File:       relPath,
+		Event:      "chunked",
+		CommitHash: commitHash,
+		Size:       int64(len(data)),
+		Hash:       hash,
+		Store:      store,
+		TraceID:    GenerateTraceID(),
+		// Lets low-bandwidth peers fetch it from us later instead
+		Owner:     cfg.Username,
+		OwnerNode: cfg.NodeID,
+	}
+	mu.Lock()
+	queueChanges(cfg.RootDir, change)
+	mu.Unlock()
+	forgetSkippedFile(cfg.RootDir, relPath)
+	Events.Publish(TopicFileChanged, FileChangedEvent{Path: relPath, Event: change.Event, TraceID: change.TraceID})
+	Events.Publish(TopicBatchCommitted, BatchCommittedEvent{CommitHash: commitHash, Files: []string{relPath}, TraceIDs: []string{change.TraceID}})
+
+	log.Printf("[BLOB] Syncing binary file %s (%d bytes) through the blob store", relPath, len(data))
+	return true
+}
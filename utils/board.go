@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Pin is a message pinned to the team's noticeboard.
+type Pin struct {
+	ID        int64  `json:"id"`
+	Text      string `json:"text"`
+	Author    string `json:"author"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// TodoItem is an entry on the team's shared TODO list.
+type TodoItem struct {
+	ID        int64  `json:"id"`
+	Text      string `json:"text"`
+	Author    string `json:"author"`
+	Timestamp int64  `json:"timestamp"`
+	Done      bool   `json:"done,omitempty"`
+	DoneBy    string `json:"doneBy,omitempty"`
+	DoneAt    int64  `json:"doneAt,omitempty"`
+}
+
+func pinsKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:pins", teamID)
+}
+
+func todosKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:todos", teamID)
+}
+
+// boardSeqKey holds the counter behind the short numeric IDs of pins and
+// TODOs, so they are easy to type ('axle todo done 3').
+func boardSeqKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:board_seq", teamID)
+}
+
+// nextBoardID allocates the next pin or TODO ID.
+func nextBoardID(ctx context.Context, cfg AppConfig) (int64, error) {
+	id, err := cfg.RedisClient.Incr(ctx, boardSeqKey(cfg.TeamID)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate ID: %w", err)
+	}
+	return id, nil
+}
+
+// AddPin pins a message to the team's noticeboard.
+func AddPin(ctx context.Context, cfg AppConfig, text string) (Pin, error) {
+	id, err := nextBoardID(ctx, cfg)
+	if err != nil {
+		return Pin{}, err
+	}
+	pin := Pin{ID: id, Text: text, Author: cfg.Username, Timestamp: time.Now().Unix()}
+	data, err := json.Marshal(pin)
+	if err != nil {
+		return Pin{}, err
+	}
+	if err := cfg.RedisClient.HSet(ctx, pinsKey(cfg.TeamID), strconv.FormatInt(id, 10), data).Err(); err != nil {
+		return Pin{}, fmt.Errorf("failed to pin message: %w", err)
+	}
+	return pin, nil
+}
+
+// RemovePin takes a message off the noticeboard.
+func RemovePin(ctx context.Context, cfg AppConfig, id int64) error {
+	removed, err := cfg.RedisClient.HDel(ctx, pinsKey(cfg.TeamID), strconv.FormatInt(id, 10)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to unpin #%d: %w", id, err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("no pinned message #%d", id)
+	}
+	return nil
+}
+
+// ListPins returns the pinned messages, oldest first.
+func ListPins(ctx context.Context, cfg AppConfig) ([]Pin, error) {
+	entries, err := cfg.RedisClient.HGetAll(ctx, pinsKey(cfg.TeamID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pins: %w", err)
+	}
+
+	pins := make([]Pin, 0, len(entries))
+	for _, entry := range entries {
+		var pin Pin
+		if err := json.Unmarshal([]byte(entry), &pin); err == nil {
+			pins = append(pins, pin)
+		}
+	}
+	sort.Slice(pins, func(i, j int) bool { return pins[i].ID < pins[j].ID })
+	return pins, nil
+}
+
+// AddTodo adds an item to the team's TODO list.
+func AddTodo(ctx context.Context, cfg AppConfig, text string) (TodoItem, error) {
+	id, err := nextBoardID(ctx, cfg)
+	if err != nil {
+		return TodoItem{}, err
+	}
+	item := TodoItem{ID: id, Text: text, Author: cfg.Username, Timestamp: time.Now().Unix()}
+	if err := saveTodo(ctx, cfg, item); err != nil {
+		return TodoItem{}, err
+	}
+	return item, nil
+}
+
+// CompleteTodo marks a TODO as done by this member.
+func CompleteTodo(ctx context.Context, cfg AppConfig, id int64) (TodoItem, error) {
+	data, err := cfg.RedisClient.HGet(ctx, todosKey(cfg.TeamID), strconv.FormatInt(id, 10)).Result()
+	if err == redis.Nil {
+		return TodoItem{}, fmt.Errorf("no TODO #%d", id)
+	} else if err != nil {
+		return TodoItem{}, fmt.Errorf("failed to read TODO #%d: %w", id, err)
+	}
+
+	var item TodoItem
+	if err := json.Unmarshal([]byte(data), &item); err != nil {
+		return TodoItem{}, fmt.Errorf("corrupt TODO #%d: %w", id, err)
+	}
+	if item.Done {
+		return item, fmt.Errorf("TODO #%d was already done by %s", id, item.DoneBy)
+	}
+
+	item.Done = true
+	item.DoneBy = cfg.Username
+	item.DoneAt = time.Now().Unix()
+	if err := saveTodo(ctx, cfg, item); err != nil {
+		return TodoItem{}, err
+	}
+	return item, nil
+}
+
+// saveTodo writes a TODO item under its ID.
+func saveTodo(ctx context.Context, cfg AppConfig, item TodoItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if err := cfg.RedisClient.HSet(ctx, todosKey(cfg.TeamID), strconv.FormatInt(item.ID, 10), data).Err(); err != nil {
+		return fmt.Errorf("failed to save TODO #%d: %w", item.ID, err)
+	}
+	return nil
+}
+
+// ListTodos returns the team's TODO items in the order they were added.
+// Completed items are included only when includeDone is set.
+func ListTodos(ctx context.Context, cfg AppConfig, includeDone bool) ([]TodoItem, error) {
+	entries, err := cfg.RedisClient.HGetAll(ctx, todosKey(cfg.TeamID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list TODOs: %w", err)
+	}
+
+	items := make([]TodoItem, 0, len(entries))
+	for _, entry := range entries {
+		var item TodoItem
+		if err := json.Unmarshal([]byte(entry), &item); err == nil && (includeDone || !item.Done) {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
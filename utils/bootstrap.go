@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// BootstrapTTL is how long the stored bootstrap snapshot outlives the
+	// last daemon that refreshed it
+	BootstrapTTL = 7 * 24 * time.Hour
+	// bootstrapRefreshInterval is how often a daemon checks whether the
+	// stored snapshot is behind its HEAD
+	bootstrapRefreshInterval = 10 * time.Minute
+	// bootstrapFirstRefresh lets catch-up settle before the first refresh
+	bootstrapFirstRefresh = time.Minute
+	// maxBootstrapSize keeps very large repositories out of Redis; joiners
+	// then need a teammate online
+	maxBootstrapSize = 64 * 1024 * 1024
+)
+
+// BootstrapSnapshot is the repository state a new member starts from.
+type BootstrapSnapshot struct {
+	Bundle   []byte
+	Source   string // Who served it, or who stored it
+	Stored   bool   // Read from Redis rather than served live
+	StoredAt time.Time
+	StreamID string // Last batch stream entry the snapshot includes
+}
+
+func bootstrapKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:bootstrap", teamID)
+}
+
+// StoreBootstrapSnapshot saves this node's HEAD in Redis for members who join
+// while nobody is online. It does nothing when the stored snapshot already
+// has this HEAD.
+func StoreBootstrapSnapshot(ctx context.Context, cfg AppConfig) error {
+	head, err := RevParse(cfg.RootDir, "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	storedHead, err := cfg.RedisClient.HGet(ctx, bootstrapKey(cfg.TeamID), "head").Result()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to read stored snapshot: %w", err)
+	}
+	if storedHead == head {
+		return nil
+	}
+
+	// Record how far into the batch stream this state goes before packing
+	// it, so a joiner replays anything that lands in between
+	streamID, err := StreamPosition(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	bundle, err := CreateBundle(cfg.RootDir)
+	if err != nil {
+		return err
+	}
+	if len(bundle) > maxBootstrapSize {
+		return fmt.Errorf("repository is too large to store a snapshot in Redis (%d bytes)", len(bundle))
+	}
+
+	pipe := cfg.RedisClient.TxPipeline()
+	pipe.HSet(ctx, bootstrapKey(cfg.TeamID), map[string]interface{}{
+		"bundle": bundle,
+		"head":   head,
+		"by":     cfg.Username,
+		"at":     time.Now().Unix(),
+		"stream": streamID,
+	})
+	pipe.Expire(ctx, bootstrapKey(cfg.TeamID), BootstrapTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	log.Printf("[SNAPSHOT] Stored a %d byte snapshot for new members", len(bundle))
+	return nil
+}
+
+// StartBootstrapRefresher keeps the stored bootstrap snapshot close to the
+// team's HEAD. The authoritative node or a hub keeps it when there is one;
+// otherwise whoever finds it older than the refresh interval updates it.
+func StartBootstrapRefresher(ctx context.Context, cfg AppConfig) {
+	keeper := cfg.Hub || cfg.AuthoritativeNode == cfg.Username
+	if !keeper && cfg.AuthoritativeNode != "" {
+		return
+	}
+
+	timer := time.NewTimer(bootstrapFirstRefresh)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		timer.Reset(bootstrapRefreshInterval)
+
+		if !keeper {
+			at, err := cfg.RedisClient.HGet(ctx, bootstrapKey(cfg.TeamID), "at").Int64()
+			if err == nil && time.Since(time.Unix(at, 0)) < bootstrapRefreshInterval {
+				continue
+			}
+		}
+		if err := StoreBootstrapSnapshot(ctx, cfg); err != nil {
+			log.Printf("[SNAPSHOT] Failed to store snapshot for new members: %v", err)
+		}
+	}
+}
+
+// LoadBootstrapSnapshot reads the snapshot stored in Redis.
+func LoadBootstrapSnapshot(ctx context.Context, cfg AppConfig) (BootstrapSnapshot, error) {
+	snapshot := BootstrapSnapshot{Stored: true}
+	fields, err := cfg.RedisClient.HGetAll(ctx, bootstrapKey(cfg.TeamID)).Result()
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to read stored snapshot: %w", err)
+	}
+	if fields["bundle"] == "" {
+		return snapshot, fmt.Errorf("the team has no stored snapshot yet")
+	}
+	at, _ := strconv.ParseInt(fields["at"], 10, 64)
+	snapshot.Bundle = []byte(fields["bundle"])
+	snapshot.Source = fields["by"]
+	snapshot.StoredAt = time.Unix(at, 0)
+	snapshot.StreamID = fields["stream"]
+	return snapshot, nil
+}
+
+// FetchBootstrapSnapshot gets the team's current state for a new member:
+// live from an online peer when one answers within timeout, otherwise the
+// snapshot stored in Redis.
+func FetchBootstrapSnapshot(ctx context.Context, cfg AppConfig, timeout time.Duration) (BootstrapSnapshot, error) {
+	// Anything published after this point is replayed by catch-up
+	var streamID string
+	if entries, err := cfg.RedisClient.XRevRangeN(ctx, BatchStreamKey(cfg.TeamID), "+", "-", 1).Result(); err == nil && len(entries) > 0 {
+		streamID = entries[0].ID
+	}
+
+	bundle, owner, liveErr := RequestSnapshot(ctx, cfg, "", timeout)
+	if liveErr == nil {
+		return BootstrapSnapshot{Bundle: bundle, Source: owner, StreamID: streamID}, nil
+	}
+	snapshot, err := LoadBootstrapSnapshot(ctx, cfg)
+	if err != nil {
+		return snapshot, fmt.Errorf("%v, and %v", liveErr, err)
+	}
+	return snapshot, nil
+}
+
+// CanBootstrap reports whether the repository in directory holds no work of
+// its own: nothing beyond a single commit and no uncommitted files, so
+// replacing it with the team's state loses nothing.
+func CanBootstrap(directory string) (bool, error) {
+	count, err := GitCommand("-C", directory, "rev-list", "--count", "HEAD").Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to count commits: %w", err)
+	}
+	if n, _ := strconv.Atoi(strings.TrimSpace(string(count))); n > 1 {
+		return false, nil
+	}
+	status, err := GitCommand("-C", directory, "status", "--porcelain").Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to check git status: %w", err)
+	}
+	if len(strings.TrimSpace(string(status))) > 0 {
+		return false, nil
+	}
+	files, err := TreeManifest(directory, "HEAD")
+	if err != nil {
+		return false, fmt.Errorf("failed to list files: %w", err)
+	}
+	return len(files) == 0, nil
+}
+
+// ApplyBootstrapSnapshot replaces the new member's tree with the snapshot
+// and starts its catch-up position where the snapshot ends.
+func ApplyBootstrapSnapshot(directory string, snapshot BootstrapSnapshot) error {
+	if err := ApplyBundle(directory, snapshot.Bundle); err != nil {
+		return err
+	}
+	return CompleteCatchup(directory, snapshot.StreamID)
+}
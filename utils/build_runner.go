@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// buildSettleDelay is how long incoming batches must stop arriving
+	// before the build command runs
+	buildSettleDelay = 10 * time.Second
+	// buildTimeout bounds one run of the build command
+	buildTimeout = 15 * time.Minute
+	// maxBuildOutput keeps the end of the build output shared with the team small
+	maxBuildOutput = 2000
+)
+
+// BuildResult is the outcome of running a member's build command after
+// teammates' changes were applied.
+type BuildResult struct {
+	Username   string   `json:"username"`
+	NodeID     string   `json:"nodeID"`
+	Command    string   `json:"command"`
+	Passed     bool     `json:"passed"`
+	Reason     string   `json:"reason,omitempty"` // Why it failed
+	Output     string   `json:"output,omitempty"` // End of what the command printed
+	Peers      []string `json:"peers,omitempty"`  // Whose changes triggered the run
+	DurationMs int64    `json:"durationMs"`
+	Timestamp  int64    `json:"timestamp"`
+}
+
+func buildsKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:builds", teamID)
+}
+
+// StartBuildRunner runs the build command once incoming batches settle and
+// shares the result with the team. Batches arriving during a run trigger
+// another once it finishes.
+func StartBuildRunner(ctx context.Context, cfg AppConfig) {
+	if cfg.BuildCommand == "" {
+		return
+	}
+	log.Printf("[BUILD] Running %q after incoming changes settle", cfg.BuildCommand)
+
+	events, unsubscribe := Events.Subscribe(TopicBatchApplied)
+	defer unsubscribe()
+
+	var settle <-chan time.Time
+	var peers []string // Whose changes arrived since the last run started
+	running := false
+	done := make(chan BuildResult, 1)
+	for {
+		select {
+		case event := <-events:
+			applied, ok := event.Payload.(BatchAppliedEvent)
+			if !ok || len(applied.Files) == 0 {
+				continue
+			}
+			if !contains(peers, applied.PeerID) {
+				peers = append(peers, applied.PeerID)
+			}
+			settle = time.After(buildSettleDelay)
+		case <-settle:
+			settle = nil
+			if running {
+				continue // Runs again once the current run finishes
+			}
+			running = true
+			go func(peers []string) { done <- RunBuild(ctx, cfg, peers) }(peers)
+			peers = nil
+		case result := <-done:
+			running = false
+			if ctx.Err() != nil {
+				return
+			}
+			noteBuildResult(cfg, result)
+			if err := publishBuildResult(ctx, cfg, result); err != nil {
+				log.Printf("[BUILD] Failed to share the build result: %v", err)
+			}
+			if len(peers) > 0 && settle == nil {
+				settle = time.After(buildSettleDelay)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// RunBuild runs the build command in the sync root. peers are whose changes
+// the run checks.
+func RunBuild(ctx context.Context, cfg AppConfig, peers []string) BuildResult {
+	result := BuildResult{Username: cfg.Username, NodeID: cfg.NodeID, Command: cfg.BuildCommand, Peers: peers}
+	start := time.Now()
+
+	fields := strings.Fields(cfg.BuildCommand)
+	buildCtx, cancel := context.WithTimeout(ctx, buildTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(buildCtx, fields[0], fields[1:]...)
+	cmd.Dir = cfg.RootDir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+
+	result.DurationMs = time.Since(start).Milliseconds()
+	result.Timestamp = time.Now().Unix()
+	// Failures are explained at the end of the output
+	result.Output = strings.TrimSpace(out.String())
+	if len(result.Output) > maxBuildOutput {
+		result.Output = "..." + result.Output[len(result.Output)-maxBuildOutput:]
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		result.Passed = true
+	case buildCtx.Err() == context.DeadlineExceeded:
+		result.Reason = fmt.Sprintf("timed out after %v", buildTimeout)
+	case errors.As(err, &exitErr):
+		result.Reason = fmt.Sprintf("exit status %d", exitErr.ExitCode())
+	default:
+		result.Reason = fmt.Sprintf("failed to run: %v", err)
+	}
+	return result
+}
+
+// publishBuildResult keeps the result for 'axle stats' and announces it to
+// the team.
+func publishBuildResult(ctx context.Context, cfg AppConfig, result BuildResult) error {
+	if cfg.HasRedis() {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		pipe := cfg.RedisClient.TxPipeline()
+		pipe.HSet(ctx, buildsKey(cfg.TeamID), cfg.Username, data)
+		pipe.Expire(ctx, buildsKey(cfg.TeamID), healthTTL)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return PublishMessage(ctx, cfg.Transport, BuildChannel(cfg.TeamID), result)
+}
+
+// TeamBuilds returns the latest build result of every member that runs a
+// build command, by username.
+func TeamBuilds(ctx context.Context, cfg AppConfig) ([]BuildResult, error) {
+	fields, err := cfg.RedisClient.HGetAll(ctx, buildsKey(cfg.TeamID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read team builds: %w", err)
+	}
+	var results []BuildResult
+	for _, raw := range fields {
+		var result BuildResult
+		if json.Unmarshal([]byte(raw), &result) == nil {
+			results = append(results, result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Username < results[j].Username })
+	return results, nil
+}
+
+// ProcessBuildResult handles a teammate's build result.
+func ProcessBuildResult(cfg AppConfig, payload string) {
+	var result BuildResult
+	if err := json.Unmarshal([]byte(payload), &result); err != nil {
+		log.Printf("[BUILD] Error unmarshaling build result: %v", err)
+		return
+	}
+	if AdmitSigned(cfg, BuildChannel(cfg.TeamID), result.Username, payload) != nil {
+		return
+	}
+	if result.NodeID == cfg.NodeID {
+		return
+	}
+	noteBuildResult(cfg, result)
+}
+
+var (
+	buildStatesMu sync.Mutex
+	buildStates   = make(map[string]bool) // Node ID -> whether its last build passed
+)
+
+// noteBuildResult logs a build result and sends a notification when a
+// member's build breaks or is fixed.
+func noteBuildResult(cfg AppConfig, result BuildResult) {
+	buildStatesMu.Lock()
+	passedBefore, known := buildStates[result.NodeID]
+	buildStates[result.NodeID] = result.Passed
+	buildStatesMu.Unlock()
+
+	who := result.Username + "'s"
+	if result.NodeID == cfg.NodeID {
+		who = "Your"
+	}
+	after := ""
+	if len(result.Peers) > 0 {
+		after = " after changes from " + strings.Join(result.Peers, ", ")
+	}
+	duration := time.Duration(result.DurationMs) * time.Millisecond
+	if result.Passed {
+		log.Printf("[BUILD] ✅ %s build passed%s (%v)", who, after, duration.Round(time.Second))
+		if known && !passedBefore {
+			SendNotification("Axle - Build fixed", fmt.Sprintf("%s build passes again%s", who, after))
+		}
+		return
+	}
+	log.Printf("[BUILD] ❌ %s build failed%s: %s", who, after, result.Reason)
+	if !known || passedBefore {
+		SendNotification("Axle - Build broken", fmt.Sprintf("%s build failed%s: %s", who, after, result.Reason))
+	}
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dirGroupThreshold is how many changed files in one directory the
+// summarized changelog folds into a single line
+const dirGroupThreshold = 3
+
+// lockfiles are regenerated by package managers; the summarized changelog
+// mentions them once instead of as changes of their own
+var lockfiles = map[string]bool{
+	"package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true, "bun.lockb": true,
+	"go.sum": true, "Cargo.lock": true, "poetry.lock": true, "Pipfile.lock": true, "uv.lock": true,
+	"Gemfile.lock": true, "composer.lock": true, "pubspec.lock": true, "mix.lock": true,
+}
+
+// autoSubjectPrefixes start the commit messages Axle writes itself; they say
+// nothing the file list doesn't
+var autoSubjectPrefixes = []string{
+	"Created ", "Modified ", "Deleted ", "Renamed ", "Batch update: ",
+	"[SYNC] ", "[RESYNC] ", "Initial commit",
+}
+
+// ChangelogOptions configures GenerateChangelog.
+type ChangelogOptions struct {
+	Since time.Time // Only include activity after this, zero for everything
+	// Fold directories with several changes into one line and lockfiles
+	// into a note, instead of listing every file
+	Summarize bool
+}
+
+// changelogDay is one member's activity on one day.
+type changelogDay struct {
+	subjects  []string
+	files     []string
+	conflicts int
+}
+
+// GenerateChangelog renders the sync timeline of rootDir as Markdown, by day
+// (newest first) and member. Commit messages people wrote are listed as they
+// are; Axle's own sync commits only contribute their files.
+func GenerateChangelog(rootDir string, opts ChangelogOptions) (string, error) {
+	entries, err := SyncTimeline(rootDir, TimelineFilter{Since: opts.Since})
+	if err != nil {
+		return "", err
+	}
+
+	days := make(map[string]map[string]*changelogDay)
+	// The timeline is newest first; the changelog reads each day in order
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		date := entry.Time.Local().Format("2006-01-02")
+		member := entry.Peer
+		if member == "" {
+			member = "unknown"
+		}
+		if days[date] == nil {
+			days[date] = make(map[string]*changelogDay)
+		}
+		activity := days[date][member]
+		if activity == nil {
+			activity = &changelogDay{}
+			days[date][member] = activity
+		}
+
+		if entry.Kind == TimelineConflict {
+			activity.conflicts++
+			continue
+		}
+		if !isAutoSubject(entry.Subject) && !contains(activity.subjects, entry.Subject) {
+			activity.subjects = append(activity.subjects, entry.Subject)
+		}
+		for _, file := range entry.Files {
+			if !contains(activity.files, file) {
+				activity.files = append(activity.files, file)
+			}
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString("# Changelog\n")
+	if len(days) == 0 {
+		out.WriteString("\nNo changes yet.\n")
+		return out.String(), nil
+	}
+
+	dates := make([]string, 0, len(days))
+	for date := range days {
+		dates = append(dates, date)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+
+	for _, date := range dates {
+		fmt.Fprintf(&out, "\n## %s\n", date)
+		members := make([]string, 0, len(days[date]))
+		for member := range days[date] {
+			members = append(members, member)
+		}
+		sort.Strings(members)
+
+		for _, member := range members {
+			activity := days[date][member]
+			fmt.Fprintf(&out, "\n### %s\n\n", member)
+			for _, subject := range activity.subjects {
+				fmt.Fprintf(&out, "- %s\n", subject)
+			}
+			for _, line := range changelogFileLines(activity.files, opts.Summarize) {
+				fmt.Fprintf(&out, "- %s\n", line)
+			}
+			if activity.conflicts == 1 {
+				out.WriteString("- 1 change hit a sync conflict\n")
+			} else if activity.conflicts > 1 {
+				fmt.Fprintf(&out, "- %d changes hit sync conflicts\n", activity.conflicts)
+			}
+		}
+	}
+	return out.String(), nil
+}
+
+// changelogFileLines lists changed files, one per line, or summarized.
+func changelogFileLines(files []string, summarize bool) []string {
+	sorted := append([]string(nil), files...)
+	sort.Strings(sorted)
+	if !summarize {
+		lines := make([]string, 0, len(sorted))
+		for _, file := range sorted {
+			lines = append(lines, fmt.Sprintf("Changed `%s`", file))
+		}
+		return lines
+	}
+
+	byDir := make(map[string][]string)
+	var dirs, locks []string
+	for _, file := range sorted {
+		if lockfiles[path.Base(file)] {
+			locks = append(locks, file)
+			continue
+		}
+		dir := path.Dir(file)
+		if _, ok := byDir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], file)
+	}
+
+	var lines []string
+	for _, dir := range dirs {
+		files := byDir[dir]
+		if dir != "." && len(files) >= dirGroupThreshold {
+			lines = append(lines, fmt.Sprintf("Changed %d files in `%s/`", len(files), dir))
+			continue
+		}
+		for _, file := range files {
+			lines = append(lines, fmt.Sprintf("Changed `%s`", file))
+		}
+	}
+	if len(locks) > 0 {
+		lines = append(lines, "Updated dependency lockfiles")
+	}
+	return lines
+}
+
+func isAutoSubject(subject string) bool {
+	for _, prefix := range autoSubjectPrefixes {
+		if strings.HasPrefix(subject, prefix) {
+			return true
+		}
+	}
+	return false
+}
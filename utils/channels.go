@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// The channels a team's messages go over. Every name is built here, so
+// publishers and subscribers can't drift apart.
+
+// TeamChannel returns the channel sync batches are published on.
+func TeamChannel(teamID string) string {
+	return fmt.Sprintf("axle:team:%s", teamID)
+}
+
+// ChatChannel returns the channel chat messages are published on.
+func ChatChannel(teamID string) string {
+	return fmt.Sprintf("axle:chat:%s", teamID)
+}
+
+// PresenceChannel returns the channel heartbeats, announces and goodbyes are published on.
+func PresenceChannel(teamID string) string {
+	return fmt.Sprintf("axle:presence:%s", teamID)
+}
+
+// SnapshotChannel returns the channel used for snapshot requests.
+func SnapshotChannel(teamID string) string {
+	return fmt.Sprintf("axle:snapshot:%s", teamID)
+}
+
+// FetchChannel returns the channel used for on-demand file fetch requests.
+func FetchChannel(teamID string) string {
+	return fmt.Sprintf("axle:fetch:%s", teamID)
+}
+
+// ErrorsChannel returns the channel used for apply-failure reports.
+func ErrorsChannel(teamID string) string {
+	return fmt.Sprintf("axle:errors:%s", teamID)
+}
+
+// AnnounceChannel returns the channel announcements and acknowledgments are published on.
+func AnnounceChannel(teamID string) string {
+	return fmt.Sprintf("axle:announce:%s", teamID)
+}
+
+// ScratchpadChannel returns the channel scratchpad edits are announced on.
+func ScratchpadChannel(teamID string) string {
+	return fmt.Sprintf("axle:scratchpad:%s", teamID)
+}
+
+// AuditChannel returns the channel used for divergence audits and elections.
+func AuditChannel(teamID string) string {
+	return fmt.Sprintf("axle:audit:%s", teamID)
+}
+
+// ResyncChannel returns the channel used for resync requests.
+func ResyncChannel(teamID string) string {
+	return fmt.Sprintf("axle:resync:%s", teamID)
+}
+
+// HealthChannel returns the channel daemons broadcast stats summaries on.
+func HealthChannel(teamID string) string {
+	return fmt.Sprintf("axle:stats:%s", teamID)
+}
+
+// BuildChannel returns the channel build results are published on.
+func BuildChannel(teamID string) string {
+	return fmt.Sprintf("axle:build:%s", teamID)
+}
+
+// PasswordChannel returns the channel team password changes are announced on.
+func PasswordChannel(teamID string) string {
+	return fmt.Sprintf("axle:passwd:%s", teamID)
+}
+
+// DaemonChannels returns the channels 'axle start' subscribes to. Stats
+// summaries are left out; only 'axle stats --live' listens to them.
+func DaemonChannels(teamID string) []string {
+	return []string{
+		TeamChannel(teamID),       // Sync messages
+		ChatChannel(teamID),       // Chat messages
+		PresenceChannel(teamID),   // Presence messages
+		SnapshotChannel(teamID),   // Snapshot requests
+		FetchChannel(teamID),      // Large-file fetch requests
+		ErrorsChannel(teamID),     // Apply-failure reports
+		AnnounceChannel(teamID),   // Announcements and acknowledgments
+		ScratchpadChannel(teamID), // Scratchpad edits
+		AuditChannel(teamID),      // Divergence audits and elections
+		ResyncChannel(teamID),     // Manifest and file requests for 'axle resync'
+		BuildChannel(teamID),      // Build results after incoming changes
+		PasswordChannel(teamID),   // Team password changes
+	}
+}
+
+// Compatibility with older releases, which published chat and presence on
+// the team's sync channel. Messages found there are still routed to where
+// they belong for this release, with a warning; the next release drops it.
+
+var (
+	legacyWarnedMu sync.Mutex
+	legacyWarned   = make(map[string]bool)
+)
+
+// legacyMessage holds the fields that tell a legacy chat or presence
+// message from a sync batch.
+type legacyMessage struct {
+	Changes  json.RawMessage `json:"changes"`
+	Sender   string          `json:"sender"`
+	Message  *string         `json:"message"`
+	Type     string          `json:"type"`
+	NodeID   string          `json:"nodeID"`
+	Username string          `json:"username"`
+}
+
+// RouteLegacyMessage recognizes a message an older release published on a
+// legacy channel and returns the channel it belongs on now. Regular traffic
+// returns false.
+func RouteLegacyMessage(teamID, channel, payload string) (string, bool) {
+	// Sync batches always carry their changes; only look closer at the rest
+	if channel != TeamChannel(teamID) || strings.Contains(payload, `"changes":`) {
+		return "", false
+	}
+	var msg legacyMessage
+	if json.Unmarshal([]byte(payload), &msg) != nil || msg.Changes != nil {
+		return "", false
+	}
+
+	var canonical, kind, sender string
+	switch {
+	case msg.Sender != "" && msg.Message != nil:
+		canonical, kind, sender = ChatChannel(teamID), "chat", msg.Sender
+	case msg.Type != "" && msg.NodeID != "" && msg.Username != "":
+		canonical, kind, sender = PresenceChannel(teamID), "presence", msg.Username
+	default:
+		return "", false
+	}
+	warnLegacyChannel(sender, kind, channel, canonical)
+	return canonical, true
+}
+
+// warnLegacyChannel logs once per sender and kind of message that they use
+// a legacy channel.
+func warnLegacyChannel(sender, kind, legacy, canonical string) {
+	legacyWarnedMu.Lock()
+	defer legacyWarnedMu.Unlock()
+	key := sender + "\x00" + kind
+	if legacyWarned[key] {
+		return
+	}
+	legacyWarned[key] = true
+	log.Printf("[COMPAT] ⚠️  %s sends %s on the legacy channel %s instead of %s; they should upgrade Axle, as the next release stops reading it",
+		sender, kind, legacy, canonical)
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxChatLogEntries bounds the local chat log; older messages drop off
+	maxChatLogEntries = 500
+	// clockSkewTolerance is how far a sender's clock may be off before its
+	// time is shown next to the arrival time
+	clockSkewTolerance = time.Minute
+)
+
+var chatLogMu sync.Mutex
+
+func sequenceKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:seq", teamID)
+}
+
+// NextSequence returns the next number in the team-wide message sequence.
+// Redis hands them out, so they order chat and batches the same way for
+// everyone regardless of clocks. It returns 0 if Redis can't be reached;
+// such messages are ordered by arrival time, as are all of them for teams
+// on NATS.
+func NextSequence(ctx context.Context, cfg AppConfig) int64 {
+	if cfg.RedisClient == nil || !cfg.HasRedis() {
+		return 0
+	}
+	seq, err := cfg.RedisClient.Incr(ctx, sequenceKey(cfg.TeamID)).Result()
+	if err != nil {
+		log.Printf("[SEQ] Failed to get a sequence number: %v", err)
+		return 0
+	}
+	return seq
+}
+
+// ClockSkew returns how far a sender's timestamp is from when the message
+// arrived, or 0 when it is within tolerance.
+func ClockSkew(sentAt, receivedAt int64) time.Duration {
+	if sentAt == 0 || receivedAt == 0 {
+		return 0
+	}
+	skew := time.Duration(sentAt-receivedAt) * time.Second
+	if skew < clockSkewTolerance && skew > -clockSkewTolerance {
+		return 0
+	}
+	return skew
+}
+
+func chatLogFile(rootDir string) string {
+	return AxlePath(rootDir, "chat.log")
+}
+
+// RecordChat adds a received chat message to the local chat log.
+func RecordChat(rootDir string, msg ChatMessage) {
+	chatLogMu.Lock()
+	defer chatLogMu.Unlock()
+
+	messages, err := loadChatLog(rootDir)
+	if err != nil {
+		log.Printf("[CHAT] %v", err)
+	}
+	messages = append(messages, msg)
+	if len(messages) > maxChatLogEntries {
+		messages = messages[len(messages)-maxChatLogEntries:]
+	}
+
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		log.Printf("[CHAT] Failed to create %s: %v", AxleDirName, err)
+		return
+	}
+	var buf strings.Builder
+	for _, message := range messages {
+		data, err := json.Marshal(message)
+		if err != nil {
+			continue
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	if err := os.WriteFile(chatLogFile(rootDir), []byte(buf.String()), 0644); err != nil {
+		log.Printf("[CHAT] Failed to record message: %v", err)
+	}
+}
+
+// ChatHistory returns the last limit messages of the local chat log (all of
+// them for 0), oldest first in team sequence order.
+func ChatHistory(rootDir string, limit int) ([]ChatMessage, error) {
+	chatLogMu.Lock()
+	messages, err := loadChatLog(rootDir)
+	chatLogMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// A message without a sequence number (Redis was unreachable, or an
+	// older sender) stays right after the message that arrived before it
+	order := make([]int64, len(messages))
+	var last int64
+	for i, msg := range messages {
+		if msg.Seq != 0 {
+			last = msg.Seq
+		}
+		order[i] = last
+	}
+	indexes := make([]int, len(messages))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool { return order[indexes[a]] < order[indexes[b]] })
+	sorted := make([]ChatMessage, len(messages))
+	for i, index := range indexes {
+		sorted[i] = messages[index]
+	}
+	messages = sorted
+	if limit > 0 && len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return messages, nil
+}
+
+// loadChatLog reads the chat log in arrival order. Unreadable lines are
+// skipped.
+func loadChatLog(rootDir string) ([]ChatMessage, error) {
+	file, err := os.Open(chatLogFile(rootDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read chat log: %w", err)
+	}
+	defer file.Close()
+
+	var messages []ChatMessage
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var msg ChatMessage
+		if json.Unmarshal(scanner.Bytes(), &msg) == nil {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, scanner.Err()
+}
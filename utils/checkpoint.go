@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CheckpointTagPrefix namespaces checkpoint tags so they don't collide with user tags.
+const CheckpointTagPrefix = "axle/snapshot/"
+
+// CurrentTreeRef names the working tree when comparing checkpoints.
+const CurrentTreeRef = "current"
+
+// Checkpoint is a named, stored snapshot of the repository.
+type Checkpoint struct {
+	Name    string
+	Commit  string
+	Created time.Time
+}
+
+// ManifestEntry describes one file in a tree manifest.
+type ManifestEntry struct {
+	Hash string
+	Size int64
+}
+
+// ManifestDiff describes how one file differs between two manifests.
+type ManifestDiff struct {
+	Path    string
+	Status  string // "added", "removed", or "changed"
+	OldSize int64
+	NewSize int64
+}
+
+// CreateCheckpoint tags the current HEAD as a named checkpoint.
+func CreateCheckpoint(directory, name string) error {
+	if UsingEmbeddedBackend() {
+		return ErrGitMissing
+	}
+	message := fmt.Sprintf("Axle checkpoint %s", name)
+	cmd := GitCommand("-C", directory, "tag", "-f", "-a", "-m", message, CheckpointTagPrefix+name, "HEAD")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create checkpoint %s: %s", name, string(output))
+	}
+	return nil
+}
+
+// ListCheckpoints returns all checkpoints, oldest first.
+func ListCheckpoints(directory string) ([]Checkpoint, error) {
+	cmd := GitCommand("-C", directory, "for-each-ref", "--sort=creatordate",
+		"--format=%(refname)|%(*objectname)|%(creatordate:unix)|%(objectname)", "refs/tags/"+CheckpointTagPrefix)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	var checkpoints []Checkpoint
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		parts := strings.SplitN(line, "|", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		// Lightweight tags, such as ones made with 'git tag', point at the
+		// commit itself
+		commit := parts[1]
+		if commit == "" {
+			commit = parts[3]
+		}
+		created, _ := strconv.ParseInt(parts[2], 10, 64)
+		checkpoints = append(checkpoints, Checkpoint{
+			Name:    strings.TrimPrefix(parts[0], "refs/tags/"+CheckpointTagPrefix),
+			Commit:  commit,
+			Created: time.Unix(created, 0),
+		})
+	}
+	return checkpoints, nil
+}
+
+// ResolveCheckpointRef maps a checkpoint name to a git ref. Names that are
+// not checkpoints are passed through so commits and branches work too.
+func ResolveCheckpointRef(directory, name string) (string, error) {
+	if name == CurrentTreeRef {
+		return CurrentTreeRef, nil
+	}
+
+	for _, ref := range []string{"refs/tags/" + CheckpointTagPrefix + name, name} {
+		if _, err := RevParse(directory, ref); err == nil {
+			return ref, nil
+		}
+	}
+	return "", fmt.Errorf("unknown checkpoint or commit: %s", name)
+}
+
+// TreeManifest lists every file in a ref (or the working tree for
+// CurrentTreeRef) with its blob hash and size.
+func TreeManifest(directory, ref string) (map[string]ManifestEntry, error) {
+	if ref == CurrentTreeRef {
+		if UsingEmbeddedBackend() {
+			return embeddedManifest(directory)
+		}
+		return workingTreeManifest(directory)
+	}
+	if usingGitLibrary() {
+		return libraryTreeManifest(directory, ref)
+	}
+
+	output, err := GitCommand("-C", directory, "ls-tree", "-r", "-l", ref).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tree for %s: %w", ref, err)
+	}
+
+	manifest := make(map[string]ManifestEntry)
+	for _, line := range strings.Split(string(output), "\n") {
+		// Format: <mode> <type> <hash> <size>\t<path>
+		tab := strings.Index(line, "\t")
+		if tab == -1 {
+			continue
+		}
+		fields := strings.Fields(line[:tab])
+		if len(fields) != 4 || fields[1] != "blob" {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[3], 10, 64)
+		manifest[line[tab+1:]] = ManifestEntry{Hash: fields[2], Size: size}
+	}
+	return manifest, nil
+}
+
+// workingTreeManifest hashes tracked and untracked (non-ignored) files on disk.
+func workingTreeManifest(directory string) (map[string]ManifestEntry, error) {
+	output, err := GitCommand("-C", directory, "ls-files", "-co", "--exclude-standard").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list working tree files: %w", err)
+	}
+
+	var paths []string
+	for _, path := range strings.Split(string(output), "\n") {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(directory, path)); err == nil && info.Mode().IsRegular() {
+			paths = append(paths, path)
+		}
+	}
+
+	manifest := make(map[string]ManifestEntry)
+	if len(paths) == 0 {
+		return manifest, nil
+	}
+
+	hashCmd := GitCommand("-C", directory, "hash-object", "--stdin-paths")
+	hashCmd.Stdin = strings.NewReader(strings.Join(paths, "\n") + "\n")
+	var hashOut bytes.Buffer
+	hashCmd.Stdout = &hashOut
+	if err := hashCmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to hash working tree files: %w", err)
+	}
+
+	hashes := strings.Split(strings.TrimSpace(hashOut.String()), "\n")
+	for i, path := range paths {
+		if i >= len(hashes) {
+			break
+		}
+		info, err := os.Stat(filepath.Join(directory, path))
+		if err != nil {
+			continue
+		}
+		manifest[path] = ManifestEntry{Hash: hashes[i], Size: info.Size()}
+	}
+	return manifest, nil
+}
+
+// DiffManifests compares two manifests and returns the differences sorted by path.
+func DiffManifests(from, to map[string]ManifestEntry) []ManifestDiff {
+	var diffs []ManifestDiff
+	for path, old := range from {
+		if current, ok := to[path]; !ok {
+			diffs = append(diffs, ManifestDiff{Path: path, Status: "removed", OldSize: old.Size})
+		} else if current.Hash != old.Hash {
+			diffs = append(diffs, ManifestDiff{Path: path, Status: "changed", OldSize: old.Size, NewSize: current.Size})
+		}
+	}
+	for path, current := range to {
+		if _, ok := from[path]; !ok {
+			diffs = append(diffs, ManifestDiff{Path: path, Status: "added", NewSize: current.Size})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// ChunkSize is the size of each stored chunk; small enough to keep
+	// individual Redis values reasonable on shared instances
+	ChunkSize = 512 * 1024
+	// BlobTTL bounds how long transferred chunks stay in Redis
+	BlobTTL = time.Hour
+)
+
+// HashContent returns the hex-encoded SHA-256 of the content.
+func HashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func blobMetaKey(teamID, hash string) string {
+	return fmt.Sprintf("axle:blob:%s:%s:meta", teamID, hash)
+}
+
+func blobChunkKey(teamID, hash string, index int) string {
+	return fmt.Sprintf("axle:blob:%s:%s:%d", teamID, hash, index)
+}
+
+// StoreChunks splits content into chunks and stores them in Redis under its
+// content hash. Content that is already stored is not uploaded again.
+func StoreChunks(ctx context.Context, rdb redis.UniversalClient, teamID string, data []byte) (string, error) {
+	hash := HashContent(data)
+	if exists, err := rdb.Exists(ctx, blobMetaKey(teamID, hash)).Result(); err == nil && exists == 1 {
+		rdb.Expire(ctx, blobMetaKey(teamID, hash), BlobTTL)
+		return hash, nil
+	}
+
+	count := 0
+	for offset := 0; offset < len(data) || count == 0; offset += ChunkSize {
+		end := offset + ChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if err := rdb.Set(ctx, blobChunkKey(teamID, hash, count), data[offset:end], BlobTTL).Err(); err != nil {
+			return "", fmt.Errorf("failed to store chunk %d: %w", count, err)
+		}
+		count++
+	}
+
+	// Write the chunk count last so readers never see a partial blob
+	if err := rdb.Set(ctx, blobMetaKey(teamID, hash), count, BlobTTL).Err(); err != nil {
+		return "", fmt.Errorf("failed to store blob metadata: %w", err)
+	}
+	return hash, nil
+}
+
+// LoadChunks reassembles a blob from Redis and verifies its content hash.
+func LoadChunks(ctx context.Context, rdb redis.UniversalClient, teamID, hash string) ([]byte, error) {
+	countStr, err := rdb.Get(ctx, blobMetaKey(teamID, hash)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("blob %s is not available: %w", hash, err)
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chunk count for blob %s: %w", hash, err)
+	}
+
+	var data []byte
+	for i := 0; i < count; i++ {
+		chunk, err := rdb.Get(ctx, blobChunkKey(teamID, hash, i)).Bytes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load chunk %d of blob %s: %w", i, hash, err)
+		}
+		data = append(data, chunk...)
+	}
+
+	if HashContent(data) != hash {
+		return nil, fmt.Errorf("blob %s failed integrity check", hash)
+	}
+	return data, nil
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// configReloadDelay gathers the events of one save (an editor's write and
+// rename, or an atomic replace) into a single reload
+const configReloadDelay = 500 * time.Millisecond
+
+// WatchConfigFile calls reload after the file at path is written or
+// replaced, until ctx is done.
+func WatchConfigFile(ctx context.Context, path string, reload func()) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("[CONFIG] Failed to create config watcher: %v", err)
+		return
+	}
+	defer watcher.Close()
+
+	// Atomic saves replace the file, which ends a watch on the file itself,
+	// so watch its directory
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		log.Printf("[CONFIG] Failed to watch %s: %v", path, err)
+		return
+	}
+
+	var timer *time.Timer
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(path) || !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(configReloadDelay, reload)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[CONFIG] Config watcher error: %v", err)
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+	}
+}
@@ -19,14 +19,19 @@ const (
 	ConflictStrategyMerge      ConflictStrategy = "merge"      // Create merge conflict markers
 	ConflictStrategyBackup     ConflictStrategy = "backup"     // Create .backup files
 	ConflictStrategyInteractive ConflictStrategy = "interactive" // Open in IDE for resolution
+	ConflictStrategyAuto       ConflictStrategy = "auto"       // Deterministic tie-break by peer priority
 )
 
-// ApplyPatchWithStrategy applies a patch with a specified conflict resolution strategy
-func ApplyPatchWithStrategy(directory, patch string, strategy ConflictStrategy) (bool, error) {
+// ApplyPatchWithStrategy applies a patch from peer with a specified conflict resolution strategy
+func ApplyPatchWithStrategy(directory, patch, peer string, strategy ConflictStrategy) (bool, error) {
 	// Validate the patch for security issues
 	if err := validatePatch(patch); err != nil {
 		return false, fmt.Errorf("patch validation failed: %w", err)
 	}
+	// Without git there is no merge machinery; only clean patches apply
+	if UsingEmbeddedBackend() {
+		return false, applyPatchEmbedded(directory, patch)
+	}
 
 	// Clean up any previous git am/rebase state
 	cleanupGitState(directory)
@@ -39,11 +44,11 @@ func ApplyPatchWithStrategy(directory, patch string, strategy ConflictStrategy)
 	case ConflictStrategyMine:
 		return applyPatchMine(directory, patch, isFormatPatch)
 	case ConflictStrategyMerge:
-		return applyPatchMerge(directory, patch, isFormatPatch)
+		return applyPatchMerge(directory, patch, peer, isFormatPatch)
 	case ConflictStrategyBackup:
 		return applyPatchBackup(directory, patch, isFormatPatch)
 	case ConflictStrategyInteractive:
-		return applyPatchInteractive(directory, patch, isFormatPatch)
+		return applyPatchInteractive(directory, patch, peer, isFormatPatch)
 	default:
 		// Fallback to default behavior
 		return ApplyPatch(directory, patch)
@@ -52,12 +57,16 @@ func ApplyPatchWithStrategy(directory, patch string, strategy ConflictStrategy)
 
 // applyPatchTheirs accepts all incoming changes, discarding local changes
 func applyPatchTheirs(directory, patch string, isFormatPatch bool) (bool, error) {
+	if _, err := CreateAutoCheckpoint(directory, "accepting incoming changes over local ones"); err != nil {
+		log.Printf("[CHECKPOINT] %v", err)
+	}
+
 	// Create a stash to save current work
-	stashCmd := exec.Command("git", "-C", directory, "stash", "push", "-m", "Axle: Saving local changes before accepting incoming patch")
+	stashCmd := GitCommand("-C", directory, "stash", "push", "-m", "Axle: Saving local changes before accepting incoming patch")
 	stashCmd.Run()
 
 	// Reset to clean state
-	resetCmd := exec.Command("git", "-C", directory, "reset", "--hard", "HEAD")
+	resetCmd := GitCommand("-C", directory, "reset", "--hard", "HEAD")
 	if err := resetCmd.Run(); err != nil {
 		return false, fmt.Errorf("failed to reset repository: %w", err)
 	}
@@ -77,10 +86,10 @@ func applyPatchMine(directory, patch string, isFormatPatch bool) (bool, error) {
 }
 
 // applyPatchMerge attempts to merge and creates conflict markers
-func applyPatchMerge(directory, patch string, isFormatPatch bool) (bool, error) {
+func applyPatchMerge(directory, patch, peer string, isFormatPatch bool) (bool, error) {
 	if isFormatPatch {
 		// Use git am with 3way merge to create conflict markers
-		cmd := exec.Command("git", "-C", directory, "am", "--3way", "--no-commit")
+		cmd := GitCommand("-C", directory, "am", "--3way", "--no-commit")
 		cmd.Stdin = strings.NewReader(patch)
 		var out bytes.Buffer
 		cmd.Stdout = &out
@@ -89,7 +98,7 @@ func applyPatchMerge(directory, patch string, isFormatPatch bool) (bool, error)
 		err := cmd.Run()
 		if err != nil {
 			// Check if we have conflicts
-			statusCmd := exec.Command("git", "-C", directory, "status", "--porcelain")
+			statusCmd := GitCommand("-C", directory, "status", "--porcelain")
 			statusOut, _ := statusCmd.Output()
 
 			if strings.Contains(string(statusOut), "UU") || strings.Contains(out.String(), "Applying") {
@@ -97,11 +106,13 @@ func applyPatchMerge(directory, patch string, isFormatPatch bool) (bool, error)
 				log.Printf("[CONFLICT] Merge conflicts detected - conflict markers added to files")
 
 				// Add conflicted files to index
-				addCmd := exec.Command("git", "-C", directory, "add", "-A")
+				addCmd := GitCommand("-C", directory, "add", "-A")
 				addCmd.Run()
 
 				// List conflicted files for the user
 				conflictedFiles := findConflictedFiles(directory)
+				recordConflict(directory, peer, "left conflict markers", conflictedFiles)
+				recordConflictedFiles(directory, peer, conflictedFiles)
 				if len(conflictedFiles) > 0 {
 					log.Printf("[CONFLICT] Files with conflicts: %v", conflictedFiles)
 					log.Printf("[CONFLICT] Open these files in your IDE to resolve conflicts")
@@ -117,7 +128,7 @@ func applyPatchMerge(directory, patch string, isFormatPatch bool) (bool, error)
 		return true, nil
 	} else {
 		// For diff patches, try 3way merge
-		cmd := exec.Command("git", "-C", directory, "apply", "--3way", "--no-index")
+		cmd := GitCommand("-C", directory, "apply", "--3way", "--no-index")
 		cmd.Stdin = strings.NewReader(patch)
 		var out bytes.Buffer
 		cmd.Stdout = &out
@@ -125,7 +136,7 @@ func applyPatchMerge(directory, patch string, isFormatPatch bool) (bool, error)
 
 		if err := cmd.Run(); err != nil {
 			// Fall back to creating .rej files for conflicts
-			cmd2 := exec.Command("git", "-C", directory, "apply", "--reject", "-")
+			cmd2 := GitCommand("-C", directory, "apply", "--reject", "-")
 			cmd2.Stdin = strings.NewReader(patch)
 			var out2 bytes.Buffer
 			cmd2.Stdout = &out2
@@ -136,6 +147,8 @@ func applyPatchMerge(directory, patch string, isFormatPatch bool) (bool, error)
 				rejFiles := findRejectedFiles(directory)
 				if len(rejFiles) > 0 {
 					log.Printf("[CONFLICT] Partial application - rejected hunks saved in: %v", rejFiles)
+					recordConflict(directory, peer, "saved rejected hunks", rejFiles)
+					recordConflictArtifacts(directory, ArtifactReject, rejFiles)
 					openInIDE(directory, rejFiles)
 				}
 				return false, nil
@@ -157,7 +170,11 @@ func applyPatchBackup(directory, patch string, isFormatPatch bool) (bool, error)
 	for _, file := range affectedFiles {
 		fullPath := filepath.Join(directory, file)
 		if _, err := os.Stat(fullPath); err == nil {
-			backupPath := fullPath + ".backup"
+			// Backups live in .axle so they aren't synced as new files
+			backupPath := conflictBackupPath(directory, file)
+			if err := os.MkdirAll(filepath.Dir(backupPath), 0755); err != nil {
+				continue
+			}
 			if err := copyFile(fullPath, backupPath); err == nil {
 				backupFiles = append(backupFiles, backupPath)
 			}
@@ -166,20 +183,27 @@ func applyPatchBackup(directory, patch string, isFormatPatch bool) (bool, error)
 
 	if len(backupFiles) > 0 {
 		log.Printf("[CONFLICT] Created backup files: %v", backupFiles)
+		relPaths := make([]string, 0, len(backupFiles))
+		for _, backupPath := range backupFiles {
+			if relPath, err := filepath.Rel(directory, backupPath); err == nil {
+				relPaths = append(relPaths, relPath)
+			}
+		}
+		recordConflictArtifacts(directory, ArtifactBackup, relPaths)
 	}
 
 	// Apply the patch normally
 	autoCommitted, err := ApplyPatch(directory, patch)
 	if err != nil && len(backupFiles) > 0 {
-		log.Printf("[CONFLICT] Patch failed - your original files are saved as .backup")
+		log.Printf("[CONFLICT] Patch failed - your original files are saved in %s", AxlePath(directory, "backups"))
 	}
 	return autoCommitted, err
 }
 
 // applyPatchInteractive opens conflicts in the IDE for manual resolution
-func applyPatchInteractive(directory, patch string, isFormatPatch bool) (bool, error) {
+func applyPatchInteractive(directory, patch, peer string, isFormatPatch bool) (bool, error) {
 	// First try to apply with merge strategy to create conflict markers
-	autoCommitted, _ := applyPatchMerge(directory, patch, isFormatPatch)
+	autoCommitted, _ := applyPatchMerge(directory, patch, peer, isFormatPatch)
 
 	// Find all conflicted files
 	conflictedFiles := findConflictedFiles(directory)
@@ -211,14 +235,14 @@ func applyPatchInteractive(directory, patch string, isFormatPatch bool) (bool, e
 
 // cleanupGitState cleans up any git am/rebase in progress
 func cleanupGitState(directory string) {
-	exec.Command("git", "-C", directory, "am", "--abort").Run()
-	exec.Command("git", "-C", directory, "rebase", "--abort").Run()
-	exec.Command("git", "-C", directory, "merge", "--abort").Run()
+	GitCommand("-C", directory, "am", "--abort").Run()
+	GitCommand("-C", directory, "rebase", "--abort").Run()
+	GitCommand("-C", directory, "merge", "--abort").Run()
 }
 
 // findConflictedFiles finds files with merge conflicts
 func findConflictedFiles(directory string) []string {
-	cmd := exec.Command("git", "-C", directory, "diff", "--name-only", "--diff-filter=U")
+	cmd := GitCommand("-C", directory, "diff", "--name-only", "--diff-filter=U")
 	output, err := cmd.Output()
 	if err != nil {
 		return []string{}
@@ -237,6 +261,9 @@ func findConflictedFiles(directory string) []string {
 func findRejectedFiles(directory string) []string {
 	rejFiles := []string{}
 	filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.IsDir() && (info.Name() == ".git" || isAxlePath(info.Name())) {
+			return filepath.SkipDir
+		}
 		if err == nil && strings.HasSuffix(path, ".rej") {
 			relPath, _ := filepath.Rel(directory, path)
 			rejFiles = append(rejFiles, relPath)
@@ -335,4 +362,113 @@ func contains(slice []string, item string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+// PeerWins reports whether the remote peer's version should win a conflict
+// against the local peer. Every node evaluates the same inputs, so the whole
+// team reaches the same decision without coordinating. Peers listed in
+// priority rank above peers that are not; unlisted peers (or ties) fall back
+// to lexicographic order, where the smaller peer ID wins.
+func PeerWins(remotePeer, localPeer string, priority []string) bool {
+	rank := func(peer string) int {
+		for i, p := range priority {
+			if p == peer {
+				return i
+			}
+		}
+		return len(priority)
+	}
+
+	remoteRank, localRank := rank(remotePeer), rank(localPeer)
+	if remoteRank != localRank {
+		return remoteRank < localRank
+	}
+	return remotePeer < localPeer
+}
+
+// ApplyPatchWithTieBreak applies a patch using the deterministic 'auto' strategy.
+// The patch is applied normally when it does not conflict. On conflict the
+// winner is chosen with PeerWins: if the remote peer wins, its side of every
+// conflicted hunk is taken; otherwise the patch is discarded and local changes
+// are kept. The peer that wins will in turn discard our patch, so both sides
+// converge on the same content.
+func ApplyPatchWithTieBreak(directory, patch, localPeer, remotePeer string, priority []string) (bool, error) {
+	// Validate the patch for security issues
+	if err := validatePatch(patch); err != nil {
+		return false, fmt.Errorf("patch validation failed: %w", err)
+	}
+	if UsingEmbeddedBackend() {
+		return false, applyPatchEmbedded(directory, patch)
+	}
+
+	cleanupGitState(directory)
+
+	isFormatPatch := strings.Contains(patch, "From ") && strings.Contains(patch, "Subject:")
+	if !isFormatPatch {
+		// Plain diffs carry no commit to replay; use the regular apply path
+		// and fall back to the tie-break only if it fails.
+		autoCommitted, err := ApplyPatch(directory, patch)
+		if err == nil {
+			return autoCommitted, nil
+		}
+		files := extractFilesFromPatch(patch)
+		if !PeerWins(remotePeer, localPeer, priority) {
+			recordConflict(directory, remotePeer, "kept local version (tie-break)", files)
+			log.Printf("[CONFLICT] Tie-break: keeping local changes over %s", remotePeer)
+			return false, nil
+		}
+		recordConflict(directory, remotePeer, "took incoming version (tie-break)", files)
+		return applyPatchTheirs(directory, patch, false)
+	}
+
+	cmd := GitCommand("-C", directory, "am", "--whitespace=nowarn", "--ignore-whitespace", "--3way")
+	cmd.Stdin = strings.NewReader(patch)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if err := cmd.Run(); err == nil {
+		return true, nil
+	}
+
+	conflictedFiles := findConflictedFiles(directory)
+	if len(conflictedFiles) == 0 {
+		// Not a content conflict (e.g. independent histories); let the
+		// regular apply logic deal with it.
+		cleanupGitState(directory)
+		return ApplyPatch(directory, patch)
+	}
+
+	if !PeerWins(remotePeer, localPeer, priority) {
+		recordConflict(directory, remotePeer, "kept local version (tie-break)", conflictedFiles)
+		cleanupGitState(directory)
+		log.Printf("[CONFLICT] Tie-break: keeping local version of %v over %s", conflictedFiles, remotePeer)
+		return false, nil
+	}
+
+	recordConflict(directory, remotePeer, "took incoming version (tie-break)", conflictedFiles)
+	if _, err := CreateAutoCheckpoint(directory, "resolving conflicts in "+strings.Join(conflictedFiles, ", ")); err != nil {
+		log.Printf("[CHECKPOINT] %v", err)
+	}
+
+	// During git am, "theirs" is the incoming patch
+	checkoutArgs := append([]string{"-C", directory, "checkout", "--theirs", "--"}, conflictedFiles...)
+	if output, err := GitCommand(checkoutArgs...).CombinedOutput(); err != nil {
+		cleanupGitState(directory)
+		return false, fmt.Errorf("tie-break failed to take incoming version: %s", string(output))
+	}
+
+	addArgs := append([]string{"-C", directory, "add", "--"}, conflictedFiles...)
+	if output, err := GitCommand(addArgs...).CombinedOutput(); err != nil {
+		cleanupGitState(directory)
+		return false, fmt.Errorf("tie-break failed to stage resolved files: %s", string(output))
+	}
+
+	continueCmd := GitCommand("-C", directory, "am", "--continue")
+	if output, err := continueCmd.CombinedOutput(); err != nil {
+		cleanupGitState(directory)
+		return false, fmt.Errorf("tie-break failed to finish applying patch: %s", string(output))
+	}
+
+	log.Printf("[CONFLICT] Tie-break: %s wins, took incoming version of %v", remotePeer, conflictedFiles)
+	return true, nil
+}
@@ -0,0 +1,319 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of conflict artifacts
+const (
+	ArtifactReject = "rej"    // Hunks 'git apply --reject' couldn't apply, next to the file
+	ArtifactBackup = "backup" // A file's previous version saved by the backup strategy
+)
+
+// backupRetention is how long a backup is kept once its file is conflict-free,
+// so there is time to compare against it.
+const backupRetention = 24 * time.Hour
+
+// artifactSweepInterval is how often the daemon looks for resolved conflicts.
+const artifactSweepInterval = time.Minute
+
+// ConflictArtifact is a file a conflict strategy left behind.
+type ConflictArtifact struct {
+	Path      string `json:"path"`     // Relative to the sync root
+	Original  string `json:"original"` // The file the conflict was in
+	Kind      string `json:"kind"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+var artifactsMu sync.Mutex
+
+func artifactsFile(rootDir string) string {
+	return AxlePath(rootDir, "artifacts.json")
+}
+
+// loadConflictArtifacts reads the artifact registry, keyed by path.
+func loadConflictArtifacts(rootDir string) (map[string]ConflictArtifact, error) {
+	artifacts := make(map[string]ConflictArtifact)
+	data, err := os.ReadFile(artifactsFile(rootDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return artifacts, nil
+		}
+		return nil, fmt.Errorf("failed to read conflict artifacts: %w", err)
+	}
+	if err := json.Unmarshal(data, &artifacts); err != nil {
+		return nil, fmt.Errorf("failed to parse conflict artifacts: %w", err)
+	}
+	return artifacts, nil
+}
+
+// updateConflictArtifacts applies an edit to the artifact registry and saves
+// it. The caller must hold artifactsMu.
+func updateConflictArtifacts(rootDir string, edit func(map[string]ConflictArtifact)) error {
+	artifacts, err := loadConflictArtifacts(rootDir)
+	if err != nil {
+		return err
+	}
+	edit(artifacts)
+
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", AxleDirName, err)
+	}
+	data, err := json.MarshalIndent(artifacts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal conflict artifacts: %w", err)
+	}
+	return os.WriteFile(artifactsFile(rootDir), data, 0644)
+}
+
+// recordConflictArtifacts registers artifacts a conflict strategy created.
+// Paths are relative to the sync root; ones already registered keep their
+// original creation time.
+func recordConflictArtifacts(rootDir, kind string, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	now := time.Now().Unix()
+
+	artifactsMu.Lock()
+	defer artifactsMu.Unlock()
+	err := updateConflictArtifacts(rootDir, func(artifacts map[string]ConflictArtifact) {
+		for _, path := range paths {
+			path = filepath.ToSlash(path)
+			if _, ok := artifacts[path]; ok {
+				continue
+			}
+			artifacts[path] = ConflictArtifact{
+				Path:      path,
+				Original:  artifactOriginal(kind, path),
+				Kind:      kind,
+				CreatedAt: now,
+			}
+		}
+	})
+	if err != nil {
+		log.Printf("[CONFLICT] Failed to record conflict artifacts: %v", err)
+	}
+}
+
+// artifactOriginal returns the file an artifact belongs to.
+func artifactOriginal(kind, path string) string {
+	if kind == ArtifactBackup {
+		path = strings.TrimPrefix(path, AxleDirName+"/backups/")
+		return strings.TrimSuffix(path, ".backup")
+	}
+	return strings.TrimSuffix(path, ".rej")
+}
+
+// adoptStrayRejects registers untracked .rej files that aren't in the
+// registry yet, such as ones left by older versions, dated by their mtime.
+func adoptStrayRejects(rootDir string) {
+	output, err := GitCommand("-C", rootDir, "ls-files", "--others", "-z", "--", "*.rej").Output()
+	if err != nil {
+		return
+	}
+
+	artifactsMu.Lock()
+	defer artifactsMu.Unlock()
+	artifacts, err := loadConflictArtifacts(rootDir)
+	if err != nil {
+		return
+	}
+	var stray []ConflictArtifact
+	for _, path := range strings.Split(string(output), "\x00") {
+		if path == "" || isAxlePath(path) {
+			continue
+		}
+		if _, ok := artifacts[path]; ok {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(rootDir, path))
+		if err != nil {
+			continue
+		}
+		stray = append(stray, ConflictArtifact{
+			Path:      path,
+			Original:  artifactOriginal(ArtifactReject, path),
+			Kind:      ArtifactReject,
+			CreatedAt: info.ModTime().Unix(),
+		})
+	}
+	if len(stray) == 0 {
+		return
+	}
+	err = updateConflictArtifacts(rootDir, func(artifacts map[string]ConflictArtifact) {
+		for _, artifact := range stray {
+			artifacts[artifact.Path] = artifact
+		}
+	})
+	if err != nil {
+		log.Printf("[CONFLICT] Failed to record conflict artifacts: %v", err)
+	}
+}
+
+// ListConflictArtifacts returns the conflict artifacts still on disk, sorted
+// by path. Entries whose file is gone are dropped from the registry.
+func ListConflictArtifacts(rootDir string) ([]ConflictArtifact, error) {
+	adoptStrayRejects(rootDir)
+
+	artifactsMu.Lock()
+	defer artifactsMu.Unlock()
+	var list []ConflictArtifact
+	err := updateConflictArtifacts(rootDir, func(artifacts map[string]ConflictArtifact) {
+		for path, artifact := range artifacts {
+			if _, err := os.Stat(filepath.Join(rootDir, path)); os.IsNotExist(err) {
+				delete(artifacts, path)
+				continue
+			}
+			list = append(list, artifact)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list, nil
+}
+
+// ArtifactResolved reports whether an artifact's conflict is over. A reject
+// is resolved once its file has been saved since without conflict markers
+// (or deleted); a backup once its file has no conflict and the backup is
+// older than a day.
+func ArtifactResolved(rootDir string, artifact ConflictArtifact, unmerged []string) bool {
+	if contains(unmerged, artifact.Original) {
+		return false
+	}
+	original := filepath.Join(rootDir, artifact.Original)
+	info, err := os.Stat(original)
+	if os.IsNotExist(err) {
+		return true
+	}
+	if err != nil || hasConflictMarkers(original) {
+		return false
+	}
+
+	createdAt := time.Unix(artifact.CreatedAt, 0)
+	if artifact.Kind == ArtifactBackup {
+		return time.Since(createdAt) > backupRetention
+	}
+	return info.ModTime().After(createdAt)
+}
+
+// UnmergedFiles lists the files git still considers conflicted.
+func UnmergedFiles(rootDir string) []string {
+	return findConflictedFiles(rootDir)
+}
+
+// hasConflictMarkers reports whether a file still contains git conflict
+// markers.
+func hasConflictMarkers(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var start, end bool
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "<<<<<<< ") {
+			start = true
+		} else if strings.HasPrefix(line, ">>>>>>> ") && start {
+			end = true
+			break
+		}
+	}
+	return start && end
+}
+
+// CleanConflictArtifacts deletes conflict artifacts, either all of them or
+// only those whose conflict is resolved, and returns what it removed.
+func CleanConflictArtifacts(rootDir string, resolvedOnly bool) ([]ConflictArtifact, error) {
+	artifacts, err := ListConflictArtifacts(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(artifacts) == 0 {
+		return nil, nil
+	}
+
+	var unmerged []string
+	if resolvedOnly {
+		unmerged = findConflictedFiles(rootDir)
+	}
+	var removed []ConflictArtifact
+	for _, artifact := range artifacts {
+		if resolvedOnly && !ArtifactResolved(rootDir, artifact, unmerged) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(rootDir, artifact.Path)); err != nil && !os.IsNotExist(err) {
+			log.Printf("[CONFLICT] Failed to remove %s: %v", artifact.Path, err)
+			continue
+		}
+		removeEmptyParents(filepath.Dir(filepath.Join(rootDir, artifact.Path)), AxlePath(rootDir, "backups"))
+		removed = append(removed, artifact)
+	}
+
+	artifactsMu.Lock()
+	defer artifactsMu.Unlock()
+	err = updateConflictArtifacts(rootDir, func(artifacts map[string]ConflictArtifact) {
+		for _, artifact := range removed {
+			delete(artifacts, artifact.Path)
+		}
+	})
+	return removed, err
+}
+
+// removeEmptyParents removes empty directories from dir up to, but not
+// including, stop. Directories outside stop are left alone.
+func removeEmptyParents(dir, stop string) {
+	for {
+		rel, err := filepath.Rel(stop, dir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			return
+		}
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// StartArtifactCleanup periodically removes conflict artifacts whose
+// conflict has been resolved.
+func StartArtifactCleanup(ctx context.Context, cfg AppConfig) {
+	ticker := time.NewTicker(artifactSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			artifactsMu.Lock()
+			artifacts, err := loadConflictArtifacts(cfg.RootDir)
+			artifactsMu.Unlock()
+			if err != nil || len(artifacts) == 0 {
+				continue
+			}
+			removed, err := CleanConflictArtifacts(cfg.RootDir, true)
+			if err != nil {
+				log.Printf("[CONFLICT] Artifact cleanup failed: %v", err)
+			}
+			for _, artifact := range removed {
+				log.Printf("[CONFLICT] Conflict in %s resolved; removed %s", artifact.Original, artifact.Path)
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ConflictedFile is a file the merge strategy left conflict markers in.
+type ConflictedFile struct {
+	File  string `json:"file"`           // Relative to the sync root
+	Peer  string `json:"peer,omitempty"` // Whose change conflicted, when known
+	Since int64  `json:"since"`
+}
+
+var conflictedMu sync.Mutex
+
+func conflictedFile(rootDir string) string {
+	return AxlePath(rootDir, "conflicted.json")
+}
+
+// loadConflictedFiles reads the registry of files with conflict markers,
+// keyed by file.
+func loadConflictedFiles(rootDir string) (map[string]ConflictedFile, error) {
+	files := make(map[string]ConflictedFile)
+	data, err := os.ReadFile(conflictedFile(rootDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return files, nil
+		}
+		return nil, fmt.Errorf("failed to read conflicted files: %w", err)
+	}
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, fmt.Errorf("failed to parse conflicted files: %w", err)
+	}
+	return files, nil
+}
+
+func saveConflictedFiles(rootDir string, files map[string]ConflictedFile) error {
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", AxleDirName, err)
+	}
+	data, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal conflicted files: %w", err)
+	}
+	return os.WriteFile(conflictedFile(rootDir), data, 0644)
+}
+
+// recordConflictedFiles registers files that were left with conflict
+// markers. A file already registered keeps the time and peer of its first
+// conflict.
+func recordConflictedFiles(rootDir, peer string, files []string) {
+	var marked []string
+	for _, file := range files {
+		if hasConflictMarkers(filepath.Join(rootDir, file)) {
+			marked = append(marked, filepath.ToSlash(file))
+		}
+	}
+	if len(marked) == 0 {
+		return
+	}
+
+	conflictedMu.Lock()
+	defer conflictedMu.Unlock()
+	registry, err := loadConflictedFiles(rootDir)
+	if err != nil {
+		log.Printf("[CONFLICT] %v", err)
+		return
+	}
+	now := time.Now().Unix()
+	for _, file := range marked {
+		if _, ok := registry[file]; !ok {
+			registry[file] = ConflictedFile{File: file, Peer: peer, Since: now}
+		}
+	}
+	if err := saveConflictedFiles(rootDir, registry); err != nil {
+		log.Printf("[CONFLICT] Failed to record conflicted files: %v", err)
+	}
+}
+
+// ListConflictedFiles returns the files that still have unresolved conflict
+// markers, oldest conflict first. Files that were fixed or deleted since are
+// dropped from the registry.
+func ListConflictedFiles(rootDir string) ([]ConflictedFile, error) {
+	conflictedMu.Lock()
+	defer conflictedMu.Unlock()
+	registry, err := loadConflictedFiles(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []ConflictedFile
+	pruned := false
+	for file, entry := range registry {
+		if !hasConflictMarkers(filepath.Join(rootDir, file)) {
+			delete(registry, file)
+			pruned = true
+			continue
+		}
+		list = append(list, entry)
+	}
+	if pruned {
+		if err := saveConflictedFiles(rootDir, registry); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Since != list[j].Since {
+			return list[i].Since < list[j].Since
+		}
+		return list[i].File < list[j].File
+	})
+	return list, nil
+}
+
+// ConflictBlockedFiles returns the files in a batch that can't be published
+// yet: ones with unresolved conflict markers, and ones that earlier held
+// batches touch, which have to go out first.
+func ConflictBlockedFiles(rootDir string, changes []FileChange) []string {
+	conflictedMu.Lock()
+	registry, err := loadConflictedFiles(rootDir)
+	conflictedMu.Unlock()
+	if err != nil {
+		log.Printf("[CONFLICT] %v", err)
+	}
+
+	held := make(map[string]bool)
+	batches, _ := ListHeldBatches(rootDir, HeldConflicted, nil)
+	for _, batch := range batches {
+		for _, change := range batch.Metadata.Changes {
+			held[change.File] = true
+		}
+	}
+
+	var blocked []string
+	for _, change := range changes {
+		if contains(blocked, change.File) {
+			continue
+		}
+		_, registered := registry[change.File]
+		if held[change.File] || registered && hasConflictMarkers(filepath.Join(rootDir, change.File)) {
+			blocked = append(blocked, change.File)
+		}
+	}
+	return blocked
+}
+
+// StartConflictedRelease periodically puts batches held for conflict markers
+// back in the publish queue once their files are resolved. Batches go back
+// in the order they were held; one still blocked keeps the later ones, so
+// teammates get each file's changes in order.
+func StartConflictedRelease(ctx context.Context, cfg AppConfig) {
+	ticker := time.NewTicker(artifactSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			batches, err := ListHeldBatches(cfg.RootDir, HeldConflicted, nil)
+			if err != nil || len(batches) == 0 {
+				continue
+			}
+			unresolved := make(map[string]bool)
+			if cfg.FeatureEnabled(FeatureConflictsBlock) {
+				conflicted, err := ListConflictedFiles(cfg.RootDir)
+				if err != nil {
+					log.Printf("[CONFLICT] %v", err)
+					continue
+				}
+				for _, entry := range conflicted {
+					unresolved[entry.File] = true
+				}
+			}
+
+			var released []FileChange
+			var count int
+			for _, batch := range batches {
+				if touchesAny(batch.Metadata.Changes, unresolved) {
+					break
+				}
+				if err := ReleaseHeldBatch(batch); err != nil {
+					log.Printf("[CONFLICT] %v", err)
+					break
+				}
+				released = append(released, batch.Metadata.Changes...)
+				count++
+			}
+			if count == 0 {
+				continue
+			}
+
+			// Released changes are older than anything buffered since
+			mu.Lock()
+			changes = append(released, changes...)
+			for _, change := range released {
+				bufferedBytes += changeSize(change)
+			}
+			mu.Unlock()
+			log.Printf("[CONFLICT] Conflicts resolved; publishing %d held batches", count)
+		}
+	}
+}
+
+// touchesAny reports whether any of the changes is to one of the files.
+func touchesAny(changes []FileChange, files map[string]bool) bool {
+	for _, change := range changes {
+		if files[change.File] {
+			return true
+		}
+	}
+	return false
+}
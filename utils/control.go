@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrDaemonNotRunning is returned when no daemon listens on the control socket.
+	ErrDaemonNotRunning = errors.New("axle daemon is not running")
+	// ErrUnknownControlCommand is returned when the daemon, e.g. an older
+	// version, has no handler for a command.
+	ErrUnknownControlCommand = errors.New("unknown command")
+)
+
+// ControlSocketName is the daemon's local control socket inside the .axle directory.
+const ControlSocketName = "control.sock"
+
+// ControlRequest is a command sent to the running daemon over its control socket.
+type ControlRequest struct {
+	Command string            `json:"command"`
+	Args    map[string]string `json:"args,omitempty"`
+}
+
+// ControlResponse is the daemon's reply to a ControlRequest.
+type ControlResponse struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ControlHandler handles one control command and returns a message for the caller.
+type ControlHandler func(req ControlRequest) (string, error)
+
+var (
+	controlHandlers   = make(map[string]ControlHandler)
+	controlHandlersMu sync.RWMutex
+)
+
+// RegisterControlHandler makes command available on the daemon's control socket.
+func RegisterControlHandler(command string, handler ControlHandler) {
+	controlHandlersMu.Lock()
+	defer controlHandlersMu.Unlock()
+	controlHandlers[command] = handler
+}
+
+// ControlSocketPath returns the control socket path for the repository at rootDir.
+func ControlSocketPath(rootDir string) string {
+	return AxlePath(rootDir, ControlSocketName)
+}
+
+// StartControlServer listens on the control socket until ctx is cancelled.
+func StartControlServer(ctx context.Context, cfg AppConfig) {
+	socketPath := ControlSocketPath(cfg.RootDir)
+	if err := os.MkdirAll(AxlePath(cfg.RootDir), 0755); err != nil {
+		log.Printf("[CONTROL] Failed to create .axle directory: %v", err)
+		return
+	}
+
+	// A socket left behind by a crashed daemon blocks Listen
+	os.Remove(socketPath)
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		log.Printf("[CONTROL] Failed to listen on control socket %s: %v", socketPath, err)
+		return
+	}
+	log.Printf("[CONTROL] Listening on %s", socketPath)
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+		os.Remove(socketPath)
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("[CONTROL] Accept error: %v", err)
+			continue
+		}
+		go handleControlConn(ctx, conn)
+	}
+}
+
+// handleControlConn serves a single request/response exchange, or an event
+// stream for "subscribe" requests.
+func handleControlConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	var req ControlRequest
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(ControlResponse{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+
+	if req.Command == ControlSubscribe {
+		streamEvents(ctx, conn, req)
+		return
+	}
+
+	controlHandlersMu.RLock()
+	handler, ok := controlHandlers[req.Command]
+	controlHandlersMu.RUnlock()
+	if !ok {
+		json.NewEncoder(conn).Encode(ControlResponse{Error: fmt.Sprintf("%v: %s", ErrUnknownControlCommand, req.Command)})
+		return
+	}
+
+	message, err := handler(req)
+	if err != nil {
+		json.NewEncoder(conn).Encode(ControlResponse{Error: err.Error()})
+		return
+	}
+	json.NewEncoder(conn).Encode(ControlResponse{OK: true, Message: message})
+}
+
+// ControlSubscribe is the control command that streams event bus events to
+// the caller, one JSON object per line, until either side disconnects.
+const ControlSubscribe = "subscribe"
+
+// ControlEvent is an event bus event as streamed to control socket subscribers.
+type ControlEvent struct {
+	Topic     EventTopic  `json:"topic"`
+	Timestamp int64       `json:"timestamp"`
+	Payload   interface{} `json:"payload"`
+}
+
+// streamEvents forwards events for the requested topics (comma-separated in
+// the "topics" arg, all topics if empty) to conn.
+func streamEvents(ctx context.Context, conn net.Conn, req ControlRequest) {
+	var topics []EventTopic
+	for _, topic := range strings.Split(req.Args["topics"], ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, EventTopic(topic))
+		}
+	}
+	if len(topics) == 0 {
+		topics = AllTopics
+	}
+
+	events, unsubscribe := Events.Subscribe(topics...)
+	defer unsubscribe()
+
+	// Subscriptions are long-lived; only individual writes time out
+	conn.SetDeadline(time.Time{})
+	encoder := json.NewEncoder(conn)
+	if err := encoder.Encode(ControlResponse{OK: true, Message: fmt.Sprintf("subscribed to %d topics", len(topics))}); err != nil {
+		return
+	}
+
+	// Notice when the subscriber hangs up
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(closed)
+	}()
+
+	for {
+		select {
+		case event := <-events:
+			conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+			if err := encoder.Encode(ControlEvent{Topic: event.Topic, Timestamp: event.Timestamp.Unix(), Payload: event.Payload}); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// SendControlRequest sends req to the daemon running for rootDir and waits for its reply.
+func SendControlRequest(rootDir string, req ControlRequest, timeout time.Duration) (ControlResponse, error) {
+	conn, err := net.DialTimeout("unix", ControlSocketPath(rootDir), timeout)
+	if err != nil {
+		return ControlResponse{}, fmt.Errorf("%w (start it with 'axle start'): %v", ErrDaemonNotRunning, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return ControlResponse{}, fmt.Errorf("failed to send control request: %w", err)
+	}
+
+	var resp ControlResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return ControlResponse{}, fmt.Errorf("failed to read control response: %w", err)
+	}
+	if !resp.OK {
+		if strings.HasPrefix(resp.Error, ErrUnknownControlCommand.Error()+":") {
+			return resp, fmt.Errorf("%w: %s", ErrUnknownControlCommand, req.Command)
+		}
+		return resp, fmt.Errorf("%s", resp.Error)
+	}
+	return resp, nil
+}
@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TreeDigest is one node's fingerprint of the files it syncs, working tree
+// included, for 'axle converge-check'.
+type TreeDigest struct {
+	Username   string                   `json:"username"`
+	NodeID     string                   `json:"nodeID"`
+	Digest     string                   `json:"digest"`
+	Files      int                      `json:"files"`
+	Manifest   map[string]ManifestEntry `json:"manifest"` // Only when asked for; empty is not nil
+	ReportedAt int64                    `json:"reportedAt"`
+}
+
+// DigestGroup is a set of nodes whose synced files are identical.
+type DigestGroup struct {
+	Digest string       `json:"digest"`
+	Nodes  []TreeDigest `json:"nodes"`
+}
+
+// ConvergeReport is the outcome of a convergence check.
+type ConvergeReport struct {
+	Groups []DigestGroup `json:"groups"`           // The largest group first
+	Silent []string      `json:"silent,omitempty"` // Online nodes that didn't answer
+}
+
+// Converged reports whether every node that answered holds the same files.
+func (r ConvergeReport) Converged() bool {
+	return len(r.Groups) <= 1
+}
+
+func digestRepliesKey(teamID, requestID string) string {
+	return fmt.Sprintf("axle:audit:%s:%s:digest", teamID, requestID)
+}
+
+// LocalTreeDigest hashes the files this node syncs, as they are on disk:
+// ignored files don't count, uncommitted edits do. With withManifest the
+// per-file hashes are kept for finding the paths that differ.
+func LocalTreeDigest(cfg AppConfig, withManifest bool) (TreeDigest, error) {
+	manifest, err := syncedManifest(cfg)
+	if err != nil {
+		return TreeDigest{}, err
+	}
+
+	paths := make([]string, 0, len(manifest))
+	for path := range manifest {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	hash := sha256.New()
+	for _, path := range paths {
+		fmt.Fprintf(hash, "%s\x00%s\n", path, manifest[path].Hash)
+	}
+
+	digest := TreeDigest{
+		Username:   cfg.Username,
+		NodeID:     cfg.NodeID,
+		Digest:     hex.EncodeToString(hash.Sum(nil)),
+		Files:      len(paths),
+		ReportedAt: time.Now().Unix(),
+	}
+	if withManifest {
+		digest.Manifest = manifest
+	}
+	return digest, nil
+}
+
+// reportTreeDigest answers a digest request.
+func reportTreeDigest(ctx context.Context, cfg AppConfig, msg AuditMessage) error {
+	digest, err := LocalTreeDigest(cfg, msg.Manifest)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(digest)
+	if err != nil {
+		return err
+	}
+	key := digestRepliesKey(cfg.TeamID, msg.RequestID)
+	if err := cfg.RedisClient.HSet(ctx, key, cfg.NodeID, data).Err(); err != nil {
+		return err
+	}
+	cfg.RedisClient.Expire(ctx, key, auditTTL)
+	return nil
+}
+
+// RunConvergeCheck asks online peers to hash their synced files, waits for
+// the digests and groups the nodes, this one included, by digest. With
+// withManifest every node sends its file list too, so DigestDifferences can
+// name the paths that differ.
+func RunConvergeCheck(ctx context.Context, cfg AppConfig, wait time.Duration, withManifest bool) (ConvergeReport, error) {
+	local, err := LocalTreeDigest(cfg, withManifest)
+	if err != nil {
+		return ConvergeReport{}, fmt.Errorf("failed to hash the working tree: %w", err)
+	}
+
+	req := AuditMessage{
+		Type:      auditDigest,
+		RequestID: GenerateNodeID(),
+		Requester: cfg.Username,
+		NodeID:    cfg.NodeID,
+		Manifest:  withManifest,
+		Timestamp: time.Now().Unix(),
+	}
+	if err := PublishMessage(ctx, cfg.Transport, AuditChannel(cfg.TeamID), req); err != nil {
+		return ConvergeReport{}, fmt.Errorf("failed to start convergence check: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return ConvergeReport{}, ctx.Err()
+	case <-time.After(wait):
+	}
+
+	key := digestRepliesKey(cfg.TeamID, req.RequestID)
+	replies, err := cfg.RedisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		return ConvergeReport{}, fmt.Errorf("failed to read digests: %w", err)
+	}
+	cfg.RedisClient.Del(ctx, key)
+
+	digests := []TreeDigest{local}
+	answered := map[string]bool{cfg.NodeID: true}
+	for nodeID, raw := range replies {
+		var digest TreeDigest
+		if nodeID == cfg.NodeID || json.Unmarshal([]byte(raw), &digest) != nil {
+			continue
+		}
+		digests = append(digests, digest)
+		answered[nodeID] = true
+	}
+
+	report := ConvergeReport{Groups: groupDigests(digests, cfg.NodeID)}
+	if presence, err := GetTeamPresence(ctx, cfg); err == nil {
+		for _, info := range presence {
+			if !answered[info.NodeID] {
+				report.Silent = append(report.Silent, info.Username)
+			}
+		}
+		sort.Strings(report.Silent)
+	}
+	return report, nil
+}
+
+// groupDigests groups nodes by digest, the largest group first. A tie goes
+// to the group holding localNode, then to the smaller digest.
+func groupDigests(digests []TreeDigest, localNode string) []DigestGroup {
+	index := make(map[string]int)
+	var groups []DigestGroup
+	for _, digest := range digests {
+		i, ok := index[digest.Digest]
+		if !ok {
+			i = len(groups)
+			index[digest.Digest] = i
+			groups = append(groups, DigestGroup{Digest: digest.Digest})
+		}
+		groups[i].Nodes = append(groups[i].Nodes, digest)
+	}
+
+	hasLocal := func(group DigestGroup) bool {
+		for _, node := range group.Nodes {
+			if node.NodeID == localNode {
+				return true
+			}
+		}
+		return false
+	}
+	for _, group := range groups {
+		sort.Slice(group.Nodes, func(i, j int) bool { return group.Nodes[i].Username < group.Nodes[j].Username })
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].Nodes) != len(groups[j].Nodes) {
+			return len(groups[i].Nodes) > len(groups[j].Nodes)
+		}
+		if hasLocal(groups[i]) != hasLocal(groups[j]) {
+			return hasLocal(groups[i])
+		}
+		return groups[i].Digest < groups[j].Digest
+	})
+	return groups
+}
+
+// DigestDifferences lists how a node's files differ from the reference
+// group's. Both need a manifest; without one it returns nil.
+func DigestDifferences(reference DigestGroup, node TreeDigest) []ManifestDiff {
+	if len(reference.Nodes) == 0 || reference.Nodes[0].Manifest == nil || node.Manifest == nil {
+		return nil
+	}
+	return DiffManifests(reference.Nodes[0].Manifest, node.Manifest)
+}
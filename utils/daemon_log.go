@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// maxDaemonLogBytes is how large the daemon log grows before it is rotated;
+// one previous log is kept
+const maxDaemonLogBytes = 5 * 1024 * 1024
+
+func daemonLogFile(rootDir string) string {
+	return AxlePath(rootDir, "daemon.log")
+}
+
+// rotatingLog appends to the daemon log, moving it aside once it is full.
+type rotatingLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	size int64
+}
+
+func (l *rotatingLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.size+int64(len(p)) > maxDaemonLogBytes {
+		l.file.Close()
+		os.Rename(l.path, l.path+".1")
+		file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return 0, err
+		}
+		l.file, l.size = file, 0
+	}
+	n, err := l.file.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+// StartDaemonLog copies everything the daemon logs to .axle/daemon.log, so
+// 'axle debug-bundle' can include it. The returned function stops copying.
+func StartDaemonLog(rootDir string) (func(), error) {
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", AxleDirName, err)
+	}
+	path := daemonLogFile(rootDir)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open daemon log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open daemon log: %w", err)
+	}
+
+	logFile := &rotatingLog{path: path, file: file, size: info.Size()}
+	previous := log.Writer()
+	log.SetOutput(io.MultiWriter(previous, logFile))
+	return func() {
+		log.SetOutput(previous)
+		logFile.mu.Lock()
+		defer logFile.mu.Unlock()
+		logFile.file.Close()
+	}, nil
+}
+
+// TailDaemonLog returns the last lines of the daemon log, reaching into the
+// rotated log when the current one is shorter.
+func TailDaemonLog(rootDir string, lines int) ([]byte, error) {
+	current, err := os.ReadFile(daemonLogFile(rootDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read daemon log: %w", err)
+	}
+	if bytes.Count(current, []byte("\n")) < lines {
+		if previous, err := os.ReadFile(daemonLogFile(rootDir) + ".1"); err == nil {
+			current = append(previous, current...)
+		}
+	}
+	if len(current) == 0 {
+		return nil, os.ErrNotExist
+	}
+	return TailLines(current, lines), nil
+}
+
+// TailLines returns the last n lines of data.
+func TailLines(data []byte, n int) []byte {
+	end := len(data)
+	if end > 0 && data[end-1] == '\n' {
+		end--
+	}
+	for i := end - 1; i >= 0; i-- {
+		if data[i] == '\n' {
+			n--
+			if n == 0 {
+				return data[i+1:]
+			}
+		}
+	}
+	return data
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	stateReportInterval = 5 * time.Second
+	// DaemonStateStaleAfter is how old the state file may be before the
+	// daemon is considered not running
+	DaemonStateStaleAfter = 30 * time.Second
+)
+
+// DaemonState is a snapshot of the running daemon, written to .axle/state.json
+// so CLI commands like 'axle status' can report on it.
+type DaemonState struct {
+	PID       int             `json:"pid"`
+	StartedAt int64           `json:"startedAt"`
+	UpdatedAt int64           `json:"updatedAt"`
+	Publisher PublisherStatus `json:"publisher"`
+	Disk      DiskStatus      `json:"disk"`
+	Batching  BatchStatus     `json:"batching"`
+	Offline   bool            `json:"offline,omitempty"`   // Started with --offline and not reconnected yet
+	LastApply int64           `json:"lastApply,omitempty"` // When an incoming batch was last applied
+	Paused    int64           `json:"paused,omitempty"`    // When 'axle pause' was run, while it lasts
+}
+
+func daemonStateFile(rootDir string) string {
+	return AxlePath(rootDir, "state.json")
+}
+
+// ReadDaemonState loads the last state written by the daemon.
+func ReadDaemonState(rootDir string) (DaemonState, error) {
+	var state DaemonState
+	data, err := os.ReadFile(daemonStateFile(rootDir))
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to parse daemon state: %w", err)
+	}
+	return state, nil
+}
+
+// IsRunning reports whether the state was refreshed recently enough for the
+// daemon to be considered alive.
+func (s DaemonState) IsRunning() bool {
+	return s.UpdatedAt > 0 && time.Since(time.Unix(s.UpdatedAt, 0)) < DaemonStateStaleAfter
+}
+
+// ProcessRunning reports whether a process with the given PID is running on
+// this machine, e.g. the daemon recorded in the state file.
+func ProcessRunning(pid int) bool {
+	return processAlive(pid)
+}
+
+// StartStateReporter periodically writes the daemon state file until the
+// context is cancelled, then removes it.
+func StartStateReporter(ctx context.Context, cfg AppConfig) {
+	state := DaemonState{PID: os.Getpid(), StartedAt: time.Now().Unix()}
+
+	write := func() {
+		state.UpdatedAt = time.Now().Unix()
+		state.Publisher = GetPublisherStatus()
+		state.Disk = GetDiskStatus()
+		state.Batching = GetBatchStatus()
+		state.Offline = IsOfflineMode()
+		state.Paused = pausedSince.Load()
+
+		data, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return
+		}
+		if err := os.MkdirAll(AxlePath(cfg.RootDir), 0755); err != nil {
+			log.Printf("[STATE] Failed to create %s: %v", AxleDirName, err)
+			return
+		}
+		if err := os.WriteFile(daemonStateFile(cfg.RootDir), data, 0644); err != nil {
+			log.Printf("[STATE] Failed to write daemon state: %v", err)
+		}
+	}
+
+	write()
+	ticker := time.NewTicker(stateReportInterval)
+	defer ticker.Stop()
+	applied, unsubscribe := Events.Subscribe(TopicBatchApplied)
+	defer unsubscribe()
+
+	for {
+		select {
+		case event := <-applied:
+			state.LastApply = event.Timestamp.Unix()
+		case <-ticker.C:
+			write()
+		case <-ctx.Done():
+			os.Remove(daemonStateFile(cfg.RootDir))
+			return
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const (
+	// debounceWindow is how close together repeated events for a path must
+	// be for all but the first to be dropped
+	debounceWindow = 500 * time.Millisecond
+	// maxDebounceEntries bounds the events remembered for debouncing; the
+	// least recently seen are forgotten first
+	maxDebounceEntries = 4096
+)
+
+// debounceKey identifies the events that repeat each other: the same kind
+// of event on the same path.
+type debounceKey struct {
+	path      string
+	eventType string
+}
+
+// debounceEntry is the last event accepted for a key.
+type debounceEntry struct {
+	key debounceKey
+	at  time.Time
+}
+
+// debounceResets lists, for an event type, the types it makes new again on
+// the same path. A file deleted since it was created has to be created
+// again, so a create after a delete is never a repeat, however quick.
+var debounceResets = map[string][]string{
+	"created": {"deleted"},
+	"deleted": {"created", "modified", "attributes"},
+}
+
+// eventDebouncer drops repeats of a file event: the same type of event on
+// the same relative path within the window. Other types on the path get
+// through, and a delete or create clears what it supersedes, so the last
+// event the watcher sees for a path always matches the file. It remembers
+// at most limit events.
+type eventDebouncer struct {
+	mu      sync.Mutex
+	window  time.Duration
+	limit   int
+	entries map[debounceKey]*list.Element // Key -> element holding a *debounceEntry
+	recency *list.List                    // Most recently seen first
+}
+
+func newEventDebouncer(window time.Duration, limit int) *eventDebouncer {
+	return &eventDebouncer{
+		window:  window,
+		limit:   limit,
+		entries: make(map[debounceKey]*list.Element),
+		recency: list.New(),
+	}
+}
+
+// watcherDebouncer debounces the file watcher's events.
+var watcherDebouncer = newEventDebouncer(debounceWindow, maxDebounceEntries)
+
+// accept reports whether an event for relPath at now should be processed,
+// and records it when it is.
+func (d *eventDebouncer) accept(relPath, eventType string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := debounceKey{path: relPath, eventType: eventType}
+	if element, ok := d.entries[key]; ok {
+		entry := element.Value.(*debounceEntry)
+		if now.Sub(entry.at) < d.window {
+			return false
+		}
+		entry.at = now
+		d.recency.MoveToFront(element)
+	} else {
+		d.entries[key] = d.recency.PushFront(&debounceEntry{key: key, at: now})
+		for d.recency.Len() > d.limit {
+			d.remove(d.recency.Back())
+		}
+	}
+
+	for _, superseded := range debounceResets[eventType] {
+		if element, ok := d.entries[debounceKey{path: relPath, eventType: superseded}]; ok {
+			d.remove(element)
+		}
+	}
+	return true
+}
+
+// prune forgets events past the window as of now and returns how many are
+// still remembered.
+func (d *eventDebouncer) prune(now time.Time) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cutoff := now.Add(-d.window)
+	for element := d.recency.Back(); element != nil; element = d.recency.Back() {
+		if element.Value.(*debounceEntry).at.After(cutoff) {
+			break
+		}
+		d.remove(element)
+	}
+	return d.recency.Len()
+}
+
+// reset forgets every event.
+func (d *eventDebouncer) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = make(map[debounceKey]*list.Element)
+	d.recency.Init()
+}
+
+func (d *eventDebouncer) remove(element *list.Element) {
+	delete(d.entries, element.Value.(*debounceEntry).key)
+	d.recency.Remove(element)
+}
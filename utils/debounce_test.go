@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDebounceCreateThenDelete(t *testing.T) {
+	d := newEventDebouncer(500*time.Millisecond, 16)
+	now := time.Unix(1700000000, 0)
+
+	if !d.accept("a.txt", "created", now) {
+		t.Fatal("first create was dropped")
+	}
+	if !d.accept("a.txt", "deleted", now.Add(10*time.Millisecond)) {
+		t.Fatal("delete right after the create was dropped")
+	}
+	// Created again: the delete isn't the last word
+	if !d.accept("a.txt", "created", now.Add(20*time.Millisecond)) {
+		t.Fatal("create after the delete was dropped")
+	}
+	if !d.accept("a.txt", "deleted", now.Add(30*time.Millisecond)) {
+		t.Fatal("delete after the second create was dropped")
+	}
+	// Another path is independent
+	if !d.accept("b.txt", "deleted", now.Add(30*time.Millisecond)) {
+		t.Fatal("delete of another path was dropped")
+	}
+}
+
+func TestDebounceRepeatedWrites(t *testing.T) {
+	d := newEventDebouncer(500*time.Millisecond, 16)
+	now := time.Unix(1700000000, 0)
+
+	if !d.accept("a.txt", "modified", now) {
+		t.Fatal("first write was dropped")
+	}
+	for _, offset := range []time.Duration{50, 200, 499} {
+		if d.accept("a.txt", "modified", now.Add(offset*time.Millisecond)) {
+			t.Errorf("write %dms later, within the window, got through", offset)
+		}
+	}
+	// Dropped writes don't extend the window
+	if !d.accept("a.txt", "modified", now.Add(500*time.Millisecond)) {
+		t.Error("write at the end of the window was dropped")
+	}
+	if d.accept("a.txt", "modified", now.Add(600*time.Millisecond)) {
+		t.Error("write within the new window got through")
+	}
+	if !d.accept("a.txt", "modified", now.Add(2*time.Second)) {
+		t.Error("write long after the window was dropped")
+	}
+}
+
+func TestDebounceByType(t *testing.T) {
+	d := newEventDebouncer(500*time.Millisecond, 16)
+	now := time.Unix(1700000000, 0)
+
+	d.accept("a.txt", "created", now)
+	if !d.accept("a.txt", "modified", now.Add(10*time.Millisecond)) {
+		t.Fatal("write right after the create was dropped")
+	}
+	// Keyed by path and type, the create is still within its window
+	if d.accept("a.txt", "created", now.Add(20*time.Millisecond)) {
+		t.Error("create → write → create within the window let the second create through")
+	}
+	if !d.accept("a.txt", "attributes", now.Add(30*time.Millisecond)) {
+		t.Error("attribute change was dropped as a repeat of another type")
+	}
+}
+
+func TestDebounceEviction(t *testing.T) {
+	const limit = 4
+	d := newEventDebouncer(time.Minute, limit)
+	now := time.Unix(1700000000, 0)
+
+	for i := 0; i < limit; i++ {
+		d.accept(fmt.Sprintf("f%d", i), "modified", now)
+	}
+	// Seen again, f0 becomes the most recent; f1 is now the oldest
+	d.accept("f0", "modified", now.Add(2*time.Minute))
+	d.accept("f4", "modified", now)
+
+	if got := d.recency.Len(); got != limit {
+		t.Fatalf("remembered %d events, want the limit %d", got, limit)
+	}
+	if !d.accept("f1", "modified", now.Add(time.Second)) {
+		t.Error("evicted f1 was still debounced")
+	}
+	if d.accept("f0", "modified", now.Add(2*time.Minute+time.Second)) {
+		t.Error("recently seen f0 was evicted")
+	}
+}
+
+func TestDebouncePrune(t *testing.T) {
+	d := newEventDebouncer(500*time.Millisecond, 16)
+	now := time.Unix(1700000000, 0)
+
+	d.accept("old", "modified", now)
+	d.accept("new", "modified", now.Add(400*time.Millisecond))
+	if remembered := d.prune(now.Add(600 * time.Millisecond)); remembered != 1 {
+		t.Errorf("prune kept %d events, want 1", remembered)
+	}
+	d.reset()
+	if remembered := d.prune(now); remembered != 0 {
+		t.Errorf("reset kept %d events", remembered)
+	}
+}
@@ -0,0 +1,361 @@
+package utils
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Patches and blobs above the direct transfer threshold skip the team's
+// server: the sender's daemon keeps them under .axle/cache/direct and serves
+// them over HTTP on the port it advertises with its heartbeats, and batches
+// only carry their hash. A receiver that can't reach the sender asks it to
+// upload the content through Redis instead, like 'axle fetch' does.
+
+const (
+	// BlobStoreDirect marks a change whose content its owner's daemon serves
+	BlobStoreDirect = "direct"
+	// directFetchTimeout bounds a whole direct download
+	directFetchTimeout = 5 * time.Minute
+	// directFallbackTimeout is how long to wait for the owner to upload
+	// content through Redis when they can't be reached directly
+	directFallbackTimeout = 30 * time.Second
+)
+
+var (
+	directMu        sync.RWMutex
+	directThreshold int64
+	directPort      int                   // Port to listen on, 0 for any free port
+	directServing   int                   // Port being served, 0 when not serving
+	directPeers     = map[string]string{} // Node ID -> address of its direct transfers
+)
+
+// SetDirectTransfer sets the size in MB above which patches and blobs go
+// straight to teammates, 0 to send everything through the team's server,
+// and the port to serve them on, 0 for any free port.
+func SetDirectTransfer(thresholdMB, port int) {
+	directMu.Lock()
+	defer directMu.Unlock()
+	directThreshold = int64(thresholdMB) * 1024 * 1024
+	directPort = port
+}
+
+// DirectTransferPort returns the port this node serves direct transfers on,
+// 0 if it doesn't.
+func DirectTransferPort() int {
+	directMu.RLock()
+	defer directMu.RUnlock()
+	return directServing
+}
+
+// sendsDirect reports whether content of size bytes goes straight to
+// teammates. Only a daemon serving direct transfers sends them, and only
+// while every online teammate can fetch them.
+func sendsDirect(cfg AppConfig, size int) bool {
+	directMu.RLock()
+	enabled := directThreshold > 0 && directServing > 0 && int64(size) >= directThreshold
+	directMu.RUnlock()
+	return enabled && teamSupports(cfg, CapDirectTransfer)
+}
+
+// notePeerDirect remembers where peers serve direct transfers, from their
+// presence messages.
+func notePeerDirect(msg PresenceMessage) {
+	directMu.Lock()
+	defer directMu.Unlock()
+	if msg.DirectPort > 0 && msg.IPAddress != "" && msg.Type != "goodbye" {
+		directPeers[msg.NodeID] = net.JoinHostPort(msg.IPAddress, strconv.Itoa(msg.DirectPort))
+	} else {
+		delete(directPeers, msg.NodeID)
+	}
+}
+
+// directPeerAddr returns where a node serves direct transfers. Commands
+// other than the daemon don't see heartbeats, so they look in the node's
+// stored presence.
+func directPeerAddr(ctx context.Context, cfg AppConfig, nodeID string) (string, bool) {
+	directMu.RLock()
+	addr, ok := directPeers[nodeID]
+	directMu.RUnlock()
+	if ok || !cfg.HasRedis() {
+		return addr, ok
+	}
+
+	data, err := cfg.RedisClient.Get(ctx, presenceKey(cfg.TeamID, nodeID)).Result()
+	if err != nil {
+		return "", false
+	}
+	var info PresenceInfo
+	if json.Unmarshal([]byte(data), &info) != nil || info.DirectPort == 0 || info.IPAddress == "" {
+		return "", false
+	}
+	return net.JoinHostPort(info.IPAddress, strconv.Itoa(info.DirectPort)), true
+}
+
+func directOfferDir(rootDir string) string {
+	return AxlePath(rootDir, "cache", "direct")
+}
+
+func validContentHash(hash string) bool {
+	_, err := hex.DecodeString(hash)
+	return err == nil && len(hash) == 64
+}
+
+// offerDirect keeps content for teammates to download and returns its hash.
+// Offers expire after BlobTTL, like chunks in Redis.
+func offerDirect(rootDir string, data []byte) (string, error) {
+	hash := HashContent(data)
+	dir := directOfferDir(rootDir)
+	path := filepath.Join(dir, hash)
+	if _, err := os.Stat(path); err == nil {
+		now := time.Now()
+		os.Chtimes(path, now, now)
+		return hash, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	// Write under a temporary name so teammates never get a partial offer
+	tmp, err := os.CreateTemp(dir, hash+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to offer content: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to offer content: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to offer content: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to offer content: %w", err)
+	}
+	return hash, nil
+}
+
+// loadDirectOffer reads content this node offered and verifies its hash.
+func loadDirectOffer(rootDir, hash string) ([]byte, error) {
+	if !validContentHash(hash) {
+		return nil, fmt.Errorf("invalid content hash %q", hash)
+	}
+	data, err := os.ReadFile(filepath.Join(directOfferDir(rootDir), hash))
+	if err != nil {
+		return nil, err
+	}
+	if HashContent(data) != hash {
+		return nil, fmt.Errorf("offered content %s failed integrity check", hash)
+	}
+	return data, nil
+}
+
+// expireDirectOffers removes offers older than BlobTTL.
+func expireDirectOffers(rootDir string) {
+	entries, err := os.ReadDir(directOfferDir(rootDir))
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-BlobTTL)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err == nil && info.ModTime().Before(cutoff) {
+			os.Remove(filepath.Join(directOfferDir(rootDir), entry.Name()))
+		}
+	}
+}
+
+// directHandler serves offered content at /direct/<team>/<hash>. The hash
+// only reaches the team's members through their batches, so it is what
+// grants access.
+type directHandler struct {
+	rootDir string
+	teamID  string
+}
+
+func (h directHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/direct/"), "/")
+	if len(parts) != 2 || parts[0] != h.teamID {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := loadDirectOffer(h.rootDir, parts[1])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+// ServeDirectTransfers starts serving this node's offers to teammates, if
+// direct transfers are on, until ctx is done.
+func ServeDirectTransfers(ctx context.Context, cfg AppConfig) error {
+	directMu.RLock()
+	threshold, port := directThreshold, directPort
+	directMu.RUnlock()
+	if threshold <= 0 {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen for direct transfers on port %d: %w", port, err)
+	}
+	server := &http.Server{
+		Handler:           directHandler{rootDir: cfg.RootDir, teamID: cfg.TeamID},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	directMu.Lock()
+	directServing = listener.Addr().(*net.TCPAddr).Port
+	directMu.Unlock()
+	log.Printf("[DIRECT] Serving changes above %s to teammates on port %d", formatMB(threshold), DirectTransferPort())
+
+	go func() {
+		ticker := time.NewTicker(artifactSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				directMu.Lock()
+				directServing = 0
+				directMu.Unlock()
+				server.Close()
+				return
+			case <-ticker.C:
+				expireDirectOffers(cfg.RootDir)
+			}
+		}
+	}()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[DIRECT] Direct transfer server failed: %v", err)
+		}
+	}()
+	return nil
+}
+
+// directClient talks to teammates on the local network, never through the
+// HTTP proxy from the environment.
+var directClient = &http.Client{
+	Timeout:   directFetchTimeout,
+	Transport: &http.Transport{Proxy: nil, DialContext: (&net.Dialer{Timeout: 5 * time.Second}).DialContext},
+}
+
+// fetchDirect downloads content a teammate offered and verifies its hash.
+func fetchDirect(ctx context.Context, cfg AppConfig, ownerNode, hash string) ([]byte, error) {
+	addr, ok := directPeerAddr(ctx, cfg, ownerNode)
+	if !ok {
+		return nil, fmt.Errorf("no direct address known for node %s", ownerNode)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s/direct/%s/%s", addr, cfg.TeamID, hash), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := directClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered %s", addr, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("download from %s failed: %w", addr, err)
+	}
+	if HashContent(data) != hash {
+		return nil, fmt.Errorf("content from %s failed integrity check", addr)
+	}
+	return data, nil
+}
+
+// loadDirectContent gets the content of a change its owner serves: straight
+// from them, or uploaded through Redis when they can't be reached.
+func loadDirectContent(ctx context.Context, cfg AppConfig, change FileChange) ([]byte, error) {
+	if !validContentHash(change.Hash) {
+		return nil, fmt.Errorf("invalid content hash %q", change.Hash)
+	}
+	start := time.Now()
+	data, err := fetchDirect(ctx, cfg, change.OwnerNode, change.Hash)
+	if err == nil {
+		log.Printf("[DIRECT] Received %s (%d bytes) straight from %s in %v", change.File, len(data), change.Owner, time.Since(start).Round(time.Millisecond))
+		return data, nil
+	}
+	if !cfg.HasRedis() {
+		return nil, fmt.Errorf("can't reach %s for %s: %w", change.Owner, change.File, err)
+	}
+	log.Printf("[DIRECT] Can't reach %s for %s (%v); asking them to upload it through Redis", change.Owner, change.File, err)
+	return requestUpload(ctx, cfg, change.File, change.Hash, change.Owner, change.OwnerNode, directFallbackTimeout)
+}
+
+// OfferDirectPatches rewrites an outgoing batch so patches above the direct
+// transfer threshold are served to teammates instead of sent with it.
+func OfferDirectPatches(cfg AppConfig, metadata SyncMetadata) SyncMetadata {
+	offered := metadata
+	offered.Changes = make([]FileChange, len(metadata.Changes))
+	copy(offered.Changes, metadata.Changes)
+
+	for i := range offered.Changes {
+		change := &offered.Changes[i]
+		if change.Event == "chunked" || !sendsDirect(cfg, len(change.Patch)) {
+			continue
+		}
+		hash, err := offerDirect(cfg.RootDir, []byte(change.Patch))
+		if err != nil {
+			log.Printf("[DIRECT] %v; sending the patch for %s with the batch", err, change.File)
+			continue
+		}
+		log.Printf("[DIRECT] Serving the patch for %s (%d bytes) to teammates directly", change.File, len(change.Patch))
+		change.Size = int64(len(change.Patch))
+		change.Hash = hash
+		change.Store = BlobStoreDirect
+		change.Owner = cfg.Username
+		change.OwnerNode = cfg.NodeID
+		change.Patch = ""
+	}
+	return offered
+}
+
+// IsDirectPatch reports whether a change's patch is served by its owner
+// instead of sent with the batch.
+func (c FileChange) IsDirectPatch() bool {
+	return c.Store == BlobStoreDirect && c.Event != "chunked"
+}
+
+// FetchDirectPatches downloads the patches of an incoming batch that its
+// sender serves directly, so the batch can be held and applied like any
+// other. Patches that can't be fetched are left out, which fails their
+// changes when the batch is applied.
+func FetchDirectPatches(ctx context.Context, cfg AppConfig, metadata SyncMetadata) SyncMetadata {
+	for i, change := range metadata.Changes {
+		if !change.IsDirectPatch() {
+			continue
+		}
+		data, err := loadDirectContent(ctx, cfg, change)
+		if err != nil {
+			log.Printf("[DIRECT] Failed to get the patch for %s (trace %s): %v", change.File, change.TraceID, err)
+			continue
+		}
+		metadata.Changes[i].Patch = string(data)
+		metadata.Changes[i].Store = ""
+	}
+	return metadata
+}
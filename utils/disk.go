@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCacheQuotaMB caps the evictable caches under .axle
+	DefaultCacheQuotaMB = 256
+	// DefaultMinFreeDiskMB is the free space below which members are warned
+	DefaultMinFreeDiskMB = 500
+	diskCheckInterval    = 5 * time.Minute
+)
+
+// evictableAxleDirs are the .axle subdirectories holding data that can be
+// recreated or safely dropped, oldest first, when the cache quota is exceeded.
+var evictableAxleDirs = []string{"cache", "trash", "config-backups"}
+
+// DiskStatus summarizes disk usage for the repository and its .axle directory.
+type DiskStatus struct {
+	RepoBytes       int64  `json:"repoBytes"`
+	AxleBytes       int64  `json:"axleBytes"`
+	CacheBytes      int64  `json:"cacheBytes"`
+	CacheQuotaBytes int64  `json:"cacheQuotaBytes"`
+	FreeBytes       uint64 `json:"freeBytes"`
+	TotalBytes      uint64 `json:"totalBytes"`
+	LowSpace        bool   `json:"lowSpace"`
+	CheckedAt       int64  `json:"checkedAt"`
+}
+
+var (
+	diskStatus   DiskStatus
+	diskStatusMu sync.RWMutex
+)
+
+// GetDiskStatus returns the result of the most recent disk check.
+func GetDiskStatus() DiskStatus {
+	diskStatusMu.RLock()
+	defer diskStatusMu.RUnlock()
+	return diskStatus
+}
+
+// cacheQuotaBytes returns the configured cache quota, falling back to the default.
+func cacheQuotaBytes(cfg AppConfig) int64 {
+	if cfg.CacheQuotaMB > 0 {
+		return int64(cfg.CacheQuotaMB) * 1024 * 1024
+	}
+	return DefaultCacheQuotaMB * 1024 * 1024
+}
+
+// minFreeDiskBytes returns the configured low-space threshold, falling back to the default.
+func minFreeDiskBytes(cfg AppConfig) uint64 {
+	if cfg.MinFreeDiskMB > 0 {
+		return uint64(cfg.MinFreeDiskMB) * 1024 * 1024
+	}
+	return DefaultMinFreeDiskMB * 1024 * 1024
+}
+
+// dirSize returns the total size of regular files under dir.
+func dirSize(dir string, skip func(path string) bool) int64 {
+	var size int64
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if skip != nil && skip(path) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// CheckDisk measures disk usage of the repository, .axle, and the filesystem.
+func CheckDisk(cfg AppConfig) (DiskStatus, error) {
+	axleDir := AxlePath(cfg.RootDir)
+	status := DiskStatus{
+		RepoBytes:       dirSize(cfg.RootDir, func(path string) bool { return path == axleDir }),
+		AxleBytes:       dirSize(axleDir, nil),
+		CacheQuotaBytes: cacheQuotaBytes(cfg),
+		CheckedAt:       time.Now().Unix(),
+	}
+	for _, dir := range evictableAxleDirs {
+		status.CacheBytes += dirSize(AxlePath(cfg.RootDir, dir), nil)
+	}
+
+	free, total, err := diskSpace(cfg.RootDir)
+	if err != nil {
+		return status, fmt.Errorf("failed to read free disk space: %w", err)
+	}
+	status.FreeBytes = free
+	status.TotalBytes = total
+	status.LowSpace = free < minFreeDiskBytes(cfg)
+	return status, nil
+}
+
+// EnforceCacheQuota deletes the least recently modified files in the
+// evictable .axle directories until they fit within quotaBytes. It returns
+// the number of files removed and the bytes freed.
+func EnforceCacheQuota(rootDir string, quotaBytes int64) (int, int64, error) {
+	type cachedFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []cachedFile
+	var used int64
+	for _, dir := range evictableAxleDirs {
+		filepath.Walk(AxlePath(rootDir, dir), func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.Mode().IsRegular() {
+				return nil
+			}
+			files = append(files, cachedFile{path: path, size: info.Size(), modTime: info.ModTime()})
+			used += info.Size()
+			return nil
+		})
+	}
+	if used <= quotaBytes {
+		return 0, 0, nil
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	removed := 0
+	var freed int64
+	for _, file := range files {
+		if used-freed <= quotaBytes {
+			break
+		}
+		if err := os.Remove(file.path); err != nil {
+			return removed, freed, fmt.Errorf("failed to evict %s: %w", file.path, err)
+		}
+		removed++
+		freed += file.size
+	}
+	return removed, freed, nil
+}
+
+// StartDiskMonitor enforces the cache quota and warns before the disk fills,
+// until the context is cancelled.
+func StartDiskMonitor(ctx context.Context, cfg AppConfig) {
+	warned := false
+
+	check := func() {
+		if removed, freed, err := EnforceCacheQuota(cfg.RootDir, cacheQuotaBytes(cfg)); err != nil {
+			log.Printf("[DISK] Cache eviction failed: %v", err)
+		} else if removed > 0 {
+			log.Printf("[DISK] Evicted %d cached files (%s) to stay within the cache quota", removed, formatMB(freed))
+		}
+
+		status, err := CheckDisk(cfg)
+		if err != nil {
+			log.Printf("[DISK] %v", err)
+			return
+		}
+		diskStatusMu.Lock()
+		diskStatus = status
+		diskStatusMu.Unlock()
+
+		// Warn once per low-space episode
+		if status.LowSpace && !warned {
+			warned = true
+			log.Printf("[DISK] Low disk space: %s free (repo %s, .axle %s)",
+				formatMB(int64(status.FreeBytes)), formatMB(status.RepoBytes), formatMB(status.AxleBytes))
+			SendNotification("Axle - Low disk space",
+				fmt.Sprintf("Only %s free on the disk holding %s", formatMB(int64(status.FreeBytes)), cfg.RootDir))
+		} else if !status.LowSpace && warned {
+			warned = false
+			log.Printf("[DISK] Disk space recovered: %s free", formatMB(int64(status.FreeBytes)))
+		}
+	}
+
+	check()
+	ticker := time.NewTicker(diskCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			check()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// formatMB formats a byte count in megabytes for log output.
+func formatMB(bytes int64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package utils
+
+import "golang.org/x/sys/windows"
+
+// diskSpace returns the free (available to this user) and total bytes of the
+// volume containing path.
+func diskSpace(path string) (free, total uint64, err error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &free, &total, &totalFree); err != nil {
+		return 0, 0, err
+	}
+	return free, total, nil
+}
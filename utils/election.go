@@ -0,0 +1,454 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Election rules for picking the team's reference state
+const (
+	ElectByLedger   = "ledger"   // The state that has handled the most of the team's batch stream
+	ElectByPriority = "priority" // The authoritative node's state, then the peer priority order
+)
+
+const (
+	// auditTTL bounds how long audit replies and the elected reference stay in Redis
+	auditTTL = 10 * time.Minute
+	// referenceTTL is how long an elected reference is kept for 'axle reset --from'
+	referenceTTL = 24 * time.Hour
+)
+
+// Audit message types
+const (
+	auditRequest = "audit"   // Report your state
+	auditElected = "elected" // A reference state was elected
+	auditDigest  = "digest"  // Report your working tree's digest
+)
+
+// NodeVersion is one node's view of the synced tree.
+type NodeVersion struct {
+	Username   string `json:"username"`
+	NodeID     string `json:"nodeID"`
+	Head       string `json:"head"`                 // HEAD commit
+	Tree       string `json:"tree"`                 // HEAD's tree; equal trees mean equal content
+	CommitTime int64  `json:"commitTime"`           // Committer time of HEAD
+	LedgerID   string `json:"ledgerID,omitempty"`   // Last batch stream entry handled
+	Dirty      bool   `json:"dirty,omitempty"`      // Uncommitted changes on top of HEAD
+	ReportedAt int64  `json:"reportedAt,omitempty"` // When the node answered
+}
+
+// AuditMessage is sent on the audit channel.
+type AuditMessage struct {
+	Type      string    `json:"type"`
+	RequestID string    `json:"requestID,omitempty"`
+	Requester string    `json:"requester"`
+	NodeID    string    `json:"nodeID"`
+	Election  *Election `json:"election,omitempty"` // For auditElected
+	Manifest  bool      `json:"manifest,omitempty"` // For auditDigest: send the file list too
+	Timestamp int64     `json:"timestamp"`
+}
+
+// StateGroup is a set of nodes that hold the same tree.
+type StateGroup struct {
+	Tree  string        `json:"tree"`
+	Nodes []NodeVersion `json:"nodes"`
+}
+
+// RepairPlan lists what a diverged node has to do to take the reference state.
+type RepairPlan struct {
+	Username string   `json:"username"`
+	NodeID   string   `json:"nodeID"`
+	Steps    []string `json:"steps"`
+}
+
+// Election is the outcome of comparing the team's states.
+type Election struct {
+	Rule      string       `json:"rule"`
+	Reason    string       `json:"reason"`    // Why the reference state won
+	Reference NodeVersion  `json:"reference"` // The node to take the reference state from
+	Groups    []StateGroup `json:"groups"`    // The reference group first
+	Repairs   []RepairPlan `json:"repairs"`
+	ElectedBy string       `json:"electedBy,omitempty"`
+	ElectedAt int64        `json:"electedAt,omitempty"`
+}
+
+// Diverged reports whether the audited nodes disagree.
+func (e Election) Diverged() bool {
+	return len(e.Groups) > 1
+}
+
+func auditRepliesKey(teamID, requestID string) string {
+	return fmt.Sprintf("axle:audit:%s:%s", teamID, requestID)
+}
+
+func referenceKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:reference", teamID)
+}
+
+// ValidateElectionRule checks an election rule name.
+func ValidateElectionRule(rule string) error {
+	if rule != ElectByLedger && rule != ElectByPriority {
+		return fmt.Errorf("invalid election rule %q: must be %s or %s", rule, ElectByLedger, ElectByPriority)
+	}
+	return nil
+}
+
+// LocalNodeVersion describes this node's tree.
+func LocalNodeVersion(ctx context.Context, cfg AppConfig) (NodeVersion, error) {
+	version := NodeVersion{Username: cfg.Username, NodeID: cfg.NodeID, ReportedAt: time.Now().Unix()}
+
+	output, err := GitCommand("-C", cfg.RootDir, "log", "-1", "--format=%H %T %ct").Output()
+	if err != nil {
+		return version, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 3 {
+		return version, fmt.Errorf("unexpected git log output %q", strings.TrimSpace(string(output)))
+	}
+	version.Head, version.Tree = fields[0], fields[1]
+	version.CommitTime, _ = strconv.ParseInt(fields[2], 10, 64)
+
+	if output, err := GitCommand("-C", cfg.RootDir, "status", "--porcelain", "--untracked-files=no").Output(); err == nil {
+		version.Dirty = len(strings.TrimSpace(string(output))) > 0
+	}
+
+	// Without a stream (persistence off) the position is simply unknown
+	if position, err := StreamPosition(ctx, cfg); err == nil {
+		version.LedgerID = position
+	}
+	return version, nil
+}
+
+// RunDivergenceAudit asks online peers for their state, waits for answers and
+// returns every node's version, this one included.
+func RunDivergenceAudit(ctx context.Context, cfg AppConfig, wait time.Duration) ([]NodeVersion, error) {
+	local, err := LocalNodeVersion(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	req := AuditMessage{
+		Type:      auditRequest,
+		RequestID: GenerateNodeID(),
+		Requester: cfg.Username,
+		NodeID:    cfg.NodeID,
+		Timestamp: time.Now().Unix(),
+	}
+	if err := PublishMessage(ctx, cfg.Transport, AuditChannel(cfg.TeamID), req); err != nil {
+		return nil, fmt.Errorf("failed to start audit: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(wait):
+	}
+
+	key := auditRepliesKey(cfg.TeamID, req.RequestID)
+	replies, err := cfg.RedisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit replies: %w", err)
+	}
+	cfg.RedisClient.Del(ctx, key)
+
+	versions := []NodeVersion{local}
+	for nodeID, raw := range replies {
+		var version NodeVersion
+		if nodeID == cfg.NodeID || json.Unmarshal([]byte(raw), &version) != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Username < versions[j].Username })
+	return versions, nil
+}
+
+// ElectReference groups the audited nodes by tree and elects the reference
+// state. With the ledger rule the group that has handled the most of the
+// batch stream wins, then the most recent commit; with the priority rule the
+// highest ranked member in priority wins. Remaining ties go to the better
+// ranked member, the larger group and finally the smaller username.
+func ElectReference(versions []NodeVersion, rule string, priority []string) (Election, error) {
+	if err := ValidateElectionRule(rule); err != nil {
+		return Election{}, err
+	}
+	if len(versions) == 0 {
+		return Election{}, fmt.Errorf("no nodes to compare")
+	}
+
+	rank := func(username string) int {
+		for i, peer := range priority {
+			if peer == username {
+				return i
+			}
+		}
+		return len(priority)
+	}
+
+	byTree := make(map[string]*StateGroup)
+	var groups []*StateGroup
+	for _, version := range versions {
+		group, ok := byTree[version.Tree]
+		if !ok {
+			group = &StateGroup{Tree: version.Tree}
+			byTree[version.Tree] = group
+			groups = append(groups, group)
+		}
+		group.Nodes = append(group.Nodes, version)
+	}
+
+	// Each group is represented by its best node: clean, best ranked, furthest along
+	for _, group := range groups {
+		sort.SliceStable(group.Nodes, func(i, j int) bool {
+			a, b := group.Nodes[i], group.Nodes[j]
+			if a.Dirty != b.Dirty {
+				return !a.Dirty
+			}
+			if rank(a.Username) != rank(b.Username) {
+				return rank(a.Username) < rank(b.Username)
+			}
+			if c := compareStreamIDs(a.LedgerID, b.LedgerID); c != 0 {
+				return c > 0
+			}
+			return a.Username < b.Username
+		})
+	}
+	ledger := func(g *StateGroup) string {
+		best := ""
+		for _, node := range g.Nodes {
+			if compareStreamIDs(node.LedgerID, best) > 0 {
+				best = node.LedgerID
+			}
+		}
+		return best
+	}
+	bestRank := func(g *StateGroup) int {
+		best := len(priority)
+		for _, node := range g.Nodes {
+			best = min(best, rank(node.Username))
+		}
+		return best
+	}
+	newest := func(g *StateGroup) int64 {
+		var best int64
+		for _, node := range g.Nodes {
+			best = max(best, node.CommitTime)
+		}
+		return best
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if rule == ElectByPriority && bestRank(a) != bestRank(b) {
+			return bestRank(a) < bestRank(b)
+		}
+		if c := compareStreamIDs(ledger(a), ledger(b)); c != 0 {
+			return c > 0
+		}
+		if newest(a) != newest(b) {
+			return newest(a) > newest(b)
+		}
+		if bestRank(a) != bestRank(b) {
+			return bestRank(a) < bestRank(b)
+		}
+		if len(a.Nodes) != len(b.Nodes) {
+			return len(a.Nodes) > len(b.Nodes)
+		}
+		return a.Nodes[0].Username < b.Nodes[0].Username
+	})
+
+	winner := groups[0]
+	election := Election{Rule: rule, Reference: winner.Nodes[0]}
+	switch {
+	case len(groups) == 1:
+		election.Reason = "every node has the same tree"
+	case rule == ElectByPriority && bestRank(winner) < bestRank(groups[1]):
+		election.Reason = fmt.Sprintf("%s ranks highest in the team's priority order", priority[bestRank(winner)])
+	case compareStreamIDs(ledger(winner), ledger(groups[1])) > 0:
+		election.Reason = fmt.Sprintf("it has handled the team's batch stream up to %s, the furthest", ledger(winner))
+	case newest(winner) > newest(groups[1]):
+		election.Reason = fmt.Sprintf("its last commit (%s) is the most recent", time.Unix(newest(winner), 0).Format("2006-01-02 15:04:05"))
+	default:
+		election.Reason = "tie broken by priority order, group size and username"
+	}
+
+	for _, group := range groups {
+		election.Groups = append(election.Groups, *group)
+	}
+	for _, group := range groups[1:] {
+		for _, node := range group.Nodes {
+			election.Repairs = append(election.Repairs, RepairPlanFor(node, election.Reference))
+		}
+	}
+	// Nodes on the reference tree with local edits only need to sync them
+	for _, node := range winner.Nodes {
+		if node.Dirty && node.NodeID != election.Reference.NodeID {
+			election.Repairs = append(election.Repairs, RepairPlan{
+				Username: node.Username,
+				NodeID:   node.NodeID,
+				Steps:    []string{"Has the reference tree plus uncommitted changes; nothing to repair once they sync"},
+			})
+		}
+	}
+	return election, nil
+}
+
+// repairPlanFor lists the steps that bring a diverged node to the reference.
+func RepairPlanFor(node, reference NodeVersion) RepairPlan {
+	plan := RepairPlan{Username: node.Username, NodeID: node.NodeID}
+	if compareStreamIDs(node.LedgerID, reference.LedgerID) < 0 {
+		plan.Steps = append(plan.Steps, fmt.Sprintf("Behind the batch stream (at %s, reference at %s); its missed batches are replaced by the reset", streamIDOrNone(node.LedgerID), reference.LedgerID))
+	}
+	if node.Dirty {
+		plan.Steps = append(plan.Steps, "Has uncommitted changes; the reset stashes them")
+	}
+	plan.Steps = append(plan.Steps,
+		"Stop 'axle start'",
+		fmt.Sprintf("Run 'axle reset --from %s'; local commits are kept on an axle-backup-* branch", reference.Username),
+		"Run 'axle start' again",
+	)
+	return plan
+}
+
+func streamIDOrNone(id string) string {
+	if id == "" {
+		return "none"
+	}
+	return id
+}
+
+// compareStreamIDs orders Redis stream IDs ("<ms>-<seq>"); empty sorts first.
+func compareStreamIDs(a, b string) int {
+	parse := func(id string) (uint64, uint64) {
+		msPart, seqPart, _ := strings.Cut(id, "-")
+		ms, _ := strconv.ParseUint(msPart, 10, 64)
+		seq, _ := strconv.ParseUint(seqPart, 10, 64)
+		return ms, seq
+	}
+	aMs, aSeq := parse(a)
+	bMs, bSeq := parse(b)
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return -1
+	case b == "":
+		return 1
+	case aMs != bMs:
+		if aMs < bMs {
+			return -1
+		}
+		return 1
+	case aSeq < bSeq:
+		return -1
+	case aSeq > bSeq:
+		return 1
+	}
+	return 0
+}
+
+// PublishElection records an elected reference for the team and tells every
+// online node, so diverged ones can show their repair plan.
+func PublishElection(ctx context.Context, cfg AppConfig, election Election) error {
+	election.ElectedBy = cfg.Username
+	election.ElectedAt = time.Now().Unix()
+	data, err := json.Marshal(election)
+	if err != nil {
+		return fmt.Errorf("failed to marshal election: %w", err)
+	}
+	if err := cfg.RedisClient.Set(ctx, referenceKey(cfg.TeamID), data, referenceTTL).Err(); err != nil {
+		return fmt.Errorf("failed to record election: %w", err)
+	}
+
+	msg := AuditMessage{
+		Type:      auditElected,
+		Requester: cfg.Username,
+		NodeID:    cfg.NodeID,
+		Election:  &election,
+		Timestamp: election.ElectedAt,
+	}
+	if err := PublishMessage(ctx, cfg.Transport, AuditChannel(cfg.TeamID), msg); err != nil {
+		return fmt.Errorf("failed to announce election: %w", err)
+	}
+	return nil
+}
+
+// LoadElection returns the team's last elected reference, if one is recorded.
+func LoadElection(ctx context.Context, cfg AppConfig) (*Election, error) {
+	data, err := cfg.RedisClient.Get(ctx, referenceKey(cfg.TeamID)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read election: %w", err)
+	}
+	var election Election
+	if err := json.Unmarshal(data, &election); err != nil {
+		return nil, fmt.Errorf("failed to parse election: %w", err)
+	}
+	return &election, nil
+}
+
+// ProcessAuditMessage answers audit requests with this node's state and
+// tells the user when an election found this node diverged.
+func ProcessAuditMessage(ctx context.Context, cfg AppConfig, payload string) {
+	var msg AuditMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		log.Printf("[AUDIT] Error unmarshaling audit message: %v", err)
+		return
+	}
+	if AdmitSigned(cfg, AuditChannel(cfg.TeamID), msg.Requester, payload) != nil {
+		return
+	}
+	if msg.NodeID == cfg.NodeID {
+		return
+	}
+
+	switch msg.Type {
+	case auditRequest:
+		version, err := LocalNodeVersion(ctx, cfg)
+		if err != nil {
+			log.Printf("[AUDIT] Failed to report state to %s: %v", msg.Requester, err)
+			return
+		}
+		data, err := json.Marshal(version)
+		if err != nil {
+			return
+		}
+		key := auditRepliesKey(cfg.TeamID, msg.RequestID)
+		if err := cfg.RedisClient.HSet(ctx, key, cfg.NodeID, data).Err(); err != nil {
+			log.Printf("[AUDIT] Failed to report state to %s: %v", msg.Requester, err)
+			return
+		}
+		cfg.RedisClient.Expire(ctx, key, auditTTL)
+
+	case auditDigest:
+		if err := reportTreeDigest(ctx, cfg, msg); err != nil {
+			log.Printf("[AUDIT] Failed to report tree digest to %s: %v", msg.Requester, err)
+		}
+
+	case auditElected:
+		if msg.Election == nil {
+			return
+		}
+		for _, plan := range msg.Election.Repairs {
+			if plan.NodeID != cfg.NodeID {
+				continue
+			}
+			log.Printf("[AUDIT] %s elected %s's state as the team's reference; this node differs:", msg.Requester, msg.Election.Reference.Username)
+			for i, step := range plan.Steps {
+				log.Printf("[AUDIT]   %d. %s", i+1, step)
+			}
+			SendNotification("Axle - Your tree differs from the team",
+				fmt.Sprintf("Run 'axle elect --plan' to see how to take %s's state", msg.Election.Reference.Username))
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// endpointDialTimeout bounds each endpoint's connection attempt, so a dead
+// primary delays failover by seconds rather than the full dial timeout.
+const endpointDialTimeout = 2 * time.Second
+
+// RedisEndpoint is one Redis server the team can be reached through.
+type RedisEndpoint struct {
+	Addr   string `json:"addr"`             // host:port
+	Region string `json:"region,omitempty"` // Free-form label such as "eu-west" or "india"
+}
+
+// Label describes the endpoint for display.
+func (e RedisEndpoint) Label() string {
+	if e.Region == "" {
+		return e.Addr
+	}
+	return fmt.Sprintf("%s (%s)", e.Addr, e.Region)
+}
+
+var (
+	activeEndpointMu sync.Mutex
+	activeEndpoint   RedisEndpoint
+)
+
+// ActiveRedisEndpoint returns the endpoint the most recent connection was made
+// to, or the zero value if no failover client has connected yet.
+func ActiveRedisEndpoint() RedisEndpoint {
+	activeEndpointMu.Lock()
+	defer activeEndpointMu.Unlock()
+	return activeEndpoint
+}
+
+// NewFailoverRedisClient creates a Redis client for a prioritized list of
+// endpoints. Every new connection tries the endpoints in order, so the client
+// fails over when the primary goes down and moves back once it returns,
+// without the caller noticing beyond a failed command or two. A Sentinel or
+// Cluster deployment set with SetRedisHA takes the endpoints' place.
+func NewFailoverRedisClient(endpoints []RedisEndpoint) (redis.UniversalClient, error) {
+	if ha := CurrentRedisHA(); ha.Enabled() {
+		return connectRedis(newHARedisClient(ha), ha.Label(), 5, 1*time.Second)
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no Redis endpoints configured")
+	}
+	return connectRedis(newRedisClient(failoverOptions(endpoints)), endpoints[0].Addr, 5, 1*time.Second)
+}
+
+// NewLazyRedisClient creates the same client as NewFailoverRedisClient
+// without connecting, for a daemon that starts offline. Commands fail until
+// an endpoint is reachable.
+func NewLazyRedisClient(endpoints []RedisEndpoint) (redis.UniversalClient, error) {
+	if ha := CurrentRedisHA(); ha.Enabled() {
+		return newHARedisClient(ha), nil
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no Redis endpoints configured")
+	}
+	return newRedisClient(failoverOptions(endpoints)), nil
+}
+
+// failoverOptions returns client options for a prioritized list of endpoints.
+func failoverOptions(endpoints []RedisEndpoint) *redis.Options {
+	opts := redisOptions(endpoints[0].Addr)
+	if len(endpoints) > 1 {
+		opts.Dialer = failoverDialer(endpoints)
+	} else {
+		activeEndpointMu.Lock()
+		activeEndpoint = endpoints[0]
+		activeEndpointMu.Unlock()
+	}
+	return opts
+}
+
+// failoverDialer returns a dialer that connects to the first reachable endpoint.
+func failoverDialer(endpoints []RedisEndpoint) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var lastErr error
+		for _, endpoint := range endpoints {
+			dialer := net.Dialer{Timeout: endpointDialTimeout, KeepAlive: 5 * time.Minute}
+			conn, err := dialRedisEndpoint(ctx, &dialer, network, endpoint.Addr)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			noteActiveEndpoint(endpoint)
+			return conn, nil
+		}
+		return nil, fmt.Errorf("no Redis endpoint reachable: %w", lastErr)
+	}
+}
+
+// MeasureLatency returns the round-trip time of a single PING.
+func MeasureLatency(ctx context.Context, rdb redis.UniversalClient) (time.Duration, error) {
+	start := time.Now()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// EndpointLatency is the result of pinging one endpoint.
+type EndpointLatency struct {
+	Endpoint RedisEndpoint
+	Min      time.Duration
+	Avg      time.Duration
+	Err      error
+}
+
+// PingEndpoint measures the round-trip time to an endpoint over count PINGs.
+// Connection setup is excluded from the measurement.
+func PingEndpoint(ctx context.Context, endpoint RedisEndpoint, count int) EndpointLatency {
+	result := EndpointLatency{Endpoint: endpoint}
+
+	opts := redisOptions(endpoint.Addr)
+	opts.MaxRetries = -1 // A retried PING would report a misleading time
+	opts.DialTimeout = endpointDialTimeout
+	opts.Dialer = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialer := net.Dialer{Timeout: endpointDialTimeout}
+		return dialRedisEndpoint(ctx, &dialer, network, addr)
+	}
+	rdb := redis.NewClient(opts)
+	defer rdb.Close()
+
+	// Warm up the connection so the first sample doesn't include the dial
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		result.Err = err
+		return result
+	}
+
+	var total time.Duration
+	for i := 0; i < count; i++ {
+		rtt, err := MeasureLatency(ctx, rdb)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		total += rtt
+		if result.Min == 0 || rtt < result.Min {
+			result.Min = rtt
+		}
+	}
+	if count > 0 {
+		result.Avg = total / time.Duration(count)
+	}
+	return result
+}
+
+// RankEndpoints orders ping results from fastest to slowest average, with
+// unreachable endpoints last in their original order.
+func RankEndpoints(results []EndpointLatency) []EndpointLatency {
+	ranked := append([]EndpointLatency(nil), results...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if (ranked[i].Err == nil) != (ranked[j].Err == nil) {
+			return ranked[i].Err == nil
+		}
+		return ranked[i].Err == nil && ranked[i].Avg < ranked[j].Avg
+	})
+	return ranked
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ApplyErrorReport tells the team that a node failed to apply a change.
+type ApplyErrorReport struct {
+	Reporter     string `json:"reporter"`     // Username of the node that failed
+	ReporterNode string `json:"reporterNode"` // Node ID of the node that failed
+	Sender       string `json:"sender"`       // Username whose change failed to apply
+	File         string `json:"file"`
+	Error        string `json:"error"`
+	Timestamp    int64  `json:"timestamp"`
+}
+
+// maxReportedErrorLength keeps error reports small; git output can be long.
+const maxReportedErrorLength = 500
+
+// StartErrorReporter publishes a report to the team whenever an incoming
+// change fails to apply locally.
+func StartErrorReporter(ctx context.Context, cfg AppConfig) {
+	events, unsubscribe := Events.Subscribe(TopicApplyFailed)
+	defer unsubscribe()
+
+	for {
+		select {
+		case event := <-events:
+			failure := event.Payload.(ApplyFailedEvent)
+			errText := failure.Err.Error()
+			if len(errText) > maxReportedErrorLength {
+				errText = errText[:maxReportedErrorLength-3] + "..."
+			}
+
+			report := ApplyErrorReport{
+				Reporter:     cfg.Username,
+				ReporterNode: cfg.NodeID,
+				Sender:       failure.PeerID,
+				File:         failure.File,
+				Error:        errText,
+				Timestamp:    event.Timestamp.Unix(),
+			}
+			if err := PublishMessage(ctx, cfg.Transport, ErrorsChannel(cfg.TeamID), report); err != nil {
+				log.Printf("[ERRORS] Failed to report apply failure to the team: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// ProcessErrorReport surfaces reports about our own changes failing on a teammate's node.
+func ProcessErrorReport(cfg AppConfig, payload string) {
+	var report ApplyErrorReport
+	if err := json.Unmarshal([]byte(payload), &report); err != nil {
+		log.Printf("[ERRORS] Error unmarshaling error report: %v", err)
+		return
+	}
+	if AdmitSigned(cfg, ErrorsChannel(cfg.TeamID), report.Reporter, payload) != nil {
+		return
+	}
+
+	if report.Sender != cfg.Username || report.ReporterNode == cfg.NodeID {
+		return
+	}
+
+	when := time.Unix(report.Timestamp, 0).Format("15:04:05")
+	log.Printf("[ERRORS] %s %s failed to apply your change to %s: %s", when, report.Reporter, report.File, report.Error)
+	SendNotification("Axle - Sync failure",
+		fmt.Sprintf("%s failed to apply your change to %s", report.Reporter, report.File))
+}
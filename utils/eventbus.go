@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// EventTopic identifies a category of daemon events.
+type EventTopic string
+
+const (
+	TopicFileChanged     EventTopic = "file.changed"     // Watcher accepted a file event
+	TopicBatchCommitted  EventTopic = "batch.committed"  // A local batch was committed
+	TopicBatchPublished  EventTopic = "batch.published"  // A batch was published to the team
+	TopicBatchApplied    EventTopic = "batch.applied"    // An incoming batch was applied
+	TopicApplyFailed     EventTopic = "batch.failed"     // An incoming change failed to apply
+	TopicPresenceChanged EventTopic = "presence.changed" // A peer announced, heartbeated, left, or expired
+	TopicChatReceived    EventTopic = "chat.received"    // A chat message arrived
+	TopicRedisFailover   EventTopic = "redis.failover"   // Redis connections moved to another server
+	TopicNetworkChanged  EventTopic = "network.changed"  // The machine moved to another network
+)
+
+// AllTopics lists every topic, e.g. for subscribers that want everything.
+var AllTopics = []EventTopic{
+	TopicFileChanged, TopicBatchCommitted, TopicBatchPublished, TopicBatchApplied,
+	TopicApplyFailed, TopicPresenceChanged, TopicChatReceived, TopicRedisFailover,
+	TopicNetworkChanged,
+}
+
+// eventBufferSize is the per-subscriber queue length. Slow subscribers drop
+// events rather than stall the publisher.
+const eventBufferSize = 64
+
+// Event is a single message delivered on the event bus.
+type Event struct {
+	Topic     EventTopic
+	Timestamp time.Time
+	Payload   interface{}
+}
+
+// FileChangedEvent is the payload for TopicFileChanged.
+type FileChangedEvent struct {
+	Path    string // Path relative to the sync root
+	Event   string // "created", "modified", "deleted", "renamed", or "touched"
+	TraceID string
+}
+
+// BatchCommittedEvent is the payload for TopicBatchCommitted.
+type BatchCommittedEvent struct {
+	CommitHash string
+	Files      []string
+	TraceIDs   []string // Parallel to Files
+}
+
+// BatchPublishedEvent is the payload for TopicBatchPublished.
+type BatchPublishedEvent struct {
+	Metadata SyncMetadata
+}
+
+// BatchAppliedEvent is the payload for TopicBatchApplied.
+type BatchAppliedEvent struct {
+	PeerID   string
+	BatchID  string
+	Files    []string
+	TraceIDs []string // Parallel to Files
+}
+
+// ApplyFailedEvent is the payload for TopicApplyFailed.
+type ApplyFailedEvent struct {
+	PeerID  string
+	BatchID string
+	File    string
+	TraceID string
+	Err     error
+}
+
+// MarshalJSON renders the error as a string for control socket subscribers.
+func (e ApplyFailedEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		PeerID  string
+		BatchID string
+		File    string
+		TraceID string
+		Err     string
+	}{e.PeerID, e.BatchID, e.File, e.TraceID, fmt.Sprint(e.Err)})
+}
+
+// PresenceChangedEvent is the payload for TopicPresenceChanged.
+type PresenceChangedEvent struct {
+	Message PresenceMessage
+}
+
+// ChatReceivedEvent is the payload for TopicChatReceived.
+type ChatReceivedEvent struct {
+	Message ChatMessage
+}
+
+// RedisFailoverEvent is the payload for TopicRedisFailover.
+type RedisFailoverEvent struct {
+	From RedisEndpoint
+	To   RedisEndpoint
+}
+
+// EventBus is an in-process publish/subscribe hub that decouples the daemon's
+// subsystems (watcher, batcher, publisher, applier, presence, chat) from each
+// other and lets additional subsystems observe them.
+type EventBus struct {
+	mu   sync.RWMutex
+	subs map[EventTopic][]chan Event
+}
+
+// NewEventBus creates an empty event bus.
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[EventTopic][]chan Event)}
+}
+
+// Events is the daemon-wide event bus.
+var Events = NewEventBus()
+
+// Subscribe returns a channel receiving events for the given topics and a
+// function that cancels the subscription and closes the channel.
+func (b *EventBus) Subscribe(topics ...EventTopic) (<-chan Event, func()) {
+	ch := make(chan Event, eventBufferSize)
+
+	b.mu.Lock()
+	for _, topic := range topics {
+		b.subs[topic] = append(b.subs[topic], ch)
+	}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			for _, topic := range topics {
+				subs := b.subs[topic]
+				for i, sub := range subs {
+					if sub == ch {
+						b.subs[topic] = append(subs[:i], subs[i+1:]...)
+						break
+					}
+				}
+			}
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Publish delivers an event to every subscriber of its topic without blocking.
+func (b *EventBus) Publish(topic EventTopic, payload interface{}) {
+	event := Event{Topic: topic, Timestamp: time.Now(), Payload: payload}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, ch := range b.subs[topic] {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("[EVENTS] Subscriber queue full, dropping %s event", topic)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Team feature toggles, set with 'axle team settings'. A feature the team
+// config doesn't mention has its default: on for the settings below, off
+// for new protocol features, which teams turn on once their members have
+// upgraded.
+const (
+	FeatureSyncDeletes     = "sync.deletes"        // Propagate file deletions
+	FeatureChatEnabled     = "chat.enabled"        // Send and show team chat
+	FeaturePresenceEnabled = "presence.enabled"    // Send heartbeats and track who is online
+	FeatureSyncBinary      = "sync.binary"         // Sync binary files through the blob store
+	FeatureConflictsBlock  = "conflicts.block"     // Hold local changes to files with conflict markers
+	FeatureSyncCompress    = "sync.compress"       // Compress patches in published batches
+	FeatureSignedMessages  = "security.signatures" // Drop unsigned sync and chat messages
+)
+
+// TeamFeature describes a feature toggle for 'axle team settings'.
+type TeamFeature struct {
+	Description string
+	Default     bool // Whether the feature is on when the team config doesn't say
+	// Running daemons only pick up a change when restarted; others apply
+	// it within teamCheckInterval
+	Restart bool
+}
+
+// TeamFeatures lists the feature toggles this release knows.
+var TeamFeatures = map[string]TeamFeature{
+	FeatureSyncDeletes:     {Description: "Propagate file deletions to teammates", Default: true},
+	FeatureChatEnabled:     {Description: "Team chat through 'axle chat'", Default: true, Restart: true},
+	FeaturePresenceEnabled: {Description: "Heartbeats and online status in 'axle team'", Default: true, Restart: true},
+	FeatureSyncBinary:      {Description: "Sync binary files (images, fonts, builds) through the blob store", Default: true},
+	FeatureConflictsBlock:  {Description: "Hold local changes to files with unresolved conflict markers until they're resolved", Default: true},
+	FeatureSyncCompress:    {Description: "Compress patches in published batches for teammates that can read them", Default: true},
+	FeatureSignedMessages:  {Description: "Drop sync and chat messages not signed with the team password (turn off while members run releases that don't sign)", Default: true},
+}
+
+// ErrFeatureDisabled is returned when the team has turned off a feature.
+var ErrFeatureDisabled = errors.New("disabled by the team settings")
+
+// FeatureNames returns the feature toggles in a stable order.
+func FeatureNames() []string {
+	names := make([]string, 0, len(TeamFeatures))
+	for name := range TeamFeatures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateFeature checks a feature toggle name.
+func ValidateFeature(name string) error {
+	if _, ok := TeamFeatures[name]; !ok {
+		return fmt.Errorf("unknown setting %q (use: %s)", name, strings.Join(FeatureNames(), ", "))
+	}
+	return nil
+}
+
+// UnknownFeatures returns the toggles in features this release doesn't
+// know, set by a teammate on a newer release.
+func UnknownFeatures(features map[string]bool) []string {
+	var unknown []string
+	for name := range features {
+		if _, ok := TeamFeatures[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// featureEnabled reports whether a feature is on for username. A feature
+// being rolled out is on only for the members it lists; otherwise the team
+// setting applies, or the feature's default when there is none.
+func featureEnabled(features map[string]bool, rollout map[string][]string, name, username string) bool {
+	if members, ok := rollout[name]; ok {
+		return contains(members, username)
+	}
+	if enabled, ok := features[name]; ok {
+		return enabled
+	}
+	return TeamFeatures[name].Default
+}
+
+// FeatureEnabled reports whether the team config turns a feature on for the
+// whole team, leaving aside a rollout to some members.
+func (c AxleConfig) FeatureEnabled(name string) bool {
+	return featureEnabled(c.Features, nil, name, "")
+}
+
+// FeatureEnabledFor reports whether the team config turns a feature on for
+// username.
+func (c AxleConfig) FeatureEnabledFor(name, username string) bool {
+	return featureEnabled(c.Features, c.FeatureRollout, name, username)
+}
+
+var (
+	featuresMu     sync.RWMutex
+	teamFeatures   map[string]bool
+	featureRollout map[string][]string
+	// featuresWarned remembers unknown toggles already logged
+	featuresWarned = make(map[string]bool)
+)
+
+// SetFeatures registers the team's feature toggles and rollouts, so a
+// change reaches running daemons without a restart.
+func SetFeatures(features map[string]bool, rollout map[string][]string) {
+	featuresMu.Lock()
+	defer featuresMu.Unlock()
+	teamFeatures, featureRollout = features, rollout
+}
+
+// FeatureEnabled reports whether the team settings turn a feature on for
+// this member.
+func (c AppConfig) FeatureEnabled(name string) bool {
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+	return featureEnabled(teamFeatures, featureRollout, name, c.Username)
+}
+
+// refreshFeatures applies new team settings to a running daemon, logging
+// the features that changed for this member and the toggles this release
+// doesn't know.
+func refreshFeatures(cfg AppConfig, teamConfig AxleConfig) {
+	before := make(map[string]bool, len(TeamFeatures))
+	for name := range TeamFeatures {
+		before[name] = cfg.FeatureEnabled(name)
+	}
+	SetFeatures(teamConfig.Features, teamConfig.FeatureRollout)
+
+	for _, name := range FeatureNames() {
+		enabled := cfg.FeatureEnabled(name)
+		if enabled == before[name] {
+			continue
+		}
+		state := "off"
+		if enabled {
+			state = "on"
+		}
+		if TeamFeatures[name].Restart {
+			log.Printf("[FEATURES] The team turned %s %s; restart 'axle start' to apply it", name, state)
+		} else {
+			log.Printf("[FEATURES] The team turned %s %s", name, state)
+		}
+	}
+
+	featuresMu.Lock()
+	defer featuresMu.Unlock()
+	for _, name := range UnknownFeatures(teamConfig.Features) {
+		if featuresWarned[name] {
+			continue
+		}
+		featuresWarned[name] = true
+		log.Printf("[FEATURES] ⚠️  The team settings set %s, which this release doesn't know; upgrade Axle to use it", name)
+	}
+}
+
+// FilterDisabledChanges removes what the team settings don't sync from a
+// batch. Both sides filter, so a member with stale settings can neither
+// send nor receive a disabled kind of change.
+func FilterDisabledChanges(cfg AppConfig, changes []FileChange) []FileChange {
+	if cfg.FeatureEnabled(FeatureSyncDeletes) {
+		return changes
+	}
+
+	filtered := make([]FileChange, 0, len(changes))
+	var dropped int
+	for _, change := range changes {
+		// A rename without a patch would delete the old path
+		if change.Event == "deleted" || (change.Event == EventRenamed && change.Patch == "") {
+			dropped++
+			continue
+		}
+		// Batch commits carry the whole commit, deletions of other files included
+		if change.Patch != "" {
+			if err := DecodeChange(&change); err != nil {
+				log.Printf("[SYNC] Dropping %s: %v", change.File, err)
+				dropped++
+				continue
+			}
+			change.Patch = stripDeletions(change.Patch)
+		}
+		filtered = append(filtered, change)
+	}
+	if dropped > 0 {
+		log.Printf("[SYNC] Not syncing %d deletion(s): %s is off for this team", dropped, FeatureSyncDeletes)
+	}
+	return filtered
+}
+
+// stripDeletions removes the sections of a patch that delete a file.
+func stripDeletions(patch string) string {
+	if !strings.Contains(patch, "\ndeleted file mode ") {
+		return patch
+	}
+
+	lines := strings.SplitAfter(patch, "\n")
+	var out, section strings.Builder
+	var inSection, deletion, kept bool
+	flush := func() {
+		if inSection && !deletion {
+			out.WriteString(section.String())
+			kept = true
+		}
+		section.Reset()
+		inSection, deletion = false, false
+	}
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			inSection = true
+		case line == "-- \n" && inSection:
+			// format-patch signature after the last file
+			flush()
+		}
+		if !inSection {
+			out.WriteString(line)
+			continue
+		}
+		if strings.HasPrefix(line, "deleted file mode ") {
+			deletion = true
+		}
+		section.WriteString(line)
+	}
+	flush()
+
+	if !kept {
+		return ""
+	}
+	return out.String()
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// fileStatsFlushInterval batches per-file counters into one Redis round
+// trip instead of one per published change
+const fileStatsFlushInterval = 30 * time.Second
+
+// FileStat is how often one file was synced and conflicted, team-wide.
+type FileStat struct {
+	File      string
+	Syncs     int64
+	Bytes     int64
+	Conflicts int64
+}
+
+// Per-file counters, each a sorted set of file -> count for the whole team
+func fileSyncsKey(teamID string) string {
+	return fmt.Sprintf("axle:stats:%s:files:syncs", teamID)
+}
+
+func fileBytesKey(teamID string) string {
+	return fmt.Sprintf("axle:stats:%s:files:bytes", teamID)
+}
+
+func fileConflictsKey(teamID string) string {
+	return fmt.Sprintf("axle:stats:%s:files:conflicts", teamID)
+}
+
+// conflictedFiles collects the files that conflicted since the last flush.
+// Conflicts are detected deep in the apply path, which has no config.
+var (
+	conflictedFilesMu sync.Mutex
+	conflictedFiles   = make(map[string]int64)
+)
+
+// countConflict records a conflict on files for the next health report and
+// the per-file stats. Rejected hunk files count for the file they belong to.
+func countConflict(files ...string) {
+	conflictCount.Add(1)
+	conflictedFilesMu.Lock()
+	defer conflictedFilesMu.Unlock()
+	for _, file := range files {
+		conflictedFiles[filepath.ToSlash(strings.TrimSuffix(file, ".rej"))]++
+	}
+}
+
+// changeBytes estimates what a change put on the wire.
+func changeBytes(change FileChange) int64 {
+	if change.Size > 0 {
+		return change.Size
+	}
+	return int64(len(change.Patch) + len(change.Data))
+}
+
+// StartFileStatsRecorder adds this node's published changes and the
+// conflicts it hits to the team's per-file stats for 'axle stats --files'.
+// Each change is counted once, by the member who published it.
+func StartFileStatsRecorder(ctx context.Context, cfg AppConfig) {
+	events, unsubscribe := Events.Subscribe(TopicBatchPublished)
+	defer unsubscribe()
+	ticker := time.NewTicker(fileStatsFlushInterval)
+	defer ticker.Stop()
+
+	syncs := make(map[string]int64)
+	bytes := make(map[string]int64)
+	flush := func() {
+		conflictedFilesMu.Lock()
+		conflicts := conflictedFiles
+		conflictedFiles = make(map[string]int64)
+		conflictedFilesMu.Unlock()
+		if len(syncs) == 0 && len(conflicts) == 0 {
+			return
+		}
+
+		// Use a fresh context so the final flush still runs on shutdown
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		pipe := cfg.RedisClient.TxPipeline()
+		for file, n := range syncs {
+			pipe.ZIncrBy(flushCtx, fileSyncsKey(cfg.TeamID), float64(n), file)
+			pipe.ZIncrBy(flushCtx, fileBytesKey(cfg.TeamID), float64(bytes[file]), file)
+		}
+		for file, n := range conflicts {
+			pipe.ZIncrBy(flushCtx, fileConflictsKey(cfg.TeamID), float64(n), file)
+		}
+		if _, err := pipe.Exec(flushCtx); err != nil {
+			log.Printf("[STATS] Failed to record file stats: %v", err)
+			return
+		}
+		syncs = make(map[string]int64)
+		bytes = make(map[string]int64)
+	}
+
+	for {
+		select {
+		case event := <-events:
+			if payload, ok := event.Payload.(BatchPublishedEvent); ok {
+				for _, change := range payload.Metadata.Changes {
+					syncs[change.File]++
+					bytes[change.File] += changeBytes(change)
+				}
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			flush()
+			return
+		}
+	}
+}
+
+// TopSyncedFiles returns the n files synced most often, with their bytes and
+// conflicts.
+func TopSyncedFiles(ctx context.Context, cfg AppConfig, n int) ([]FileStat, error) {
+	return topFiles(ctx, cfg, fileSyncsKey(cfg.TeamID), n)
+}
+
+// TopConflictedFiles returns the n files that conflicted most often.
+func TopConflictedFiles(ctx context.Context, cfg AppConfig, n int) ([]FileStat, error) {
+	return topFiles(ctx, cfg, fileConflictsKey(cfg.TeamID), n)
+}
+
+// topFiles ranks files by the counter in key and fills in the others.
+func topFiles(ctx context.Context, cfg AppConfig, key string, n int) ([]FileStat, error) {
+	ranked, err := cfg.RedisClient.ZRevRangeWithScores(ctx, key, 0, int64(n)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file stats: %w", err)
+	}
+	if len(ranked) == 0 {
+		return nil, nil
+	}
+
+	pipe := cfg.RedisClient.Pipeline()
+	syncs := make([]*redis.FloatCmd, len(ranked))
+	bytes := make([]*redis.FloatCmd, len(ranked))
+	conflicts := make([]*redis.FloatCmd, len(ranked))
+	for i, z := range ranked {
+		file := z.Member.(string)
+		syncs[i] = pipe.ZScore(ctx, fileSyncsKey(cfg.TeamID), file)
+		bytes[i] = pipe.ZScore(ctx, fileBytesKey(cfg.TeamID), file)
+		conflicts[i] = pipe.ZScore(ctx, fileConflictsKey(cfg.TeamID), file)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read file stats: %w", err)
+	}
+
+	stats := make([]FileStat, len(ranked))
+	for i, z := range ranked {
+		stats[i] = FileStat{
+			File:      z.Member.(string),
+			Syncs:     int64(syncs[i].Val()),
+			Bytes:     int64(bytes[i].Val()),
+			Conflicts: int64(conflicts[i].Val()),
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Kinds of problems a fuzz run can find.
+const (
+	FuzzCrash  = "crash"  // The code under test panicked
+	FuzzHang   = "hang"   // The code under test did not return within the timeout
+	FuzzEscape = "escape" // A patch wrote outside the sandbox repository
+)
+
+// FuzzOptions configures a fuzz run.
+type FuzzOptions struct {
+	Iterations int
+	Seed       int64
+	Timeout    time.Duration  // Per input; taking longer counts as a hang
+	OutputDir  string         // Where inputs that caused findings are saved, empty to not save them
+	Progress   func(runs int) // Called after every input, may be nil
+}
+
+// FuzzFinding is an input that crashed, hung, or escaped the sandbox.
+type FuzzFinding struct {
+	Kind    string
+	Target  string // What was being fuzzed, e.g. "ApplyPatch" or a message type
+	Detail  string
+	Input   []byte
+	SavedTo string
+}
+
+// FuzzReport summarizes a fuzz run.
+type FuzzReport struct {
+	Runs     int
+	Accepted int // Inputs the code under test accepted
+	Rejected int // Inputs rejected with an error, the expected outcome for garbage
+	Findings []FuzzFinding
+}
+
+// fuzzOutcome is the result of running one input.
+type fuzzOutcome struct {
+	err   error
+	panic string
+}
+
+// runFuzzCase runs fn, turning a panic or a timeout into a finding kind.
+// On a hang the goroutine running fn is abandoned.
+func runFuzzCase(timeout time.Duration, fn func() error) (kind, detail string, err error) {
+	done := make(chan fuzzOutcome, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fuzzOutcome{panic: fmt.Sprintf("%v\n%s", r, debug.Stack())}
+			}
+		}()
+		done <- fuzzOutcome{err: fn()}
+	}()
+
+	select {
+	case outcome := <-done:
+		if outcome.panic != "" {
+			return FuzzCrash, outcome.panic, nil
+		}
+		return "", "", outcome.err
+	case <-time.After(timeout):
+		return FuzzHang, fmt.Sprintf("no result after %v", timeout), nil
+	}
+}
+
+// record adds a finding to the report, saving its input for reproduction.
+func (r *FuzzReport) record(opts FuzzOptions, finding FuzzFinding, ext string) {
+	if opts.OutputDir != "" {
+		if err := os.MkdirAll(opts.OutputDir, 0755); err == nil {
+			name := fmt.Sprintf("%s-%s-%d-%d%s", finding.Kind, sanitizeFuzzName(finding.Target), opts.Seed, r.Runs, ext)
+			path := filepath.Join(opts.OutputDir, name)
+			if err := os.WriteFile(path, finding.Input, 0644); err == nil {
+				finding.SavedTo = path
+			}
+		}
+	}
+	r.Findings = append(r.Findings, finding)
+}
+
+// sanitizeFuzzName makes a target name safe to use in a file name.
+func sanitizeFuzzName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// weirdStrings are values that tend to break parsers and path handling.
+var weirdStrings = []string{
+	"",
+	"..",
+	"../../outside/pwned",
+	"/etc/passwd",
+	"C:\\Windows\\System32",
+	".git/config",
+	"a\x00b",
+	"\xff\xfe\xfd",
+	"%2e%2e%2f",
+	strings.Repeat("A", 70000),
+	"\n\n\n",
+	"diff --git a/x b/x",
+	"🙂",
+}
+
+// mutateBytes applies a random byte-level mutation.
+func mutateBytes(rng *rand.Rand, data []byte) []byte {
+	out := append([]byte(nil), data...)
+	if len(out) == 0 {
+		return []byte(weirdStrings[rng.Intn(len(weirdStrings))])
+	}
+
+	switch rng.Intn(5) {
+	case 0: // Flip a bit
+		i := rng.Intn(len(out))
+		out[i] ^= 1 << uint(rng.Intn(8))
+	case 1: // Truncate
+		out = out[:rng.Intn(len(out))]
+	case 2: // Overwrite a byte with an interesting one
+		interesting := []byte{0, '\n', '\r', '"', '\\', '/', '.', '{', '[', 0xff}
+		out[rng.Intn(len(out))] = interesting[rng.Intn(len(interesting))]
+	case 3: // Duplicate a slice
+		start := rng.Intn(len(out))
+		end := start + rng.Intn(len(out)-start+1)
+		at := rng.Intn(len(out) + 1)
+		chunk := append([]byte(nil), out[start:end]...)
+		out = append(out[:at], append(chunk, out[at:]...)...)
+	case 4: // Insert a weird string
+		at := rng.Intn(len(out) + 1)
+		weird := []byte(weirdStrings[rng.Intn(len(weirdStrings))])
+		out = append(out[:at], append(weird, out[at:]...)...)
+	}
+	return out
+}
@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// patchSandbox is a throwaway repository that fuzzed patches are applied to.
+// A sibling directory sits next to it; any file appearing there means a
+// patch escaped the repository.
+type patchSandbox struct {
+	dir     string
+	repo    string
+	outside string
+	base    string // Commit every run is reset to
+}
+
+// sandboxFiles seed the sandbox repository.
+var sandboxFiles = map[string]string{
+	"README.md":      "# Sandbox\n\nFuzzing target.\n",
+	"src/main.go":    "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n",
+	"data/notes.txt": "one\ntwo\nthree\nfour\nfive\n",
+}
+
+// newPatchSandbox creates a sandbox repository with a few committed files.
+func newPatchSandbox() (*patchSandbox, error) {
+	dir, err := os.MkdirTemp("", "axle-fuzz-")
+	if err != nil {
+		return nil, err
+	}
+	sb := &patchSandbox{dir: dir, repo: filepath.Join(dir, "repo"), outside: filepath.Join(dir, "outside")}
+	for _, d := range []string{sb.repo, sb.outside} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			sb.Close()
+			return nil, err
+		}
+	}
+	// Commits in the sandbox must work without the user's git identity or signing setup
+	if out, err := sb.git("init", "-q"); err != nil {
+		sb.Close()
+		return nil, fmt.Errorf("failed to initialize sandbox: %s", out)
+	}
+	for key, value := range map[string]string{"user.name": "Axle Fuzzer", "user.email": "fuzz@axle.invalid", "commit.gpgsign": "false"} {
+		sb.git("config", key, value)
+	}
+	if err := InitGitRepo(sb.repo); err != nil {
+		sb.Close()
+		return nil, err
+	}
+	for name, content := range sandboxFiles {
+		path := filepath.Join(sb.repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			sb.Close()
+			return nil, err
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			sb.Close()
+			return nil, err
+		}
+	}
+	if sb.base, err = CommitChanges(sb.repo, "Sandbox files"); err != nil {
+		sb.Close()
+		return nil, err
+	}
+	return sb, nil
+}
+
+// Close removes the sandbox.
+func (sb *patchSandbox) Close() {
+	os.RemoveAll(sb.dir)
+}
+
+// git runs a git command in the sandbox repository.
+func (sb *patchSandbox) git(args ...string) (string, error) {
+	cmd := GitCommand(append([]string{"-C", sb.repo}, args...)...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.String(), err
+}
+
+// reset restores the sandbox to its base commit.
+func (sb *patchSandbox) reset() error {
+	sb.git("am", "--abort")
+	if out, err := sb.git("reset", "--hard", "-q", sb.base); err != nil {
+		return fmt.Errorf("failed to reset sandbox: %s", out)
+	}
+	if out, err := sb.git("clean", "-fdxq"); err != nil {
+		return fmt.Errorf("failed to clean sandbox: %s", out)
+	}
+	return nil
+}
+
+// escaped reports the files that appeared outside the repository.
+func (sb *patchSandbox) escaped() []string {
+	var found []string
+	filepath.Walk(sb.outside, func(path string, info os.FileInfo, err error) error {
+		if err == nil && path != sb.outside {
+			found = append(found, path)
+		}
+		return nil
+	})
+	for _, path := range found {
+		os.RemoveAll(path)
+	}
+	return found
+}
+
+// seedPatches generates valid patches against the sandbox, both plain diffs
+// and format-patch output, to mutate from.
+func (sb *patchSandbox) seedPatches() ([]string, error) {
+	edits := []map[string]string{
+		{"README.md": "# Sandbox\n\nFuzzing target, edited.\n"},
+		{"data/notes.txt": "one\n2\nthree\nfour\nfive\nsix\n"},
+		{"src/main.go": "package main\n\nfunc main() {\n\tprintln(\"bye\")\n}\n", "new.txt": "created\n"},
+	}
+
+	var seeds []string
+	for _, edit := range edits {
+		for name, content := range edit {
+			path := filepath.Join(sb.repo, filepath.FromSlash(name))
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := sb.git("add", "-A"); err != nil {
+			return nil, err
+		}
+		diff, err := sb.git("diff", "--cached")
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff sandbox: %s", diff)
+		}
+		seeds = append(seeds, diff)
+
+		hash, err := CommitChanges(sb.repo, "Seed edit")
+		if err != nil {
+			return nil, err
+		}
+		formatted, err := GetPatch(sb.repo, hash)
+		if err != nil {
+			return nil, err
+		}
+		seeds = append(seeds, formatted)
+
+		if err := sb.reset(); err != nil {
+			return nil, err
+		}
+	}
+	return seeds, nil
+}
+
+// maliciousPatchLines are header lines that try to leave the repository.
+var maliciousPatchLines = []string{
+	"diff --git a/../outside/pwned b/../outside/pwned",
+	"+++ b/../outside/pwned",
+	"--- a/../../outside/pwned",
+	"rename from README.md",
+	"rename to ../outside/pwned",
+	"+++ b/.git/hooks/post-commit",
+	"diff --git a/.axle/state.json b/.axle/state.json",
+	"new file mode 120000",
+	"deleted file mode 100644",
+	"GIT binary patch",
+	"@@ -1,99999 +1,99999 @@",
+	"@@ -0,0 +1 @@",
+	"\\ No newline at end of file",
+}
+
+// mutatePatch applies one to four random mutations to a seed patch.
+func mutatePatch(rng *rand.Rand, seeds []string, outside string) string {
+	lines := strings.Split(seeds[rng.Intn(len(seeds))], "\n")
+	for n := 1 + rng.Intn(4); n > 0; n-- {
+		i := rng.Intn(len(lines))
+		switch rng.Intn(7) {
+		case 0: // Delete a line
+			lines = append(lines[:i], lines[i+1:]...)
+		case 1: // Duplicate a line
+			lines = append(lines[:i+1], append([]string{lines[i]}, lines[i+1:]...)...)
+		case 2: // Insert a malicious header
+			lines = append(lines[:i], append([]string{maliciousPatchLines[rng.Intn(len(maliciousPatchLines))]}, lines[i:]...)...)
+		case 3: // Point a header at the directory outside the sandbox
+			lines = append(lines[:i], append([]string{"+++ " + filepath.ToSlash(filepath.Join(outside, "pwned"))}, lines[i:]...)...)
+		case 4: // Splice in a line from another seed
+			other := strings.Split(seeds[rng.Intn(len(seeds))], "\n")
+			lines[i] = other[rng.Intn(len(other))]
+		case 5: // Byte-level damage to a line
+			lines[i] = string(mutateBytes(rng, []byte(lines[i])))
+		case 6: // Truncate the patch
+			lines = lines[:i+1]
+		}
+		if len(lines) == 0 {
+			lines = []string{""}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FuzzPatch applies generated and mutated patches with validatePatch and
+// ApplyPatch in a sandbox repository, reporting crashes, hangs, and patches
+// that write outside the repository.
+func FuzzPatch(opts FuzzOptions) (FuzzReport, error) {
+	var report FuzzReport
+
+	sb, err := newPatchSandbox()
+	if err != nil {
+		return report, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	defer func() { sb.Close() }()
+
+	seeds, err := sb.seedPatches()
+	if err != nil {
+		return report, fmt.Errorf("failed to generate seed patches: %w", err)
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	for report.Runs < opts.Iterations {
+		patch := mutatePatch(rng, seeds, sb.outside)
+		report.Runs++
+
+		// ApplyPatch validates too, but validatePatch alone is cheap to check first
+		target := "validatePatch"
+		kind, detail, err := runFuzzCase(opts.Timeout, func() error { return validatePatch(patch) })
+		if kind == "" {
+			target = "ApplyPatch"
+			kind, detail, err = runFuzzCase(opts.Timeout, func() error {
+				_, err := ApplyPatch(sb.repo, patch)
+				return err
+			})
+		}
+		switch {
+		case kind != "":
+			report.record(opts, FuzzFinding{Kind: kind, Target: target, Detail: detail, Input: []byte(patch)}, ".patch")
+		case err != nil:
+			report.Rejected++
+		default:
+			report.Accepted++
+		}
+
+		if escaped := sb.escaped(); len(escaped) > 0 {
+			report.record(opts, FuzzFinding{Kind: FuzzEscape, Target: "ApplyPatch", Detail: "wrote " + strings.Join(escaped, ", "), Input: []byte(patch)}, ".patch")
+		}
+
+		// A hung git process may still be touching the sandbox, so start over
+		if kind == FuzzHang {
+			sb.Close()
+			if sb, err = newPatchSandbox(); err != nil {
+				return report, fmt.Errorf("failed to recreate sandbox: %w", err)
+			}
+		} else if err := sb.reset(); err != nil {
+			return report, err
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(report.Runs)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,261 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// protocolTarget is a message type received from Redis or the control
+// socket, with the decoding and validation that runs on it in production.
+// decode calls the functions the handlers call, never copies of them, so
+// the fuzzer checks what actually runs.
+type protocolTarget struct {
+	name   string
+	sample interface{}
+	decode func(data []byte) error
+}
+
+// decodeInto unmarshals data into a fresh T.
+func decodeInto[T any](data []byte) (T, error) {
+	var msg T
+	err := json.Unmarshal(data, &msg)
+	return msg, err
+}
+
+// protocolTargets lists every message type Axle unmarshals from peers.
+var protocolTargets = []protocolTarget{
+	{
+		name: "SyncMetadata",
+		sample: SyncMetadata{Version: 1, BatchID: "b_1", Timestamp: 1700000000, PeerID: "alice", AuthorName: "Alice", AuthorEmail: "alice@example.com", Changes: []FileChange{
+			{File: "src/main.go", Event: "modified", CommitHash: "abc123", Patch: "diff --git a/src/main.go b/src/main.go\n--- a/src/main.go\n+++ b/src/main.go\n@@ -1 +1 @@\n-a\n+b\n", TraceID: "tr_000000000000"},
+			{File: "logs/app.log", Event: "appended", Offset: 12, Data: base64.StdEncoding.EncodeToString([]byte("line\n")), Hash: "deadbeef"},
+			{File: "assets/big.bin", Event: "placeholder", Size: 1 << 30, Hash: "cafe", Owner: "alice", OwnerNode: "node_1"},
+			{File: "old.txt", Event: "deleted"},
+			{File: "docs/guide.md", Event: EventRenamed, From: "guide.md", CommitHash: "abc124"},
+			{File: "build.sh", Event: EventChmod, Mode: "100755", CommitHash: "abc124"},
+			{File: "README.md", Event: EventTouched, Mtime: 1700000000000000000},
+			{File: "assets/empty", Event: EventMkdir},
+			{File: "src/util.go", Event: "created", Patch: "H4sIAAAAAAAA/wAnANj/ZGlmZiAtLWdpdCBhL3NyYy91dGlsLmdvIGIvc3JjL3V0aWwuZ28KAwBhuAuyJwAAAA==", Encoding: EncodingGzip},
+		}},
+		decode: func(data []byte) error {
+			syncMeta, err := decodeInto[SyncMetadata](data)
+			if err != nil {
+				return err
+			}
+			for _, change := range syncMeta.Changes {
+				if err := ValidateIncomingChange(&change); err != nil {
+					return err
+				}
+			}
+			ProtectedFiles(syncMeta.Changes, []string{"*.env", "secrets/**"})
+			return nil
+		},
+	},
+	{
+		name:   "ChatMessage",
+		sample: ChatMessage{Sender: "alice", Message: "/me is deploying", Timestamp: 1700000000, Priority: true},
+		decode: func(data []byte) error {
+			_, err := decodeInto[ChatMessage](data)
+			return err
+		},
+	},
+	{
+		name:   "PresenceMessage",
+		sample: PresenceMessage{Type: "heartbeat", NodeID: "node_1", Username: "alice", IPAddress: "10.0.0.2", Timestamp: 1700000000, LatencyMs: 12.5, Region: "eu-west"},
+		decode: func(data []byte) error {
+			_, err := decodeInto[PresenceMessage](data)
+			return err
+		},
+	},
+	{
+		name:   "SnapshotRequest",
+		sample: SnapshotRequest{RequestID: "snap_1", Requester: "bob", NodeID: "node_2", Timestamp: 1700000000},
+		decode: func(data []byte) error {
+			_, err := decodeInto[SnapshotRequest](data)
+			return err
+		},
+	},
+	{
+		name:   "ResyncRequest",
+		sample: ResyncRequest{Type: "files", RequestID: "resync_1", Requester: "bob", NodeID: "node_2", From: "alice", Paths: []string{"src/main.go"}, Timestamp: 1700000000},
+		decode: func(data []byte) error {
+			req, err := decodeInto[ResyncRequest](data)
+			if err != nil {
+				return err
+			}
+			return validateResyncPaths(req.Paths)
+		},
+	},
+	{
+		name:   "HealthReport",
+		sample: HealthReport{Username: "bob", NodeID: "node_2", IntervalSeconds: 300, FilesSent: 12, FilesReceived: 30, FailedChanges: 1, Conflicts: 2, LatencyMs: 45, PublisherState: "normal", Timestamp: 1700000000},
+		decode: func(data []byte) error {
+			_, err := decodeInto[HealthReport](data)
+			return err
+		},
+	},
+	{
+		name:   "FetchRequest",
+		sample: FetchRequest{RequestID: "fetch_1", Path: "assets/big.bin", Hash: HashContent([]byte("big")), Requester: "bob", NodeID: "node_2", OwnerNode: "node_1", Timestamp: 1700000000},
+		decode: func(data []byte) error {
+			req, err := decodeInto[FetchRequest](data)
+			if err != nil {
+				return err
+			}
+			return validateFetchRequest(req)
+		},
+	},
+	{
+		name:   "ApplyErrorReport",
+		sample: ApplyErrorReport{Reporter: "bob", ReporterNode: "node_2", Sender: "alice", File: "src/main.go", Error: "patch does not apply", Timestamp: 1700000000},
+		decode: func(data []byte) error {
+			_, err := decodeInto[ApplyErrorReport](data)
+			return err
+		},
+	},
+	{
+		name:   "BatchAck",
+		sample: BatchAck{Peer: "bob", NodeID: "node_2", Status: AckFailed, Error: "conflict", Timestamp: 1700000000, FailedTraces: []string{"tr_000000000000"}},
+		decode: func(data []byte) error {
+			_, err := decodeInto[BatchAck](data)
+			return err
+		},
+	},
+	{
+		name:   "AnnouncementMessage",
+		sample: AnnouncementMessage{Type: "announce", Announcement: Announcement{ID: 3, Text: "Switching API port to 8081", Author: "alice", Timestamp: 1700000000, RequireAck: true, Recipients: []string{"bob"}}, Timestamp: 1700000000},
+		decode: func(data []byte) error {
+			_, err := decodeInto[AnnouncementMessage](data)
+			return err
+		},
+	},
+	{
+		name:   "ControlRequest",
+		sample: ControlRequest{Command: "chat", Args: map[string]string{"message": "hi", "priority": "true"}},
+		decode: func(data []byte) error {
+			_, err := decodeInto[ControlRequest](data)
+			return err
+		},
+	},
+	{
+		name:   "TeamConfig",
+		sample: AxleConfig{TeamID: "team", AdminPublicKey: base64.StdEncoding.EncodeToString(make([]byte, 32)), Signature: base64.StdEncoding.EncodeToString(make([]byte, 64))},
+		decode: func(data []byte) error {
+			_, err := VerifyTeamConfig(data, base64.StdEncoding.EncodeToString(make([]byte, 32)))
+			return err
+		},
+	},
+}
+
+// mutateJSON replaces a random value in a JSON document with one of the wrong
+// type, size, or content, keeping the rest of the message intact.
+func mutateJSON(rng *rand.Rand, data []byte) []byte {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return mutateBytes(rng, data)
+	}
+
+	replacements := []func() interface{}{
+		func() interface{} { return weirdStrings[rng.Intn(len(weirdStrings))] },
+		func() interface{} { return -1 },
+		func() interface{} { return 1e300 },
+		func() interface{} { return nil },
+		func() interface{} { return []interface{}{"x", 1, nil} },
+		func() interface{} { return map[string]interface{}{"nested": map[string]interface{}{}} },
+		func() interface{} { return true },
+	}
+	doc = replaceRandomValue(rng, doc, replacements[rng.Intn(len(replacements))]())
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return mutateBytes(rng, data)
+	}
+	return out
+}
+
+// replaceRandomValue walks down a random path in doc and replaces the value at its end.
+func replaceRandomValue(rng *rand.Rand, doc, replacement interface{}) interface{} {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 || rng.Intn(4) == 0 {
+			return replacement
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		key := keys[rng.Intn(len(keys))]
+		v[key] = replaceRandomValue(rng, v[key], replacement)
+		return v
+	case []interface{}:
+		if len(v) == 0 || rng.Intn(4) == 0 {
+			return replacement
+		}
+		i := rng.Intn(len(v))
+		v[i] = replaceRandomValue(rng, v[i], replacement)
+		return v
+	default:
+		return replacement
+	}
+}
+
+// deeplyNested builds a document nested far beyond anything a real message needs.
+func deeplyNested(rng *rand.Rand) []byte {
+	depth := 1000 + rng.Intn(20000)
+	return []byte(strings.Repeat("[", depth) + strings.Repeat("]", depth))
+}
+
+// FuzzProtocol feeds generated and mutated messages to the decoding and
+// validation code for every message type Axle receives from peers,
+// reporting crashes and hangs.
+func FuzzProtocol(opts FuzzOptions) (FuzzReport, error) {
+	var report FuzzReport
+
+	seeds := make([][]byte, len(protocolTargets))
+	for i, target := range protocolTargets {
+		data, err := json.Marshal(target.sample)
+		if err != nil {
+			return report, fmt.Errorf("failed to encode %s sample: %w", target.name, err)
+		}
+		seeds[i] = data
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	for report.Runs < opts.Iterations {
+		i := rng.Intn(len(protocolTargets))
+		target := protocolTargets[i]
+
+		var input []byte
+		switch rng.Intn(10) {
+		case 0:
+			input = deeplyNested(rng)
+		case 1, 2, 3:
+			input = mutateBytes(rng, seeds[i])
+		default:
+			input = mutateJSON(rng, seeds[i])
+		}
+		// Stack a few more mutations now and then
+		for rng.Intn(3) == 0 {
+			input = mutateJSON(rng, input)
+		}
+		report.Runs++
+
+		kind, detail, err := runFuzzCase(opts.Timeout, func() error { return target.decode(input) })
+		switch {
+		case kind != "":
+			report.record(opts, FuzzFinding{Kind: kind, Target: target.name, Detail: detail, Input: input}, ".json")
+		case err != nil:
+			report.Rejected++
+		default:
+			report.Accepted++
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(report.Runs)
+		}
+	}
+	return report, nil
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -10,7 +11,7 @@ import (
 
 // GetGitDiff retrieves the Git diff for a given file.
 func GetGitDiff(filePath string) (string, error) {
-	cmd := exec.Command("git", "diff", filePath)
+	cmd := GitCommand("diff", filePath)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return "", fmt.Errorf("failed to get Git diff: %w", err)
@@ -21,8 +22,21 @@ func GetGitDiff(filePath string) (string, error) {
 // CommitChanges stages all changes and commits them.
 // It returns the new commit hash. If there are no changes to commit, it returns an empty string.
 func CommitChanges(directory, message string) (string, error) {
+	return CommitChangesAs(directory, message, CommitAuthor{})
+}
+
+// CommitChangesAs is CommitChanges with the commit attributed to author,
+// such as the teammate whose changes were applied.
+func CommitChangesAs(directory, message string, author CommitAuthor) (string, error) {
+	if UsingEmbeddedBackend() {
+		return "", nil // No history to record
+	}
+	if usingGitLibrary() {
+		return libraryCommit(directory, message, author)
+	}
+
 	// Stage all changes
-	addCmd := exec.Command("git", "-C", directory, "add", ".")
+	addCmd := GitCommand("-C", directory, "add", ".")
 	var addErr bytes.Buffer
 	addCmd.Stderr = &addErr
 	if err := addCmd.Run(); err != nil {
@@ -30,7 +44,7 @@ func CommitChanges(directory, message string) (string, error) {
 	}
 
 	// Commit the staged changes
-	commitCmd := exec.Command("git", "-C", directory, "commit", "-m", message)
+	commitCmd := commitCommand(directory, author, "-m", message)
 	var out bytes.Buffer
 	var stderr bytes.Buffer
 	commitCmd.Stdout = &out
@@ -60,7 +74,7 @@ func CommitChanges(directory, message string) (string, error) {
 		}
 		
 		// Check git status to provide more context
-		statusCmd := exec.Command("git", "-C", directory, "status", "--porcelain")
+		statusCmd := GitCommand("-C", directory, "status", "--porcelain")
 		if statusOutput, statusErr := statusCmd.CombinedOutput(); statusErr == nil {
 			errorDetails += fmt.Sprintf("\nGit Status: %s", string(statusOutput))
 		} else {
@@ -71,27 +85,84 @@ func CommitChanges(directory, message string) (string, error) {
 	}
 
 	// Get the commit hash of the new commit
-	hashCmd := exec.Command("git", "-C", directory, "rev-parse", "HEAD")
-	output, err := hashCmd.CombinedOutput()
+	hash, err := RevParse(directory, "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to get new commit hash: %w", err)
+	}
+	return hash, nil
+}
+
+// CommitFiles stages and commits only the given files, leaving any other
+// changes in the working tree untouched. It returns the new commit hash.
+func CommitFiles(directory, message string, files ...string) (string, error) {
+	return CommitFilesAs(directory, message, CommitAuthor{}, files...)
+}
+
+// CommitFilesAs is CommitFiles with the commit attributed to author.
+func CommitFilesAs(directory, message string, author CommitAuthor, files ...string) (string, error) {
+	if UsingEmbeddedBackend() {
+		return "", nil
+	}
+	if usingGitLibrary() {
+		return libraryCommit(directory, message, author, files...)
+	}
+
+	addArgs := append([]string{"-C", directory, "add", "--"}, files...)
+	if output, err := GitCommand(addArgs...).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to stage files (git add): %s", string(output))
+	}
+
+	commitArgs := append([]string{"-m", message, "--"}, files...)
+	if output, err := commitCommand(directory, author, commitArgs...).CombinedOutput(); err != nil {
+		if strings.Contains(string(output), "nothing to commit") || strings.Contains(string(output), "no changes added to commit") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to commit files: %s", string(output))
+	}
+
+	hash, err := RevParse(directory, "HEAD")
 	if err != nil {
 		return "", fmt.Errorf("failed to get new commit hash: %w", err)
 	}
+	return hash, nil
+}
+
+// RevParse resolves rev, such as "HEAD" or "<hash>^", to a commit hash.
+func RevParse(directory, rev string) (string, error) {
+	if usingGitLibrary() {
+		return libraryRevParse(directory, rev)
+	}
+	output, err := GitCommand("-C", directory, "rev-parse", "--verify", "--quiet", rev+"^{commit}").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
 	return strings.TrimSpace(string(output)), nil
 }
 
 // GetPatch generates a patch for a given commit.
 func GetPatch(directory, commitHash string) (string, error) {
+	if usingGitLibrary() {
+		patch, err := libraryFormatPatch(directory, commitHash)
+		if !errors.Is(err, ErrBinaryPatch) {
+			return patch, err
+		}
+		// go-git can't encode binary changes; git can
+	}
+
 	// Check if the commit has a parent. If not, it's the initial commit.
-	parentCheckCmd := exec.Command("git", "-C", directory, "rev-parse", "--verify", commitHash+"^")
-	isInitial := parentCheckCmd.Run() != nil
+	_, err := RevParse(directory, commitHash+"^")
+	isInitial := err != nil
+
+	// Axle's own state never travels, even if an old commit tracked it
+	excludeAxle := ":(exclude,glob,icase)**/" + AxleDirName + "/**"
 
 	var cmd *exec.Cmd
 	if isInitial {
 		// For the initial commit, create a patch from the root.
-		cmd = exec.Command("git", "-C", directory, "show", commitHash)
+		cmd = GitCommand("-C", directory, "show", commitHash, "--", ".", excludeAxle)
 	} else {
 		// For subsequent commits, create a patch from the previous commit.
-		cmd = exec.Command("git", "-C", directory, "format-patch", "--stdout", commitHash+"^.."+commitHash)
+		cmd = GitCommand("-C", directory, "format-patch", "--stdout", commitHash+"^.."+commitHash, "--", ".", excludeAxle)
 	}
 
 	output, err := cmd.CombinedOutput()
@@ -105,7 +176,14 @@ func GetPatch(directory, commitHash string) (string, error) {
 
 // InitGitRepo initializes a Git repository in the specified directory
 func InitGitRepo(directory string) error {
-	cmd := exec.Command("git", "-C", directory, "init")
+	if UsingEmbeddedBackend() {
+		return nil
+	}
+	if usingGitLibrary() {
+		return libraryInitRepo(directory)
+	}
+
+	cmd := GitCommand("-C", directory, "init")
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
@@ -114,17 +192,17 @@ func InitGitRepo(directory string) error {
 	}
 
 	// Check if this is a fresh repo and add an initial commit
-	statusCmd := exec.Command("git", "-C", directory, "status", "--porcelain")
+	statusCmd := GitCommand("-C", directory, "status", "--porcelain")
 	_, err := statusCmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to check git status: %v", err)
 	}
 
 	// If there are no commits yet, create an initial empty commit
-	logCmd := exec.Command("git", "-C", directory, "log", "--oneline", "-n", "1")
+	logCmd := GitCommand("-C", directory, "log", "--oneline", "-n", "1")
 	if err := logCmd.Run(); err != nil {
 		// No commits exist, create initial commit
-		initialCommitCmd := exec.Command("git", "-C", directory, "commit", "--allow-empty", "-m", "Initial commit")
+		initialCommitCmd := GitCommand("-C", directory, "commit", "--allow-empty", "-m", "Initial commit")
 		var commitStderr bytes.Buffer
 		initialCommitCmd.Stderr = &commitStderr
 
@@ -163,6 +241,15 @@ func validatePatch(patch string) error {
 				}
 			}
 
+			// Axle's state directory is local to each node
+			if patchHeaderTouchesAxleDir(line) {
+				return fmt.Errorf("patch touches Axle's reserved %s directory", AxleDirName)
+			}
+			// Generated output stays on the node that wrote it
+			if dir := patchHeaderOutputDir(line); dir != "" {
+				return fmt.Errorf("patch touches the generated-output directory %s", dir)
+			}
+
 			// Check for absolute paths (security risk)
 			if strings.Contains(line, " /") && !strings.Contains(line, " a/") && !strings.Contains(line, " b/") {
 				// Allow git's a/ and b/ prefixes but block other absolute paths
@@ -189,12 +276,22 @@ func ApplyPatch(directory, patch string) (bool, error) {
 	if err := validatePatch(patch); err != nil {
 		return false, fmt.Errorf("patch validation failed: %w", err)
 	}
+	if UsingEmbeddedBackend() {
+		return false, applyPatchEmbedded(directory, patch)
+	}
+	if usingGitLibrary() {
+		return libraryApplyPatch(directory, patch)
+	}
+	return applyPatchExec(directory, patch)
+}
 
+// applyPatchExec is ApplyPatch on the git executable.
+func applyPatchExec(directory, patch string) (bool, error) {
 	// First clean up any previous git am/rebase state
-	abortCmd := exec.Command("git", "-C", directory, "am", "--abort")
+	abortCmd := GitCommand("-C", directory, "am", "--abort")
 	abortCmd.Run() // Ignore errors - this is cleanup
 
-	rebaseAbortCmd := exec.Command("git", "-C", directory, "rebase", "--abort")
+	rebaseAbortCmd := GitCommand("-C", directory, "rebase", "--abort")
 	rebaseAbortCmd.Run() // Ignore errors - this is cleanup
 	
 	// Check if this is a format-patch style patch (has "From" header)
@@ -202,7 +299,7 @@ func ApplyPatch(directory, patch string) (bool, error) {
 	
 	if isFormatPatch {
 		// First try with --3way for repos with shared history
-		cmd := exec.Command("git", "-C", directory, "am", "--whitespace=nowarn", "--ignore-whitespace", "--3way")
+		cmd := GitCommand("-C", directory, "am", "--whitespace=nowarn", "--ignore-whitespace", "--3way")
 		cmd.Stdin = strings.NewReader(patch)
 		var out bytes.Buffer
 		cmd.Stdout = &out
@@ -223,7 +320,7 @@ func ApplyPatch(directory, patch string) (bool, error) {
 				diffPatch := patch[diffStart:]
 
 				// Apply as a regular diff without --3way
-				applyCmd := exec.Command("git", "-C", directory, "apply", "--whitespace=nowarn", "--ignore-whitespace", "-")
+				applyCmd := GitCommand("-C", directory, "apply", "--whitespace=nowarn", "--ignore-whitespace", "-")
 				applyCmd.Stdin = strings.NewReader(diffPatch)
 				var applyOut bytes.Buffer
 				applyCmd.Stdout = &applyOut
@@ -246,11 +343,11 @@ func ApplyPatch(directory, patch string) (bool, error) {
 						commitMessage = strings.TrimPrefix(commitMessage, "[PATCH] ")
 
 						// Stage all changes
-						addCmd := exec.Command("git", "-C", directory, "add", ".")
+						addCmd := GitCommand("-C", directory, "add", ".")
 						addCmd.Run()
 
 						// Commit with the extracted message
-						commitCmd := exec.Command("git", "-C", directory, "commit", "-m", commitMessage)
+						commitCmd := GitCommand("-C", directory, "commit", "-m", commitMessage)
 						commitCmd.Run()
 					}
 				}
@@ -258,14 +355,14 @@ func ApplyPatch(directory, patch string) (bool, error) {
 				return true, nil
 			} else if strings.Contains(out.String(), "would be overwritten") || strings.Contains(out.String(), "already exists") {
 				// Reset to clean state and try again
-				resetCmd := exec.Command("git", "-C", directory, "reset", "--hard", "HEAD")
+				resetCmd := GitCommand("-C", directory, "reset", "--hard", "HEAD")
 				resetCmd.Run()
 
-				cleanCmd := exec.Command("git", "-C", directory, "clean", "-fd")
+				cleanCmd := GitCommand("-C", directory, "clean", "-fd")
 				cleanCmd.Run()
 
 				// Try git am again
-				cmd2 := exec.Command("git", "-C", directory, "am", "--whitespace=nowarn", "--ignore-whitespace", "--3way")
+				cmd2 := GitCommand("-C", directory, "am", "--whitespace=nowarn", "--ignore-whitespace", "--3way")
 				cmd2.Stdin = strings.NewReader(patch)
 				var out2 bytes.Buffer
 				cmd2.Stdout = &out2
@@ -282,7 +379,7 @@ func ApplyPatch(directory, patch string) (bool, error) {
 		return true, nil
 	} else {
 		// For regular diff patches, use git apply
-		cmd := exec.Command("git", "-C", directory, "apply", "--whitespace=nowarn", "--index", "--reject", "-")
+		cmd := GitCommand("-C", directory, "apply", "--whitespace=nowarn", "--index", "--reject", "-")
 		cmd.Stdin = strings.NewReader(patch)
 		var out bytes.Buffer
 		cmd.Stdout = &out
@@ -290,7 +387,7 @@ func ApplyPatch(directory, patch string) (bool, error) {
 		
 		if err := cmd.Run(); err != nil {
 			// If that fails, try with --3way for better conflict resolution
-			cmd2 := exec.Command("git", "-C", directory, "apply", "--whitespace=nowarn", "--3way", "-")
+			cmd2 := GitCommand("-C", directory, "apply", "--whitespace=nowarn", "--3way", "-")
 			cmd2.Stdin = strings.NewReader(patch)
 			var out2 bytes.Buffer
 			cmd2.Stdout = &out2
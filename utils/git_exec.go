@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// isolatedGitVars point git at a different repository, index or object store.
+// They leak in when axle runs from a git hook or a shell inside another
+// repository, so they are never passed on to Axle's git subprocesses.
+var isolatedGitVars = []string{
+	"GIT_DIR",
+	"GIT_WORK_TREE",
+	"GIT_INDEX_FILE",
+	"GIT_OBJECT_DIRECTORY",
+	"GIT_ALTERNATE_OBJECT_DIRECTORIES",
+	"GIT_COMMON_DIR",
+	"GIT_NAMESPACE",
+	"GIT_PREFIX",
+}
+
+var (
+	gitMu   sync.RWMutex
+	gitPath = "git"
+	gitEnv  map[string]string
+
+	// Whether gitPath was looked up, and found, for UsingEmbeddedBackend
+	gitChecked bool
+	gitFound   bool
+)
+
+// SetGitConfig sets the git executable and the extra environment variables,
+// such as GIT_SSH_COMMAND or HTTPS_PROXY, used for every git subprocess. An
+// empty path means "git" from PATH.
+func SetGitConfig(path string, env map[string]string) {
+	gitMu.Lock()
+	defer gitMu.Unlock()
+	gitPath = "git"
+	if path != "" {
+		gitPath = path
+	}
+	gitEnv = env
+	gitChecked = false
+}
+
+// GitPath returns the git executable Axle runs.
+func GitPath() string {
+	gitMu.RLock()
+	defer gitMu.RUnlock()
+	return gitPath
+}
+
+// GitCommand returns a command running Axle's git with the given arguments.
+// Its environment is the process environment without variables that would
+// redirect git to another repository, in the C locale so output Axle parses
+// isn't translated, plus the configured extra variables. Callers adding
+// variables should append to Env rather than replace it.
+func GitCommand(args ...string) *exec.Cmd {
+	gitMu.RLock()
+	path, extra := gitPath, gitEnv
+	gitMu.RUnlock()
+
+	cmd := exec.Command(path, args...)
+	cmd.Env = gitEnvironment(os.Environ(), extra)
+	profileGitCommand(cmd)
+	return cmd
+}
+
+// gitEnvironment builds a git subprocess environment from base.
+func gitEnvironment(base []string, extra map[string]string) []string {
+	env := make([]string, 0, len(base)+len(extra)+1)
+	for _, entry := range base {
+		name, _, _ := strings.Cut(entry, "=")
+		if contains(isolatedGitVars, strings.ToUpper(name)) || name == "LC_ALL" {
+			continue
+		}
+		if _, overridden := extra[name]; overridden {
+			continue
+		}
+		env = append(env, entry)
+	}
+	env = append(env, "LC_ALL=C")
+
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+extra[name])
+	}
+	return env
+}
+
+// ValidateGitEnv checks configured git environment variables. Variables that
+// would point git away from the synced repository are refused.
+func ValidateGitEnv(env map[string]string) error {
+	for name := range env {
+		if name == "" || strings.ContainsAny(name, "= \t\n") {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+		if contains(isolatedGitVars, strings.ToUpper(name)) || name == "LC_ALL" {
+			return fmt.Errorf("%s can't be set for git: Axle manages it", name)
+		}
+	}
+	return nil
+}
+
+// GitVersion runs the configured git and returns its version string.
+func GitVersion() (string, error) {
+	output, err := GitCommand("--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s: %w", GitPath(), err)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(string(output), "git version ")), nil
+}
@@ -0,0 +1,439 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The embedded backend keeps Axle working on machines without git. Changes
+// are sent as whole files through the blob store instead of as patches,
+// incoming patches are applied by a built-in applier, and nothing is
+// committed: there is no local history, no checkpoints and no merging of
+// conflicting edits.
+
+// ErrGitMissing is returned by operations the embedded backend can't do.
+var ErrGitMissing = errors.New("git is not installed; Axle is running on its embedded backend, which keeps no history")
+
+// UsingEmbeddedBackend reports whether the git executable is missing, so
+// Axle runs on the embedded backend. The lookup is redone when the git
+// configuration changes.
+func UsingEmbeddedBackend() bool {
+	gitMu.Lock()
+	defer gitMu.Unlock()
+	if !gitChecked {
+		_, err := exec.LookPath(gitPath)
+		gitFound = err == nil
+		gitChecked = true
+	}
+	return !gitFound
+}
+
+// queueSnapshotBatch publishes the pending batch without git: each changed
+// file goes through the blob store in full, and deletions as they are. The
+// caller must hold batchMutex.
+func queueSnapshotBatch(cfg AppConfig) {
+	var queued []FileChange
+	var files, traceIDs []string
+	for path, event := range pendingFiles {
+		change := FileChange{File: path, Event: event, TraceID: pendingTraces[path]}
+		if event != "deleted" {
+			data, err := os.ReadFile(filepath.Join(cfg.RootDir, filepath.FromSlash(path)))
+			if err != nil {
+				// Directories, and files removed again before the batch ran
+				continue
+			}
+			hash, store, err := StoreBlob(context.Background(), cfg, data)
+			if err != nil {
+				log.Printf("[BLOB] Failed to upload %s: %v", path, err)
+				recordSkippedFile(cfg.RootDir, path, "upload failed; run 'axle force-sync' to retry")
+				continue
+			}
+			change.Event = "chunked"
+			change.Size = int64(len(data))
+			change.Hash = hash
+			change.Store = store
+		}
+		queued = append(queued, change)
+		files = append(files, path)
+		traceIDs = append(traceIDs, change.TraceID)
+	}
+	if len(queued) == 0 {
+		return
+	}
+
+	mu.Lock()
+	queueChanges(cfg.RootDir, queued...)
+	mu.Unlock()
+	Events.Publish(TopicBatchCommitted, BatchCommittedEvent{Files: files, TraceIDs: traceIDs})
+	log.Printf("[BATCH] Queued %d whole files (no git; using the embedded backend)", len(queued))
+}
+
+// embeddedManifest hashes the files under directory like 'git hash-object'
+// does, so manifests compare equal with those of peers that have git. Hidden
+// directories, .git and .axle included, are skipped.
+func embeddedManifest(directory string) (map[string]ManifestEntry, error) {
+	manifest := make(map[string]ManifestEntry)
+	err := filepath.WalkDir(directory, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != directory && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(directory, path)
+		if err != nil {
+			return nil
+		}
+		manifest[filepath.ToSlash(rel)] = ManifestEntry{Hash: gitBlobHash(data), Size: int64(len(data))}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash working tree files: %w", err)
+	}
+	return manifest, nil
+}
+
+// gitBlobHash is the object ID git gives content as a blob.
+func gitBlobHash(data []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(data))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// patchedFile is one file's part of a patch.
+type patchedFile struct {
+	oldPath, newPath string // "" for /dev/null
+	modeSet          bool   // The patch gives the new file's mode
+	executable       bool
+	binary           bool
+	hunks            []patchHunk
+}
+
+type patchHunk struct {
+	oldStart int
+	oldCount int // Line counts from the hunk header
+	newCount int
+	oldLines []string // Context and removed lines, with their line endings
+	newLines []string // Context and added lines, with their line endings
+}
+
+var (
+	// ErrPatchDoesNotApply is returned when a hunk doesn't match the file it changes.
+	ErrPatchDoesNotApply = errors.New("doesn't match the incoming change")
+	// ErrBinaryPatch is returned for binary patches, which only git applies.
+	ErrBinaryPatch = errors.New("binary patches need git")
+)
+
+// applyPatchEmbedded applies a unified diff or format-patch without git.
+func applyPatchEmbedded(directory, patch string) error {
+	_, err := applyPatchInProcess(directory, patch)
+	switch {
+	case errors.Is(err, ErrPatchDoesNotApply):
+		return fmt.Errorf("%w and this node has no git to merge it; run 'axle resync' to take the team's version", err)
+	case errors.Is(err, ErrBinaryPatch):
+		return fmt.Errorf("%w; run 'axle resync' to download the file", err)
+	}
+	return err
+}
+
+// applyPatchInProcess applies a unified diff or format-patch to the working
+// tree and returns the paths it wrote or deleted. Every hunk must match the
+// file exactly, though it may have moved; nothing is written unless the
+// whole patch applies.
+func applyPatchInProcess(directory, patch string) ([]string, error) {
+	files, err := parseFilePatches(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string][]byte)
+	removed := make(map[string]bool)
+	modes := make(map[string]os.FileMode)
+	for _, file := range files {
+		if file.binary {
+			return nil, fmt.Errorf("%s: %w", file.newPath, ErrBinaryPatch)
+		}
+		for _, path := range []string{file.oldPath, file.newPath} {
+			if path == "" {
+				continue
+			}
+			if err := validatePatchPath(path); err != nil {
+				return nil, err
+			}
+		}
+
+		var lines []string
+		mode := os.FileMode(0644)
+		if file.oldPath != "" {
+			if m, ok := modes[file.oldPath]; ok {
+				mode = m
+			} else if info, err := os.Stat(filepath.Join(directory, filepath.FromSlash(file.oldPath))); err == nil {
+				mode = info.Mode().Perm()
+			}
+			data, ok := results[file.oldPath]
+			if !ok {
+				if data, err = os.ReadFile(filepath.Join(directory, filepath.FromSlash(file.oldPath))); err != nil {
+					return nil, fmt.Errorf("%s: %w", file.oldPath, err)
+				}
+			}
+			lines = strings.SplitAfter(string(data), "\n")
+			if len(lines) > 0 && lines[len(lines)-1] == "" {
+				lines = lines[:len(lines)-1]
+			}
+		}
+
+		offset := 0
+		for _, hunk := range file.hunks {
+			at := findHunk(lines, hunk, hunk.oldStart-1+offset)
+			if at < 0 {
+				name := file.oldPath
+				if name == "" {
+					name = file.newPath
+				}
+				return nil, fmt.Errorf("%s %w", name, ErrPatchDoesNotApply)
+			}
+			updated := append([]string{}, lines[:at]...)
+			updated = append(updated, hunk.newLines...)
+			lines = append(updated, lines[at+len(hunk.oldLines):]...)
+			offset = at - (hunk.oldStart - 1) + len(hunk.newLines) - len(hunk.oldLines)
+		}
+
+		if file.oldPath != "" && file.oldPath != file.newPath {
+			removed[file.oldPath] = true
+			delete(results, file.oldPath)
+		}
+		if file.newPath != "" {
+			results[file.newPath] = []byte(strings.Join(lines, ""))
+			delete(removed, file.newPath)
+			// Without a mode line the file keeps the one it had
+			modes[file.newPath] = mode
+			if file.modeSet {
+				modes[file.newPath] = 0644
+				if file.executable {
+					modes[file.newPath] = 0755
+				}
+			}
+		}
+	}
+
+	var changed []string
+	for path := range removed {
+		if err := os.Remove(filepath.Join(directory, filepath.FromSlash(path))); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to delete %s: %w", path, err)
+		}
+		changed = append(changed, path)
+	}
+	for path, data := range results {
+		fullPath := filepath.Join(directory, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+		if err := os.WriteFile(fullPath, data, modes[path]); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		// WriteFile leaves the mode of an existing file alone
+		if err := os.Chmod(fullPath, modes[path]); err != nil {
+			return nil, fmt.Errorf("failed to change the mode of %s: %w", path, err)
+		}
+		changed = append(changed, path)
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// findHunk returns where hunk's old lines are in lines, preferring the
+// position closest to want, or -1.
+func findHunk(lines []string, hunk patchHunk, want int) int {
+	want = max(0, min(want, len(lines)))
+	matches := func(at int) bool {
+		if at < 0 || at+len(hunk.oldLines) > len(lines) {
+			return false
+		}
+		for i, line := range hunk.oldLines {
+			if lines[at+i] != line {
+				return false
+			}
+		}
+		return true
+	}
+	for distance := 0; distance <= len(lines); distance++ {
+		if matches(want - distance) {
+			return want - distance
+		}
+		if matches(want + distance) {
+			return want + distance
+		}
+	}
+	return -1
+}
+
+// parseFilePatches splits a patch into its files and hunks. Mail headers and
+// the diffstat of a format-patch are skipped.
+func parseFilePatches(patch string) ([]patchedFile, error) {
+	lines := strings.SplitAfter(patch, "\n")
+	var files []patchedFile
+	var file *patchedFile
+	var hunk *patchHunk
+	for i := 0; i < len(lines); i++ {
+		raw := lines[i]
+		line := strings.TrimRight(raw, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, patchedFile{})
+			file = &files[len(files)-1]
+			hunk = nil
+			if a, b, ok := splitDiffGitPaths(line[len("diff --git "):]); ok {
+				file.oldPath, file.newPath = a, b
+			}
+		case file == nil:
+			// Mail headers, commit message and diffstat
+		case hunk != nil && (strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "-") || strings.HasPrefix(raw, "+")) && !isHunkEnd(hunk):
+			text := strings.TrimSuffix(raw[1:], "\n") + "\n"
+			if i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\\") {
+				// "\ No newline at end of file" applies to this line
+				text = strings.TrimSuffix(text, "\n")
+			}
+			switch raw[0] {
+			case ' ':
+				hunk.oldLines = append(hunk.oldLines, text)
+				hunk.newLines = append(hunk.newLines, text)
+			case '-':
+				hunk.oldLines = append(hunk.oldLines, text)
+			case '+':
+				hunk.newLines = append(hunk.newLines, text)
+			}
+		case strings.HasPrefix(line, "\\"):
+		case strings.HasPrefix(line, "@@ "):
+			oldStart, oldCount, newCount, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			file.hunks = append(file.hunks, patchHunk{oldStart: oldStart, oldCount: oldCount, newCount: newCount})
+			hunk = &file.hunks[len(file.hunks)-1]
+		case strings.HasPrefix(line, "--- "):
+			file.oldPath = patchPath(line[4:], "a/")
+		case strings.HasPrefix(line, "+++ "):
+			file.newPath = patchPath(line[4:], "b/")
+		case strings.HasPrefix(line, "new file mode "), strings.HasPrefix(line, "new mode "):
+			file.modeSet = true
+			file.executable = strings.HasSuffix(line, "755")
+			if strings.HasPrefix(line, "new file mode ") {
+				file.oldPath = ""
+			}
+		case strings.HasPrefix(line, "deleted file mode "):
+			file.newPath = ""
+		case strings.HasPrefix(line, "rename from "):
+			file.oldPath = unquotePatchPath(line[len("rename from "):])
+		case strings.HasPrefix(line, "rename to "):
+			file.newPath = unquotePatchPath(line[len("rename to "):])
+		case strings.HasPrefix(line, "Binary files "), line == "GIT binary patch":
+			file.binary = true
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("patch contains no file changes")
+	}
+	return files, nil
+}
+
+// isHunkEnd reports whether hunk has all the lines its header announced, so
+// a following "-- " signature isn't taken for a removed line.
+func isHunkEnd(hunk *patchHunk) bool {
+	return len(hunk.oldLines) >= hunk.oldCount && len(hunk.newLines) >= hunk.newCount
+}
+
+// parseHunkHeader reads "@@ -start,count +start,count @@"; a missing count
+// is 1.
+func parseHunkHeader(line string) (oldStart, oldCount, newCount int, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, fmt.Errorf("malformed hunk header %q", line)
+	}
+	parse := func(spec string) (int, int, error) {
+		startText, countText, hasCount := strings.Cut(spec[1:], ",")
+		start, err := strconv.Atoi(startText)
+		if err != nil {
+			return 0, 0, fmt.Errorf("malformed hunk header %q", line)
+		}
+		count := 1
+		if hasCount {
+			if count, err = strconv.Atoi(countText); err != nil {
+				return 0, 0, fmt.Errorf("malformed hunk header %q", line)
+			}
+		}
+		return start, count, nil
+	}
+	if oldStart, oldCount, err = parse(fields[1]); err != nil {
+		return 0, 0, 0, err
+	}
+	if _, newCount, err = parse(fields[2]); err != nil {
+		return 0, 0, 0, err
+	}
+	// An empty old side is anchored after line start, not at it
+	if oldCount == 0 {
+		oldStart++
+	}
+	return oldStart, oldCount, newCount, nil
+}
+
+// patchPath reads a path from a ---/+++ line; /dev/null is "".
+func patchPath(value, prefix string) string {
+	value = unquotePatchPath(strings.TrimRight(value, "\t"))
+	if value == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(value, prefix)
+}
+
+// unquotePatchPath undoes git's C-style quoting of unusual paths.
+func unquotePatchPath(value string) string {
+	if strings.HasPrefix(value, "\"") {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+	}
+	return value
+}
+
+// splitDiffGitPaths splits the "a/x b/x" of a diff --git line. Paths with
+// spaces are ambiguous there; the ---/+++ and rename lines that follow
+// settle them.
+func splitDiffGitPaths(value string) (string, string, bool) {
+	if strings.HasPrefix(value, "\"") {
+		return "", "", false
+	}
+	half := len(value) / 2
+	if len(value)%2 == 1 && value[half] == ' ' {
+		a, b := value[:half], value[half+1:]
+		if strings.HasPrefix(a, "a/") && strings.HasPrefix(b, "b/") {
+			return a[2:], b[2:], true
+		}
+	}
+	a, b, ok := strings.Cut(value, " b/")
+	if !ok || !strings.HasPrefix(a, "a/") {
+		return "", "", false
+	}
+	return a[2:], b, true
+}
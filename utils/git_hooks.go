@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// InternalGitEnv is set for every git process started by Axle so the
+// post-commit hook can tell Axle's own commits apart from the user's.
+const InternalGitEnv = "AXLE_INTERNAL_COMMIT"
+
+// postCommitHookMarker identifies the lines Axle owns in .git/hooks/post-commit.
+const postCommitHookMarker = "# axle: publish manual commits to the team"
+
+func init() {
+	// Child git processes inherit this, so hooks fired by Axle's own commits are no-ops
+	os.Setenv(InternalGitEnv, "1")
+}
+
+// InstallPostCommitHook installs (or appends to) .git/hooks/post-commit so
+// commits the user makes by hand are handed to the running daemon for
+// publishing. It returns true if the hook was written.
+func InstallPostCommitHook(rootDir string) (bool, error) {
+	if UsingEmbeddedBackend() {
+		return false, nil // No commits to hook
+	}
+	hooksDir, err := gitHooksDir(rootDir)
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return false, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "axle"
+	}
+
+	hookPath := filepath.Join(hooksDir, "post-commit")
+	existing, err := os.ReadFile(hookPath)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read existing post-commit hook: %w", err)
+	}
+	if strings.Contains(string(existing), postCommitHookMarker) {
+		return false, nil
+	}
+
+	snippet := fmt.Sprintf("%s\n[ -n \"$%s\" ] || %q notify-commit \"$(git rev-parse HEAD)\" >/dev/null 2>&1 || true\n",
+		postCommitHookMarker, InternalGitEnv, filepath.ToSlash(executable))
+
+	content := "#!/bin/sh\n" + snippet
+	if len(existing) > 0 {
+		// Keep the user's own hook and append ours
+		content = strings.TrimRight(string(existing), "\n") + "\n\n" + snippet
+	}
+
+	if err := os.WriteFile(hookPath, []byte(content), 0755); err != nil {
+		return false, fmt.Errorf("failed to write post-commit hook: %w", err)
+	}
+	log.Printf("[HOOKS] Installed post-commit hook at %s", hookPath)
+	return true, nil
+}
+
+// gitHooksDir resolves the hooks directory, honoring core.hooksPath.
+func gitHooksDir(rootDir string) (string, error) {
+	output, err := GitCommand("-C", rootDir, "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate git hooks directory: %w", err)
+	}
+	hooksDir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(rootDir, hooksDir)
+	}
+	return hooksDir, nil
+}
+
+// QueueManualCommit queues the patch of a commit made outside Axle (e.g. by
+// 'git commit') for the next publish. It returns the number of files queued.
+func QueueManualCommit(cfg AppConfig, commitHash string) (int, error) {
+	// Commits replayed by a rebase or pulled by a merge are not the user's new work
+	if IsGitOperationInProgress() {
+		return 0, fmt.Errorf("a git operation is in progress; commit %s was not published", commitHash)
+	}
+	return queueCommit(cfg, commitHash)
+}
+
+// queueCommit queues the patch of a commit for the next publish, one change
+// per file it touches.
+func queueCommit(cfg AppConfig, commitHash string) (int, error) {
+	resolved, err := RevParse(cfg.RootDir, commitHash)
+	if err != nil {
+		return 0, fmt.Errorf("unknown commit %s", commitHash)
+	}
+	commitHash = resolved
+
+	described, metadataOnly, err := describeCommit(cfg, commitHash)
+	if err != nil {
+		return 0, err
+	}
+
+	var patch string
+	if !metadataOnly {
+		if patch, err = GetPatch(cfg.RootDir, commitHash); err != nil {
+			return 0, err
+		}
+	}
+
+	var queued []FileChange
+	for _, change := range described {
+		if shouldIgnore(cfg, filepath.Join(cfg.RootDir, change.File)) {
+			continue
+		}
+		change.TraceID = GenerateTraceID()
+		// The patch covers every file in the commit, so only the first change carries it
+		if len(queued) == 0 {
+			change.Patch = patch
+		}
+		queued = append(queued, change)
+	}
+
+	if len(queued) == 0 {
+		return 0, nil
+	}
+
+	mu.Lock()
+	queueChanges(cfg.RootDir, queued...)
+	mu.Unlock()
+
+	files := make([]string, 0, len(queued))
+	traceIDs := make([]string, 0, len(queued))
+	for _, change := range queued {
+		files = append(files, change.File)
+		traceIDs = append(traceIDs, change.TraceID)
+	}
+	Events.Publish(TopicBatchCommitted, BatchCommittedEvent{CommitHash: commitHash, Files: files, TraceIDs: traceIDs})
+	log.Printf("[SYNC] Queued commit %s (%d files) for publishing", shortHash(commitHash), len(queued))
+	return len(queued), nil
+}
+
+// shortHash abbreviates a commit hash for log output.
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
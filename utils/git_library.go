@@ -0,0 +1,408 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Git backends, as set by "gitBackend" in axle_config.json
+const (
+	GitBackendExec    = "exec"
+	GitBackendLibrary = "library"
+)
+
+// The library backend, the default, runs the git operations Axle does on
+// every batch in process with go-git: init, staging, commits, revision
+// lookups, tree listings, patch generation and applying patches that apply
+// cleanly. Patches that don't apply cleanly are handed to git's three-way
+// merge, and bundles, stashes and everything else still run the git
+// executable, so the library backend doesn't replace it. go-git doesn't run
+// .gitattributes filters, so files are committed exactly as they are on
+// disk.
+
+var gitBackend = GitBackendLibrary // Guarded by gitMu
+
+// SetGitBackend selects the git backend by name; "" is the library backend.
+func SetGitBackend(name string) error {
+	switch name {
+	case "":
+		name = GitBackendLibrary
+	case GitBackendExec, GitBackendLibrary:
+	default:
+		return fmt.Errorf("unknown git backend %q (use: %s or %s)", name, GitBackendExec, GitBackendLibrary)
+	}
+	gitMu.Lock()
+	defer gitMu.Unlock()
+	gitBackend = name
+	return nil
+}
+
+// GitBackend returns the configured git backend.
+func GitBackend() string {
+	gitMu.RLock()
+	defer gitMu.RUnlock()
+	return gitBackend
+}
+
+// usingGitLibrary reports whether init, commits, lookups and patches go
+// through go-git.
+func usingGitLibrary() bool {
+	return GitBackend() == GitBackendLibrary
+}
+
+// openGitRepo opens the repository directory is in.
+func openGitRepo(directory string) (*git.Repository, error) {
+	repo, err := git.PlainOpenWithOptions(directory, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open git repository in %s: %w", directory, err)
+	}
+	return repo, nil
+}
+
+// libraryInitRepo is InitGitRepo on go-git. A new repository starts on the
+// branch named by init.defaultBranch in the global git config, as with git.
+func libraryInitRepo(directory string) error {
+	repo, err := git.PlainOpen(directory)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		options := &git.PlainInitOptions{}
+		if global, err := config.LoadConfig(config.GlobalScope); err == nil && global.Init.DefaultBranch != "" {
+			options.InitOptions.DefaultBranch = plumbing.NewBranchReferenceName(global.Init.DefaultBranch)
+		}
+		repo, err = git.PlainInitWithOptions(directory, options)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to initialize git repository: %w", err)
+	}
+
+	if _, err := repo.Head(); err == nil {
+		return nil
+	} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to open working tree: %w", err)
+	}
+	if _, err := worktree.Commit("Initial commit", &git.CommitOptions{AllowEmptyCommits: true}); err != nil {
+		return fmt.Errorf("failed to create initial commit: %w", err)
+	}
+	return nil
+}
+
+// libraryCommit is CommitChangesAs, or CommitFilesAs when files are given,
+// on go-git. It returns "" when there is nothing to commit.
+func libraryCommit(directory, message string, author CommitAuthor, files ...string) (string, error) {
+	repo, err := openGitRepo(directory)
+	if err != nil {
+		return "", err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to open working tree: %w", err)
+	}
+
+	if len(files) == 0 {
+		if err := worktree.AddWithOptions(&git.AddOptions{All: true}); err != nil {
+			return "", fmt.Errorf("failed to stage changes: %w", err)
+		}
+	} else {
+		for _, file := range files {
+			if err := worktree.AddWithOptions(&git.AddOptions{Path: file}); err != nil {
+				return "", fmt.Errorf("failed to stage %s: %w", file, err)
+			}
+		}
+		// Like 'git commit -- files', leave whatever else is staged out of
+		// the commit and staged afterwards
+		staged, err := repo.Storer.Index()
+		if err != nil {
+			return "", fmt.Errorf("failed to read the index: %w", err)
+		}
+		only, err := headIndexWith(repo, staged, files)
+		if err != nil {
+			return "", err
+		}
+		if err := repo.Storer.SetIndex(only); err != nil {
+			return "", fmt.Errorf("failed to write the index: %w", err)
+		}
+		defer repo.Storer.SetIndex(staged)
+	}
+
+	options := &git.CommitOptions{}
+	if author.Name != "" {
+		options.Author = &object.Signature{Name: author.Name, Email: author.Email, When: time.Now()}
+		// The committer stays the local user
+		if local, err := repo.ConfigScoped(config.SystemScope); err == nil && local.User.Name != "" {
+			options.Committer = &object.Signature{Name: local.User.Name, Email: local.User.Email, When: time.Now()}
+		}
+	}
+	hash, err := worktree.Commit(message, options)
+	if errors.Is(err, git.ErrEmptyCommit) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to commit changes: %w", err)
+	}
+	return hash.String(), nil
+}
+
+// headIndexWith returns an index holding HEAD's files, with files as they
+// are staged in staged.
+func headIndexWith(repo *git.Repository, staged *index.Index, files []string) (*index.Index, error) {
+	only := &index.Index{Version: staged.Version}
+	entries := make(map[string]*index.Entry)
+	if head, err := repo.Head(); err == nil {
+		commit, err := repo.CommitObject(head.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read HEAD: %w", err)
+		}
+		tree, err := commit.Tree()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read HEAD: %w", err)
+		}
+		err = tree.Files().ForEach(func(file *object.File) error {
+			entries[file.Name] = &index.Entry{Name: file.Name, Hash: file.Hash, Mode: file.Mode}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list HEAD: %w", err)
+		}
+	} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	for _, file := range files {
+		file = strings.TrimPrefix(file, "./")
+		if entry, err := staged.Entry(file); err == nil {
+			entries[file] = entry
+		} else {
+			delete(entries, file) // Deleted
+		}
+	}
+	for _, entry := range entries {
+		only.Entries = append(only.Entries, entry)
+	}
+	sort.Slice(only.Entries, func(i, j int) bool { return only.Entries[i].Name < only.Entries[j].Name })
+	return only, nil
+}
+
+// libraryRevParse is RevParse on go-git.
+func libraryRevParse(directory, rev string) (string, error) {
+	repo, err := openGitRepo(directory)
+	if err != nil {
+		return "", err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	return hash.String(), nil
+}
+
+// libraryTreeManifest lists the files of the commit ref on go-git, as
+// 'git ls-tree -r -l' does.
+func libraryTreeManifest(directory, ref string) (map[string]ManifestEntry, error) {
+	repo, err := openGitRepo(directory)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tree for %s: %w", ref, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tree for %s: %w", ref, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tree for %s: %w", ref, err)
+	}
+
+	manifest := make(map[string]ManifestEntry)
+	err = tree.Files().ForEach(func(file *object.File) error {
+		manifest[file.Name] = ManifestEntry{Hash: file.Hash.String(), Size: file.Size}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tree for %s: %w", ref, err)
+	}
+	return manifest, nil
+}
+
+// libraryFormatPatch is GetPatch on go-git: a format-patch for a commit with
+// a parent and a plain diff for a root commit. Changes to Axle's own state
+// are left out. Binary changes return ErrBinaryPatch, as go-git can't
+// encode them.
+func libraryFormatPatch(directory, commitHash string) (string, error) {
+	repo, err := openGitRepo(directory)
+	if err != nil {
+		return "", err
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(commitHash))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate patch: %w", err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate patch: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate patch: %w", err)
+	}
+	var parentTree *object.Tree
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate patch: %w", err)
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return "", fmt.Errorf("failed to generate patch: %w", err)
+		}
+	}
+
+	changes, err := object.DiffTreeWithOptions(context.Background(), parentTree, tree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate patch: %w", err)
+	}
+	// Axle's own state never travels, even if an old commit tracked it
+	kept := changes[:0]
+	for _, change := range changes {
+		if !isAxlePath(change.From.Name) && !isAxlePath(change.To.Name) {
+			kept = append(kept, change)
+		}
+	}
+	if len(kept) == 0 {
+		return "", nil
+	}
+	patch, err := kept.Patch()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate patch: %w", err)
+	}
+	for _, filePatch := range patch.FilePatches() {
+		if filePatch.IsBinary() {
+			return "", ErrBinaryPatch
+		}
+	}
+
+	var buf bytes.Buffer
+	if commit.NumParents() > 0 {
+		writePatchHeader(&buf, commit)
+	}
+	if err := patch.Encode(&buf); err != nil {
+		return "", fmt.Errorf("failed to generate patch: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// writePatchHeader writes the mail header 'git format-patch' puts before the
+// diff, so 'git am' and libraryApplyPatch can commit the change as its
+// author did.
+func writePatchHeader(w io.Writer, commit *object.Commit) {
+	subject, body, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n\n")
+	author := mail.Address{Name: commit.Author.Name, Address: commit.Author.Email}
+	fmt.Fprintf(w, "From %s Mon Sep 17 00:00:00 2001\n", commit.Hash)
+	fmt.Fprintf(w, "From: %s\n", author.String())
+	fmt.Fprintf(w, "Date: %s\n", commit.Author.When.Format("Mon, 2 Jan 2006 15:04:05 -0700"))
+	fmt.Fprintf(w, "Subject: [PATCH] %s\n\n", mime.QEncoding.Encode("utf-8", strings.Join(strings.Fields(subject), " ")))
+	if body != "" {
+		fmt.Fprintf(w, "%s\n", body)
+	}
+	fmt.Fprint(w, "---\n")
+}
+
+// parsePatchHeader reads the author and commit message from the mail header
+// of a format-patch.
+func parsePatchHeader(patch string) (CommitAuthor, string, error) {
+	if strings.HasPrefix(patch, "From ") {
+		_, patch, _ = strings.Cut(patch, "\n") // The mbox separator
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(patch))
+	if err != nil {
+		return CommitAuthor{}, "", fmt.Errorf("failed to read patch header: %w", err)
+	}
+	var author CommitAuthor
+	if from, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		author = CommitAuthor{Name: from.Name, Email: from.Address}
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	if strings.HasPrefix(subject, "[PATCH") {
+		if _, rest, ok := strings.Cut(subject, "] "); ok {
+			subject = rest
+		}
+	}
+
+	message := subject
+	rest, _ := io.ReadAll(msg.Body)
+	if body, _, ok := strings.Cut(string(rest), "\n---\n"); ok {
+		if body = strings.TrimSpace(body); body != "" && !strings.HasPrefix(body, "---") {
+			message += "\n\n" + body
+		}
+	}
+	return author, message, nil
+}
+
+// libraryApplyPatch is ApplyPatch on go-git. A patch that applies cleanly is
+// written in process, then committed as its author if it is a
+// format-patch, or staged otherwise. One that doesn't, or that carries
+// binary changes, goes to git so it gets a three-way merge.
+func libraryApplyPatch(directory, patch string) (bool, error) {
+	// Only a format-patch starts with its mbox line; a plain diff may still
+	// contain "From " and "Subject:" in its content
+	isFormatPatch := strings.HasPrefix(patch, "From ")
+	var author CommitAuthor
+	var message string
+	if isFormatPatch {
+		var err error
+		if author, message, err = parsePatchHeader(patch); err != nil {
+			return false, err
+		}
+	}
+
+	changed, err := applyPatchInProcess(directory, patch)
+	if errors.Is(err, ErrPatchDoesNotApply) || errors.Is(err, ErrBinaryPatch) {
+		return applyPatchExec(directory, patch)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if isFormatPatch {
+		if _, err := libraryCommit(directory, message, author, changed...); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	repo, err := openGitRepo(directory)
+	if err != nil {
+		return false, err
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return false, fmt.Errorf("failed to open working tree: %w", err)
+	}
+	for _, path := range changed {
+		if err := worktree.AddWithOptions(&git.AddOptions{Path: path}); err != nil {
+			return false, fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+	}
+	return false, nil
+}
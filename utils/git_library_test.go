@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// withGitLibrary switches to the library backend in a new repository with
+// an initial commit, and returns its directory.
+func withGitLibrary(t *testing.T) string {
+	t.Helper()
+	if err := SetGitBackend(GitBackendLibrary); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetGitBackend("") })
+
+	directory := t.TempDir()
+	repo, err := git.PlainInit(directory, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.User.Name, cfg.User.Email = "Ada", "ada@example.com"
+	if err := repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := InitGitRepo(directory); err != nil {
+		t.Fatal(err)
+	}
+	return directory
+}
+
+func writeTestFile(t *testing.T, directory, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitLibraryCommit(t *testing.T) {
+	directory := withGitLibrary(t)
+	writeTestFile(t, directory, "a.txt", "one\n")
+
+	hash, err := CommitChangesAs(directory, "Add a", CommitAuthor{Name: "Grace", Email: "grace@example.com"})
+	if err != nil || hash == "" {
+		t.Fatalf("CommitChangesAs = %q, %v; want a commit", hash, err)
+	}
+	if head, err := RevParse(directory, "HEAD"); err != nil || head != hash {
+		t.Fatalf("RevParse(HEAD) = %q, %v; want %s", head, err, hash)
+	}
+	if _, err := RevParse(directory, hash+"^"); err != nil {
+		t.Fatalf("RevParse(%s^) failed: %v", hash, err)
+	}
+
+	manifest, err := TreeManifest(directory, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ManifestEntry{Hash: gitBlobHash([]byte("one\n")), Size: 4}
+	if len(manifest) != 1 || manifest["a.txt"] != want {
+		t.Fatalf("TreeManifest(HEAD) = %v; want only a.txt %v", manifest, want)
+	}
+
+	if hash, err := CommitChanges(directory, "Nothing"); err != nil || hash != "" {
+		t.Fatalf("CommitChanges on a clean tree = %q, %v; want nothing to commit", hash, err)
+	}
+}
+
+func TestGitLibraryCommitFiles(t *testing.T) {
+	directory := withGitLibrary(t)
+	writeTestFile(t, directory, "a.txt", "one\n")
+	writeTestFile(t, directory, "b.txt", "one\n")
+	if _, err := CommitChanges(directory, "Add a and b"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a is staged by hand; committing b leaves it staged and out of the commit
+	writeTestFile(t, directory, "a.txt", "two\n")
+	writeTestFile(t, directory, "b.txt", "two\n")
+	if output, err := GitCommand("-C", directory, "add", "a.txt").CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v: %s", err, output)
+	}
+	if _, err := CommitFiles(directory, "Change b", "b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := TreeManifest(directory, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest["a.txt"].Hash != gitBlobHash([]byte("one\n")) || manifest["b.txt"].Hash != gitBlobHash([]byte("two\n")) {
+		t.Fatalf("TreeManifest(HEAD) = %v; want the old a.txt and the new b.txt", manifest)
+	}
+	staged, err := GitCommand("-C", directory, "diff", "--cached", "--name-only").Output()
+	if err != nil || string(staged) != "a.txt\n" {
+		t.Fatalf("staged after CommitFiles = %q, %v; want a.txt", staged, err)
+	}
+
+	os.Remove(filepath.Join(directory, "b.txt"))
+	if _, err := CommitFiles(directory, "Delete b", "b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if manifest, err = TreeManifest(directory, "HEAD"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := manifest["b.txt"]; ok || len(manifest) != 1 {
+		t.Fatalf("TreeManifest(HEAD) = %v; want b.txt deleted", manifest)
+	}
+}
+
+func TestGitLibraryPatchRoundTrip(t *testing.T) {
+	from := withGitLibrary(t)
+	to := withGitLibrary(t)
+	grace := CommitAuthor{Name: "Grace Hopper", Email: "grace@example.com"}
+
+	writeTestFile(t, from, "a.txt", "one\ntwo\nthree\n")
+	writeTestFile(t, from, "b.txt", "bee\n")
+	os.MkdirAll(AxlePath(from), 0755)
+	writeTestFile(t, from, filepath.Join(AxleDirName, "state.json"), "{}\n")
+	first, err := CommitChangesAs(from, "Add a and b\n\nWith a body.", grace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err := GetPatch(from, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(patch, "From "+first) || !strings.Contains(patch, "Subject: [PATCH] Add a and b\n") {
+		t.Fatalf("GetPatch = %q; want a format-patch", patch)
+	}
+	if strings.Contains(patch, AxleDirName) {
+		t.Fatalf("GetPatch = %q; want %s left out", patch, AxleDirName)
+	}
+
+	if committed, err := ApplyPatch(to, patch); err != nil || !committed {
+		t.Fatalf("ApplyPatch = %v, %v; want it committed", committed, err)
+	}
+	repo, err := git.PlainOpen(to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commit.Author.Name != grace.Name || commit.Author.Email != grace.Email || commit.Message != "Add a and b\n\nWith a body." {
+		t.Fatalf("applied commit by %s <%s>: %q; want Grace's message", commit.Author.Name, commit.Author.Email, commit.Message)
+	}
+
+	writeTestFile(t, from, "a.txt", "one\n2\nthree\n")
+	os.Remove(filepath.Join(from, "b.txt"))
+	second, err := CommitChangesAs(from, "Change a, delete b", grace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patch, err = GetPatch(from, second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ApplyPatch(to, patch); err != nil {
+		t.Fatal(err)
+	}
+	want, err := TreeManifest(from, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := TreeManifest(to, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got["a.txt"] != want["a.txt"] {
+		t.Fatalf("TreeManifest(HEAD) after both patches = %v; want %v", got, want)
+	}
+}
+
+func TestGitLibraryApplyModeAndPlainDiff(t *testing.T) {
+	directory := withGitLibrary(t)
+	writeTestFile(t, directory, "build.sh", "echo hi\n")
+	if _, err := CommitChanges(directory, "Add build.sh"); err != nil {
+		t.Fatal(err)
+	}
+
+	chmod := "From 0000000000000000000000000000000000000000 Mon Sep 17 00:00:00 2001\n" +
+		"From: Grace <grace@example.com>\nSubject: [PATCH] Make build.sh executable\n\n---\n" +
+		"diff --git a/build.sh b/build.sh\nold mode 100644\nnew mode 100755\n"
+	if committed, err := ApplyPatch(directory, chmod); err != nil || !committed {
+		t.Fatalf("ApplyPatch(mode change) = %v, %v; want it committed", committed, err)
+	}
+	if info, err := os.Stat(filepath.Join(directory, "build.sh")); err != nil || info.Mode().Perm() != 0755 {
+		t.Fatalf("build.sh mode = %v, %v; want 0755", info.Mode().Perm(), err)
+	}
+	if tree, err := GitCommand("-C", directory, "ls-tree", "HEAD", "build.sh").Output(); err != nil || !strings.HasPrefix(string(tree), "100755 ") {
+		t.Fatalf("build.sh in HEAD = %q, %v; want mode 100755", tree, err)
+	}
+
+	// A plain diff adding a file that quotes a mail header isn't a format-patch
+	plain := "diff --git a/mail.txt b/mail.txt\nnew file mode 100644\n--- /dev/null\n+++ b/mail.txt\n" +
+		"@@ -0,0 +1,2 @@\n+From me\n+Subject: hello\n"
+	if committed, err := ApplyPatch(directory, plain); err != nil || committed {
+		t.Fatalf("ApplyPatch(plain diff) = %v, %v; want it staged, not committed", committed, err)
+	}
+	staged, err := GitCommand("-C", directory, "diff", "--cached", "--name-only").Output()
+	if err != nil || string(staged) != "mail.txt\n" {
+		t.Fatalf("staged after ApplyPatch = %q, %v; want mail.txt", staged, err)
+	}
+}
+
+func TestSetGitBackend(t *testing.T) {
+	t.Cleanup(func() { SetGitBackend("") })
+	if err := SetGitBackend("libgit2"); err == nil {
+		t.Fatal("SetGitBackend accepted an unknown backend")
+	}
+	if err := SetGitBackend(GitBackendExec); err != nil || GitBackend() != GitBackendExec {
+		t.Fatalf("SetGitBackend(%q) = %v, backend %s", GitBackendExec, err, GitBackend())
+	}
+	if err := SetGitBackend(""); err != nil || GitBackend() != GitBackendLibrary {
+		t.Fatalf("SetGitBackend(\"\") = %v, backend %s; want %s", err, GitBackend(), GitBackendLibrary)
+	}
+}
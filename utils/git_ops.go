@@ -0,0 +1,233 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// gitOperationQuietPeriod is how long .git must stay untouched before a
+// user-initiated operation (checkout, merge, rebase, reset) counts as finished.
+const gitOperationQuietPeriod = 2 * time.Second
+
+// gitOperationMarkers are the files under .git that git writes during
+// operations that rewrite the working tree. Axle's own commits only move
+// the branch ref, so they never touch these.
+var gitOperationMarkers = map[string]bool{
+	"HEAD":             true,
+	"ORIG_HEAD":        true,
+	"MERGE_HEAD":       true,
+	"CHERRY_PICK_HEAD": true,
+	"REVERT_HEAD":      true,
+	"rebase-merge":     true,
+	"rebase-apply":     true,
+}
+
+// gitHeadState identifies what HEAD points at.
+type gitHeadState struct {
+	Branch string // empty when detached
+	Commit string
+}
+
+var (
+	gitOpMu       sync.Mutex
+	gitOpActive   bool
+	gitOpTimer    *time.Timer
+	gitHeadBefore gitHeadState
+)
+
+// IsGitOperationInProgress reports whether the user is running a git
+// operation that rewrites the working tree; file events are ignored meanwhile.
+func IsGitOperationInProgress() bool {
+	gitOpMu.Lock()
+	defer gitOpMu.Unlock()
+	return gitOpActive
+}
+
+// readGitHead returns the current branch and commit.
+func readGitHead(rootDir string) gitHeadState {
+	var state gitHeadState
+	if output, err := GitCommand("-C", rootDir, "symbolic-ref", "--short", "-q", "HEAD").Output(); err == nil {
+		state.Branch = strings.TrimSpace(string(output))
+	}
+	if commit, err := RevParse(rootDir, "HEAD"); err == nil {
+		state.Commit = commit
+	}
+	return state
+}
+
+// gitOperationPaused reports whether a merge/rebase/cherry-pick is stopped
+// waiting for the user (e.g. on conflicts).
+func gitOperationPaused(gitDir string) bool {
+	for _, marker := range []string{"MERGE_HEAD", "CHERRY_PICK_HEAD", "REVERT_HEAD", "rebase-merge", "rebase-apply"} {
+		if _, err := os.Stat(filepath.Join(gitDir, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// watchGitOperations watches .git for checkouts, merges, rebases and resets
+// made by the user, suppresses the file event flood they cause, and
+// reconciles once the operation has finished.
+func watchGitOperations(ctx context.Context, cfg AppConfig) {
+	gitDir := filepath.Join(cfg.RootDir, ".git")
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("[GIT] Failed to create .git watcher: %v", err)
+		return
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(gitDir); err != nil {
+		log.Printf("[GIT] Failed to watch %s: %v", gitDir, err)
+		return
+	}
+
+	gitOpMu.Lock()
+	gitHeadBefore = readGitHead(cfg.RootDir)
+	gitOpMu.Unlock()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			name := filepath.Base(event.Name)
+			if !gitOperationMarkers[strings.TrimSuffix(name, ".lock")] {
+				continue
+			}
+			// Applying a teammate's patch uses git am, which writes the same markers
+			if getIsApplyingPatch() {
+				continue
+			}
+			beginGitOperation(cfg, gitDir)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[GIT] .git watcher error: %v", err)
+		case <-ctx.Done():
+			gitOpMu.Lock()
+			if gitOpTimer != nil {
+				gitOpTimer.Stop()
+			}
+			gitOpMu.Unlock()
+			return
+		}
+	}
+}
+
+// beginGitOperation marks a git operation as active and (re)arms the quiet timer.
+func beginGitOperation(cfg AppConfig, gitDir string) {
+	gitOpMu.Lock()
+	defer gitOpMu.Unlock()
+
+	if !gitOpActive {
+		gitOpActive = true
+		log.Println("[GIT] Git operation detected; pausing file sync until it finishes")
+
+		// The working tree is rewritten before HEAD moves, so drop what the
+		// batch picked up so far; reconciliation re-adds real local edits
+		batchMutex.Lock()
+		if batchTimer != nil {
+			batchTimer.Stop()
+			batchTimer = nil
+		}
+		pendingFiles = make(map[string]string)
+		batchMutex.Unlock()
+	}
+
+	if gitOpTimer != nil {
+		gitOpTimer.Stop()
+	}
+	gitOpTimer = time.AfterFunc(gitOperationQuietPeriod, func() {
+		finishGitOperation(cfg, gitDir)
+	})
+}
+
+// finishGitOperation resumes syncing once the operation is complete.
+func finishGitOperation(cfg AppConfig, gitDir string) {
+	gitOpMu.Lock()
+	defer gitOpMu.Unlock()
+
+	// Still waiting on the user to resolve a merge or rebase
+	if gitOperationPaused(gitDir) {
+		gitOpTimer = time.AfterFunc(gitOperationQuietPeriod, func() {
+			finishGitOperation(cfg, gitDir)
+		})
+		return
+	}
+
+	before := gitHeadBefore
+	after := readGitHead(cfg.RootDir)
+	gitHeadBefore = after
+	gitOpActive = false
+	gitOpTimer = nil
+
+	switch {
+	case before.Branch != after.Branch:
+		from, to := describeHead(before), describeHead(after)
+		log.Printf("[GIT] Switched from %s to %s; incoming changes now apply to %s", from, to, to)
+		SendNotification("Axle - Branch switched", "Now syncing on "+to)
+	case before.Commit != after.Commit:
+		log.Printf("[GIT] HEAD moved from %s to %s on %s; the operation's commits were not republished",
+			shortHash(before.Commit), shortHash(after.Commit), describeHead(after))
+	default:
+		log.Println("[GIT] Git operation finished")
+	}
+
+	reconcileWorkingTree(cfg)
+}
+
+// describeHead names a HEAD state for log output.
+func describeHead(state gitHeadState) string {
+	if state.Branch != "" {
+		return state.Branch
+	}
+	return "detached HEAD " + shortHash(state.Commit)
+}
+
+// reconcileWorkingTree queues any uncommitted edits left after a git
+// operation (e.g. carried across a checkout) as a normal batch.
+func reconcileWorkingTree(cfg AppConfig) {
+	output, err := GitCommand("-C", cfg.RootDir, "status", "--porcelain").Output()
+	if err != nil {
+		log.Printf("[GIT] Failed to read working tree status: %v", err)
+		return
+	}
+
+	queued := 0
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		status, relPath := line[:2], strings.Trim(line[3:], "\"")
+		if idx := strings.Index(relPath, " -> "); idx >= 0 {
+			relPath = relPath[idx+4:]
+		}
+		if shouldIgnore(cfg, filepath.Join(cfg.RootDir, relPath)) {
+			continue
+		}
+
+		event := "modified"
+		if strings.Contains(status, "D") {
+			event = "deleted"
+		} else if status == "??" || strings.Contains(status, "A") {
+			event = "created"
+		}
+		addToBatch(cfg, relPath, event)
+		queued++
+	}
+
+	if queued > 0 {
+		log.Printf("[GIT] Queued %d uncommitted local changes left after the git operation", queued)
+	}
+}
@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// gitignoreRule is one pattern line of a .gitignore file.
+type gitignoreRule struct {
+	re      *regexp.Regexp // Matches paths relative to the file's directory
+	negate  bool           // "!pattern" re-includes what an earlier line ignored
+	dirOnly bool           // "pattern/" only matches directories
+}
+
+// gitignoreCache holds the parsed .gitignore rules of each directory, keyed
+// by its absolute path. The watcher drops a directory's entry when its
+// .gitignore changes.
+var gitignoreCache = struct {
+	sync.Mutex
+	rules map[string][]gitignoreRule
+}{rules: make(map[string][]gitignoreRule)}
+
+// reloadedIgnorePatterns replaces the configured ignorePatterns once
+// 'axle ignore' changes them while the daemon runs
+var reloadedIgnorePatterns = struct {
+	sync.RWMutex
+	patterns []string
+	set      bool
+}{}
+
+// SetIgnorePatterns makes the running daemon use new ignorePatterns.
+func SetIgnorePatterns(patterns []string) {
+	reloadedIgnorePatterns.Lock()
+	reloadedIgnorePatterns.patterns = append([]string(nil), patterns...)
+	reloadedIgnorePatterns.set = true
+	reloadedIgnorePatterns.Unlock()
+}
+
+// currentIgnorePatterns returns the ignorePatterns in effect.
+func currentIgnorePatterns(cfg AppConfig) []string {
+	reloadedIgnorePatterns.RLock()
+	defer reloadedIgnorePatterns.RUnlock()
+	if reloadedIgnorePatterns.set {
+		return reloadedIgnorePatterns.patterns
+	}
+	return cfg.IgnorePatterns
+}
+
+// shouldIgnore reports whether the watcher and the sync paths leave a file
+// alone: Axle's own rules and ignorePatterns, the generated-output
+// directories, or the repository's .gitignore files, so node_modules and
+// build output never travel.
+func shouldIgnore(cfg AppConfig, path string) bool {
+	if isIgnored(path, currentIgnorePatterns(cfg)) || isOutputPath(cfg.RootDir, path) {
+		return true
+	}
+	info, err := os.Lstat(path)
+	return gitIgnored(cfg.RootDir, path, err == nil && info.IsDir())
+}
+
+// gitIgnored reports whether git would ignore path, given the .gitignore
+// files from rootDir down to it and .git/info/exclude. As in git, nothing
+// under an ignored directory can be re-included.
+func gitIgnored(rootDir, path string, isDir bool) bool {
+	rel, err := filepath.Rel(rootDir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range parts {
+		dir := i < len(parts)-1 || isDir
+		if matchGitignore(rootDir, parts[:i+1], dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGitignore applies the rules of every .gitignore above the path, root
+// first; the last matching line wins, so deeper files override shallower ones.
+func matchGitignore(rootDir string, parts []string, isDir bool) bool {
+	ignored := false
+	for depth := 0; depth < len(parts); depth++ {
+		dir := filepath.Join(append([]string{rootDir}, parts[:depth]...)...)
+		local := strings.Join(parts[depth:], "/")
+		for _, rule := range loadGitignore(rootDir, dir) {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.re.MatchString(local) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// loadGitignore returns the rules of dir's .gitignore, parsing it on first
+// use. The root also gets .git/info/exclude, which ranks below .gitignore.
+func loadGitignore(rootDir, dir string) []gitignoreRule {
+	gitignoreCache.Lock()
+	defer gitignoreCache.Unlock()
+	if rules, ok := gitignoreCache.rules[dir]; ok {
+		return rules
+	}
+
+	var rules []gitignoreRule
+	if dir == filepath.Clean(rootDir) {
+		rules = append(rules, parseGitignore(filepath.Join(rootDir, ".git", "info", "exclude"))...)
+	}
+	rules = append(rules, parseGitignore(filepath.Join(dir, ".gitignore"))...)
+	gitignoreCache.rules[dir] = rules
+	return rules
+}
+
+// forgetGitignore drops the cached rules for dir, after its .gitignore changed.
+func forgetGitignore(dir string) {
+	gitignoreCache.Lock()
+	delete(gitignoreCache.rules, dir)
+	gitignoreCache.Unlock()
+}
+
+// parseGitignore reads the patterns of a .gitignore file. A missing file has
+// none.
+func parseGitignore(path string) []gitignoreRule {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules []gitignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseGitignoreLine(scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func parseGitignoreLine(line string) (gitignoreRule, bool) {
+	line = strings.TrimRight(strings.TrimSuffix(line, "\r"), " ")
+	if strings.HasSuffix(line, "\\") {
+		line += " " // "\ " keeps a trailing space
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return gitignoreRule{}, false
+	}
+
+	var rule gitignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return gitignoreRule{}, false
+	}
+
+	// A slash anywhere but the end anchors the pattern to the file's
+	// directory; otherwise it matches a name at any depth below it
+	prefix := "^(?:.*/)?"
+	if strings.Contains(line, "/") {
+		prefix = "^"
+		line = strings.TrimPrefix(line, "/")
+	}
+	re, err := regexp.Compile(prefix + gitignoreGlobToRegexp(line) + "$")
+	if err != nil {
+		return gitignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// gitignoreGlobToRegexp translates a gitignore glob: globToRegexp's "*",
+// "?" and "**", plus character classes and backslash escapes.
+func gitignoreGlobToRegexp(pattern string) string {
+	var re strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					re.WriteString("(?:.*/)?")
+				} else {
+					re.WriteString(".*")
+				}
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				re.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return re.String()
+}
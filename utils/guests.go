@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// GuestPass lets someone outside the team, such as a mentor, join for a
+// limited time with a token instead of the team password. Guests receive
+// the team's changes; theirs only sync for the paths the pass allows.
+type GuestPass struct {
+	Name      string   `json:"name"`
+	TokenHash string   `json:"tokenHash,omitempty"` // Cleared once the pass expires, revoking the token
+	Paths     []string `json:"paths,omitempty"`     // Globs the guest may change; none for read-only
+	ExpiresAt int64    `json:"expiresAt"`
+	InvitedBy string   `json:"invitedBy,omitempty"`
+	// Hex Ed25519 public key checking what the guest signs, derived from the token
+	SigningKey string `json:"signingKey,omitempty"`
+}
+
+// ErrGuestExpired is returned for a guest pass that is past its expiry.
+var ErrGuestExpired = errors.New("guest access has expired")
+
+// Expired reports whether the pass is past its expiry or revoked.
+func (g GuestPass) Expired() bool {
+	return g.TokenHash == "" || time.Now().Unix() >= g.ExpiresAt
+}
+
+// Allows reports whether the guest may change path.
+func (g GuestPass) Allows(path string) bool {
+	return !g.Expired() && MatchAnyGlob(g.Paths, path)
+}
+
+// NewGuestPass creates a pass for name valid for ttl, returning it with the
+// token the guest joins with. Without a name the guest gets a generated one.
+func NewGuestPass(name string, paths []string, ttl time.Duration, invitedBy string) (GuestPass, string, error) {
+	bytes := make([]byte, 19)
+	if _, err := rand.Read(bytes); err != nil {
+		return GuestPass{}, "", fmt.Errorf("failed to generate guest token: %w", err)
+	}
+	if name == "" {
+		name = "guest-" + hex.EncodeToString(bytes[16:])
+	}
+	token := "axg_" + hex.EncodeToString(bytes[:16])
+	hash, err := bcrypt.GenerateFromPassword([]byte(token), bcrypt.DefaultCost)
+	if err != nil {
+		return GuestPass{}, "", fmt.Errorf("failed to hash guest token: %w", err)
+	}
+	pass := GuestPass{
+		Name:       name,
+		TokenHash:  string(hash),
+		Paths:      paths,
+		ExpiresAt:  time.Now().Add(ttl).Unix(),
+		InvitedBy:  invitedBy,
+		SigningKey: GuestVerifyKey(name, token),
+	}
+	return pass, token, nil
+}
+
+// Guest returns the guest pass issued to name, if any.
+func (c AxleConfig) Guest(name string) (GuestPass, bool) {
+	for _, guest := range c.Guests {
+		if guest.Name == name {
+			return guest, true
+		}
+	}
+	return GuestPass{}, false
+}
+
+// VerifyGuestToken checks the token a guest joins or starts with.
+func (c AxleConfig) VerifyGuestToken(name, token string) error {
+	guest, ok := c.Guest(name)
+	if !ok {
+		return fmt.Errorf("no guest pass for %s", name)
+	}
+	if guest.Expired() {
+		return ErrGuestExpired
+	}
+	if bcrypt.CompareHashAndPassword([]byte(guest.TokenHash), []byte(token)) != nil {
+		return fmt.Errorf("invalid guest token")
+	}
+	return nil
+}
+
+var (
+	guestsMu sync.RWMutex
+	guests   []GuestPass
+)
+
+// SetGuests registers the team's guest passes, so changes from guests are
+// checked against them.
+func SetGuests(passes []GuestPass) {
+	guestsMu.Lock()
+	defer guestsMu.Unlock()
+	guests = passes
+}
+
+// GuestPassFor returns the guest pass of a member, if they are a guest.
+func GuestPassFor(name string) (GuestPass, bool) {
+	guestsMu.RLock()
+	defer guestsMu.RUnlock()
+	for _, guest := range guests {
+		if guest.Name == name {
+			return guest, true
+		}
+	}
+	return GuestPass{}, false
+}
+
+// FilterGuestChanges removes the changes a guest's pass doesn't allow from
+// a batch by peer, and the sections for such files from the patches that
+// are left, as FilterSyncScope does. Both sides filter, so a guest can't
+// push changes past a daemon that knows the pass.
+func FilterGuestChanges(peer string, changes []FileChange) []FileChange {
+	guest, ok := GuestPassFor(peer)
+	if !ok {
+		return changes
+	}
+	// A section is kept only when the pass allows every path it touches, so
+	// a rename can't delete a file outside the pass either
+	allowed := func(lines []string) bool {
+		for _, path := range sectionPaths(lines) {
+			if !guest.Allows(path) {
+				return false
+			}
+		}
+		return true
+	}
+
+	filtered := make([]FileChange, 0, len(changes))
+	orphaned := make(map[string]FileChange) // Dropped changes carrying their commit's patch
+	var dropped int
+	for _, change := range changes {
+		// Every change of a commit can carry its whole patch
+		if change.Patch != "" {
+			if err := DecodeChange(&change); err != nil {
+				log.Printf("[GUEST] Dropping %s by guest %s: %v", change.File, peer, err)
+				dropped++
+				continue
+			}
+			change.Patch = filterPatchSections(change.Patch, allowed)
+		}
+		if !guest.Allows(change.File) || (change.From != "" && !guest.Allows(change.From)) {
+			if change.Patch != "" && change.CommitHash != "" {
+				if _, ok := orphaned[change.CommitHash]; !ok {
+					orphaned[change.CommitHash] = change
+				}
+			}
+			dropped++
+			continue
+		}
+		filtered = append(filtered, change)
+	}
+
+	for i := range filtered {
+		change := &filtered[i]
+		if carrier, ok := orphaned[change.CommitHash]; ok && change.CommitHash != "" {
+			if change.Patch == "" {
+				change.Patch = carrier.Patch
+			}
+			delete(orphaned, change.CommitHash)
+		}
+	}
+	if dropped > 0 {
+		log.Printf("[GUEST] Dropped %d changes by guest %s outside what their pass allows", dropped, peer)
+	}
+	return filtered
+}
+
+// guestPassExpired reports whether this node's member is a guest whose pass
+// has expired, telling them so.
+func guestPassExpired(cfg AppConfig) bool {
+	guest, ok := GuestPassFor(cfg.Username)
+	if !ok || !guest.Expired() {
+		return false
+	}
+	log.Printf("[GUEST] Your guest access to team %s has expired; stopping", cfg.TeamID)
+	SendNotification("Axle - Guest access expired", fmt.Sprintf("Your access to team %s has ended", cfg.TeamID))
+	return true
+}
+
+// revokeExpiredGuests clears the tokens of expired guest passes and drops
+// the guests from the member list. The passes stay in the team config, so
+// changes still arriving from an expired guest are recognized and refused.
+func revokeExpiredGuests(ctx context.Context, cfg AppConfig, teamConfig AxleConfig) {
+	// The passes are shared with SetGuests; edit a copy
+	teamConfig.Guests = append([]GuestPass(nil), teamConfig.Guests...)
+	var expired []string
+	for i, guest := range teamConfig.Guests {
+		if guest.TokenHash == "" || time.Now().Unix() < guest.ExpiresAt {
+			continue
+		}
+		expired = append(expired, guest.Name)
+		teamConfig.Guests[i].TokenHash = ""
+	}
+	if len(expired) == 0 {
+		return
+	}
+
+	if cfg.HasRedis() {
+		for _, name := range expired {
+			if err := UnregisterMember(ctx, cfg.RedisClient, cfg.TeamID, name); err != nil {
+				log.Printf("[GUEST] %v", err)
+			}
+		}
+	}
+	// Only the admin can re-sign the config; other daemons leave it to them
+	if err := SaveTeamConfig(ctx, cfg.RedisClient, teamConfig); err == nil {
+		log.Printf("[GUEST] Revoked expired guest access for %v", expired)
+	} else if !errors.Is(err, ErrNotTeamAdmin) {
+		log.Printf("[GUEST] Failed to revoke expired guest access: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// guestCommitPatch is a format-patch of one commit touching an allowed
+// file, a file outside the pass and a rename out of it.
+const guestCommitPatch = `From 1111111111111111111111111111111111111111 Mon Sep 17 00:00:00 2001
+From: guest <guest@example.com>
+Subject: [PATCH] Mixed commit
+
+---
+diff --git a/docs/readme.md b/docs/readme.md
+index 1111111..2222222 100644
+--- a/docs/readme.md
++++ b/docs/readme.md
+@@ -1 +1 @@
+-old
++new
+diff --git a/src/main.go b/src/main.go
+index 3333333..4444444 100644
+--- a/src/main.go
++++ b/src/main.go
+@@ -1 +1 @@
+-package main
++package evil
+diff --git a/src/secret.go b/docs/secret.go
+similarity index 100%
+rename from src/secret.go
+rename to docs/secret.go
+-- 
+2.40.0
+`
+
+func withGuest(t *testing.T, pass GuestPass) {
+	t.Helper()
+	SetGuests([]GuestPass{pass})
+	t.Cleanup(func() { SetGuests(nil) })
+}
+
+func TestFilterGuestChangesTrimsPatch(t *testing.T) {
+	withGuest(t, GuestPass{Name: "mentor", TokenHash: "hash", Paths: []string{"docs/**"}, ExpiresAt: time.Now().Add(time.Hour).Unix()})
+
+	changes := []FileChange{
+		{File: "docs/readme.md", Event: "modified", CommitHash: "c1", Patch: guestCommitPatch},
+		{File: "src/main.go", Event: "modified", CommitHash: "c1", Patch: guestCommitPatch},
+	}
+	filtered := FilterGuestChanges("mentor", changes)
+	if len(filtered) != 1 || filtered[0].File != "docs/readme.md" {
+		t.Fatalf("kept %+v, want only docs/readme.md", filtered)
+	}
+	patch := filtered[0].Patch
+	if !strings.Contains(patch, "diff --git a/docs/readme.md") {
+		t.Errorf("allowed section was dropped:\n%s", patch)
+	}
+	for _, path := range []string{"src/main.go", "src/secret.go"} {
+		if strings.Contains(patch, path) {
+			t.Errorf("section for %s outside the pass was kept:\n%s", path, patch)
+		}
+	}
+}
+
+func TestFilterGuestChangesRehomesPatch(t *testing.T) {
+	withGuest(t, GuestPass{Name: "mentor", TokenHash: "hash", Paths: []string{"docs/**"}, ExpiresAt: time.Now().Add(time.Hour).Unix()})
+
+	// The commit's patch travels on the disallowed change only
+	changes := []FileChange{
+		{File: "src/main.go", Event: "modified", CommitHash: "c1", Patch: guestCommitPatch},
+		{File: "docs/readme.md", Event: "modified", CommitHash: "c1"},
+	}
+	filtered := FilterGuestChanges("mentor", changes)
+	if len(filtered) != 1 || filtered[0].File != "docs/readme.md" {
+		t.Fatalf("kept %+v, want only docs/readme.md", filtered)
+	}
+	if patch := filtered[0].Patch; !strings.Contains(patch, "docs/readme.md") || strings.Contains(patch, "src/main.go") {
+		t.Errorf("patch wasn't moved to the kept change, trimmed:\n%s", patch)
+	}
+}
+
+func TestFilterGuestChangesMembersUntouched(t *testing.T) {
+	withGuest(t, GuestPass{Name: "mentor", TokenHash: "hash", Paths: []string{"docs/**"}, ExpiresAt: time.Now().Add(time.Hour).Unix()})
+
+	changes := []FileChange{{File: "src/main.go", Event: "modified", CommitHash: "c1", Patch: guestCommitPatch}}
+	if filtered := FilterGuestChanges("alice", changes); len(filtered) != 1 || filtered[0].Patch != guestCommitPatch {
+		t.Errorf("a member's batch was changed: %+v", filtered)
+	}
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// MinHealthInterval keeps stats broadcasts from becoming chatter
+	MinHealthInterval = time.Minute
+	// healthTTL bounds how long the last reports are kept for 'axle stats'
+	healthTTL = 24 * time.Hour
+)
+
+// conflictCount counts incoming patches that conflicted with local work
+var conflictCount atomic.Int64
+
+// HealthReport is the compact stats summary a daemon broadcasts every
+// interval. Counts cover the interval; latency and queue are current.
+type HealthReport struct {
+	Username        string `json:"username"`
+	NodeID          string `json:"nodeID"`
+	IntervalSeconds int    `json:"intervalSeconds"`
+	FilesSent       int    `json:"filesSent"`
+	FilesReceived   int    `json:"filesReceived"`
+	FailedChanges   int    `json:"failedChanges"` // Incoming changes that failed to apply
+	Conflicts       int    `json:"conflicts"`
+	LatencyMs       int64  `json:"latencyMs"` // Last publish round trip
+	PublisherState  string `json:"publisherState"`
+	QueuedChanges   int    `json:"queuedChanges"`
+	Timestamp       int64  `json:"timestamp"`
+}
+
+// Stale reports whether the member has missed two broadcasts in a row.
+func (r HealthReport) Stale() bool {
+	return time.Since(time.Unix(r.Timestamp, 0)) > 2*time.Duration(r.IntervalSeconds)*time.Second
+}
+
+func healthKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:health", teamID)
+}
+
+// StartHealthBroadcaster publishes a HealthReport every cfg.HealthInterval
+// while the team has stats broadcasts on, and keeps the latest one per
+// member in Redis for 'axle stats'.
+func StartHealthBroadcaster(ctx context.Context, cfg AppConfig) {
+	if cfg.HealthInterval <= 0 {
+		return
+	}
+	log.Printf("[STATS] Broadcasting a stats summary every %v", cfg.HealthInterval)
+
+	events, unsubscribe := Events.Subscribe(TopicBatchPublished, TopicBatchApplied, TopicApplyFailed)
+	defer unsubscribe()
+	ticker := time.NewTicker(cfg.HealthInterval)
+	defer ticker.Stop()
+
+	report := HealthReport{Username: cfg.Username, NodeID: cfg.NodeID, IntervalSeconds: int(cfg.HealthInterval.Seconds())}
+	conflictsBefore := conflictCount.Load()
+	for {
+		select {
+		case event := <-events:
+			switch payload := event.Payload.(type) {
+			case BatchPublishedEvent:
+				report.FilesSent += len(payload.Metadata.Changes)
+			case BatchAppliedEvent:
+				report.FilesReceived += len(payload.Files)
+			case ApplyFailedEvent:
+				report.FailedChanges++
+			}
+		case <-ticker.C:
+			conflicts := conflictCount.Load()
+			report.Conflicts = int(conflicts - conflictsBefore)
+			conflictsBefore = conflicts
+			status := GetPublisherStatus()
+			report.LatencyMs = status.LastLatencyMs
+			report.PublisherState = status.State
+			report.QueuedChanges = status.QueuedChanges
+			report.Timestamp = time.Now().Unix()
+
+			if err := publishHealthReport(ctx, cfg, report); err != nil {
+				log.Printf("[STATS] Failed to broadcast stats: %v", err)
+			}
+			report = HealthReport{Username: cfg.Username, NodeID: cfg.NodeID, IntervalSeconds: report.IntervalSeconds}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func publishHealthReport(ctx context.Context, cfg AppConfig, report HealthReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	pipe := cfg.RedisClient.TxPipeline()
+	pipe.HSet(ctx, healthKey(cfg.TeamID), cfg.Username, data)
+	pipe.Expire(ctx, healthKey(cfg.TeamID), healthTTL)
+	pipe.Publish(ctx, HealthChannel(cfg.TeamID), data)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// TeamHealth returns the latest report of every member, by username.
+func TeamHealth(ctx context.Context, cfg AppConfig) ([]HealthReport, error) {
+	fields, err := cfg.RedisClient.HGetAll(ctx, healthKey(cfg.TeamID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read team health: %w", err)
+	}
+	var reports []HealthReport
+	for _, raw := range fields {
+		var report HealthReport
+		if json.Unmarshal([]byte(raw), &report) == nil {
+			reports = append(reports, report)
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Username < reports[j].Username })
+	return reports, nil
+}
@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is used when no supported locale is configured or detected.
+const DefaultLocale = "en"
+
+// Message bundles for user-facing CLI output. Only the commands listed under
+// "Language" in docs/COMMANDS.md are translated; the rest print English. Log
+// lines stay in English so that logs from different members remain comparable.
+var messageBundles = map[string]map[string]string{
+	"en": {
+		"common.done":            "done",
+		"common.failed":          "failed",
+		"common.warning":         "warning",
+		"common.next_steps":      "Next steps:",
+		"common.get_started":     "Get started:",
+		"prompt.new_password":    "Enter a new team password: ",
+		"prompt.team_password":   "Enter the team password: ",
+		"hint.join":              "  axle join     - Have your team members run this command to join the team",
+		"hint.init":              "  axle init     - Initialize a new team repository",
+		"hint.start":             "  axle start    - Start file synchronization",
+		"hint.team":              "  axle team     - Check team member status",
+		"hint.chat":              "  axle chat \"Hi team!\" - Send a message to your team",
+		"hint.chat_short":        "  axle chat     - Send a message to your team",
+		"root.title":             "🔄 Axle",
+		"root.welcome":           "Welcome to Axle! Use --help to see available commands.",
+		"init.title":             "🚀 Initializing Axle Repository",
+		"init.success":           "Axle repository initialized successfully!",
+		"init.step.git":          "Setting up Git repository... ",
+		"init.step.gitignore":    "Detecting project stack and configuring .gitignore... ",
+		"init.gitignore_failed":  "  Could not create .gitignore: %v",
+		"init.step.attributes":   "Configuring line endings and editor settings... ",
+		"init.commit_failed":     "  Could not commit baseline files: %v",
+		"init.step.exclude":      "Configuring Git exclusions... ",
+		"init.step.signing_key":  "Generating team signing key... ",
+		"init.step.hook":         "Installing Git commit hook... ",
+		"init.hook_failed":       "  Manual commits won't sync automatically: %v",
+		"init.step.config":       "Creating local configuration file... ",
+		"init.step.hash":         "Hashing team password... ",
+		"init.step.redis":        "Saving team configuration to Redis... ",
+		"join.title":             "🤝 Joining Axle Team",
+		"join.success":           "Successfully joined the team!",
+		"join.step.redis":        "Connecting to Redis... ",
+		"join.step.team_config":  "Fetching team configuration... ",
+		"join.step.password":     "Verifying password... ",
+		"join.step.membership":   "Registering team membership... ",
+		"start.title":            "🔄 Starting Axle",
+		"start.summary":          "Team: %s | User: %s | Directory: %s",
+		"start.stop_hint":        "Press Ctrl+C to stop",
+		"start.conflict_mode":    "Conflict resolution mode: %s",
+		"start.authority":        "Authoritative node: %s",
+		"start.offline":          "Working offline: changes are committed locally and published when Redis is reachable again ('axle sync-now')",
+		"start.hub":              "Running as the team hub: serving snapshots, stored history and repairs",
+		"start.hub_authority":    "The hub isn't the authoritative node; the team admin can run 'axle team authority %s'",
+		"start.hub_no_history":   "Batch persistence is off, so members who were away can't catch up; the team admin can run 'axle team persistence on'",
+		"board.pinned":           "📌 Pinned",
+		"board.nothing_pinned":   "  Nothing pinned",
+		"board.todo":             "✅ TODO",
+		"board.nothing_todo":     "  Nothing to do",
+		"board.todo_done":        "  #%-4d [x] %s  (done by %s)",
+		"announce.pending":       "📣 Announcements waiting for your acknowledgment",
+		"announce.ack_hint":      "  Acknowledge with 'axle ack <id>'",
+		"chat.title":             "💬 Sending Message",
+		"chat.to_team":           "To team: %s",
+		"chat.message":           "Message: \"%s\"",
+		"chat.priority":          "Priority: 🔔 HIGH (will trigger notifications)",
+		"chat.sent_priority":     "Priority message sent with notifications!",
+		"chat.sent":              "Message sent successfully!",
+		"chat.status_set":        "Status set: %s",
+		"chat.status_cleared":    "Status cleared",
+		"chat.status_action":     "is now: %s",
+		"chat.locked":            "Locked %s; run 'axle chat /unlock %[1]s' when you're done",
+		"chat.lock_action":       "locked %s",
+		"chat.unlocked":          "Unlocked %s",
+		"chat.unlock_action":     "unlocked %s",
+		"chat.no_locks":          "No files are locked",
+		"chat.help_title":        "💬 Chat Commands",
+		"chat.history_title":     "💬 Recent Messages",
+		"chat.no_history":        "No messages received yet; the running daemon records them",
+		"chat.help":              "  /me <action>     Describe what you're doing\n  /status [text]   Set your status in 'axle team' (no text clears it)\n  /lock [file]     Claim a file so teammates leave it alone (no file lists locks)\n  /unlock <file>   Release your claim on a file\n  /who             Show who is online\n  /history [n]     Show the last n messages in the order they were sent\n  /help            Show this list\n  //text           Send a message that starts with /",
+		"error.flags_required":   "both --team and --username flags are required",
+		"error.invalid_password": "invalid password",
+	},
+	"es": {
+		"common.done":            "listo",
+		"common.failed":          "falló",
+		"common.warning":         "aviso",
+		"common.next_steps":      "Próximos pasos:",
+		"common.get_started":     "Para empezar:",
+		"prompt.new_password":    "Introduce una nueva contraseña del equipo: ",
+		"prompt.team_password":   "Introduce la contraseña del equipo: ",
+		"hint.join":              "  axle join     - Los miembros del equipo ejecutan este comando para unirse",
+		"hint.init":              "  axle init     - Inicializa un nuevo repositorio de equipo",
+		"hint.start":             "  axle start    - Inicia la sincronización de archivos",
+		"hint.team":              "  axle team     - Consulta el estado de los miembros",
+		"hint.chat":              "  axle chat \"¡Hola equipo!\" - Envía un mensaje a tu equipo",
+		"hint.chat_short":        "  axle chat     - Envía un mensaje a tu equipo",
+		"root.title":             "🔄 Axle",
+		"root.welcome":           "¡Bienvenido a Axle! Usa --help para ver los comandos disponibles.",
+		"init.title":             "🚀 Inicializando repositorio Axle",
+		"init.success":           "¡Repositorio Axle inicializado correctamente!",
+		"init.step.git":          "Configurando el repositorio Git... ",
+		"init.step.gitignore":    "Detectando el stack del proyecto y configurando .gitignore... ",
+		"init.gitignore_failed":  "  No se pudo crear .gitignore: %v",
+		"init.step.attributes":   "Configurando finales de línea y ajustes del editor... ",
+		"init.commit_failed":     "  No se pudieron confirmar los archivos base: %v",
+		"init.step.exclude":      "Configurando exclusiones de Git... ",
+		"init.step.signing_key":  "Generando la clave de firma del equipo... ",
+		"init.step.hook":         "Instalando el hook de commits de Git... ",
+		"init.hook_failed":       "  Los commits manuales no se sincronizarán automáticamente: %v",
+		"init.step.config":       "Creando el archivo de configuración local... ",
+		"init.step.hash":         "Cifrando la contraseña del equipo... ",
+		"init.step.redis":        "Guardando la configuración del equipo en Redis... ",
+		"join.title":             "🤝 Uniéndose al equipo Axle",
+		"join.success":           "¡Te has unido al equipo!",
+		"join.step.redis":        "Conectando a Redis... ",
+		"join.step.team_config":  "Obteniendo la configuración del equipo... ",
+		"join.step.password":     "Verificando la contraseña... ",
+		"join.step.membership":   "Registrando la membresía del equipo... ",
+		"start.title":            "🔄 Iniciando Axle",
+		"start.summary":          "Equipo: %s | Usuario: %s | Directorio: %s",
+		"start.stop_hint":        "Pulsa Ctrl+C para detener",
+		"start.conflict_mode":    "Modo de resolución de conflictos: %s",
+		"start.authority":        "Nodo autoritativo: %s",
+		"start.offline":          "Trabajando sin conexión: los cambios se confirman localmente y se publican cuando Redis vuelva a estar disponible ('axle sync-now')",
+		"start.hub":              "Ejecutando como hub del equipo: sirve snapshots, el historial guardado y reparaciones",
+		"start.hub_authority":    "El hub no es el nodo autoritativo; el administrador del equipo puede ejecutar 'axle team authority %s'",
+		"start.hub_no_history":   "La persistencia de lotes está desactivada, así que los miembros que estuvieron ausentes no pueden ponerse al día; el administrador del equipo puede ejecutar 'axle team persistence on'",
+		"board.pinned":           "📌 Fijados",
+		"board.nothing_pinned":   "  Nada fijado",
+		"board.todo":             "✅ Pendientes",
+		"board.nothing_todo":     "  Nada pendiente",
+		"board.todo_done":        "  #%-4d [x] %s  (hecho por %s)",
+		"announce.pending":       "📣 Anuncios pendientes de tu confirmación",
+		"announce.ack_hint":      "  Confirma con 'axle ack <id>'",
+		"chat.title":             "💬 Enviando mensaje",
+		"chat.to_team":           "Para el equipo: %s",
+		"chat.message":           "Mensaje: \"%s\"",
+		"chat.priority":          "Prioridad: 🔔 ALTA (enviará notificaciones)",
+		"chat.sent_priority":     "¡Mensaje prioritario enviado con notificaciones!",
+		"chat.sent":              "¡Mensaje enviado correctamente!",
+		"chat.status_set":        "Estado establecido: %s",
+		"chat.status_cleared":    "Estado borrado",
+		"chat.status_action":     "ahora está: %s",
+		"chat.locked":            "Bloqueado %s; ejecuta 'axle chat /unlock %[1]s' cuando termines",
+		"chat.lock_action":       "bloqueó %s",
+		"chat.unlocked":          "Desbloqueado %s",
+		"chat.unlock_action":     "desbloqueó %s",
+		"chat.no_locks":          "No hay archivos bloqueados",
+		"chat.help_title":        "💬 Comandos del chat",
+		"chat.history_title":     "💬 Mensajes recientes",
+		"chat.no_history":        "Aún no se han recibido mensajes; el daemon en ejecución los guarda",
+		"chat.help":              "  /me <acción>       Describe lo que estás haciendo\n  /status [texto]    Establece tu estado en 'axle team' (sin texto lo borra)\n  /lock [archivo]    Reclama un archivo para que el equipo no lo toque (sin archivo lista los bloqueos)\n  /unlock <archivo>  Libera tu reclamo sobre un archivo\n  /who               Muestra quién está en línea\n  /history [n]       Muestra los últimos n mensajes en el orden en que se enviaron\n  /help              Muestra esta lista\n  //texto            Envía un mensaje que empieza por /",
+		"error.flags_required":   "las opciones --team y --username son obligatorias",
+		"error.invalid_password": "contraseña incorrecta",
+	},
+}
+
+var (
+	localeMu      sync.RWMutex
+	currentLocale = DetectLocale()
+)
+
+// SupportedLocales returns the locales that have a message bundle.
+func SupportedLocales() []string {
+	locales := make([]string, 0, len(messageBundles))
+	for locale := range messageBundles {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// normalizeLocale turns values like "es_ES.UTF-8" into a supported bundle name, or "".
+func normalizeLocale(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if i := strings.IndexAny(value, ".@"); i >= 0 {
+		value = value[:i]
+	}
+	if i := strings.IndexAny(value, "_-"); i >= 0 {
+		value = value[:i]
+	}
+	if _, ok := messageBundles[value]; ok {
+		return value
+	}
+	return ""
+}
+
+// DetectLocale picks a locale from AXLE_LANG, LC_ALL, LC_MESSAGES, or LANG.
+func DetectLocale() string {
+	for _, env := range []string{"AXLE_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := normalizeLocale(os.Getenv(env)); locale != "" {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// SetLocale selects the UI locale. The first supported value among the
+// preferences wins (e.g. --lang, then the config file); otherwise the
+// environment is used.
+func SetLocale(preferences ...string) string {
+	locale := ""
+	for _, preference := range preferences {
+		if locale = normalizeLocale(preference); locale != "" {
+			break
+		}
+	}
+	if locale == "" {
+		locale = DetectLocale()
+	}
+
+	localeMu.Lock()
+	currentLocale = locale
+	localeMu.Unlock()
+	return locale
+}
+
+// CurrentLocale returns the active UI locale.
+func CurrentLocale() string {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+	return currentLocale
+}
+
+// T returns the localized message for key, formatted with args. Messages
+// missing from the active bundle fall back to English, then to the key itself.
+func T(key string, args ...interface{}) string {
+	message, ok := messageBundles[CurrentLocale()][key]
+	if !ok {
+		if message, ok = messageBundles[DefaultLocale][key]; !ok {
+			message = key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
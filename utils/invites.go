@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// invitePrefix starts every member invite token, telling them apart from
+// guest tokens
+const invitePrefix = "axi_"
+
+// ErrInviteInvalid is returned for an invite token that is unknown, expired
+// or already used.
+var ErrInviteInvalid = errors.New("invite token is invalid, expired or already used")
+
+// memberInvite is what Redis holds for an invite: the team password,
+// encrypted with a key only the token gives, so reading Redis doesn't
+// reveal it. The entry expires with the invite.
+type memberInvite struct {
+	Password  []byte `json:"password"` // AES-GCM sealed team password
+	Nonce     []byte `json:"nonce"`
+	Username  string `json:"username,omitempty"` // Only this username may use it; "" for anyone
+	InvitedBy string `json:"invitedBy"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+// inviteKey is the Redis key of an invite. It is named by the token's hash,
+// so the key doesn't give the token away.
+func inviteKey(teamID, token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("axle:team:%s:invite:%s", teamID, hex.EncodeToString(sum[:]))
+}
+
+// inviteCipher returns the cipher sealing the password of the invite token.
+func inviteCipher(token string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("axle-invite:" + token))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// IsInviteToken reports whether token looks like a member invite token.
+func IsInviteToken(token string) bool {
+	return strings.HasPrefix(token, invitePrefix)
+}
+
+// CreateInvite stores a single-use invite that lets someone join the team
+// without being sent the password, and returns its token. With a username
+// only that username can use it.
+func CreateInvite(ctx context.Context, rdb redis.UniversalClient, teamID, password, invitedBy, username string, ttl time.Duration) (string, time.Time, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate invite token: %w", err)
+	}
+	token := invitePrefix + hex.EncodeToString(secret)
+
+	aead, err := inviteCipher(token)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to seal invite: %w", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to seal invite: %w", err)
+	}
+	expiresAt := time.Now().Add(ttl)
+	invite := memberInvite{
+		Password:  aead.Seal(nil, nonce, []byte(password), []byte(teamID)),
+		Nonce:     nonce,
+		Username:  username,
+		InvitedBy: invitedBy,
+		ExpiresAt: expiresAt.Unix(),
+	}
+	data, err := json.Marshal(invite)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to marshal invite: %w", err)
+	}
+	if err := rdb.Set(ctx, inviteKey(teamID, token), data, ttl).Err(); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store invite: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// RedeemedInvite is an invite taken out of Redis by RedeemInvite.
+type RedeemedInvite struct {
+	Password  string // The team password it carried
+	InvitedBy string
+
+	key       string
+	data      string
+	expiresAt int64
+}
+
+// Restore puts the invite back, unless it has expired meanwhile, so the
+// invitee can try again after a join that failed once it was redeemed.
+func (r RedeemedInvite) Restore(ctx context.Context, rdb redis.UniversalClient) error {
+	ttl := time.Until(time.Unix(r.expiresAt, 0))
+	if r.key == "" || ttl <= 0 {
+		return nil
+	}
+	if err := rdb.SetNX(ctx, r.key, r.data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to restore invite: %w", err)
+	}
+	return nil
+}
+
+// RedeemInvite uses up an invite token and returns the team password it
+// carries, with who sent the invite. The invite is deleted as it is read,
+// so a token works once even when two people race for it; a join that
+// fails afterwards puts it back with Restore.
+func RedeemInvite(ctx context.Context, rdb redis.UniversalClient, teamID, token, username string) (RedeemedInvite, error) {
+	if !IsInviteToken(token) {
+		return RedeemedInvite{}, ErrInviteInvalid
+	}
+	key := inviteKey(teamID, token)
+	var get *redis.StringCmd
+	if _, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	}); err != nil && err != redis.Nil {
+		return RedeemedInvite{}, fmt.Errorf("failed to read invite: %w", err)
+	}
+	data, err := get.Result()
+	if err == redis.Nil {
+		return RedeemedInvite{}, ErrInviteInvalid
+	} else if err != nil {
+		return RedeemedInvite{}, fmt.Errorf("failed to read invite: %w", err)
+	}
+
+	var invite memberInvite
+	if err := json.Unmarshal([]byte(data), &invite); err != nil {
+		return RedeemedInvite{}, fmt.Errorf("failed to parse invite: %w", err)
+	}
+	if time.Now().Unix() >= invite.ExpiresAt {
+		return RedeemedInvite{}, ErrInviteInvalid
+	}
+	redeemed := RedeemedInvite{InvitedBy: invite.InvitedBy, key: key, data: data, expiresAt: invite.ExpiresAt}
+	if invite.Username != "" && invite.Username != username {
+		// Put it back for the member it was meant for
+		redeemed.Restore(ctx, rdb)
+		return RedeemedInvite{}, fmt.Errorf("this invite is for %s, not %s", invite.Username, username)
+	}
+
+	aead, err := inviteCipher(token)
+	if err != nil {
+		redeemed.Restore(ctx, rdb)
+		return RedeemedInvite{}, fmt.Errorf("failed to open invite: %w", err)
+	}
+	password, err := aead.Open(nil, invite.Nonce, invite.Password, []byte(teamID))
+	if err != nil {
+		return RedeemedInvite{}, ErrInviteInvalid
+	}
+	redeemed.Password = string(password)
+	return redeemed, nil
+}
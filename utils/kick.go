@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ErrKicked is returned when a removed member tries to rejoin or start.
+var ErrKicked = errors.New("this username was removed from the team")
+
+// WasKicked reports whether username was removed with 'axle team kick'.
+func (c AxleConfig) WasKicked(username string) bool {
+	for _, name := range c.Kicked {
+		if name == username {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	kickedMu sync.RWMutex
+	kicked   map[string]bool
+)
+
+// SetKicked registers the team's removed members, so their messages are
+// ignored.
+func SetKicked(names []string) {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	kickedMu.Lock()
+	defer kickedMu.Unlock()
+	kicked = set
+}
+
+// IsKicked reports whether a member was removed from the team.
+func IsKicked(username string) bool {
+	kickedMu.RLock()
+	defer kickedMu.RUnlock()
+	return kicked[username]
+}
+
+// removedFromTeam reports whether this node's member was kicked, telling
+// them so.
+func removedFromTeam(cfg AppConfig) bool {
+	if !IsKicked(cfg.Username) {
+		return false
+	}
+	log.Printf("[KICK] You were removed from team %s; stopping", cfg.TeamID)
+	SendNotification("Axle - Removed from team", fmt.Sprintf("You were removed from team %s", cfg.TeamID))
+	return true
+}
+
+// KickMember removes username from the team: it is recorded in the team
+// config, which only the admin can sign, so every daemon ignores their
+// messages, and their membership and presence are purged. It returns how
+// many of their nodes were online.
+func KickMember(ctx context.Context, cfg AppConfig, username string) (int, error) {
+	teamConfig, err := GetTeamConfigForUpdate(ctx, cfg)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case username == cfg.Username:
+		return 0, fmt.Errorf("you can't kick yourself; use 'axle leave'")
+	case username == teamConfig.Admin:
+		return 0, fmt.Errorf("%s is the team owner and can't be kicked", username)
+	case teamConfig.WasKicked(username):
+		return 0, fmt.Errorf("%s was already kicked", username)
+	}
+
+	teamConfig.Kicked = append(append([]string(nil), teamConfig.Kicked...), username)
+	if teamConfig.AuthoritativeNode == username {
+		teamConfig.AuthoritativeNode = ""
+	}
+	priority := []string{}
+	for _, peer := range teamConfig.PeerPriority {
+		if peer != username {
+			priority = append(priority, peer)
+		}
+	}
+	teamConfig.PeerPriority = priority
+	if _, ok := teamConfig.Roles[username]; ok {
+		roles := make(map[string]string, len(teamConfig.Roles))
+		for name, role := range teamConfig.Roles {
+			if name != username {
+				roles[name] = role
+			}
+		}
+		teamConfig.Roles = roles
+	}
+	// A guest's token stops working too
+	teamConfig.Guests = append([]GuestPass(nil), teamConfig.Guests...)
+	for i, guest := range teamConfig.Guests {
+		if guest.Name == username {
+			teamConfig.Guests[i].TokenHash = ""
+		}
+	}
+	if err := SaveTeamConfig(ctx, cfg.RedisClient, teamConfig); err != nil {
+		return 0, err
+	}
+
+	if err := UnregisterMember(ctx, cfg.RedisClient, cfg.TeamID, username); err != nil {
+		return 0, err
+	}
+	return purgePresence(ctx, cfg, username)
+}
+
+// UnkickMember lets a removed member join again.
+func UnkickMember(ctx context.Context, cfg AppConfig, username string) error {
+	teamConfig, err := GetTeamConfigForUpdate(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if !teamConfig.WasKicked(username) {
+		return fmt.Errorf("%s was not kicked", username)
+	}
+	remaining := []string{}
+	for _, name := range teamConfig.Kicked {
+		if name != username {
+			remaining = append(remaining, name)
+		}
+	}
+	teamConfig.Kicked = remaining
+	return SaveTeamConfig(ctx, cfg.RedisClient, teamConfig)
+}
+
+// purgePresence deletes the presence entries of username's nodes and says
+// goodbye for them, so they drop out of everyone's team table at once.
+func purgePresence(ctx context.Context, cfg AppConfig, username string) (int, error) {
+	presence, err := GetTeamPresence(ctx, cfg)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, info := range presence {
+		if info.Username != username {
+			continue
+		}
+		if err := cfg.RedisClient.Del(ctx, presenceKey(cfg.TeamID, info.NodeID)).Err(); err != nil {
+			return purged, fmt.Errorf("failed to purge presence of %s: %w", username, err)
+		}
+		purged++
+		goodbye := PresenceMessage{Type: "goodbye", NodeID: info.NodeID, Username: username, IPAddress: info.IPAddress, Timestamp: time.Now().Unix()}
+		if err := PublishMessage(ctx, cfg.Transport, PresenceChannel(cfg.TeamID), goodbye); err != nil {
+			log.Printf("[KICK] Failed to announce that %s left: %v", username, err)
+		}
+	}
+	return purged, nil
+}
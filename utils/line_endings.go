@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Line-ending conversions a teammate's patches may need when they come from
+// a checkout with different line endings, e.g. Windows with autocrlf.
+const (
+	EOLToLF   = "crlf-to-lf"
+	EOLToCRLF = "lf-to-crlf"
+)
+
+var lineEndingsMu sync.Mutex
+
+func lineEndingsFile(rootDir string) string {
+	return AxlePath(rootDir, "line_endings.json")
+}
+
+// loadLineEndings reads the conversions that made peers' patches apply,
+// keyed by peer.
+func loadLineEndings(rootDir string) (map[string]string, error) {
+	conversions := make(map[string]string)
+	data, err := os.ReadFile(lineEndingsFile(rootDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return conversions, nil
+		}
+		return nil, fmt.Errorf("failed to read line-ending conversions: %w", err)
+	}
+	if err := json.Unmarshal(data, &conversions); err != nil {
+		return nil, fmt.Errorf("failed to parse line-ending conversions: %w", err)
+	}
+	return conversions, nil
+}
+
+// PeerLineEndings returns the conversion recorded for a peer's patches, or "".
+func PeerLineEndings(rootDir, peer string) string {
+	lineEndingsMu.Lock()
+	defer lineEndingsMu.Unlock()
+	conversions, err := loadLineEndings(rootDir)
+	if err != nil {
+		return ""
+	}
+	return conversions[peer]
+}
+
+// recordLineEndings remembers the conversion that made a peer's patch
+// apply, so their next patches are converted up front. "" forgets it.
+func recordLineEndings(rootDir, peer, conversion string) error {
+	lineEndingsMu.Lock()
+	defer lineEndingsMu.Unlock()
+	conversions, err := loadLineEndings(rootDir)
+	if err != nil {
+		return err
+	}
+	if conversions[peer] == conversion {
+		return nil
+	}
+	if conversion == "" {
+		delete(conversions, peer)
+	} else {
+		conversions[peer] = conversion
+	}
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", AxleDirName, err)
+	}
+	data, err := json.MarshalIndent(conversions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal line-ending conversions: %w", err)
+	}
+	return os.WriteFile(lineEndingsFile(rootDir), data, 0644)
+}
+
+// ApplyPatchWithLineEndings applies a peer's patch with apply, converting
+// line endings when that is all that stands in the way. A peer whose
+// patches needed a conversion before gets it up front; when a patch fails
+// only because its line endings differ from the files it changes, it is
+// retried converted, through apply so the conflict strategy still holds,
+// and the conversion recorded.
+func ApplyPatchWithLineEndings(directory, peer, patch string, apply func(patch string) (bool, error)) (bool, error) {
+	known := PeerLineEndings(directory, peer)
+	if known != "" {
+		if autoCommitted, err := apply(ConvertPatchLineEndings(patch, known)); err == nil {
+			return autoCommitted, nil
+		}
+		// Their setup may have changed; start over from what they sent
+		cleanupGitState(directory)
+	}
+
+	autoCommitted, err := apply(patch)
+	if err == nil {
+		if known != "" {
+			log.Printf("[EOL] %s's patches apply without converting their line endings again", peer)
+			if err := recordLineEndings(directory, peer, ""); err != nil {
+				log.Printf("[EOL] Failed to forget the conversion for %s: %v", peer, err)
+			}
+		}
+		return autoCommitted, nil
+	}
+	conversion := lineEndingMismatch(directory, patch)
+	if conversion == "" {
+		return autoCommitted, err
+	}
+
+	cleanupGitState(directory)
+	autoCommitted, retryErr := apply(ConvertPatchLineEndings(patch, conversion))
+	if retryErr != nil {
+		log.Printf("[EOL] Converting %s's patch (%s) did not help: %v", peer, conversion, retryErr)
+		return autoCommitted, err
+	}
+	log.Printf("[EOL] Applied %s's patch after converting its line endings (%s); their next patches are converted up front", peer, conversion)
+	if err := recordLineEndings(directory, peer, conversion); err != nil {
+		log.Printf("[EOL] Failed to record the conversion for %s: %v", peer, err)
+	}
+	return autoCommitted, nil
+}
+
+// lineEndingMismatch returns the conversion a patch needs when the lines it
+// expects end differently from the files it changes, or "" when line
+// endings aren't the problem.
+func lineEndingMismatch(directory, patch string) string {
+	patchCRLF, patchLF := hunkLineEndings(patch)
+	for _, file := range extractFilesFromPatch(patch) {
+		data, err := os.ReadFile(filepath.Join(directory, filepath.FromSlash(file)))
+		if err != nil || bytes.IndexByte(data, 0) >= 0 {
+			continue // New or binary
+		}
+		crlf := bytes.Count(data, []byte("\r\n"))
+		lf := bytes.Count(data, []byte("\n")) - crlf
+		switch {
+		case patchCRLF > 0 && patchLF == 0 && crlf == 0 && lf > 0:
+			return EOLToLF
+		case patchLF > 0 && patchCRLF == 0 && lf == 0 && crlf > 0:
+			return EOLToCRLF
+		}
+	}
+	return ""
+}
+
+// hunkLineEndings counts the context and removed lines of a patch, the
+// ones that must match the files, ending in CRLF and in LF.
+func hunkLineEndings(patch string) (crlf, lf int) {
+	forEachHunkLine(patch, func(line string) string {
+		if line[0] == ' ' || line[0] == '-' {
+			if strings.HasSuffix(line, "\r") {
+				crlf++
+			} else {
+				lf++
+			}
+		}
+		return line
+	})
+	return crlf, lf
+}
+
+// ConvertPatchLineEndings converts the line endings of a patch's hunk lines.
+// Headers and the commit message are left as they are.
+func ConvertPatchLineEndings(patch, conversion string) string {
+	return forEachHunkLine(patch, func(line string) string {
+		trimmed := strings.TrimSuffix(line, "\r")
+		if conversion == EOLToCRLF {
+			return trimmed + "\r"
+		}
+		return trimmed
+	})
+}
+
+// forEachHunkLine passes the content lines of a patch's hunks, without their
+// "\n", through edit and returns the edited patch. Each hunk is as long as
+// its "@@" header says, so a removed line reading "- " isn't mistaken for
+// the signature separator of a format-patch.
+func forEachHunkLine(patch string, edit func(line string) string) string {
+	lines := strings.Split(patch, "\n")
+	oldLeft, newLeft := 0, 0
+	for i, line := range lines {
+		if oldLeft <= 0 && newLeft <= 0 {
+			if strings.HasPrefix(line, "@@ ") {
+				_, oldLeft, newLeft, _ = parseHunkHeader(strings.TrimSuffix(line, "\r"))
+			}
+			continue
+		}
+		switch {
+		case line == "" || line == "\r" || line[0] == ' ':
+			// Some editors strip the space off empty context lines
+			oldLeft--
+			newLeft--
+		case line[0] == '-':
+			oldLeft--
+		case line[0] == '+':
+			newLeft--
+		default:
+			continue // "\ No newline at end of file"
+		}
+		if line != "" {
+			lines[i] = edit(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
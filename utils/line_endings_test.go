@@ -0,0 +1,23 @@
+package utils
+
+import "testing"
+
+func TestConvertPatchLineEndingsKeepsRemovedDashLines(t *testing.T) {
+	// The second hunk line removes a line reading "- ", which looks like the
+	// format-patch signature separator
+	patch := "Subject: [PATCH] list\n---\n list.md | 2 +-\n\n" +
+		"diff --git a/list.md b/list.md\n--- a/list.md\n+++ b/list.md\n" +
+		"@@ -1,3 +1,3 @@\n one\n-- \n+- two\n three\n" +
+		"-- \n2.43.0\n"
+	want := "Subject: [PATCH] list\n---\n list.md | 2 +-\n\n" +
+		"diff --git a/list.md b/list.md\n--- a/list.md\n+++ b/list.md\n" +
+		"@@ -1,3 +1,3 @@\n one\r\n-- \r\n+- two\r\n three\r\n" +
+		"-- \n2.43.0\n"
+
+	if got := ConvertPatchLineEndings(patch, EOLToCRLF); got != want {
+		t.Errorf("ConvertPatchLineEndings:\ngot  %q\nwant %q", got, want)
+	}
+	if crlf, lf := hunkLineEndings(patch); crlf != 0 || lf != 3 {
+		t.Errorf("hunkLineEndings = %d CRLF, %d LF; want 0, 3", crlf, lf)
+	}
+}
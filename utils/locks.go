@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrFileLocked is returned when a teammate already holds the lock on a file.
+var ErrFileLocked = errors.New("file is locked")
+
+// FileLock is an advisory claim on a file, so teammates know to leave it alone.
+type FileLock struct {
+	File      string `json:"file"`
+	Owner     string `json:"owner"`
+	NodeID    string `json:"nodeID"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func locksKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:locks", teamID)
+}
+
+// normalizeLockPath turns a user-supplied path into the repository-relative
+// form used as the lock key.
+func normalizeLockPath(file string) (string, error) {
+	if err := validatePatchPath(file); err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(filepath.Clean(file)), nil
+}
+
+// LockFile claims file for this member. Locking a file you already hold
+// refreshes the claim; a teammate's lock is returned with ErrFileLocked.
+func LockFile(ctx context.Context, cfg AppConfig, file string) (FileLock, error) {
+	path, err := normalizeLockPath(file)
+	if err != nil {
+		return FileLock{}, err
+	}
+
+	lock := FileLock{File: path, Owner: cfg.Username, NodeID: cfg.NodeID, Timestamp: time.Now().Unix()}
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return FileLock{}, err
+	}
+
+	acquired, err := cfg.RedisClient.HSetNX(ctx, locksKey(cfg.TeamID), path, data).Result()
+	if err != nil {
+		return FileLock{}, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	if acquired {
+		return lock, nil
+	}
+
+	existing, err := getFileLock(ctx, cfg, path)
+	if err != nil {
+		return FileLock{}, err
+	}
+	if existing.Owner != cfg.Username {
+		return existing, fmt.Errorf("%w by %s since %s", ErrFileLocked, existing.Owner, time.Unix(existing.Timestamp, 0).Format("15:04"))
+	}
+	if err := cfg.RedisClient.HSet(ctx, locksKey(cfg.TeamID), path, data).Err(); err != nil {
+		return FileLock{}, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	return lock, nil
+}
+
+// UnlockFile releases this member's lock on file.
+func UnlockFile(ctx context.Context, cfg AppConfig, file string) error {
+	path, err := normalizeLockPath(file)
+	if err != nil {
+		return err
+	}
+
+	existing, err := getFileLock(ctx, cfg, path)
+	if err != nil {
+		return err
+	}
+	if existing.Owner == "" {
+		return fmt.Errorf("%s is not locked", path)
+	}
+	if existing.Owner != cfg.Username {
+		return fmt.Errorf("%s is locked by %s; only they can unlock it", path, existing.Owner)
+	}
+	if err := cfg.RedisClient.HDel(ctx, locksKey(cfg.TeamID), path).Err(); err != nil {
+		return fmt.Errorf("failed to unlock %s: %w", path, err)
+	}
+	return nil
+}
+
+// getFileLock returns the lock on path, or a zero FileLock if it is unlocked.
+func getFileLock(ctx context.Context, cfg AppConfig, path string) (FileLock, error) {
+	data, err := cfg.RedisClient.HGet(ctx, locksKey(cfg.TeamID), path).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return FileLock{}, nil
+		}
+		return FileLock{}, fmt.Errorf("failed to read lock on %s: %w", path, err)
+	}
+	var lock FileLock
+	if err := json.Unmarshal([]byte(data), &lock); err != nil {
+		return FileLock{}, fmt.Errorf("corrupt lock on %s: %w", path, err)
+	}
+	return lock, nil
+}
+
+// GetFileLocks returns every file lock held in the team, sorted by path.
+func GetFileLocks(ctx context.Context, cfg AppConfig) ([]FileLock, error) {
+	entries, err := cfg.RedisClient.HGetAll(ctx, locksKey(cfg.TeamID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list file locks: %w", err)
+	}
+
+	locks := make([]FileLock, 0, len(entries))
+	for _, entry := range entries {
+		var lock FileLock
+		if err := json.Unmarshal([]byte(entry), &lock); err == nil {
+			locks = append(locks, lock)
+		}
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].File < locks[j].File })
+	return locks, nil
+}
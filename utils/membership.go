@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// MembersKey returns the Redis key of the set holding registered team usernames.
+func MembersKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:members", teamID)
+}
+
+// RegisterMember adds a username to the team's membership registry.
+func RegisterMember(ctx context.Context, rdb redis.UniversalClient, teamID, username string) error {
+	if err := rdb.SAdd(ctx, MembersKey(teamID), username).Err(); err != nil {
+		return fmt.Errorf("failed to register member %s: %w", username, err)
+	}
+	return nil
+}
+
+// UnregisterMember removes a username from the team's membership registry.
+func UnregisterMember(ctx context.Context, rdb redis.UniversalClient, teamID, username string) error {
+	if err := rdb.SRem(ctx, MembersKey(teamID), username).Err(); err != nil {
+		return fmt.Errorf("failed to unregister member %s: %w", username, err)
+	}
+	return nil
+}
+
+// GetMembers returns the registered team usernames in sorted order.
+func GetMembers(ctx context.Context, rdb redis.UniversalClient, teamID string) ([]string, error) {
+	members, err := rdb.SMembers(ctx, MembersKey(teamID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team members: %w", err)
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// LeaveTeam announces that this node is leaving, removes its presence entry,
+// and unregisters its username. Team-level settings that reference the
+// username (priority order, authoritative node) are cleaned up as well.
+func LeaveTeam(ctx context.Context, cfg AppConfig) error {
+	if err := sendPresenceMessage(ctx, cfg, "goodbye"); err != nil {
+		return fmt.Errorf("failed to send goodbye message: %w", err)
+	}
+
+	CleanupPresence(ctx, cfg)
+
+	if err := UnregisterMember(ctx, cfg.RedisClient, cfg.TeamID, cfg.Username); err != nil {
+		return err
+	}
+
+	teamConfig, err := GetVerifiedTeamConfig(ctx, cfg.RedisClient, cfg.TeamID, cfg.TeamAdminKey)
+	if errors.Is(err, ErrTeamConfigTampered) {
+		// Leaving still works; the cleanup is left to the admin rather than
+		// signing a config someone changed in Redis
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	changed := false
+	if teamConfig.AuthoritativeNode == cfg.Username {
+		teamConfig.AuthoritativeNode = ""
+		changed = true
+	}
+	priority := []string{}
+	for _, peer := range teamConfig.PeerPriority {
+		if peer == cfg.Username {
+			changed = true
+			continue
+		}
+		priority = append(priority, peer)
+	}
+	teamConfig.PeerPriority = priority
+
+	if changed {
+		// Only the admin can re-sign the config; they can drop us from it later
+		if err := SaveTeamConfig(ctx, cfg.RedisClient, teamConfig); err != nil && !errors.Is(err, ErrNotTeamAdmin) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Kinds of messages the team's limits apply to
+const (
+	LimitChat = "chat"
+	LimitSync = "sync"
+)
+
+const (
+	// limitWindow is the period the per-minute budgets cover
+	limitWindow = time.Minute
+	// receiveBudgetSlack lets a sender's messages arrive bunched up: a
+	// receiver only drops traffic that is well past the team's budget
+	receiveBudgetSlack = 2
+)
+
+// MessageLimits caps the size of chat and sync messages, protecting the
+// shared server from a runaway member. Sizes are in KB; 0 means no limit.
+type MessageLimits struct {
+	ChatMessageKB   int `json:"chatMessageKB,omitempty"`
+	ChatKBPerMinute int `json:"chatKBPerMinute,omitempty"`
+	SyncMessageKB   int `json:"syncMessageKB,omitempty"`
+	SyncKBPerMinute int `json:"syncKBPerMinute,omitempty"`
+}
+
+// For returns the per-message and per-minute limits for a kind of message.
+func (l MessageLimits) For(kind string) (messageKB, perMinuteKB int) {
+	if kind == LimitChat {
+		return l.ChatMessageKB, l.ChatKBPerMinute
+	}
+	return l.SyncMessageKB, l.SyncKBPerMinute
+}
+
+var (
+	// ErrMessageTooLarge is returned for a message over the team's per-message limit.
+	ErrMessageTooLarge = errors.New("message is over the team's size limit")
+	// ErrMessageQuota is returned when the team's per-minute budget is used up.
+	ErrMessageQuota = errors.New("team's per-minute message budget is used up")
+)
+
+// QuotaError tells a sender when the per-minute budget has room again.
+type QuotaError struct {
+	Kind        string
+	PerMinuteKB int
+	RetryAt     time.Time
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("the team allows %d KB of %s messages per minute; try again at %s",
+		e.PerMinuteKB, e.Kind, e.RetryAt.Format("15:04:05"))
+}
+
+func (e *QuotaError) Unwrap() error { return ErrMessageQuota }
+
+// usage is what was sent or received in the current window.
+type usage struct {
+	at   time.Time
+	size int
+}
+
+var (
+	limitsMu      sync.Mutex
+	messageLimits MessageLimits
+	sentUsage     = make(map[string][]usage) // Kind -> messages we sent
+	receivedUsage = make(map[string][]usage) // Kind and sender -> messages they sent
+)
+
+// SetMessageLimits registers the team's message limits.
+func SetMessageLimits(limits MessageLimits) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	messageLimits = limits
+}
+
+// GetMessageLimits returns the team's message limits.
+func GetMessageLimits() MessageLimits {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	return messageLimits
+}
+
+// CheckOutgoing checks a message of size bytes about to be published
+// against the team's limits, counting it toward the budget when it fits.
+func CheckOutgoing(kind string, size int) error {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+
+	messageKB, perMinuteKB := messageLimits.For(kind)
+	if messageKB > 0 && size > messageKB*1024 {
+		return fmt.Errorf("%s %w: %d KB, limit %d KB", kind, ErrMessageTooLarge, sizeKB(size), messageKB)
+	}
+	if perMinuteKB > 0 && size > perMinuteKB*1024 {
+		return fmt.Errorf("%s %w: %d KB, more than the %d KB allowed per minute", kind, ErrMessageTooLarge, sizeKB(size), perMinuteKB)
+	}
+
+	now := time.Now()
+	window := pruneUsage(sentUsage[kind], now)
+	if perMinuteKB > 0 && usedBytes(window)+size > perMinuteKB*1024 {
+		sentUsage[kind] = window
+		return &QuotaError{Kind: kind, PerMinuteKB: perMinuteKB, RetryAt: window[0].at.Add(limitWindow)}
+	}
+	sentUsage[kind] = append(window, usage{at: now, size: size})
+	return nil
+}
+
+// AdmitIncoming checks a message of size bytes from sender against the
+// team's limits. Senders enforce the limits themselves, so anything over
+// them comes from an older release or a misbehaving client and is dropped.
+func AdmitIncoming(kind, sender string, size int) error {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+
+	messageKB, perMinuteKB := messageLimits.For(kind)
+	if messageKB > 0 && size > messageKB*1024 {
+		return fmt.Errorf("%s %w: %d KB, limit %d KB", kind, ErrMessageTooLarge, sizeKB(size), messageKB)
+	}
+
+	key := kind + "\x00" + sender
+	now := time.Now()
+	window := pruneUsage(receivedUsage[key], now)
+	if perMinuteKB > 0 && usedBytes(window)+size > receiveBudgetSlack*perMinuteKB*1024 {
+		receivedUsage[key] = window
+		return fmt.Errorf("%s sent over %d KB of %s messages in the last minute: %w",
+			sender, receiveBudgetSlack*perMinuteKB, kind, ErrMessageQuota)
+	}
+	receivedUsage[key] = append(window, usage{at: now, size: size})
+	return nil
+}
+
+// CheckOutgoingBatch checks a batch about to be published against the
+// team's sync limits.
+func CheckOutgoingBatch(metadata SyncMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch: %w", err)
+	}
+	return CheckOutgoing(LimitSync, len(data))
+}
+
+// CheckOutgoingChat checks a chat message about to be published against
+// the team's chat limits.
+func CheckOutgoingChat(msg ChatMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chat message: %w", err)
+	}
+	return CheckOutgoing(LimitChat, len(data))
+}
+
+var quotaLoggedUntil time.Time // Only touched by the publisher
+
+// holdOversizedBatch handles a batch CheckOutgoingBatch refused, and
+// reports whether it was held. Batches over the per-minute budget aren't;
+// they are retried once the budget has room.
+func holdOversizedBatch(cfg AppConfig, metadata SyncMetadata, err error) bool {
+	var quota *QuotaError
+	if errors.As(err, &quota) {
+		if !quota.RetryAt.Equal(quotaLoggedUntil) {
+			quotaLoggedUntil = quota.RetryAt
+			log.Printf("[LIMITS] Holding back %d changes: %v", len(metadata.Changes), err)
+		}
+		return false
+	}
+	if !errors.Is(err, ErrMessageTooLarge) {
+		log.Printf("[LIMITS] Failed to check batch size: %v", err)
+		return false
+	}
+
+	id, holdErr := HoldBatch(cfg.RootDir, HeldOversized, metadata)
+	if holdErr != nil {
+		log.Printf("[LIMITS] ❌ Batch refused (%v) and could not be held: %v", err, holdErr)
+		return false
+	}
+	log.Printf("[LIMITS] ❌ Held batch %s with %d changes: %v. Ask a team admin to raise the limit with 'axle team limits', then publish it with 'axle push-protected --confirm'",
+		id, len(metadata.Changes), err)
+	SendNotification("Axle - Changes not synced", fmt.Sprintf("A batch of %d changes is over the team's sync message limit", len(metadata.Changes)))
+	return true
+}
+
+// pruneUsage drops usage older than the budget window.
+func pruneUsage(window []usage, now time.Time) []usage {
+	cutoff := now.Add(-limitWindow)
+	kept := window[:0]
+	for _, u := range window {
+		if u.at.After(cutoff) {
+			kept = append(kept, u)
+		}
+	}
+	return kept
+}
+
+// sizeKB rounds a size in bytes up to KB.
+func sizeKB(size int) int {
+	return (size + 1023) / 1024
+}
+
+func usedBytes(window []usage) int {
+	total := 0
+	for _, u := range window {
+		total += u.size
+	}
+	return total
+}
+
+var (
+	droppedMu     sync.Mutex
+	droppedLogged = make(map[string]time.Time) // Kind and sender -> last drop logged
+)
+
+// ReportDroppedMessage logs a message dropped by AdmitIncoming, at most once
+// a minute per sender and kind so a flood doesn't flood the log too.
+func ReportDroppedMessage(kind, sender string, err error) {
+	droppedMu.Lock()
+	defer droppedMu.Unlock()
+	key := kind + "\x00" + sender
+	if last, ok := droppedLogged[key]; ok && time.Since(last) < limitWindow {
+		return
+	}
+	droppedLogged[key] = time.Now()
+	log.Printf("[LIMITS] ⚠️  Dropped %s message from %s: %v", kind, sender, err)
+}
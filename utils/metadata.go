@@ -7,20 +7,54 @@ import (
 
 // Struct for individual file changes
 type FileChange struct {
-	File        string `json:"file"`
-	Event       string `json:"event"`
-	CommitHash  string `json:"commit_hash,omitempty"`
-	Patch       string `json:"patch,omitempty"`
-	NewBlobID   string `json:"new_blob_id,omitempty"`
-	PrevBlobID  string `json:"prev_blob_id,omitempty"`
+	File       string `json:"file"`
+	Event      string `json:"event"`
+	CommitHash string `json:"commit_hash,omitempty"`
+	Patch      string `json:"patch,omitempty"`
+	NewBlobID  string `json:"new_blob_id,omitempty"`
+	PrevBlobID string `json:"prev_blob_id,omitempty"`
+	TraceID    string `json:"trace_id,omitempty"` // Correlation ID assigned when the change was captured
+	// Large-file fields (Event "placeholder", or "chunked" for files sent with 'axle force-sync')
+	Size      int64  `json:"size,omitempty"`
+	Hash      string `json:"hash,omitempty"`
+	Owner     string `json:"owner,omitempty"`
+	OwnerNode string `json:"owner_node,omitempty"`
+	Store     string `json:"store,omitempty"` // Where "chunked" content is: "" for Redis, "shared" for the shared blob directory, "direct" with its owner (also for patches)
+	// Append-only fields (Event "appended"): base64 bytes written at Offset
+	Offset int64  `json:"offset,omitempty"`
+	Data   string `json:"data,omitempty"`
+	// How Patch is encoded; "gzip" (base64) in batches trimmed for low-bandwidth peers
+	Encoding string `json:"encoding,omitempty"`
+	// Metadata-only fields: the old path of a "renamed" file, the git mode
+	// (e.g. "100755") of a "chmod", the nanosecond mtime of a "touched" file
+	From  string `json:"from,omitempty"`
+	Mode  string `json:"mode,omitempty"`
+	Mtime int64  `json:"mtime,omitempty"`
 }
 
 // Struct for batch sync metadata
 type SyncMetadata struct {
-	Version   int          `json:"version"`
-	Timestamp int64        `json:"timestamp"`
-	PeerID    string       `json:"peer_id"`
-	Changes   []FileChange `json:"changes"`
+	Version   int    `json:"version"`
+	BatchID   string `json:"batch_id,omitempty"` // Identifies the batch for delivery ACKs
+	Timestamp int64  `json:"timestamp"`          // By the sender's clock
+	Seq       int64  `json:"seq,omitempty"`      // Team-wide sequence number, for ordering independent of clocks
+	PeerID    string `json:"peer_id"`
+	// Who made the changes, so applied commits keep their author in git blame
+	AuthorName  string       `json:"author_name,omitempty"`
+	AuthorEmail string       `json:"author_email,omitempty"`
+	Changes     []FileChange `json:"changes"`
+	// How the sender compresses patches: "gzip", or "none" when the team
+	// turned it off. Releases before wire compression leave it out.
+	Compression string `json:"compression,omitempty"`
+}
+
+// Author returns who the batch's changes are attributed to. Batches from
+// older peers carry no author, so their peer ID is used as the name.
+func (m SyncMetadata) Author() CommitAuthor {
+	if m.AuthorName == "" {
+		return CommitAuthor{Name: m.PeerID}
+	}
+	return CommitAuthor{Name: m.AuthorName, Email: m.AuthorEmail}
 }
 
 // Save metadata to JSON file
@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata-only events. The content of the file is unchanged, so when a
+// commit has nothing else they travel without a patch and receivers apply
+// them directly.
+const (
+	EventRenamed = "renamed" // Moved from From to File
+	EventChmod   = "chmod"   // Executable bit changed; Mode is the new git mode
+	EventTouched = "touched" // Only the modification time changed; Mtime has it
+	EventMkdir   = "mkdir"   // An empty directory, which git can't carry
+)
+
+// IsMetadataEvent reports whether event leaves file content alone.
+func IsMetadataEvent(event string) bool {
+	switch event {
+	case EventRenamed, EventChmod, EventTouched, EventMkdir:
+		return true
+	}
+	return false
+}
+
+// describeCommit lists what a commit changed, one change per file, with
+// pure renames and mode-only changes as metadata events. metadataOnly is
+// true when nothing else changed, so the commit needs no patch. A team that
+// doesn't sync deletions still gets renames as a patch, which it can strip
+// down to the new file.
+func describeCommit(cfg AppConfig, commitHash string) (changes []FileChange, metadataOnly bool, err error) {
+	excludeAxle := ":(exclude,glob,icase)**/" + AxleDirName + "/**"
+	output, err := GitCommand("-C", cfg.RootDir, "diff-tree", "-r", "-M", "--raw", "-z", "--no-commit-id", "--root", commitHash, "--", ".", excludeAxle).Output()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list files in commit %s: %w", commitHash, err)
+	}
+
+	metadataOnly = true
+	fields := strings.Split(string(output), "\x00")
+	for i := 0; i+1 < len(fields); i++ {
+		// :<old mode> <new mode> <old blob> <new blob> <status>, then the path(s)
+		info := strings.Fields(strings.TrimPrefix(fields[i], ":"))
+		if len(info) != 5 {
+			continue
+		}
+		oldMode, newMode, oldBlob, newBlob, status := info[0], info[1], info[2], info[3], info[4]
+		change := FileChange{File: fields[i+1], Event: "modified", CommitHash: commitHash}
+		i++
+
+		switch status[0] {
+		case 'A':
+			change.Event = "created"
+		case 'D':
+			change.Event = "deleted"
+		case 'R':
+			if i+1 >= len(fields) {
+				continue
+			}
+			change.From, change.File = change.File, fields[i+1]
+			change.Event = EventRenamed
+			i++
+		case 'M':
+			if oldBlob == newBlob && oldMode != newMode {
+				change.Event = EventChmod
+				change.Mode = newMode
+			}
+		}
+		// A rename that also edited the file still needs the patch
+		pure := oldBlob == newBlob && (change.Event == EventChmod ||
+			(change.Event == EventRenamed && oldMode == newMode && cfg.FeatureEnabled(FeatureSyncDeletes)))
+		if !pure {
+			metadataOnly = false
+		}
+		changes = append(changes, change)
+	}
+	return changes, metadataOnly && len(changes) > 0, nil
+}
+
+// queueAttributeChange handles a chmod or touch of a tracked file. A changed
+// executable bit goes through the batch, whose commit shows it as a mode
+// change; a file that is otherwise unchanged only had its time set, which
+// is sent as is.
+func queueAttributeChange(cfg AppConfig, absPath, relPath string) {
+	if UsingEmbeddedBackend() {
+		return
+	}
+	info, err := os.Stat(absPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+	// New files are sent when they are created
+	if GitCommand("-C", cfg.RootDir, "ls-files", "--error-unmatch", "--", relPath).Run() != nil {
+		return
+	}
+	diff, err := GitCommand("-C", cfg.RootDir, "diff", "--numstat", "HEAD", "--", relPath).Output()
+	if err != nil {
+		return
+	}
+	if len(strings.TrimSpace(string(diff))) > 0 {
+		addToBatch(cfg, relPath, "modified")
+		return
+	}
+
+	change := FileChange{File: relPath, Event: EventTouched, Mtime: info.ModTime().UnixNano(), TraceID: GenerateTraceID()}
+	mu.Lock()
+	queueChanges(cfg.RootDir, change)
+	mu.Unlock()
+	Events.Publish(TopicFileChanged, FileChangedEvent{Path: relPath, Event: change.Event, TraceID: change.TraceID})
+}
+
+// queueEmptyDirectories takes new empty directories out of the pending
+// batch and sends them as mkdir events, since a commit can't hold them. The
+// caller must hold batchMutex.
+func queueEmptyDirectories(cfg AppConfig) {
+	var queued []FileChange
+	for path, event := range pendingFiles {
+		if event != "created" {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(cfg.RootDir, filepath.FromSlash(path)))
+		if err != nil || len(entries) > 0 {
+			continue
+		}
+		queued = append(queued, FileChange{File: path, Event: EventMkdir, TraceID: pendingTraces[path]})
+		delete(pendingFiles, path)
+		delete(pendingTraces, path)
+	}
+	if len(queued) == 0 {
+		return
+	}
+
+	mu.Lock()
+	queueChanges(cfg.RootDir, queued...)
+	mu.Unlock()
+}
+
+// ApplyDeletion removes a file or directory a teammate deleted. A path that
+// is already gone counts as deleted.
+func ApplyDeletion(rootDir string, change FileChange) error {
+	if err := validatePatchPath(change.File); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(rootDir, filepath.FromSlash(change.File))); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s: %w", change.File, err)
+	}
+	return nil
+}
+
+// ApplyMetadataChange applies a metadata-only event from a teammate.
+func ApplyMetadataChange(rootDir string, change FileChange) error {
+	if err := validatePatchPath(change.File); err != nil {
+		return err
+	}
+	target := filepath.Join(rootDir, filepath.FromSlash(change.File))
+
+	switch change.Event {
+	case EventRenamed:
+		if err := validatePatchPath(change.From); err != nil {
+			return err
+		}
+		if _, err := os.Lstat(target); err == nil {
+			return fmt.Errorf("can't move %s to %s: it already exists", change.From, change.File)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", change.File, err)
+		}
+		if err := os.Rename(filepath.Join(rootDir, filepath.FromSlash(change.From)), target); err != nil {
+			return fmt.Errorf("failed to move %s to %s: %w", change.From, change.File, err)
+		}
+	case EventChmod:
+		mode, err := strconv.ParseUint(change.Mode, 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid mode %q for %s", change.Mode, change.File)
+		}
+		perm := os.FileMode(0644)
+		if mode&0111 != 0 {
+			perm = 0755
+		}
+		if err := os.Chmod(target, perm); err != nil {
+			return fmt.Errorf("failed to change the mode of %s: %w", change.File, err)
+		}
+	case EventTouched:
+		mtime := time.Unix(0, change.Mtime)
+		if err := os.Chtimes(target, time.Now(), mtime); err != nil {
+			return fmt.Errorf("failed to set the time of %s: %w", change.File, err)
+		}
+	case EventMkdir:
+		if err := os.MkdirAll(target, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", change.File, err)
+		}
+	default:
+		return fmt.Errorf("%s is not a metadata event", change.Event)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mirrorEntry is one row of a mirror directory listing.
+type mirrorEntry struct {
+	Name    string
+	URL     string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+}
+
+var mirrorListingTemplate = template.Must(template.New("listing").Funcs(template.FuncMap{
+	"size": formatMirrorSize,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Team}}: /{{.Path}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; }
+td { padding: 0.2em 1.5em 0.2em 0; }
+.meta { color: #777; }
+a { text-decoration: none; }
+</style>
+</head>
+<body>
+<h2>🔄 {{.Team}}: /{{.Path}}</h2>
+<p class="meta">Read-only mirror served by Axle · updated {{.Now.Format "15:04:05"}}</p>
+<table>
+{{if .Path}}<tr><td><a href="../">⬆️ ..</a></td></tr>{{end}}
+{{range .Entries}}<tr>
+{{if .IsDir}}<td><a href="{{.URL}}/">📁 {{.Name}}/</a></td><td></td>
+{{else}}<td><a href="{{.URL}}">📄 {{.Name}}</a></td><td class="meta">{{size .Size}}</td>{{end}}
+<td class="meta">{{.ModTime.Format "Jan 02 15:04"}}</td>
+{{if not .IsDir}}<td><a href="{{.URL}}?download=1">⬇️ download</a></td>{{end}}
+</tr>{{end}}
+</table>
+</body>
+</html>
+`))
+
+// MirrorHandler serves a read-only, browsable view of the synced tree. Paths
+// Axle doesn't sync (.git, .axle, ignore patterns) are hidden.
+type MirrorHandler struct {
+	RootDir        string
+	TeamID         string
+	IgnorePatterns []string
+}
+
+// ServeHTTP lists directories as HTML and serves files inline, or as
+// attachments with ?download=1.
+func (h MirrorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "read-only mirror", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Synced files are untrusted content; never let them run scripts
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; img-src 'self'; style-src 'unsafe-inline'; sandbox")
+
+	relPath := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	absPath := filepath.Join(h.RootDir, filepath.FromSlash(relPath))
+	// "" is the root's listing
+	if relPath != "" && (validatePatchPath(relPath) != nil || isIgnored(absPath, h.IgnorePatterns)) {
+		http.NotFound(w, r)
+		return
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if info.IsDir() {
+		if relPath != "" && !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		h.serveListing(w, relPath, absPath)
+		return
+	}
+
+	file, err := os.Open(absPath)
+	if err != nil {
+		http.Error(w, "cannot read file", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	switch {
+	case r.URL.Query().Get("download") != "":
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+info.Name()+"\"")
+	case !isBinaryFile(absPath):
+		// Show source code as text instead of rendering or running it in the
+		// browser; images and PDFs keep their own type
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+// serveListing renders the HTML listing of a directory.
+func (h MirrorHandler) serveListing(w http.ResponseWriter, relPath, absPath string) {
+	dirEntries, err := os.ReadDir(absPath)
+	if err != nil {
+		http.Error(w, "cannot read directory", http.StatusInternalServerError)
+		return
+	}
+
+	var entries []mirrorEntry
+	for _, dirEntry := range dirEntries {
+		if isIgnored(filepath.Join(absPath, dirEntry.Name()), h.IgnorePatterns) {
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, mirrorEntry{
+			Name:    dirEntry.Name(),
+			URL:     url.PathEscape(dirEntry.Name()),
+			IsDir:   dirEntry.IsDir(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+
+	// Directories first, then files, each alphabetically
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir != entries[j].IsDir {
+			return entries[i].IsDir
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	mirrorListingTemplate.Execute(w, struct {
+		Team    string
+		Path    string
+		Now     time.Time
+		Entries []mirrorEntry
+	}{h.TeamID, relPath, time.Now(), entries})
+}
+
+// formatMirrorSize renders a byte count for directory listings.
+func formatMirrorSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return strconv.FormatFloat(float64(size)/(1<<20), 'f', 1, 64) + " MB"
+	case size >= 1<<10:
+		return strconv.FormatFloat(float64(size)/(1<<10), 'f', 1, 64) + " KB"
+	default:
+		return strconv.FormatInt(size, 10) + " B"
+	}
+}
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// networkCheckInterval is how often the network interfaces and the
+	// route to the internet are checked for a change
+	networkCheckInterval = 3 * time.Second
+	// reannounceTimeout bounds retrying the announce while a new network
+	// is still coming up
+	reannounceTimeout = time.Minute
+)
+
+// NetworkChangedEvent is the payload for TopicNetworkChanged.
+type NetworkChangedEvent struct {
+	OldIP string
+	NewIP string
+}
+
+// networkState is what identifies the network the machine is on: the
+// address the route to the internet leaves from, and the addresses of the
+// interfaces that are up.
+type networkState struct {
+	localIP   string
+	addresses string
+}
+
+// currentNetworkState reads the network state.
+func currentNetworkState() networkState {
+	var addresses []string
+	interfaces, _ := net.Interfaces()
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			// IPv6 link-local addresses don't move with the network
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			addresses = append(addresses, iface.Name+"="+addr.String())
+		}
+	}
+	sort.Strings(addresses)
+	return networkState{localIP: GetLocalIPAddress(), addresses: strings.Join(addresses, ",")}
+}
+
+// StartNetworkMonitor watches for the machine moving to another network,
+// e.g. a laptop switching Wi-Fi. Connections made on the old network die
+// without an error, so on a change they are dropped and redialed at once,
+// and presence is announced again with the new address.
+func StartNetworkMonitor(ctx context.Context, cfg AppConfig) {
+	state := currentNetworkState()
+	ticker := time.NewTicker(networkCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			current := currentNetworkState()
+			if current == state {
+				continue
+			}
+			previous := state
+			state = current
+			if IsOfflineMode() {
+				continue // Reconnecting is up to the offline watcher
+			}
+
+			if previous.localIP != current.localIP {
+				log.Printf("[NETWORK] Network changed (%s -> %s); reconnecting", previous.localIP, current.localIP)
+			} else {
+				log.Printf("[NETWORK] Network interfaces changed; reconnecting")
+			}
+			ResetConnections(cfg)
+			Events.Publish(TopicNetworkChanged, NetworkChangedEvent{OldIP: previous.localIP, NewIP: current.localIP})
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reannouncePresence announces this node again after a network change,
+// retrying while the new network comes up.
+func reannouncePresence(ctx context.Context, cfg AppConfig) {
+	ctx, cancel := context.WithTimeout(ctx, reannounceTimeout)
+	defer cancel()
+
+	delay := time.Second
+	for {
+		err := sendPresenceMessage(ctx, cfg, "announce")
+		if err == nil {
+			log.Printf("[PRESENCE] Re-announced presence at %s", GetLocalIPAddress())
+			return
+		}
+		select {
+		case <-time.After(delay):
+			delay *= 2
+		case <-ctx.Done():
+			log.Printf("[PRESENCE] Failed to re-announce presence after the network change: %v", err)
+			return
+		}
+	}
+}
+
+// reconnecter is a transport that can drop its connection and redial.
+type reconnecter interface {
+	reconnect() error
+}
+
+// ResetConnections drops the connections to the team's server so the next
+// command redials them on the current network. go-redis replaces a pooled
+// connection that fails, and resubscribes its subscriptions.
+func ResetConnections(cfg AppConfig) {
+	if n := closeRedisConns(); n > 0 {
+		log.Printf("[REDIS] Dropped %d connections made on the previous network", n)
+	}
+	if r, ok := cfg.Transport.(reconnecter); ok {
+		if err := r.reconnect(); err != nil {
+			log.Printf("[%s] Failed to reconnect: %v", strings.ToUpper(cfg.Transport.Name()), err)
+		}
+	}
+}
+
+// Connections to Redis are tracked so a network change can close them; the
+// clients don't offer a way to drop their pooled connections.
+var (
+	redisConnsMu sync.Mutex
+	redisConns   = make(map[*trackedConn]struct{})
+)
+
+// trackedConn is a Redis connection that forgets itself once closed.
+type trackedConn struct {
+	net.Conn
+	once sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(func() {
+		redisConnsMu.Lock()
+		delete(redisConns, c)
+		redisConnsMu.Unlock()
+	})
+	return c.Conn.Close()
+}
+
+// trackRedisConn registers a new Redis connection.
+func trackRedisConn(conn net.Conn) net.Conn {
+	tracked := &trackedConn{Conn: conn}
+	redisConnsMu.Lock()
+	redisConns[tracked] = struct{}{}
+	redisConnsMu.Unlock()
+	return tracked
+}
+
+// closeRedisConns closes every open Redis connection, returning how many.
+func closeRedisConns() int {
+	redisConnsMu.Lock()
+	conns := make([]*trackedConn, 0, len(redisConns))
+	for conn := range redisConns {
+		conns = append(conns, conn)
+	}
+	redisConnsMu.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+	return len(conns)
+}
@@ -9,6 +9,11 @@ import (
 
 // SendNotification sends a desktop notification
 func SendNotification(title, message string) error {
+	// Notifications spawn external processes; skip them to save power
+	if IsLowPowerMode() {
+		return nil
+	}
+
 	switch runtime.GOOS {
 	case "windows":
 		return sendWindowsNotification(title, message)
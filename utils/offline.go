@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// reconnectInterval is how often an offline daemon checks whether Redis is
+// reachable again.
+const reconnectInterval = 30 * time.Second
+
+var (
+	offlineMode atomic.Bool
+	catchingUp  atomic.Bool
+	// syncNowCh carries 'axle sync-now' requests to the reconnect loop
+	syncNowCh = make(chan struct{}, 1)
+)
+
+// SetOfflineMode turns offline mode on or off. While offline the watcher
+// keeps committing locally and moves every change to the outbox instead of
+// publishing it.
+func SetOfflineMode(on bool) {
+	offlineMode.Store(on)
+
+	publisherMu.Lock()
+	defer publisherMu.Unlock()
+	if on {
+		publisherStatus.State = PublisherOffline
+		return
+	}
+	publisherStatus.State = PublisherNormal
+	publisherStatus.NextAttempt = 0
+	publishBackoff = initialPublishBackoff
+}
+
+// IsOfflineMode reports whether the daemon is working offline.
+func IsOfflineMode() bool {
+	return offlineMode.Load()
+}
+
+// SetCatchingUp holds local changes in the outbox while the daemon replays
+// the team batches it missed, so a stale node doesn't publish changes that
+// conflict with all of them.
+func SetCatchingUp(on bool) {
+	catchingUp.Store(on)
+
+	publisherMu.Lock()
+	defer publisherMu.Unlock()
+	if on {
+		publisherStatus.State = PublisherCatchingUp
+	} else if publisherStatus.State == PublisherCatchingUp {
+		publisherStatus.State = PublisherNormal
+	}
+}
+
+// IsCatchingUp reports whether local publishes wait for a catch-up.
+func IsCatchingUp() bool {
+	return catchingUp.Load()
+}
+
+// RequestSyncNow asks an offline daemon to try reconnecting right away. It
+// reports false when the daemon isn't offline.
+func RequestSyncNow() bool {
+	if !IsOfflineMode() {
+		return false
+	}
+	select {
+	case syncNowCh <- struct{}{}:
+	default:
+		// A request is already pending
+	}
+	return true
+}
+
+// WaitForConnectivity blocks until Redis answers a PING. It tries whenever
+// 'axle sync-now' asks and, with auto set, every reconnectInterval.
+func WaitForConnectivity(ctx context.Context, rdb redis.UniversalClient, auto bool) error {
+	var tick <-chan time.Time
+	if auto {
+		ticker := time.NewTicker(reconnectInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		manual := false
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-syncNowCh:
+			manual = true
+		case <-tick:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err := rdb.Ping(pingCtx).Err()
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if manual {
+			log.Printf("[OFFLINE] Redis is still unreachable: %v", err)
+		}
+	}
+}
+
+// spillForOffline moves the in-memory change buffer to the outbox, so work
+// done offline survives a restart. The caller must hold mu.
+func spillForOffline(rootDir string) {
+	if len(changes) > 0 {
+		if err := spillChanges(rootDir, changes); err != nil {
+			log.Printf("[OUTBOX] Failed to save %d offline changes to disk: %v", len(changes), err)
+		} else {
+			resetChangeBuffer()
+		}
+	}
+
+	publisherMu.Lock()
+	publisherStatus.QueuedChanges = len(changes) + spilledChanges
+	publisherMu.Unlock()
+}
+
+func teamConfigCacheFile(rootDir string) string {
+	return AxlePath(rootDir, "team.json")
+}
+
+// CacheTeamConfig keeps a copy of the verified team config, so the daemon
+// can start offline with the team's settings and check the password.
+func CacheTeamConfig(rootDir string, teamConfig AxleConfig) error {
+	data, err := json.MarshalIndent(teamConfig, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal team config: %w", err)
+	}
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", AxleDirName, err)
+	}
+	return os.WriteFile(teamConfigCacheFile(rootDir), data, 0600)
+}
+
+// LoadCachedTeamConfig returns the team config saved by the last online start.
+func LoadCachedTeamConfig(rootDir string) (AxleConfig, error) {
+	var teamConfig AxleConfig
+	data, err := os.ReadFile(teamConfigCacheFile(rootDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return teamConfig, fmt.Errorf("no saved team settings; run 'axle start' online once before working offline")
+		}
+		return teamConfig, fmt.Errorf("failed to read saved team settings: %w", err)
+	}
+	if err := json.Unmarshal(data, &teamConfig); err != nil {
+		return teamConfig, fmt.Errorf("failed to parse saved team settings: %w", err)
+	}
+	return teamConfig, nil
+}
+
+// FlushOfflineChanges saves changes still in memory to the outbox when the
+// daemon stops while offline.
+func FlushOfflineChanges(rootDir string) {
+	mu.Lock()
+	defer mu.Unlock()
+	spillForOffline(rootDir)
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// maxBufferedChanges and maxBufferedBytes bound the in-memory changes
+	// buffer. Past either limit, queued changes spill to the on-disk outbox so
+	// a long Redis outage during heavy editing can't exhaust memory.
+	maxBufferedChanges = 5000
+	maxBufferedBytes   = 32 * 1024 * 1024
+)
+
+var (
+	// Guarded by mu, like changes
+	bufferedBytes  int64
+	spilledChanges int
+)
+
+// outboxDir holds changes spilled from memory, oldest first by file name.
+func outboxDir(rootDir string) string {
+	return AxlePath(rootDir, "outbox")
+}
+
+// changeSize estimates the memory a queued change holds.
+func changeSize(change FileChange) int64 {
+	return int64(len(change.Patch) + len(change.Data))
+}
+
+// queueChanges adds changes to the publish buffer, spilling the whole buffer
+// to the outbox once it grows past its limits. The caller must hold mu.
+func queueChanges(rootDir string, queued ...FileChange) {
+	changes = append(changes, queued...)
+	for _, change := range queued {
+		bufferedBytes += changeSize(change)
+	}
+	if len(changes) < maxBufferedChanges && bufferedBytes < maxBufferedBytes {
+		return
+	}
+
+	if err := spillChanges(rootDir, changes); err != nil {
+		// Keeping the changes in memory is better than losing them
+		log.Printf("[OUTBOX] Failed to spill %d queued changes to disk: %v", len(changes), err)
+		return
+	}
+	log.Printf("[OUTBOX] Change buffer full; spilled %d changes (%d KB) to disk until publishing recovers",
+		len(changes), bufferedBytes/1024)
+	changes = nil
+	bufferedBytes = 0
+}
+
+// resetChangeBuffer empties the in-memory buffer after its changes were
+// published or held. The caller must hold mu.
+func resetChangeBuffer() {
+	changes = nil
+	bufferedBytes = 0
+}
+
+// spillChanges writes changes to a new outbox file.
+func spillChanges(rootDir string, spilled []FileChange) error {
+	dir := outboxDir(rootDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create outbox: %w", err)
+	}
+
+	// Zero-padded timestamps keep file names in spill order
+	path := filepath.Join(dir, fmt.Sprintf("%020d.json", time.Now().UnixNano()))
+	if err := SaveMetadata(SyncMetadata{Version: 1, Changes: spilled}, path); err != nil {
+		return err
+	}
+	spilledChanges += len(spilled)
+	return nil
+}
+
+// takeSpilledChanges loads the oldest outbox files, merged into one list of
+// changes up to the buffer's byte limit (always at least one file), along
+// with the files they came from. The caller must hold mu.
+func takeSpilledChanges(rootDir string) ([]FileChange, []string) {
+	entries, err := os.ReadDir(outboxDir(rootDir))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[OUTBOX] Failed to read outbox: %v", err)
+		}
+		return nil, nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var merged []FileChange
+	var paths []string
+	var size int64
+	for _, name := range names {
+		path := filepath.Join(outboxDir(rootDir), name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("[OUTBOX] Failed to read %s: %v", name, err)
+			continue
+		}
+		var metadata SyncMetadata
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			log.Printf("[OUTBOX] Skipping corrupt outbox file %s: %v", name, err)
+			continue
+		}
+
+		var fileSize int64
+		for _, change := range metadata.Changes {
+			fileSize += changeSize(change)
+		}
+		if len(paths) > 0 && size+fileSize > maxBufferedBytes {
+			break
+		}
+		merged = append(merged, metadata.Changes...)
+		paths = append(paths, path)
+		size += fileSize
+	}
+
+	return merged, paths
+}
+
+// loadOutboxCount counts the changes left in the outbox, e.g. by a daemon
+// that stopped before Redis recovered, so they are published first.
+func loadOutboxCount(rootDir string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	spilledChanges = 0
+	files, _ := filepath.Glob(filepath.Join(outboxDir(rootDir), "*.json"))
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var metadata SyncMetadata
+		if err := json.Unmarshal(data, &metadata); err == nil {
+			spilledChanges += len(metadata.Changes)
+		}
+	}
+	if spilledChanges > 0 {
+		log.Printf("[OUTBOX] %d changes from a previous session are waiting to be published", spilledChanges)
+	}
+}
+
+// removeSpilledChanges deletes outbox files once their changes were
+// published or held. The caller must hold mu.
+func removeSpilledChanges(paths []string, count int) {
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("[OUTBOX] Failed to remove %s: %v", path, err)
+		}
+	}
+	spilledChanges -= count
+	if spilledChanges < 0 {
+		spilledChanges = 0
+	}
+}
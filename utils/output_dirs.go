@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// outputDirs are the directories inside the sync root, relative to it with
+// forward slashes, that generated output is written to: backups, exports,
+// recordings, archives, the shared blob directory. Nothing in them is
+// watched, committed or accepted from a teammate's patch, or every export
+// would set off another round of sync.
+var (
+	outputDirsMu sync.RWMutex
+	outputDirs   []string
+)
+
+// SetOutputDirs registers the generated-output directories of rootDir,
+// replacing the ones registered before. Relative directories are relative
+// to rootDir; directories outside it can't loop back and are left out.
+func SetOutputDirs(rootDir string, dirs []string) error {
+	var rels []string
+	for _, dir := range dirs {
+		rel, inside, err := outputDirRel(rootDir, dir)
+		if err != nil {
+			return err
+		}
+		if inside && !contains(rels, rel) {
+			rels = append(rels, rel)
+		}
+	}
+
+	outputDirsMu.Lock()
+	defer outputDirsMu.Unlock()
+	outputDirs = rels
+	return nil
+}
+
+// RegisterOutputDir adds one generated-output directory, for features that
+// write into a directory the user picks.
+func RegisterOutputDir(rootDir, dir string) error {
+	rel, inside, err := outputDirRel(rootDir, dir)
+	if err != nil || !inside {
+		return err
+	}
+
+	outputDirsMu.Lock()
+	defer outputDirsMu.Unlock()
+	if !contains(outputDirs, rel) {
+		outputDirs = append(outputDirs, rel)
+	}
+	return nil
+}
+
+func outputDirRel(rootDir, dir string) (string, bool, error) {
+	if strings.TrimSpace(dir) == "" {
+		return "", false, nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(rootDir, dir)
+	}
+	rel, err := filepath.Rel(rootDir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false, nil
+	}
+	if rel == "." {
+		return "", false, fmt.Errorf("output directory %s is the sync root itself", dir)
+	}
+	return filepath.ToSlash(rel), true, nil
+}
+
+// outputDirOf returns the generated-output directory that holds relPath (a
+// path relative to the sync root), or "" when it is in none.
+func outputDirOf(relPath string) string {
+	relPath = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(relPath)), "./")
+	outputDirsMu.RLock()
+	defer outputDirsMu.RUnlock()
+	for _, dir := range outputDirs {
+		if relPath == dir || strings.HasPrefix(relPath, dir+"/") {
+			return dir
+		}
+	}
+	return ""
+}
+
+// isOutputPath reports whether an absolute path is in a generated-output
+// directory of rootDir.
+func isOutputPath(rootDir, path string) bool {
+	rel, err := filepath.Rel(rootDir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return outputDirOf(rel) != ""
+}
+
+// patchHeaderOutputDir returns the generated-output directory a diff header
+// line names a path in, or "".
+func patchHeaderOutputDir(line string) string {
+	for _, field := range strings.Fields(line) {
+		field = strings.Trim(field, `"`)
+		if strings.HasPrefix(field, "a/") || strings.HasPrefix(field, "b/") {
+			field = field[2:]
+		}
+		if dir := outputDirOf(field); dir != "" {
+			return dir
+		}
+	}
+	return ""
+}
+
+// excludeOutputDirs keeps the generated-output directories out of git, so
+// commits that stage everything (checkpoints, conflict resolution) skip
+// them too. Files git already tracks there are reported, not untracked:
+// untracking would delete them for the whole team.
+func excludeOutputDirs(rootDir string) error {
+	outputDirsMu.RLock()
+	dirs := append([]string(nil), outputDirs...)
+	outputDirsMu.RUnlock()
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	patterns := make([]string, len(dirs))
+	for i, dir := range dirs {
+		patterns[i] = "/" + dir + "/"
+	}
+	if err := EnsureGitExclude(rootDir, patterns...); err != nil {
+		return err
+	}
+
+	if UsingEmbeddedBackend() {
+		return nil
+	}
+	args := append([]string{"-C", rootDir, "ls-files", "--"}, dirs...)
+	if output, err := GitCommand(args...).Output(); err == nil && len(output) > 0 {
+		log.Printf("[AXLE] Some files in the output directories %s are tracked by git; changes to them are still committed until you run 'git rm -r --cached' on them", strings.Join(dirs, ", "))
+	}
+	return nil
+}
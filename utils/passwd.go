@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordChange announces that the team password changed. It is signed
+// with the signing key of the old password, which members still hold, so
+// nobody without the old password can make daemons stop with it.
+type PasswordChange struct {
+	ChangedBy    string `json:"changedBy"`
+	PasswordHash string `json:"passwordHash"` // New password hash, as in the team config
+	KeyCheck     string `json:"keyCheck"`     // Fingerprint of the new signing key
+	Timestamp    int64  `json:"timestamp"`
+}
+
+var (
+	passwordMu sync.Mutex
+	// passwordHash is the hash of the password the signing key in use is
+	// derived from, "" when this node has none
+	passwordHash string
+	// passwordChanged tells StartTeamWatcher who changed the password when
+	// this node's signing key is outdated
+	passwordChanged = make(chan string, 1)
+)
+
+// SetPasswordHash records the hash of the password this node's signing key
+// is derived from.
+func SetPasswordHash(hash string) {
+	passwordMu.Lock()
+	defer passwordMu.Unlock()
+	passwordHash = hash
+}
+
+// passwordOutdated reports whether the team password is no longer the one
+// this node's signing key is derived from.
+func passwordOutdated(teamHash string) bool {
+	passwordMu.Lock()
+	defer passwordMu.Unlock()
+	return passwordHash != "" && teamHash != passwordHash
+}
+
+// signingKeyCheck fingerprints a signing key, so a daemon can tell whether
+// the key it holds is the new one without being sent the key.
+func signingKeyCheck(key []byte) string {
+	sum := sha256.Sum256(append([]byte("axle-key-check:"), key...))
+	return hex.EncodeToString(sum[:])
+}
+
+// currentKeyCheck fingerprints the signing key in use, "" without one.
+func currentKeyCheck() string {
+	signingMu.RLock()
+	defer signingMu.RUnlock()
+	if len(signingKey) == 0 {
+		return ""
+	}
+	return signingKeyCheck(signingKey)
+}
+
+// ChangeTeamPassword replaces the team password, which only the admin can
+// do as the team config has to be re-signed. This node switches to the
+// signing key of the new password, and the team is told, so members'
+// daemons, whose keys no longer match, stop until restarted with it.
+func ChangeTeamPassword(ctx context.Context, cfg AppConfig, current, password string) error {
+	teamConfig, err := GetTeamConfigForUpdate(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(teamConfig.PasswordHash), []byte(current)); err != nil {
+		return errors.New(T("error.invalid_password"))
+	}
+	if current == password {
+		return fmt.Errorf("the new password is the same as the current one")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	teamConfig.PasswordHash = string(hash)
+	if err := SaveTeamConfig(ctx, cfg.RedisClient, teamConfig); err != nil {
+		return err
+	}
+
+	// Stored before the announcement, so a daemon running here finds it;
+	// the announcement itself is signed with the old key
+	key := DeriveSigningKey(cfg.TeamID, password)
+	if err := writeSigningKey(cfg.RootDir, key); err != nil {
+		return err
+	}
+	SetSigningKey(DeriveSigningKey(cfg.TeamID, current))
+	change := PasswordChange{
+		ChangedBy:    cfg.Username,
+		PasswordHash: teamConfig.PasswordHash,
+		KeyCheck:     signingKeyCheck(key),
+		Timestamp:    time.Now().Unix(),
+	}
+	err = PublishMessage(ctx, cfg.Transport, PasswordChannel(cfg.TeamID), change)
+	SetSigningKey(key)
+	if err != nil {
+		return fmt.Errorf("password changed, but announcing it failed (members' daemons notice within %s): %w", teamCheckInterval, err)
+	}
+	return nil
+}
+
+// ProcessPasswordChange handles the announcement of a new team password. A
+// daemon whose .axle directory already holds the new signing key, as the
+// one of the member who changed it does, switches to it; any other member's
+// daemon stops, as its messages would no longer be accepted.
+func ProcessPasswordChange(cfg AppConfig, payload string) {
+	if !HasSigningKey() {
+		return // Guests have no password to change
+	}
+	if err := VerifyMessage(PasswordChannel(cfg.TeamID), payload); err != nil {
+		warnSignatureOnce("passwd\x00"+err.Error(), fmt.Sprintf("Ignoring a password change announcement: %v", err))
+		return
+	}
+	var change PasswordChange
+	if err := json.Unmarshal([]byte(payload), &change); err != nil {
+		log.Printf("[SECURITY] Error unmarshaling password change: %v", err)
+		return
+	}
+
+	if err := LoadSigningKey(cfg.RootDir); err != nil {
+		log.Printf("[SECURITY] %v", err)
+	}
+	if currentKeyCheck() == change.KeyCheck {
+		SetPasswordHash(change.PasswordHash)
+		log.Printf("[SECURITY] %s changed the team password; now signing with the new key", change.ChangedBy)
+		return
+	}
+	select {
+	case passwordChanged <- change.ChangedBy:
+	default:
+	}
+}
+
+// stopForPassword tells the member their daemon stops because the team
+// password changed.
+func stopForPassword(cfg AppConfig, changedBy string) {
+	log.Printf("[SECURITY] %s changed the password of team %s; stopping. Run 'axle start' with the new password", changedBy, cfg.TeamID)
+	SendNotification("Axle - Team password changed", fmt.Sprintf("%s changed the password of team %s; run 'axle start' with the new one", changedBy, cfg.TeamID))
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	globCache   = make(map[string]*regexp.Regexp)
+	globCacheMu sync.Mutex
+)
+
+// MatchGlob reports whether a slash-separated relative path matches a glob
+// pattern. Besides the usual '*' and '?' wildcards, '**' matches across
+// directory boundaries, so "infra/prod/**" matches everything under
+// infra/prod and "**/*.env" matches .env files at any depth.
+func MatchGlob(pattern, relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	pattern = filepath.ToSlash(pattern)
+
+	globCacheMu.Lock()
+	re, ok := globCache[pattern]
+	if !ok {
+		re = regexp.MustCompile(globToRegexp(pattern))
+		globCache[pattern] = re
+	}
+	globCacheMu.Unlock()
+
+	return re.MatchString(relPath)
+}
+
+// MatchAnyGlob reports whether a relative path matches any of the patterns.
+func MatchAnyGlob(patterns []string, relPath string) bool {
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegexp translates a glob pattern into an anchored regular expression.
+func globToRegexp(pattern string) string {
+	var re strings.Builder
+	re.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more leading directories
+					i++
+					re.WriteString("(?:.*/)?")
+				} else {
+					re.WriteString(".*")
+				}
+			} else {
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	return re.String()
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// pausedSince is when 'axle pause' was run, or 0 while syncing
+	pausedSince atomic.Int64
+	// pauseMu orders holding incoming batches against releasing them
+	pauseMu sync.Mutex
+)
+
+func pauseFile(rootDir string) string {
+	return AxlePath(rootDir, "paused")
+}
+
+// PausedSince returns when sync was paused, or the zero time if it isn't.
+func PausedSince() time.Time {
+	if since := pausedSince.Load(); since > 0 {
+		return time.Unix(since, 0)
+	}
+	return time.Time{}
+}
+
+// IsSyncPaused reports whether 'axle pause' is in effect.
+func IsSyncPaused() bool {
+	return pausedSince.Load() > 0
+}
+
+// LoadPauseState restores a pause from the daemon's last run, so a restart
+// in the middle of risky work doesn't start syncing again.
+func LoadPauseState(rootDir string) {
+	data, err := os.ReadFile(pauseFile(rootDir))
+	if err != nil {
+		return
+	}
+	since, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || since <= 0 {
+		since = time.Now().Unix()
+	}
+	pausedSince.Store(since)
+}
+
+// PauseSync stops publishing and applying changes until ResumeSync. Local
+// changes are still committed and wait in the outbox; team changes are held.
+func PauseSync(rootDir string) error {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	if IsSyncPaused() {
+		return nil
+	}
+	now := time.Now().Unix()
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", AxleDirName, err)
+	}
+	if err := os.WriteFile(pauseFile(rootDir), []byte(strconv.FormatInt(now, 10)), 0644); err != nil {
+		return fmt.Errorf("failed to record the pause: %w", err)
+	}
+	pausedSince.Store(now)
+	return nil
+}
+
+// HoldIfPaused keeps an incoming batch for ResumeSync while sync is paused.
+// It reports whether the batch was held.
+func HoldIfPaused(rootDir string, metadata SyncMetadata) (bool, error) {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+	if !IsSyncPaused() {
+		return false, nil
+	}
+	_, err := HoldBatch(rootDir, HeldPaused, metadata)
+	return true, err
+}
+
+// ResumeSync ends a pause. The batches held meanwhile are handed to apply,
+// oldest first, before any newer batch gets through. It returns how many
+// there were.
+func ResumeSync(rootDir string, apply func(SyncMetadata)) (int, error) {
+	pauseMu.Lock()
+	defer pauseMu.Unlock()
+
+	batches, err := ListHeldBatches(rootDir, HeldPaused, nil)
+	if err != nil {
+		return 0, err
+	}
+	for i, batch := range batches {
+		if err := ReleaseHeldBatch(batch); err != nil {
+			return i, err
+		}
+		apply(batch.Metadata)
+	}
+
+	if err := os.Remove(pauseFile(rootDir)); err != nil && !os.IsNotExist(err) {
+		return len(batches), fmt.Errorf("failed to clear the pause: %w", err)
+	}
+	pausedSince.Store(0)
+	return len(batches), nil
+}
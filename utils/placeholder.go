@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Placeholder describes a file that exists on a teammate's machine but was too
+// large to sync automatically.
+type Placeholder struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	Hash      string `json:"hash"`
+	Owner     string `json:"owner"`     // Username of the node holding the file
+	OwnerNode string `json:"ownerNode"` // Node ID of the node holding the file
+	Timestamp int64  `json:"timestamp"`
+}
+
+// FetchRequest asks the owner of a placeholder to upload the file's chunks.
+type FetchRequest struct {
+	RequestID string `json:"requestID"`
+	Path      string `json:"path"`
+	Hash      string `json:"hash"`
+	Requester string `json:"requester"`
+	NodeID    string `json:"nodeID"`
+	OwnerNode string `json:"ownerNode"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+var placeholderMu sync.Mutex
+
+func fetchResultKey(teamID, requestID string) string {
+	return fmt.Sprintf("axle:fetch:%s:%s", teamID, requestID)
+}
+
+func placeholderFile(rootDir string) string {
+	return AxlePath(rootDir, "placeholders.json")
+}
+
+// LoadPlaceholders reads the local registry of known large-file placeholders.
+func LoadPlaceholders(rootDir string) (map[string]Placeholder, error) {
+	placeholders := make(map[string]Placeholder)
+	data, err := os.ReadFile(placeholderFile(rootDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return placeholders, nil
+		}
+		return nil, fmt.Errorf("failed to read placeholders: %w", err)
+	}
+	if err := json.Unmarshal(data, &placeholders); err != nil {
+		return nil, fmt.Errorf("failed to parse placeholders: %w", err)
+	}
+	return placeholders, nil
+}
+
+// ListPlaceholders returns the known placeholders sorted by path.
+func ListPlaceholders(rootDir string) ([]Placeholder, error) {
+	placeholders, err := LoadPlaceholders(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Placeholder, 0, len(placeholders))
+	for _, placeholder := range placeholders {
+		list = append(list, placeholder)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list, nil
+}
+
+// updatePlaceholders applies an edit to the placeholder registry and saves it.
+func updatePlaceholders(rootDir string, edit func(map[string]Placeholder)) error {
+	placeholderMu.Lock()
+	defer placeholderMu.Unlock()
+
+	placeholders, err := LoadPlaceholders(rootDir)
+	if err != nil {
+		return err
+	}
+	edit(placeholders)
+
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", AxleDirName, err)
+	}
+	data, err := json.MarshalIndent(placeholders, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal placeholders: %w", err)
+	}
+	return os.WriteFile(placeholderFile(rootDir), data, 0644)
+}
+
+// RecordPlaceholder stores a placeholder received from a teammate.
+func RecordPlaceholder(rootDir string, change FileChange) error {
+	// 'axle fetch' writes the file where the placeholder says
+	if err := validatePatchPath(change.File); err != nil {
+		return err
+	}
+	return updatePlaceholders(rootDir, func(placeholders map[string]Placeholder) {
+		placeholders[change.File] = Placeholder{
+			Path:      change.File,
+			Size:      change.Size,
+			Hash:      change.Hash,
+			Owner:     change.Owner,
+			OwnerNode: change.OwnerNode,
+			Timestamp: time.Now().Unix(),
+		}
+	})
+}
+
+// RemovePlaceholder forgets a placeholder, e.g. after the file was fetched.
+func RemovePlaceholder(rootDir, path string) error {
+	return updatePlaceholders(rootDir, func(placeholders map[string]Placeholder) {
+		delete(placeholders, path)
+	})
+}
+
+// queueLargeFilePlaceholder queues a placeholder change for a file that is too
+// large to sync. It returns false if the file isn't oversized.
+func queueLargeFilePlaceholder(cfg AppConfig, absPath, relPath, event string) bool {
+	info, err := os.Stat(absPath)
+	if err != nil || info.IsDir() || info.Size() <= maxFileSize {
+		return false
+	}
+
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		log.Printf("[PLACEHOLDER] Cannot read %s: %v", relPath, err)
+		return true
+	}
+
+	mu.Lock()
+	queueChanges(cfg.RootDir, FileChange{
+		File:      relPath,
+		Event:     "placeholder",
+		Size:      info.Size(),
+		Hash:      HashContent(data),
+		Owner:     cfg.Username,
+		OwnerNode: cfg.NodeID,
+		TraceID:   GenerateTraceID(),
+	})
+	mu.Unlock()
+
+	log.Printf("[PLACEHOLDER] %s (%d bytes) exceeds the sync limit; sharing a placeholder instead (%s)", relPath, info.Size(), event)
+	return true
+}
+
+// FetchFile requests a placeholder's content from its owner and writes it into the working tree.
+func FetchFile(ctx context.Context, cfg AppConfig, placeholder Placeholder, timeout time.Duration) error {
+	// Registries written before placeholders were validated may hold any path
+	if err := validatePatchPath(placeholder.Path); err != nil {
+		return err
+	}
+	data, err := requestUpload(ctx, cfg, placeholder.Path, placeholder.Hash, placeholder.Owner, placeholder.OwnerNode, timeout)
+	if err != nil {
+		return err
+	}
+
+	fullPath := filepath.Join(cfg.RootDir, filepath.FromSlash(placeholder.Path))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", placeholder.Path, err)
+	}
+	if err := os.WriteFile(fullPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", placeholder.Path, err)
+	}
+	return RemovePlaceholder(cfg.RootDir, placeholder.Path)
+}
+
+// requestUpload asks the owner of some content to upload it through Redis
+// and waits for it.
+func requestUpload(ctx context.Context, cfg AppConfig, path, hash, owner, ownerNode string, timeout time.Duration) ([]byte, error) {
+	req := FetchRequest{
+		RequestID: GenerateNodeID(),
+		Path:      path,
+		Hash:      hash,
+		Requester: cfg.Username,
+		NodeID:    cfg.NodeID,
+		OwnerNode: ownerNode,
+		Timestamp: time.Now().Unix(),
+	}
+	if err := PublishMessage(ctx, cfg.Transport, FetchChannel(cfg.TeamID), req); err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", path, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		result, err := cfg.RedisClient.Get(ctx, fetchResultKey(cfg.TeamID, req.RequestID)).Result()
+		if err == nil {
+			if result != hash {
+				return nil, fmt.Errorf("%s: %s", owner, result)
+			}
+			return LoadChunks(ctx, cfg.RedisClient, cfg.TeamID, hash)
+		}
+		if err != redis.Nil {
+			return nil, fmt.Errorf("failed to read fetch result: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+
+	return nil, fmt.Errorf("%s did not serve %s within %v; make sure they are running 'axle start'", owner, path, timeout)
+}
+
+// validateFetchRequest checks that a fetch request names the hash of the
+// content it wants and a path inside the sync root.
+func validateFetchRequest(req FetchRequest) error {
+	if !validContentHash(req.Hash) {
+		return fmt.Errorf("request for %s has no valid content hash", req.Path)
+	}
+	return validatePatchPath(req.Path)
+}
+
+// ProcessFetchRequest serves a file to a teammate if this node owns it.
+// Only content matching the hash the requester names is served, and only
+// from inside the sync root, so a request can't read arbitrary files.
+func ProcessFetchRequest(ctx context.Context, cfg AppConfig, payload string) {
+	var req FetchRequest
+	if err := json.Unmarshal([]byte(payload), &req); err != nil {
+		log.Printf("[FETCH] Error unmarshaling fetch request: %v", err)
+		return
+	}
+	if req.OwnerNode != cfg.NodeID {
+		return
+	}
+	// Answering uploads files from this machine
+	if AdmitSigned(cfg, FetchChannel(cfg.TeamID), req.Requester, payload) != nil {
+		return
+	}
+	if AdmitFresh(FetchChannel(cfg.TeamID), req.Requester, payload, req.Timestamp) != nil {
+		return
+	}
+	if err := validateFetchRequest(req); err != nil {
+		log.Printf("[FETCH] Refused a request from %s: %v", req.Requester, err)
+		return
+	}
+
+	go func() {
+		resultKey := fetchResultKey(cfg.TeamID, req.RequestID)
+		// Content offered for a direct transfer is what a teammate who
+		// couldn't reach us asks for; otherwise it's the file as it is now
+		data, err := loadDirectOffer(cfg.RootDir, req.Hash)
+		if err != nil {
+			data, err = os.ReadFile(filepath.Join(cfg.RootDir, filepath.FromSlash(req.Path)))
+			if err != nil {
+				cfg.RedisClient.Set(ctx, resultKey, "file is no longer available", BlobTTL)
+				return
+			}
+			if HashContent(data) != req.Hash {
+				cfg.RedisClient.Set(ctx, resultKey, "file has changed since the placeholder was shared", BlobTTL)
+				return
+			}
+		}
+
+		hash, err := StoreChunks(ctx, cfg.RedisClient, cfg.TeamID, data)
+		if err != nil {
+			log.Printf("[FETCH] Failed to upload %s for %s: %v", req.Path, req.Requester, err)
+			cfg.RedisClient.Set(ctx, resultKey, "upload failed", BlobTTL)
+			return
+		}
+		cfg.RedisClient.Set(ctx, resultKey, hash, BlobTTL)
+		log.Printf("[FETCH] Served %s (%d bytes) to %s", req.Path, len(data), req.Requester)
+	}()
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// PluginPrefix is the executable name prefix that marks an Axle plugin.
+const PluginPrefix = "axle-"
+
+// Plugin is an external executable exposed as an 'axle <name>' subcommand.
+type Plugin struct {
+	Name string // Subcommand name, e.g. "dashboard" for axle-dashboard
+	Path string // Absolute path of the executable
+}
+
+// DiscoverPlugins finds executables named axle-<name> on PATH. When the same
+// name appears more than once, the first directory on PATH wins.
+func DiscoverPlugins() []Plugin {
+	seen := make(map[string]bool)
+	var plugins []Plugin
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasPrefix(name, PluginPrefix) {
+				continue
+			}
+
+			pluginName := strings.TrimPrefix(name, PluginPrefix)
+			if runtime.GOOS == "windows" {
+				ext := strings.ToLower(filepath.Ext(pluginName))
+				if ext != ".exe" && ext != ".bat" && ext != ".cmd" {
+					continue
+				}
+				pluginName = strings.TrimSuffix(pluginName, filepath.Ext(pluginName))
+			} else if info, err := entry.Info(); err != nil || info.Mode()&0111 == 0 {
+				continue
+			}
+
+			if pluginName == "" || seen[pluginName] {
+				continue
+			}
+			seen[pluginName] = true
+			plugins = append(plugins, Plugin{Name: pluginName, Path: filepath.Join(dir, name)})
+		}
+	}
+
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// PluginEnv returns the environment passed to plugins so they can find the
+// repository and the running daemon's control socket.
+func PluginEnv(rootDir, teamID, username string) []string {
+	env := os.Environ()
+	if rootDir != "" {
+		env = append(env,
+			"AXLE_ROOT="+rootDir,
+			"AXLE_CONTROL_SOCKET="+ControlSocketPath(rootDir),
+		)
+	}
+	if teamID != "" {
+		env = append(env, "AXLE_TEAM_ID="+teamID)
+	}
+	if username != "" {
+		env = append(env, "AXLE_USERNAME="+username)
+	}
+	return env
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Low-power mode settings
+const (
+	LowPowerOff  = "off"  // Never throttle
+	LowPowerOn   = "on"   // Always throttle
+	LowPowerAuto = "auto" // Throttle while running on battery
+
+	lowPowerBatchDuration    = 10 * time.Second
+	lowPowerPublishInterval  = 15 * time.Second
+	powerSourceCheckInterval = time.Minute
+)
+
+var (
+	lowPowerMu      sync.RWMutex
+	lowPowerEnabled bool
+)
+
+// SetLowPowerMode enables or disables low-power mode for the running daemon.
+func SetLowPowerMode(enabled bool) {
+	lowPowerMu.Lock()
+	defer lowPowerMu.Unlock()
+	if lowPowerEnabled != enabled {
+		if enabled {
+			log.Println("[POWER] Low-power mode enabled: longer batch windows, slower heartbeats, no notifications")
+		} else {
+			log.Println("[POWER] Low-power mode disabled")
+		}
+	}
+	lowPowerEnabled = enabled
+}
+
+// IsLowPowerMode reports whether low-power mode is currently active.
+func IsLowPowerMode() bool {
+	lowPowerMu.RLock()
+	defer lowPowerMu.RUnlock()
+	return lowPowerEnabled
+}
+
+// ValidateLowPowerSetting checks a low-power setting value.
+func ValidateLowPowerSetting(setting string) error {
+	switch setting {
+	case LowPowerOff, LowPowerOn, LowPowerAuto:
+		return nil
+	default:
+		return fmt.Errorf("invalid low-power setting: %s (use: off, on, or auto)", setting)
+	}
+}
+
+// StartPowerMonitor applies the low-power setting and, in auto mode, keeps
+// checking the power source so throttling follows the charger being plugged
+// in or removed.
+func StartPowerMonitor(ctx context.Context, setting string) {
+	switch setting {
+	case LowPowerOn:
+		SetLowPowerMode(true)
+		return
+	case LowPowerAuto:
+	default:
+		return
+	}
+
+	check := func() {
+		onBattery, err := IsOnBattery()
+		if err != nil {
+			return // Unknown power source; leave the mode unchanged
+		}
+		SetLowPowerMode(onBattery)
+	}
+
+	check()
+	ticker := time.NewTicker(powerSourceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			check()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// IsOnBattery reports whether the machine is currently running on battery power.
+func IsOnBattery() (bool, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return isOnBatteryLinux()
+	case "darwin":
+		output, err := exec.Command("pmset", "-g", "batt").Output()
+		if err != nil {
+			return false, err
+		}
+		return strings.Contains(string(output), "Battery Power"), nil
+	case "windows":
+		// BatteryStatus 1 means the battery is discharging
+		output, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
+			"(Get-CimInstance Win32_Battery).BatteryStatus").Output()
+		if err != nil {
+			return false, err
+		}
+		return strings.TrimSpace(string(output)) == "1", nil
+	default:
+		return false, fmt.Errorf("power source detection not supported on %s", runtime.GOOS)
+	}
+}
+
+func isOnBatteryLinux() (bool, error) {
+	supplies, err := filepath.Glob("/sys/class/power_supply/*")
+	if err != nil || len(supplies) == 0 {
+		return false, fmt.Errorf("no power supply information available")
+	}
+
+	hasBattery := false
+	for _, supply := range supplies {
+		supplyType, _ := os.ReadFile(filepath.Join(supply, "type"))
+		switch strings.TrimSpace(string(supplyType)) {
+		case "Mains", "USB":
+			if online, err := os.ReadFile(filepath.Join(supply, "online")); err == nil && strings.TrimSpace(string(online)) == "1" {
+				return false, nil
+			}
+		case "Battery":
+			hasBattery = true
+		}
+	}
+
+	if !hasBattery {
+		return false, fmt.Errorf("no battery found")
+	}
+	return true, nil
+}
+
+// ApplyResourceLimits caps the CPU parallelism and soft memory limit of the
+// process. Zero values leave the Go runtime defaults in place.
+func ApplyResourceLimits(maxProcs int, memoryLimitMB int) {
+	if maxProcs > 0 {
+		runtime.GOMAXPROCS(maxProcs)
+		log.Printf("[POWER] Limited CPU parallelism to %d", maxProcs)
+	}
+	if memoryLimitMB > 0 {
+		debug.SetMemoryLimit(int64(memoryLimitMB) * 1024 * 1024)
+		log.Printf("[POWER] Set soft memory limit to %d MB", memoryLimitMB)
+	}
+}
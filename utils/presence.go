@@ -7,15 +7,58 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	mathrand "math/rand"
 	"net"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/go-redis/redis/v8"
 )
 
 const (
-	HeartbeatInterval = 30 * time.Second
-	PresenceTimeout   = 60 * time.Second
+	HeartbeatInterval    = 30 * time.Second // Default heartbeat interval
+	PresenceTimeout      = 60 * time.Second // Default presence timeout (2× the default interval)
+	MinHeartbeatInterval = 5 * time.Second
+	// heartbeatJitter spreads heartbeats by up to ±10% so large teams don't
+	// hit Redis in lockstep
+	heartbeatJitter = 0.1
+)
+
+// presenceNames maps the node IDs heard from to their usernames, so an expiry
+// notice, which only names the key, can say who went offline
+var (
+	presenceNames   = make(map[string]string)
+	presenceNamesMu sync.Mutex
 )
 
+// EffectiveHeartbeatInterval returns the team's configured heartbeat interval,
+// falling back to the default when none is set.
+func EffectiveHeartbeatInterval(cfg AppConfig) time.Duration {
+	if cfg.HeartbeatInterval <= 0 {
+		return HeartbeatInterval
+	}
+	return cfg.HeartbeatInterval
+}
+
+// EffectivePresenceTimeout returns how long a member may stay silent before
+// being considered offline. It always scales to twice the heartbeat interval.
+func EffectivePresenceTimeout(cfg AppConfig) time.Duration {
+	return 2 * EffectiveHeartbeatInterval(cfg)
+}
+
+// nextHeartbeatDelay returns the delay until the next heartbeat, including
+// jitter. In low-power mode heartbeats are 1.5× slower, which together with
+// the jitter still stays below the presence timeout.
+func nextHeartbeatDelay(cfg AppConfig) time.Duration {
+	interval := EffectiveHeartbeatInterval(cfg)
+	if IsLowPowerMode() {
+		interval = interval * 3 / 2
+	}
+	jitter := (mathrand.Float64()*2 - 1) * heartbeatJitter * float64(interval)
+	return interval + time.Duration(jitter)
+}
+
 // GenerateNodeID creates a unique identifier for this node instance
 func GenerateNodeID() string {
 	bytes := make([]byte, 8)
@@ -42,20 +85,33 @@ func GetLocalIPAddress() string {
 
 // StartPresenceHeartbeat starts sending periodic heartbeat messages
 func StartPresenceHeartbeat(ctx context.Context, cfg AppConfig) {
-	ticker := time.NewTicker(HeartbeatInterval)
-	defer ticker.Stop()
+	if !cfg.FeatureEnabled(FeaturePresenceEnabled) {
+		log.Printf("[PRESENCE] Not sending heartbeats: %s is off for this team", FeaturePresenceEnabled)
+		return
+	}
+
+	// Use a timer so the interval follows low-power mode changes
+	timer := time.NewTimer(nextHeartbeatDelay(cfg))
+	defer timer.Stop()
 
 	// Send initial announce message
 	if err := sendPresenceMessage(ctx, cfg, "announce"); err != nil {
 		log.Printf("[PRESENCE] Failed to send announce message: %v", err)
 	}
 
+	// Teammates shouldn't wait a heartbeat for a new address
+	networkChanges, unsubscribe := Events.Subscribe(TopicNetworkChanged)
+	defer unsubscribe()
+
 	for {
 		select {
-		case <-ticker.C:
+		case <-networkChanges:
+			go reannouncePresence(ctx, cfg)
+		case <-timer.C:
 			if err := sendPresenceMessage(ctx, cfg, "heartbeat"); err != nil {
 				log.Printf("[PRESENCE] Failed to send heartbeat: %v", err)
 			}
+			timer.Reset(nextHeartbeatDelay(cfg))
 		case <-ctx.Done():
 			// Send goodbye message before exiting
 			if err := sendPresenceMessage(ctx, cfg, "goodbye"); err != nil {
@@ -66,7 +122,77 @@ func StartPresenceHeartbeat(ctx context.Context, cfg AppConfig) {
 	}
 }
 
-// sendPresenceMessage sends a presence message to Redis
+// presenceKey is the key holding a node's presence. It expires after the
+// presence timeout unless the node's next heartbeat renews it.
+func presenceKey(teamID, nodeID string) string {
+	return fmt.Sprintf("axle:team:%s:presence:%s", teamID, nodeID)
+}
+
+// PresenceExpiryPattern is the keyspace notification channel pattern that
+// reports the team's presence keys; Redis sends "expired" on it when a node
+// misses its heartbeats.
+func PresenceExpiryPattern(cfg AppConfig) string {
+	return fmt.Sprintf("__keyspace@%d__:%s", currentRedisAuth().DB, presenceKey(cfg.TeamID, "*"))
+}
+
+// EnablePresenceExpiryEvents turns on the keyspace notifications for expired
+// keys, keeping whatever else the server already notifies. Without them
+// (e.g. CONFIG is disabled on a managed Redis) members still drop out of
+// 'axle team' when their key expires; only the offline notice is lost.
+func EnablePresenceExpiryEvents(ctx context.Context, cfg AppConfig) {
+	current, err := cfg.RedisClient.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		log.Printf("[PRESENCE] Can't read keyspace notification settings (%v); offline notices are disabled", err)
+		return
+	}
+	var flags string
+	if len(current) == 2 {
+		flags, _ = current[1].(string)
+	}
+	hasKeyspace := strings.Contains(flags, "K")
+	hasExpired := strings.Contains(flags, "x") || strings.Contains(flags, "A")
+	if hasKeyspace && hasExpired {
+		return
+	}
+	if !hasKeyspace {
+		flags += "K"
+	}
+	if !hasExpired {
+		flags += "x"
+	}
+	if err := cfg.RedisClient.ConfigSet(ctx, "notify-keyspace-events", flags).Err(); err != nil {
+		log.Printf("[PRESENCE] Can't enable keyspace notifications (%v); offline notices are disabled", err)
+	}
+}
+
+// storePresence renews this node's presence key, or removes it on goodbye.
+func storePresence(ctx context.Context, cfg AppConfig, msg PresenceMessage) error {
+	key := presenceKey(cfg.TeamID, cfg.NodeID)
+	if msg.Type == "goodbye" {
+		return cfg.RedisClient.Del(ctx, key).Err()
+	}
+
+	info := PresenceInfo{
+		Username:     msg.Username,
+		Status:       "online",
+		LastSeen:     msg.Timestamp,
+		IPAddress:    msg.IPAddress,
+		NodeID:       msg.NodeID,
+		LatencyMs:    msg.LatencyMs,
+		Region:       msg.Region,
+		LowBandwidth: msg.LowBandwidth,
+		Guest:        msg.Guest,
+		DirectPort:   msg.DirectPort,
+		Spectator:    msg.Spectator,
+	}
+	infoJSON, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("failed to marshal presence info: %w", err)
+	}
+	return cfg.RedisClient.Set(ctx, key, infoJSON, EffectivePresenceTimeout(cfg)).Err()
+}
+
+// sendPresenceMessage stores this node's presence and announces it to the team
 func sendPresenceMessage(ctx context.Context, cfg AppConfig, msgType string) error {
 	msg := PresenceMessage{
 		Type:      msgType,
@@ -76,99 +202,179 @@ func sendPresenceMessage(ctx context.Context, cfg AppConfig, msgType string) err
 		Timestamp: time.Now().Unix(),
 	}
 
-	channel := fmt.Sprintf("axle:presence:%s", cfg.TeamID)
-	return PublishMessage(ctx, cfg.RedisClient, channel, msg)
+	if msgType != "goodbye" {
+		// Piggyback our Redis latency so the team table can show it
+		if cfg.HasRedis() {
+			if rtt, err := MeasureLatency(ctx, cfg.RedisClient); err == nil {
+				msg.LatencyMs = float64(rtt.Microseconds()) / 1000
+				msg.Region = ActiveRedisEndpoint().Region
+				observeLatency(rtt)
+			}
+		}
+		msg.LowBandwidth = IsLowBandwidthMode()
+		_, msg.Guest = GuestPassFor(cfg.Username)
+		msg.DirectPort = DirectTransferPort()
+		msg.Protocol = ProtocolVersion
+		msg.Capabilities = Capabilities()
+		msg.Spectator = IsSpectator(cfg.Username)
+	}
+
+	// Teams on NATS only have the announcements, not the stored presence
+	if cfg.HasRedis() {
+		if err := storePresence(ctx, cfg, msg); err != nil {
+			log.Printf("[PRESENCE] Error updating presence in Redis: %v", err)
+		}
+	}
+
+	channel := PresenceChannel(cfg.TeamID)
+	return PublishMessage(ctx, cfg.Transport, channel, msg)
 }
 
 // ProcessPresenceMessage processes incoming presence messages
 func ProcessPresenceMessage(ctx context.Context, cfg AppConfig, payload string) {
+	if !cfg.FeatureEnabled(FeaturePresenceEnabled) {
+		return
+	}
+
 	var msg PresenceMessage
 	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
 		log.Printf("[PRESENCE] Error unmarshaling presence message: %v", err)
 		return
 	}
 
-	// Don't process our own messages
-	if msg.NodeID == cfg.NodeID {
+	// Don't process our own messages, or a removed member's daemon that
+	// hasn't noticed yet
+	if msg.NodeID == cfg.NodeID || (msg.Type != "goodbye" && IsKicked(msg.Username)) {
 		return
 	}
+	// Presence decides who serves snapshots and direct transfers
+	if AdmitSigned(cfg, PresenceChannel(cfg.TeamID), msg.Username, payload) != nil {
+		return
+	}
+	if AdmitFresh(PresenceChannel(cfg.TeamID), msg.Username, payload, msg.Timestamp) != nil {
+		return
+	}
+
+	Events.Publish(TopicPresenceChanged, PresenceChangedEvent{Message: msg})
+	notePeerBandwidth(msg)
+	notePeerDirect(msg)
+	notePeerProtocol(msg)
 
-	// Update presence information in Redis
-	presenceKey := fmt.Sprintf("axle:team:%s:presence", cfg.TeamID)
-	
+	// Each node stores its own presence; remember names for expiry notices
 	switch msg.Type {
 	case "announce", "heartbeat":
-		info := PresenceInfo{
-			Username:  msg.Username,
-			Status:    "online",
-			LastSeen:  msg.Timestamp,
-			IPAddress: msg.IPAddress,
-			NodeID:    msg.NodeID,
-		}
-		
-		infoJSON, err := json.Marshal(info)
-		if err != nil {
-			log.Printf("[PRESENCE] Error marshaling presence info: %v", err)
-			return
-		}
-		
-		if err := cfg.RedisClient.HSet(ctx, presenceKey, msg.NodeID, infoJSON).Err(); err != nil {
-			log.Printf("[PRESENCE] Error updating presence in Redis: %v", err)
-			return
-		}
-		
+		presenceNamesMu.Lock()
+		presenceNames[msg.NodeID] = msg.Username
+		presenceNamesMu.Unlock()
 		if msg.Type == "announce" {
 			log.Printf("[PRESENCE] %s (%s) joined the team", msg.Username, msg.IPAddress)
 		}
-		
+
 	case "goodbye":
-		if err := cfg.RedisClient.HDel(ctx, presenceKey, msg.NodeID).Err(); err != nil {
-			log.Printf("[PRESENCE] Error removing presence from Redis: %v", err)
-			return
-		}
+		presenceNamesMu.Lock()
+		delete(presenceNames, msg.NodeID)
+		presenceNamesMu.Unlock()
 		log.Printf("[PRESENCE] %s (%s) left the team", msg.Username, msg.IPAddress)
 	}
 }
 
-// GetTeamPresence retrieves all team member presence information
+// ProcessPresenceExpiry handles a keyspace notification for a presence key.
+// An expired key means the node missed its heartbeats, so every member sees
+// it go offline at the same moment.
+func ProcessPresenceExpiry(cfg AppConfig, channel, event string) {
+	if event != "expired" {
+		return
+	}
+	prefix := strings.TrimSuffix(PresenceExpiryPattern(cfg), "*")
+	nodeID := strings.TrimPrefix(channel, prefix)
+	if nodeID == channel || nodeID == cfg.NodeID {
+		return
+	}
+
+	presenceNamesMu.Lock()
+	username, known := presenceNames[nodeID]
+	delete(presenceNames, nodeID)
+	presenceNamesMu.Unlock()
+	if !known {
+		username = nodeID
+	}
+
+	msg := PresenceMessage{Type: "expired", NodeID: nodeID, Username: username, Timestamp: time.Now().Unix()}
+	Events.Publish(TopicPresenceChanged, PresenceChangedEvent{Message: msg})
+	notePeerBandwidth(msg)
+	notePeerDirect(msg)
+	notePeerProtocol(msg)
+	log.Printf("[PRESENCE] %s stopped sending heartbeats and is offline", username)
+}
+
+// GetTeamPresence retrieves all team member presence information. Only
+// online nodes have a presence key, since it expires with their heartbeats.
 func GetTeamPresence(ctx context.Context, cfg AppConfig) ([]PresenceInfo, error) {
-	presenceKey := fmt.Sprintf("axle:team:%s:presence", cfg.TeamID)
-	
-	// Get all presence information from Redis
-	presenceData, err := cfg.RedisClient.HGetAll(ctx, presenceKey).Result()
+	keys, err := ScanKeys(ctx, cfg.RedisClient, presenceKey(cfg.TeamID, "*"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get team presence: %w", err)
 	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	// One GET per key rather than MGET, which a Cluster refuses for keys in
+	// different slots. Keys that expire between the scan and here are missing.
+	pipe := cfg.RedisClient.Pipeline()
+	gets := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		gets[i] = pipe.Get(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get team presence: %w", err)
+	}
+
+	// Custom statuses are per member, not per node; they survive restarts
+	notes, _ := cfg.RedisClient.HGetAll(ctx, statusNotesKey(cfg.TeamID)).Result()
 
 	var presenceList []PresenceInfo
-	currentTime := time.Now().Unix()
-	
-	for nodeID, infoJSON := range presenceData {
+	for i, get := range gets {
+		infoJSON, err := get.Result()
+		if err != nil {
+			continue
+		}
 		var info PresenceInfo
 		if err := json.Unmarshal([]byte(infoJSON), &info); err != nil {
-			log.Printf("[PRESENCE] Error unmarshaling presence info for node %s: %v", nodeID, err)
+			log.Printf("[PRESENCE] Error unmarshaling presence info in %s: %v", keys[i], err)
 			continue
 		}
-		
-		// Check if the node is considered offline (no heartbeat for more than PresenceTimeout)
-		if currentTime-info.LastSeen > int64(PresenceTimeout.Seconds()) {
-			info.Status = "offline"
-			// Optionally remove stale entries
-			go func(nodeID string) {
-				cfg.RedisClient.HDel(context.Background(), presenceKey, nodeID)
-			}(nodeID)
+		if IsKicked(info.Username) {
+			continue // Until their daemon notices and stops
 		}
-		
+		info.Note = notes[info.Username]
 		presenceList = append(presenceList, info)
 	}
-	
+
 	return presenceList, nil
 }
 
+func statusNotesKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:notes", teamID)
+}
+
+// SetStatusNote sets the custom status shown next to this member in the team
+// table. An empty note clears it.
+func SetStatusNote(ctx context.Context, cfg AppConfig, note string) error {
+	var err error
+	if note == "" {
+		err = cfg.RedisClient.HDel(ctx, statusNotesKey(cfg.TeamID), cfg.Username).Err()
+	} else {
+		err = cfg.RedisClient.HSet(ctx, statusNotesKey(cfg.TeamID), cfg.Username, note).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set status: %w", err)
+	}
+	return nil
+}
+
 // CleanupPresence removes this node's presence information
 func CleanupPresence(ctx context.Context, cfg AppConfig) {
-	presenceKey := fmt.Sprintf("axle:team:%s:presence", cfg.TeamID)
-	if err := cfg.RedisClient.HDel(ctx, presenceKey, cfg.NodeID).Err(); err != nil {
+	if err := cfg.RedisClient.Del(ctx, presenceKey(cfg.TeamID, cfg.NodeID)).Err(); err != nil {
 		log.Printf("[PRESENCE] Error cleaning up presence: %v", err)
 	}
 }
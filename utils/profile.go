@@ -0,0 +1,239 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Profile categories reported by --profile
+const (
+	ProfileConfig = "config"
+	ProfileRedis  = "redis"
+	ProfileGit    = "git"
+	ProfileRender = "render"
+)
+
+// profileTop is how many of the slowest operations the report names per category
+const profileTop = 3
+
+// profileStat is the time spent on one kind of operation, such as a Redis
+// command or a git subcommand.
+type profileStat struct {
+	count int
+	total time.Duration
+	max   time.Duration
+}
+
+var (
+	profiling atomic.Bool
+	profileMu sync.Mutex
+	profile   = make(map[string]map[string]*profileStat)
+	// Git subprocesses started while profiling; their CPU time is read
+	// once they have exited
+	profiledGit []*exec.Cmd
+)
+
+// EnableProfiling starts recording where a command's time goes, for
+// ProfileReport. Redis clients created before it aren't measured.
+func EnableProfiling() {
+	profiling.Store(true)
+}
+
+// ProfileSpan starts timing an operation and returns the function that
+// records it.
+func ProfileSpan(category, name string) func() {
+	if !profiling.Load() {
+		return func() {}
+	}
+	start := time.Now()
+	return func() { recordProfile(category, name, time.Since(start)) }
+}
+
+func recordProfile(category, name string, elapsed time.Duration) {
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	if profile[category] == nil {
+		profile[category] = make(map[string]*profileStat)
+	}
+	stat := profile[category][name]
+	if stat == nil {
+		stat = &profileStat{}
+		profile[category][name] = stat
+	}
+	stat.count++
+	stat.total += elapsed
+	if elapsed > stat.max {
+		stat.max = elapsed
+	}
+}
+
+// profileGitCommand remembers a git subprocess. exec.Cmd has no hook for
+// when it finishes, so git is measured in CPU time from its process state.
+func profileGitCommand(cmd *exec.Cmd) {
+	if !profiling.Load() {
+		return
+	}
+	profileMu.Lock()
+	defer profileMu.Unlock()
+	profiledGit = append(profiledGit, cmd)
+}
+
+// gitSubcommand returns the git subcommand in args, skipping global options
+// such as "-C dir".
+func gitSubcommand(args []string) string {
+	for i := 1; i < len(args); i++ {
+		switch {
+		case args[i] == "-C" || args[i] == "-c":
+			i++
+		case !strings.HasPrefix(args[i], "-"):
+			return args[i]
+		}
+	}
+	return "git"
+}
+
+// redisProfileHook times every Redis command and pipeline.
+type redisProfileHook struct{}
+
+type profileStartKey struct{}
+
+func (redisProfileHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, profileStartKey{}, time.Now()), nil
+}
+
+func (redisProfileHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if start, ok := ctx.Value(profileStartKey{}).(time.Time); ok {
+		recordProfile(ProfileRedis, strings.ToUpper(cmd.Name()), time.Since(start))
+	}
+	return nil
+}
+
+func (redisProfileHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, profileStartKey{}, time.Now()), nil
+}
+
+func (redisProfileHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	if start, ok := ctx.Value(profileStartKey{}).(time.Time); ok {
+		recordProfile(ProfileRedis, "pipeline", time.Since(start))
+	}
+	return nil
+}
+
+// newRedisClient creates a Redis client, timed when profiling.
+func newRedisClient(opts *redis.Options) *redis.Client {
+	rdb := redis.NewClient(opts)
+	if profiling.Load() {
+		rdb.AddHook(redisProfileHook{})
+	}
+	return rdb
+}
+
+// ProfileReport summarizes where the time went since profiling was enabled:
+// per category the total and the slowest operations. Git is CPU time of
+// its subprocesses; the rest is wall-clock time, which can overlap when
+// work runs concurrently.
+func ProfileReport(total time.Duration) string {
+	profileMu.Lock()
+	defer profileMu.Unlock()
+
+	for _, cmd := range profiledGit {
+		if cmd.ProcessState == nil {
+			continue
+		}
+		cpu := cmd.ProcessState.UserTime() + cmd.ProcessState.SystemTime()
+		if profile[ProfileGit] == nil {
+			profile[ProfileGit] = make(map[string]*profileStat)
+		}
+		name := gitSubcommand(cmd.Args)
+		stat := profile[ProfileGit][name]
+		if stat == nil {
+			stat = &profileStat{}
+			profile[ProfileGit][name] = stat
+		}
+		stat.count++
+		stat.total += cpu
+		if cpu > stat.max {
+			stat.max = cpu
+		}
+	}
+	profiledGit = nil
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "Profile: %s total\n", total.Round(time.Microsecond))
+	var accounted time.Duration
+	for _, category := range []string{ProfileConfig, ProfileRedis, ProfileGit, ProfileRender} {
+		stats := profile[category]
+		var sum time.Duration
+		var count int
+		names := make([]string, 0, len(stats))
+		for name, stat := range stats {
+			sum += stat.total
+			count += stat.count
+			names = append(names, name)
+		}
+		unit := "calls"
+		switch category {
+		case ProfileRedis:
+			unit = "commands"
+		case ProfileGit:
+			unit = "runs, CPU time"
+		}
+		fmt.Fprintf(&out, "  %-8s %10s  %d %s\n", category, sum.Round(time.Microsecond), count, unit)
+		if category != ProfileGit {
+			accounted += sum
+		}
+
+		sort.Slice(names, func(i, j int) bool { return stats[names[i]].total > stats[names[j]].total })
+		if len(names) > profileTop {
+			names = names[:profileTop]
+		}
+		for _, name := range names {
+			stat := stats[name]
+			fmt.Fprintf(&out, "    %-24s %10s  %dx, slowest %s\n", name, stat.total.Round(time.Microsecond), stat.count, stat.max.Round(time.Microsecond))
+		}
+	}
+	if other := total - accounted; other > 0 {
+		fmt.Fprintf(&out, "  %-8s %10s  (everything else, including git wall time)\n", "other", other.Round(time.Microsecond))
+	}
+	return out.String()
+}
+
+// StartPprofServer serves Go's pprof profiles on addr until ctx is done,
+// for 'axle start --pprof'. It has its own mux, so nothing else Axle serves
+// exposes them.
+func StartPprofServer(ctx context.Context, addr string) {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			log.Printf("[PPROF] Warning: %s is reachable from other machines; profiles reveal file paths and memory contents", addr)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	log.Printf("[PPROF] Serving profiles on http://%s/debug/pprof/", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("[PPROF] Failed to serve profiles: %v", err)
+	}
+}
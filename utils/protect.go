@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Directions for held batches
+const (
+	HeldOutgoing   = "outgoing"   // Local changes waiting for 'axle push-protected --confirm'
+	HeldIncoming   = "incoming"   // Team changes waiting for 'axle accept-protected --confirm'
+	HeldPaused     = "paused"     // Team changes that arrived during 'axle pause'
+	HeldConflicted = "conflicted" // Local changes to files with unresolved conflict markers
+	HeldOversized  = "oversized"  // Local changes over the team's sync message limit
+)
+
+// HeldBatch is a batch touching protected paths that is waiting for confirmation.
+type HeldBatch struct {
+	ID             string
+	Path           string
+	Metadata       SyncMetadata
+	ProtectedFiles []string
+}
+
+// ProtectedFiles returns the files in a batch that match any protected pattern.
+func ProtectedFiles(changes []FileChange, patterns []string) []string {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	var files []string
+	for _, change := range changes {
+		if MatchAnyGlob(patterns, change.File) && !contains(files, change.File) {
+			files = append(files, change.File)
+		}
+	}
+	return files
+}
+
+// HoldBatch stores a batch touching protected paths until it is confirmed.
+func HoldBatch(rootDir, direction string, metadata SyncMetadata) (string, error) {
+	dir := AxlePath(rootDir, "held", direction)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create held batch directory: %w", err)
+	}
+
+	id := fmt.Sprintf("%d-%s", time.Now().UnixNano(), metadata.PeerID)
+	if err := SaveMetadata(metadata, filepath.Join(dir, id+".json")); err != nil {
+		return "", fmt.Errorf("failed to save held batch: %w", err)
+	}
+	return id, nil
+}
+
+// ListHeldBatches returns the held batches for a direction, oldest first.
+func ListHeldBatches(rootDir, direction string, patterns []string) ([]HeldBatch, error) {
+	dir := AxlePath(rootDir, "held", direction)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read held batches: %w", err)
+	}
+
+	var batches []HeldBatch
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var metadata SyncMetadata
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			continue
+		}
+
+		batches = append(batches, HeldBatch{
+			ID:             strings.TrimSuffix(entry.Name(), ".json"),
+			Path:           path,
+			Metadata:       metadata,
+			ProtectedFiles: ProtectedFiles(metadata.Changes, patterns),
+		})
+	}
+
+	sort.Slice(batches, func(i, j int) bool { return batches[i].ID < batches[j].ID })
+	return batches, nil
+}
+
+// ReleaseHeldBatch deletes a held batch once it has been confirmed.
+func ReleaseHeldBatch(batch HeldBatch) error {
+	if err := os.Remove(batch.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove held batch %s: %w", batch.ID, err)
+	}
+	return nil
+}
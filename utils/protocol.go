@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProtocolVersion is the version of the sync message schema this release
+// speaks, sent as SyncMetadata.Version and in presence messages. Version 2
+// added capabilities; peers that advertise none speak version 1.
+const ProtocolVersion = 2
+
+// Capabilities a node advertises in its presence messages. A sender only
+// uses one while every online teammate advertises it, so older peers get
+// batches they can apply.
+const (
+	CapGzipPatches    = "patch.gzip"      // Reads patches with Encoding "gzip" in any batch
+	CapDirectTransfer = "transfer.direct" // Fetches content with Store "direct" from its owner
+)
+
+// Capabilities returns what this release understands.
+func Capabilities() []string {
+	return []string{CapGzipPatches, CapDirectTransfer}
+}
+
+// peerProtocol is what a peer advertised about its protocol.
+type peerProtocol struct {
+	username     string
+	version      int
+	capabilities []string
+	lastSeen     int64
+}
+
+var (
+	protocolMu    sync.Mutex
+	peerProtocols = make(map[string]peerProtocol) // Node ID -> advertised protocol
+	// protocolWarned remembers warnings already logged, so each shows once
+	protocolWarned = make(map[string]bool)
+)
+
+// notePeerProtocol remembers the protocol version and capabilities peers
+// advertise, from their presence messages.
+func notePeerProtocol(msg PresenceMessage) {
+	protocolMu.Lock()
+	defer protocolMu.Unlock()
+	if msg.Type == "goodbye" || msg.Type == "expired" {
+		delete(peerProtocols, msg.NodeID)
+		return
+	}
+
+	version := msg.Protocol
+	if version == 0 {
+		version = 1 // Releases before negotiation don't say
+	}
+	peerProtocols[msg.NodeID] = peerProtocol{
+		username:     msg.Username,
+		version:      version,
+		capabilities: msg.Capabilities,
+		lastSeen:     msg.Timestamp,
+	}
+	if version > ProtocolVersion {
+		warnProtocolOnce("newer:"+msg.NodeID, fmt.Sprintf("%s runs a newer Axle (sync protocol %d, this release speaks %d); upgrade to receive everything they can send",
+			msg.Username, version, ProtocolVersion))
+	}
+}
+
+// PeersLacking returns the online peers that don't advertise a capability.
+func PeersLacking(cfg AppConfig, capability string) []string {
+	protocolMu.Lock()
+	defer protocolMu.Unlock()
+
+	cutoff := time.Now().Add(-EffectivePresenceTimeout(cfg)).Unix()
+	var lacking []string
+	for nodeID, peer := range peerProtocols {
+		if peer.lastSeen < cutoff {
+			delete(peerProtocols, nodeID)
+			continue
+		}
+		if !contains(peer.capabilities, capability) && !contains(lacking, peer.username) {
+			lacking = append(lacking, peer.username)
+		}
+	}
+	sort.Strings(lacking)
+	return lacking
+}
+
+// teamSupports reports whether every online teammate can handle a
+// capability, and explains once why the batch is downgraded when not.
+func teamSupports(cfg AppConfig, capability string) bool {
+	lacking := PeersLacking(cfg, capability)
+	if len(lacking) == 0 {
+		return true
+	}
+	protocolMu.Lock()
+	defer protocolMu.Unlock()
+	warnProtocolOnce("lacks:"+capability+":"+strings.Join(lacking, ","), fmt.Sprintf("Not using %s while %s, on an older Axle, is online",
+		capability, strings.Join(lacking, ", ")))
+	return false
+}
+
+// warnProtocolOnce logs a warning the first time it comes up. The caller
+// must hold protocolMu.
+func warnProtocolOnce(key, message string) {
+	if protocolWarned[key] {
+		return
+	}
+	protocolWarned[key] = true
+	log.Printf("[PROTOCOL] ⚠️  %s", message)
+}
+
+// CheckBatchProtocol checks a batch sent in a newer schema than this
+// release speaks. Batches that only use what this release understands are
+// applied, with a warning; ones that don't are refused with an error.
+func CheckBatchProtocol(metadata SyncMetadata) error {
+	if metadata.Version <= ProtocolVersion {
+		return nil
+	}
+	for _, change := range metadata.Changes {
+		if unknown := unsupportedFeature(change); unknown != "" {
+			return fmt.Errorf("%s speaks sync protocol %d and sent %s for %s, which this release (protocol %d) can't apply; upgrade Axle",
+				metadata.PeerID, metadata.Version, unknown, change.File, ProtocolVersion)
+		}
+	}
+
+	protocolMu.Lock()
+	defer protocolMu.Unlock()
+	warnProtocolOnce(fmt.Sprintf("batch:%s:%d", metadata.PeerID, metadata.Version), fmt.Sprintf("%s sends sync protocol %d, newer than this release's %d; applying what it understands",
+		metadata.PeerID, metadata.Version, ProtocolVersion))
+	return nil
+}
+
+// unsupportedFeature describes what in a change this release can't apply,
+// or returns "".
+func unsupportedFeature(change FileChange) string {
+	switch change.Event {
+	case "created", "modified", "deleted", "chunked", "placeholder", "appended",
+		EventRenamed, EventChmod, EventTouched, EventMkdir:
+	default:
+		return fmt.Sprintf("event %q", change.Event)
+	}
+	switch change.Encoding {
+	case "", EncodingGzip:
+	default:
+		return fmt.Sprintf("encoding %q", change.Encoding)
+	}
+	switch change.Store {
+	case "", BlobStoreShared, BlobStoreDirect:
+	default:
+		return fmt.Sprintf("store %q", change.Store)
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// Publisher states
+const (
+	PublisherNormal     = "normal"     // Publishing every poll interval
+	PublisherCoalescing = "coalescing" // Redis is slow; merging batches into fewer publishes
+	PublisherBackoff    = "backoff"    // Publishing failed; waiting before retrying
+	PublisherOffline    = "offline"    // Working offline; changes wait in the outbox
+	PublisherCatchingUp = "catchingUp" // Replaying missed team batches; changes wait in the outbox
+)
+
+const (
+	slowPublishThreshold    = time.Second            // Latency that triggers coalescing
+	recoveredPublishLatency = 250 * time.Millisecond // Latency that ends coalescing
+	coalescingInterval      = 15 * time.Second
+	initialPublishBackoff   = 5 * time.Second
+	maxPublishBackoff       = 2 * time.Minute
+)
+
+// PublisherStatus describes the publisher's current behavior under Redis pressure.
+type PublisherStatus struct {
+	State               string `json:"state"`
+	QueuedChanges       int    `json:"queuedChanges"`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	NextAttempt         int64  `json:"nextAttempt,omitempty"` // Unix timestamp
+	LastLatencyMs       int64  `json:"lastLatencyMs"`
+	LastPublish         int64  `json:"lastPublish,omitempty"` // Unix timestamp
+	LastError           string `json:"lastError,omitempty"`
+}
+
+var (
+	publisherMu     sync.Mutex
+	publisherStatus = PublisherStatus{State: PublisherNormal}
+	publishBackoff  = initialPublishBackoff
+)
+
+// GetPublisherStatus returns a copy of the current publisher status.
+func GetPublisherStatus() PublisherStatus {
+	publisherMu.Lock()
+	defer publisherMu.Unlock()
+	return publisherStatus
+}
+
+// publisherReady reports whether the publisher may attempt a publish now.
+func publisherReady(queued int) bool {
+	publisherMu.Lock()
+	defer publisherMu.Unlock()
+	publisherStatus.QueuedChanges = queued
+	return publisherStatus.NextAttempt == 0 || time.Now().Unix() >= publisherStatus.NextAttempt
+}
+
+// recordPublishResult updates the publisher state after a publish attempt.
+// Failures back off exponentially; slow successes switch to coalescing so
+// pending batches are merged into fewer, larger publishes until latency
+// recovers.
+func recordPublishResult(latency time.Duration, queued int, err error) {
+	publisherMu.Lock()
+	defer publisherMu.Unlock()
+
+	now := time.Now()
+	publisherStatus.LastLatencyMs = latency.Milliseconds()
+	publisherStatus.QueuedChanges = queued
+
+	if err != nil {
+		publisherStatus.State = PublisherBackoff
+		publisherStatus.ConsecutiveFailures++
+		publisherStatus.LastError = err.Error()
+		publisherStatus.NextAttempt = now.Add(publishBackoff).Unix()
+		publishBackoff *= 2
+		if publishBackoff > maxPublishBackoff {
+			publishBackoff = maxPublishBackoff
+		}
+		return
+	}
+
+	publisherStatus.ConsecutiveFailures = 0
+	publisherStatus.LastError = ""
+	publisherStatus.LastPublish = now.Unix()
+	publishBackoff = initialPublishBackoff
+
+	switch {
+	case latency > slowPublishThreshold:
+		publisherStatus.State = PublisherCoalescing
+	case publisherStatus.State != PublisherCoalescing || latency < recoveredPublishLatency:
+		publisherStatus.State = PublisherNormal
+	}
+
+	if publisherStatus.State == PublisherCoalescing {
+		publisherStatus.NextAttempt = now.Add(coalescingInterval).Unix()
+	} else {
+		publisherStatus.NextAttempt = 0
+	}
+}
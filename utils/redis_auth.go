@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisAuth is how Axle logs in to the team's Redis: an ACL username and
+// password, a database index, and TLS, which managed services such as
+// Upstash or ElastiCache require.
+type RedisAuth struct {
+	Username string
+	Password string
+	DB       int
+	TLS      bool
+}
+
+var (
+	redisAuthMu sync.RWMutex
+	redisAuth   RedisAuth
+)
+
+// SetRedisAuth sets the credentials and transport of every Redis connection
+// made afterwards.
+func SetRedisAuth(auth RedisAuth) {
+	redisAuthMu.Lock()
+	defer redisAuthMu.Unlock()
+	redisAuth = auth
+}
+
+func currentRedisAuth() RedisAuth {
+	redisAuthMu.RLock()
+	defer redisAuthMu.RUnlock()
+	return redisAuth
+}
+
+// ParseRedisURL splits a redis:// or rediss:// (TLS) URL, such as the ones
+// managed Redis services hand out, into host, port and login.
+func ParseRedisURL(raw string) (string, int, RedisAuth, error) {
+	opts, err := redis.ParseURL(strings.TrimSpace(raw))
+	if err != nil {
+		return "", 0, RedisAuth{}, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	host, portText, err := net.SplitHostPort(opts.Addr)
+	if err != nil {
+		return "", 0, RedisAuth{}, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		return "", 0, RedisAuth{}, fmt.Errorf("invalid Redis URL port %q", portText)
+	}
+	auth := RedisAuth{
+		Username: opts.Username,
+		Password: opts.Password,
+		DB:       opts.DB,
+		TLS:      opts.TLSConfig != nil,
+	}
+	return host, port, auth, nil
+}
+
+// dialRedisEndpoint connects to a Redis server, through the proxy if one is
+// set, and starts TLS on the connection when the login asks for it. Axle's
+// dialers replace go-redis's own, which is what would otherwise do the TLS.
+func dialRedisEndpoint(ctx context.Context, dialer *net.Dialer, network, addr string) (net.Conn, error) {
+	conn, err := DialThroughProxy(ctx, dialer, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if !currentRedisAuth().TLS {
+		return trackRedisConn(conn), nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if dialer.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(dialer.Timeout))
+	}
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12})
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("TLS handshake with Redis at %s failed: %w", addr, err)
+	}
+	conn.SetDeadline(time.Time{})
+	return trackRedisConn(tlsConn), nil
+}
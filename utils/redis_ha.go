@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisHA is a highly-available Redis deployment the team runs against
+// instead of a single server: a master that Redis Sentinel fails over, or a
+// Redis Cluster.
+type RedisHA struct {
+	SentinelMaster string   // Name of the master the Sentinels monitor
+	SentinelAddrs  []string // Sentinels, host:port
+	ClusterAddrs   []string // Cluster nodes to discover the cluster from, host:port
+}
+
+// Enabled reports whether a Sentinel or Cluster deployment is configured.
+func (ha RedisHA) Enabled() bool {
+	return ha.SentinelMaster != "" || len(ha.ClusterAddrs) > 0
+}
+
+// Label describes the deployment for display.
+func (ha RedisHA) Label() string {
+	if len(ha.ClusterAddrs) > 0 {
+		return fmt.Sprintf("cluster %v", ha.ClusterAddrs)
+	}
+	return fmt.Sprintf("sentinel master %q via %v", ha.SentinelMaster, ha.SentinelAddrs)
+}
+
+// Validate checks that the deployment is either Sentinel or Cluster and
+// complete.
+func (ha RedisHA) Validate(db int) error {
+	switch {
+	case !ha.Enabled():
+		if len(ha.SentinelAddrs) > 0 {
+			return fmt.Errorf("Redis Sentinel addresses need a master name")
+		}
+		return nil
+	case ha.SentinelMaster != "" && len(ha.ClusterAddrs) > 0:
+		return fmt.Errorf("use either Redis Sentinel or Redis Cluster, not both")
+	case ha.SentinelMaster != "" && len(ha.SentinelAddrs) == 0:
+		return fmt.Errorf("Redis Sentinel master %q needs at least one Sentinel address", ha.SentinelMaster)
+	case len(ha.ClusterAddrs) > 0 && db != 0:
+		return fmt.Errorf("Redis Cluster only has database 0, not %d", db)
+	}
+	return nil
+}
+
+var (
+	redisHAMu sync.RWMutex
+	redisHA   RedisHA
+)
+
+// SetRedisHA makes every Redis connection made afterwards go through a
+// Sentinel or Cluster deployment; the zero value returns to single servers.
+func SetRedisHA(ha RedisHA) {
+	redisHAMu.Lock()
+	defer redisHAMu.Unlock()
+	redisHA = ha
+}
+
+// CurrentRedisHA returns the deployment set with SetRedisHA.
+func CurrentRedisHA() RedisHA {
+	redisHAMu.RLock()
+	defer redisHAMu.RUnlock()
+	return redisHA
+}
+
+// newHARedisClient creates a client for a Sentinel or Cluster deployment
+// with the same login, timeouts and dialer as single-server clients.
+// Subscriptions reconnect and resubscribe on their own after a failover.
+func newHARedisClient(ha RedisHA) redis.UniversalClient {
+	base := redisOptions("")
+	var rdb redis.UniversalClient
+	if len(ha.ClusterAddrs) > 0 {
+		rdb = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:           ha.ClusterAddrs,
+			Username:        base.Username,
+			Password:        base.Password,
+			MaxRetries:      base.MaxRetries,
+			MinRetryBackoff: base.MinRetryBackoff,
+			MaxRetryBackoff: base.MaxRetryBackoff,
+			DialTimeout:     base.DialTimeout,
+			ReadTimeout:     base.ReadTimeout,
+			WriteTimeout:    base.WriteTimeout,
+			Dialer:          base.Dialer,
+		})
+	} else {
+		rdb = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:      ha.SentinelMaster,
+			SentinelAddrs:   ha.SentinelAddrs,
+			Username:        base.Username,
+			Password:        base.Password,
+			DB:              base.DB,
+			MaxRetries:      base.MaxRetries,
+			MinRetryBackoff: base.MinRetryBackoff,
+			MaxRetryBackoff: base.MaxRetryBackoff,
+			DialTimeout:     base.DialTimeout,
+			ReadTimeout:     base.ReadTimeout,
+			WriteTimeout:    base.WriteTimeout,
+			Dialer:          sentinelDialer(ha.SentinelAddrs),
+		})
+	}
+	if profiling.Load() {
+		rdb.AddHook(redisProfileHook{})
+	}
+	return rdb
+}
+
+// sentinelDialer returns a dialer for a Sentinel client. go-redis dials the
+// Sentinels and the master it resolved through the same dialer; a master
+// address that changes is a failover, reported as the active endpoint.
+func sentinelDialer(sentinels []string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialRedis(ctx, network, addr)
+		if err != nil || contains(sentinels, addr) {
+			return conn, err
+		}
+		noteActiveEndpoint(RedisEndpoint{Addr: addr, Region: "sentinel master"})
+		return conn, nil
+	}
+}
+
+// noteActiveEndpoint records the Redis server connections go to, announcing
+// a switch from another one on the event bus.
+func noteActiveEndpoint(endpoint RedisEndpoint) {
+	activeEndpointMu.Lock()
+	previous := activeEndpoint
+	activeEndpoint = endpoint
+	activeEndpointMu.Unlock()
+	if previous.Addr == endpoint.Addr {
+		return
+	}
+	log.Printf("[REDIS] Using endpoint %s", endpoint.Label())
+	if previous.Addr != "" {
+		Events.Publish(TopicRedisFailover, RedisFailoverEvent{From: previous, To: endpoint})
+	}
+}
+
+// ScanKeys returns the keys matching pattern. A Cluster spreads keys over
+// its masters, so each one is scanned.
+func ScanKeys(ctx context.Context, rdb redis.UniversalClient, pattern string) ([]string, error) {
+	scan := func(ctx context.Context, client redis.Cmdable) ([]string, error) {
+		var keys []string
+		iter := client.Scan(ctx, 0, pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+		return keys, iter.Err()
+	}
+
+	cluster, ok := rdb.(*redis.ClusterClient)
+	if !ok {
+		return scan(ctx, rdb)
+	}
+	var mu sync.Mutex
+	var keys []string
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, master *redis.Client) error {
+		found, err := scan(ctx, master)
+		mu.Lock()
+		keys = append(keys, found...)
+		mu.Unlock()
+		return err
+	})
+	return keys, err
+}
+
+// DeleteKeys deletes keys one by one in a single round trip. A multi-key DEL
+// fails in a Cluster when the keys hash to different slots.
+func DeleteKeys(ctx context.Context, rdb redis.UniversalClient, keys ...string) error {
+	pipe := rdb.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
@@ -6,30 +6,54 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
+	"strings"
 	"time" // Added for context timeouts or other time-based operations
 
 	"github.com/go-redis/redis/v8"
 )
 
 // NewRedisClient creates and returns a new Redis client with retry logic and exponential backoff.
-func NewRedisClient(addr string) (*redis.Client, error) {
+func NewRedisClient(addr string) (redis.UniversalClient, error) {
 	return NewRedisClientWithRetry(addr, 5, 1*time.Second)
 }
 
 // NewRedisClientWithRetry creates a Redis client with configurable retry attempts and backoff.
-func NewRedisClientWithRetry(addr string, maxRetries int, initialBackoff time.Duration) (*redis.Client, error) {
-	rdb := redis.NewClient(&redis.Options{
+// A Sentinel or Cluster deployment set with SetRedisHA takes addr's place.
+func NewRedisClientWithRetry(addr string, maxRetries int, initialBackoff time.Duration) (redis.UniversalClient, error) {
+	if ha := CurrentRedisHA(); ha.Enabled() {
+		return connectRedis(newHARedisClient(ha), ha.Label(), maxRetries, initialBackoff)
+	}
+	return connectRedis(newRedisClient(redisOptions(addr)), addr, maxRetries, initialBackoff)
+}
+
+// redisOptions returns the client options shared by every Redis connection.
+func redisOptions(addr string) *redis.Options {
+	auth := currentRedisAuth()
+	return &redis.Options{
 		Addr:            addr,
-		Password:        "", // No password in our current Docker setup
-		DB:              0,  // Default DB
-		MaxRetries:      3,  // Internal retries per operation
+		Username:        auth.Username,
+		Password:        auth.Password,
+		DB:              auth.DB,
+		MaxRetries:      3, // Internal retries per operation
 		MinRetryBackoff: 100 * time.Millisecond,
 		MaxRetryBackoff: 3 * time.Second,
 		DialTimeout:     5 * time.Second,
 		ReadTimeout:     3 * time.Second,
 		WriteTimeout:    3 * time.Second,
-	})
+		Dialer:          dialRedis,
+	}
+}
+
+// dialRedis connects to a Redis server, through the proxy if one is set.
+func dialRedis(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: 5 * time.Second, KeepAlive: 5 * time.Minute}
+	return dialRedisEndpoint(ctx, &dialer, network, addr)
+}
 
+// connectRedis pings a new client of the Redis at addr until it answers,
+// backing off exponentially between attempts.
+func connectRedis(rdb redis.UniversalClient, addr string, maxRetries int, initialBackoff time.Duration) (redis.UniversalClient, error) {
 	// Try to connect with exponential backoff
 	backoff := initialBackoff
 	for attempt := 1; attempt <= maxRetries; attempt++ {
@@ -61,18 +85,19 @@ func NewRedisClientWithRetry(addr string, maxRetries int, initialBackoff time.Du
 
 // PublishMessage marshals the given message (interface{}) to JSON and publishes it to the specified channel.
 // Includes automatic retry logic for transient failures.
-func PublishMessage(ctx context.Context, rdb *redis.Client, channel string, message interface{}) error {
+func PublishMessage(ctx context.Context, transport Transport, channel string, message interface{}) error {
 	jsonMessage, err := json.Marshal(message)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message for channel %s: %w", channel, err)
 	}
+	jsonMessage = SignMessage(channel, jsonMessage)
 
 	// Retry logic for publish operations
 	maxRetries := 3
 	retryDelay := 500 * time.Millisecond
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		err = rdb.Publish(ctx, channel, jsonMessage).Err()
+		err = transport.Publish(ctx, channel, jsonMessage)
 		if err == nil {
 			return nil
 		}
@@ -93,15 +118,13 @@ func PublishMessage(ctx context.Context, rdb *redis.Client, channel string, mess
 	return fmt.Errorf("failed to publish message to channel %s: %w", channel, err)
 }
 
-// SubscribeToChannels subscribes to the given Redis channels and returns the PubSub instance.
-// The caller is responsible for receiving messages from the PubSub.Channel() and closing the PubSub.
-func SubscribeToChannels(ctx context.Context, rdb *redis.Client, channels ...string) (*redis.PubSub, error) {
-	pubsub := rdb.Subscribe(ctx, channels...)
-	_, err := pubsub.Receive(ctx) // Blocks until subscription is confirmed or error occurs
+// SubscribeToChannels subscribes to the given channels and returns the subscription.
+// The caller is responsible for receiving messages from its Channel() and closing it.
+func SubscribeToChannels(ctx context.Context, transport Transport, channels ...string) (Subscription, error) {
+	sub, err := transport.Subscribe(ctx, channels...)
 	if err != nil {
-		pubsub.Close() // Ensure pubsub is closed on error
 		return nil, fmt.Errorf("failed to subscribe to channels %v: %w", channels, err)
 	}
-	log.Printf("[REDIS] Subscribed to channels: %v", channels)
-	return pubsub, nil
+	log.Printf("[%s] Subscribed to channels: %v", strings.ToUpper(transport.Name()), channels)
+	return sub, nil
 }
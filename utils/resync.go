@@ -0,0 +1,341 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// resyncTTL bounds how long manifests and served file lists stay in Redis
+const resyncTTL = 5 * time.Minute
+
+var errResyncTimeout = errors.New("no answer")
+
+// Resync request types
+const (
+	resyncManifest = "manifest" // Send your file manifest
+	resyncFiles    = "files"    // Upload these files
+)
+
+// ResyncRequest asks a peer for its manifest or for files, for 'axle resync'.
+type ResyncRequest struct {
+	Type      string   `json:"type"`
+	RequestID string   `json:"requestID"`
+	Requester string   `json:"requester"`
+	NodeID    string   `json:"nodeID"`
+	From      string   `json:"from,omitempty"`  // Only this peer may answer
+	Paths     []string `json:"paths,omitempty"` // For resyncFiles
+	Timestamp int64    `json:"timestamp"`
+}
+
+// resyncAnswer carries the answer to a resync request.
+type resyncAnswer struct {
+	Answer interface{} `json:"answer"`
+}
+
+// ResyncReport is the outcome of 'axle resync'.
+type ResyncReport struct {
+	From       string   `json:"from"`
+	Compared   int      `json:"compared"`
+	DryRun     bool     `json:"dryRun,omitempty"`
+	Downloaded []string `json:"downloaded,omitempty"`
+	Uploaded   []string `json:"uploaded,omitempty"`
+	Deleted    []string `json:"deleted,omitempty"`
+	Failed     []string `json:"failed,omitempty"`
+}
+
+func resyncResultKey(teamID, requestID string) string {
+	return fmt.Sprintf("axle:resync:%s:%s", teamID, requestID)
+}
+
+func resyncOwnerKey(teamID, requestID string) string {
+	return fmt.Sprintf("axle:resync:%s:%s:owner", teamID, requestID)
+}
+
+// syncedManifest hashes the files this node syncs: the working tree as
+// checkpoints see it, minus the configured ignore patterns.
+func syncedManifest(cfg AppConfig) (map[string]ManifestEntry, error) {
+	manifest, err := TreeManifest(cfg.RootDir, CurrentTreeRef)
+	if err != nil {
+		return nil, err
+	}
+	for path := range manifest {
+		if shouldIgnore(cfg, filepath.Join(cfg.RootDir, filepath.FromSlash(path))) {
+			delete(manifest, path)
+		}
+	}
+	return manifest, nil
+}
+
+// RequestManifest asks online peers for their manifest and waits for one.
+// When from is set only that peer may answer; otherwise the authoritative
+// node or a hub answers first. It returns the manifest and who sent it.
+func RequestManifest(ctx context.Context, cfg AppConfig, from string, timeout time.Duration) (map[string]ManifestEntry, string, error) {
+	req := ResyncRequest{Type: resyncManifest, From: from}
+	var manifest map[string]ManifestEntry
+	owner, err := awaitResync(ctx, cfg, req, timeout, &manifest)
+	if errors.Is(err, errResyncTimeout) {
+		if from != "" {
+			return nil, "", fmt.Errorf("%s didn't send a manifest within %v; make sure they are running 'axle start'", from, timeout)
+		}
+		return nil, "", fmt.Errorf("no peer sent a manifest within %v; make sure a teammate is running 'axle start'", timeout)
+	}
+	return manifest, owner, err
+}
+
+// RequestFiles asks owner to upload paths through the blob store and returns
+// the changes that write them.
+func RequestFiles(ctx context.Context, cfg AppConfig, owner string, paths []string, timeout time.Duration) ([]FileChange, error) {
+	req := ResyncRequest{Type: resyncFiles, From: owner, Paths: paths}
+	var changes []FileChange
+	if _, err := awaitResync(ctx, cfg, req, timeout, &changes); err != nil {
+		return nil, fmt.Errorf("%s didn't upload the files within %v: %w", owner, timeout, err)
+	}
+	return changes, nil
+}
+
+// awaitResync publishes a request and decodes the answer into result.
+func awaitResync(ctx context.Context, cfg AppConfig, req ResyncRequest, timeout time.Duration, result interface{}) (string, error) {
+	req.RequestID = GenerateNodeID()
+	req.Requester = cfg.Username
+	req.NodeID = cfg.NodeID
+	req.Timestamp = time.Now().Unix()
+	if err := PublishMessage(ctx, cfg.Transport, ResyncChannel(cfg.TeamID), req); err != nil {
+		return "", fmt.Errorf("failed to send resync request: %w", err)
+	}
+
+	resultKey := resyncResultKey(cfg.TeamID, req.RequestID)
+	ownerKey := resyncOwnerKey(cfg.TeamID, req.RequestID)
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		data, err := cfg.RedisClient.Get(ctx, resultKey).Bytes()
+		if err == nil {
+			owner, _ := cfg.RedisClient.Get(ctx, ownerKey).Result()
+			DeleteKeys(ctx, cfg.RedisClient, resultKey, ownerKey)
+			// The answer is applied to the tree; refuse a forged one
+			if err := AdmitSigned(cfg, resultKey, owner, string(data)); err != nil {
+				return "", fmt.Errorf("refusing the resync answer from %s: %w", owner, err)
+			}
+			if err := json.Unmarshal(data, &resyncAnswer{Answer: result}); err != nil {
+				return "", fmt.Errorf("failed to parse resync answer: %w", err)
+			}
+			return owner, nil
+		}
+		if err != redis.Nil {
+			return "", fmt.Errorf("failed to read resync answer: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	return "", errResyncTimeout
+}
+
+// ProcessResyncRequest answers a peer's resync request. Manifest requests
+// are claimed like snapshot requests; file requests go to the named peer.
+func ProcessResyncRequest(ctx context.Context, cfg AppConfig, payload string) {
+	var req ResyncRequest
+	if err := json.Unmarshal([]byte(payload), &req); err != nil {
+		log.Printf("[RESYNC] Error unmarshaling resync request: %v", err)
+		return
+	}
+	// Answering writes files into the requester's tree
+	if AdmitSigned(cfg, ResyncChannel(cfg.TeamID), req.Requester, payload) != nil {
+		return
+	}
+	if req.NodeID == cfg.NodeID || (req.From != "" && req.From != cfg.Username) {
+		return
+	}
+
+	go func() {
+		if req.From == "" && !cfg.Hub && cfg.AuthoritativeNode != "" && cfg.AuthoritativeNode != cfg.Username {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(snapshotFallbackDelay):
+			}
+		}
+
+		ownerKey := resyncOwnerKey(cfg.TeamID, req.RequestID)
+		claimed, err := cfg.RedisClient.SetNX(ctx, ownerKey, cfg.Username, resyncTTL).Result()
+		if err != nil || !claimed {
+			return
+		}
+
+		var answer interface{}
+		switch req.Type {
+		case resyncManifest:
+			answer, err = syncedManifest(cfg)
+		case resyncFiles:
+			answer, err = uploadResyncFiles(ctx, cfg, req.Paths)
+		default:
+			err = fmt.Errorf("unknown request type %q", req.Type)
+		}
+		if err != nil {
+			log.Printf("[RESYNC] Failed to answer %s: %v", req.Requester, err)
+			cfg.RedisClient.Del(ctx, ownerKey)
+			return
+		}
+
+		// Wrapped in an object, so it can be signed like a message
+		resultKey := resyncResultKey(cfg.TeamID, req.RequestID)
+		data, err := json.Marshal(resyncAnswer{Answer: answer})
+		if err != nil {
+			return
+		}
+		if err := cfg.RedisClient.Set(ctx, resultKey, SignMessage(resultKey, data), resyncTTL).Err(); err != nil {
+			log.Printf("[RESYNC] Failed to answer %s: %v", req.Requester, err)
+			return
+		}
+		log.Printf("[RESYNC] Sent %s %s", req.Requester, req.Type)
+	}()
+}
+
+// validateResyncPaths checks that every path a resync request names is
+// inside the sync root.
+func validateResyncPaths(paths []string) error {
+	for _, rel := range paths {
+		if err := validatePatchPath(rel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadResyncFiles puts each file in the blob store and describes it as a
+// change the requester can apply. Files that are gone are skipped.
+func uploadResyncFiles(ctx context.Context, cfg AppConfig, paths []string) ([]FileChange, error) {
+	if err := validateResyncPaths(paths); err != nil {
+		return nil, err
+	}
+	var changes []FileChange
+	for _, rel := range paths {
+		data, err := os.ReadFile(filepath.Join(cfg.RootDir, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		hash, store, err := StoreBlob(ctx, cfg, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload %s: %w", rel, err)
+		}
+		changes = append(changes, FileChange{
+			File:    rel,
+			Event:   "chunked",
+			Size:    int64(len(data)),
+			Hash:    hash,
+			Store:   store,
+			TraceID: GenerateTraceID(),
+			// Content sent directly is fetched from us
+			Owner:     cfg.Username,
+			OwnerNode: cfg.NodeID,
+		})
+	}
+	return changes, nil
+}
+
+// RunResync brings the local tree to the state of a peer: from, the
+// reference of the last election, or whichever peer answers first. Files
+// that are missing or differ are downloaded over the local version (a
+// checkpoint keeps the old tree), and files only this node has are
+// published to the team, or deleted with prune. The daemon runs it so the
+// watcher doesn't publish the downloads.
+func RunResync(ctx context.Context, cfg AppConfig, from string, prune, dryRun bool, timeout time.Duration) (ResyncReport, error) {
+	report := ResyncReport{DryRun: dryRun}
+	if from == "" {
+		if election, err := LoadElection(ctx, cfg); err == nil && election != nil && election.Reference.Username != cfg.Username {
+			from = election.Reference.Username
+		}
+	}
+
+	remote, owner, err := RequestManifest(ctx, cfg, from, timeout)
+	if err != nil {
+		return report, err
+	}
+	report.From = owner
+	local, err := syncedManifest(cfg)
+	if err != nil {
+		return report, err
+	}
+	report.Compared = len(remote)
+
+	// Missing here or different: take theirs. Only here: publish or prune.
+	var download, extra []string
+	for _, diff := range DiffManifests(local, remote) {
+		if diff.Status == "removed" {
+			extra = append(extra, diff.Path)
+		} else {
+			download = append(download, diff.Path)
+		}
+	}
+	if dryRun {
+		report.Downloaded = download
+		if prune {
+			report.Deleted = extra
+		} else {
+			report.Uploaded = extra
+		}
+		return report, nil
+	}
+	if len(download) == 0 && len(extra) == 0 {
+		return report, nil
+	}
+
+	var changes []FileChange
+	if len(download) > 0 {
+		if changes, err = RequestFiles(ctx, cfg, owner, download, timeout); err != nil {
+			return report, err
+		}
+	}
+	if _, err := CreateAutoCheckpoint(cfg.RootDir, "resyncing with "+owner); err != nil {
+		return report, err
+	}
+
+	SetIsApplyingPatch(true)
+	for _, change := range changes {
+		if err := ApplyChunkedChange(ctx, cfg, change); err != nil {
+			report.Failed = append(report.Failed, fmt.Sprintf("%s: %v", change.File, err))
+			continue
+		}
+		report.Downloaded = append(report.Downloaded, change.File)
+	}
+	if prune {
+		for _, rel := range extra {
+			if err := os.Remove(filepath.Join(cfg.RootDir, filepath.FromSlash(rel))); err != nil && !os.IsNotExist(err) {
+				report.Failed = append(report.Failed, fmt.Sprintf("%s: %v", rel, err))
+				continue
+			}
+			report.Deleted = append(report.Deleted, rel)
+		}
+	}
+	if len(report.Downloaded) > 0 || len(report.Deleted) > 0 {
+		message := fmt.Sprintf("[RESYNC] Took %d files from %s", len(report.Downloaded)+len(report.Deleted), owner)
+		if _, err := CommitChanges(cfg.RootDir, message); err != nil {
+			log.Printf("[RESYNC] Failed to commit: %v", err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond) // Let the watcher see the writes while they're suppressed
+	SetIsApplyingPatch(false)
+
+	if !prune {
+		for _, rel := range extra {
+			if _, err := QueueForcedFile(ctx, cfg, rel); err != nil {
+				report.Failed = append(report.Failed, fmt.Sprintf("%s: %v", rel, err))
+				continue
+			}
+			report.Uploaded = append(report.Uploaded, rel)
+		}
+	}
+	log.Printf("[RESYNC] Resynced with %s: %d downloaded, %d uploaded, %d deleted, %d failed",
+		owner, len(report.Downloaded), len(report.Uploaded), len(report.Deleted), len(report.Failed))
+	return report, nil
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+)
+
+// Membership roles, set by the team admin with 'axle team role'
+const (
+	RoleMember    = "member"    // Sends and receives changes
+	RoleSpectator = "spectator" // Receives changes but never publishes any
+)
+
+// ValidateRole checks a role name.
+func ValidateRole(role string) error {
+	switch role {
+	case RoleMember, RoleSpectator:
+		return nil
+	default:
+		return fmt.Errorf("invalid role %q (use: %s or %s)", role, RoleMember, RoleSpectator)
+	}
+}
+
+// Role returns a member's role; members without one are full members.
+func (c AxleConfig) Role(username string) string {
+	if role, ok := c.Roles[username]; ok {
+		return role
+	}
+	return RoleMember
+}
+
+// Spectators returns the usernames of the team's spectators, sorted.
+func (c AxleConfig) Spectators() []string {
+	var spectators []string
+	for username, role := range c.Roles {
+		if role == RoleSpectator {
+			spectators = append(spectators, username)
+		}
+	}
+	sort.Strings(spectators)
+	return spectators
+}
+
+var (
+	rolesMu sync.RWMutex
+	roles   map[string]string
+)
+
+// SetRoles registers the team's membership roles, so changes from
+// spectators are held back and dropped.
+func SetRoles(teamRoles map[string]string) {
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+	roles = teamRoles
+}
+
+// IsSpectator reports whether a member only watches the team's changes.
+func IsSpectator(username string) bool {
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	return roles[username] == RoleSpectator
+}
+
+// FilterSpectatorChanges removes every change from a batch by a spectator.
+// Both sides filter: a spectator's daemon never publishes, and daemons that
+// know the role drop what a spectator sends anyway.
+func FilterSpectatorChanges(peer string, changes []FileChange) []FileChange {
+	if !IsSpectator(peer) || len(changes) == 0 {
+		return changes
+	}
+	log.Printf("[ROLE] Not syncing %d changes by %s: spectators only receive changes", len(changes), peer)
+	return nil
+}
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRollbackCommits keeps a mistyped count from undoing the whole history
+const maxRollbackCommits = 50
+
+// RollbackCommit is a commit 'axle rollback' reverts.
+type RollbackCommit struct {
+	Hash    string `json:"hash"`
+	Author  string `json:"author"`
+	Subject string `json:"subject"`
+}
+
+// RollbackReport is the outcome of 'axle rollback'.
+type RollbackReport struct {
+	Reverted []RollbackCommit `json:"reverted"` // Newest first
+	Commit   string           `json:"commit"`   // The revert commit
+	Files    int              `json:"files"`    // Files published with it
+}
+
+// RollbackTargets resolves what 'axle rollback' would revert, newest first:
+// the last n commits for a count, or every commit from HEAD back to and
+// including the given one. Merge commits are skipped.
+func RollbackTargets(directory, target string) ([]RollbackCommit, error) {
+	if UsingEmbeddedBackend() {
+		return nil, ErrGitMissing
+	}
+	args := []string{"-C", directory, "log", "--no-merges", "--format=%H%x1f%an%x1f%s"}
+	if n, err := strconv.Atoi(target); err == nil && len(target) < 4 {
+		if n < 1 || n > maxRollbackCommits {
+			return nil, fmt.Errorf("can roll back 1 to %d commits at a time", maxRollbackCommits)
+		}
+		args = append(args, fmt.Sprintf("-n%d", n), "HEAD")
+	} else {
+		commit, err := RevParse(directory, target)
+		if err != nil {
+			return nil, fmt.Errorf("unknown commit %s", target)
+		}
+		if GitCommand("-C", directory, "merge-base", "--is-ancestor", commit, "HEAD").Run() != nil {
+			return nil, fmt.Errorf("%s is not in the current history", target)
+		}
+		// The commit itself and everything after it
+		args = append(args, fmt.Sprintf("-n%d", maxRollbackCommits+1), "HEAD", "--not")
+		parents, _ := GitCommand("-C", directory, "rev-parse", commit+"^@").Output()
+		args = append(args, strings.Fields(string(parents))...)
+		if len(strings.Fields(string(parents))) == 0 {
+			return nil, fmt.Errorf("%s is the first commit and can't be rolled back", shortHash(commit))
+		}
+	}
+
+	output, err := GitCommand(args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git history: %w", err)
+	}
+	var commits []RollbackCommit
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, RollbackCommit{Hash: fields[0], Author: fields[1], Subject: fields[2]})
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("there are no commits to roll back")
+	}
+	if len(commits) > maxRollbackCommits {
+		return nil, fmt.Errorf("%s is more than %d commits back; roll back in steps", target, maxRollbackCommits)
+	}
+	for _, commit := range commits {
+		if GitCommand("-C", directory, "rev-parse", "--verify", "--quiet", commit.Hash+"^").Run() != nil {
+			return nil, fmt.Errorf("%s is the first commit and can't be rolled back", shortHash(commit.Hash))
+		}
+	}
+	return commits, nil
+}
+
+// RunRollback reverts target (see RollbackTargets) in one new commit and
+// publishes it, so every teammate applies the same revert instead of each
+// rolling back on their own. expectHead, when set, must still be HEAD, so
+// the commits the user confirmed are the ones reverted. A checkpoint keeps
+// the tree as it was. The daemon runs it so the watcher doesn't publish the
+// reverted files separately.
+func RunRollback(cfg AppConfig, target, expectHead string) (RollbackReport, error) {
+	var report RollbackReport
+	if expectHead != "" {
+		head, err := RevParse(cfg.RootDir, "HEAD")
+		if err != nil || head != expectHead {
+			return report, fmt.Errorf("new commits arrived since the rollback was previewed; run 'axle rollback' again")
+		}
+	}
+	commits, err := RollbackTargets(cfg.RootDir, target)
+	if err != nil {
+		return report, err
+	}
+
+	// Reverting over local edits would publish them as part of the rollback
+	status, err := GitCommand("-C", cfg.RootDir, "status", "--porcelain", "--untracked-files=no").Output()
+	if err != nil {
+		return report, fmt.Errorf("failed to check for local changes: %w", err)
+	}
+	if len(strings.TrimSpace(string(status))) > 0 {
+		return report, fmt.Errorf("you have changes that aren't committed yet; wait for them to sync and try again")
+	}
+
+	if _, err := CreateAutoCheckpoint(cfg.RootDir, fmt.Sprintf("rolling back %d commits", len(commits))); err != nil {
+		return report, err
+	}
+
+	SetIsApplyingPatch(true)
+	defer func() {
+		time.Sleep(100 * time.Millisecond) // Let the watcher see the writes while they're suppressed
+		SetIsApplyingPatch(false)
+	}()
+
+	revertArgs := []string{"-C", cfg.RootDir, "revert", "--no-commit"}
+	for _, commit := range commits {
+		revertArgs = append(revertArgs, commit.Hash)
+	}
+	if output, err := GitCommand(revertArgs...).CombinedOutput(); err != nil {
+		GitCommand("-C", cfg.RootDir, "revert", "--abort").Run()
+		return report, fmt.Errorf("the commits can't be reverted cleanly; later changes depend on them:\n%s", strings.TrimSpace(string(output)))
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "[ROLLBACK] Revert %d commits\n\n", len(commits))
+	for _, commit := range commits {
+		fmt.Fprintf(&message, "Reverts %s %s\n", shortHash(commit.Hash), commit.Subject)
+	}
+	hash, err := CommitChanges(cfg.RootDir, message.String())
+	GitCommand("-C", cfg.RootDir, "revert", "--quit").Run()
+	if err != nil {
+		return report, err
+	}
+	if hash == "" {
+		return report, fmt.Errorf("nothing to roll back; the changes of these commits are already undone")
+	}
+
+	report.Reverted = commits
+	report.Commit = hash
+	if report.Files, err = queueCommit(cfg, hash); err != nil {
+		return report, fmt.Errorf("rolled back locally but failed to publish the rollback: %w", err)
+	}
+	log.Printf("[ROLLBACK] Reverted %d commits in %s", len(commits), shortHash(hash))
+	return report, nil
+}
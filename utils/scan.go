@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// scanTimeout bounds how long the configured scanner may take on one batch.
+const scanTimeout = 2 * time.Minute
+
+// maxScanOutput keeps the scanner output stored with a quarantined batch small.
+const maxScanOutput = 4000
+
+// ScanVerdict is the outcome of scanning an incoming batch.
+type ScanVerdict struct {
+	Clean  bool
+	Reason string // Why the batch isn't clean
+	Output string // What the scanner printed
+}
+
+// QuarantinedBatch is an incoming batch the scanner flagged, kept in
+// .axle/quarantine instead of being applied.
+type QuarantinedBatch struct {
+	ID            string       `json:"id"`
+	Metadata      SyncMetadata `json:"metadata"`
+	Reason        string       `json:"reason"`
+	Output        string       `json:"output,omitempty"`
+	QuarantinedAt int64        `json:"quarantinedAt"`
+}
+
+func quarantineDir(rootDir string) string {
+	return AxlePath(rootDir, "quarantine")
+}
+
+// ScanBatch writes the content an incoming batch would bring in to a scratch
+// directory and runs the scanner command on it. The directory replaces {} in
+// the command, or is added as its last argument. Exit status 0 means clean;
+// anything else, including a scanner that can't run, fails closed.
+func ScanBatch(ctx context.Context, cfg AppConfig, metadata SyncMetadata) ScanVerdict {
+	if cfg.ScanCommand == "" {
+		return ScanVerdict{Clean: true}
+	}
+
+	tmpRoot := AxlePath(cfg.RootDir, "tmp")
+	if err := os.MkdirAll(tmpRoot, 0755); err != nil {
+		return ScanVerdict{Reason: fmt.Sprintf("failed to create %s: %v", tmpRoot, err)}
+	}
+	dir, err := os.MkdirTemp(tmpRoot, "scan-*")
+	if err != nil {
+		return ScanVerdict{Reason: fmt.Sprintf("failed to create scan directory: %v", err)}
+	}
+	defer os.RemoveAll(dir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	written, err := writeScanFiles(ctx, cfg, metadata, dir)
+	if err != nil {
+		return ScanVerdict{Reason: err.Error()}
+	}
+	if written == 0 {
+		return ScanVerdict{Clean: true}
+	}
+
+	fields := strings.Fields(cfg.ScanCommand)
+	args := fields[1:]
+	if strings.Contains(cfg.ScanCommand, "{}") {
+		for i, arg := range args {
+			args[i] = strings.ReplaceAll(arg, "{}", dir)
+		}
+	} else {
+		args = append(args, dir)
+	}
+
+	scanCtx, cancel := context.WithTimeout(ctx, scanTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(scanCtx, fields[0], args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = cmd.Run()
+
+	output := strings.TrimSpace(out.String())
+	if len(output) > maxScanOutput {
+		output = output[:maxScanOutput] + "..."
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return ScanVerdict{Clean: true, Output: output}
+	case scanCtx.Err() == context.DeadlineExceeded:
+		return ScanVerdict{Reason: fmt.Sprintf("scanner timed out after %v", scanTimeout), Output: output}
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return ScanVerdict{Reason: "scanner flagged the content", Output: output}
+	case errors.As(err, &exitErr):
+		return ScanVerdict{Reason: fmt.Sprintf("scanner failed with exit status %d", exitErr.ExitCode()), Output: output}
+	default:
+		return ScanVerdict{Reason: fmt.Sprintf("failed to run scanner: %v", err), Output: output}
+	}
+}
+
+// writeScanFiles writes what each change would write to the tree under dir:
+// the added lines of a patch, the bytes of an append, the whole content of a
+// force-synced file. Binary patches are written as-is next to them. It
+// returns how many files it wrote.
+func writeScanFiles(ctx context.Context, cfg AppConfig, metadata SyncMetadata, dir string) (int, error) {
+	contents := make(map[string][]byte)
+	for _, change := range metadata.Changes {
+		if err := DecodeChange(&change); err != nil {
+			return 0, fmt.Errorf("failed to decode %s for scanning: %v", change.File, err)
+		}
+
+		switch change.Event {
+		case "placeholder", "deleted":
+			// Nothing arrives with these
+		case "appended":
+			data, err := base64.StdEncoding.DecodeString(change.Data)
+			if err != nil {
+				return 0, fmt.Errorf("failed to decode appended data for %s: %v", change.File, err)
+			}
+			contents[change.File] = append(contents[change.File], data...)
+		case "chunked":
+			data, err := LoadBlob(ctx, cfg, change)
+			if err != nil {
+				return 0, fmt.Errorf("failed to load %s for scanning: %v", change.File, err)
+			}
+			contents[change.File] = data
+		default:
+			for file, added := range addedPatchContent(change.Patch) {
+				contents[file] = append(contents[file], added...)
+			}
+			if strings.Contains(change.Patch, "GIT binary patch") {
+				contents[change.File+".patch"] = []byte(change.Patch)
+			}
+		}
+	}
+
+	var i int
+	for file, data := range contents {
+		i++
+		rel := filepath.FromSlash(file)
+		// Paths that would escape the scan directory are scanned under a safe name
+		if !filepath.IsLocal(rel) {
+			rel = fmt.Sprintf("change-%d", i)
+		}
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return 0, fmt.Errorf("failed to prepare %s for scanning: %v", file, err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return 0, fmt.Errorf("failed to prepare %s for scanning: %v", file, err)
+		}
+	}
+	return len(contents), nil
+}
+
+// addedPatchContent collects the lines a patch adds, per target file.
+func addedPatchContent(patch string) map[string][]byte {
+	added := make(map[string][]byte)
+	var file string
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			file = strings.TrimPrefix(strings.Trim(strings.TrimPrefix(line, "+++ "), `"`), "b/")
+			if file == "/dev/null" {
+				file = ""
+			}
+		case strings.HasPrefix(line, "diff --git "):
+			file = ""
+		case strings.HasPrefix(line, "+") && file != "":
+			added[file] = append(added[file], line[1:]+"\n"...)
+		}
+	}
+	return added
+}
+
+// QuarantineBatch stores a flagged batch in .axle/quarantine and returns its ID.
+func QuarantineBatch(rootDir string, metadata SyncMetadata, verdict ScanVerdict) (string, error) {
+	if err := os.MkdirAll(quarantineDir(rootDir), 0755); err != nil {
+		return "", fmt.Errorf("failed to create quarantine directory: %w", err)
+	}
+
+	batch := QuarantinedBatch{
+		ID:            fmt.Sprintf("%d-%s", time.Now().UnixNano(), metadata.PeerID),
+		Metadata:      metadata,
+		Reason:        verdict.Reason,
+		Output:        verdict.Output,
+		QuarantinedAt: time.Now().Unix(),
+	}
+	data, err := json.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal quarantined batch: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(quarantineDir(rootDir), batch.ID+".json"), data, 0600); err != nil {
+		return "", fmt.Errorf("failed to save quarantined batch: %w", err)
+	}
+	return batch.ID, nil
+}
+
+// ListQuarantinedBatches returns the quarantined batches, oldest first.
+func ListQuarantinedBatches(rootDir string) ([]QuarantinedBatch, error) {
+	entries, err := os.ReadDir(quarantineDir(rootDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read quarantine: %w", err)
+	}
+
+	var batches []QuarantinedBatch
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		batch, err := LoadQuarantinedBatch(rootDir, strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		batches = append(batches, batch)
+	}
+	sort.Slice(batches, func(i, j int) bool { return batches[i].ID < batches[j].ID })
+	return batches, nil
+}
+
+// LoadQuarantinedBatch reads one quarantined batch by ID.
+func LoadQuarantinedBatch(rootDir, id string) (QuarantinedBatch, error) {
+	var batch QuarantinedBatch
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return batch, fmt.Errorf("invalid quarantine ID %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(quarantineDir(rootDir), id+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return batch, fmt.Errorf("no quarantined batch %s", id)
+		}
+		return batch, fmt.Errorf("failed to read quarantined batch %s: %w", id, err)
+	}
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return batch, fmt.Errorf("failed to parse quarantined batch %s: %w", id, err)
+	}
+	return batch, nil
+}
+
+// RemoveQuarantinedBatch deletes a quarantined batch.
+func RemoveQuarantinedBatch(rootDir, id string) error {
+	if _, err := LoadQuarantinedBatch(rootDir, id); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(quarantineDir(rootDir), id+".json")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove quarantined batch %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,411 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScratchpadFileName is the team scratchpad at the root of the sync folder.
+// It is kept out of git and synced line by line through Redis instead, so
+// simultaneous edits merge without conflicts.
+const ScratchpadFileName = "TEAM_NOTES.md"
+
+// maxScratchpadLines bounds the line diff, which is quadratic
+const maxScratchpadLines = 2000
+
+// ScratchpadEntry is one line of the scratchpad. Entries form a replicated
+// growable array: each is placed after the entry it was typed after, and
+// entries are never changed except to be marked deleted, so edits from any
+// number of members merge the same way everywhere.
+type ScratchpadEntry struct {
+	ID        int64  `json:"id"`
+	After     int64  `json:"after,omitempty"` // Entry this line follows, 0 for the start
+	Text      string `json:"text"`
+	Author    string `json:"author"`
+	Timestamp int64  `json:"timestamp"`
+	Deleted   bool   `json:"deleted,omitempty"`
+}
+
+// ScratchpadMessage tells the team that the scratchpad changed.
+type ScratchpadMessage struct {
+	Author    string `json:"author"`
+	Inserted  int    `json:"inserted"`
+	Deleted   int    `json:"deleted"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// scratchpadSettleDelay is how long the scratchpad file must be quiet
+// before edits to it are shared, so half-saved files aren't diffed
+const scratchpadSettleDelay = 500 * time.Millisecond
+
+var (
+	scratchpadMu sync.Mutex
+	// scratchpadBase is what this daemon last wrote to the scratchpad file;
+	// edits to the file are diffed against it
+	scratchpadBase []ScratchpadEntry
+	// scratchpadLoaded is set once the file has been written from Redis;
+	// until then there is no base to diff against
+	scratchpadLoaded bool
+	// scratchpadEditMu serializes sharing file edits, so one edit can't be
+	// diffed against the same base twice
+	scratchpadEditMu sync.Mutex
+	scratchpadTimer  *time.Timer
+)
+
+func scratchpadKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:scratchpad", teamID)
+}
+
+func scratchpadSeqKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:scratchpad_seq", teamID)
+}
+
+// LoadScratchpad returns the scratchpad's lines in document order, without
+// deleted ones.
+func LoadScratchpad(ctx context.Context, cfg AppConfig) ([]ScratchpadEntry, error) {
+	fields, err := cfg.RedisClient.HGetAll(ctx, scratchpadKey(cfg.TeamID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the scratchpad: %w", err)
+	}
+
+	entries := make([]ScratchpadEntry, 0, len(fields))
+	for _, data := range fields {
+		var entry ScratchpadEntry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return orderScratchpad(entries), nil
+}
+
+// orderScratchpad puts entries in document order. An entry comes right after
+// the one it follows; when several follow the same entry, the newest comes
+// first, which keeps each member's run of lines together.
+func orderScratchpad(entries []ScratchpadEntry) []ScratchpadEntry {
+	children := make(map[int64][]ScratchpadEntry)
+	for _, entry := range entries {
+		children[entry.After] = append(children[entry.After], entry)
+	}
+	for after := range children {
+		siblings := children[after]
+		sort.Slice(siblings, func(i, j int) bool { return siblings[i].ID > siblings[j].ID })
+	}
+
+	// Depth-first without recursion; a long scratchpad is one long chain
+	var ordered []ScratchpadEntry
+	stack := reverseEntries(children[0])
+	for len(stack) > 0 {
+		entry := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		// Deleted lines still anchor the lines typed after them
+		if !entry.Deleted {
+			ordered = append(ordered, entry)
+		}
+		stack = append(stack, reverseEntries(children[entry.ID])...)
+	}
+	return ordered
+}
+
+func reverseEntries(entries []ScratchpadEntry) []ScratchpadEntry {
+	reversed := make([]ScratchpadEntry, len(entries))
+	for i, entry := range entries {
+		reversed[len(entries)-1-i] = entry
+	}
+	return reversed
+}
+
+// RenderScratchpad returns the text of the scratchpad's lines.
+func RenderScratchpad(entries []ScratchpadEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	lines := make([]string, len(entries))
+	for i, entry := range entries {
+		lines[i] = entry.Text
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// EditScratchpad turns the difference between base, the lines the member
+// started from, and text, what they ended up with, into inserted and deleted
+// lines. Lines others added since base was read are left alone. Returns how
+// many lines were inserted and deleted.
+func EditScratchpad(ctx context.Context, cfg AppConfig, base []ScratchpadEntry, text string) (int, int, error) {
+	lines := splitScratchpadLines(text)
+	if len(base) > maxScratchpadLines || len(lines) > maxScratchpadLines {
+		return 0, 0, fmt.Errorf("the scratchpad is limited to %d lines", maxScratchpadLines)
+	}
+
+	ops := diffLines(base, lines)
+	if len(ops) == 0 {
+		return 0, 0, nil
+	}
+	var inserted int
+	for _, op := range ops {
+		if op.insert {
+			inserted++
+		}
+	}
+
+	var nextID int64
+	if inserted > 0 {
+		last, err := cfg.RedisClient.IncrBy(ctx, scratchpadSeqKey(cfg.TeamID), int64(inserted)).Result()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to allocate scratchpad lines: %w", err)
+		}
+		nextID = last - int64(inserted) + 1
+	}
+
+	now := time.Now().Unix()
+	var anchor int64
+	var deleted int
+	values := make(map[string]interface{})
+	for _, op := range ops {
+		switch {
+		case op.insert:
+			entry := ScratchpadEntry{ID: nextID, After: anchor, Text: lines[op.index], Author: cfg.Username, Timestamp: now}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return 0, 0, err
+			}
+			values[strconv.FormatInt(entry.ID, 10)] = data
+			anchor = entry.ID
+			nextID++
+		case op.delete:
+			// Deleting only ever sets the flag, so concurrent deletes agree
+			entry := base[op.index]
+			entry.Deleted = true
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return 0, 0, err
+			}
+			values[strconv.FormatInt(entry.ID, 10)] = data
+			deleted++
+		default:
+			anchor = base[op.index].ID
+		}
+	}
+	if err := cfg.RedisClient.HSet(ctx, scratchpadKey(cfg.TeamID), values).Err(); err != nil {
+		return 0, 0, fmt.Errorf("failed to save the scratchpad: %w", err)
+	}
+
+	msg := ScratchpadMessage{Author: cfg.Username, Inserted: inserted, Deleted: deleted, Timestamp: now}
+	if err := PublishMessage(ctx, cfg.Transport, ScratchpadChannel(cfg.TeamID), msg); err != nil {
+		log.Printf("[NOTES] Failed to announce scratchpad edit: %v", err)
+	}
+	return inserted, deleted, nil
+}
+
+// AppendScratchpad adds lines to the end of the scratchpad.
+func AppendScratchpad(ctx context.Context, cfg AppConfig, text string) error {
+	base, err := LoadScratchpad(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	_, _, err = EditScratchpad(ctx, cfg, base, RenderScratchpad(base)+text)
+	return err
+}
+
+func splitScratchpadLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// lineOp is one step of a line diff: keep or delete base[index], or insert
+// lines[index].
+type lineOp struct {
+	index          int
+	insert, delete bool
+}
+
+// diffLines returns the steps turning base into lines, keeping their longest
+// common subsequence. Returns nil if nothing changed.
+func diffLines(base []ScratchpadEntry, lines []string) []lineOp {
+	// Most edits touch a few lines; only the middle needs the quadratic part
+	prefix := 0
+	for prefix < len(base) && prefix < len(lines) && base[prefix].Text == lines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(base)-prefix && suffix < len(lines)-prefix &&
+		base[len(base)-1-suffix].Text == lines[len(lines)-1-suffix] {
+		suffix++
+	}
+	if prefix+suffix == len(base) && prefix+suffix == len(lines) {
+		return nil
+	}
+
+	var ops []lineOp
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, lineOp{index: i})
+	}
+
+	n, m := len(base)-prefix-suffix, len(lines)-prefix-suffix
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if base[prefix+i].Text == lines[prefix+j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && base[prefix+i].Text == lines[prefix+j]:
+			ops = append(ops, lineOp{index: prefix + i})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]):
+			ops = append(ops, lineOp{index: prefix + j, insert: true})
+			j++
+		default:
+			ops = append(ops, lineOp{index: prefix + i, delete: true})
+			i++
+		}
+	}
+
+	for k := len(base) - suffix; k < len(base); k++ {
+		ops = append(ops, lineOp{index: k})
+	}
+	return ops
+}
+
+// StartScratchpad prepares the scratchpad file when the daemon starts: it is
+// kept out of git, seeded into Redis if the team has no scratchpad yet, and
+// otherwise refreshed from Redis.
+func StartScratchpad(ctx context.Context, cfg AppConfig) {
+	if err := EnsureGitExclude(cfg.RootDir, "/"+ScratchpadFileName); err != nil {
+		log.Printf("[NOTES] %v", err)
+	}
+	// A scratchpad committed before it was managed would conflict like any file
+	if output, err := GitCommand("-C", cfg.RootDir, "ls-files", "--", ScratchpadFileName).Output(); err == nil && len(output) > 0 {
+		if err := GitCommand("-C", cfg.RootDir, "rm", "--cached", "--quiet", "--", ScratchpadFileName).Run(); err != nil {
+			log.Printf("[NOTES] Failed to stop tracking %s: %v", ScratchpadFileName, err)
+		}
+	}
+
+	entries, err := LoadScratchpad(ctx, cfg)
+	if err != nil {
+		log.Printf("[NOTES] %v", err)
+		return
+	}
+	path := filepath.Join(cfg.RootDir, ScratchpadFileName)
+	local, err := os.ReadFile(path)
+	if err == nil && len(entries) == 0 && len(splitScratchpadLines(string(local))) > 0 {
+		if _, _, err := EditScratchpad(ctx, cfg, nil, string(local)); err != nil {
+			log.Printf("[NOTES] Failed to share %s: %v", ScratchpadFileName, err)
+			return
+		}
+		log.Printf("[NOTES] Shared the existing %s as the team scratchpad", ScratchpadFileName)
+	} else if err == nil && string(local) != RenderScratchpad(entries) {
+		// Keep local notes the team never saw rather than overwriting them
+		backup := conflictBackupPath(cfg.RootDir, ScratchpadFileName)
+		if err := os.MkdirAll(filepath.Dir(backup), 0755); err == nil && copyFile(path, backup) == nil {
+			log.Printf("[NOTES] Replaced %s with the team scratchpad; your copy is in %s", ScratchpadFileName, backup)
+		}
+	}
+	RefreshScratchpadFile(ctx, cfg)
+}
+
+// RefreshScratchpadFile writes the team's scratchpad to the local file.
+func RefreshScratchpadFile(ctx context.Context, cfg AppConfig) {
+	entries, err := LoadScratchpad(ctx, cfg)
+	if err != nil {
+		log.Printf("[NOTES] %v", err)
+		return
+	}
+
+	scratchpadMu.Lock()
+	defer scratchpadMu.Unlock()
+	path := filepath.Join(cfg.RootDir, ScratchpadFileName)
+	text := RenderScratchpad(entries)
+	if current, err := os.ReadFile(path); err != nil || string(current) != text {
+		if err := WriteFileAtomic(path, []byte(text), 0644); err != nil {
+			log.Printf("[NOTES] Failed to write %s: %v", ScratchpadFileName, err)
+			return
+		}
+	}
+	scratchpadBase = entries
+	scratchpadLoaded = true
+}
+
+// scratchpadFileChanged shares edits made directly to the scratchpad file
+// once it has been quiet for a moment.
+func scratchpadFileChanged(ctx context.Context, cfg AppConfig) {
+	scratchpadMu.Lock()
+	defer scratchpadMu.Unlock()
+	if scratchpadTimer != nil {
+		scratchpadTimer.Stop()
+	}
+	scratchpadTimer = time.AfterFunc(scratchpadSettleDelay, func() {
+		shareScratchpadFile(ctx, cfg)
+	})
+}
+
+// shareScratchpadFile turns the differences between the scratchpad file and
+// what was last written to it into scratchpad edits.
+func shareScratchpadFile(ctx context.Context, cfg AppConfig) {
+	scratchpadEditMu.Lock()
+	defer scratchpadEditMu.Unlock()
+
+	data, err := os.ReadFile(filepath.Join(cfg.RootDir, ScratchpadFileName))
+	if err != nil {
+		return // Deleted; it comes back with the next edit
+	}
+
+	scratchpadMu.Lock()
+	base, loaded := scratchpadBase, scratchpadLoaded
+	scratchpadMu.Unlock()
+	if !loaded {
+		return // StartScratchpad hasn't synced the file yet
+	}
+	if string(data) == RenderScratchpad(base) {
+		return // Our own write
+	}
+
+	inserted, deleted, err := EditScratchpad(ctx, cfg, base, string(data))
+	if err != nil {
+		log.Printf("[NOTES] Failed to share edits to %s: %v", ScratchpadFileName, err)
+		return
+	}
+	if inserted > 0 || deleted > 0 {
+		log.Printf("[NOTES] Shared your scratchpad edits (%d added, %d removed)", inserted, deleted)
+	}
+	RefreshScratchpadFile(ctx, cfg)
+}
+
+// ProcessScratchpadMessage refreshes the scratchpad file after a teammate's edit.
+func ProcessScratchpadMessage(ctx context.Context, cfg AppConfig, payload string) {
+	var msg ScratchpadMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		log.Printf("[NOTES] Error unmarshaling scratchpad message: %v", err)
+		return
+	}
+	if AdmitSigned(cfg, ScratchpadChannel(cfg.TeamID), msg.Author, payload) != nil {
+		return
+	}
+	if msg.Author != cfg.Username {
+		log.Printf("[NOTES] %s edited %s (%d added, %d removed)", msg.Author, ScratchpadFileName, msg.Inserted, msg.Deleted)
+	}
+	RefreshScratchpadFile(ctx, cfg)
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// ErrDaemonAlreadyRunning is returned when another daemon holds the sync
+// root's session lock.
+var ErrDaemonAlreadyRunning = errors.New("axle is already running in this directory")
+
+// SessionLockInfo identifies the daemon holding a sync root, as written to
+// .axle/daemon.lock.
+type SessionLockInfo struct {
+	PID       int    `json:"pid"`
+	Hostname  string `json:"hostname"`
+	StartedAt int64  `json:"startedAt"`
+}
+
+func sessionLockFile(rootDir string) string {
+	return AxlePath(rootDir, "daemon.lock")
+}
+
+// AcquireSessionLock makes this process the only daemon for a sync root. Two
+// daemons in one directory would commit every change twice and echo each
+// other's events. The lock is an OS lock on .axle/daemon.lock, so it is
+// released even if the daemon crashes; the file also records the holder's
+// PID for the error message, and for a liveness check on file systems
+// without locking. Call the returned function on shutdown.
+func AcquireSessionLock(rootDir string) (func(), error) {
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", AxleDirName, err)
+	}
+	file, err := os.OpenFile(sessionLockFile(rootDir), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session lock: %w", err)
+	}
+
+	locked, lockErr := tryLockSession(file)
+	previous, hasPrevious := readSessionLock(file)
+	if lockErr == nil && !locked {
+		file.Close()
+		if hasPrevious {
+			return nil, fmt.Errorf("%w (PID %d on %s, started %s). Stop it first, or run Axle commands against it instead",
+				ErrDaemonAlreadyRunning, previous.PID, previous.Hostname, time.Unix(previous.StartedAt, 0).Format("Jan 2 15:04:05"))
+		}
+		return nil, fmt.Errorf("%w. Stop it first, or run Axle commands against it instead", ErrDaemonAlreadyRunning)
+	}
+	if lockErr != nil {
+		log.Printf("[AXLE] File locking isn't available here (%v); falling back to a PID check", lockErr)
+		if hasPrevious && previous.PID != os.Getpid() && sessionHolderAlive(rootDir, previous) {
+			file.Close()
+			return nil, fmt.Errorf("%w (PID %d on %s). Stop it first, or delete %s if it's gone",
+				ErrDaemonAlreadyRunning, previous.PID, previous.Hostname, sessionLockFile(rootDir))
+		}
+	}
+	if hasPrevious && previous.PID != os.Getpid() {
+		log.Printf("[AXLE] Cleared stale session lock left by PID %d on %s", previous.PID, previous.Hostname)
+	}
+
+	hostname, _ := os.Hostname()
+	info := SessionLockInfo{PID: os.Getpid(), Hostname: hostname, StartedAt: time.Now().Unix()}
+	if err := writeSessionLock(file, &info); err != nil {
+		if locked {
+			unlockSession(file)
+		}
+		file.Close()
+		return nil, fmt.Errorf("failed to write session lock: %w", err)
+	}
+
+	return func() {
+		// Emptied rather than removed: deleting a locked file lets a
+		// starting daemon lock the old inode while another creates a new one
+		writeSessionLock(file, nil)
+		if locked {
+			unlockSession(file)
+		}
+		file.Close()
+	}, nil
+}
+
+// readSessionLock returns the holder recorded in the lock file, if any.
+func readSessionLock(file *os.File) (SessionLockInfo, bool) {
+	var info SessionLockInfo
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, 4096))
+	if err != nil || len(data) == 0 {
+		return info, false
+	}
+	if err := json.Unmarshal(data, &info); err != nil || info.PID == 0 {
+		return info, false
+	}
+	return info, true
+}
+
+// writeSessionLock replaces the lock file's contents with info, or empties it.
+func writeSessionLock(file *os.File, info *SessionLockInfo) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	if info == nil {
+		return nil
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteAt(data, 0); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// sessionHolderAlive reports whether the daemon recorded in a lock file still
+// seems to be running. Processes on other machines sharing the directory
+// can't be checked directly, so their daemon state file's freshness is used.
+func sessionHolderAlive(rootDir string, info SessionLockInfo) bool {
+	if hostname, _ := os.Hostname(); info.Hostname == hostname {
+		return processAlive(info.PID)
+	}
+	state, err := ReadDaemonState(rootDir)
+	return err == nil && state.PID == info.PID && state.IsRunning()
+}
@@ -0,0 +1,348 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Messages are signed with an HMAC keyed by the team password, so someone
+// with access to Redis but not the password can't inject batches or chat
+// that members apply. The signature is appended to the JSON object as a
+// last "sig" field, which older releases ignore.
+//
+// Guests have no password. They sign with an Ed25519 key derived from their
+// token instead, whose public half is in their pass in the admin-signed team
+// config, so their messages can be checked without anyone else being able
+// to make them.
+
+// signatureField starts the field a signature is appended to a message as
+const signatureField = `"sig":"`
+
+var (
+	// ErrUnsigned is returned for a message without a signature.
+	ErrUnsigned = errors.New("message is not signed")
+	// ErrBadSignature is returned for a message whose signature doesn't match.
+	ErrBadSignature = errors.New("message signature does not match the team's key")
+	// ErrReplayed is returned for a message that was already received, or
+	// was sent too long ago to tell.
+	ErrReplayed = errors.New("message was replayed")
+)
+
+var (
+	signingMu  sync.RWMutex
+	signingKey []byte
+	guestKey   ed25519.PrivateKey // Set instead of signingKey on a guest's node
+)
+
+// guestSigningKey derives the key guest name signs with from their token.
+func guestSigningKey(name, token string) ed25519.PrivateKey {
+	seed := sha256.Sum256([]byte("axle-guest-signing:" + name + ":" + token))
+	return ed25519.NewKeyFromSeed(seed[:])
+}
+
+// GuestVerifyKey returns the public key that checks what guest name signs
+// with their token, for their pass.
+func GuestVerifyKey(name, token string) string {
+	return hex.EncodeToString(guestSigningKey(name, token).Public().(ed25519.PublicKey))
+}
+
+// SetGuestSigningKey makes this node sign as guest name, with their token.
+func SetGuestSigningKey(name, token string) {
+	signingMu.Lock()
+	defer signingMu.Unlock()
+	guestKey = guestSigningKey(name, token)
+}
+
+func signingKeyFile(rootDir string) string {
+	return AxlePath(rootDir, "signing.key")
+}
+
+// DeriveSigningKey derives the team's message signing key from its
+// password. It is deliberately slow, so a captured message doesn't make the
+// password cheaper to guess than the stored password hash does.
+func DeriveSigningKey(teamID, password string) []byte {
+	return argon2.IDKey([]byte(password), []byte("axle-signing:"+teamID), 1, 64*1024, 4, 32)
+}
+
+// SaveSigningKey derives the team's signing key from the password, starts
+// using it and keeps it in the .axle directory, readable only by the
+// user, for commands that publish without asking for the password.
+func SaveSigningKey(rootDir, teamID, password string) error {
+	key := DeriveSigningKey(teamID, password)
+	SetSigningKey(key)
+	return writeSigningKey(rootDir, key)
+}
+
+// writeSigningKey keeps key in the .axle directory without starting to use
+// it.
+func writeSigningKey(rootDir string, key []byte) error {
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", AxleDirName, err)
+	}
+	if err := os.WriteFile(signingKeyFile(rootDir), []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to save signing key: %w", err)
+	}
+	return nil
+}
+
+// LoadSigningKey starts using the signing key kept in the .axle directory.
+// Without one, as for guests, messages go out unsigned.
+func LoadSigningKey(rootDir string) error {
+	data, err := os.ReadFile(signingKeyFile(rootDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read signing key: %w", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != 32 {
+		return fmt.Errorf("invalid signing key in %s", signingKeyFile(rootDir))
+	}
+	SetSigningKey(key)
+	return nil
+}
+
+// SetSigningKey sets the key messages are signed and verified with.
+func SetSigningKey(key []byte) {
+	signingMu.Lock()
+	defer signingMu.Unlock()
+	signingKey = key
+}
+
+// HasSigningKey reports whether this node can sign and verify messages.
+func HasSigningKey() bool {
+	signingMu.RLock()
+	defer signingMu.RUnlock()
+	return len(signingKey) > 0
+}
+
+// messageMAC returns the signature of a message published on channel, so a
+// message can't be replayed on another channel.
+func messageMAC(key []byte, channel string, data []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(channel))
+	mac.Write([]byte{0})
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signedContent is what a guest's signature covers: the channel and the
+// message, as for messageMAC.
+func signedContent(channel string, data []byte) []byte {
+	content := make([]byte, 0, len(channel)+1+len(data))
+	content = append(content, channel...)
+	content = append(content, 0)
+	return append(content, data...)
+}
+
+// SignMessage appends a signature to a JSON object about to be published on
+// channel. Without a signing key it is returned as is.
+func SignMessage(channel string, data []byte) []byte {
+	signingMu.RLock()
+	key, guest := signingKey, guestKey
+	signingMu.RUnlock()
+	if len(data) < 2 || data[len(data)-1] != '}' {
+		return data
+	}
+	var signature string
+	switch {
+	case len(key) > 0:
+		signature = messageMAC(key, channel, data)
+	case guest != nil:
+		signature = hex.EncodeToString(ed25519.Sign(guest, signedContent(channel, data)))
+	default:
+		return data
+	}
+
+	signed := make([]byte, 0, len(data)+len(signatureField)+len(signature)+3)
+	signed = append(signed, data[:len(data)-1]...)
+	if !bytes.Equal(bytes.TrimSpace(data), []byte("{}")) {
+		signed = append(signed, ',')
+	}
+	signed = append(signed, signatureField...)
+	signed = append(signed, signature...)
+	return append(signed, '"', '}')
+}
+
+// splitSignature separates a signed message into what was signed and the
+// signature. Unsigned messages return an empty signature.
+func splitSignature(payload string) (string, string) {
+	return splitSignatureOfLength(payload, sha256.Size*2)
+}
+
+// splitGuestSignature separates a message signed by a guest into what was
+// signed and the signature.
+func splitGuestSignature(payload string) (string, string) {
+	return splitSignatureOfLength(payload, ed25519.SignatureSize*2)
+}
+
+// splitSignatureOfLength separates a signature of sigLen hex digits from a
+// message.
+func splitSignatureOfLength(payload string, sigLen int) (string, string) {
+	end := len(payload) - len(`"}`)
+	start := end - sigLen - len(signatureField)
+	if start < 1 || !strings.HasSuffix(payload, `"}`) || payload[start:start+len(signatureField)] != signatureField {
+		return payload, ""
+	}
+	signature := payload[end-sigLen : end]
+	switch payload[start-1] {
+	case ',':
+		return payload[:start-1] + "}", signature
+	case '{':
+		return "{}", signature
+	}
+	return payload, ""
+}
+
+// VerifyMessage checks the signature of a message received on channel.
+// Without a signing key nothing can be checked and every message passes.
+func VerifyMessage(channel, payload string) error {
+	signingMu.RLock()
+	key := signingKey
+	signingMu.RUnlock()
+	if len(key) == 0 {
+		return nil
+	}
+
+	data, signature := splitSignature(payload)
+	if signature == "" {
+		return ErrUnsigned
+	}
+	if !hmac.Equal([]byte(signature), []byte(messageMAC(key, channel, []byte(data)))) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+var (
+	signatureWarnedMu sync.Mutex
+	signatureWarned   = make(map[string]bool)
+)
+
+// ErrGuestUnsigned is returned for a message from a guest whose pass
+// predates guest signing keys; the pass has to be issued again.
+var ErrGuestUnsigned = errors.New("guest pass has no signing key; issue a new one with 'axle invite --guest'")
+
+// verifyGuestMessage checks the signature of a message a guest sent on
+// channel against the key in their pass.
+func verifyGuestMessage(guest GuestPass, channel, payload string) error {
+	if guest.SigningKey == "" {
+		return ErrGuestUnsigned
+	}
+	publicKey, err := hex.DecodeString(guest.SigningKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid signing key in the guest pass of %s", guest.Name)
+	}
+	data, signature := splitGuestSignature(payload)
+	if signature == "" {
+		return ErrUnsigned
+	}
+	raw, err := hex.DecodeString(signature)
+	if err != nil || !ed25519.Verify(publicKey, signedContent(channel, []byte(data)), raw) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// AdmitSigned decides whether a message from sender on channel is
+// processed. Forged signatures are always refused. A guest's messages have
+// to be signed with the key in their pass. Members' unsigned messages are
+// refused too, unless the team turned the requirement off for members
+// still on releases that don't sign.
+func AdmitSigned(cfg AppConfig, channel, sender, payload string) error {
+	if guest, ok := GuestPassFor(sender); ok {
+		err := verifyGuestMessage(guest, channel, payload)
+		if err != nil {
+			warnSignatureOnce(sender+"\x00"+err.Error(), fmt.Sprintf("Dropping messages claiming to be from guest %s on %s: %v", sender, channel, err))
+		}
+		return err
+	}
+	err := VerifyMessage(channel, payload)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrUnsigned) {
+		if !cfg.FeatureEnabled(FeatureSignedMessages) {
+			warnSignatureOnce(sender, fmt.Sprintf("%s sends unsigned messages; they should upgrade Axle", sender))
+			return nil
+		}
+	}
+	warnSignatureOnce(sender+"\x00"+err.Error(), fmt.Sprintf("Dropping messages claiming to be from %s on %s: %v", sender, channel, err))
+	return err
+}
+
+// replayWindow is how far a live message's timestamp may be from the local
+// clock. Within it, messages are told apart by their signature.
+const replayWindow = 10 * time.Minute
+
+var (
+	replayMu    sync.Mutex
+	replaySeen  = make(map[string]int64) // Channel and signature -> when it can be forgotten
+	replaySwept int64
+)
+
+// AdmitFresh refuses a live message from sender on channel that was sent
+// outside the replay window, or that already arrived, so a captured signed
+// message can't be published again. It is checked after AdmitSigned;
+// messages delivered again on purpose, such as by catch-up, don't go through it.
+func AdmitFresh(channel, sender, payload string, sentAt int64) error {
+	now := time.Now()
+	if skew := now.Sub(time.Unix(sentAt, 0)); skew > replayWindow || skew < -replayWindow {
+		err := fmt.Errorf("%w: sent at %s, outside the %v window", ErrReplayed, time.Unix(sentAt, 0).Format(time.RFC3339), replayWindow)
+		warnSignatureOnce(sender+"\x00stale", fmt.Sprintf("Dropping messages claiming to be from %s on %s: %v", sender, channel, err))
+		return err
+	}
+
+	_, signature := splitSignature(payload)
+	if signature == "" {
+		_, signature = splitGuestSignature(payload)
+	}
+	if signature == "" {
+		// Unsigned messages, while the team allows them, are told apart by content
+		sum := sha256.Sum256([]byte(payload))
+		signature = hex.EncodeToString(sum[:])
+	}
+	key := channel + "\x00" + signature
+
+	replayMu.Lock()
+	defer replayMu.Unlock()
+	if now.Unix()-replaySwept > int64(replayWindow/time.Second) {
+		for seen, expires := range replaySeen {
+			if expires < now.Unix() {
+				delete(replaySeen, seen)
+			}
+		}
+		replaySwept = now.Unix()
+	}
+	if _, ok := replaySeen[key]; ok {
+		warnSignatureOnce(sender+"\x00replayed", fmt.Sprintf("Dropping a message from %s on %s that was already received", sender, channel))
+		return ErrReplayed
+	}
+	// Kept until its timestamp falls out of the window
+	replaySeen[key] = sentAt + int64(replayWindow/time.Second)
+	return nil
+}
+
+// warnSignatureOnce logs a signature problem the first time it comes up.
+func warnSignatureOnce(key, message string) {
+	signatureWarnedMu.Lock()
+	defer signatureWarnedMu.Unlock()
+	if signatureWarned[key] {
+		return
+	}
+	signatureWarned[key] = true
+	log.Printf("[SECURITY] ⚠️  %s", message)
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAdmitSignedGuest(t *testing.T) {
+	pass, token, err := NewGuestPass("mentor", []string{"docs/**"}, time.Hour, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withGuest(t, pass)
+	t.Cleanup(func() { guestKey = nil })
+
+	cfg := AppConfig{TeamID: "team", Username: "alice"}
+	channel := TeamChannel("team")
+	data := []byte(`{"peer_id":"mentor","changes":[]}`)
+
+	if err := AdmitSigned(cfg, channel, "mentor", string(data)); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("unsigned message from a guest: got %v, want ErrUnsigned", err)
+	}
+
+	SetGuestSigningKey("mentor", "axg_wrong")
+	if err := AdmitSigned(cfg, channel, "mentor", string(SignMessage(channel, data))); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("message signed with the wrong token: got %v, want ErrBadSignature", err)
+	}
+
+	SetGuestSigningKey("mentor", token)
+	signed := string(SignMessage(channel, data))
+	if err := AdmitSigned(cfg, channel, "mentor", signed); err != nil {
+		t.Errorf("message signed with the guest's token was refused: %v", err)
+	}
+	if err := AdmitSigned(cfg, ChatChannel("team"), "mentor", signed); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("message replayed on another channel: got %v, want ErrBadSignature", err)
+	}
+
+	pass.SigningKey = ""
+	withGuest(t, pass)
+	if err := AdmitSigned(cfg, channel, "mentor", signed); !errors.Is(err, ErrGuestUnsigned) {
+		t.Errorf("pass without a signing key: got %v, want ErrGuestUnsigned", err)
+	}
+}
+
+func TestAdmitSignedMember(t *testing.T) {
+	SetSigningKey(DeriveSigningKey("team", "secret"))
+	t.Cleanup(func() { SetSigningKey(nil) })
+
+	cfg := AppConfig{TeamID: "team", Username: "alice"}
+	channel := ResyncChannel("team")
+	data := []byte(`{"type":"files","requester":"bob"}`)
+
+	if err := AdmitSigned(cfg, channel, "bob", string(SignMessage(channel, data))); err != nil {
+		t.Errorf("signed message was refused: %v", err)
+	}
+	if err := AdmitSigned(cfg, channel, "bob", string(data)); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("unsigned message: got %v, want ErrUnsigned", err)
+	}
+}
+
+func TestAdmitFreshRefusesReplays(t *testing.T) {
+	SetSigningKey(make([]byte, 32))
+	t.Cleanup(func() { SetSigningKey(nil) })
+
+	channel := ChatChannel("team")
+	now := time.Now().Unix()
+	signed := string(SignMessage(channel, []byte(`{"sender":"alice","message":"hi","timestamp":1}`)))
+
+	if err := AdmitFresh(channel, "alice", signed, now); err != nil {
+		t.Fatalf("first delivery was refused: %v", err)
+	}
+	if err := AdmitFresh(channel, "alice", signed, now); !errors.Is(err, ErrReplayed) {
+		t.Errorf("second delivery: got %v, want ErrReplayed", err)
+	}
+	if err := AdmitFresh(TeamChannel("team"), "alice", signed, now); err != nil {
+		t.Errorf("same signature on another channel was refused: %v", err)
+	}
+
+	other := string(SignMessage(channel, []byte(`{"sender":"alice","message":"hi","timestamp":2}`)))
+	if err := AdmitFresh(channel, "alice", other, now-int64(2*replayWindow/time.Second)); !errors.Is(err, ErrReplayed) {
+		t.Errorf("message outside the window: got %v, want ErrReplayed", err)
+	}
+}
+
+func TestVerifySnapshot(t *testing.T) {
+	SetSigningKey(make([]byte, 32))
+	t.Cleanup(func() { SetSigningKey(nil) })
+
+	cfg := AppConfig{TeamID: "team", Username: "alice"}
+	key := snapshotKey("team", "request")
+	bundle := []byte("bundle")
+	digest := string(SignMessage(key, []byte(`{"hash":"`+HashContent(bundle)+`"}`)))
+
+	if err := verifySnapshot(cfg, key, "bob", digest, bundle); err != nil {
+		t.Errorf("snapshot with a signed digest was refused: %v", err)
+	}
+	if err := verifySnapshot(cfg, key, "bob", digest, []byte("planted")); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("bundle that doesn't match the digest: got %v, want ErrBadSignature", err)
+	}
+	if err := verifySnapshot(cfg, snapshotKey("team", "other"), "bob", digest, bundle); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("digest of another request: got %v, want ErrBadSignature", err)
+	}
+}
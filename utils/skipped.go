@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SkippedFile is a file the watcher did not sync in full, because it is over
+// the size limit (and was shared as a placeholder) or looks binary.
+type SkippedFile struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	Reason    string `json:"reason"`
+	SkippedAt int64  `json:"skippedAt"`
+	ForcedAt  int64  `json:"forcedAt,omitempty"` // Last time it was pushed with 'axle force-sync'
+}
+
+var (
+	skippedMu sync.Mutex
+	// skippedPaths caches which paths are in the registry so the watcher only
+	// touches the file when something changes; nil until first loaded
+	skippedPaths map[string]bool
+)
+
+func skippedFile(rootDir string) string {
+	return AxlePath(rootDir, "skipped.json")
+}
+
+// SetMaxFileSize sets the size above which files are not synced in full.
+func SetMaxFileSize(bytes int64) {
+	if bytes > 0 {
+		maxFileSize = bytes
+	}
+}
+
+// MaxFileSize returns the size above which files are not synced in full.
+func MaxFileSize() int64 {
+	return maxFileSize
+}
+
+// LoadSkippedFiles reads the registry of files the watcher skipped.
+func LoadSkippedFiles(rootDir string) (map[string]SkippedFile, error) {
+	skipped := make(map[string]SkippedFile)
+	data, err := os.ReadFile(skippedFile(rootDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return skipped, nil
+		}
+		return nil, fmt.Errorf("failed to read skipped files: %w", err)
+	}
+	if err := json.Unmarshal(data, &skipped); err != nil {
+		return nil, fmt.Errorf("failed to parse skipped files: %w", err)
+	}
+	return skipped, nil
+}
+
+// ListSkippedFiles returns the skipped files sorted by path.
+func ListSkippedFiles(rootDir string) ([]SkippedFile, error) {
+	skipped, err := LoadSkippedFiles(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]SkippedFile, 0, len(skipped))
+	for _, file := range skipped {
+		list = append(list, file)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list, nil
+}
+
+// updateSkippedFiles applies an edit to the skipped file registry and saves
+// it. The caller must hold skippedMu.
+func updateSkippedFiles(rootDir string, edit func(map[string]SkippedFile)) error {
+	skipped, err := LoadSkippedFiles(rootDir)
+	if err != nil {
+		return err
+	}
+	edit(skipped)
+
+	skippedPaths = make(map[string]bool, len(skipped))
+	for path := range skipped {
+		skippedPaths[path] = true
+	}
+
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", AxleDirName, err)
+	}
+	data, err := json.MarshalIndent(skipped, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal skipped files: %w", err)
+	}
+	return os.WriteFile(skippedFile(rootDir), data, 0644)
+}
+
+// recordSkippedFile remembers that the watcher skipped a file.
+func recordSkippedFile(rootDir, relPath, reason string) {
+	var size int64
+	if info, err := os.Stat(filepath.Join(rootDir, relPath)); err == nil {
+		size = info.Size()
+	}
+
+	skippedMu.Lock()
+	defer skippedMu.Unlock()
+	err := updateSkippedFiles(rootDir, func(skipped map[string]SkippedFile) {
+		file := skipped[relPath]
+		file.Path = relPath
+		file.Size = size
+		file.Reason = reason
+		file.SkippedAt = time.Now().Unix()
+		skipped[relPath] = file
+	})
+	if err != nil {
+		log.Printf("[WATCHER] Failed to record skipped file %s: %v", relPath, err)
+	}
+}
+
+// forgetSkippedFile drops a file from the registry once it syncs normally or
+// is deleted.
+func forgetSkippedFile(rootDir, relPath string) {
+	skippedMu.Lock()
+	defer skippedMu.Unlock()
+
+	if skippedPaths == nil {
+		skipped, err := LoadSkippedFiles(rootDir)
+		if err != nil {
+			return
+		}
+		skippedPaths = make(map[string]bool, len(skipped))
+		for path := range skipped {
+			skippedPaths[path] = true
+		}
+	}
+	if !skippedPaths[relPath] {
+		return
+	}
+
+	err := updateSkippedFiles(rootDir, func(skipped map[string]SkippedFile) {
+		delete(skipped, relPath)
+	})
+	if err != nil {
+		log.Printf("[WATCHER] Failed to update skipped files: %v", err)
+	}
+}
+
+// ReevaluateSkippedFiles queues skipped files that are now within the limits,
+// because they shrank or the limit was raised while the daemon was stopped,
+// and forgets ones that were deleted.
+func ReevaluateSkippedFiles(cfg AppConfig) {
+	skipped, err := LoadSkippedFiles(cfg.RootDir)
+	if err != nil {
+		log.Printf("[WATCHER] Failed to load skipped files: %v", err)
+		return
+	}
+
+	for relPath := range skipped {
+		absPath := filepath.Join(cfg.RootDir, relPath)
+		if _, err := os.Stat(absPath); os.IsNotExist(err) {
+			forgetSkippedFile(cfg.RootDir, relPath)
+			continue
+		}
+		if queueBinaryChange(cfg, absPath, relPath, "modified") {
+			continue
+		}
+		if skip, _ := shouldSkipFile(absPath); skip {
+			continue
+		}
+		log.Printf("[WATCHER] %s is now within the sync limits; syncing it", relPath)
+		forgetSkippedFile(cfg.RootDir, relPath)
+		addToBatch(cfg, relPath, "modified")
+	}
+}
+
+// QueueForcedFile sends a file in full through the blob store regardless of
+// the size and binary checks, for 'axle force-sync'.
+func QueueForcedFile(ctx context.Context, cfg AppConfig, relPath string) (FileChange, error) {
+	if err := validatePatchPath(relPath); err != nil {
+		return FileChange{}, err
+	}
+	if shouldIgnore(cfg, filepath.Join(cfg.RootDir, relPath)) {
+		return FileChange{}, fmt.Errorf("%s is ignored and is never synced", relPath)
+	}
+
+	data, err := os.ReadFile(filepath.Join(cfg.RootDir, relPath))
+	if err != nil {
+		return FileChange{}, fmt.Errorf("cannot read %s: %w", relPath, err)
+	}
+	hash, store, err := StoreBlob(ctx, cfg, data)
+	if err != nil {
+		return FileChange{}, fmt.Errorf("failed to upload %s: %w", relPath, err)
+	}
+
+	change := FileChange{
+		File:    relPath,
+		Event:   "chunked",
+		Size:    int64(len(data)),
+		Hash:    hash,
+		Store:   store,
+		TraceID: GenerateTraceID(),
+		// Lets low-bandwidth peers fetch it from us later instead
+		Owner:     cfg.Username,
+		OwnerNode: cfg.NodeID,
+	}
+	mu.Lock()
+	queueChanges(cfg.RootDir, change)
+	mu.Unlock()
+	Events.Publish(TopicFileChanged, FileChangedEvent{Path: relPath, Event: change.Event, TraceID: change.TraceID})
+
+	skippedMu.Lock()
+	defer skippedMu.Unlock()
+	err = updateSkippedFiles(cfg.RootDir, func(skipped map[string]SkippedFile) {
+		if file, ok := skipped[relPath]; ok {
+			file.ForcedAt = time.Now().Unix()
+			skipped[relPath] = file
+		}
+	})
+	if err != nil {
+		log.Printf("[WATCHER] Failed to update skipped files: %v", err)
+	}
+
+	log.Printf("[SYNC] Force-syncing %s (%d bytes) through the blob store", relPath, len(data))
+	return change, nil
+}
+
+// ApplyChunkedChange writes a file a teammate sent through the blob store. Chunks
+// in Redis expire after BlobTTL, so old batches may fail to apply.
+func ApplyChunkedChange(ctx context.Context, cfg AppConfig, change FileChange) error {
+	if err := validatePatchPath(change.File); err != nil {
+		return err
+	}
+	data, err := LoadBlob(ctx, cfg, change)
+	if err != nil {
+		return err
+	}
+
+	fullPath := filepath.Join(cfg.RootDir, change.File)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", change.File, err)
+	}
+	if err := os.WriteFile(fullPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", change.File, err)
+	}
+	// The real file replaces any placeholder we had for it
+	return RemovePlaceholder(cfg.RootDir, change.File)
+}
@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// SnapshotTTL bounds how long a served snapshot stays in Redis
+	SnapshotTTL = 5 * time.Minute
+	// snapshotFallbackDelay gives the authoritative node a head start before
+	// other peers offer their own snapshot
+	snapshotFallbackDelay = 2 * time.Second
+)
+
+// SnapshotRequest asks online peers to publish the team's canonical repository state.
+type SnapshotRequest struct {
+	RequestID string `json:"requestID"`
+	Requester string `json:"requester"`      // Username of the requesting node
+	NodeID    string `json:"nodeID"`         // Node ID of the requesting node
+	From      string `json:"from,omitempty"` // Only this peer may serve it
+	Timestamp int64  `json:"timestamp"`
+}
+
+func snapshotKey(teamID, requestID string) string {
+	return fmt.Sprintf("axle:snapshot:%s:%s", teamID, requestID)
+}
+
+func snapshotOwnerKey(teamID, requestID string) string {
+	return fmt.Sprintf("axle:snapshot:%s:%s:owner", teamID, requestID)
+}
+
+func snapshotDigestKey(teamID, requestID string) string {
+	return fmt.Sprintf("axle:snapshot:%s:%s:digest", teamID, requestID)
+}
+
+// snapshotDigest is stored, signed by the peer that served it, next to a
+// snapshot, so whoever claims a request can't hand out a bundle of their own.
+type snapshotDigest struct {
+	Hash string `json:"hash"` // HashContent of the bundle
+}
+
+// CreateBundle packs the current HEAD history of the repository into a git bundle.
+func CreateBundle(directory string) ([]byte, error) {
+	if UsingEmbeddedBackend() {
+		return nil, ErrGitMissing
+	}
+	tmpPath, err := axleTempFile(directory, "snapshot-*.bundle")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary bundle file: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	cmd := GitCommand("-C", directory, "bundle", "create", tmpPath, "HEAD")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to create git bundle: %s", stderr.String())
+	}
+
+	return os.ReadFile(tmpPath)
+}
+
+// ApplyBundle replaces the working tree with the HEAD stored in a git bundle.
+// Ignored files (including the local Axle config) are left untouched.
+func ApplyBundle(directory string, bundle []byte) error {
+	if UsingEmbeddedBackend() {
+		return ErrGitMissing
+	}
+	tmpPath, err := axleTempFile(directory, "restore-*.bundle")
+	if err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := os.WriteFile(tmpPath, bundle, 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	steps := [][]string{
+		{"fetch", tmpPath, "HEAD"},
+		{"reset", "--hard", "FETCH_HEAD"},
+		{"clean", "-fd"},
+	}
+	for _, step := range steps {
+		cmd := GitCommand(append([]string{"-C", directory}, step...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s failed: %s", step[0], string(output))
+		}
+	}
+	return nil
+}
+
+// verifySnapshot checks that bundle is the one owner signed the digest of.
+// Peers on releases before digests send none, which passes only while the
+// team accepts unsigned messages.
+func verifySnapshot(cfg AppConfig, key, owner, digest string, bundle []byte) error {
+	if err := AdmitSigned(cfg, key, owner, digest); err != nil {
+		return err
+	}
+	if digest == "" {
+		return nil
+	}
+	var signed snapshotDigest
+	if err := json.Unmarshal([]byte(digest), &signed); err != nil || signed.Hash != HashContent(bundle) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// RequestSnapshot asks online peers for a snapshot and waits for one to be served.
+// When from is set only that peer may serve it. It returns the bundle and the
+// username of the peer that served it.
+func RequestSnapshot(ctx context.Context, cfg AppConfig, from string, timeout time.Duration) ([]byte, string, error) {
+	req := SnapshotRequest{
+		RequestID: GenerateNodeID(),
+		Requester: cfg.Username,
+		NodeID:    cfg.NodeID,
+		From:      from,
+		Timestamp: time.Now().Unix(),
+	}
+
+	if err := PublishMessage(ctx, cfg.Transport, SnapshotChannel(cfg.TeamID), req); err != nil {
+		return nil, "", fmt.Errorf("failed to request snapshot: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		data, err := cfg.RedisClient.Get(ctx, snapshotKey(cfg.TeamID, req.RequestID)).Bytes()
+		if err == nil {
+			owner, _ := cfg.RedisClient.Get(ctx, snapshotOwnerKey(cfg.TeamID, req.RequestID)).Result()
+			digest, _ := cfg.RedisClient.Get(ctx, snapshotDigestKey(cfg.TeamID, req.RequestID)).Result()
+			DeleteKeys(ctx, cfg.RedisClient, snapshotKey(cfg.TeamID, req.RequestID), snapshotOwnerKey(cfg.TeamID, req.RequestID), snapshotDigestKey(cfg.TeamID, req.RequestID))
+			// The bundle replaces the working tree; refuse a forged one
+			if err := verifySnapshot(cfg, snapshotKey(cfg.TeamID, req.RequestID), owner, digest, data); err != nil {
+				return nil, "", fmt.Errorf("refusing the snapshot from %s: %w", owner, err)
+			}
+			return data, owner, nil
+		}
+		if err != redis.Nil {
+			return nil, "", fmt.Errorf("failed to read snapshot: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+
+	if from != "" {
+		return nil, "", fmt.Errorf("%s didn't serve a snapshot within %v; make sure they are running 'axle start'", from, timeout)
+	}
+	return nil, "", fmt.Errorf("no peer served a snapshot within %v; make sure a teammate is running 'axle start'", timeout)
+}
+
+// ProcessSnapshotRequest serves a snapshot in response to a peer's request.
+// The authoritative node and a hub answer immediately; other peers wait briefly and
+// only answer if nobody else has claimed the request. A request for a
+// specific peer is answered by that peer alone.
+func ProcessSnapshotRequest(ctx context.Context, cfg AppConfig, payload string) {
+	var req SnapshotRequest
+	if err := json.Unmarshal([]byte(payload), &req); err != nil {
+		log.Printf("[SNAPSHOT] Error unmarshaling snapshot request: %v", err)
+		return
+	}
+
+	// Never answer our own request, or one meant for someone else
+	if req.NodeID == cfg.NodeID || (req.From != "" && req.From != cfg.Username) {
+		return
+	}
+	// Answering uploads the repository's history
+	if AdmitSigned(cfg, SnapshotChannel(cfg.TeamID), req.Requester, payload) != nil {
+		return
+	}
+	if AdmitFresh(SnapshotChannel(cfg.TeamID), req.Requester, payload, req.Timestamp) != nil {
+		return
+	}
+
+	go func() {
+		if req.From == "" && !cfg.Hub && cfg.AuthoritativeNode != "" && cfg.AuthoritativeNode != cfg.Username {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(snapshotFallbackDelay):
+			}
+		}
+
+		claimed, err := cfg.RedisClient.SetNX(ctx, snapshotOwnerKey(cfg.TeamID, req.RequestID), cfg.Username, SnapshotTTL).Result()
+		if err != nil || !claimed {
+			return
+		}
+
+		bundle, err := CreateBundle(cfg.RootDir)
+		if err != nil {
+			log.Printf("[SNAPSHOT] Failed to create snapshot for %s: %v", req.Requester, err)
+			cfg.RedisClient.Del(ctx, snapshotOwnerKey(cfg.TeamID, req.RequestID))
+			return
+		}
+
+		digest, err := json.Marshal(snapshotDigest{Hash: HashContent(bundle)})
+		if err != nil {
+			log.Printf("[SNAPSHOT] Failed to sign snapshot for %s: %v", req.Requester, err)
+			return
+		}
+		// The digest goes first; the requester reads it once the bundle is there
+		signed := SignMessage(snapshotKey(cfg.TeamID, req.RequestID), digest)
+		if err := cfg.RedisClient.Set(ctx, snapshotDigestKey(cfg.TeamID, req.RequestID), signed, SnapshotTTL).Err(); err != nil {
+			log.Printf("[SNAPSHOT] Failed to store snapshot for %s: %v", req.Requester, err)
+			return
+		}
+		if err := cfg.RedisClient.Set(ctx, snapshotKey(cfg.TeamID, req.RequestID), bundle, SnapshotTTL).Err(); err != nil {
+			log.Printf("[SNAPSHOT] Failed to store snapshot for %s: %v", req.Requester, err)
+			return
+		}
+		log.Printf("[SNAPSHOT] Served snapshot (%d bytes) to %s", len(bundle), req.Requester)
+	}()
+}
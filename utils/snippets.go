@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// DefaultSnippetTTL is how long a snippet lives unless the sender picks otherwise
+	DefaultSnippetTTL = 24 * time.Hour
+	// MaxSnippetTTL keeps the shared clipboard from becoming long-term storage
+	MaxSnippetTTL = 7 * 24 * time.Hour
+	// MaxSnippetSize caps a snippet; larger content belongs in the repo
+	MaxSnippetSize = 1024 * 1024
+)
+
+// ErrSnippetNotFound is returned for snippet IDs that never existed or have expired.
+var ErrSnippetNotFound = errors.New("snippet not found")
+
+// Snippet is a piece of text shared through the team clipboard.
+type Snippet struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name,omitempty"` // Usually the file it came from
+	Author    string `json:"author"`
+	Content   []byte `json:"content"`
+	Timestamp int64  `json:"timestamp"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+func snippetKey(teamID string, id int64) string {
+	return fmt.Sprintf("axle:team:%s:snip:%d", teamID, id)
+}
+
+// snippetIndexKey is a sorted set of snippet IDs scored by creation time.
+// Entries can outlive their snippet, which expires on its own.
+func snippetIndexKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:snips", teamID)
+}
+
+func snippetSeqKey(teamID string) string {
+	return fmt.Sprintf("axle:team:%s:snip_seq", teamID)
+}
+
+// PushSnippet shares content with the team for ttl.
+func PushSnippet(ctx context.Context, cfg AppConfig, name string, content []byte, ttl time.Duration) (Snippet, error) {
+	if len(content) == 0 {
+		return Snippet{}, fmt.Errorf("snippet is empty")
+	}
+	if len(content) > MaxSnippetSize {
+		return Snippet{}, fmt.Errorf("snippet is %d bytes; the limit is %d", len(content), MaxSnippetSize)
+	}
+	if ttl <= 0 || ttl > MaxSnippetTTL {
+		return Snippet{}, fmt.Errorf("snippet lifetime must be between 1s and %v", MaxSnippetTTL)
+	}
+
+	id, err := cfg.RedisClient.Incr(ctx, snippetSeqKey(cfg.TeamID)).Result()
+	if err != nil {
+		return Snippet{}, fmt.Errorf("failed to allocate snippet ID: %w", err)
+	}
+
+	now := time.Now()
+	snippet := Snippet{
+		ID:        id,
+		Name:      name,
+		Author:    cfg.Username,
+		Content:   content,
+		Timestamp: now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+	data, err := json.Marshal(snippet)
+	if err != nil {
+		return Snippet{}, err
+	}
+
+	pipe := cfg.RedisClient.TxPipeline()
+	pipe.Set(ctx, snippetKey(cfg.TeamID, id), data, ttl)
+	pipe.ZAdd(ctx, snippetIndexKey(cfg.TeamID), &redis.Z{Score: float64(snippet.Timestamp), Member: strconv.FormatInt(id, 10)})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Snippet{}, fmt.Errorf("failed to share snippet: %w", err)
+	}
+	return snippet, nil
+}
+
+// GetSnippet returns a snippet by ID, or the newest one when id is 0.
+func GetSnippet(ctx context.Context, cfg AppConfig, id int64) (Snippet, error) {
+	if id == 0 {
+		snippets, err := ListSnippets(ctx, cfg)
+		if err != nil {
+			return Snippet{}, err
+		}
+		if len(snippets) == 0 {
+			return Snippet{}, fmt.Errorf("no snippets have been shared (or they have all expired)")
+		}
+		return snippets[len(snippets)-1], nil
+	}
+
+	data, err := cfg.RedisClient.Get(ctx, snippetKey(cfg.TeamID, id)).Bytes()
+	if err == redis.Nil {
+		return Snippet{}, fmt.Errorf("%w: #%d may have expired", ErrSnippetNotFound, id)
+	} else if err != nil {
+		return Snippet{}, fmt.Errorf("failed to read snippet #%d: %w", id, err)
+	}
+
+	var snippet Snippet
+	if err := json.Unmarshal(data, &snippet); err != nil {
+		return Snippet{}, fmt.Errorf("corrupt snippet #%d: %w", id, err)
+	}
+	return snippet, nil
+}
+
+// ListSnippets returns the snippets that haven't expired, oldest first, and
+// drops expired ones from the index.
+func ListSnippets(ctx context.Context, cfg AppConfig) ([]Snippet, error) {
+	ids, err := cfg.RedisClient.ZRange(ctx, snippetIndexKey(cfg.TeamID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snippets: %w", err)
+	}
+
+	var snippets []Snippet
+	for _, member := range ids {
+		id, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		snippet, err := GetSnippet(ctx, cfg, id)
+		if errors.Is(err, ErrSnippetNotFound) {
+			cfg.RedisClient.ZRem(ctx, snippetIndexKey(cfg.TeamID), member)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		snippets = append(snippets, snippet)
+	}
+	return snippets, nil
+}
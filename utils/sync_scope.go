@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"log"
+	"strings"
+)
+
+// InSyncScope reports whether this member syncs relPath: it matches one of
+// the include patterns, when there are any, and none of the exclude
+// patterns. A directory is in scope when an include pattern reaches into it,
+// so "frontend/**" covers "frontend" itself.
+func InSyncScope(cfg AppConfig, relPath string) bool {
+	if MatchAnyGlob(cfg.SyncExclude, relPath) {
+		return false
+	}
+	if len(cfg.SyncInclude) == 0 {
+		return true
+	}
+	return MatchAnyGlob(cfg.SyncInclude, relPath) || MatchAnyGlob(cfg.SyncInclude, relPath+"/")
+}
+
+// FilterSyncScope removes changes outside this member's sync scope from a
+// batch, and the sections for such files from the patches that are left. A
+// commit's patch travels on one of its changes, so when that change is
+// dropped the patch moves to the next change of the same commit.
+func FilterSyncScope(cfg AppConfig, changes []FileChange) []FileChange {
+	if len(cfg.SyncInclude) == 0 && len(cfg.SyncExclude) == 0 {
+		return changes
+	}
+	inScope := func(path string) bool { return InSyncScope(cfg, path) }
+
+	filtered := make([]FileChange, 0, len(changes))
+	orphaned := make(map[string]FileChange) // Dropped changes carrying their commit's patch
+	var dropped int
+	for _, change := range changes {
+		if change.Patch != "" {
+			if err := DecodeChange(&change); err != nil {
+				log.Printf("[SCOPE] Dropping %s: %v", change.File, err)
+				dropped++
+				continue
+			}
+			change.Patch = filterPatchFiles(change.Patch, inScope)
+		}
+		if !inScope(change.File) {
+			if change.Patch != "" && change.CommitHash != "" {
+				if _, ok := orphaned[change.CommitHash]; !ok {
+					orphaned[change.CommitHash] = change
+				}
+			}
+			dropped++
+			continue
+		}
+		filtered = append(filtered, change)
+	}
+
+	for i := range filtered {
+		change := &filtered[i]
+		if carrier, ok := orphaned[change.CommitHash]; ok && change.CommitHash != "" {
+			if change.Patch == "" {
+				change.Patch = carrier.Patch
+			}
+			delete(orphaned, change.CommitHash)
+		}
+	}
+
+	if dropped > 0 {
+		log.Printf("[SCOPE] Skipped %d change(s) outside this member's sync scope", dropped)
+	}
+	return filtered
+}
+
+// filterPatchFiles keeps the sections of a patch whose file keep accepts.
+// It returns "" when no section is left.
+func filterPatchFiles(patch string, keep func(path string) bool) string {
+	return filterPatchSections(patch, func(lines []string) bool {
+		return keep(sectionPath(lines))
+	})
+}
+
+// filterPatchSections keeps the sections of a patch keep accepts, given
+// their lines. It returns "" when no section is left.
+func filterPatchSections(patch string, keep func(lines []string) bool) string {
+	lines := strings.SplitAfter(patch, "\n")
+	var out, section strings.Builder
+	var sectionLines []string
+	var kept bool
+	flush := func() {
+		if sectionLines != nil && keep(sectionLines) {
+			out.WriteString(section.String())
+			kept = true
+		}
+		section.Reset()
+		sectionLines = nil
+	}
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			sectionLines = []string{}
+		case line == "-- \n" && sectionLines != nil:
+			// format-patch signature after the last file
+			flush()
+		}
+		if sectionLines == nil {
+			out.WriteString(line)
+			continue
+		}
+		sectionLines = append(sectionLines, line)
+		section.WriteString(line)
+	}
+	flush()
+
+	if !kept {
+		return ""
+	}
+	return out.String()
+}
+
+// sectionPath returns the file a patch section leaves behind: the new path,
+// or the old one for a deletion.
+func sectionPath(lines []string) string {
+	var oldPath, newPath string
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "GIT binary patch"):
+			return firstNonEmpty(newPath, oldPath, diffGitPath(lines[0]))
+		case strings.HasPrefix(line, "+++ "):
+			newPath = patchPath(line[4:], "b/")
+		case strings.HasPrefix(line, "--- "):
+			oldPath = patchPath(line[4:], "a/")
+		case strings.HasPrefix(line, "rename to "):
+			newPath = unquotePatchPath(strings.TrimPrefix(line, "rename to "))
+		}
+	}
+	return firstNonEmpty(newPath, oldPath, diffGitPath(lines[0]))
+}
+
+// sectionPaths returns every file a patch section touches: the old and new
+// paths, which differ for a rename or copy.
+func sectionPaths(lines []string) []string {
+	paths := []string{sectionPath(lines)}
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r\n")
+		var path string
+		switch {
+		case strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "GIT binary patch"):
+			return paths
+		case strings.HasPrefix(line, "--- "):
+			path = patchPath(line[4:], "a/")
+		case strings.HasPrefix(line, "rename from "):
+			path = unquotePatchPath(strings.TrimPrefix(line, "rename from "))
+		}
+		if path != "" && !contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// diffGitPath reads the new path from a "diff --git a/x b/x" line.
+func diffGitPath(line string) string {
+	_, b, _ := splitDiffGitPaths(strings.TrimRight(strings.TrimPrefix(line, "diff --git "), "\r\n"))
+	return b
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
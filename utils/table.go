@@ -34,6 +34,7 @@ var (
 
 // RenderPresenceTable creates a beautiful table showing team member presence
 func RenderPresenceTable(presenceList []PresenceInfo) string {
+	defer ProfileSpan(ProfileRender, "RenderPresenceTable")()
 	if len(presenceList) == 0 {
 		noDataStyle := lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241")).
@@ -47,6 +48,19 @@ func RenderPresenceTable(presenceList []PresenceInfo) string {
 	// Define headers
 	headers := []string{"Username", "Status", "Last Seen", "IP Address", "Node ID"}
 
+	// Only show the latency and note columns when someone has data for them
+	hasLatency, hasNotes := false, false
+	for _, info := range presenceList {
+		hasLatency = hasLatency || info.LatencyMs > 0
+		hasNotes = hasNotes || info.Note != ""
+	}
+	if hasLatency {
+		headers = append(headers, "Latency")
+	}
+	if hasNotes {
+		headers = append(headers, "Note")
+	}
+
 	// Calculate column widths
 	colWidths := make([]int, len(headers))
 	for i, header := range headers {
@@ -59,13 +73,25 @@ func RenderPresenceTable(presenceList []PresenceInfo) string {
 		statusStr := formatStatus(info.Status)
 		lastSeenStr := formatLastSeen(info.LastSeen)
 
+		username := info.Username
+		if info.Guest {
+			username += " (guest)"
+		} else if info.Spectator {
+			username += " (spectator)"
+		}
 		row := []string{
-			info.Username,
+			username,
 			statusStr,
 			lastSeenStr,
 			info.IPAddress,
 			truncateNodeID(info.NodeID),
 		}
+		if hasLatency {
+			row = append(row, formatLatency(info))
+		}
+		if hasNotes {
+			row = append(row, info.Note)
+		}
 		rows = append(rows, row)
 
 		// Update column widths
@@ -138,6 +164,21 @@ func formatLastSeen(timestamp int64) string {
 	}
 }
 
+// formatLatency formats a member's Redis round-trip time and region
+func formatLatency(info PresenceInfo) string {
+	if info.LatencyMs <= 0 {
+		return "-"
+	}
+	latency := fmt.Sprintf("%.1fms", info.LatencyMs)
+	if info.Region != "" {
+		latency += " (" + info.Region + ")"
+	}
+	if info.LowBandwidth {
+		latency += " 🐢"
+	}
+	return latency
+}
+
 // truncateNodeID shortens the node ID for display
 func truncateNodeID(nodeID string) string {
 	if len(nodeID) > 12 {
@@ -148,6 +189,7 @@ func truncateNodeID(nodeID string) string {
 
 // RenderTitle renders a styled title for the CLI
 func RenderTitle(title string) string {
+	defer ProfileSpan(ProfileRender, "RenderTitle")()
 	titleStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("86")). // Cyan
 		Bold(true).
@@ -159,6 +201,7 @@ func RenderTitle(title string) string {
 
 // RenderSuccess renders a success message
 func RenderSuccess(message string) string {
+	defer ProfileSpan(ProfileRender, "RenderSuccess")()
 	successStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("82")). // Green
 		Bold(true)
@@ -168,6 +211,7 @@ func RenderSuccess(message string) string {
 
 // RenderError renders an error message
 func RenderError(message string) string {
+	defer ProfileSpan(ProfileRender, "RenderError")()
 	errorStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("196")). // Red
 		Bold(true)
@@ -177,6 +221,7 @@ func RenderError(message string) string {
 
 // RenderWarning renders a warning message
 func RenderWarning(message string) string {
+	defer ProfileSpan(ProfileRender, "RenderWarning")()
 	warningStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("214")). // Orange
 		Bold(true)
@@ -186,6 +231,7 @@ func RenderWarning(message string) string {
 
 // RenderInfo renders an info message
 func RenderInfo(message string) string {
+	defer ProfileSpan(ProfileRender, "RenderInfo")()
 	infoStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("39")). // Blue
 		Bold(true)
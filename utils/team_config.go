@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// TeamConfigKey returns the Redis key holding the team configuration.
+func TeamConfigKey(teamID string) string {
+	return fmt.Sprintf("axle:config:%s", teamID)
+}
+
+// readTeamConfig returns the stored team config, from the transport for
+// teams that don't use Redis.
+func readTeamConfig(ctx context.Context, rdb redis.UniversalClient, teamID string) ([]byte, error) {
+	if store := currentValueStore(); store != nil {
+		return store.getValue(ctx, TeamConfigKey(teamID))
+	}
+	return rdb.Get(ctx, TeamConfigKey(teamID)).Bytes()
+}
+
+// GetTeamConfig fetches and decodes the team configuration from Redis
+// without checking its signature. The result is for display only and can't
+// be saved; commands that change the config use GetVerifiedTeamConfig.
+func GetTeamConfig(ctx context.Context, rdb redis.UniversalClient, teamID string) (AxleConfig, error) {
+	data, err := readTeamConfig(ctx, rdb, teamID)
+	if err != nil {
+		return AxleConfig{}, fmt.Errorf("failed to fetch team config: %w", err)
+	}
+
+	var teamConfig AxleConfig
+	if err := json.Unmarshal(data, &teamConfig); err != nil {
+		return AxleConfig{}, fmt.Errorf("failed to unmarshal team config: %w", err)
+	}
+	return teamConfig, nil
+}
+
+// CreateTeamConfig stores the config of a team that is being created.
+func CreateTeamConfig(ctx context.Context, rdb redis.UniversalClient, teamConfig AxleConfig) error {
+	teamConfig.verified = true
+	return SaveTeamConfig(ctx, rdb, teamConfig)
+}
+
+// SaveTeamConfig encodes the team configuration and stores it in Redis.
+// Configs with an admin key are re-signed, which requires the admin's
+// private key on this machine. Only configs read through
+// GetVerifiedTeamConfig are accepted, so changes someone made directly in
+// Redis are never signed along with the admin's edit.
+func SaveTeamConfig(ctx context.Context, rdb redis.UniversalClient, teamConfig AxleConfig) error {
+	if !teamConfig.verified {
+		return ErrUnverifiedTeamConfig
+	}
+
+	var data []byte
+	var err error
+	if teamConfig.AdminPublicKey != "" {
+		key, keyErr := LoadAdminKey(teamConfig.TeamID)
+		if keyErr != nil {
+			if errors.Is(keyErr, ErrNotTeamAdmin) {
+				return fmt.Errorf("%w; ask %s to make this change", ErrNotTeamAdmin, teamConfig.Admin)
+			}
+			return keyErr
+		}
+		data, err = signTeamConfig(teamConfig, key)
+	} else {
+		data, err = json.Marshal(teamConfig)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal team config to JSON: %w", err)
+	}
+
+	if store := currentValueStore(); store != nil {
+		err = store.setValue(ctx, TeamConfigKey(teamConfig.TeamID), data)
+	} else {
+		err = rdb.Set(ctx, TeamConfigKey(teamConfig.TeamID), data, 0).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save team config: %w", err)
+	}
+	return nil
+}
+
+// EffectivePriority returns the tie-break order used by the 'auto' conflict
+// strategy. The authoritative node, if any, always ranks first.
+func (c AxleConfig) EffectivePriority() []string {
+	if c.AuthoritativeNode == "" {
+		return c.PeerPriority
+	}
+
+	priority := []string{c.AuthoritativeNode}
+	for _, peer := range c.PeerPriority {
+		if peer != c.AuthoritativeNode {
+			priority = append(priority, peer)
+		}
+	}
+	return priority
+}
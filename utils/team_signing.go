@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const teamConfigGuardInterval = 30 * time.Second
+
+var (
+	// ErrNotTeamAdmin is returned when a signed team config is changed on a
+	// machine that doesn't hold the team admin's signing key
+	ErrNotTeamAdmin = errors.New("only the team admin can change team settings")
+	// ErrTeamConfigTampered is returned when the team config in Redis fails verification
+	ErrTeamConfigTampered = errors.New("team config failed signature verification")
+	// ErrUnverifiedTeamConfig is returned when saving a team config that wasn't
+	// read through GetVerifiedTeamConfig, which would sign whatever is in Redis
+	ErrUnverifiedTeamConfig = errors.New("refusing to save a team config that wasn't verified")
+)
+
+// AdminKeyPath returns where the team admin's private signing key is stored.
+// It lives in the user's config directory so it survives 'axle leave --purge'.
+func AdminKeyPath(teamID string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user config directory: %w", err)
+	}
+	return filepath.Join(configDir, "axle", "keys", teamID+".key"), nil
+}
+
+// GenerateAdminKey creates and stores a new signing key for the team and
+// returns its base64-encoded public key.
+func GenerateAdminKey(teamID string) (string, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate admin key: %w", err)
+	}
+
+	keyPath, err := AdminKeyPath(teamID)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		return "", fmt.Errorf("failed to create key directory: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(privateKey)
+	if err := os.WriteFile(keyPath, []byte(encoded), 0600); err != nil {
+		return "", fmt.Errorf("failed to write admin key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(publicKey), nil
+}
+
+// LoadAdminKey loads the team admin's private signing key, if this machine has it.
+func LoadAdminKey(teamID string) (ed25519.PrivateKey, error) {
+	keyPath, err := AdminKeyPath(teamID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, ErrNotTeamAdmin
+	}
+	key, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil || len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("admin key %s is corrupt", keyPath)
+	}
+	return ed25519.PrivateKey(key), nil
+}
+
+// teamConfigSigningPayload returns the canonical bytes covered by the
+// signature: the stored JSON without its signature field, with keys sorted.
+// Working from the raw JSON keeps fields unknown to this version covered.
+func teamConfigSigningPayload(raw []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse team config: %w", err)
+	}
+	delete(fields, "signature")
+	return json.Marshal(fields)
+}
+
+// signTeamConfig returns the team config JSON with a signature by key.
+func signTeamConfig(teamConfig AxleConfig, key ed25519.PrivateKey) ([]byte, error) {
+	teamConfig.Signature = ""
+	unsigned, err := json.Marshal(teamConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal team config to JSON: %w", err)
+	}
+	payload, err := teamConfigSigningPayload(unsigned)
+	if err != nil {
+		return nil, err
+	}
+
+	teamConfig.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+	return json.Marshal(teamConfig)
+}
+
+// VerifyTeamConfig checks the stored team config JSON against the admin
+// public key this member pinned when joining. Teams created before signing
+// existed have no key and no signature; they verify only while nothing is pinned.
+func VerifyTeamConfig(raw []byte, pinnedKey string) (AxleConfig, error) {
+	var teamConfig AxleConfig
+	if err := json.Unmarshal(raw, &teamConfig); err != nil {
+		return AxleConfig{}, fmt.Errorf("failed to unmarshal team config: %w", err)
+	}
+
+	if teamConfig.AdminPublicKey == "" && teamConfig.Signature == "" {
+		if pinnedKey != "" {
+			return teamConfig, fmt.Errorf("%w: the signature was removed", ErrTeamConfigTampered)
+		}
+		teamConfig.verified = true
+		return teamConfig, nil
+	}
+	if pinnedKey != "" && teamConfig.AdminPublicKey != pinnedKey {
+		return teamConfig, fmt.Errorf("%w: the admin key was replaced", ErrTeamConfigTampered)
+	}
+
+	publicKey, err := base64.StdEncoding.DecodeString(teamConfig.AdminPublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return teamConfig, fmt.Errorf("%w: invalid admin key", ErrTeamConfigTampered)
+	}
+	signature, err := base64.StdEncoding.DecodeString(teamConfig.Signature)
+	if err != nil {
+		return teamConfig, fmt.Errorf("%w: invalid signature", ErrTeamConfigTampered)
+	}
+	payload, err := teamConfigSigningPayload(raw)
+	if err != nil {
+		return teamConfig, err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(publicKey), payload, signature) {
+		return teamConfig, fmt.Errorf("%w: the contents don't match the admin's signature", ErrTeamConfigTampered)
+	}
+	teamConfig.verified = true
+	return teamConfig, nil
+}
+
+// GetVerifiedTeamConfig fetches the team config and verifies it against the pinned admin key.
+func GetVerifiedTeamConfig(ctx context.Context, rdb redis.UniversalClient, teamID, pinnedKey string) (AxleConfig, error) {
+	raw, err := readTeamConfig(ctx, rdb, teamID)
+	if err != nil {
+		return AxleConfig{}, fmt.Errorf("failed to fetch team config: %w", err)
+	}
+	return VerifyTeamConfig(raw, pinnedKey)
+}
+
+// GetTeamConfigForUpdate reads the team config for a command that changes
+// it. A config that fails verification is refused rather than re-signed, so
+// the admin never signs changes someone made directly in Redis.
+func GetTeamConfigForUpdate(ctx context.Context, cfg AppConfig) (AxleConfig, error) {
+	teamConfig, err := GetVerifiedTeamConfig(ctx, cfg.RedisClient, cfg.TeamID, cfg.TeamAdminKey)
+	if errors.Is(err, ErrTeamConfigTampered) {
+		return AxleConfig{}, fmt.Errorf("%w. Someone with Redis access changed it; refusing to sign their changes", err)
+	}
+	return teamConfig, err
+}
+
+// StartTeamConfigGuard watches the team config until the context is
+// cancelled and alerts this member when it changes without a valid signature.
+func StartTeamConfigGuard(ctx context.Context, cfg AppConfig) {
+	lastSeen, _ := readTeamConfig(ctx, cfg.RedisClient, cfg.TeamID)
+
+	ticker := time.NewTicker(teamConfigGuardInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			raw, err := readTeamConfig(ctx, cfg.RedisClient, cfg.TeamID)
+			if err != nil {
+				if err == redis.Nil {
+					log.Printf("[CONFIG] ⚠️  The team config was deleted from Redis")
+					SendNotification("Axle - Team config deleted", "The team configuration was removed from Redis")
+				}
+				continue
+			}
+			if string(raw) == string(lastSeen) {
+				continue
+			}
+			lastSeen = raw
+
+			teamConfig, err := VerifyTeamConfig(raw, cfg.TeamAdminKey)
+			if err != nil {
+				log.Printf("[CONFIG] ⚠️  Unexpected team config change: %v", err)
+				SendNotification("Axle - Team config tampered",
+					"The team configuration changed without the admin's signature. Check who has access to Redis.")
+				continue
+			}
+			log.Printf("[CONFIG] Team config updated by %s", teamConfig.Admin)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
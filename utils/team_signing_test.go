@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestSaveTeamConfigRefusesUnverified(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned := base64.StdEncoding.EncodeToString(publicKey)
+
+	raw, err := signTeamConfig(AxleConfig{TeamID: "team", AdminPublicKey: pinned}, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	teamConfig, err := VerifyTeamConfig(raw, pinned)
+	if err != nil {
+		t.Fatalf("signed config was refused: %v", err)
+	}
+	if !teamConfig.verified {
+		t.Error("verified config is not marked as verified")
+	}
+
+	// Someone with Redis access edits the config without the admin's key
+	tampered, err := signTeamConfig(AxleConfig{TeamID: "team", AdminPublicKey: pinned, Kicked: []string{"alice"}}, privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered = append(tampered[:len(tampered)-1], []byte(`,"roles":{"mallory":"member"}}`)...)
+	teamConfig, err = VerifyTeamConfig(tampered, pinned)
+	if !errors.Is(err, ErrTeamConfigTampered) {
+		t.Fatalf("tampered config: got %v, want ErrTeamConfigTampered", err)
+	}
+	if err := SaveTeamConfig(context.Background(), nil, teamConfig); !errors.Is(err, ErrUnverifiedTeamConfig) {
+		t.Errorf("saving a tampered config: got %v, want ErrUnverifiedTeamConfig", err)
+	}
+	if err := SaveTeamConfig(context.Background(), nil, AxleConfig{TeamID: "team"}); !errors.Is(err, ErrUnverifiedTeamConfig) {
+		t.Errorf("saving a config that was never verified: got %v, want ErrUnverifiedTeamConfig", err)
+	}
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// teamCheckInterval is how often running daemons refresh the team settings.
+const teamCheckInterval = 30 * time.Second
+
+// StartTeamWatcher keeps a running daemon in step with the team settings.
+// Every teamCheckInterval it refreshes the guest passes, roles, removed
+// members and feature toggles, and the admin's daemon revokes expired guest
+// passes. It calls stop once this node may no longer run: its member was
+// kicked, its guest pass expired, or the team password changed.
+func StartTeamWatcher(ctx context.Context, cfg AppConfig, stop func()) {
+	ticker := time.NewTicker(teamCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if removedFromTeam(cfg) || guestPassExpired(cfg) {
+			stop()
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case changedBy := <-passwordChanged:
+			stopForPassword(cfg, changedBy)
+			stop()
+			return
+		case <-ticker.C:
+		}
+
+		teamConfig, err := GetVerifiedTeamConfig(ctx, cfg.RedisClient, cfg.TeamID, cfg.TeamAdminKey)
+		if err != nil {
+			continue
+		}
+		// The announcement of a password change can be missed while offline
+		if passwordOutdated(teamConfig.PasswordHash) {
+			stopForPassword(cfg, "the team admin")
+			stop()
+			return
+		}
+		refreshTeamSettings(ctx, cfg, teamConfig)
+	}
+}
+
+// refreshTeamSettings applies the team settings a running daemon follows.
+func refreshTeamSettings(ctx context.Context, cfg AppConfig, teamConfig AxleConfig) {
+	SetGuests(teamConfig.Guests)
+	SetRoles(teamConfig.Roles)
+	SetKicked(teamConfig.Kicked)
+	refreshFeatures(cfg, teamConfig)
+	revokeExpiredGuests(ctx, cfg, teamConfig)
+}
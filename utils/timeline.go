@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxConflictLogEntries bounds the conflict log; older conflicts drop off
+const maxConflictLogEntries = 500
+
+// Kinds of timeline entries
+const (
+	TimelineSent     = "sent"     // A local change committed here and published
+	TimelineReceived = "received" // A peer's change applied here
+	TimelineConflict = "conflict" // An incoming change that conflicted
+)
+
+// ConflictRecord is one conflict as the apply path resolved it.
+type ConflictRecord struct {
+	Time    int64    `json:"time"`
+	Peer    string   `json:"peer,omitempty"` // Whose change conflicted, when known
+	Files   []string `json:"files"`
+	Outcome string   `json:"outcome"`
+}
+
+// TimelineEntry is a commit or conflict on the sync timeline.
+type TimelineEntry struct {
+	Time    time.Time // When it happened here, by the local clock
+	SentAt  time.Time // For received commits, when the peer made it, by their clock
+	Kind    string
+	Peer    string
+	Files   []string
+	Subject string // Commit subject or conflict outcome
+	Commit  string
+}
+
+// TimelineFilter narrows SyncTimeline. Zero values match everything.
+type TimelineFilter struct {
+	Since  time.Time
+	Author string // Substring of the peer name, case-insensitive
+	File   string // A path or directory relative to the sync root
+	Limit  int
+}
+
+var conflictLogMu sync.Mutex
+
+func conflictLogFile(rootDir string) string {
+	return AxlePath(rootDir, "conflicts.log")
+}
+
+// recordConflict counts a conflict for the stats and adds it to the conflict
+// log 'axle history --timeline' reads.
+func recordConflict(rootDir, peer, outcome string, files []string) {
+	countConflict(files...)
+
+	record := ConflictRecord{Time: time.Now().Unix(), Peer: peer, Outcome: outcome}
+	for _, file := range files {
+		record.Files = append(record.Files, filepath.ToSlash(strings.TrimSuffix(file, ".rej")))
+	}
+
+	conflictLogMu.Lock()
+	defer conflictLogMu.Unlock()
+	records, err := loadConflictLog(rootDir)
+	if err != nil {
+		log.Printf("[CONFLICT] %v", err)
+	}
+	records = append(records, record)
+	if len(records) > maxConflictLogEntries {
+		records = records[len(records)-maxConflictLogEntries:]
+	}
+	if err := saveConflictLog(rootDir, records); err != nil {
+		log.Printf("[CONFLICT] Failed to record conflict: %v", err)
+	}
+}
+
+// loadConflictLog reads the conflict log, oldest first. Unreadable lines are
+// skipped.
+func loadConflictLog(rootDir string) ([]ConflictRecord, error) {
+	file, err := os.Open(conflictLogFile(rootDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read conflict log: %w", err)
+	}
+	defer file.Close()
+
+	var records []ConflictRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record ConflictRecord
+		if json.Unmarshal(scanner.Bytes(), &record) == nil {
+			records = append(records, record)
+		}
+	}
+	return records, scanner.Err()
+}
+
+func saveConflictLog(rootDir string, records []ConflictRecord) error {
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", AxleDirName, err)
+	}
+	var buf strings.Builder
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return os.WriteFile(conflictLogFile(rootDir), []byte(buf.String()), 0644)
+}
+
+// SyncTimeline returns the sync commits and conflicts in rootDir, newest
+// first. A commit whose author isn't its committer came in through 'git am'
+// or a sync commit on a peer's behalf, so it counts as received from the
+// author; the rest were sent from here. Entries are ordered by the local
+// commit time, so a peer's skewed clock can't move their changes around.
+func SyncTimeline(rootDir string, filter TimelineFilter) ([]TimelineEntry, error) {
+	args := []string{"-C", rootDir, "log", "--no-merges", "--name-only", "--format=%x1e%H%x1f%ct%x1f%an%x1f%cn%x1f%s%x1f%at"}
+	if !filter.Since.IsZero() {
+		args = append(args, fmt.Sprintf("--since=@%d", filter.Since.Unix()))
+	}
+	if filter.Limit > 0 && filter.Author == "" {
+		args = append(args, fmt.Sprintf("-n%d", filter.Limit))
+	}
+	if filter.File != "" {
+		args = append(args, "--full-diff", "--", filter.File)
+	}
+	output, err := GitCommand(args...).Output()
+	if err != nil {
+		// A repository without commits has no history yet
+		if _, headErr := GitCommand("-C", rootDir, "rev-parse", "--verify", "HEAD").Output(); headErr != nil {
+			output = nil
+		} else {
+			return nil, fmt.Errorf("failed to read git history: %w", err)
+		}
+	}
+
+	var entries []TimelineEntry
+	for _, record := range strings.Split(string(output), "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.Split(lines[0], "\x1f")
+		if len(fields) != 6 {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[1], 10, 64)
+		authored, _ := strconv.ParseInt(fields[5], 10, 64)
+		entry := TimelineEntry{
+			Time:    time.Unix(unix, 0),
+			Kind:    TimelineSent,
+			Peer:    fields[2],
+			Subject: fields[4],
+			Commit:  fields[0],
+		}
+		if fields[2] != fields[3] {
+			entry.Kind = TimelineReceived
+			entry.SentAt = time.Unix(authored, 0)
+		}
+		for _, line := range lines[1:] {
+			if line = strings.TrimSpace(line); line != "" {
+				entry.Files = append(entry.Files, line)
+			}
+		}
+		if filter.Author == "" || containsFold(entry.Peer, filter.Author) {
+			entries = append(entries, entry)
+		}
+	}
+
+	conflicts, err := loadConflictLog(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, conflict := range conflicts {
+		entry := TimelineEntry{
+			Time:    time.Unix(conflict.Time, 0),
+			Kind:    TimelineConflict,
+			Peer:    conflict.Peer,
+			Files:   conflict.Files,
+			Subject: conflict.Outcome,
+		}
+		if entry.Time.Before(filter.Since) ||
+			(filter.Author != "" && !containsFold(entry.Peer, filter.Author)) ||
+			(filter.File != "" && !touchesPath(entry.Files, filter.File)) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+	}
+	return entries, nil
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// touchesPath reports whether any of files is target or lies under it.
+func touchesPath(files []string, target string) bool {
+	target = path.Clean(filepath.ToSlash(target))
+	for _, file := range files {
+		if file == target || strings.HasPrefix(file, target+"/") || target == "." {
+			return true
+		}
+	}
+	return false
+}
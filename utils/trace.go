@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// Trace stages, in the order a change normally passes through them
+const (
+	TraceCaptured  = "captured"  // Watcher accepted the file event
+	TraceCommitted = "committed" // Change was committed locally
+	TracePublished = "published" // Batch carrying the change was published
+	TraceApplied   = "applied"   // A peer applied the change
+	TraceFailed    = "failed"    // A peer failed to apply the change
+)
+
+// TraceStep is one recorded stage in a change's journey.
+type TraceStep struct {
+	Stage       string `json:"stage"`
+	Peer        string `json:"peer"`
+	NodeID      string `json:"nodeID"`
+	File        string `json:"file,omitempty"`
+	CommitHash  string `json:"commitHash,omitempty"`
+	BatchID     string `json:"batchID,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+	TimestampMs int64  `json:"timestampMs"`
+}
+
+// GenerateTraceID creates the correlation ID assigned to a change when it is captured.
+func GenerateTraceID() string {
+	bytes := make([]byte, 6)
+	if _, err := rand.Read(bytes); err != nil {
+		return fmt.Sprintf("tr_%d", time.Now().UnixNano())
+	}
+	return "tr_" + hex.EncodeToString(bytes)
+}
+
+func traceKey(teamID, traceID string) string {
+	return fmt.Sprintf("axle:trace:%s:%s", teamID, traceID)
+}
+
+// recordTraceStep logs a step and appends it to the trace's shared timeline.
+func recordTraceStep(ctx context.Context, cfg AppConfig, traceID string, step TraceStep) {
+	if traceID == "" {
+		return // Change from an older client without correlation IDs
+	}
+	step.Peer = cfg.Username
+	step.NodeID = cfg.NodeID
+	step.TimestampMs = time.Now().UnixMilli()
+	log.Printf("[TRACE %s] %s %s", traceID, step.Stage, step.File)
+
+	data, err := json.Marshal(step)
+	if err != nil {
+		return
+	}
+	key := traceKey(cfg.TeamID, traceID)
+	pipe := cfg.RedisClient.TxPipeline()
+	pipe.RPush(ctx, key, data)
+	pipe.Expire(ctx, key, ackRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[TRACE] Failed to record %s step for %s: %v", step.Stage, traceID, err)
+	}
+}
+
+// StartTraceRecorder records every stage a change passes through on this node
+// so 'axle trace' can reconstruct its journey across the team. It runs only
+// in trace mode ('axle start --trace').
+func StartTraceRecorder(ctx context.Context, cfg AppConfig) {
+	events, unsubscribe := Events.Subscribe(TopicFileChanged, TopicBatchCommitted, TopicBatchPublished, TopicBatchApplied, TopicApplyFailed)
+	defer unsubscribe()
+
+	for {
+		select {
+		case event := <-events:
+			switch payload := event.Payload.(type) {
+			case FileChangedEvent:
+				recordTraceStep(ctx, cfg, payload.TraceID, TraceStep{Stage: TraceCaptured, File: payload.Path, Detail: payload.Event})
+			case BatchCommittedEvent:
+				for i, traceID := range payload.TraceIDs {
+					recordTraceStep(ctx, cfg, traceID, TraceStep{Stage: TraceCommitted, File: payload.Files[i], CommitHash: payload.CommitHash})
+				}
+			case BatchPublishedEvent:
+				for _, change := range payload.Metadata.Changes {
+					recordTraceStep(ctx, cfg, change.TraceID, TraceStep{Stage: TracePublished, File: change.File, CommitHash: change.CommitHash, BatchID: payload.Metadata.BatchID})
+				}
+			case BatchAppliedEvent:
+				for i, traceID := range payload.TraceIDs {
+					recordTraceStep(ctx, cfg, traceID, TraceStep{Stage: TraceApplied, File: payload.Files[i], BatchID: payload.BatchID, Detail: "from " + payload.PeerID})
+				}
+			case ApplyFailedEvent:
+				recordTraceStep(ctx, cfg, payload.TraceID, TraceStep{Stage: TraceFailed, File: payload.File, BatchID: payload.BatchID, Detail: fmt.Sprint(payload.Err)})
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// GetTraceSteps returns the recorded steps of a trace, oldest first.
+func GetTraceSteps(ctx context.Context, cfg AppConfig, traceID string) ([]TraceStep, error) {
+	entries, err := cfg.RedisClient.LRange(ctx, traceKey(cfg.TeamID, traceID), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trace %s: %w", traceID, err)
+	}
+
+	steps := make([]TraceStep, 0, len(entries))
+	for _, entry := range entries {
+		var step TraceStep
+		if err := json.Unmarshal([]byte(entry), &step); err == nil {
+			steps = append(steps, step)
+		}
+	}
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].TimestampMs < steps[j].TimestampMs })
+	return steps, nil
+}
+
+// FindTracedBatch searches the team's published batch records for the batch
+// that carried traceID, so its journey can be reconstructed from the ledger
+// and ACKs even when trace mode was off.
+func FindTracedBatch(ctx context.Context, cfg AppConfig, traceID string) (BatchRecord, bool, error) {
+	members, err := GetMembers(ctx, cfg.RedisClient, cfg.TeamID)
+	if err != nil {
+		return BatchRecord{}, false, err
+	}
+	for _, member := range members {
+		records, err := ListSentBatches(ctx, cfg, member, maxSentBatches)
+		if err != nil {
+			return BatchRecord{}, false, err
+		}
+		for _, record := range records {
+			if contains(record.TraceIDs, traceID) {
+				return record, true, nil
+			}
+		}
+	}
+	return BatchRecord{}, false, nil
+}
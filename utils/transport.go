@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Transports the team's messages can go over, as set by "transport" in
+// axle_config.json
+const (
+	TransportRedis = "redis"
+	TransportNATS  = "nats"
+)
+
+// TransportMessage is one message received on a subscription.
+type TransportMessage struct {
+	Channel string
+	Pattern string // Pattern the channel matched, for pattern subscriptions
+	Payload string
+}
+
+// Transport carries the team's messages: batches, chat, presence, and the
+// requests daemons send each other. Redis pub/sub is the default; teams
+// without Redis can use NATS.
+type Transport interface {
+	Name() string
+	Publish(ctx context.Context, channel string, payload []byte) error
+	Subscribe(ctx context.Context, channels ...string) (Subscription, error)
+	Close() error
+}
+
+// Subscription delivers the messages published on the channels subscribed
+// to until it is closed.
+type Subscription interface {
+	Channel() <-chan TransportMessage
+	Close() error
+}
+
+// PatternSubscriber is a subscription that can also subscribe to channel
+// patterns, such as the keyspace notifications of Redis.
+type PatternSubscriber interface {
+	PSubscribe(ctx context.Context, patterns ...string) error
+}
+
+// ErrNoRedis is what features that keep state in Redis fail with when the
+// team syncs over NATS.
+var ErrNoRedis = errors.New("this needs Redis, and the team syncs over NATS")
+
+// NewTransport connects to the transport named by name: NATS at natsURL, or
+// Redis through rdb, which the caller keeps and closes.
+func NewTransport(name, natsURL string, rdb redis.UniversalClient) (Transport, error) {
+	switch name {
+	case "", TransportRedis:
+		return NewRedisTransport(rdb), nil
+	case TransportNATS:
+		return NewNATSTransport(natsURL)
+	}
+	return nil, fmt.Errorf("unknown transport %q (use: %s or %s)", name, TransportRedis, TransportNATS)
+}
+
+// NewNoRedisClient returns a Redis client whose every command fails with
+// ErrNoRedis at once, for teams on NATS: what keeps state in Redis reports
+// itself unavailable rather than trying to connect.
+func NewNoRedisClient() redis.UniversalClient {
+	return redis.NewClient(&redis.Options{
+		Addr:       "no-redis:0",
+		MaxRetries: -1,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, ErrNoRedis
+		},
+	})
+}
+
+// valueStore keeps the few records a transport without Redis still has to
+// share, such as the team config.
+type valueStore interface {
+	getValue(ctx context.Context, key string) ([]byte, error)
+	setValue(ctx context.Context, key string, value []byte) error
+}
+
+var (
+	activeTransportMu sync.RWMutex
+	activeTransport   Transport
+)
+
+// SetTransport registers the transport the team uses, so the team config is
+// read from and written to it when it isn't Redis.
+func SetTransport(t Transport) {
+	activeTransportMu.Lock()
+	defer activeTransportMu.Unlock()
+	activeTransport = t
+}
+
+// currentValueStore returns the transport's value store, nil when the team
+// config lives in Redis.
+func currentValueStore() valueStore {
+	activeTransportMu.RLock()
+	defer activeTransportMu.RUnlock()
+	store, _ := activeTransport.(valueStore)
+	return store
+}
+
+// redisTransport carries messages over Redis pub/sub.
+type redisTransport struct {
+	rdb redis.UniversalClient
+}
+
+// NewRedisTransport returns a transport over rdb. Closing the transport
+// leaves rdb open; it's shared with everything that keeps state in Redis.
+func NewRedisTransport(rdb redis.UniversalClient) Transport {
+	return &redisTransport{rdb: rdb}
+}
+
+func (t *redisTransport) Name() string {
+	return TransportRedis
+}
+
+func (t *redisTransport) Publish(ctx context.Context, channel string, payload []byte) error {
+	return t.rdb.Publish(ctx, channel, payload).Err()
+}
+
+func (t *redisTransport) Subscribe(ctx context.Context, channels ...string) (Subscription, error) {
+	pubsub := t.rdb.Subscribe(ctx, channels...)
+	if _, err := pubsub.Receive(ctx); err != nil { // Blocks until subscription is confirmed or error occurs
+		pubsub.Close()
+		return nil, err
+	}
+	return &redisSubscription{pubsub: pubsub, done: make(chan struct{})}, nil
+}
+
+func (t *redisTransport) Close() error {
+	return nil
+}
+
+// redisSubscription adapts a Redis PubSub. go-redis reconnects and
+// resubscribes it by itself when the connection drops.
+type redisSubscription struct {
+	pubsub    *redis.PubSub
+	once      sync.Once
+	ch        chan TransportMessage
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *redisSubscription) Channel() <-chan TransportMessage {
+	s.once.Do(func() {
+		s.ch = make(chan TransportMessage)
+		go func() {
+			defer close(s.ch)
+			for msg := range s.pubsub.Channel() {
+				select {
+				case s.ch <- TransportMessage{Channel: msg.Channel, Pattern: msg.Pattern, Payload: msg.Payload}:
+				case <-s.done:
+					return
+				}
+			}
+		}()
+	})
+	return s.ch
+}
+
+func (s *redisSubscription) PSubscribe(ctx context.Context, patterns ...string) error {
+	return s.pubsub.PSubscribe(ctx, patterns...)
+}
+
+func (s *redisSubscription) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	return s.pubsub.Close()
+}
+
+// HasRedis reports whether the team keeps its shared state in Redis, which
+// teams on NATS go without.
+func (c AppConfig) HasRedis() bool {
+	return c.Transport == nil || c.Transport.Name() == TransportRedis
+}
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+const (
+	// tunnelMaxBackoff caps the wait between SSH reconnection attempts
+	tunnelMaxBackoff = 20 * time.Second
+	// tunnelStableAfter is how long an SSH session must last for the next
+	// failure to start over from a short backoff
+	tunnelStableAfter = time.Minute
+)
+
+// TunnelState describes a running 'axle tunnel', written to .axle/tunnel.json
+// so other axle commands send their Redis traffic through it.
+type TunnelState struct {
+	PID        int    `json:"pid"`
+	Target     string `json:"target"`     // user@host the tunnel goes through
+	RemoteAddr string `json:"remoteAddr"` // Redis address as seen from the SSH host
+	LocalAddr  string `json:"localAddr"`  // Local end of the forward
+	Connected  bool   `json:"connected"`
+	Restarts   int    `json:"restarts"`
+	UpdatedAt  int64  `json:"updatedAt"`
+}
+
+// TunnelOptions configures 'axle tunnel'.
+type TunnelOptions struct {
+	Target     string // user@host
+	RemoteAddr string // Redis address as seen from the SSH host
+	LocalPort  int    // 0 picks a free port
+	SSHPort    int    // 0 for ssh's default
+	Identity   string // Private key file, "" for ssh's default
+}
+
+func tunnelStateFile(rootDir string) string {
+	return AxlePath(rootDir, "tunnel.json")
+}
+
+// ReadTunnelState loads the state of the running tunnel.
+func ReadTunnelState(rootDir string) (TunnelState, error) {
+	var state TunnelState
+	data, err := os.ReadFile(tunnelStateFile(rootDir))
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to parse tunnel state: %w", err)
+	}
+	return state, nil
+}
+
+// IsRunning reports whether the tunnel refreshed its state recently enough
+// to be considered alive.
+func (s TunnelState) IsRunning() bool {
+	return s.UpdatedAt > 0 && time.Since(time.Unix(s.UpdatedAt, 0)) < DaemonStateStaleAfter
+}
+
+// sshArgs builds the ssh command line for the forward.
+func (o TunnelOptions) sshArgs(localAddr string) []string {
+	args := []string{
+		"-N",
+		"-L", localAddr + ":" + o.RemoteAddr,
+		// Exit instead of running without the forward, and notice a dead
+		// link within a minute so the supervisor can reconnect
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "ServerAliveInterval=15",
+		"-o", "ServerAliveCountMax=3",
+	}
+	if o.SSHPort > 0 {
+		args = append(args, "-p", strconv.Itoa(o.SSHPort))
+	}
+	if o.Identity != "" {
+		args = append(args, "-i", o.Identity)
+	}
+	return append(args, o.Target)
+}
+
+// RunTunnel keeps an SSH port forward to the team's Redis up until ctx is
+// cancelled, restarting ssh with backoff whenever it exits. While it runs,
+// axle commands in rootDir connect to Redis through the forward.
+func RunTunnel(ctx context.Context, rootDir string, opts TunnelOptions) error {
+	sshPath, err := exec.LookPath("ssh")
+	if err != nil {
+		return fmt.Errorf("ssh was not found on PATH; install an OpenSSH client first")
+	}
+	if existing, err := ReadTunnelState(rootDir); err == nil && existing.IsRunning() && existing.PID != os.Getpid() {
+		return fmt.Errorf("a tunnel to %s is already running (PID %d)", existing.Target, existing.PID)
+	}
+
+	port := opts.LocalPort
+	if port == 0 {
+		if port, err = FreeLocalPort(); err != nil {
+			return err
+		}
+	}
+	state := TunnelState{
+		PID:        os.Getpid(),
+		Target:     opts.Target,
+		RemoteAddr: opts.RemoteAddr,
+		LocalAddr:  net.JoinHostPort("127.0.0.1", strconv.Itoa(port)),
+	}
+	if err := os.MkdirAll(AxlePath(rootDir), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", AxleDirName, err)
+	}
+	defer os.Remove(tunnelStateFile(rootDir))
+
+	write := func() {
+		state.UpdatedAt = time.Now().Unix()
+		state.Connected = localPortOpen(state.LocalAddr)
+		data, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return
+		}
+		if err := os.WriteFile(tunnelStateFile(rootDir), data, 0644); err != nil {
+			log.Printf("[TUNNEL] Failed to write tunnel state: %v", err)
+		}
+	}
+
+	backoff := time.Second
+	for {
+		started := time.Now()
+		cmd := exec.CommandContext(ctx, sshPath, opts.sshArgs(state.LocalAddr)...)
+		// Let ssh ask for a password or passphrase on the terminal
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("failed to run ssh: %w", err)
+		}
+		log.Printf("[TUNNEL] Forwarding %s to %s through %s", state.LocalAddr, opts.RemoteAddr, opts.Target)
+
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+
+		wasConnected := false
+		ticker := time.NewTicker(time.Second)
+	supervise:
+		for {
+			select {
+			case err := <-done:
+				ticker.Stop()
+				if ctx.Err() != nil {
+					return nil
+				}
+				write()
+				if time.Since(started) > tunnelStableAfter {
+					backoff = time.Second
+				}
+				log.Printf("[TUNNEL] ssh exited (%v); reconnecting in %v", err, backoff)
+				break supervise
+			case <-ticker.C:
+				write()
+				if state.Connected && !wasConnected {
+					log.Printf("[TUNNEL] Connected; axle commands here now reach Redis at %s", opts.RemoteAddr)
+				}
+				wasConnected = state.Connected
+				// Checking every second only matters while the forward comes up
+				if state.Connected {
+					ticker.Reset(stateReportInterval)
+				}
+			case <-ctx.Done():
+				ticker.Stop()
+				<-done
+				return nil
+			}
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil
+		}
+		backoff = min(backoff*2, tunnelMaxBackoff)
+		state.Restarts++
+	}
+}
+
+// FreeLocalPort picks a local port nothing is listening on.
+func FreeLocalPort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("failed to find a free local port: %w", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// localPortOpen reports whether something accepts connections on addr.
+func localPortOpen(addr string) bool {
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
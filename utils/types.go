@@ -1,49 +1,140 @@
 // utils/types.go
 package utils
 
-import "github.com/go-redis/redis/v8"
+import (
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
 
 // ChatMessage represents a single chat message sent between Axle users.
 type ChatMessage struct {
-	Sender    string `json:"sender"`    // Username of the sender
-	Message   string `json:"message"`   // The chat message content
-	Timestamp int64  `json:"timestamp"` // Unix timestamp of when the message was sent
-	Priority  bool   `json:"priority"`  // If true, triggers desktop notification
+	Sender    string `json:"sender"`           // Username of the sender
+	Message   string `json:"message"`          // The chat message content
+	Timestamp int64  `json:"timestamp"`        // Unix timestamp of when the message was sent
+	Priority  bool   `json:"priority"`         // If true, triggers desktop notification
+	Action    bool   `json:"action,omitempty"` // Sent with /me; shown as "* sender message"
+	Seq       int64  `json:"seq,omitempty"`    // Team-wide sequence number, for ordering independent of clocks
+	// When the message arrived here, by the local clock; set by the receiver
+	ReceivedAt int64 `json:"receivedAt,omitempty"`
 }
 
 // AxleConfig defines the structure for configuration stored in Redis.
 // Note: RedisClient is NOT part of this struct as it's a runtime connection.
 type AxleConfig struct {
-	TeamID       string `json:"teamID"`
-	PasswordHash string `json:"passwordHash"`
+	TeamID       string   `json:"teamID"`
+	PasswordHash string   `json:"passwordHash"`
+	PeerPriority []string `json:"peerPriority,omitempty"` // Usernames in conflict-winning order for the 'auto' strategy
+	// Username whose version always wins automatic conflict resolution and
+	// who serves as the source for snapshots and repairs
+	AuthoritativeNode string `json:"authoritativeNode,omitempty"`
+	// Heartbeat interval for all members; the presence timeout is 2× this value
+	HeartbeatIntervalSeconds int `json:"heartbeatIntervalSeconds,omitempty"`
+	// Path globs whose changes need explicit confirmation before syncing
+	ProtectedPaths []string `json:"protectedPaths,omitempty"`
+	// Durably store every batch in a Redis Stream so offline members can catch up
+	PersistBatches bool `json:"persistBatches,omitempty"`
+	RetentionDays  int  `json:"retentionDays,omitempty"`
+	// Minutes between the stats summaries each daemon broadcasts, 0 for off
+	StatsIntervalMinutes int `json:"statsIntervalMinutes,omitempty"`
+	// Feature toggles such as "sync.deletes"; features not listed are on
+	Features map[string]bool `json:"features,omitempty"`
+	// Feature -> members it is turned on for while it is rolled out; it is
+	// off for everyone else, whatever Features says
+	FeatureRollout map[string][]string `json:"featureRollout,omitempty"`
+	// Time-limited passes for guests, who join with a token instead of the password
+	Guests []GuestPass `json:"guests,omitempty"`
+	// Size limits for chat and sync messages, enforced by senders and receivers
+	Limits MessageLimits `json:"limits,omitempty"`
+	// Username -> role, such as "spectator"; members not listed are full members
+	Roles map[string]string `json:"roles,omitempty"`
+	// Usernames removed with 'axle team kick'; their messages are ignored
+	Kicked []string `json:"kicked,omitempty"`
+	// Founding admin and the public key that signs this config
+	Admin          string `json:"admin,omitempty"`
+	AdminPublicKey string `json:"adminPublicKey,omitempty"`
+	Signature      string `json:"signature,omitempty"`
+
+	// Set when the config passed signature verification or was just created
+	// by its admin; SaveTeamConfig only signs configs that have it
+	verified bool
 }
 
 // PresenceInfo represents information about a team member's presence
 type PresenceInfo struct {
-	Username  string `json:"username"`
-	Status    string `json:"status"`    // "online" or "offline"
-	LastSeen  int64  `json:"lastSeen"`  // Unix timestamp
-	IPAddress string `json:"ipAddress"` // IP address of the node
-	NodeID    string `json:"nodeID"`    // Unique identifier for this node instance
+	Username  string  `json:"username"`
+	Status    string  `json:"status"`              // "online" or "offline"
+	LastSeen  int64   `json:"lastSeen"`            // Unix timestamp
+	IPAddress string  `json:"ipAddress"`           // IP address of the node
+	NodeID    string  `json:"nodeID"`              // Unique identifier for this node instance
+	Note      string  `json:"note,omitempty"`      // Custom status set with /status in chat
+	LatencyMs float64 `json:"latencyMs,omitempty"` // Round-trip time to Redis from the member's last heartbeat
+	Region    string  `json:"region,omitempty"`    // Region of the Redis endpoint the member is using
+	// Member asked for compressed patches and on-demand large files
+	LowBandwidth bool `json:"lowBandwidth,omitempty"`
+	// Member joined with a guest pass
+	Guest bool `json:"guest,omitempty"`
+	// Port the member serves large changes on, at IPAddress; 0 for none
+	DirectPort int `json:"directPort,omitempty"`
+	// Member receives changes but never publishes any
+	Spectator bool `json:"spectator,omitempty"`
 }
 
 // PresenceMessage represents presence-related messages
 type PresenceMessage struct {
-	Type      string `json:"type"`      // "heartbeat", "announce", "goodbye"
-	NodeID    string `json:"nodeID"`    // Unique identifier for this node
-	Username  string `json:"username"`  // Username of the sender
-	IPAddress string `json:"ipAddress"` // IP address
-	Timestamp int64  `json:"timestamp"` // Unix timestamp
+	Type      string  `json:"type"`                // "heartbeat", "announce", "goodbye"; "expired" is local, from Redis
+	NodeID    string  `json:"nodeID"`              // Unique identifier for this node
+	Username  string  `json:"username"`            // Username of the sender
+	IPAddress string  `json:"ipAddress"`           // IP address
+	Timestamp int64   `json:"timestamp"`           // Unix timestamp
+	LatencyMs float64 `json:"latencyMs,omitempty"` // Sender's round-trip time to Redis
+	Region    string  `json:"region,omitempty"`    // Region of the sender's Redis endpoint
+	// Sender is on a slow link and wants lean payloads
+	LowBandwidth bool `json:"lowBandwidth,omitempty"`
+	// Sender joined with a guest pass
+	Guest bool `json:"guest,omitempty"`
+	// Port the sender serves large changes on, at IPAddress; 0 for none
+	DirectPort int `json:"directPort,omitempty"`
+	// Sync protocol version and capabilities of the sender's release;
+	// releases before negotiation send neither
+	Protocol     int      `json:"protocol,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	// Sender is a spectator
+	Spectator bool `json:"spectator,omitempty"`
 }
 
 // AppConfig holds the application's runtime configuration.
 type AppConfig struct {
-	TeamID           string
-	Username         string
-	RootDir          string
-	RedisAddr        string
-	RedisClient      *redis.Client
-	IgnorePatterns   []string
-	NodeID           string           // Unique identifier for this node instance
-	ConflictStrategy ConflictStrategy // Strategy for handling merge conflicts
+	TeamID            string
+	Username          string
+	RootDir           string
+	RedisAddr         string
+	RedisEndpoints    []RedisEndpoint // Redis servers in priority order; RedisAddr is the first
+	RedisClient       redis.UniversalClient
+	Transport         Transport // Carries the team's messages; Redis pub/sub or NATS
+	IgnorePatterns    []string
+	NodeID            string           // Unique identifier for this node instance
+	ConflictStrategy  ConflictStrategy // Strategy for handling merge conflicts
+	PeerPriority      []string         // Team-wide tie-break order, loaded from the team config
+	AuthoritativeNode string           // Username of the team's authoritative node, if any
+	LowPower          string           // Low-power setting: "off", "on", or "auto"
+	LowBandwidth      string           // Low-bandwidth setting: "off", "on", or "auto" (latency-based)
+	MaxProcs          int              // CPU parallelism cap, 0 for no limit
+	MemoryLimitMB     int              // Soft memory limit in MB, 0 for no limit
+	HeartbeatInterval time.Duration    // Team-wide heartbeat interval, 0 for the default
+	ProtectedPaths    []string         // Path globs that need confirmation before syncing
+	CacheQuotaMB      int              // Cap for evictable caches under .axle, 0 for the default
+	MinFreeDiskMB     int              // Warn when free disk space drops below this, 0 for the default
+	MaxFileSizeMB     int              // Files above this are shared as placeholders, 0 for the default
+	BatchWindow       time.Duration    // Fixed batch window, 0 to adapt it to activity
+	TeamAdminKey      string           // Admin public key pinned at join, used to verify the team config
+	PersistBatches    bool             // Whether published batches are stored for 'axle catchup'
+	RetentionDays     int              // How long persisted batches are kept, 0 for the default
+	Trace             bool             // Record every change's journey for 'axle trace'
+	ScanCommand       string           // Scanner run on incoming content before it is applied, "" for none
+	Hub               bool             // Headless always-on node that serves the team
+	SyncInclude       []string         // Path globs this member syncs, nil for everything
+	SyncExclude       []string         // Path globs this member never syncs
+	HealthInterval    time.Duration    // How often a stats summary is broadcast to the team, 0 for never
+	BuildCommand      string           // Build or test command run after incoming changes settle, "" for none
 }
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log"
@@ -17,21 +18,16 @@ import (
 var (
 	changes         []FileChange
 	mu              sync.Mutex
-	lastEventTime   = make(map[string]time.Time) // Generic debounce map
-	eventTimeMutex  sync.RWMutex                  // Mutex for lastEventTime map
 	muApplyingPatch sync.Mutex
 	isApplyingPatch bool
 	// Batching variables
 	pendingFiles  = make(map[string]string) // file path -> event type
+	pendingTraces = make(map[string]string) // file path -> correlation ID
 	batchTimer    *time.Timer
 	batchMutex    sync.Mutex
 	batchDuration = 5 * time.Second // Wait 5 seconds to accumulate changes (increased for testing)
 	// File size limits
 	maxFileSize int64 = 10 * 1024 * 1024 // 10MB default, can be configured
-	// Dynamic batching
-	recentEventCount int                    // Track recent events for dynamic batching
-	lastEventReset   = time.Now()           // When we last reset the event counter
-	dynamicBatchMux  sync.RWMutex           // Mutex for dynamic batch variables
 )
 
 // SetIsApplyingPatch sets the state of the patch application flag.
@@ -48,34 +44,6 @@ func getIsApplyingPatch() bool {
 	return isApplyingPatch
 }
 
-// debounceEvent prevents duplicate events within the debounce window
-func debounceEvent(eventMap map[string]time.Time, key string, debounceTime time.Duration) bool {
-	eventTimeMutex.Lock()
-	defer eventTimeMutex.Unlock()
-
-	now := time.Now()
-	if lastTime, exists := eventMap[key]; exists {
-		if now.Sub(lastTime) < debounceTime {
-			return false // Skip duplicate event
-		}
-	}
-	eventMap[key] = now
-	return true // Accept event
-}
-
-// cleanupOldEventTimes removes entries older than 5 minutes from lastEventTime map
-func cleanupOldEventTimes() {
-	eventTimeMutex.Lock()
-	defer eventTimeMutex.Unlock()
-
-	cutoff := time.Now().Add(-5 * time.Minute)
-	for key, timestamp := range lastEventTime {
-		if timestamp.Before(cutoff) {
-			delete(lastEventTime, key)
-		}
-	}
-}
-
 // isIgnored checks if a path should be ignored.
 func isIgnored(path string, ignorePatterns []string) bool {
 	fileName := filepath.Base(path)
@@ -85,6 +53,16 @@ func isIgnored(path string, ignorePatterns []string) bool {
 		return true
 	}
 
+	// Always ignore Axle's own state directory
+	if isAxlePath(path) {
+		return true
+	}
+
+	// Conflict artifacts are local; syncing them spreads them to everyone
+	if strings.HasSuffix(fileName, ".rej") {
+		return true
+	}
+
 	// Ignore temporary/swap files
 	if strings.HasSuffix(fileName, ".tmp") || strings.HasSuffix(fileName, ".swp") || strings.HasSuffix(fileName, "~") {
 		return true
@@ -139,6 +117,7 @@ func isBinaryFile(path string) bool {
 		".db", ".sqlite", ".mdb",
 		".bin", ".dat", ".iso",
 		".pyc", ".pyo", ".class",
+		".ttf", ".otf", ".woff", ".woff2", ".eot", ".webp",
 	}
 
 	ext := strings.ToLower(filepath.Ext(path))
@@ -148,7 +127,15 @@ func isBinaryFile(path string) bool {
 		}
 	}
 
-	return false
+	// Like git, treat anything with a NUL byte near the start as binary
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	head := make([]byte, 8000)
+	n, _ := file.Read(head)
+	return bytes.IndexByte(head[:n], 0) >= 0
 }
 
 // processBatch processes accumulated file changes and commits them as a batch
@@ -164,6 +151,21 @@ func processBatchInternal(cfg AppConfig) {
 		return
 	}
 
+	// Empty directories never make it into a commit; send them as they are
+	if queueEmptyDirectories(cfg); len(pendingFiles) == 0 {
+		batchTimer = nil
+		return
+	}
+
+	// Without git there is nothing to commit or diff; send whole files
+	if UsingEmbeddedBackend() {
+		queueSnapshotBatch(cfg)
+		pendingFiles = make(map[string]string)
+		pendingTraces = make(map[string]string)
+		batchTimer = nil
+		return
+	}
+
 	// Create commit message based on changes
 	var commitMessage string
 	if len(pendingFiles) == 1 {
@@ -180,6 +182,7 @@ func processBatchInternal(cfg AppConfig) {
 	if err != nil {
 		log.Printf("Error committing batched changes: %v", err)
 		pendingFiles = make(map[string]string) // Clear pending files even on error
+		pendingTraces = make(map[string]string)
 		return
 	}
 	
@@ -187,64 +190,59 @@ func processBatchInternal(cfg AppConfig) {
 	if commitHash == "" {
 		log.Printf("[BATCH] No changes to commit for batch (working tree was already clean)")
 		pendingFiles = make(map[string]string)
+		pendingTraces = make(map[string]string)
 		return
 	}
 
 	if commitHash != "" {
-		// Generate patch for the commit
-		patch, err := GetPatch(cfg.RootDir, commitHash)
+		// Renames and mode changes alone need no patch
+		described, metadataOnly, err := describeCommit(cfg, commitHash)
 		if err != nil {
-			log.Printf("Error getting patch for batched commit: %v", err)
-		} else {
-			// Create file changes for all files in the batch
+			log.Printf("Error describing batched commit: %v", err)
+		}
+
+		var patch string
+		if err == nil && !metadataOnly {
+			if patch, err = GetPatch(cfg.RootDir, commitHash); err != nil {
+				log.Printf("Error getting patch for batched commit: %v", err)
+			}
+		}
+		if err == nil {
+			// Create file changes for all files in the commit
+			committedFiles := make([]string, 0, len(described))
+			traceIDs := make([]string, 0, len(described))
 			mu.Lock()
-			for path, event := range pendingFiles {
-				changes = append(changes, FileChange{
-					File:       path,
-					Event:      event,
-					CommitHash: commitHash,
-					Patch:      patch,
-				})
+			for _, change := range described {
+				change.Patch = patch
+				change.TraceID = pendingTraces[change.File]
+				if change.TraceID == "" {
+					change.TraceID = pendingTraces[change.From]
+				}
+				if change.TraceID == "" {
+					change.TraceID = GenerateTraceID()
+				}
+				queueChanges(cfg.RootDir, change)
+				committedFiles = append(committedFiles, change.File)
+				traceIDs = append(traceIDs, change.TraceID)
 			}
 			mu.Unlock()
+			Events.Publish(TopicBatchCommitted, BatchCommittedEvent{CommitHash: commitHash, Files: committedFiles, TraceIDs: traceIDs})
 		}
 	}
 
 	// Clear pending files
 	pendingFiles = make(map[string]string)
+	pendingTraces = make(map[string]string)
 
 	// Reset timer
 	batchTimer = nil
 }
 
-// getDynamicBatchDuration calculates batch duration based on recent activity
-func getDynamicBatchDuration() time.Duration {
-	dynamicBatchMux.Lock()
-	defer dynamicBatchMux.Unlock()
-
-	// Reset counter every minute
-	if time.Since(lastEventReset) > time.Minute {
-		recentEventCount = 0
-		lastEventReset = time.Now()
-	}
-
-	recentEventCount++
-
-	// Calculate events per second in the last minute
-	eventsPerSecond := float64(recentEventCount) / time.Since(lastEventReset).Seconds()
-
-	// Adjust batch duration based on activity
-	// High activity (>5 events/sec): wait longer (5 seconds) to batch more
-	// Medium activity (1-5 events/sec): normal wait (2 seconds)
-	// Low activity (<1 event/sec): quick response (1 second)
-	switch {
-	case eventsPerSecond > 5:
-		return 5 * time.Second
-	case eventsPerSecond > 1:
-		return 2 * time.Second
-	default:
-		return 1 * time.Second
-	}
+// pendingBatchSize returns how many files wait in the current batch.
+func pendingBatchSize() int {
+	batchMutex.Lock()
+	defer batchMutex.Unlock()
+	return len(pendingFiles)
 }
 
 // addToBatch adds a file change to the pending batch and starts/resets the timer
@@ -254,6 +252,11 @@ func addToBatch(cfg AppConfig, filePath, eventType string) {
 
 	// Add to pending files (this will overwrite if the same file has multiple events)
 	pendingFiles[filePath] = eventType
+	// Later events for a file fold into the same change and keep its correlation ID
+	if _, ok := pendingTraces[filePath]; !ok {
+		pendingTraces[filePath] = GenerateTraceID()
+	}
+	Events.Publish(TopicFileChanged, FileChangedEvent{Path: filePath, Event: eventType, TraceID: pendingTraces[filePath]})
 
 	// Calculate dynamic batch duration
 	dynamicDuration := getDynamicBatchDuration()
@@ -300,9 +303,10 @@ func CleanupWatcherState() {
 	defer batchMutex.Unlock()
 	
 	// Clear all state
-	changes = nil
-	lastEventTime = make(map[string]time.Time)
+	resetChangeBuffer()
+	watcherDebouncer.reset()
 	pendingFiles = make(map[string]string)
+	pendingTraces = make(map[string]string)
 	
 	// Stop any running timer
 	if batchTimer != nil {
@@ -325,7 +329,7 @@ func WatchDirectory(ctx context.Context, cfg AppConfig) {
 
 	log.Printf("[WATCHER] Watching directory: %s", cfg.RootDir)
 
-	// Start cleanup goroutine for lastEventTime map
+	// Forget debounced paths once their window has passed
 	go func() {
 		ticker := time.NewTicker(5 * time.Minute)
 		defer ticker.Stop()
@@ -333,11 +337,8 @@ func WatchDirectory(ctx context.Context, cfg AppConfig) {
 		for {
 			select {
 			case <-ticker.C:
-				cleanupOldEventTimes()
-				eventTimeMutex.RLock()
-				mapSize := len(lastEventTime)
-				eventTimeMutex.RUnlock()
-				log.Printf("[WATCHER] Cleaned up old event times, map size: %d", mapSize)
+				remembered := watcherDebouncer.prune(time.Now())
+				log.Printf("[WATCHER] Cleaned up old event times, paths remembered: %d", remembered)
 			case <-ctx.Done():
 				return
 			}
@@ -350,7 +351,7 @@ func WatchDirectory(ctx context.Context, cfg AppConfig) {
 			return err
 		}
 		if info.IsDir() {
-			if isIgnored(path, cfg.IgnorePatterns) {
+			if shouldIgnore(cfg, path) {
 				return filepath.SkipDir
 			}
 			return watcher.Add(path)
@@ -373,7 +374,17 @@ func WatchDirectory(ctx context.Context, cfg AppConfig) {
 					continue
 				}
 
-				if isIgnored(event.Name, cfg.IgnorePatterns) {
+				// Checkouts, merges and rebases are reconciled once they finish
+				if IsGitOperationInProgress() {
+					continue
+				}
+
+				// A changed .gitignore changes what is ignored from now on
+				if filepath.Base(event.Name) == ".gitignore" {
+					forgetGitignore(filepath.Dir(event.Name))
+				}
+
+				if shouldIgnore(cfg, event.Name) {
 					continue
 				}
 
@@ -384,13 +395,32 @@ func WatchDirectory(ctx context.Context, cfg AppConfig) {
 					continue
 				}
 
+				// The scratchpad syncs through its own channel, never through git
+				if relPath == ScratchpadFileName {
+					if event.Op&(fsnotify.Create|fsnotify.Write) != 0 {
+						scratchpadFileChanged(ctx, cfg)
+					}
+					continue
+				}
+
 				if event.Op&fsnotify.Create == fsnotify.Create {
-					if debounceEvent(lastEventTime, event.Name, 500*time.Millisecond) {
+					if watcherDebouncer.accept(relPath, "created", time.Now()) {
+						// Share oversized files as placeholders
+						if queueLargeFilePlaceholder(cfg, event.Name, relPath, "created") {
+							recordSkippedFile(cfg.RootDir, relPath, fmt.Sprintf("over the %d byte limit; shared as a placeholder", maxFileSize))
+							continue
+						}
+						// Send binary files by content hash instead of as patches
+						if queueBinaryChange(cfg, event.Name, relPath, "created") {
+							continue
+						}
 						// Check file size and type before processing
 						if skip, reason := shouldSkipFile(event.Name); skip {
 							log.Printf("[WATCHER] Skipping %s: %s", relPath, reason)
+							recordSkippedFile(cfg.RootDir, relPath, reason)
 							continue
 						}
+						forgetSkippedFile(cfg.RootDir, relPath)
 						addToBatch(cfg, relPath, "created")
 
 						// Check if the created path is a directory. If so, walk it and add all subdirectories to the watcher.
@@ -401,7 +431,7 @@ func WatchDirectory(ctx context.Context, cfg AppConfig) {
 									return err
 								}
 								if fi.IsDir() {
-									if isIgnored(path, cfg.IgnorePatterns) {
+									if shouldIgnore(cfg, path) {
 										return filepath.SkipDir
 									}
 									err = watcher.Add(path)
@@ -415,16 +445,33 @@ func WatchDirectory(ctx context.Context, cfg AppConfig) {
 						}
 					}
 				} else if event.Op&fsnotify.Write == fsnotify.Write {
-					if debounceEvent(lastEventTime, event.Name, 500*time.Millisecond) {
+					if watcherDebouncer.accept(relPath, "modified", time.Now()) {
+						// Sync only the tail of large append-only files,
+						// even when the whole file exceeds the size limit
+						if queueAppendChange(cfg, event.Name, relPath) {
+							continue
+						}
+						// Share oversized files as placeholders
+						if queueLargeFilePlaceholder(cfg, event.Name, relPath, "modified") {
+							recordSkippedFile(cfg.RootDir, relPath, fmt.Sprintf("over the %d byte limit; shared as a placeholder", maxFileSize))
+							continue
+						}
+						// Send binary files by content hash instead of as patches
+						if queueBinaryChange(cfg, event.Name, relPath, "modified") {
+							continue
+						}
 						// Check file size and type before processing
 						if skip, reason := shouldSkipFile(event.Name); skip {
 							log.Printf("[WATCHER] Skipping %s: %s", relPath, reason)
+							recordSkippedFile(cfg.RootDir, relPath, reason)
 							continue
 						}
+						forgetSkippedFile(cfg.RootDir, relPath)
 						addToBatch(cfg, relPath, "modified")
 					}
 				} else if event.Op&fsnotify.Remove == fsnotify.Remove {
-					if debounceEvent(lastEventTime, event.Name, 500*time.Millisecond) {
+					if watcherDebouncer.accept(relPath, "deleted", time.Now()) {
+						forgetSkippedFile(cfg.RootDir, relPath)
 						addToBatch(cfg, relPath, "deleted")
 					}
 				} else if event.Op&fsnotify.Rename == fsnotify.Rename {
@@ -435,6 +482,10 @@ func WatchDirectory(ctx context.Context, cfg AppConfig) {
 						watcher.Add(event.Name)
 					}
 					addToBatch(cfg, relPath, "renamed")
+				} else if event.Op&fsnotify.Chmod == fsnotify.Chmod {
+					if watcherDebouncer.accept(relPath, "attributes", time.Now()) {
+						queueAttributeChange(cfg, event.Name, relPath)
+					}
 				}
 
 			case err, ok := <-watcher.Errors:
@@ -448,6 +499,12 @@ func WatchDirectory(ctx context.Context, cfg AppConfig) {
 		}
 	}()
 
+	// Watch .git for branch switches and other large git operations
+	go watchGitOperations(ctx, cfg)
+
+	// Sync skipped files that fit the limits now
+	ReevaluateSkippedFiles(cfg)
+
 	// Start polling changes
 	go pollChanges(ctx, cfg)
 
@@ -459,34 +516,180 @@ func WatchDirectory(ctx context.Context, cfg AppConfig) {
 func pollChanges(ctx context.Context, cfg AppConfig) {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
+	lastPublish := time.Now()
+	loadOutboxCount(cfg.RootDir)
 	
 	for {
 		select {
 		case <-ticker.C:
+			// Offline, paused or catching up, everything waits in the outbox
+			// until the daemon reconnects, 'axle resume' or the catch-up ends
+			if IsOfflineMode() || IsSyncPaused() || IsCatchingUp() {
+				mu.Lock()
+				spillForOffline(cfg.RootDir)
+				mu.Unlock()
+				continue
+			}
+
+			// Publish less often in low-power mode
+			if IsLowPowerMode() && time.Since(lastPublish) < lowPowerPublishInterval {
+				continue
+			}
+
 			mu.Lock()
-			if len(changes) == 0 {
+			if len(changes) == 0 && spilledChanges == 0 {
 				mu.Unlock()
 				continue
 			}
+			lastPublish = time.Now()
+
+			// Spilled changes are older than the in-memory buffer, so they go
+			// out first, merged into as few publishes as the size limit allows
+			pending := changes
+			spilled, spillPaths := []FileChange(nil), []string(nil)
+			if spilledChanges > 0 {
+				if spilled, spillPaths = takeSpilledChanges(cfg.RootDir); len(spillPaths) > 0 {
+					pending = spilled
+				} else {
+					spilledChanges = 0 // Outbox was emptied or removed outside the daemon
+				}
+			}
+			if len(pending) == 0 {
+				mu.Unlock()
+				continue
+			}
+			queued := len(changes) + spilledChanges
 
 			// Create metadata
+			author := LocalCommitAuthor(cfg.RootDir, cfg.Username)
 			metadata := SyncMetadata{
-				Version:   1,
-				Timestamp: time.Now().Unix(),
-				PeerID:    cfg.Username, // Use username from config
-				Changes:   changes,
+				Version:     ProtocolVersion,
+				Timestamp:   time.Now().Unix(),
+				PeerID:      cfg.Username, // Use username from config
+				AuthorName:  author.Name,
+				AuthorEmail: author.Email,
+				Changes:     pending,
+			}
+
+			// Leave out what the team settings don't sync, such as deletions,
+			// and what is outside this member's sync scope
+			metadata.Changes = FilterSyncScope(cfg, FilterDisabledChanges(cfg, metadata.Changes))
+			// Guests only send what their pass allows, spectators nothing
+			metadata.Changes = FilterGuestChanges(cfg.Username, metadata.Changes)
+			metadata.Changes = FilterSpectatorChanges(cfg.Username, metadata.Changes)
+			if len(metadata.Changes) == 0 {
+				if len(spillPaths) > 0 {
+					removeSpilledChanges(spillPaths, len(pending))
+				} else {
+					resetChangeBuffer()
+				}
+				mu.Unlock()
+				continue
+			}
+
+			// Hold batches touching protected paths until 'axle push-protected --confirm'
+			if protected := ProtectedFiles(metadata.Changes, cfg.ProtectedPaths); len(protected) > 0 {
+				id, err := HoldBatch(cfg.RootDir, HeldOutgoing, metadata)
+				if err != nil {
+					// Neither held nor published; keep the changes and retry next tick
+					log.Printf("[PROTECT] Failed to hold batch touching protected paths, retrying: %v", err)
+					mu.Unlock()
+					continue
+				}
+				log.Printf("[PROTECT] Held batch %s touching protected paths %v; run 'axle push-protected --confirm' to publish", id, protected)
+				if len(spillPaths) > 0 {
+					removeSpilledChanges(spillPaths, len(pending))
+				} else {
+					resetChangeBuffer()
+				}
+				mu.Unlock()
+				continue
+			}
+
+			// Hold batches touching files with unresolved conflict markers
+			// until the markers are gone, so teammates don't receive them
+			if cfg.FeatureEnabled(FeatureConflictsBlock) {
+				if blocked := ConflictBlockedFiles(cfg.RootDir, metadata.Changes); len(blocked) > 0 {
+					id, err := HoldBatch(cfg.RootDir, HeldConflicted, metadata)
+					if err != nil {
+						// Neither held nor published; keep the changes and retry next tick
+						log.Printf("[CONFLICT] Failed to hold batch touching conflicted files, retrying: %v", err)
+						mu.Unlock()
+						continue
+					}
+					log.Printf("[CONFLICT] Held batch %s touching files with unresolved conflicts %v; it is published once they are resolved", id, blocked)
+					if len(spillPaths) > 0 {
+						removeSpilledChanges(spillPaths, len(pending))
+					} else {
+						resetChangeBuffer()
+					}
+					mu.Unlock()
+					continue
+				}
+			}
+
+			// While backing off or coalescing, keep accumulating; everything
+			// queued so far goes out as one merged publish on the next attempt
+			if !publisherReady(queued) {
+				mu.Unlock()
+				continue
+			}
+
+			// Everyone gets the same broadcast, so one slow peer means lean payloads
+			if peers := LowBandwidthPeersOnline(cfg); len(peers) > 0 {
+				metadata = AdaptForLowBandwidth(cfg, metadata)
+			}
+			// Compress the rest for everyone, then serve what is still large
+			// to teammates instead of publishing it
+			metadata = CompressPatches(cfg, metadata)
+			metadata = OfferDirectPatches(cfg, metadata)
+
+			// Stay within the team's sync limits. Over the per-minute budget
+			// the changes wait and merge into a later publish; a batch over
+			// the per-message limit is held so it doesn't block the rest
+			if err := CheckOutgoingBatch(metadata); err != nil {
+				if holdOversizedBatch(cfg, metadata, err) {
+					if len(spillPaths) > 0 {
+						removeSpilledChanges(spillPaths, len(pending))
+					} else {
+						resetChangeBuffer()
+					}
+				}
+				mu.Unlock()
+				continue
 			}
 
 			// Publish metadata to Redis
-			channel := fmt.Sprintf("axle:team:%s", cfg.TeamID)
-			if err := PublishMessage(ctx, cfg.RedisClient, channel, metadata); err != nil {
-				log.Println("Error publishing metadata to Redis:", err)
+			metadata.BatchID = GenerateBatchID()
+			metadata.Seq = NextSequence(ctx, cfg)
+			channel := TeamChannel(cfg.TeamID)
+			publishStart := time.Now()
+			err := PublishMessage(ctx, cfg.Transport, channel, metadata)
+			latency := time.Since(publishStart)
+			if err != nil {
+				// Keep the changes queued so they are merged into the next attempt
+				recordPublishResult(latency, queued, err)
+				status := GetPublisherStatus()
+				log.Printf("[SYNC] Error publishing %d changes to Redis (attempt %d), retrying at %s: %v",
+					len(pending), status.ConsecutiveFailures, time.Unix(status.NextAttempt, 0).Format("15:04:05"), err)
+				mu.Unlock()
+				continue
+			}
+
+			// Clear what was published; anything else stays queued for the next tick
+			if len(spillPaths) > 0 {
+				removeSpilledChanges(spillPaths, len(pending))
+				log.Printf("[OUTBOX] Published %d spilled changes, %d still in the outbox", len(pending), spilledChanges)
+			} else {
+				resetChangeBuffer()
+			}
+			recordPublishResult(latency, len(changes)+spilledChanges, nil)
+			if status := GetPublisherStatus(); status.State == PublisherCoalescing {
+				log.Printf("[SYNC] Published batch with %d changes to team %s (slow Redis: %v, coalescing further publishes)", len(metadata.Changes), cfg.TeamID, latency)
 			} else {
 				log.Printf("[SYNC] Published batch with %d changes to team %s", len(metadata.Changes), cfg.TeamID)
 			}
-
-			// Clear changes after publishing
-			changes = nil
+			Events.Publish(TopicBatchPublished, BatchPublishedEvent{Metadata: metadata})
 			mu.Unlock()
 		case <-ctx.Done():
 			return